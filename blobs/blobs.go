@@ -0,0 +1,21 @@
+// Package blobs stores files, such as archived statements, by key.
+package blobs
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrNotFound is returned when no blob has the key.
+var ErrNotFound = errors.New("blob not found")
+
+// Store keeps blobs under slash separated keys, e.g.
+// "statements/2025-04/1744847261.pdf".
+type Store interface {
+	// Put stores the content read from r under key, replacing any blob with
+	// the same key. A failed Put leaves the previous blob in place.
+	Put(ctx context.Context, key string, r io.Reader) error
+	// Open returns the content of the blob, or ErrNotFound.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+}
@@ -0,0 +1,78 @@
+package blobs_test
+
+import (
+	"accounts-service/blobs"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingReader returns some content, then an error.
+type failingReader struct {
+	read bool
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.read {
+		return 0, errors.New("generator failed")
+	}
+	r.read = true
+	return copy(p, "partial"), nil
+}
+
+func TestFileStore(t *testing.T) {
+	ctx := context.Background()
+	root := filepath.Join(t.TempDir(), "blobs")
+	store, err := blobs.NewFileStore(root)
+	require.NoError(t, err)
+
+	read := func(t *testing.T, key string) string {
+		blob, err := store.Open(ctx, key)
+		require.NoError(t, err)
+		defer blob.Close()
+		content, err := io.ReadAll(blob)
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	t.Run("blobs are stored and replaced", func(t *testing.T) {
+		require.NoError(t, store.Put(ctx, "statements/2025-04/1744847261.pdf", strings.NewReader("first")))
+		assert.Equal(t, "first", read(t, "statements/2025-04/1744847261.pdf"))
+
+		require.NoError(t, store.Put(ctx, "statements/2025-04/1744847261.pdf", strings.NewReader("second")))
+		assert.Equal(t, "second", read(t, "statements/2025-04/1744847261.pdf"))
+		assert.FileExists(t, filepath.Join(root, "statements", "2025-04", "1744847261.pdf"))
+	})
+
+	t.Run("failed put keeps the previous blob", func(t *testing.T) {
+		require.NoError(t, store.Put(ctx, "kept.pdf", strings.NewReader("complete")))
+		assert.Error(t, store.Put(ctx, "kept.pdf", &failingReader{}))
+		assert.Equal(t, "complete", read(t, "kept.pdf"))
+
+		entries, err := os.ReadDir(root)
+		require.NoError(t, err)
+		for _, entry := range entries {
+			assert.False(t, strings.HasPrefix(entry.Name(), "."), "temporary file %s left behind", entry.Name())
+		}
+	})
+
+	t.Run("missing blob", func(t *testing.T) {
+		_, err := store.Open(ctx, "statements/1999-01/1000000000.pdf")
+		assert.ErrorIs(t, err, blobs.ErrNotFound)
+	})
+
+	t.Run("keys cannot leave the root", func(t *testing.T) {
+		for _, key := range []string{"../outside.pdf", "/etc/passwd", "a/../../b", ""} {
+			assert.Error(t, store.Put(ctx, key, strings.NewReader("x")), key)
+			_, err := store.Open(ctx, key)
+			assert.Error(t, err, key)
+		}
+	})
+}
@@ -0,0 +1,186 @@
+package clearing
+
+import (
+	"accounts-service/blobs"
+	"accounts-service/models"
+	"accounts-service/usecases"
+	"accounts-service/utils"
+	"bytes"
+	"context"
+	"io"
+	"time"
+)
+
+// batchReferencePrefix starts the reference of a batch, followed by the
+// date and time of its cut-off.
+const batchReferencePrefix = "KLR"
+
+// ClearerOptions describe the clearing windows.
+type ClearerOptions struct {
+	// BankCode is the clearing code of this bank, written in batch files.
+	BankCode string
+	// Cutoffs are the cut-off times of a day, as offsets from midnight in
+	// models.StatementZone, in increasing order.
+	Cutoffs []time.Duration
+	// Interval is the wait between checks for a cut-off passed.
+	Interval time.Duration
+}
+
+// ImportResult counts the records of a settlement file. Skipped counts the
+// transfers already completed the same way, by an earlier import.
+type ImportResult struct {
+	Settled  int
+	Rejected int
+	Skipped  int
+	Failed   int
+}
+
+// Clearer writes the transfers queued before each cut-off to a batch file
+// in the blob store, and imports the settlement files of the clearing
+// house. A window is cut once, whatever the number of instances running.
+type Clearer struct {
+	interbankUsecase usecases.InterbankUsecase
+	store            blobs.Store
+	options          ClearerOptions
+	logger           utils.Logger
+}
+
+func NewClearer(interbankUsecase usecases.InterbankUsecase, store blobs.Store, options ClearerOptions, logger utils.Logger) *Clearer {
+	return &Clearer{
+		interbankUsecase: interbankUsecase,
+		store:            store,
+		options:          options,
+		logger:           logger,
+	}
+}
+
+// LastCutoff returns the last of cutoffs passed at now, on the day of now
+// or the day before.
+func LastCutoff(now time.Time, cutoffs []time.Duration) time.Time {
+	now = now.In(models.StatementZone)
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, models.StatementZone)
+	for i := len(cutoffs) - 1; i >= 0; i-- {
+		if cutoffAt := midnight.Add(cutoffs[i]); !cutoffAt.After(now) {
+			return cutoffAt
+		}
+	}
+	return midnight.AddDate(0, 0, -1).Add(cutoffs[len(cutoffs)-1])
+}
+
+// BatchReference returns the reference of the batch of the window ending
+// at cutoffAt, e.g. "KLR202504280900".
+func BatchReference(cutoffAt time.Time) string {
+	return batchReferencePrefix + cutoffAt.In(models.StatementZone).Format("200601021504")
+}
+
+// BlobKey returns the key of the file of a batch, e.g.
+// "clearing/2025-04-28/KLR202504280900.txt".
+func BlobKey(cutoffAt time.Time) string {
+	return "clearing/" + cutoffAt.In(models.StatementZone).Format("2006-01-02") + "/" + BatchReference(cutoffAt) + ".txt"
+}
+
+// Run cuts the last window passed until ctx is done. A window that failed,
+// or whose file was not written, is cut again on the next check.
+func (c *Clearer) Run(ctx context.Context) {
+	c.logger.Info("Interbank clearer started")
+
+	ticker := time.NewTicker(c.options.Interval)
+	defer ticker.Stop()
+
+	var cut time.Time
+	for {
+		cutoffAt := LastCutoff(time.Now(), c.options.Cutoffs)
+		if !cutoffAt.Equal(cut) {
+			if _, err := c.Cut(ctx, cutoffAt); err != nil {
+				if ctx.Err() == nil {
+					c.logger.Error("Error cutting interbank batch %s: %v", BatchReference(cutoffAt), err)
+				}
+			} else {
+				cut = cutoffAt
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			c.logger.Info("Interbank clearer stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Cut writes the files of the batches cut earlier whose file was not
+// written, then cuts and writes the batch of the window ending at cutoffAt.
+// It returns nil when the window was already cut or nothing is queued.
+func (c *Clearer) Cut(ctx context.Context, cutoffAt time.Time) (*models.InterbankBatch, error) {
+	written, err := c.interbankUsecase.WritePendingBatches(ctx, c.write)
+	if written > 0 {
+		c.logger.Info("Wrote %d pending interbank batches", written)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	batch := &models.InterbankBatch{
+		Reference: BatchReference(cutoffAt),
+		CutoffAt:  cutoffAt,
+		BlobKey:   BlobKey(cutoffAt),
+	}
+	cut, err := c.interbankUsecase.CutBatch(ctx, batch, c.write)
+	if err != nil || !cut {
+		return nil, err
+	}
+
+	c.logger.Info("Cut interbank batch %s: %d transfers, %.2f total", batch.Reference, batch.TransferCount, batch.TotalNominal)
+	return batch, nil
+}
+
+// write puts the file of batch in the blob store, replacing the one of an
+// earlier attempt.
+func (c *Clearer) write(ctx context.Context, batch *models.InterbankBatch) error {
+	var file bytes.Buffer
+	if err := WriteBatch(&file, batch, c.options.BankCode); err != nil {
+		return err
+	}
+	return c.store.Put(ctx, batch.BlobKey, &file)
+}
+
+// Import settles or rejects the transfers of a settlement file, each in its
+// own transaction. Only the transfers sent in the batch the file names are
+// completed. A record that fails is logged and counted, the others
+// are still imported, so the file can be imported again once fixed.
+func (c *Clearer) Import(ctx context.Context, r io.Reader) (ImportResult, error) {
+	var result ImportResult
+
+	settlement, err := ReadSettlement(r)
+	if err != nil {
+		return result, err
+	}
+
+	for _, record := range settlement.Records {
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+
+		var completed bool
+		if record.Settled {
+			completed, err = c.interbankUsecase.Settle(ctx, settlement.BatchReference, record.TransferID, record.Nominal)
+		} else {
+			completed, err = c.interbankUsecase.Reject(ctx, settlement.BatchReference, record.TransferID, record.Nominal, record.ReturnReason)
+		}
+		switch {
+		case err != nil:
+			c.logger.Error("Error importing settlement of %s on line %d: %v", record.TransferID, record.Line, err)
+			result.Failed++
+		case !completed:
+			result.Skipped++
+		case record.Settled:
+			result.Settled++
+		default:
+			result.Rejected++
+		}
+	}
+
+	c.logger.Info("Imported settlement of %s: %d settled, %d rejected, %d skipped, %d failed", settlement.BatchReference, result.Settled, result.Rejected, result.Skipped, result.Failed)
+	return result, nil
+}
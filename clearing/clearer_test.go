@@ -0,0 +1,122 @@
+package clearing_test
+
+import (
+	"accounts-service/blobs"
+	"accounts-service/clearing"
+	"accounts-service/models"
+	"accounts-service/repositories"
+	"accounts-service/usecases"
+	"accounts-service/utils"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyStore fails the first puts, as a full disk would.
+type flakyStore struct {
+	blobs.Store
+	failures int
+}
+
+func (s *flakyStore) Put(ctx context.Context, key string, r io.Reader) error {
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("disk full")
+	}
+	return s.Store.Put(ctx, key, r)
+}
+
+func TestClearer(t *testing.T) {
+	ctx := context.Background()
+	logger := utils.NewLogger("critical")
+	store := repositories.NewMemoryStore()
+	txManager := repositories.NewMemoryTxManager(store, logger)
+	accountRepo := repositories.NewMemoryAccountRepository(store, logger)
+	accountUsecase := usecases.NewAccountUsecase(txManager, accountRepo, repositories.NewMemoryMutationRepository(store, logger), repositories.NewMemoryOutboxRepository(store, logger), repositories.NewNoopBalanceCache(), logger)
+
+	require.NoError(t, accountRepo.CreateAccount(ctx, &models.Account{Name: "Kliring Keluar", NIK: "3201010101700001", NoHP: "+6281200000001", NoRekening: "9000000001"}))
+	require.NoError(t, accountRepo.CreateAccount(ctx, &models.Account{Name: "Siti Aminah", NIK: "3201014508950001", NoHP: "+6281234567890", NoRekening: "1744847261"}))
+	require.NoError(t, accountUsecase.Credit(ctx, &models.TransactionRequest{NoRekening: "1744847261", Nominal: 1000000}))
+
+	interbankUsecase := usecases.NewInterbankUsecase(txManager, accountRepo, repositories.NewMemoryInterbankRepository(store, logger), accountUsecase, usecases.InterbankOptions{
+		BankCode:           "484",
+		SuspenseNoRekening: "9000000001",
+	}, logger)
+	blobStore, err := blobs.NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	clearer := clearing.NewClearer(interbankUsecase, &flakyStore{Store: blobStore, failures: 1}, clearing.ClearerOptions{BankCode: "484"}, logger)
+
+	var transfers []*models.InterbankTransfer
+	for _, reference := range []string{"INV-1", "INV-2"} {
+		transfer, err := interbankUsecase.Order(ctx, &models.InterbankTransferRequest{
+			NoRekening:         "1744847261",
+			BankCode:           "014",
+			BeneficiaryAccount: "1234567890",
+			BeneficiaryName:    "BUDI SANTOSO",
+			Nominal:            100000,
+			Reference:          reference,
+		})
+		require.NoError(t, err)
+		transfers = append(transfers, transfer)
+	}
+
+	cutoffAt := time.Now().Add(time.Minute)
+	_, err = clearer.Cut(ctx, cutoffAt)
+	require.Error(t, err)
+	_, err = blobStore.Open(ctx, clearing.BlobKey(cutoffAt))
+	require.ErrorIs(t, err, blobs.ErrNotFound)
+
+	batch, err := clearer.Cut(ctx, cutoffAt)
+	require.NoError(t, err)
+	assert.Nil(t, batch, "the window is already cut, only its file is written again")
+
+	blob, err := blobStore.Open(ctx, clearing.BlobKey(cutoffAt))
+	require.NoError(t, err)
+	file, err := io.ReadAll(blob)
+	blob.Close()
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSuffix(string(file), "\n"), "\n")
+	require.Len(t, lines, 4)
+	assert.True(t, strings.HasPrefix(lines[1], "D000001"+transfers[0].TransferID))
+	assert.Equal(t, "T000002000000000020000000", lines[3])
+
+	batch, err = clearer.Cut(ctx, cutoffAt)
+	require.NoError(t, err)
+	assert.Nil(t, batch, "the window is already cut")
+
+	settlement := "H" + clearing.BatchReference(cutoffAt) + cutoffAt.In(models.StatementZone).Format("20060102") + "\n" +
+		"D" + transfers[0].TransferID + "S    000000010000000\n" +
+		"D" + transfers[1].TransferID + "RR03 000000010000000\n" +
+		"D" + models.InterbankTransferID(99) + "S    000000010000000\n" +
+		"T000003\n"
+
+	otherBatch := "H" + clearing.BatchReference(cutoffAt.Add(-time.Hour)) + cutoffAt.In(models.StatementZone).Format("20060102") + "\n" +
+		"D" + transfers[0].TransferID + "S    000000010000000\n" +
+		"T000001\n"
+	result, err := clearer.Import(ctx, strings.NewReader(otherBatch))
+	require.NoError(t, err)
+	assert.Equal(t, clearing.ImportResult{Failed: 1}, result, "the transfer was sent in another batch")
+
+	result, err = clearer.Import(ctx, strings.NewReader(settlement))
+	require.NoError(t, err)
+	assert.Equal(t, clearing.ImportResult{Settled: 1, Rejected: 1, Failed: 1}, result)
+
+	result, err = clearer.Import(ctx, strings.NewReader(settlement))
+	require.NoError(t, err)
+	assert.Equal(t, clearing.ImportResult{Skipped: 2, Failed: 1}, result, "a file imported again")
+
+	for noRekening, expected := range map[string]float64{"1744847261": 900000, "9000000001": 0} {
+		saldo, err := accountUsecase.GetSaldo(ctx, noRekening)
+		require.NoError(t, err)
+		assert.Equal(t, expected, saldo.Saldo, noRekening)
+	}
+
+	_, err = clearer.Import(ctx, strings.NewReader("H\n"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,215 @@
+package clearing
+
+import (
+	"accounts-service/models"
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Record types of clearing files. Every file is a header, detail records
+// and a trailer, one fixed-width record per line.
+const (
+	recordHeader  = 'H'
+	recordDetail  = 'D'
+	recordTrailer = 'T'
+)
+
+// Outcomes of a settlement detail record.
+const (
+	outcomeSettled  = 'S'
+	outcomeRejected = 'R'
+)
+
+// Widths of the fields of a batch file. The header is the batch reference,
+// the date and time of the cut-off and the bank code of the sender. A
+// detail record is the sequence, transfer ID, bank code, beneficiary
+// account and name, nominal in sen, source no_rekening and description.
+// The trailer is the count of details and their total in sen.
+const (
+	referenceWidth   = 15
+	sequenceWidth    = 6
+	transferIDWidth  = 16
+	bankCodeWidth    = 3
+	accountWidth     = 34
+	nameWidth        = 35
+	amountWidth      = 15
+	descriptionWidth = 35
+	totalWidth       = 18
+	reasonWidth      = 4
+)
+
+// Lengths of the records of a settlement file. The header is the batch
+// reference and the settlement date, a detail record the transfer ID, the
+// outcome, the return reason and the nominal in sen, the trailer the count
+// of details.
+const (
+	settlementHeaderLength  = 1 + referenceWidth + 8
+	settlementDetailLength  = 1 + transferIDWidth + 1 + reasonWidth + amountWidth
+	settlementTrailerLength = 1 + sequenceWidth
+)
+
+// SettlementRecord is the outcome of one transfer in a settlement file.
+// ReturnReason is only set on a rejection.
+type SettlementRecord struct {
+	Line         int
+	TransferID   string
+	Settled      bool
+	ReturnReason string
+	Nominal      float64
+}
+
+// Settlement is a settlement file read.
+type Settlement struct {
+	BatchReference string
+	Date           time.Time
+	Records        []SettlementRecord
+}
+
+// WriteBatch writes the batch file of batch, sent by the bank with
+// bankCode.
+func WriteBatch(w io.Writer, batch *models.InterbankBatch, bankCode string) error {
+	bw := bufio.NewWriter(w)
+	cutoffAt := batch.CutoffAt.In(models.StatementZone)
+
+	fmt.Fprintf(bw, "%c%s%s%s\n", recordHeader, text(batch.Reference, referenceWidth), cutoffAt.Format("200601021504"), text(bankCode, bankCodeWidth))
+	var total int64
+	for i, transfer := range batch.Transfers {
+		total += sen(transfer.Nominal)
+		fmt.Fprintf(bw, "%c%s%s%s%s%s%s%s%s\n",
+			recordDetail,
+			number(int64(i+1), sequenceWidth),
+			text(transfer.TransferID, transferIDWidth),
+			text(transfer.BankCode, bankCodeWidth),
+			text(transfer.BeneficiaryAccount, accountWidth),
+			text(transfer.BeneficiaryName, nameWidth),
+			number(sen(transfer.Nominal), amountWidth),
+			text(transfer.SourceNoRekening, accountWidth),
+			text(transfer.Description, descriptionWidth),
+		)
+	}
+	fmt.Fprintf(bw, "%c%s%s\n", recordTrailer, number(int64(len(batch.Transfers)), sequenceWidth), number(total, totalWidth))
+
+	return bw.Flush()
+}
+
+// ReadSettlement reads a settlement file. Blank lines are skipped. A file
+// that is not well formed, or whose trailer does not count its details, is
+// rejected whole.
+func ReadSettlement(r io.Reader) (*Settlement, error) {
+	settlement := &Settlement{}
+	scanner := bufio.NewScanner(r)
+	line, header, trailer := 0, false, false
+
+	for scanner.Scan() {
+		line++
+		record := strings.TrimRight(scanner.Text(), "\r")
+		if record == "" {
+			continue
+		}
+		if trailer {
+			return nil, fmt.Errorf("line %d: record after the trailer", line)
+		}
+
+		switch {
+		case !header:
+			if len(record) != settlementHeaderLength || record[0] != recordHeader {
+				return nil, fmt.Errorf("line %d: expected a header of %d characters", line, settlementHeaderLength)
+			}
+			date, err := time.ParseInLocation("20060102", record[1+referenceWidth:], models.StatementZone)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid settlement date: %w", line, err)
+			}
+			settlement.BatchReference = strings.TrimSpace(record[1 : 1+referenceWidth])
+			settlement.Date = date
+			header = true
+
+		case record[0] == recordDetail:
+			detail, err := readDetail(record)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			detail.Line = line
+			settlement.Records = append(settlement.Records, detail)
+
+		case record[0] == recordTrailer:
+			if len(record) != settlementTrailerLength {
+				return nil, fmt.Errorf("line %d: expected a trailer of %d characters", line, settlementTrailerLength)
+			}
+			count, err := strconv.Atoi(record[1:])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid count: %w", line, err)
+			}
+			if count != len(settlement.Records) {
+				return nil, fmt.Errorf("line %d: trailer counts %d records, the file has %d", line, count, len(settlement.Records))
+			}
+			trailer = true
+
+		default:
+			return nil, fmt.Errorf("line %d: unknown record type %q", line, record[0])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !header {
+		return nil, fmt.Errorf("empty settlement file")
+	}
+	if !trailer {
+		return nil, fmt.Errorf("missing trailer")
+	}
+
+	return settlement, nil
+}
+
+// readDetail reads a settlement detail record.
+func readDetail(record string) (SettlementRecord, error) {
+	var detail SettlementRecord
+	if len(record) != settlementDetailLength {
+		return detail, fmt.Errorf("expected a detail record of %d characters", settlementDetailLength)
+	}
+
+	fields := record[1:]
+	detail.TransferID = strings.TrimSpace(fields[:transferIDWidth])
+	fields = fields[transferIDWidth:]
+
+	outcome := fields[0]
+	detail.ReturnReason = strings.TrimSpace(fields[1 : 1+reasonWidth])
+	switch {
+	case outcome == outcomeSettled && detail.ReturnReason == "":
+		detail.Settled = true
+	case outcome == outcomeRejected && detail.ReturnReason != "":
+	default:
+		return detail, fmt.Errorf("invalid outcome %q with return reason %q", outcome, detail.ReturnReason)
+	}
+
+	amount, err := strconv.ParseInt(fields[1+reasonWidth:], 10, 64)
+	if err != nil || amount <= 0 {
+		return detail, fmt.Errorf("invalid amount %q", fields[1+reasonWidth:])
+	}
+	detail.Nominal = float64(amount) / 100
+
+	return detail, nil
+}
+
+// text pads s with spaces, or cuts it, to width.
+func text(s string, width int) string {
+	if len(s) > width {
+		return s[:width]
+	}
+	return s + strings.Repeat(" ", width-len(s))
+}
+
+// number zero-pads n to width.
+func number(n int64, width int) string {
+	return fmt.Sprintf("%0*d", width, n)
+}
+
+// sen returns nominal in sen.
+func sen(nominal float64) int64 {
+	return int64(math.Round(nominal * 100))
+}
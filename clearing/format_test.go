@@ -0,0 +1,107 @@
+package clearing_test
+
+import (
+	"accounts-service/clearing"
+	"accounts-service/models"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteBatch(t *testing.T) {
+	batch := &models.InterbankBatch{
+		Reference: "KLR202504280900",
+		CutoffAt:  time.Date(2025, 4, 28, 2, 0, 0, 0, time.UTC),
+		Transfers: []models.InterbankTransfer{
+			{TransferID: "IBT0000000000001", BankCode: "014", BeneficiaryAccount: "1234567890", BeneficiaryName: "BUDI SANTOSO", Nominal: 1500000.5, SourceNoRekening: "1744847261", Description: "SEWA APRIL"},
+			{TransferID: "IBT0000000000002", BankCode: "008", BeneficiaryAccount: "ID12BANK0001", BeneficiaryName: "PT MAJU JAYA", Nominal: 25000, SourceNoRekening: "1744847262"},
+		},
+	}
+
+	var file bytes.Buffer
+	require.NoError(t, clearing.WriteBatch(&file, batch, "484"))
+
+	lines := strings.Split(strings.TrimSuffix(file.String(), "\n"), "\n")
+	require.Len(t, lines, 4)
+	assert.Equal(t, "HKLR202504280900202504280900484", lines[0], "cut-off in WIB")
+	assert.Equal(t, "D000001IBT0000000000001014"+
+		pad("1234567890", 34)+pad("BUDI SANTOSO", 35)+"000000150000050"+pad("1744847261", 34)+pad("SEWA APRIL", 35), lines[1])
+	assert.Equal(t, "D000002IBT0000000000002008"+
+		pad("ID12BANK0001", 34)+pad("PT MAJU JAYA", 35)+"000000002500000"+pad("1744847262", 34)+pad("", 35), lines[2])
+	assert.Equal(t, "T000002000000000152500050", lines[3])
+}
+
+func TestReadSettlement(t *testing.T) {
+	t.Run("reads settled and rejected transfers", func(t *testing.T) {
+		file := "HKLR20250428090020250428\r\n" +
+			"DIBT0000000000001S    000000150000050\r\n" +
+			"DIBT0000000000002RR03 000000002500000\r\n" +
+			"T000002\r\n"
+
+		settlement, err := clearing.ReadSettlement(strings.NewReader(file))
+		require.NoError(t, err)
+		assert.Equal(t, "KLR202504280900", settlement.BatchReference)
+		assert.Equal(t, "2025-04-28", settlement.Date.Format("2006-01-02"))
+		assert.Equal(t, []clearing.SettlementRecord{
+			{Line: 2, TransferID: "IBT0000000000001", Settled: true, Nominal: 1500000.5},
+			{Line: 3, TransferID: "IBT0000000000002", ReturnReason: "R03", Nominal: 25000},
+		}, settlement.Records)
+	})
+
+	t.Run("skips blank lines before the header", func(t *testing.T) {
+		file := "\r\n\nHKLR20250428090020250428\nDIBT0000000000001S    000000150000050\nT000001\n"
+
+		settlement, err := clearing.ReadSettlement(strings.NewReader(file))
+		require.NoError(t, err)
+		assert.Equal(t, "KLR202504280900", settlement.BatchReference)
+		require.Len(t, settlement.Records, 1)
+		assert.Equal(t, 4, settlement.Records[0].Line)
+	})
+
+	for name, file := range map[string]string{
+		"empty":              "",
+		"blank":              "\n\n",
+		"blank then detail":  "\nDIBT0000000000001S    000000150000050\nT000001\n",
+		"no header":          "DIBT0000000000001S    000000150000050\nT000001\n",
+		"short detail":       "HKLR20250428090020250428\nDIBT0000000000001S    15000\nT000001\n",
+		"rejected no reason": "HKLR20250428090020250428\nDIBT0000000000001R    000000150000050\nT000001\n",
+		"settled reason":     "HKLR20250428090020250428\nDIBT0000000000001SR03 000000150000050\nT000001\n",
+		"zero amount":        "HKLR20250428090020250428\nDIBT0000000000001S    000000000000000\nT000001\n",
+		"count mismatch":     "HKLR20250428090020250428\nDIBT0000000000001S    000000150000050\nT000002\n",
+		"no trailer":         "HKLR20250428090020250428\nDIBT0000000000001S    000000150000050\n",
+		"after trailer":      "HKLR20250428090020250428\nT000000\nDIBT0000000000001S    000000150000050\n",
+		"unknown record":     "HKLR20250428090020250428\nX\nT000000\n",
+	} {
+		t.Run("rejects "+name, func(t *testing.T) {
+			_, err := clearing.ReadSettlement(strings.NewReader(file))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLastCutoff(t *testing.T) {
+	cutoffs := []time.Duration{9 * time.Hour, 13 * time.Hour, 16 * time.Hour}
+	wib := models.StatementZone
+
+	for now, expected := range map[time.Time]time.Time{
+		time.Date(2025, 4, 28, 10, 30, 0, 0, wib):    time.Date(2025, 4, 28, 9, 0, 0, 0, wib),
+		time.Date(2025, 4, 28, 13, 0, 0, 0, wib):     time.Date(2025, 4, 28, 13, 0, 0, 0, wib),
+		time.Date(2025, 4, 28, 23, 0, 0, 0, wib):     time.Date(2025, 4, 28, 16, 0, 0, 0, wib),
+		time.Date(2025, 4, 28, 8, 59, 0, 0, wib):     time.Date(2025, 4, 27, 16, 0, 0, 0, wib),
+		time.Date(2025, 4, 28, 1, 0, 0, 0, time.UTC): time.Date(2025, 4, 27, 16, 0, 0, 0, wib),
+	} {
+		assert.True(t, expected.Equal(clearing.LastCutoff(now, cutoffs)), now)
+	}
+
+	cutoffAt := time.Date(2025, 4, 28, 2, 0, 0, 0, time.UTC)
+	assert.Equal(t, "KLR202504280900", clearing.BatchReference(cutoffAt))
+	assert.Equal(t, "clearing/2025-04-28/KLR202504280900.txt", clearing.BlobKey(cutoffAt))
+}
+
+func pad(s string, width int) string {
+	return s + strings.Repeat(" ", width-len(s))
+}
@@ -1,57 +1,700 @@
 package config
 
 import (
+	"accounts-service/utils"
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
-	"github.com/joho/godotenv"
-	"github.com/kelseyhightower/envconfig"
 	_ "github.com/lib/pq" // PostgreSQL driver
+	"github.com/redis/go-redis/v9"
+	"github.com/sethvargo/go-envconfig"
+	_ "modernc.org/sqlite" // SQLite driver
+)
+
+const (
+	StoragePostgres = "postgres"
+	StorageMemory   = "memory"
+	StorageSQLite   = "sqlite"
+)
+
+const (
+	OutboxPublisherNone   = "none"
+	OutboxPublisherStdout = "stdout"
+	OutboxPublisherFile   = "file"
+	OutboxPublisherHTTP   = "http"
+)
+
+const (
+	NotificationSinkStdout = "stdout"
+	NotificationSinkFile   = "file"
+)
+
+const (
+	BlobStoreFile = "file"
+)
+
+const (
+	BulkCreditModeAllOrNothing = "all_or_nothing"
+	BulkCreditModePerRow       = "per_row"
+)
+
+const (
+	BalanceCacheNone   = "none"
+	BalanceCacheMemory = "memory"
+	BalanceCacheRedis  = "redis"
 )
 
 type Config struct {
-	AppPort    string `envconfig:"APP_PORT" default:"8080"`
-	DBHost     string `envconfig:"DB_HOST" default:"localhost"`
-	DBPort     string `envconfig:"DB_PORT" default:"5432"`
-	DBUser     string `envconfig:"DB_USER" default:"postgres"`
-	DBPassword string `envconfig:"DB_PASSWORD" default:"postgres"`
-	DBName     string `envconfig:"DB_NAME" default:"accounts_db"`
-	DBSSLMode  string `envconfig:"DB_SSLMODE" default:"disable"`
-	LogLevel   string `envconfig:"LOG_LEVEL" default:"info"`
+	AppPort    string `env:"APP_PORT, default=8080"`
+	DBHost     string `env:"DB_HOST, default=localhost"`
+	DBPort     string `env:"DB_PORT, default=5432"`
+	DBUser     string `env:"DB_USER, default=postgres"`
+	DBPassword string `env:"DB_PASSWORD, default=postgres" secret:"true"`
+	DBName     string `env:"DB_NAME, default=accounts_db"`
+	DBSSLMode  string `env:"DB_SSLMODE, default=disable"`
+	LogLevel   string `env:"LOG_LEVEL, default=info"`
+
+	// Storage selects the repository backend: postgres, sqlite or memory.
+	Storage string `env:"STORAGE, default=postgres"`
+
+	// SQLitePath is the database file of the sqlite storage.
+	SQLitePath string `env:"SQLITE_PATH, default=accounts.db"`
+
+	// GRPCEnabled serves the accounts.v1 gRPC API on GRPCPort next to the
+	// REST API on AppPort.
+	GRPCEnabled bool   `env:"GRPC_ENABLED, default=false"`
+	GRPCPort    string `env:"GRPC_PORT, default=9090"`
+
+	// ISO8583Enabled serves ATM and EDC switches ISO 8583 messages over TCP
+	// on ISO8583Port. Connections sending nothing for ISO8583IdleTimeout
+	// are closed, 0 keeps them open.
+	ISO8583Enabled     bool          `env:"ISO8583_ENABLED, default=false"`
+	ISO8583Port        string        `env:"ISO8583_PORT, default=8583"`
+	ISO8583IdleTimeout time.Duration `env:"ISO8583_IDLE_TIMEOUT, default=5m"`
+
+	// SNAPEnabled serves the SNAP BI API under /snap to the partners of
+	// SNAPPartnersFile. Its access tokens are signed with SNAPTokenSecret and
+	// live for SNAPTokenTTL. X-TIMESTAMP may be SNAPTimestampSkew off.
+	SNAPEnabled       bool          `env:"SNAP_ENABLED, default=false"`
+	SNAPPartnersFile  string        `env:"SNAP_PARTNERS_FILE, default=snap_partners.json"`
+	SNAPTokenSecret   string        `env:"SNAP_TOKEN_SECRET" secret:"true"`
+	SNAPTokenTTL      time.Duration `env:"SNAP_TOKEN_TTL, default=15m"`
+	SNAPTimestampSkew time.Duration `env:"SNAP_TIMESTAMP_SKEW, default=5m"`
+
+	// AutoMigrate applies pending migrations before the server starts.
+	AutoMigrate bool `env:"AUTO_MIGRATE, default=false"`
+
+	// Connection pool settings, 0 means unlimited.
+	DBMaxOpenConns    int           `env:"DB_MAX_OPEN_CONNS, default=25"`
+	DBMaxIdleConns    int           `env:"DB_MAX_IDLE_CONNS, default=25"`
+	DBConnMaxLifetime time.Duration `env:"DB_CONN_MAX_LIFETIME, default=5m"`
+	DBConnMaxIdleTime time.Duration `env:"DB_CONN_MAX_IDLE_TIME, default=0"`
+
+	// DBStatementTimeout is sent to Postgres as statement_timeout, 0 disables it.
+	DBStatementTimeout time.Duration `env:"DB_STATEMENT_TIMEOUT, default=30s"`
+
+	// DBQueryTimeout is the deadline for the queries of one request, 0 disables it.
+	DBQueryTimeout time.Duration `env:"DB_QUERY_TIMEOUT, default=10s"`
+
+	// Startup retry, waiting DBConnectBackoff after the first failed ping and
+	// doubling up to DBConnectMaxBackoff.
+	DBConnectRetries    int           `env:"DB_CONNECT_RETRIES, default=5"`
+	DBConnectBackoff    time.Duration `env:"DB_CONNECT_BACKOFF, default=1s"`
+	DBConnectMaxBackoff time.Duration `env:"DB_CONNECT_MAX_BACKOFF, default=30s"`
+
+	// Optional Postgres read replica for balance and lookup queries. Reads go
+	// to the primary while the replica lags more than DBReplicaMaxLag, or has
+	// not heard from the primary for DBReplicaMaxReceiptAge, checked at most
+	// once per DBReplicaLagCheckInterval. The primary sends keepalives every
+	// wal_sender_timeout/2 while idle, so the receipt age must exceed that.
+	DBReplicaDSN              string        `env:"DB_REPLICA_DSN" secret:"true"`
+	DBReplicaMaxLag           time.Duration `env:"DB_REPLICA_MAX_LAG, default=5s"`
+	DBReplicaMaxReceiptAge    time.Duration `env:"DB_REPLICA_MAX_RECEIPT_AGE, default=1m"`
+	DBReplicaLagCheckInterval time.Duration `env:"DB_REPLICA_LAG_CHECK_INTERVAL, default=1s"`
+
+	// Isolation level of posting transactions and how many times a
+	// serialization failure is retried.
+	DBTxIsolation  string `env:"DB_TX_ISOLATION, default=read_committed"`
+	DBTxMaxRetries int    `env:"DB_TX_MAX_RETRIES, default=3"`
+
+	// Balance cache in front of GetSaldo: none, memory (in-process, single
+	// instance only) or redis. Entries live for BalanceCacheTTL.
+	BalanceCache     string        `env:"BALANCE_CACHE, default=none"`
+	BalanceCacheSize int           `env:"BALANCE_CACHE_SIZE, default=10000"`
+	BalanceCacheTTL  time.Duration `env:"BALANCE_CACHE_TTL, default=30s"`
+
+	// Redis server of the redis balance cache.
+	RedisAddr      string `env:"REDIS_ADDR, default=localhost:6379"`
+	RedisPassword  string `env:"REDIS_PASSWORD" secret:"true"`
+	RedisDB        int    `env:"REDIS_DB, default=0"`
+	RedisKeyPrefix string `env:"REDIS_KEY_PREFIX, default=accounts:saldo:"`
+
+	// Publisher of the outbox relay: none, stdout, file or http. With none
+	// the relay does not run and events stay pending in the outbox.
+	OutboxPublisher   string        `env:"OUTBOX_PUBLISHER, default=none"`
+	OutboxFilePath    string        `env:"OUTBOX_FILE_PATH, default=events.jsonl"`
+	OutboxHTTPURL     string        `env:"OUTBOX_HTTP_URL"`
+	OutboxHTTPTimeout time.Duration `env:"OUTBOX_HTTP_TIMEOUT, default=5s"`
+
+	// Relay polling and retries. A delivery is retried after
+	// OutboxRetryBackoff, doubling up to OutboxRetryMaxBackoff, and the event
+	// is dead-lettered after OutboxMaxAttempts.
+	OutboxPollInterval    time.Duration `env:"OUTBOX_POLL_INTERVAL, default=1s"`
+	OutboxBatchSize       int           `env:"OUTBOX_BATCH_SIZE, default=100"`
+	OutboxMaxAttempts     int           `env:"OUTBOX_MAX_ATTEMPTS, default=10"`
+	OutboxRetryBackoff    time.Duration `env:"OUTBOX_RETRY_BACKOFF, default=1s"`
+	OutboxRetryMaxBackoff time.Duration `env:"OUTBOX_RETRY_MAX_BACKOFF, default=5m"`
+
+	// AdminToken is the bearer token of the /api/admin endpoints, which are
+	// disabled while it is empty.
+	AdminToken string `env:"ADMIN_TOKEN" secret:"true"`
+
+	// Webhook deliveries. A failed POST is retried after WebhookRetryBackoff,
+	// doubling up to WebhookRetryMaxBackoff, and the delivery fails after
+	// WebhookMaxAttempts.
+	WebhooksEnabled        bool          `env:"WEBHOOKS_ENABLED, default=false"`
+	WebhookTimeout         time.Duration `env:"WEBHOOK_TIMEOUT, default=10s"`
+	WebhookPollInterval    time.Duration `env:"WEBHOOK_POLL_INTERVAL, default=1s"`
+	WebhookBatchSize       int           `env:"WEBHOOK_BATCH_SIZE, default=50"`
+	WebhookMaxAttempts     int           `env:"WEBHOOK_MAX_ATTEMPTS, default=8"`
+	WebhookRetryBackoff    time.Duration `env:"WEBHOOK_RETRY_BACKOFF, default=10s"`
+	WebhookRetryMaxBackoff time.Duration `env:"WEBHOOK_RETRY_MAX_BACKOFF, default=1h"`
+
+	// Customer notifications, sent by the outbox relay. SMS and WhatsApp go
+	// to NotificationSink (stdout or file) until a provider is wired in, and
+	// email goes to the SMTP server, or to the sink while SMTPHost is empty.
+	NotificationsEnabled    bool    `env:"NOTIFICATIONS_ENABLED, default=false"`
+	NotificationSink        string  `env:"NOTIFICATION_SINK, default=stdout"`
+	NotificationFilePath    string  `env:"NOTIFICATION_FILE_PATH, default=notifications.jsonl"`
+	NotificationLargeCredit float64 `env:"NOTIFICATION_LARGE_CREDIT, default=10000000"`
+
+	SMTPHost     string        `env:"SMTP_HOST"`
+	SMTPPort     int           `env:"SMTP_PORT, default=587"`
+	SMTPUsername string        `env:"SMTP_USERNAME"`
+	SMTPPassword string        `env:"SMTP_PASSWORD" secret:"true"`
+	SMTPFrom     string        `env:"SMTP_FROM"`
+	SMTPTimeout  time.Duration `env:"SMTP_TIMEOUT, default=10s"`
+
+	// Bank header printed on PDF statements.
+	StatementBankName    string `env:"STATEMENT_BANK_NAME, default=Accounts Service"`
+	StatementBankAddress string `env:"STATEMENT_BANK_ADDRESS"`
+
+	// StatementTimeout is the deadline for streaming one rekening koran in
+	// place of DBQueryTimeout, 0 disables it.
+	StatementTimeout time.Duration `env:"STATEMENT_TIMEOUT, default=5m"`
+
+	// BlobStore keeps archived files, only file (the local filesystem under
+	// BlobStorePath) for now.
+	BlobStore     string `env:"BLOB_STORE, default=file"`
+	BlobStorePath string `env:"BLOB_STORE_PATH, default=data/blobs"`
+
+	// Monthly statements of every account are archived once the month is
+	// over, checking every MonthlyStatementsInterval. With
+	// MonthlyStatementsNotify customers are sent their statement through the
+	// notification channels.
+	MonthlyStatementsEnabled   bool          `env:"MONTHLY_STATEMENTS_ENABLED, default=false"`
+	MonthlyStatementsInterval  time.Duration `env:"MONTHLY_STATEMENTS_INTERVAL, default=1h"`
+	MonthlyStatementsBatchSize int           `env:"MONTHLY_STATEMENTS_BATCH_SIZE, default=100"`
+	MonthlyStatementsNotify    bool          `env:"MONTHLY_STATEMENTS_NOTIFY, default=false"`
+
+	// Bulk credits. BulkCreditMode applies to batches sent without a mode:
+	// all_or_nothing or per_row. per_row batches left processing are resumed
+	// every BulkCreditResumeInterval.
+	BulkCreditMode           string        `env:"BULK_CREDIT_MODE, default=all_or_nothing"`
+	BulkCreditMaxRows        int           `env:"BULK_CREDIT_MAX_ROWS, default=10000"`
+	BulkCreditResumeInterval time.Duration `env:"BULK_CREDIT_RESUME_INTERVAL, default=5m"`
+
+	// QRIS codes. QRISGlobalID is the reverse domain of this bank as
+	// acquirer, the merchant PAN is QRISPANPrefix and the no_rekening.
+	QRISGlobalID         string `env:"QRIS_GLOBAL_ID, default=ID.CO.ACCOUNTS.WWW"`
+	QRISPANPrefix        string `env:"QRIS_PAN_PREFIX, default=9360099"`
+	QRISMerchantCategory string `env:"QRIS_MERCHANT_CATEGORY, default=5499"`
+	QRISMerchantCriteria string `env:"QRIS_MERCHANT_CRITERIA, default=UMI"`
+	QRISMerchantCity     string `env:"QRIS_MERCHANT_CITY, default=JAKARTA"`
+	QRISPostalCode       string `env:"QRIS_POSTAL_CODE"`
+
+	// VACompanyPrefix starts every VA number, followed by the customer
+	// number.
+	VACompanyPrefix string `env:"VA_COMPANY_PREFIX, default=8808"`
+
+	// InterbankEnabled takes transfers to other banks. Orders debit the
+	// customer into the account InterbankSuspenseNoRekening, and the
+	// transfers queued before each of InterbankCutoffs (HH:MM in WIB) are
+	// written to a clearing batch file, checking every
+	// InterbankClearingInterval. InterbankBankCode is the clearing code of
+	// this bank.
+	InterbankEnabled            bool          `env:"INTERBANK_ENABLED, default=false"`
+	InterbankBankCode           string        `env:"INTERBANK_BANK_CODE"`
+	InterbankSuspenseNoRekening string        `env:"INTERBANK_SUSPENSE_NO_REKENING"`
+	InterbankCutoffs            string        `env:"INTERBANK_CUTOFFS, default=09:00,13:00,16:00"`
+	InterbankClearingInterval   time.Duration `env:"INTERBANK_CLEARING_INTERVAL, default=1m"`
 }
 
+// LoadConfig loads the config file at configPath (.yaml, .yml, .toml or
+// dotenv), overrides it with environment variables and validates the result.
+// An empty path loads .env when it exists.
 func LoadConfig(configPath string) (*Config, error) {
-	// Load .env file if it exists
-	_ = godotenv.Load(configPath)
+	values, err := loadLayers(configPath)
+	if err != nil {
+		return nil, err
+	}
 
 	var cfg Config
-	err := envconfig.Process("", &cfg)
+	err = envconfig.ProcessWith(context.Background(), &envconfig.Config{
+		Target:   &cfg,
+		Lookuper: envconfig.MapLookuper(values),
+	})
 	if err != nil {
 		return nil, fmt.Errorf("error processing env config: %w", err)
 	}
 
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
 	return &cfg, nil
 }
 
-func NewDatabaseConnection(cfg *Config) (*sql.DB, error) {
+var (
+	sslModes = map[string]bool{
+		"disable": true, "allow": true, "prefer": true, "require": true, "verify-ca": true, "verify-full": true,
+	}
+	logLevels = map[string]bool{
+		"critical": true, "error": true, "warning": true, "info": true,
+	}
+	storages = map[string]bool{
+		StoragePostgres: true, StorageSQLite: true, StorageMemory: true,
+	}
+	outboxPublishers = map[string]bool{
+		OutboxPublisherNone: true, OutboxPublisherStdout: true, OutboxPublisherFile: true, OutboxPublisherHTTP: true,
+	}
+	notificationSinks = map[string]bool{
+		NotificationSinkStdout: true, NotificationSinkFile: true,
+	}
+	blobStores = map[string]bool{
+		BlobStoreFile: true,
+	}
+	bulkCreditModes = map[string]bool{
+		BulkCreditModeAllOrNothing: true, BulkCreditModePerRow: true,
+	}
+	balanceCaches = map[string]bool{
+		BalanceCacheNone: true, BalanceCacheMemory: true, BalanceCacheRedis: true,
+	}
+	txIsolationLevels = map[string]sql.IsolationLevel{
+		"read_committed":  sql.LevelReadCommitted,
+		"repeatable_read": sql.LevelRepeatableRead,
+		"serializable":    sql.LevelSerializable,
+	}
+)
+
+// InterbankCutoffTimes returns InterbankCutoffs as offsets from midnight, in
+// increasing order.
+func (c *Config) InterbankCutoffTimes() ([]time.Duration, error) {
+	var cutoffs []time.Duration
+	for _, cutoff := range strings.Split(c.InterbankCutoffs, ",") {
+		at, err := time.Parse("15:04", strings.TrimSpace(cutoff))
+		if err != nil {
+			return nil, err
+		}
+		offset := time.Duration(at.Hour())*time.Hour + time.Duration(at.Minute())*time.Minute
+		if len(cutoffs) > 0 && offset <= cutoffs[len(cutoffs)-1] {
+			return nil, fmt.Errorf("%s is not after the cut-off before it", strings.TrimSpace(cutoff))
+		}
+		cutoffs = append(cutoffs, offset)
+	}
+	return cutoffs, nil
+}
+
+// TxIsolationLevel returns DBTxIsolation as a database/sql isolation level.
+func (c *Config) TxIsolationLevel() sql.IsolationLevel {
+	return txIsolationLevels[c.DBTxIsolation]
+}
+
+func isDigits(s string) bool {
+	_, err := strconv.ParseUint(s, 10, 64)
+	return err == nil
+}
+
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n > 0 && n <= 65535
+}
+
+// Validate reports every invalid setting at once.
+func (c *Config) Validate() error {
+	var errs []error
+
+	if !storages[c.Storage] {
+		errs = append(errs, fmt.Errorf("STORAGE must be one of postgres, sqlite, memory, got %q", c.Storage))
+	}
+	if c.Storage == StorageSQLite && c.SQLitePath == "" {
+		errs = append(errs, errors.New("SQLITE_PATH must not be empty when STORAGE is sqlite"))
+	}
+	if !validPort(c.AppPort) {
+		errs = append(errs, fmt.Errorf("APP_PORT must be a port number between 1 and 65535, got %q", c.AppPort))
+	}
+	if c.GRPCEnabled {
+		if !validPort(c.GRPCPort) {
+			errs = append(errs, fmt.Errorf("GRPC_PORT must be a port number between 1 and 65535, got %q", c.GRPCPort))
+		} else if c.GRPCPort == c.AppPort {
+			errs = append(errs, fmt.Errorf("GRPC_PORT must differ from APP_PORT, both are %s", c.AppPort))
+		}
+	}
+	if c.ISO8583Enabled {
+		switch {
+		case !validPort(c.ISO8583Port):
+			errs = append(errs, fmt.Errorf("ISO8583_PORT must be a port number between 1 and 65535, got %q", c.ISO8583Port))
+		case c.ISO8583Port == c.AppPort:
+			errs = append(errs, fmt.Errorf("ISO8583_PORT must differ from APP_PORT, both are %s", c.AppPort))
+		case c.GRPCEnabled && c.ISO8583Port == c.GRPCPort:
+			errs = append(errs, fmt.Errorf("ISO8583_PORT must differ from GRPC_PORT, both are %s", c.GRPCPort))
+		}
+		if c.ISO8583IdleTimeout < 0 {
+			errs = append(errs, fmt.Errorf("ISO8583_IDLE_TIMEOUT must be >= 0, got %s", c.ISO8583IdleTimeout))
+		}
+	}
+	if c.SNAPEnabled {
+		if c.SNAPPartnersFile == "" {
+			errs = append(errs, errors.New("SNAP_PARTNERS_FILE must not be empty when SNAP_ENABLED is set"))
+		}
+		if len(c.SNAPTokenSecret) < 32 {
+			errs = append(errs, errors.New("SNAP_TOKEN_SECRET must be at least 32 characters when SNAP_ENABLED is set"))
+		}
+		if c.SNAPTokenTTL <= 0 {
+			errs = append(errs, fmt.Errorf("SNAP_TOKEN_TTL must be > 0, got %s", c.SNAPTokenTTL))
+		}
+		if c.SNAPTimestampSkew <= 0 {
+			errs = append(errs, fmt.Errorf("SNAP_TIMESTAMP_SKEW must be > 0, got %s", c.SNAPTimestampSkew))
+		}
+	}
+	if !validPort(c.DBPort) {
+		errs = append(errs, fmt.Errorf("DB_PORT must be a port number between 1 and 65535, got %q", c.DBPort))
+	}
+	if c.DBHost == "" {
+		errs = append(errs, errors.New("DB_HOST must not be empty"))
+	}
+	if c.DBUser == "" {
+		errs = append(errs, errors.New("DB_USER must not be empty"))
+	}
+	if c.DBName == "" {
+		errs = append(errs, errors.New("DB_NAME must not be empty"))
+	}
+	if !sslModes[c.DBSSLMode] {
+		errs = append(errs, fmt.Errorf("DB_SSLMODE must be one of disable, allow, prefer, require, verify-ca, verify-full, got %q", c.DBSSLMode))
+	}
+	if !logLevels[strings.ToLower(c.LogLevel)] {
+		errs = append(errs, fmt.Errorf("LOG_LEVEL must be one of critical, error, warning, info, got %q", c.LogLevel))
+	}
+	if c.DBMaxOpenConns < 0 {
+		errs = append(errs, fmt.Errorf("DB_MAX_OPEN_CONNS must be >= 0, got %d", c.DBMaxOpenConns))
+	}
+	if c.DBMaxIdleConns < 0 {
+		errs = append(errs, fmt.Errorf("DB_MAX_IDLE_CONNS must be >= 0, got %d", c.DBMaxIdleConns))
+	}
+	if c.DBMaxOpenConns > 0 && c.DBMaxIdleConns > c.DBMaxOpenConns {
+		errs = append(errs, fmt.Errorf("DB_MAX_IDLE_CONNS (%d) must not exceed DB_MAX_OPEN_CONNS (%d)", c.DBMaxIdleConns, c.DBMaxOpenConns))
+	}
+	if c.DBConnMaxLifetime < 0 {
+		errs = append(errs, fmt.Errorf("DB_CONN_MAX_LIFETIME must be >= 0, got %s", c.DBConnMaxLifetime))
+	}
+	if c.DBConnMaxIdleTime < 0 {
+		errs = append(errs, fmt.Errorf("DB_CONN_MAX_IDLE_TIME must be >= 0, got %s", c.DBConnMaxIdleTime))
+	}
+	if c.DBStatementTimeout < 0 {
+		errs = append(errs, fmt.Errorf("DB_STATEMENT_TIMEOUT must be >= 0, got %s", c.DBStatementTimeout))
+	} else if c.DBStatementTimeout%time.Millisecond != 0 {
+		errs = append(errs, fmt.Errorf("DB_STATEMENT_TIMEOUT must be a whole number of milliseconds, got %s", c.DBStatementTimeout))
+	}
+	if c.DBQueryTimeout < 0 {
+		errs = append(errs, fmt.Errorf("DB_QUERY_TIMEOUT must be >= 0, got %s", c.DBQueryTimeout))
+	}
+	if c.DBConnectRetries < 0 {
+		errs = append(errs, fmt.Errorf("DB_CONNECT_RETRIES must be >= 0, got %d", c.DBConnectRetries))
+	}
+	if c.DBConnectRetries > 0 && c.DBConnectBackoff <= 0 {
+		errs = append(errs, fmt.Errorf("DB_CONNECT_BACKOFF must be > 0 when DB_CONNECT_RETRIES is set, got %s", c.DBConnectBackoff))
+	}
+	if c.DBConnectRetries > 0 && c.DBConnectMaxBackoff < c.DBConnectBackoff {
+		errs = append(errs, fmt.Errorf("DB_CONNECT_MAX_BACKOFF (%s) must not be less than DB_CONNECT_BACKOFF (%s)", c.DBConnectMaxBackoff, c.DBConnectBackoff))
+	}
+	if c.DBReplicaDSN != "" && c.Storage != StoragePostgres {
+		errs = append(errs, fmt.Errorf("DB_REPLICA_DSN requires STORAGE=postgres, got %q", c.Storage))
+	}
+	if c.DBReplicaMaxLag < 0 {
+		errs = append(errs, fmt.Errorf("DB_REPLICA_MAX_LAG must be >= 0, got %s", c.DBReplicaMaxLag))
+	}
+	if c.DBReplicaMaxReceiptAge <= 0 {
+		errs = append(errs, fmt.Errorf("DB_REPLICA_MAX_RECEIPT_AGE must be > 0, got %s", c.DBReplicaMaxReceiptAge))
+	}
+	if c.DBReplicaLagCheckInterval <= 0 {
+		errs = append(errs, fmt.Errorf("DB_REPLICA_LAG_CHECK_INTERVAL must be > 0, got %s", c.DBReplicaLagCheckInterval))
+	}
+	if _, ok := txIsolationLevels[c.DBTxIsolation]; !ok {
+		errs = append(errs, fmt.Errorf("DB_TX_ISOLATION must be one of read_committed, repeatable_read, serializable, got %q", c.DBTxIsolation))
+	}
+	if c.DBTxMaxRetries < 0 {
+		errs = append(errs, fmt.Errorf("DB_TX_MAX_RETRIES must be >= 0, got %d", c.DBTxMaxRetries))
+	}
+	if !balanceCaches[c.BalanceCache] {
+		errs = append(errs, fmt.Errorf("BALANCE_CACHE must be one of none, memory, redis, got %q", c.BalanceCache))
+	}
+	if c.BalanceCache != BalanceCacheNone {
+		if c.BalanceCacheTTL <= 0 {
+			errs = append(errs, fmt.Errorf("BALANCE_CACHE_TTL must be > 0, got %s", c.BalanceCacheTTL))
+		}
+		if c.DBReplicaDSN != "" && c.BalanceCacheTTL < c.DBReplicaMaxLag {
+			errs = append(errs, fmt.Errorf("BALANCE_CACHE_TTL (%s) must not be less than DB_REPLICA_MAX_LAG (%s)", c.BalanceCacheTTL, c.DBReplicaMaxLag))
+		}
+	}
+	if c.BalanceCache == BalanceCacheMemory && c.BalanceCacheSize <= 0 {
+		errs = append(errs, fmt.Errorf("BALANCE_CACHE_SIZE must be > 0, got %d", c.BalanceCacheSize))
+	}
+	if c.BalanceCache == BalanceCacheRedis && c.RedisAddr == "" {
+		errs = append(errs, errors.New("REDIS_ADDR must not be empty when BALANCE_CACHE is redis"))
+	}
+	if !outboxPublishers[c.OutboxPublisher] {
+		errs = append(errs, fmt.Errorf("OUTBOX_PUBLISHER must be one of none, stdout, file, http, got %q", c.OutboxPublisher))
+	}
+	if c.OutboxPublisher == OutboxPublisherFile && c.OutboxFilePath == "" {
+		errs = append(errs, errors.New("OUTBOX_FILE_PATH must not be empty when OUTBOX_PUBLISHER is file"))
+	}
+	if c.OutboxPublisher == OutboxPublisherHTTP {
+		if c.OutboxHTTPURL == "" {
+			errs = append(errs, errors.New("OUTBOX_HTTP_URL must not be empty when OUTBOX_PUBLISHER is http"))
+		}
+		if c.OutboxHTTPTimeout <= 0 {
+			errs = append(errs, fmt.Errorf("OUTBOX_HTTP_TIMEOUT must be > 0, got %s", c.OutboxHTTPTimeout))
+		}
+	}
+	if c.OutboxPollInterval <= 0 {
+		errs = append(errs, fmt.Errorf("OUTBOX_POLL_INTERVAL must be > 0, got %s", c.OutboxPollInterval))
+	}
+	if c.OutboxBatchSize <= 0 {
+		errs = append(errs, fmt.Errorf("OUTBOX_BATCH_SIZE must be > 0, got %d", c.OutboxBatchSize))
+	}
+	if c.OutboxMaxAttempts <= 0 {
+		errs = append(errs, fmt.Errorf("OUTBOX_MAX_ATTEMPTS must be > 0, got %d", c.OutboxMaxAttempts))
+	}
+	if c.OutboxRetryBackoff <= 0 {
+		errs = append(errs, fmt.Errorf("OUTBOX_RETRY_BACKOFF must be > 0, got %s", c.OutboxRetryBackoff))
+	}
+	if c.OutboxRetryMaxBackoff < c.OutboxRetryBackoff {
+		errs = append(errs, fmt.Errorf("OUTBOX_RETRY_MAX_BACKOFF (%s) must not be less than OUTBOX_RETRY_BACKOFF (%s)", c.OutboxRetryMaxBackoff, c.OutboxRetryBackoff))
+	}
+	if c.AdminToken != "" && len(c.AdminToken) < 16 {
+		errs = append(errs, errors.New("ADMIN_TOKEN must be at least 16 characters"))
+	}
+	if c.WebhooksEnabled {
+		if c.WebhookTimeout <= 0 {
+			errs = append(errs, fmt.Errorf("WEBHOOK_TIMEOUT must be > 0, got %s", c.WebhookTimeout))
+		}
+		if c.WebhookPollInterval <= 0 {
+			errs = append(errs, fmt.Errorf("WEBHOOK_POLL_INTERVAL must be > 0, got %s", c.WebhookPollInterval))
+		}
+		if c.WebhookBatchSize <= 0 {
+			errs = append(errs, fmt.Errorf("WEBHOOK_BATCH_SIZE must be > 0, got %d", c.WebhookBatchSize))
+		}
+		if c.WebhookMaxAttempts <= 0 {
+			errs = append(errs, fmt.Errorf("WEBHOOK_MAX_ATTEMPTS must be > 0, got %d", c.WebhookMaxAttempts))
+		}
+		if c.WebhookRetryBackoff <= 0 {
+			errs = append(errs, fmt.Errorf("WEBHOOK_RETRY_BACKOFF must be > 0, got %s", c.WebhookRetryBackoff))
+		}
+		if c.WebhookRetryMaxBackoff < c.WebhookRetryBackoff {
+			errs = append(errs, fmt.Errorf("WEBHOOK_RETRY_MAX_BACKOFF (%s) must not be less than WEBHOOK_RETRY_BACKOFF (%s)", c.WebhookRetryMaxBackoff, c.WebhookRetryBackoff))
+		}
+	}
+	if c.NotificationsEnabled && c.NotificationLargeCredit <= 0 {
+		errs = append(errs, fmt.Errorf("NOTIFICATION_LARGE_CREDIT must be > 0, got %v", c.NotificationLargeCredit))
+	}
+	if c.NotificationsEnabled || c.MonthlyStatementsNotify {
+		if !notificationSinks[c.NotificationSink] {
+			errs = append(errs, fmt.Errorf("NOTIFICATION_SINK must be one of stdout, file, got %q", c.NotificationSink))
+		}
+		if c.NotificationSink == NotificationSinkFile && c.NotificationFilePath == "" {
+			errs = append(errs, errors.New("NOTIFICATION_FILE_PATH must not be empty when NOTIFICATION_SINK is file"))
+		}
+		if c.SMTPHost != "" {
+			if c.SMTPPort <= 0 || c.SMTPPort > 65535 {
+				errs = append(errs, fmt.Errorf("SMTP_PORT must be between 1 and 65535, got %d", c.SMTPPort))
+			}
+			if c.SMTPFrom == "" {
+				errs = append(errs, errors.New("SMTP_FROM must not be empty when SMTP_HOST is set"))
+			}
+			if c.SMTPTimeout <= 0 {
+				errs = append(errs, fmt.Errorf("SMTP_TIMEOUT must be > 0, got %s", c.SMTPTimeout))
+			}
+		}
+	}
+	if strings.TrimSpace(c.StatementBankName) == "" {
+		errs = append(errs, errors.New("STATEMENT_BANK_NAME must not be empty"))
+	}
+	if c.StatementTimeout < 0 {
+		errs = append(errs, fmt.Errorf("STATEMENT_TIMEOUT must be >= 0, got %s", c.StatementTimeout))
+	}
+	if !blobStores[c.BlobStore] {
+		errs = append(errs, fmt.Errorf("BLOB_STORE must be one of file, got %q", c.BlobStore))
+	}
+	if c.BlobStore == BlobStoreFile && c.BlobStorePath == "" {
+		errs = append(errs, errors.New("BLOB_STORE_PATH must not be empty when BLOB_STORE is file"))
+	}
+	if c.MonthlyStatementsEnabled && c.MonthlyStatementsInterval <= 0 {
+		errs = append(errs, fmt.Errorf("MONTHLY_STATEMENTS_INTERVAL must be > 0, got %s", c.MonthlyStatementsInterval))
+	}
+	if c.MonthlyStatementsBatchSize <= 0 {
+		errs = append(errs, fmt.Errorf("MONTHLY_STATEMENTS_BATCH_SIZE must be > 0, got %d", c.MonthlyStatementsBatchSize))
+	}
+	if !bulkCreditModes[c.BulkCreditMode] {
+		errs = append(errs, fmt.Errorf("BULK_CREDIT_MODE must be one of all_or_nothing, per_row, got %q", c.BulkCreditMode))
+	}
+	if c.BulkCreditMaxRows <= 0 {
+		errs = append(errs, fmt.Errorf("BULK_CREDIT_MAX_ROWS must be > 0, got %d", c.BulkCreditMaxRows))
+	}
+	if c.BulkCreditResumeInterval <= 0 {
+		errs = append(errs, fmt.Errorf("BULK_CREDIT_RESUME_INTERVAL must be > 0, got %s", c.BulkCreditResumeInterval))
+	}
+	if c.QRISGlobalID == "" || len(c.QRISGlobalID) > 32 {
+		errs = append(errs, fmt.Errorf("QRIS_GLOBAL_ID must be 1 to 32 characters, got %q", c.QRISGlobalID))
+	}
+	if !isDigits(c.QRISPANPrefix) || len(c.QRISPANPrefix) > 7 {
+		errs = append(errs, fmt.Errorf("QRIS_PAN_PREFIX must be 1 to 7 digits, got %q", c.QRISPANPrefix))
+	}
+	if !isDigits(c.QRISMerchantCategory) || len(c.QRISMerchantCategory) != 4 {
+		errs = append(errs, fmt.Errorf("QRIS_MERCHANT_CATEGORY must be 4 digits, got %q", c.QRISMerchantCategory))
+	}
+	if c.QRISMerchantCriteria == "" {
+		errs = append(errs, errors.New("QRIS_MERCHANT_CRITERIA must not be empty"))
+	}
+	if strings.TrimSpace(c.QRISMerchantCity) == "" {
+		errs = append(errs, errors.New("QRIS_MERCHANT_CITY must not be empty"))
+	}
+	if len(c.QRISPostalCode) > 10 {
+		errs = append(errs, fmt.Errorf("QRIS_POSTAL_CODE must be at most 10 characters, got %q", c.QRISPostalCode))
+	}
+	if !isDigits(c.VACompanyPrefix) || len(c.VACompanyPrefix) > 8 {
+		errs = append(errs, fmt.Errorf("VA_COMPANY_PREFIX must be 1 to 8 digits, got %q", c.VACompanyPrefix))
+	}
+	if c.InterbankEnabled {
+		if !isDigits(c.InterbankBankCode) || len(c.InterbankBankCode) != 3 {
+			errs = append(errs, fmt.Errorf("INTERBANK_BANK_CODE must be 3 digits when INTERBANK_ENABLED is set, got %q", c.InterbankBankCode))
+		}
+		if !utils.IsValidNoRekening(c.InterbankSuspenseNoRekening) {
+			errs = append(errs, fmt.Errorf("INTERBANK_SUSPENSE_NO_REKENING must be a no_rekening when INTERBANK_ENABLED is set, got %q", c.InterbankSuspenseNoRekening))
+		}
+		if _, err := c.InterbankCutoffTimes(); err != nil {
+			errs = append(errs, fmt.Errorf("INTERBANK_CUTOFFS must be increasing HH:MM times separated by commas, got %q: %w", c.InterbankCutoffs, err))
+		}
+		if c.InterbankClearingInterval <= 0 {
+			errs = append(errs, fmt.Errorf("INTERBANK_CLEARING_INTERVAL must be > 0, got %s", c.InterbankClearingInterval))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func NewDatabaseConnection(cfg *Config, logger utils.Logger) (*sql.DB, error) {
 	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
 		cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPassword, cfg.DBName, cfg.DBSSLMode)
 
+	if cfg.DBStatementTimeout > 0 {
+		connStr += fmt.Sprintf(" statement_timeout=%d", cfg.DBStatementTimeout.Milliseconds())
+	}
+
 	db, err := sql.Open("postgres", connStr)
 	if err != nil {
 		return nil, fmt.Errorf("error opening database connection: %w", err)
 	}
 
-	// Verify the connection
-	if err = db.Ping(); err != nil {
+	// Set connection pool settings
+	db.SetMaxOpenConns(cfg.DBMaxOpenConns)
+	db.SetMaxIdleConns(cfg.DBMaxIdleConns)
+	db.SetConnMaxLifetime(cfg.DBConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.DBConnMaxIdleTime)
+
+	// Verify the connection, retrying while the database is starting up
+	if err = pingWithRetry(db, cfg, logger); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("error pinging database: %w", err)
 	}
 
-	// Set connection pool settings (optional but recommended)
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(25)
-	db.SetConnMaxLifetime(5 * time.Minute)
+	return db, nil
+}
+
+// NewReplicaConnection opens the read replica at cfg.DBReplicaDSN with the
+// pool settings of the primary. It is not pinged, an unreachable replica
+// only makes reads fall back to the primary.
+func NewReplicaConnection(cfg *Config) (*sql.DB, error) {
+	db, err := sql.Open("postgres", cfg.DBReplicaDSN)
+	if err != nil {
+		return nil, fmt.Errorf("error opening replica connection: %w", err)
+	}
+
+	db.SetMaxOpenConns(cfg.DBMaxOpenConns)
+	db.SetMaxIdleConns(cfg.DBMaxIdleConns)
+	db.SetConnMaxLifetime(cfg.DBConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.DBConnMaxIdleTime)
+
+	return db, nil
+}
+
+// NewSQLiteConnection opens the SQLite database file at cfg.SQLitePath.
+// Transactions take the write lock when they begin, and a single connection
+// is used so that writers queue instead of failing with SQLITE_BUSY.
+func NewSQLiteConnection(cfg *Config) (*sql.DB, error) {
+	dsn := "file:" + cfg.SQLitePath +
+		"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate"
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("error opening sqlite database: %w", err)
+	}
+
+	db.SetMaxOpenConns(1)
+
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error opening sqlite database %s: %w", cfg.SQLitePath, err)
+	}
 
 	return db, nil
 }
+
+// NewRedisClient connects to the Redis server of the balance cache.
+func NewRedisClient(cfg *Config) (*redis.Client, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.RedisAddr,
+		Password: cfg.RedisPassword,
+		DB:       cfg.RedisDB,
+	})
+
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("error connecting to redis at %s: %w", cfg.RedisAddr, err)
+	}
+
+	return client, nil
+}
+
+func pingWithRetry(db *sql.DB, cfg *Config, logger utils.Logger) error {
+	backoff := cfg.DBConnectBackoff
+
+	for attempt := 0; ; attempt++ {
+		err := db.PingContext(context.Background())
+		if err == nil || attempt >= cfg.DBConnectRetries {
+			return err
+		}
+
+		logger.Warning("Database not ready (attempt %d/%d), retrying in %s: %v", attempt+1, cfg.DBConnectRetries, backoff, err)
+		time.Sleep(backoff)
+
+		backoff *= 2
+		if backoff > cfg.DBConnectMaxBackoff {
+			backoff = cfg.DBConnectMaxBackoff
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package config_test
+
+import (
+	"accounts-service/config"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clearEnv unsets the given variables for the duration of the test, so the
+// values come from the config file or the defaults.
+func clearEnv(t *testing.T, keys ...string) {
+	for _, key := range keys {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadConfig_Defaults(t *testing.T) {
+	clearEnv(t, "DB_MAX_OPEN_CONNS", "DB_CONN_MAX_LIFETIME", "DB_STATEMENT_TIMEOUT", "DB_CONNECT_RETRIES")
+
+	cfg, err := config.LoadConfig("")
+	require.NoError(t, err)
+
+	assert.Equal(t, 25, cfg.DBMaxOpenConns)
+	assert.Equal(t, 5*time.Minute, cfg.DBConnMaxLifetime)
+	assert.Equal(t, 30*time.Second, cfg.DBStatementTimeout)
+	assert.Equal(t, 5, cfg.DBConnectRetries)
+}
+
+func TestConfig_Validate(t *testing.T) {
+	t.Setenv("DB_MAX_OPEN_CONNS", "10")
+	t.Setenv("DB_MAX_IDLE_CONNS", "20")
+	t.Setenv("DB_STATEMENT_TIMEOUT", "-1s")
+	t.Setenv("DB_CONNECT_BACKOFF", "0s")
+	t.Setenv("DB_SSLMODE", "sometimes")
+	t.Setenv("BALANCE_CACHE", "memory")
+	t.Setenv("BALANCE_CACHE_TTL", "0s")
+	t.Setenv("ADMIN_TOKEN", "short")
+	t.Setenv("WEBHOOKS_ENABLED", "true")
+	t.Setenv("WEBHOOK_RETRY_MAX_BACKOFF", "1s")
+	t.Setenv("NOTIFICATIONS_ENABLED", "true")
+	t.Setenv("SMTP_HOST", "localhost")
+	t.Setenv("GRPC_ENABLED", "true")
+	t.Setenv("GRPC_PORT", "8080")
+	t.Setenv("APP_PORT", "8080")
+	t.Setenv("ISO8583_ENABLED", "true")
+	t.Setenv("ISO8583_PORT", "8583")
+	t.Setenv("ISO8583_IDLE_TIMEOUT", "-1s")
+	t.Setenv("SNAP_ENABLED", "true")
+	t.Setenv("SNAP_TOKEN_SECRET", "short")
+	t.Setenv("STATEMENT_BANK_NAME", " ")
+	t.Setenv("BLOB_STORE", "s3")
+	t.Setenv("MONTHLY_STATEMENTS_ENABLED", "true")
+	t.Setenv("MONTHLY_STATEMENTS_INTERVAL", "0s")
+	t.Setenv("BULK_CREDIT_MODE", "best_effort")
+	t.Setenv("BULK_CREDIT_MAX_ROWS", "0")
+	t.Setenv("QRIS_MERCHANT_CATEGORY", "54A9")
+	t.Setenv("VA_COMPANY_PREFIX", "880800001")
+	t.Setenv("INTERBANK_ENABLED", "true")
+	t.Setenv("INTERBANK_BANK_CODE", "14")
+	t.Setenv("INTERBANK_SUSPENSE_NO_REKENING", "9000000001")
+	t.Setenv("INTERBANK_CUTOFFS", "13:00,09:00")
+
+	_, err := config.LoadConfig("")
+	require.Error(t, err)
+
+	assert.Contains(t, err.Error(), "DB_MAX_IDLE_CONNS (20) must not exceed DB_MAX_OPEN_CONNS (10)")
+	assert.Contains(t, err.Error(), "DB_STATEMENT_TIMEOUT must be >= 0, got -1s")
+	assert.Contains(t, err.Error(), "DB_CONNECT_BACKOFF must be > 0 when DB_CONNECT_RETRIES is set")
+	assert.Contains(t, err.Error(), `DB_SSLMODE must be one of disable, allow, prefer, require, verify-ca, verify-full, got "sometimes"`)
+	assert.Contains(t, err.Error(), "BALANCE_CACHE_TTL must be > 0, got 0s")
+	assert.Contains(t, err.Error(), "ADMIN_TOKEN must be at least 16 characters")
+	assert.Contains(t, err.Error(), "WEBHOOK_RETRY_MAX_BACKOFF (1s) must not be less than WEBHOOK_RETRY_BACKOFF (10s)")
+	assert.Contains(t, err.Error(), "SMTP_FROM must not be empty when SMTP_HOST is set")
+	assert.Contains(t, err.Error(), "GRPC_PORT must differ from APP_PORT, both are 8080")
+	assert.Contains(t, err.Error(), "ISO8583_IDLE_TIMEOUT must be >= 0, got -1s")
+	assert.Contains(t, err.Error(), "SNAP_TOKEN_SECRET must be at least 32 characters when SNAP_ENABLED is set")
+	assert.Contains(t, err.Error(), "STATEMENT_BANK_NAME must not be empty")
+	assert.Contains(t, err.Error(), `BLOB_STORE must be one of file, got "s3"`)
+	assert.Contains(t, err.Error(), "MONTHLY_STATEMENTS_INTERVAL must be > 0, got 0s")
+	assert.Contains(t, err.Error(), `BULK_CREDIT_MODE must be one of all_or_nothing, per_row, got "best_effort"`)
+	assert.Contains(t, err.Error(), "BULK_CREDIT_MAX_ROWS must be > 0, got 0")
+	assert.Contains(t, err.Error(), `QRIS_MERCHANT_CATEGORY must be 4 digits, got "54A9"`)
+	assert.Contains(t, err.Error(), `VA_COMPANY_PREFIX must be 1 to 8 digits, got "880800001"`)
+	assert.Contains(t, err.Error(), `INTERBANK_BANK_CODE must be 3 digits when INTERBANK_ENABLED is set, got "14"`)
+	assert.Contains(t, err.Error(), `INTERBANK_CUTOFFS must be increasing HH:MM times separated by commas, got "13:00,09:00"`)
+}
+
+func TestConfig_ValidateConnectBackoff(t *testing.T) {
+	t.Setenv("DB_CONNECT_BACKOFF", "1m")
+	t.Setenv("DB_CONNECT_MAX_BACKOFF", "1s")
+
+	t.Setenv("DB_CONNECT_RETRIES", "3")
+	_, err := config.LoadConfig("")
+	assert.ErrorContains(t, err, "DB_CONNECT_MAX_BACKOFF (1s) must not be less than DB_CONNECT_BACKOFF (1m0s)")
+
+	t.Setenv("DB_CONNECT_RETRIES", "0")
+	_, err = config.LoadConfig("")
+	assert.NoError(t, err, "the backoff is not used without retries")
+}
+
+func TestLoadConfig_Files(t *testing.T) {
+	t.Run("yaml with env override", func(t *testing.T) {
+		clearEnv(t, "APP_PORT", "DB_HOST", "DB_NAME", "DB_MAX_OPEN_CONNS")
+		t.Setenv("DB_NAME", "from_env")
+
+		path := writeFile(t, "config.yaml", "app_port: 9090\ndb:\n  host: db.internal\n  name: from_file\n  max_open_conns: 40\n")
+		cfg, err := config.LoadConfig(path)
+		require.NoError(t, err)
+
+		assert.Equal(t, "9090", cfg.AppPort)
+		assert.Equal(t, "db.internal", cfg.DBHost)
+		assert.Equal(t, "from_env", cfg.DBName)
+		assert.Equal(t, 40, cfg.DBMaxOpenConns)
+
+		_, ok := os.LookupEnv("DB_HOST")
+		assert.False(t, ok, "file values stay out of the process environment")
+	})
+
+	t.Run("toml", func(t *testing.T) {
+		clearEnv(t, "DB_HOST", "DB_CONN_MAX_LIFETIME")
+
+		path := writeFile(t, "config.toml", "[db]\nhost = \"toml.internal\"\nconn_max_lifetime = \"1m\"\n")
+		cfg, err := config.LoadConfig(path)
+		require.NoError(t, err)
+
+		assert.Equal(t, "toml.internal", cfg.DBHost)
+		assert.Equal(t, time.Minute, cfg.DBConnMaxLifetime)
+	})
+
+	t.Run("secret file", func(t *testing.T) {
+		clearEnv(t, "DB_PASSWORD", "DB_PASSWORD_FILE")
+		t.Setenv("DB_PASSWORD_FILE", writeFile(t, "db_password", "s3cret\n"))
+
+		cfg, err := config.LoadConfig("")
+		require.NoError(t, err)
+		assert.Equal(t, "s3cret", cfg.DBPassword)
+		_, ok := os.LookupEnv("DB_PASSWORD")
+		assert.False(t, ok, "the secret stays out of the process environment")
+
+		var out bytes.Buffer
+		require.NoError(t, cfg.Print(&out))
+		assert.Contains(t, out.String(), "DB_PASSWORD=******\n")
+		assert.NotContains(t, out.String(), "s3cret")
+	})
+
+	t.Run("secret and plain value conflict", func(t *testing.T) {
+		t.Setenv("DB_PASSWORD", "plain")
+		t.Setenv("DB_PASSWORD_FILE", writeFile(t, "db_password", "s3cret"))
+
+		_, err := config.LoadConfig("")
+		assert.EqualError(t, err, "both DB_PASSWORD and DB_PASSWORD_FILE are set, use only one")
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		path := writeFile(t, "config.yaml", "db:\n  hots: typo\n")
+		_, err := config.LoadConfig(path)
+		assert.ErrorContains(t, err, "unknown keys in config file")
+		assert.ErrorContains(t, err, "DB_HOTS")
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := config.LoadConfig(filepath.Join(t.TempDir(), "missing.yaml"))
+		assert.ErrorContains(t, err, "error reading config file")
+	})
+}
@@ -0,0 +1,192 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultEnvFile = ".env"
+	secretFileExt  = "_FILE"
+	secretMask     = "******"
+)
+
+// loadLayers merges the config file at path with the environment and resolves
+// *_FILE secret indirection. It returns the effective values for envconfig to
+// look up, leaving the process environment as it is. Precedence, lowest
+// first: config file, environment variables.
+func loadLayers(path string) (map[string]string, error) {
+	fileValues, err := readConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	known := knownKeys()
+	var unknown []string
+	for key := range fileValues {
+		if !known[strings.TrimSuffix(key, secretFileExt)] {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown keys in config file %s: %s", path, strings.Join(unknown, ", "))
+	}
+
+	values := make(map[string]string)
+	for key := range known {
+		for _, name := range []string{key, key + secretFileExt} {
+			if v, ok := os.LookupEnv(name); ok {
+				values[name] = v
+			}
+		}
+		// A key set in the environment replaces both forms from the file.
+		_, inEnv := values[key]
+		_, fileInEnv := values[key+secretFileExt]
+		if inEnv || fileInEnv {
+			continue
+		}
+		if v, ok := fileValues[key]; ok {
+			values[key] = v
+		}
+		if v, ok := fileValues[key+secretFileExt]; ok {
+			values[key+secretFileExt] = v
+		}
+	}
+
+	for key := range known {
+		secretPath, ok := values[key+secretFileExt]
+		if !ok {
+			continue
+		}
+		if _, ok := values[key]; ok {
+			return nil, fmt.Errorf("both %s and %s are set, use only one", key, key+secretFileExt)
+		}
+
+		secret, err := os.ReadFile(secretPath)
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %w", key+secretFileExt, err)
+		}
+		values[key] = strings.TrimRight(string(secret), "\r\n")
+	}
+
+	return values, nil
+}
+
+// readConfigFile reads a .yaml/.yml, .toml or dotenv file into upper case
+// keys. Nested sections are joined with underscores, so `db: {host: x}`
+// becomes DB_HOST. An empty path reads .env when it exists.
+func readConfigFile(path string) (map[string]string, error) {
+	if path == "" {
+		if _, err := os.Stat(defaultEnvFile); errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		path = defaultEnvFile
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading config file: %w", err)
+	}
+
+	raw := make(map[string]interface{})
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		var env map[string]string
+		env, err = godotenv.UnmarshalBytes(data)
+		for k, v := range env {
+			raw[k] = v
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error parsing config file %s: %w", path, err)
+	}
+
+	values := make(map[string]string)
+	if err := flatten("", raw, values); err != nil {
+		return nil, fmt.Errorf("error parsing config file %s: %w", path, err)
+	}
+	return values, nil
+}
+
+func flatten(prefix string, raw map[string]interface{}, out map[string]string) error {
+	for k, v := range raw {
+		key := strings.ToUpper(k)
+		if prefix != "" {
+			key = prefix + "_" + key
+		}
+
+		switch value := v.(type) {
+		case map[string]interface{}:
+			if err := flatten(key, value, out); err != nil {
+				return err
+			}
+		case []interface{}:
+			return fmt.Errorf("%s: lists are not supported", key)
+		case nil:
+			out[key] = ""
+		default:
+			out[key] = fmt.Sprint(value)
+		}
+	}
+	return nil
+}
+
+// knownKeys returns the envconfig keys of Config.
+func knownKeys() map[string]bool {
+	keys := make(map[string]bool)
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		if key := envKey(t.Field(i)); key != "" {
+			keys[key] = true
+		}
+	}
+	return keys
+}
+
+// envKey returns the variable of a Config field, the env tag up to its
+// options.
+func envKey(field reflect.StructField) string {
+	key, _, _ := strings.Cut(field.Tag.Get("env"), ",")
+	return strings.TrimSpace(key)
+}
+
+// Print writes the effective config as KEY=value lines, masking secret
+// fields.
+func (c *Config) Print(w io.Writer) error {
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := envKey(field)
+		if key == "" {
+			continue
+		}
+
+		value := fmt.Sprint(v.Field(i).Interface())
+		if field.Tag.Get("secret") == "true" && value != "" {
+			value = secretMask
+		}
+
+		if _, err := fmt.Fprintf(w, "%s=%s\n", key, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package events_test
+
+import (
+	"accounts-service/events"
+	"accounts-service/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mutationEvent() *models.OutboxEvent {
+	return &models.OutboxEvent{
+		ID:           7,
+		AggregateKey: "1744847261",
+		EventType:    models.EventMutationCreated,
+		Payload:      json.RawMessage(`{"nominal":150000.5,"type":"credit/tabung"}`),
+		CreatedAt:    time.Date(2025, 4, 20, 9, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestWriterPublisher(t *testing.T) {
+	var buf bytes.Buffer
+	publisher := events.NewWriterPublisher(&buf)
+
+	require.NoError(t, publisher.Publish(context.Background(), mutationEvent()))
+	require.NoError(t, publisher.Publish(context.Background(), mutationEvent()))
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+	assert.JSONEq(t, `{
+		"id": 7,
+		"aggregate_key": "1744847261",
+		"event_type": "mutation.created",
+		"payload": {"nominal": 150000.5, "type": "credit/tabung"},
+		"created_at": "2025-04-20T09:00:00Z"
+	}`, string(lines[0]))
+}
+
+func TestHTTPPublisher(t *testing.T) {
+	t.Run("posts the event", func(t *testing.T) {
+		var received *http.Request
+		var body []byte
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = r
+			body, _ = io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusAccepted)
+		}))
+		defer server.Close()
+
+		err := events.NewHTTPPublisher(server.URL, time.Second).Publish(context.Background(), mutationEvent())
+		require.NoError(t, err)
+
+		assert.Equal(t, http.MethodPost, received.Method)
+		assert.Equal(t, "7", received.Header.Get("X-Event-ID"))
+		assert.Equal(t, "mutation.created", received.Header.Get("X-Event-Type"))
+		assert.Contains(t, string(body), `"aggregate_key":"1744847261"`)
+	})
+
+	t.Run("non 2xx is a failed delivery", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		err := events.NewHTTPPublisher(server.URL, time.Second).Publish(context.Background(), mutationEvent())
+		assert.ErrorContains(t, err, "status 503")
+	})
+}
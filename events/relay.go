@@ -0,0 +1,123 @@
+package events
+
+import (
+	"accounts-service/repositories"
+	"accounts-service/utils"
+	"context"
+	"time"
+)
+
+// RelayOptions tune the outbox relay.
+type RelayOptions struct {
+	// PollInterval is the wait between passes once the outbox is drained.
+	PollInterval time.Duration
+	// BatchSize is how many events are read per query.
+	BatchSize int
+	// MaxAttempts is how many deliveries are tried before an event is
+	// dead-lettered.
+	MaxAttempts int
+	// Backoff is the wait after the first failed delivery, doubling up to
+	// MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// Relay publishes pending outbox events. Only the oldest pending event of an
+// account is ever delivered, so events of one account are published in the
+// order they were recorded, and a failing event holds back the later events
+// of its account until it is published or dead-lettered. Events of other
+// accounts are not held back.
+//
+// The relay assumes it is the only one reading the outbox, run it on a
+// single instance.
+type Relay struct {
+	outboxRepo repositories.OutboxRepository
+	publisher  Publisher
+	options    RelayOptions
+	logger     utils.Logger
+}
+
+func NewRelay(outboxRepo repositories.OutboxRepository, publisher Publisher, options RelayOptions, logger utils.Logger) *Relay {
+	return &Relay{
+		outboxRepo: outboxRepo,
+		publisher:  publisher,
+		options:    options,
+		logger:     logger,
+	}
+}
+
+// Run relays events until ctx is done.
+func (r *Relay) Run(ctx context.Context) {
+	r.logger.Info("Outbox relay started")
+
+	ticker := time.NewTicker(r.options.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.Drain(ctx); err != nil && ctx.Err() == nil {
+			r.logger.Error("Error relaying outbox events: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			r.logger.Info("Outbox relay stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Drain delivers deliverable events until none are left and returns how
+// many were published.
+func (r *Relay) Drain(ctx context.Context) (int, error) {
+	published := 0
+	for {
+		events, err := r.outboxRepo.ListDeliverable(ctx, time.Now(), r.options.BatchSize)
+		if err != nil || len(events) == 0 {
+			return published, err
+		}
+
+		for i := range events {
+			if ctx.Err() != nil {
+				return published, ctx.Err()
+			}
+
+			event := &events[i]
+			if err := r.publisher.Publish(ctx, event); err != nil {
+				if err := r.fail(ctx, event.ID, event.Attempts+1, err); err != nil {
+					return published, err
+				}
+				continue
+			}
+
+			if err := r.outboxRepo.MarkPublished(ctx, event.ID, time.Now()); err != nil {
+				return published, err
+			}
+			published++
+		}
+	}
+}
+
+// fail schedules the next attempt of an event, or dead-letters it once it
+// has used up its attempts.
+func (r *Relay) fail(ctx context.Context, id uint, attempts int, cause error) error {
+	dead := attempts >= r.options.MaxAttempts
+	if dead {
+		r.logger.Error("Outbox event %d dead-lettered after %d attempts: %v", id, attempts, cause)
+	} else {
+		r.logger.Warning("Outbox event %d delivery %d failed, retrying: %v", id, attempts, cause)
+	}
+
+	next := time.Now().Add(backoff(r.options.Backoff, r.options.MaxBackoff, attempts))
+	return r.outboxRepo.MarkFailed(ctx, id, cause.Error(), next, dead)
+}
+
+// backoff returns the wait after the given number of failed attempts,
+// starting at base and doubling up to limit.
+func backoff(base, limit time.Duration, attempts int) time.Duration {
+	wait := base
+	for i := 1; i < attempts && wait < limit; i++ {
+		wait *= 2
+	}
+	return min(wait, limit)
+}
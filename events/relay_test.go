@@ -0,0 +1,114 @@
+package events_test
+
+import (
+	"accounts-service/events"
+	"accounts-service/models"
+	"accounts-service/repositories"
+	"accounts-service/utils"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingPublisher records published event IDs and fails the events in
+// failing.
+type recordingPublisher struct {
+	published []uint
+	failing   map[uint]bool
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, event *models.OutboxEvent) error {
+	if p.failing[event.ID] {
+		return errors.New("downstream unavailable")
+	}
+	p.published = append(p.published, event.ID)
+	return nil
+}
+
+func TestRelay(t *testing.T) {
+	logger := utils.NewLogger("critical")
+	ctx := context.Background()
+
+	setup := func(t *testing.T, keys ...string) (repositories.OutboxRepository, []uint) {
+		outboxRepo := repositories.NewMemoryOutboxRepository(repositories.NewMemoryStore(), logger)
+
+		var ids []uint
+		for _, key := range keys {
+			event := &models.OutboxEvent{AggregateKey: key, EventType: models.EventMutationCreated, Payload: []byte(`{}`)}
+			require.NoError(t, outboxRepo.CreateEvent(ctx, event))
+			ids = append(ids, event.ID)
+		}
+		return outboxRepo, ids
+	}
+
+	options := events.RelayOptions{
+		PollInterval: time.Millisecond,
+		BatchSize:    2,
+		MaxAttempts:  2,
+		Backoff:      time.Millisecond,
+		MaxBackoff:   time.Millisecond,
+	}
+
+	t.Run("publishes every event in order per account", func(t *testing.T) {
+		outboxRepo, ids := setup(t, "1000000001", "1000000002", "1000000001", "1000000001")
+		publisher := &recordingPublisher{}
+
+		published, err := events.NewRelay(outboxRepo, publisher, options, logger).Drain(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 4, published)
+		assert.ElementsMatch(t, ids, publisher.published)
+
+		var account1 []uint
+		for _, id := range publisher.published {
+			if id != ids[1] {
+				account1 = append(account1, id)
+			}
+		}
+		assert.Equal(t, []uint{ids[0], ids[2], ids[3]}, account1)
+
+		remaining, err := outboxRepo.ListDeliverable(ctx, time.Now().Add(time.Hour), 10)
+		require.NoError(t, err)
+		assert.Empty(t, remaining)
+	})
+
+	t.Run("failing event holds back its account and is dead-lettered", func(t *testing.T) {
+		outboxRepo, ids := setup(t, "1000000001", "1000000001", "1000000002")
+		publisher := &recordingPublisher{failing: map[uint]bool{ids[0]: true}}
+		relay := events.NewRelay(outboxRepo, publisher, options, logger)
+
+		_, err := relay.Drain(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []uint{ids[2]}, publisher.published)
+
+		// The retry fails too and uses up the attempts
+		time.Sleep(5 * time.Millisecond)
+		_, err = relay.Drain(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []uint{ids[2], ids[1]}, publisher.published)
+	})
+
+	t.Run("run stops with the context", func(t *testing.T) {
+		outboxRepo, ids := setup(t, "1000000001")
+		publisher := &recordingPublisher{}
+
+		ctx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+		go func() {
+			events.NewRelay(outboxRepo, publisher, options, logger).Run(ctx)
+			close(done)
+		}()
+
+		require.Eventually(t, func() bool {
+			remaining, err := outboxRepo.ListDeliverable(context.Background(), time.Now().Add(time.Hour), 10)
+			return err == nil && len(remaining) == 0
+		}, time.Second, time.Millisecond)
+		cancel()
+		<-done
+
+		assert.Equal(t, []uint{ids[0]}, publisher.published)
+	})
+}
@@ -0,0 +1,262 @@
+package events
+
+import (
+	"accounts-service/models"
+	"accounts-service/repositories"
+	"accounts-service/utils"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers of a webhook delivery. The signature is "sha256=" followed by the
+// hex HMAC-SHA256 of "<timestamp>.<body>" keyed with the webhook secret.
+const (
+	HeaderWebhookID        = "X-Webhook-ID"
+	HeaderWebhookEvent     = "X-Webhook-Event"
+	HeaderWebhookTimestamp = "X-Webhook-Timestamp"
+	HeaderWebhookSignature = "X-Webhook-Signature"
+)
+
+// SignWebhook returns the signature header of body sent at timestamp.
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhook checks the signature and timestamp headers of a delivery as
+// a receiver would, rejecting timestamps further than tolerance from now.
+func VerifyWebhook(secret string, header http.Header, body []byte, tolerance time.Duration) error {
+	timestamp, err := strconv.ParseInt(header.Get(HeaderWebhookTimestamp), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid %s header: %w", HeaderWebhookTimestamp, err)
+	}
+
+	if age := time.Since(time.Unix(timestamp, 0)); age > tolerance || age < -tolerance {
+		return fmt.Errorf("timestamp is %s off, more than %s", age, tolerance)
+	}
+
+	expected := SignWebhook(secret, timestamp, body)
+	if !hmac.Equal([]byte(expected), []byte(header.Get(HeaderWebhookSignature))) {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
+
+// WebhookFanout is the Publisher recording a delivery for every webhook
+// subscribed to an event. Publishing the same event again records nothing
+// new.
+type WebhookFanout struct {
+	webhookRepo repositories.WebhookRepository
+	logger      utils.Logger
+}
+
+func NewWebhookFanout(webhookRepo repositories.WebhookRepository, logger utils.Logger) *WebhookFanout {
+	return &WebhookFanout{
+		webhookRepo: webhookRepo,
+		logger:      logger,
+	}
+}
+
+func (f *WebhookFanout) Publish(ctx context.Context, event *models.OutboxEvent) error {
+	webhooks, err := f.webhookRepo.ListWebhooks(ctx)
+	if err != nil {
+		return err
+	}
+
+	var body []byte
+	for _, webhook := range webhooks {
+		if !webhook.Matches(event.EventType, event.AggregateKey) {
+			continue
+		}
+
+		if body == nil {
+			body, err = json.Marshal(models.WebhookEvent{
+				EventID:    event.ID,
+				EventType:  event.EventType,
+				NoRekening: event.AggregateKey,
+				CreatedAt:  event.CreatedAt,
+				Data:       event.Payload,
+			})
+			if err != nil {
+				return fmt.Errorf("error encoding webhook event %d: %w", event.ID, err)
+			}
+		}
+
+		err := f.webhookRepo.CreateDelivery(ctx, &models.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			EventID:       event.ID,
+			EventType:     event.EventType,
+			Body:          body,
+			NextAttemptAt: time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DispatcherOptions tune the webhook dispatcher.
+type DispatcherOptions struct {
+	PollInterval time.Duration
+	BatchSize    int
+	Timeout      time.Duration
+	// MaxAttempts is how many POSTs are tried before a delivery fails.
+	MaxAttempts int
+	// Backoff is the wait after the first failed POST, doubling up to
+	// MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// WebhookDispatcher POSTs pending deliveries to their webhooks, signing each
+// attempt, and logs every attempt. A delivery is done on a 2xx response and
+// retried with exponential backoff otherwise.
+type WebhookDispatcher struct {
+	txManager   repositories.TxManager
+	webhookRepo repositories.WebhookRepository
+	client      *http.Client
+	options     DispatcherOptions
+	logger      utils.Logger
+}
+
+func NewWebhookDispatcher(txManager repositories.TxManager, webhookRepo repositories.WebhookRepository, options DispatcherOptions, logger utils.Logger) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		txManager:   txManager,
+		webhookRepo: webhookRepo,
+		client:      &http.Client{Timeout: options.Timeout},
+		options:     options,
+		logger:      logger,
+	}
+}
+
+// Run dispatches deliveries until ctx is done.
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	d.logger.Info("Webhook dispatcher started")
+
+	ticker := time.NewTicker(d.options.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.Drain(ctx); err != nil && ctx.Err() == nil {
+			d.logger.Error("Error dispatching webhooks: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			d.logger.Info("Webhook dispatcher stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Drain attempts every due delivery until none are left and returns how many
+// were delivered.
+func (d *WebhookDispatcher) Drain(ctx context.Context) (int, error) {
+	delivered := 0
+	for {
+		deliveries, err := d.webhookRepo.ListDueDeliveries(ctx, time.Now(), d.options.BatchSize)
+		if err != nil || len(deliveries) == 0 {
+			return delivered, err
+		}
+
+		for i := range deliveries {
+			if ctx.Err() != nil {
+				return delivered, ctx.Err()
+			}
+
+			ok, err := d.attempt(ctx, &deliveries[i])
+			if err != nil {
+				return delivered, err
+			}
+			if ok {
+				delivered++
+			}
+		}
+	}
+}
+
+// attempt POSTs a delivery once and records the outcome.
+func (d *WebhookDispatcher) attempt(ctx context.Context, delivery *models.WebhookDelivery) (bool, error) {
+	webhook, err := d.webhookRepo.GetWebhook(ctx, delivery.WebhookID)
+	if err != nil {
+		return false, err
+	}
+
+	attempt := &models.WebhookAttempt{DeliveryID: delivery.ID}
+	if webhook == nil || !webhook.Active {
+		attempt.Error = "webhook is deleted or inactive"
+		return false, d.record(ctx, attempt, models.WebhookDeliveryFailed, time.Now())
+	}
+
+	started := time.Now()
+	attempt.StatusCode, err = d.post(ctx, webhook, delivery)
+	attempt.DurationMs = time.Since(started).Milliseconds()
+
+	if err == nil {
+		return true, d.record(ctx, attempt, models.WebhookDeliveryDelivered, time.Now())
+	}
+
+	attempt.Error = err.Error()
+	attempts := delivery.Attempts + 1
+	if attempts >= d.options.MaxAttempts {
+		d.logger.Error("Webhook delivery %d to %s failed after %d attempts: %v", delivery.ID, webhook.URL, attempts, err)
+		return false, d.record(ctx, attempt, models.WebhookDeliveryFailed, time.Now())
+	}
+
+	d.logger.Warning("Webhook delivery %d to %s attempt %d failed, retrying: %v", delivery.ID, webhook.URL, attempts, err)
+	next := time.Now().Add(backoff(d.options.Backoff, d.options.MaxBackoff, attempts))
+	return false, d.record(ctx, attempt, models.WebhookDeliveryPending, next)
+}
+
+func (d *WebhookDispatcher) record(ctx context.Context, attempt *models.WebhookAttempt, status string, next time.Time) error {
+	return d.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		return d.webhookRepo.RecordAttempt(ctx, attempt, status, next)
+	})
+}
+
+// post sends the signed delivery and returns the response status.
+func (d *WebhookDispatcher) post(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Body))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "accounts-service-webhooks")
+	req.Header.Set(HeaderWebhookID, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(HeaderWebhookEvent, delivery.EventType)
+	req.Header.Set(HeaderWebhookTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderWebhookSignature, SignWebhook(webhook.Secret, timestamp, delivery.Body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+		return resp.StatusCode, fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(snippet)))
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	return resp.StatusCode, nil
+}
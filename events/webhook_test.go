@@ -0,0 +1,204 @@
+package events_test
+
+import (
+	"accounts-service/events"
+	"accounts-service/models"
+	"accounts-service/repositories"
+	"accounts-service/usecases"
+	"accounts-service/utils"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// receiver is a partner endpoint verifying signatures, answering with the
+// queued statuses and 200 once they run out.
+type receiver struct {
+	t      *testing.T
+	secret string
+
+	mu       sync.Mutex
+	statuses []int
+	received []models.WebhookEvent
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(req.Body)
+	require.NoError(r.t, err)
+	assert.NoError(r.t, events.VerifyWebhook(r.secret, req.Header, body, time.Minute))
+	assert.Equal(r.t, models.EventMutationCreated, req.Header.Get(events.HeaderWebhookEvent))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.statuses) > 0 {
+		status := r.statuses[0]
+		r.statuses = r.statuses[1:]
+		w.WriteHeader(status)
+		return
+	}
+
+	var event models.WebhookEvent
+	require.NoError(r.t, json.Unmarshal(body, &event))
+	r.received = append(r.received, event)
+}
+
+func TestWebhooks(t *testing.T) {
+	logger := utils.NewLogger("critical")
+	ctx := context.Background()
+	secret := "0123456789abcdef0123456789abcdef"
+
+	options := events.DispatcherOptions{
+		PollInterval: time.Millisecond,
+		BatchSize:    10,
+		Timeout:      time.Second,
+		MaxAttempts:  3,
+		Backoff:      time.Millisecond,
+		MaxBackoff:   time.Millisecond,
+	}
+
+	type env struct {
+		receiver   *receiver
+		fanout     *events.WebhookFanout
+		dispatcher *events.WebhookDispatcher
+		usecase    usecases.WebhookUsecase
+		webhookID  uint
+	}
+
+	setup := func(t *testing.T, noRekening string, statuses ...int) env {
+		store := repositories.NewMemoryStore()
+		webhookRepo := repositories.NewMemoryWebhookRepository(store, logger)
+		usecase := usecases.NewWebhookUsecase(webhookRepo, logger)
+
+		rcv := &receiver{t: t, secret: secret, statuses: statuses}
+		server := httptest.NewServer(rcv)
+		t.Cleanup(server.Close)
+
+		webhook, err := usecase.CreateWebhook(ctx, &models.CreateWebhookRequest{
+			URL:        server.URL,
+			EventTypes: []string{models.EventMutationCreated},
+			NoRekening: noRekening,
+			Secret:     secret,
+		})
+		require.NoError(t, err)
+
+		return env{
+			receiver:   rcv,
+			fanout:     events.NewWebhookFanout(webhookRepo, logger),
+			dispatcher: events.NewWebhookDispatcher(repositories.NewMemoryTxManager(store, logger), webhookRepo, options, logger),
+			usecase:    usecase,
+			webhookID:  webhook.ID,
+		}
+	}
+
+	event := func(id uint, noRekening string) *models.OutboxEvent {
+		return &models.OutboxEvent{
+			ID:           id,
+			AggregateKey: noRekening,
+			EventType:    models.EventMutationCreated,
+			Payload:      json.RawMessage(`{"type":"credit/tabung","nominal":150000}`),
+			CreatedAt:    time.Now(),
+		}
+	}
+
+	t.Run("delivers signed events for the subscribed account", func(t *testing.T) {
+		e := setup(t, "1744847261")
+
+		require.NoError(t, e.fanout.Publish(ctx, event(1, "1744847261")))
+		require.NoError(t, e.fanout.Publish(ctx, event(1, "1744847261")))
+		require.NoError(t, e.fanout.Publish(ctx, event(2, "1000000001")))
+
+		delivered, err := e.dispatcher.Drain(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, delivered, "duplicate and other account must not be delivered")
+
+		require.Len(t, e.receiver.received, 1)
+		assert.Equal(t, uint(1), e.receiver.received[0].EventID)
+		assert.Equal(t, "1744847261", e.receiver.received[0].NoRekening)
+		assert.JSONEq(t, `{"type":"credit/tabung","nominal":150000}`, string(e.receiver.received[0].Data))
+	})
+
+	t.Run("retries with backoff and logs every attempt", func(t *testing.T) {
+		e := setup(t, "", http.StatusInternalServerError, http.StatusBadGateway)
+		require.NoError(t, e.fanout.Publish(ctx, event(1, "1744847261")))
+
+		require.Eventually(t, func() bool {
+			_, err := e.dispatcher.Drain(ctx)
+			require.NoError(t, err)
+			return len(e.receiver.received) == 1
+		}, time.Second, 2*time.Millisecond)
+
+		deliveries, err := e.usecase.ListDeliveries(ctx, &models.WebhookDeliveriesRequest{ID: e.webhookID})
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+
+		delivery, err := e.usecase.GetDelivery(ctx, e.webhookID, deliveries[0].ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.WebhookDeliveryDelivered, delivery.Status)
+		assert.Equal(t, 3, delivery.Attempts)
+		require.Len(t, delivery.Log, 3)
+		assert.Equal(t, http.StatusInternalServerError, delivery.Log[0].StatusCode)
+		assert.Equal(t, http.StatusBadGateway, delivery.Log[1].StatusCode)
+		assert.Equal(t, http.StatusOK, delivery.Log[2].StatusCode)
+	})
+
+	t.Run("fails after max attempts and can be redelivered", func(t *testing.T) {
+		e := setup(t, "", http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError)
+		require.NoError(t, e.fanout.Publish(ctx, event(1, "1744847261")))
+
+		require.Eventually(t, func() bool {
+			_, err := e.dispatcher.Drain(ctx)
+			require.NoError(t, err)
+			failed, err := e.usecase.ListDeliveries(ctx, &models.WebhookDeliveriesRequest{ID: e.webhookID, Status: models.WebhookDeliveryFailed})
+			require.NoError(t, err)
+			return len(failed) == 1
+		}, time.Second, 2*time.Millisecond)
+		assert.Empty(t, e.receiver.received)
+
+		deliveries, err := e.usecase.ListDeliveries(ctx, &models.WebhookDeliveriesRequest{ID: e.webhookID})
+		require.NoError(t, err)
+		redelivered, err := e.usecase.Redeliver(ctx, e.webhookID, deliveries[0].ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.WebhookDeliveryPending, redelivered.Status)
+
+		delivered, err := e.dispatcher.Drain(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, delivered)
+		assert.Len(t, e.receiver.received, 1)
+	})
+
+	t.Run("unknown delivery", func(t *testing.T) {
+		e := setup(t, "")
+
+		_, err := e.usecase.Redeliver(ctx, e.webhookID, 99)
+		assert.ErrorIs(t, err, models.WebhookDeliveryNotFoundErr)
+
+		_, err = e.usecase.GetWebhook(ctx, e.webhookID+1)
+		assert.ErrorIs(t, err, models.WebhookNotFoundErr)
+	})
+
+	t.Run("invalid signature is rejected", func(t *testing.T) {
+		header := http.Header{}
+		body := []byte(`{"event_id":1}`)
+		timestamp := time.Now().Unix()
+		header.Set(events.HeaderWebhookTimestamp, strconv.FormatInt(timestamp-3600, 10))
+		header.Set(events.HeaderWebhookSignature, events.SignWebhook(secret, timestamp-3600, body))
+		assert.Error(t, events.VerifyWebhook(secret, header, body, time.Minute), "stale timestamp")
+
+		header.Set(events.HeaderWebhookTimestamp, strconv.FormatInt(timestamp, 10))
+		header.Set(events.HeaderWebhookSignature, events.SignWebhook("another secret", timestamp, body))
+		assert.Error(t, events.VerifyWebhook(secret, header, body, time.Minute))
+
+		header.Set(events.HeaderWebhookSignature, events.SignWebhook(secret, timestamp, body))
+		assert.NoError(t, events.VerifyWebhook(secret, header, body, time.Minute))
+	})
+}
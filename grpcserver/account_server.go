@@ -0,0 +1,149 @@
+package grpcserver
+
+import (
+	"accounts-service/models"
+	"accounts-service/usecases"
+	"accounts-service/utils"
+	"context"
+	"strconv"
+
+	accountsv1 "accounts-service/proto/accounts/v1"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// maxPageSize is the largest page_size served, larger sizes are lowered to it.
+const maxPageSize = 200
+
+// AccountServer implements accounts.v1.AccountService on the same usecase
+// and request validation as the REST handlers.
+type AccountServer struct {
+	accountsv1.UnimplementedAccountServiceServer
+
+	accountUsecase usecases.AccountUsecase
+	validator      *utils.RequestValidator
+	logger         utils.Logger
+}
+
+func NewAccountServer(accountUsecase usecases.AccountUsecase, logger utils.Logger) *AccountServer {
+	return &AccountServer{
+		accountUsecase: accountUsecase,
+		validator:      utils.NewRequestValidator(models.RequestValidationRemarks),
+		logger:         logger,
+	}
+}
+
+func (s *AccountServer) CreateAccount(ctx context.Context, in *accountsv1.CreateAccountRequest) (*accountsv1.Account, error) {
+	req := models.CreateAccountRequest{
+		Name: in.GetName(),
+		NIK:  in.GetNik(),
+		NoHP: in.GetNoHp(),
+	}
+	if err := s.validator.Validate(&req); err != nil {
+		return nil, err
+	}
+
+	account, err := s.accountUsecase.CreateAccount(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &accountsv1.Account{
+		Id:         uint64(account.ID),
+		Name:       account.Name,
+		Nik:        account.NIK,
+		NoHp:       account.NoHP,
+		NoRekening: account.NoRekening,
+		Saldo:      account.Saldo,
+		CreatedAt:  timestamppb.New(account.CreatedAt),
+		UpdatedAt:  timestamppb.New(account.UpdatedAt),
+	}, nil
+}
+
+func (s *AccountServer) GetSaldo(ctx context.Context, in *accountsv1.GetSaldoRequest) (*accountsv1.Saldo, error) {
+	req := models.SaldoRequest{NoRekening: in.GetNoRekening()}
+	if err := s.validator.Validate(&req); err != nil {
+		return nil, err
+	}
+
+	saldo, err := s.accountUsecase.GetSaldo(ctx, req.NoRekening)
+	if err != nil {
+		return nil, err
+	}
+
+	return &accountsv1.Saldo{NoRekening: saldo.NoRekening, Saldo: saldo.Saldo}, nil
+}
+
+func (s *AccountServer) Credit(ctx context.Context, in *accountsv1.TransactionRequest) (*accountsv1.TransactionResponse, error) {
+	req := transactionRequest(in)
+	if err := s.validator.Validate(req); err != nil {
+		return nil, err
+	}
+
+	if err := s.accountUsecase.Credit(ctx, req); err != nil {
+		return nil, err
+	}
+
+	return &accountsv1.TransactionResponse{}, nil
+}
+
+func (s *AccountServer) Debit(ctx context.Context, in *accountsv1.TransactionRequest) (*accountsv1.TransactionResponse, error) {
+	req := transactionRequest(in)
+	if err := s.validator.Validate(req); err != nil {
+		return nil, err
+	}
+
+	if err := s.accountUsecase.Debit(ctx, req); err != nil {
+		return nil, err
+	}
+
+	return &accountsv1.TransactionResponse{}, nil
+}
+
+func (s *AccountServer) ListMutations(ctx context.Context, in *accountsv1.ListMutationsRequest) (*accountsv1.ListMutationsResponse, error) {
+	req := models.ListMutationsRequest{
+		NoRekening: in.GetNoRekening(),
+		Limit:      int(min(max(in.GetPageSize(), 0), maxPageSize)),
+	}
+	if token := in.GetPageToken(); token != "" {
+		beforeID, err := strconv.ParseUint(token, 10, 0)
+		if err != nil || beforeID == 0 {
+			return nil, models.MutationPageTokenInvalidErr
+		}
+		req.BeforeID = uint(beforeID)
+	}
+	if err := s.validator.Validate(&req); err != nil {
+		return nil, err
+	}
+
+	page, err := s.accountUsecase.ListMutations(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &accountsv1.ListMutationsResponse{
+		Mutations: make([]*accountsv1.Mutation, len(page.Mutations)),
+	}
+	for i, mutation := range page.Mutations {
+		resp.Mutations[i] = &accountsv1.Mutation{
+			Id:        uint64(mutation.ID),
+			Type:      mutation.Type,
+			Nominal:   mutation.Nominal,
+			Reference: mutation.Reference,
+			CreatedAt: timestamppb.New(mutation.CreatedAt),
+		}
+	}
+	if page.NextBeforeID > 0 {
+		resp.NextPageToken = strconv.FormatUint(uint64(page.NextBeforeID), 10)
+	}
+
+	return resp, nil
+}
+
+func transactionRequest(in *accountsv1.TransactionRequest) *models.TransactionRequest {
+	return &models.TransactionRequest{
+		NoRekening: in.GetNoRekening(),
+		Nominal:    in.GetNominal(),
+		Reference:  in.GetReference(),
+	}
+}
@@ -0,0 +1,100 @@
+package grpcserver
+
+import (
+	"accounts-service/models"
+	"accounts-service/utils"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorDomain is the domain of the google.rpc.ErrorInfo of every error.
+const ErrorDomain = "accounts-service"
+
+// codesByHTTPStatus maps the HTTP status of a Remark to its gRPC code.
+var codesByHTTPStatus = map[int]codes.Code{
+	http.StatusBadRequest:          codes.InvalidArgument,
+	http.StatusUnauthorized:        codes.Unauthenticated,
+	http.StatusForbidden:           codes.PermissionDenied,
+	http.StatusNotFound:            codes.NotFound,
+	http.StatusConflict:            codes.AlreadyExists,
+	http.StatusUnprocessableEntity: codes.FailedPrecondition,
+	http.StatusTooManyRequests:     codes.ResourceExhausted,
+	http.StatusServiceUnavailable:  codes.Unavailable,
+	http.StatusGatewayTimeout:      codes.DeadlineExceeded,
+	http.StatusInternalServerError: codes.Internal,
+}
+
+// toStatus maps err to a gRPC status. The status message is the English
+// Remark message, and the details carry the Remark code as an ErrorInfo
+// reason, the message in lang and, for validation failures, every field.
+func toStatus(err error, lang string) *status.Status {
+	remark, ok := utils.AsRemark(err)
+	if !ok {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return status.New(codes.Canceled, err.Error())
+		case errors.Is(err, context.DeadlineExceeded):
+			remark = models.QueryTimeoutErr
+		default:
+			remark = models.InternalServerErr
+		}
+	}
+
+	code, ok := codesByHTTPStatus[remark.HTTPStatus()]
+	if !ok {
+		code = codes.Unknown
+	}
+
+	english := models.Messages.Localize(remark, utils.LangEN)
+	localized := models.Messages.Localize(remark, lang)
+
+	info := &errdetails.ErrorInfo{
+		Reason:   remark.Remark.Code,
+		Domain:   ErrorDomain,
+		Metadata: make(map[string]string),
+	}
+	if remark.Remark.Field != "" {
+		info.Metadata["field"] = remark.Remark.Field
+	}
+	for key, value := range remark.Params() {
+		info.Metadata[key] = fmt.Sprint(value)
+	}
+
+	st := status.New(code, english.Remark.Message)
+	withDetails, err := st.WithDetails(info, &errdetails.LocalizedMessage{
+		Locale:  lang,
+		Message: localized.Remark.Message,
+	})
+	if err != nil {
+		return st
+	}
+
+	if fields, ok := localized.Remark.Object.([]*utils.Remark); ok {
+		badRequest := &errdetails.BadRequest{}
+		for _, field := range fields {
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       field.Remark.Field,
+				Description: field.Remark.Message,
+			})
+		}
+		if withFields, err := withDetails.WithDetails(badRequest); err == nil {
+			withDetails = withFields
+		}
+	}
+
+	return withDetails
+}
+
+// requestLanguage picks the language of the accept-language metadata.
+func requestLanguage(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return utils.ParseAcceptLanguage(strings.Join(md.Get("accept-language"), ","))
+}
@@ -0,0 +1,102 @@
+package grpcserver
+
+import (
+	"accounts-service/models"
+	"accounts-service/usecases"
+	"accounts-service/utils"
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	accountsv1 "accounts-service/proto/accounts/v1"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// Options configure the gRPC server.
+type Options struct {
+	// QueryTimeout bounds every call like DB_QUERY_TIMEOUT bounds REST
+	// requests. 0 disables it.
+	QueryTimeout time.Duration
+}
+
+// NewServer returns the gRPC server with the account service, the standard
+// health service and server reflection registered.
+func NewServer(accountUsecase usecases.AccountUsecase, options Options, logger utils.Logger) *grpc.Server {
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		errorInterceptor(logger),
+		recoveryInterceptor(logger),
+		timeoutInterceptor(options.QueryTimeout),
+	))
+
+	accountsv1.RegisterAccountServiceServer(server, NewAccountServer(accountUsecase, logger))
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus(accountsv1.AccountService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	reflection.Register(server)
+
+	return server
+}
+
+// errorInterceptor logs failed calls and answers their errors as statuses
+// with details, in the language of the accept-language metadata. Internal
+// causes are logged and never sent to clients.
+func errorInterceptor(logger utils.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		if err == nil {
+			return resp, nil
+		}
+
+		st := toStatus(err, requestLanguage(ctx))
+		switch st.Code() {
+		case codes.Internal, codes.Unknown, codes.Unavailable, codes.DeadlineExceeded:
+			logger.Error("%s: %v", info.FullMethod, err)
+		default:
+			logger.Warning("%s: %v", info.FullMethod, err)
+		}
+
+		return nil, st.Err()
+	}
+}
+
+// recoveryInterceptor turns a panic of a call into an internal error.
+func recoveryInterceptor(logger utils.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.Error("%s panic: %v\n%s", info.FullMethod, r, debug.Stack())
+				err = models.InternalServerErr.Wrap(fmt.Errorf("panic: %v", r))
+			}
+		}()
+
+		return handler(ctx, req)
+	}
+}
+
+// timeoutInterceptor bounds every call by timeout, answering calls running
+// out of it with QueryTimeoutErr.
+func timeoutInterceptor(timeout time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if timeout <= 0 {
+			return handler(ctx, req)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		resp, err := handler(ctx, req)
+		if err != nil && (errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded)) {
+			return nil, models.QueryTimeoutErr.Wrap(err)
+		}
+		return resp, err
+	}
+}
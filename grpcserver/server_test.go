@@ -0,0 +1,188 @@
+package grpcserver_test
+
+import (
+	"accounts-service/grpcserver"
+	"accounts-service/models"
+	"accounts-service/repositories"
+	"accounts-service/usecases"
+	"accounts-service/utils"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	accountsv1 "accounts-service/proto/accounts/v1"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newClient(t *testing.T) accountsv1.AccountServiceClient {
+	conn := dial(t, grpcserver.Options{QueryTimeout: time.Second})
+	return accountsv1.NewAccountServiceClient(conn)
+}
+
+func dial(t *testing.T, options grpcserver.Options) *grpc.ClientConn {
+	logger := utils.NewLogger("critical")
+	store := repositories.NewMemoryStore()
+	accountUsecase := usecases.NewAccountUsecase(
+		repositories.NewMemoryTxManager(store, logger),
+		repositories.NewMemoryAccountRepository(store, logger),
+		repositories.NewMemoryMutationRepository(store, logger),
+		repositories.NewMemoryOutboxRepository(store, logger),
+		repositories.NewNoopBalanceCache(),
+		logger,
+	)
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpcserver.NewServer(accountUsecase, options, logger)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// details splits the details of a status error by type.
+func details(t *testing.T, err error) (*status.Status, *errdetails.ErrorInfo, *errdetails.LocalizedMessage, *errdetails.BadRequest) {
+	st, ok := status.FromError(err)
+	require.True(t, ok, "not a status error: %v", err)
+
+	var (
+		info      *errdetails.ErrorInfo
+		localized *errdetails.LocalizedMessage
+		fields    *errdetails.BadRequest
+	)
+	for _, detail := range st.Details() {
+		switch d := detail.(type) {
+		case *errdetails.ErrorInfo:
+			info = d
+		case *errdetails.LocalizedMessage:
+			localized = d
+		case *errdetails.BadRequest:
+			fields = d
+		}
+	}
+	require.NotNil(t, info)
+	require.NotNil(t, localized)
+	return st, info, localized, fields
+}
+
+func TestAccountServer(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("accounts, postings and mutation pages", func(t *testing.T) {
+		client := newClient(t)
+
+		account, err := client.CreateAccount(ctx, &accountsv1.CreateAccountRequest{Name: " Siti Aminah ", Nik: "3201014508950001", NoHp: "081234567890"})
+		require.NoError(t, err)
+		assert.NotZero(t, account.GetId())
+		assert.Equal(t, "Siti Aminah", account.GetName())
+		assert.Equal(t, "+6281234567890", account.GetNoHp())
+		assert.NotEmpty(t, account.GetNoRekening())
+		assert.False(t, account.GetCreatedAt().AsTime().IsZero())
+
+		noRekening := account.GetNoRekening()
+		_, err = client.Credit(ctx, &accountsv1.TransactionRequest{NoRekening: noRekening, Nominal: 100000})
+		require.NoError(t, err)
+		_, err = client.Debit(ctx, &accountsv1.TransactionRequest{NoRekening: noRekening, Nominal: 40000, Reference: "ATM-001"})
+		require.NoError(t, err)
+		_, err = client.Credit(ctx, &accountsv1.TransactionRequest{NoRekening: noRekening, Nominal: 5000})
+		require.NoError(t, err)
+
+		saldo, err := client.GetSaldo(ctx, &accountsv1.GetSaldoRequest{NoRekening: noRekening})
+		require.NoError(t, err)
+		assert.Equal(t, float64(65000), saldo.GetSaldo())
+
+		page, err := client.ListMutations(ctx, &accountsv1.ListMutationsRequest{NoRekening: noRekening, PageSize: 2})
+		require.NoError(t, err)
+		require.Len(t, page.GetMutations(), 2)
+		assert.Equal(t, models.MutationTypeCredit, page.GetMutations()[0].GetType())
+		assert.Equal(t, "ATM-001", page.GetMutations()[1].GetReference())
+		require.NotEmpty(t, page.GetNextPageToken())
+
+		page, err = client.ListMutations(ctx, &accountsv1.ListMutationsRequest{NoRekening: noRekening, PageSize: 2, PageToken: page.GetNextPageToken()})
+		require.NoError(t, err)
+		require.Len(t, page.GetMutations(), 1)
+		assert.Equal(t, float64(100000), page.GetMutations()[0].GetNominal())
+		assert.Empty(t, page.GetNextPageToken())
+
+		page, err = client.ListMutations(ctx, &accountsv1.ListMutationsRequest{NoRekening: noRekening, PageSize: 1000})
+		require.NoError(t, err, "page size above the maximum is lowered")
+		assert.Len(t, page.GetMutations(), 3)
+	})
+
+	t.Run("remarks map to status codes with details", func(t *testing.T) {
+		client := newClient(t)
+
+		account, err := client.CreateAccount(ctx, &accountsv1.CreateAccountRequest{Name: "Siti", Nik: "3201014508950001", NoHp: "+6281234567890"})
+		require.NoError(t, err)
+
+		_, err = client.CreateAccount(ctx, &accountsv1.CreateAccountRequest{Name: "Budi", Nik: "3201014508950001", NoHp: "+6281200000000"})
+		st, info, _, _ := details(t, err)
+		assert.Equal(t, codes.AlreadyExists, st.Code())
+		assert.Equal(t, models.AccountWithNIKIsExist, info.GetReason())
+		assert.Equal(t, grpcserver.ErrorDomain, info.GetDomain())
+		assert.Equal(t, "nik", info.GetMetadata()["field"])
+
+		_, err = client.GetSaldo(ctx, &accountsv1.GetSaldoRequest{NoRekening: "1000000000"})
+		st, info, _, _ = details(t, err)
+		assert.Equal(t, codes.NotFound, st.Code())
+		assert.Equal(t, models.AccountWithNoRekeningNotFound, info.GetReason())
+
+		_, err = client.Debit(ctx, &accountsv1.TransactionRequest{NoRekening: account.GetNoRekening(), Nominal: 1000})
+		st, info, _, _ = details(t, err)
+		assert.Equal(t, codes.FailedPrecondition, st.Code())
+		assert.Equal(t, models.Accountinsufficient, info.GetReason())
+
+		_, err = client.ListMutations(ctx, &accountsv1.ListMutationsRequest{NoRekening: account.GetNoRekening(), PageToken: "abc"})
+		st, info, _, _ = details(t, err)
+		assert.Equal(t, codes.InvalidArgument, st.Code())
+		assert.Equal(t, models.MutationPageTokenInvalid, info.GetReason())
+	})
+
+	t.Run("validation failures list every field in the requested language", func(t *testing.T) {
+		client := newClient(t)
+
+		md := metadata.Pairs("accept-language", "en-US,en;q=0.9")
+		_, err := client.Credit(metadata.NewOutgoingContext(ctx, md), &accountsv1.TransactionRequest{NoRekening: "abc", Nominal: -1})
+		st, info, localized, fields := details(t, err)
+		assert.Equal(t, codes.InvalidArgument, st.Code())
+		assert.Equal(t, models.RequestValidationRemarks.Aggregate.Remark.Code, info.GetReason())
+		assert.Equal(t, utils.LangEN, localized.GetLocale())
+		require.NotNil(t, fields)
+
+		violations := map[string]string{}
+		for _, violation := range fields.GetFieldViolations() {
+			violations[violation.GetField()] = violation.GetDescription()
+		}
+		assert.Contains(t, violations, "no_rekening")
+		assert.Contains(t, violations, "nominal")
+
+		_, err = client.Credit(ctx, &accountsv1.TransactionRequest{NoRekening: "abc", Nominal: 1000})
+		st, _, localized, _ = details(t, err)
+		assert.Equal(t, utils.LangID, localized.GetLocale(), "Bahasa Indonesia by default")
+		assert.NotEqual(t, st.Message(), localized.GetMessage(), "the status message stays in English")
+	})
+
+	t.Run("health", func(t *testing.T) {
+		conn := dial(t, grpcserver.Options{})
+
+		resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: accountsv1.AccountService_ServiceDesc.ServiceName})
+		require.NoError(t, err)
+		assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+	})
+}
@@ -25,41 +25,34 @@ func (h *AccountHandler) CreateAccount(ctx echo.Context) error {
 	var req models.CreateAccountRequest
 
 	if err := ctx.Bind(&req); err != nil {
-		h.logger.Warning("Error param request: %v", err)
-		return ctx.JSON(http.StatusBadRequest, models.AccountInvalidRequestErr)
+		return models.AccountInvalidRequestErr.Wrap(err)
 	}
 
-	if req.NIK == "" {
-		h.logger.Warning("Error param request: %v", models.AccountNikEmptyErr)
-		return ctx.JSON(http.StatusBadRequest, models.AccountNikEmptyErr)
-	}
-
-	if req.Name == "" {
-		h.logger.Warning("Error param request: %v", models.AccountNameEmptyErr)
-		return ctx.JSON(http.StatusBadRequest, models.AccountNameEmptyErr)
-	}
-
-	if req.NoHP == "" {
-		h.logger.Warning("Error param request: %v", models.AccountNoHpEmptyErr)
-		return ctx.JSON(http.StatusBadRequest, models.AccountNoHpEmptyErr)
+	if err := ctx.Validate(&req); err != nil {
+		return err
 	}
 
 	account, err := h.accountUsecase.CreateAccount(ctx.Request().Context(), &req)
 	if err != nil {
-		h.logger.Error("Error param request: %v", err)
-		return ctx.JSON(http.StatusBadRequest, err)
+		return err
 	}
 
 	return ctx.JSON(http.StatusCreated, account)
 }
 
 func (h *AccountHandler) GetSaldo(ctx echo.Context) error {
-	noRekening := ctx.Param("no_rekening")
+	var req models.SaldoRequest
+	if err := ctx.Bind(&req); err != nil {
+		return models.RequestValidationErr.Wrap(err)
+	}
 
-	saldo, err := h.accountUsecase.GetSaldo(ctx.Request().Context(), noRekening)
+	if err := ctx.Validate(&req); err != nil {
+		return err
+	}
+
+	saldo, err := h.accountUsecase.GetSaldo(ctx.Request().Context(), req.NoRekening)
 	if err != nil {
-		h.logger.Error("Error getting saldo: %v", err)
-		return ctx.JSON(http.StatusBadRequest, err)
+		return err
 	}
 
 	return ctx.JSON(http.StatusOK, saldo)
@@ -68,24 +61,16 @@ func (h *AccountHandler) GetSaldo(ctx echo.Context) error {
 func (h *AccountHandler) Debit(ctx echo.Context) error {
 	var req models.TransactionRequest
 	if err := ctx.Bind(&req); err != nil {
-		h.logger.Warning("Error param request: %v", err)
-		return ctx.JSON(http.StatusBadRequest, models.DebitInvalidRequestErr)
-	}
-
-	if req.NoRekening == "" {
-		h.logger.Warning("Error binding credit/tabung request: %v", models.AccountParamNoRekeningEmptyErr)
-		return ctx.JSON(http.StatusBadRequest, models.AccountParamNoRekeningEmptyErr)
+		return models.DebitInvalidRequestErr.Wrap(err)
 	}
 
-	if req.Nominal <= 0 {
-		h.logger.Warning("Error binding credit/tabung request: %v", models.AccountParamNominalErr)
-		return ctx.JSON(http.StatusBadRequest, models.AccountParamNominalErr)
+	if err := ctx.Validate(&req); err != nil {
+		return err
 	}
 
 	err := h.accountUsecase.Debit(ctx.Request().Context(), &req)
 	if err != nil {
-		h.logger.Error("Error processing debit: %v", err)
-		return ctx.JSON(http.StatusBadRequest, err)
+		return err
 	}
 
 	return ctx.JSON(http.StatusOK, map[string]string{"message": "penarikan saldo successful"})
@@ -94,24 +79,16 @@ func (h *AccountHandler) Debit(ctx echo.Context) error {
 func (h *AccountHandler) Credit(ctx echo.Context) error {
 	var req models.TransactionRequest
 	if err := ctx.Bind(&req); err != nil {
-		h.logger.Warning("Error binding credit/tabung request: %v", err)
-		return ctx.JSON(http.StatusBadRequest, models.CreditInvalidRequestErr)
-	}
-
-	if req.NoRekening == "" {
-		h.logger.Error("Error param request: %v", models.AccountParamNoRekeningEmptyErr)
-		return ctx.JSON(http.StatusBadRequest, models.AccountParamNoRekeningEmptyErr)
+		return models.CreditInvalidRequestErr.Wrap(err)
 	}
 
-	if req.Nominal <= 0 {
-		h.logger.Error("Error param request: %v", models.AccountParamNominalErr)
-		return ctx.JSON(http.StatusBadRequest, models.AccountParamNominalErr)
+	if err := ctx.Validate(&req); err != nil {
+		return err
 	}
 
 	err := h.accountUsecase.Credit(ctx.Request().Context(), &req)
 	if err != nil {
-		h.logger.Error("Error processing credit/tabung: %v", err)
-		return ctx.JSON(http.StatusInternalServerError, err)
+		return err
 	}
 
 	return ctx.JSON(http.StatusOK, map[string]string{"message": "menabung successful"})
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"accounts-service/models"
+	"accounts-service/usecases"
+	"accounts-service/utils"
+	"encoding/csv"
+	"errors"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// MIMETextCSV is the content type of a batch sent as a CSV file.
+const MIMETextCSV = "text/csv"
+
+type BulkCreditHandler struct {
+	bulkCreditUsecase usecases.BulkCreditUsecase
+	logger            utils.Logger
+}
+
+func NewBulkCreditHandler(bulkCreditUsecase usecases.BulkCreditUsecase, logger utils.Logger) *BulkCreditHandler {
+	return &BulkCreditHandler{
+		bulkCreditUsecase: bulkCreditUsecase,
+		logger:            logger,
+	}
+}
+
+// SubmitBatch takes a batch as JSON, or as a CSV file with the batch fields
+// in the query, and answers with the report of every row. A per_row batch is
+// answered 202 while its rows are credited, its report is then polled at the
+// Location.
+func (h *BulkCreditHandler) SubmitBatch(ctx echo.Context) error {
+	var req models.BulkCreditRequest
+
+	mediaType, _, _ := mime.ParseMediaType(ctx.Request().Header.Get(echo.HeaderContentType))
+	if mediaType == MIMETextCSV {
+		if err := (&echo.DefaultBinder{}).BindQueryParams(ctx, &req); err != nil {
+			return models.BulkCreditInvalidRequestErr.Wrap(err)
+		}
+
+		rows, err := parseBulkCreditCSV(ctx.Request().Body)
+		if err != nil {
+			return err
+		}
+		req.Rows = rows
+	} else if err := ctx.Bind(&req); err != nil {
+		return models.BulkCreditInvalidRequestErr.Wrap(err)
+	}
+
+	if err := ctx.Validate(&req); err != nil {
+		return err
+	}
+
+	batch, err := h.bulkCreditUsecase.SubmitBatch(ctx.Request().Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	ctx.Response().Header().Set(echo.HeaderLocation, "/api/account/bulk/credit/"+url.PathEscape(batch.Reference))
+	if batch.Status == models.BulkCreditProcessing {
+		return ctx.JSON(http.StatusAccepted, batch)
+	}
+	return ctx.JSON(http.StatusCreated, batch)
+}
+
+func (h *BulkCreditHandler) GetBatch(ctx echo.Context) error {
+	var req models.BulkCreditBatchRequest
+	if err := ctx.Bind(&req); err != nil {
+		return models.BulkCreditInvalidRequestErr.Wrap(err)
+	}
+
+	if err := ctx.Validate(&req); err != nil {
+		return err
+	}
+
+	batch, err := h.bulkCreditUsecase.GetBatch(ctx.Request().Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, batch)
+}
+
+// parseBulkCreditCSV reads the rows of a batch from a CSV file with the
+// header no_rekening,nominal and an optional reference column. Errors give
+// the line of the file, counting the header, as an editor shows it.
+func parseBulkCreditCSV(r io.Reader) ([]models.BulkCreditRowRequest, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	invalid := func(line int, err error) error {
+		return models.BulkCreditFileInvalidErr.WithParams(map[string]interface{}{"line": line}).Wrap(err)
+	}
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, invalid(1, err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, seen := columns[name]; seen || (name != "no_rekening" && name != "nominal" && name != "reference") {
+			return nil, invalid(1, errors.New("unexpected column "+strconv.Quote(name)))
+		}
+		columns[name] = i
+	}
+	noRekening, hasNoRekening := columns["no_rekening"]
+	nominal, hasNominal := columns["nominal"]
+	reference, hasReference := columns["reference"]
+	if !hasNoRekening || !hasNominal {
+		return nil, invalid(1, errors.New("missing no_rekening or nominal column"))
+	}
+
+	rows := []models.BulkCreditRowRequest{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				return nil, invalid(parseErr.StartLine, err)
+			}
+			return nil, models.BulkCreditInvalidRequestErr.Wrap(err)
+		}
+		line, _ := reader.FieldPos(0)
+		if len(record) != len(header) {
+			return nil, invalid(line, csv.ErrFieldCount)
+		}
+
+		amount, err := strconv.ParseFloat(strings.TrimSpace(record[nominal]), 64)
+		if err != nil || math.IsNaN(amount) || math.IsInf(amount, 0) {
+			return nil, invalid(line, errors.New("nominal is not a number"))
+		}
+
+		row := models.BulkCreditRowRequest{NoRekening: record[noRekening], Nominal: amount}
+		if hasReference {
+			row.Reference = record[reference]
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
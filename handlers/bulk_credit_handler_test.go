@@ -0,0 +1,89 @@
+package handlers_test
+
+import (
+	"accounts-service/handlers"
+	"accounts-service/models"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulkCreditHandler_CSV(t *testing.T) {
+	e := newAPI(t)
+	ctx := context.Background()
+
+	source := &models.Account{Name: "PT Maju Jaya", NIK: "3201014508950001", NoHP: "+6281234567890", NoRekening: "1744847261"}
+	require.NoError(t, e.accountRepo.CreateAccount(ctx, source))
+	require.NoError(t, e.accountRepo.UpdateSaldo(ctx, source.ID, 1000000))
+	require.NoError(t, e.accountRepo.CreateAccount(ctx, &models.Account{Name: "Budi Santoso", NIK: "3201014508950002", NoHP: "+6281234567891", NoRekening: "1744847262"}))
+
+	post := func(reference, csv string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/account/bulk/credit?mode=per_row&source_no_rekening="+source.NoRekening+"&batch_reference="+reference, strings.NewReader(csv))
+		req.Header.Set(echo.HeaderContentType, handlers.MIMETextCSV+"; charset=utf-8")
+		req.Header.Set("Accept-Language", "en")
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// report polls the batch at location until it is processed.
+	report := func(t *testing.T, location string) models.BulkCreditBatch {
+		t.Helper()
+		var batch models.BulkCreditBatch
+		require.Eventually(t, func() bool {
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, location, nil))
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &batch))
+			return batch.Status != models.BulkCreditProcessing
+		}, 5*time.Second, 10*time.Millisecond)
+		return batch
+	}
+
+	t.Run("columns are found by the header", func(t *testing.T) {
+		rec := post("PAYROLL-1", "\ufeffreference, nominal,no_rekening\r\nGAJI-04,1500.50,1744847262\n\"GAJI, BONUS\",100,1744847262\n")
+		require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+		assert.Equal(t, "/api/account/bulk/credit/PAYROLL-1", rec.Header().Get(echo.HeaderLocation))
+		assert.Contains(t, rec.Body.String(), `"status":"processing"`)
+
+		batch := report(t, rec.Header().Get(echo.HeaderLocation))
+		assert.Equal(t, models.BulkCreditCompleted, batch.Status)
+		require.Len(t, batch.Rows, 2)
+		assert.Equal(t, models.BulkCreditRow{Line: 1, NoRekening: "1744847262", Nominal: 1500.5, Reference: "GAJI-04", Status: models.BulkCreditRowCredited}, batch.Rows[0])
+		assert.Equal(t, "GAJI, BONUS", batch.Rows[1].Reference)
+	})
+
+	t.Run("reference column is optional", func(t *testing.T) {
+		rec := post("PAYROLL-2", "no_rekening,nominal\n1744847262,10\n")
+		require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+		assert.Equal(t, models.BulkCreditCompleted, report(t, rec.Header().Get(echo.HeaderLocation)).Status)
+	})
+
+	for name, test := range map[string]struct {
+		csv  string
+		line string
+	}{
+		"empty file":          {csv: "", line: "Line 1"},
+		"missing column":      {csv: "no_rekening\n1744847262\n", line: "Line 1"},
+		"unknown column":      {csv: "no_rekening,nominal,note\n1744847262,10,x\n", line: "Line 1"},
+		"nominal not numeric": {csv: "no_rekening,nominal\n1744847262,10\n1744847262,sepuluh\n", line: "Line 3"},
+		"nominal not finite":  {csv: "no_rekening,nominal\n1744847262,NaN\n", line: "Line 2"},
+		"missing field":       {csv: "no_rekening,nominal\n1744847262\n", line: "Line 2"},
+		"bare quote":          {csv: "no_rekening,nominal\n1744847262,10\n17448\"47262,10\n", line: "Line 3"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rec := post("PAYROLL-3", test.csv)
+			require.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+			assert.Contains(t, rec.Body.String(), models.BulkCreditFileInvalid)
+			assert.Contains(t, rec.Body.String(), test.line+" of the CSV file")
+		})
+	}
+}
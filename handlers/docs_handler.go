@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"accounts-service/openapi"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type DocsHandler struct{}
+
+func NewDocsHandler() *DocsHandler {
+	return &DocsHandler{}
+}
+
+// Spec serves the OpenAPI document.
+func (h *DocsHandler) Spec(ctx echo.Context) error {
+	return ctx.Blob(http.StatusOK, echo.MIMEApplicationJSONCharsetUTF8, openapi.Spec)
+}
+
+// UI serves the API docs page rendering the OpenAPI document.
+func (h *DocsHandler) UI(ctx echo.Context) error {
+	return ctx.HTMLBlob(http.StatusOK, openapi.DocsPage)
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"accounts-service/models"
+	"accounts-service/utils"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// NewHTTPErrorHandler returns the echo error handler that maps every error
+// returned by a handler to its Remark and HTTP status. Internal causes are
+// logged and never written to the response body. Messages are translated
+// according to the Accept-Language request header.
+func NewHTTPErrorHandler(logger utils.Logger) echo.HTTPErrorHandler {
+	return func(err error, ctx echo.Context) {
+		if ctx.Response().Committed {
+			return
+		}
+
+		req := ctx.Request()
+		lang := utils.ParseAcceptLanguage(req.Header.Get("Accept-Language"))
+
+		remark := models.Messages.Localize(toRemark(err), lang)
+		status := remark.HTTPStatus()
+
+		if status >= http.StatusInternalServerError {
+			logger.Error("%s %s: %v", req.Method, req.URL.Path, err)
+		} else {
+			logger.Warning("%s %s: %v", req.Method, req.URL.Path, err)
+		}
+
+		ctx.Response().Header().Set("Content-Language", lang)
+		ctx.Response().Header().Add(echo.HeaderVary, "Accept-Language")
+
+		if req.Method == http.MethodHead {
+			err = ctx.NoContent(status)
+		} else {
+			err = ctx.JSON(status, remark)
+		}
+		if err != nil {
+			logger.Error("Error writing error response: %v", err)
+		}
+	}
+}
+
+func toRemark(err error) *utils.Remark {
+	if remark, ok := utils.AsRemark(err); ok {
+		return remark
+	}
+
+	var he *echo.HTTPError
+	if errors.As(err, &he) {
+		if he.Code == http.StatusNotFound {
+			return models.RouteNotFoundErr
+		}
+		return utils.NewRemark(he.Code, http.StatusText(he.Code), models.HTTPRequestError, "", nil)
+	}
+
+	return models.InternalServerErr
+}
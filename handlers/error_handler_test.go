@@ -0,0 +1,59 @@
+package handlers_test
+
+import (
+	"accounts-service/handlers"
+	"accounts-service/models"
+	"accounts-service/utils"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPErrorHandler(t *testing.T) {
+	logger := utils.NewLogger("error")
+	e := echo.New()
+	handle := handlers.NewHTTPErrorHandler(logger)
+
+	serve := func(err error) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/account/tabung", nil)
+		rec := httptest.NewRecorder()
+		handle(err, e.NewContext(req, rec))
+		return rec
+	}
+
+	t.Run("remark uses its own status", func(t *testing.T) {
+		rec := serve(models.AccountWithNoRekeningNotFoundErr)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Contains(t, rec.Body.String(), models.AccountWithNoRekeningNotFound)
+	})
+
+	t.Run("insufficient saldo has its own code", func(t *testing.T) {
+		rec := serve(models.AccountinsufficientErr)
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Contains(t, rec.Body.String(), models.Accountinsufficient)
+	})
+
+	t.Run("internal cause is not serialized", func(t *testing.T) {
+		rec := serve(models.UpdateSaldoErr.Wrap(errors.New(`pq: relation "accounts" does not exist`)))
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.Contains(t, rec.Body.String(), models.UpdateSaldoError)
+		assert.NotContains(t, rec.Body.String(), "pq:")
+	})
+
+	t.Run("unknown error is internal server error", func(t *testing.T) {
+		rec := serve(errors.New("boom"))
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.Contains(t, rec.Body.String(), models.InternalServerError)
+		assert.NotContains(t, rec.Body.String(), "boom")
+	})
+
+	t.Run("echo http error keeps its status", func(t *testing.T) {
+		rec := serve(echo.ErrMethodNotAllowed)
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+		assert.Contains(t, rec.Body.String(), models.HTTPRequestError)
+	})
+}
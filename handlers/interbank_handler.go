@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"accounts-service/models"
+	"accounts-service/usecases"
+	"accounts-service/utils"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type InterbankHandler struct {
+	interbankUsecase usecases.InterbankUsecase
+	logger           utils.Logger
+}
+
+func NewInterbankHandler(interbankUsecase usecases.InterbankUsecase, logger utils.Logger) *InterbankHandler {
+	return &InterbankHandler{
+		interbankUsecase: interbankUsecase,
+		logger:           logger,
+	}
+}
+
+// Order debits the account of the path and queues a transfer to another
+// bank for the next clearing batch.
+func (h *InterbankHandler) Order(ctx echo.Context) error {
+	var req models.InterbankTransferRequest
+	if err := ctx.Bind(&req); err != nil {
+		return models.InterbankInvalidRequestErr.Wrap(err)
+	}
+
+	if err := ctx.Validate(&req); err != nil {
+		return err
+	}
+
+	transfer, err := h.interbankUsecase.Order(ctx.Request().Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusCreated, transfer)
+}
+
+// GetTransfer returns the interbank transfer of the path with its clearing
+// status.
+func (h *InterbankHandler) GetTransfer(ctx echo.Context) error {
+	var req models.InterbankTransferLookupRequest
+	if err := ctx.Bind(&req); err != nil {
+		return models.InterbankInvalidRequestErr.Wrap(err)
+	}
+
+	if err := ctx.Validate(&req); err != nil {
+		return err
+	}
+
+	transfer, err := h.interbankUsecase.GetTransfer(ctx.Request().Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, transfer)
+}
@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"accounts-service/repositories"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type MetricsHandler struct {
+	balanceCache repositories.BalanceCache
+}
+
+func NewMetricsHandler(balanceCache repositories.BalanceCache) *MetricsHandler {
+	return &MetricsHandler{
+		balanceCache: balanceCache,
+	}
+}
+
+// BalanceCache returns the hit, miss and store counters of the balance cache.
+func (h *MetricsHandler) BalanceCache(ctx echo.Context) error {
+	return ctx.JSON(http.StatusOK, h.balanceCache.Stats())
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"accounts-service/models"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// QueryTimeout bounds the database work of a request with a deadline on its
+// context. Errors caused by the deadline are answered with QueryTimeoutErr.
+// Routes in exempt, by their path pattern, bound their work themselves.
+func QueryTimeout(timeout time.Duration, exempt []string) echo.MiddlewareFunc {
+	return middleware.ContextTimeoutWithConfig(middleware.ContextTimeoutConfig{
+		Skipper: func(ctx echo.Context) bool {
+			return slices.Contains(exempt, ctx.Path())
+		},
+		Timeout: timeout,
+		ErrorHandler: func(err error, ctx echo.Context) error {
+			if errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Request().Context().Err(), context.DeadlineExceeded) {
+				return models.QueryTimeoutErr.Wrap(err)
+			}
+			return err
+		},
+	})
+}
+
+// AdminAuth only lets through requests carrying the admin token as a bearer
+// token. Without a token nothing is let through.
+func AdminAuth(token string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			given, ok := strings.CutPrefix(ctx.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+			if !ok || token == "" || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+				return models.AdminUnauthorizedErr
+			}
+			return next(ctx)
+		}
+	}
+}
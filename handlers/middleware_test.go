@@ -0,0 +1,36 @@
+package handlers_test
+
+import (
+	"accounts-service/handlers"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryTimeout(t *testing.T) {
+	e := echo.New()
+	e.Use(handlers.QueryTimeout(time.Second, []string{"/untimed/:id"}))
+
+	deadline := func(ctx echo.Context) error {
+		_, ok := ctx.Request().Context().Deadline()
+		if ok {
+			return ctx.String(http.StatusOK, "deadline")
+		}
+		return ctx.String(http.StatusOK, "none")
+	}
+	e.GET("/timed/:id", deadline)
+	e.GET("/untimed/:id", deadline)
+
+	for path, want := range map[string]string{
+		"/timed/1":   "deadline",
+		"/untimed/1": "none",
+	} {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, want, rec.Body.String(), path)
+	}
+}
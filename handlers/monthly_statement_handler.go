@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"accounts-service/models"
+	"accounts-service/usecases"
+	"accounts-service/utils"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// HeaderChecksumSHA256 carries the hex SHA-256 of a downloaded statement, for
+// the client to check the file it saved.
+const HeaderChecksumSHA256 = "X-Checksum-SHA256"
+
+type MonthlyStatementHandler struct {
+	monthlyStatementUsecase usecases.MonthlyStatementUsecase
+	logger                  utils.Logger
+}
+
+func NewMonthlyStatementHandler(monthlyStatementUsecase usecases.MonthlyStatementUsecase, logger utils.Logger) *MonthlyStatementHandler {
+	return &MonthlyStatementHandler{
+		monthlyStatementUsecase: monthlyStatementUsecase,
+		logger:                  logger,
+	}
+}
+
+func (h *MonthlyStatementHandler) ListStatements(ctx echo.Context) error {
+	var req models.MonthlyStatementsRequest
+	if err := ctx.Bind(&req); err != nil {
+		return models.AccountParamNoRekeningEmptyErr.Wrap(err)
+	}
+
+	if err := ctx.Validate(&req); err != nil {
+		return err
+	}
+
+	statements, err := h.monthlyStatementUsecase.ListStatements(ctx.Request().Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, statements)
+}
+
+// GetStatement sends an archived monthly statement once its checksum is
+// verified.
+func (h *MonthlyStatementHandler) GetStatement(ctx echo.Context) error {
+	var req models.MonthlyStatementRequest
+	if err := ctx.Bind(&req); err != nil {
+		return models.MonthlyStatementInvalidErr.Wrap(err)
+	}
+
+	if err := ctx.Validate(&req); err != nil {
+		return err
+	}
+
+	statement, file, err := h.monthlyStatementUsecase.OpenStatement(ctx.Request().Context(), &req)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	header := ctx.Response().Header()
+	header.Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", "rekening-koran-"+statement.NoRekening+"-"+statement.Period+".pdf"))
+	header.Set(echo.HeaderContentLength, strconv.FormatInt(statement.Size, 10))
+	header.Set(HeaderChecksumSHA256, statement.Checksum)
+	header.Set("ETag", strconv.Quote(statement.Checksum))
+
+	return ctx.Stream(http.StatusOK, "application/pdf", file)
+}
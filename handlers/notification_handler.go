@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"accounts-service/models"
+	"accounts-service/usecases"
+	"accounts-service/utils"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type NotificationHandler struct {
+	notificationUsecase usecases.NotificationUsecase
+	logger              utils.Logger
+}
+
+func NewNotificationHandler(notificationUsecase usecases.NotificationUsecase, logger utils.Logger) *NotificationHandler {
+	return &NotificationHandler{
+		notificationUsecase: notificationUsecase,
+		logger:              logger,
+	}
+}
+
+func (h *NotificationHandler) GetPreference(ctx echo.Context) error {
+	var req models.NotificationPreferenceRequest
+	if err := ctx.Bind(&req); err != nil {
+		return models.AccountParamNoRekeningEmptyErr.Wrap(err)
+	}
+
+	if err := ctx.Validate(&req); err != nil {
+		return err
+	}
+
+	preference, err := h.notificationUsecase.GetPreference(ctx.Request().Context(), req.NoRekening)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, preference)
+}
+
+func (h *NotificationHandler) UpdatePreference(ctx echo.Context) error {
+	var req models.UpdateNotificationPreferenceRequest
+	if err := ctx.Bind(&req); err != nil {
+		return models.NotificationInvalidRequestErr.Wrap(err)
+	}
+
+	if err := ctx.Validate(&req); err != nil {
+		return err
+	}
+
+	preference, err := h.notificationUsecase.UpdatePreference(ctx.Request().Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, preference)
+}
@@ -0,0 +1,499 @@
+package handlers_test
+
+import (
+	"accounts-service/blobs"
+	"accounts-service/handlers"
+	"accounts-service/models"
+	"accounts-service/openapi"
+	"accounts-service/repositories"
+	"accounts-service/statements"
+	"accounts-service/usecases"
+	"accounts-service/utils"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var pathParamPattern = regexp.MustCompile(`:([a-z_]+)`)
+
+// adminToken guards the /api/account routes that are not for customers.
+const adminToken = "admin-token-0123456789"
+
+// suspenseNoRekening holds the interbank transfers ordered through the API.
+const suspenseNoRekening = "9000000001"
+
+func loadSpec(t *testing.T) *openapi3.T {
+	doc, err := openapi3.NewLoader().LoadFromData(openapi.Spec)
+	require.NoError(t, err)
+	require.NoError(t, doc.Validate(context.Background()))
+	return doc
+}
+
+// testAPI is the API with the storage behind it, for tests to set up what
+// the API cannot create.
+type testAPI struct {
+	*echo.Echo
+	accountRepo   repositories.AccountRepository
+	statementRepo repositories.MonthlyStatementRepository
+	blobStore     blobs.Store
+}
+
+// newAPI serves the /api/account routes on memory storage, set up like
+// main.go.
+func newAPI(t *testing.T) *testAPI {
+	logger := utils.NewLogger("critical")
+	store := repositories.NewMemoryStore()
+	accountRepo := repositories.NewMemoryAccountRepository(store, logger)
+	statementRepo := repositories.NewMemoryMonthlyStatementRepository(store, logger)
+	blobStore, err := blobs.NewFileStore(t.TempDir())
+	require.NoError(t, err)
+
+	accountUsecase := usecases.NewAccountUsecase(
+		repositories.NewMemoryTxManager(store, logger),
+		accountRepo,
+		repositories.NewMemoryMutationRepository(store, logger),
+		repositories.NewMemoryOutboxRepository(store, logger),
+		repositories.NewNoopBalanceCache(),
+		logger,
+	)
+	notificationUsecase := usecases.NewNotificationUsecase(accountRepo, repositories.NewMemoryNotificationPreferenceRepository(store, logger), logger)
+
+	e := echo.New()
+	e.HTTPErrorHandler = handlers.NewHTTPErrorHandler(logger)
+	e.Validator = utils.NewRequestValidator(models.RequestValidationRemarks)
+	statementHandler := handlers.NewStatementHandler(
+		usecases.NewStatementUsecase(accountRepo, repositories.NewMemoryMutationRepository(store, logger), logger),
+		statements.Header{BankName: "Bank Contoh"},
+		time.Minute,
+		logger,
+	)
+	monthlyStatementHandler := handlers.NewMonthlyStatementHandler(usecases.NewMonthlyStatementUsecase(accountRepo, statementRepo, blobStore, logger), logger)
+	bulkCreditHandler := handlers.NewBulkCreditHandler(usecases.NewBulkCreditUsecase(
+		repositories.NewMemoryTxManager(store, logger),
+		accountRepo,
+		repositories.NewMemoryMutationRepository(store, logger),
+		repositories.NewMemoryOutboxRepository(store, logger),
+		repositories.NewMemoryBulkCreditRepository(store, logger),
+		repositories.NewNoopBalanceCache(),
+		usecases.BulkCreditOptions{DefaultMode: models.BulkCreditModeAllOrNothing, MaxRows: 100},
+		logger,
+	), logger)
+	qrisHandler := handlers.NewQRISHandler(usecases.NewQRISUsecase(
+		repositories.NewMemoryTxManager(store, logger),
+		accountRepo,
+		repositories.NewMemoryMutationRepository(store, logger),
+		repositories.NewMemoryQRISRepository(store, logger),
+		accountUsecase,
+		usecases.QRISOptions{GlobalID: "ID.CO.ACCOUNTS.WWW", PANPrefix: "9360099", MerchantCategory: "5499", MerchantCriteria: "UMI", MerchantCity: "Jakarta"},
+		logger,
+	), logger)
+	virtualAccountHandler := handlers.NewVirtualAccountHandler(usecases.NewVirtualAccountUsecase(
+		repositories.NewMemoryTxManager(store, logger),
+		accountRepo,
+		repositories.NewMemoryMutationRepository(store, logger),
+		repositories.NewMemoryVirtualAccountRepository(store, logger),
+		accountUsecase,
+		"8808",
+		logger,
+	), logger)
+	interbankHandler := handlers.NewInterbankHandler(usecases.NewInterbankUsecase(
+		repositories.NewMemoryTxManager(store, logger),
+		accountRepo,
+		repositories.NewMemoryInterbankRepository(store, logger),
+		accountUsecase,
+		usecases.InterbankOptions{BankCode: "484", SuspenseNoRekening: suspenseNoRekening},
+		logger,
+	), logger)
+	handlers.RegisterAccountRoutes(e.Group("/api/account"), handlers.AdminAuth(adminToken), handlers.NewAccountHandler(accountUsecase, logger), handlers.NewNotificationHandler(notificationUsecase, logger), statementHandler, monthlyStatementHandler, bulkCreditHandler, qrisHandler, virtualAccountHandler)
+	handlers.RegisterInterbankRoutes(e.Group("/api/account"), interbankHandler)
+
+	return &testAPI{Echo: e, accountRepo: accountRepo, statementRepo: statementRepo, blobStore: blobStore}
+}
+
+// archiveStatement stores content as the monthly statement of period with
+// the given checksum.
+func (api *testAPI) archiveStatement(t *testing.T, noRekening, period, content, checksum string) {
+	t.Helper()
+	ctx := context.Background()
+
+	account, err := api.accountRepo.GetAccountByNoRekening(ctx, noRekening)
+	require.NoError(t, err)
+
+	key := statements.BlobKey(period, noRekening)
+	require.NoError(t, api.blobStore.Put(ctx, key, strings.NewReader(content)))
+
+	generatedAt := time.Now()
+	require.NoError(t, api.statementRepo.SaveStatement(ctx, &models.MonthlyStatement{
+		AccountID:   account.ID,
+		Period:      period,
+		Status:      models.MonthlyStatementGenerated,
+		BlobKey:     key,
+		Size:        int64(len(content)),
+		Checksum:    checksum,
+		GeneratedAt: &generatedAt,
+	}))
+}
+
+// jsonFields returns the fields of a struct type serialized in JSON bodies
+// by their JSON name. Path and query fields are left out.
+func jsonFields(typ reflect.Type) map[string]reflect.StructField {
+	fields := map[string]reflect.StructField{}
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag, hasTag := field.Tag.Lookup("json")
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "-" || (!hasTag && (field.Tag.Get("param") != "" || field.Tag.Get("query") != "")) {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field
+	}
+	return fields
+}
+
+// schemaType is the OpenAPI type of a Go type, "" for any.
+func schemaType(typ reflect.Type) string {
+	if typ == reflect.TypeOf(time.Time{}) {
+		return openapi3.TypeString
+	}
+	switch typ.Kind() {
+	case reflect.String:
+		return openapi3.TypeString
+	case reflect.Float32, reflect.Float64:
+		return openapi3.TypeNumber
+	case reflect.Int, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return openapi3.TypeInteger
+	case reflect.Bool:
+		return openapi3.TypeBoolean
+	case reflect.Slice:
+		return openapi3.TypeArray
+	case reflect.Struct, reflect.Map:
+		return openapi3.TypeObject
+	}
+	return ""
+}
+
+func TestOpenAPI_RoutesDocumented(t *testing.T) {
+	doc := loadSpec(t)
+
+	var routes []string
+	for _, route := range newAPI(t).Routes() {
+		if strings.HasPrefix(route.Path, "/api/account/") {
+			routes = append(routes, route.Method+" "+pathParamPattern.ReplaceAllString(route.Path, "{$1}"))
+		}
+	}
+
+	var documented []string
+	for path, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			documented = append(documented, method+" "+path)
+		}
+	}
+
+	sort.Strings(routes)
+	sort.Strings(documented)
+	assert.Equal(t, routes, documented, "routes and openapi/openapi.json paths differ")
+}
+
+func TestOpenAPI_RemarkCodesDocumented(t *testing.T) {
+	doc := loadSpec(t)
+	schema := doc.Components.Schemas["RemarkCode"].Value
+
+	var codes, documented []string
+	for code := range models.Messages {
+		codes = append(codes, code)
+		assert.Contains(t, schema.Description, "`"+code+"`", "code %s is missing from the RemarkCode table", code)
+	}
+	for _, code := range schema.Enum {
+		documented = append(documented, code.(string))
+	}
+
+	sort.Strings(codes)
+	sort.Strings(documented)
+	assert.Equal(t, codes, documented, "remark codes and the RemarkCode enum differ")
+}
+
+func TestOpenAPI_SchemasMatchModels(t *testing.T) {
+	doc := loadSpec(t)
+
+	for name, model := range map[string]struct {
+		value   interface{}
+		request bool
+	}{
+		"Account":                             {value: models.Account{}},
+		"SaldoResponse":                       {value: models.SaldoResponse{}},
+		"NotificationPreference":              {value: models.NotificationPreference{}},
+		"MonthlyStatement":                    {value: models.MonthlyStatement{}},
+		"BulkCreditBatch":                     {value: models.BulkCreditBatch{}},
+		"BulkCreditRow":                       {value: models.BulkCreditRow{}},
+		"QRISCode":                            {value: models.QRISCode{}},
+		"QRISPayment":                         {value: models.QRISPayment{}},
+		"VirtualAccount":                      {value: models.VirtualAccount{}},
+		"VirtualAccountInquiry":               {value: models.VirtualAccountInquiry{}},
+		"VirtualAccountPayment":               {value: models.VirtualAccountPayment{}},
+		"InterbankTransfer":                   {value: models.InterbankTransfer{}},
+		"ErrorResponse":                       {value: utils.Remark{}},
+		"ErrorDetails":                        {value: utils.ErrorDetails{}},
+		"CreateAccountRequest":                {value: models.CreateAccountRequest{}, request: true},
+		"TransactionRequest":                  {value: models.TransactionRequest{}, request: true},
+		"UpdateNotificationPreferenceRequest": {value: models.UpdateNotificationPreferenceRequest{}, request: true},
+		"BulkCreditRequest":                   {value: models.BulkCreditRequest{}, request: true},
+		"QRISRequest":                         {value: models.QRISRequest{}, request: true},
+		"QRISParseRequest":                    {value: models.QRISParseRequest{}, request: true},
+		"QRISPaymentRequest":                  {value: models.QRISPaymentRequest{}, request: true},
+		"VirtualAccountRequest":               {value: models.VirtualAccountRequest{}, request: true},
+		"VirtualAccountPaymentRequest":        {value: models.VirtualAccountPaymentRequest{}, request: true},
+		"InterbankTransferRequest":            {value: models.InterbankTransferRequest{}, request: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ref, ok := doc.Components.Schemas[name]
+			require.True(t, ok, "schema %s is missing", name)
+			schema := ref.Value
+
+			fields := jsonFields(reflect.TypeOf(model.value))
+
+			var names, properties []string
+			for field := range fields {
+				names = append(names, field)
+			}
+			for property := range schema.Properties {
+				properties = append(properties, property)
+			}
+			sort.Strings(names)
+			sort.Strings(properties)
+			require.Equal(t, names, properties, "fields and properties differ")
+
+			for property, field := range fields {
+				if want := schemaType(field.Type); want != "" && schema.Properties[property].Value.Type != nil {
+					assert.True(t, schema.Properties[property].Value.Type.Is(want), "%s is %s in the model", property, want)
+				}
+
+				// Requests require what is validated as required, responses
+				// what is never omitted.
+				var required bool
+				if model.request {
+					required = strings.Contains(","+field.Tag.Get("validate")+",", ",required,")
+				} else {
+					required = !strings.Contains(field.Tag.Get("json"), ",omitempty")
+				}
+				assert.Equal(t, required, slices.Contains(schema.Required, property), "%s required", property)
+			}
+		})
+	}
+}
+
+// TestOpenAPI_ResponsesMatchSpec exercises every operation and checks the
+// requests and responses against the spec, including the error bodies.
+func TestOpenAPI_ResponsesMatchSpec(t *testing.T) {
+	doc := loadSpec(t)
+	router, err := legacy.NewRouter(doc)
+	require.NoError(t, err)
+
+	// PDF statements are validated as opaque files
+	openapi3filter.RegisterBodyDecoder("application/pdf", openapi3filter.FileBodyDecoder)
+
+	e := newAPI(t)
+	ctx := context.Background()
+	exercised := map[string]bool{}
+
+	// authorization is sent with every call, tests clear it to call without
+	authorization := "Bearer " + adminToken
+	call := func(t *testing.T, method, path, body string, validRequest bool, wantStatus int) []byte {
+		t.Helper()
+
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if strings.HasPrefix(body, "{") {
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		} else if body != "" {
+			// Bodies other than JSON objects are CSV files
+			req.Header.Set(echo.HeaderContentType, handlers.MIMETextCSV)
+		}
+		req.Header.Set("Accept-Language", "en")
+		if authorization != "" {
+			req.Header.Set(echo.HeaderAuthorization, authorization)
+		}
+
+		route, pathParams, err := router.FindRoute(req)
+		require.NoError(t, err)
+		exercised[method+" "+route.Path] = true
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    req,
+			PathParams: pathParams,
+			Route:      route,
+			Options:    &openapi3filter.Options{IncludeResponseStatus: true, MultiError: true, AuthenticationFunc: openapi3filter.NoopAuthenticationFunc},
+		}
+		if validRequest {
+			require.NoError(t, openapi3filter.ValidateRequest(ctx, input), "request does not match the spec")
+			req.Body = io.NopCloser(strings.NewReader(body))
+		}
+
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		require.Equal(t, wantStatus, rec.Code, rec.Body.String())
+
+		responseBody := rec.Body.Bytes()
+		err = openapi3filter.ValidateResponse(ctx, &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: input,
+			Status:                 rec.Code,
+			Header:                 rec.Header(),
+			Body:                   io.NopCloser(bytes.NewReader(responseBody)),
+			Options:                input.Options,
+		})
+		require.NoError(t, err, "response does not match the spec: %s", responseBody)
+		return responseBody
+	}
+
+	body := call(t, http.MethodPost, "/api/account/daftar", `{"name":"Siti Aminah","nik":"3201014508950001","no_hp":"081234567890"}`, true, http.StatusCreated)
+	noRekening := regexp.MustCompile(`"no_rekening":"([0-9]+)"`).FindStringSubmatch(string(body))[1]
+
+	call(t, http.MethodPost, "/api/account/daftar", `{"name":"Budi","nik":"3201014508950001","no_hp":"081200000000"}`, true, http.StatusConflict)
+	call(t, http.MethodPost, "/api/account/daftar", `{"name":"","nik":"123","no_hp":"x"}`, false, http.StatusBadRequest)
+	call(t, http.MethodPost, "/api/account/daftar", `{"name":`, false, http.StatusBadRequest)
+
+	call(t, http.MethodPost, "/api/account/tabung", `{"no_rekening":"`+noRekening+`","nominal":500000}`, true, http.StatusOK)
+	call(t, http.MethodPost, "/api/account/tabung", `{"no_rekening":"1000000000","nominal":1000}`, true, http.StatusNotFound)
+	call(t, http.MethodPost, "/api/account/tarik", `{"no_rekening":"`+noRekening+`","nominal":150000,"reference":"ATM-001"}`, true, http.StatusOK)
+	call(t, http.MethodPost, "/api/account/tarik", `{"no_rekening":"`+noRekening+`","nominal":1000000}`, true, http.StatusUnprocessableEntity)
+	call(t, http.MethodPost, "/api/account/tarik", `{"no_rekening":"abc","nominal":-1}`, false, http.StatusBadRequest)
+
+	call(t, http.MethodGet, "/api/account/saldo/"+noRekening, "", true, http.StatusOK)
+	call(t, http.MethodGet, "/api/account/saldo/1000000000", "", true, http.StatusNotFound)
+	call(t, http.MethodGet, "/api/account/saldo/abc", "", true, http.StatusBadRequest)
+
+	call(t, http.MethodGet, "/api/account/notifikasi/"+noRekening, "", true, http.StatusOK)
+	call(t, http.MethodPut, "/api/account/notifikasi/"+noRekening, `{"channels":["sms","email"],"email":"siti@example.com","low_balance_threshold":100000}`, true, http.StatusOK)
+	call(t, http.MethodPut, "/api/account/notifikasi/"+noRekening, `{"channels":["email"]}`, true, http.StatusBadRequest)
+	call(t, http.MethodPut, "/api/account/notifikasi/1000000000", `{"channels":[]}`, true, http.StatusNotFound)
+
+	authorization = "Bearer wrong-token"
+	call(t, http.MethodGet, "/api/account/notifikasi/"+noRekening, "", true, http.StatusUnauthorized)
+	authorization = ""
+	call(t, http.MethodPut, "/api/account/notifikasi/"+noRekening, `{"channels":[]}`, true, http.StatusUnauthorized)
+	authorization = "Bearer " + adminToken
+
+	today := time.Now().In(models.StatementZone).Format(models.StatementDateLayout)
+	call(t, http.MethodGet, "/api/account/"+noRekening+"/statement?from="+today+"&to="+today, "", true, http.StatusOK)
+	call(t, http.MethodGet, "/api/account/"+noRekening+"/statement?from="+today+"&to="+today+"&format=pdf", "", true, http.StatusOK)
+	call(t, http.MethodGet, "/api/account/"+noRekening+"/statement?from=2025-05-01&to=2025-04-01", "", true, http.StatusBadRequest)
+	call(t, http.MethodGet, "/api/account/1000000000/statement?from=2025-04-01&to=2025-04-30", "", true, http.StatusNotFound)
+
+	content := "%PDF-1.4 rekening koran"
+	sum := sha256.Sum256([]byte(content))
+	e.archiveStatement(t, noRekening, "2025-04", content, hex.EncodeToString(sum[:]))
+	e.archiveStatement(t, noRekening, "2025-03", content, strings.Repeat("0", 64))
+
+	body = call(t, http.MethodGet, "/api/account/"+noRekening+"/statements", "", true, http.StatusOK)
+	assert.Contains(t, string(body), `"period":"2025-04"`)
+	call(t, http.MethodGet, "/api/account/1000000000/statements", "", true, http.StatusNotFound)
+	body = call(t, http.MethodGet, "/api/account/"+noRekening+"/statements/2025-04", "", true, http.StatusOK)
+	assert.Equal(t, content, string(body))
+	call(t, http.MethodGet, "/api/account/"+noRekening+"/statements/2025-03", "", true, http.StatusInternalServerError)
+	call(t, http.MethodGet, "/api/account/"+noRekening+"/statements/2025-02", "", true, http.StatusNotFound)
+	call(t, http.MethodGet, "/api/account/"+noRekening+"/statements/2025-13", "", false, http.StatusBadRequest)
+
+	// Accounts opened in the same second share a no_rekening from the API
+	recipient := "1744847262"
+	require.NoError(t, e.accountRepo.CreateAccount(ctx, &models.Account{Name: "Budi Santoso", NIK: "3201014508950002", NoHP: "+6281234567891", NoRekening: recipient}))
+
+	call(t, http.MethodPost, "/api/account/bulk/credit", `{"batch_reference":"PAYROLL-2025-04","source_no_rekening":"`+noRekening+`","rows":[{"no_rekening":"`+recipient+`","nominal":100000,"reference":"GAJI-04"}]}`, true, http.StatusCreated)
+	call(t, http.MethodPost, "/api/account/bulk/credit", `{"batch_reference":"PAYROLL-2025-04","source_no_rekening":"`+noRekening+`","rows":[{"no_rekening":"`+recipient+`","nominal":100000}]}`, true, http.StatusConflict)
+	body = call(t, http.MethodPost, "/api/account/bulk/credit?batch_reference=PAYROLL-2025-05&mode=per_row&source_no_rekening="+noRekening, "no_rekening,nominal\n"+recipient+",50000\n1000000000,50000\n", true, http.StatusAccepted)
+	assert.Contains(t, string(body), `"status":"processing"`)
+	call(t, http.MethodPost, "/api/account/bulk/credit?batch_reference=PAYROLL-2025-06&source_no_rekening="+noRekening, "no_rekening,nominal\n"+recipient+",lima\n", true, http.StatusBadRequest)
+	call(t, http.MethodPost, "/api/account/bulk/credit", `{"batch_reference":"PAYROLL-2025-06","source_no_rekening":"1000000000","rows":[{"no_rekening":"`+recipient+`","nominal":1000}]}`, true, http.StatusNotFound)
+	call(t, http.MethodPost, "/api/account/bulk/credit", `{"batch_reference":"PAYROLL-2025-06","source_no_rekening":"`+noRekening+`","rows":[{"no_rekening":"`+recipient+`","nominal":10000000}]}`, true, http.StatusUnprocessableEntity)
+	call(t, http.MethodPost, "/api/account/bulk/credit", `{"source_no_rekening":"`+noRekening+`","rows":[]}`, false, http.StatusBadRequest)
+
+	call(t, http.MethodGet, "/api/account/bulk/credit/PAYROLL-2025-05", "", true, http.StatusOK)
+	call(t, http.MethodGet, "/api/account/bulk/credit/PAYROLL-2025-06", "", true, http.StatusNotFound)
+	call(t, http.MethodGet, "/api/account/bulk/credit/"+strings.Repeat("X", 65), "", false, http.StatusBadRequest)
+
+	body = call(t, http.MethodPost, "/api/account/"+recipient+"/qris", `{"nominal":25000,"bill_number":"INV001"}`, true, http.StatusCreated)
+	dynamic := regexp.MustCompile(`"payload":"([^"]+)"`).FindStringSubmatch(string(body))[1]
+	body = call(t, http.MethodPost, "/api/account/"+recipient+"/qris", `{}`, true, http.StatusCreated)
+	static := regexp.MustCompile(`"payload":"([^"]+)"`).FindStringSubmatch(string(body))[1]
+	call(t, http.MethodPost, "/api/account/"+recipient+"/qris", `{"nominal":10000,"bill_number":"INV001"}`, true, http.StatusConflict)
+	call(t, http.MethodPost, "/api/account/"+recipient+"/qris", `{"bill_number":"INV002"}`, true, http.StatusBadRequest)
+	call(t, http.MethodPost, "/api/account/1000000000/qris", `{}`, true, http.StatusNotFound)
+
+	body = call(t, http.MethodPost, "/api/account/qris/parse", `{"payload":"`+dynamic+`"}`, true, http.StatusOK)
+	assert.Contains(t, string(body), `"bill_number":"INV001"`)
+	call(t, http.MethodPost, "/api/account/qris/parse", `{"payload":"000201"}`, true, http.StatusBadRequest)
+
+	call(t, http.MethodPost, "/api/account/qris/payment", `{"payload":"`+dynamic+`"}`, true, http.StatusOK)
+	call(t, http.MethodPost, "/api/account/qris/payment", `{"payload":"`+dynamic+`"}`, true, http.StatusConflict)
+	call(t, http.MethodPost, "/api/account/qris/payment", `{"payload":"`+static+`","nominal":10000,"reference":"RRN000001"}`, true, http.StatusOK)
+	call(t, http.MethodPost, "/api/account/qris/payment", `{"payload":"`+static+`"}`, true, http.StatusBadRequest)
+	body = call(t, http.MethodPost, "/api/account/"+recipient+"/qris", `{"nominal":25000}`, true, http.StatusCreated)
+	unpaid := regexp.MustCompile(`"payload":"([^"]+)"`).FindStringSubmatch(string(body))[1]
+	authorization = ""
+	call(t, http.MethodPost, "/api/account/qris/payment", `{"payload":"`+unpaid+`"}`, true, http.StatusUnauthorized)
+	authorization = "Bearer " + adminToken
+
+	body = call(t, http.MethodPost, "/api/account/"+recipient+"/va", `{"customer_number":"512345678901","name":"PLN 512345678901","nominal":150000,"single_use":true,"expires_at":"2099-01-01T00:00:00+07:00"}`, true, http.StatusCreated)
+	assert.Contains(t, string(body), `"va_number":"8808512345678901"`)
+	call(t, http.MethodPost, "/api/account/"+recipient+"/va", `{"customer_number":"512345678901"}`, true, http.StatusConflict)
+	call(t, http.MethodPost, "/api/account/"+recipient+"/va", `{}`, true, http.StatusCreated)
+	call(t, http.MethodPost, "/api/account/"+recipient+"/va", `{"customer_number":"51234A"}`, false, http.StatusBadRequest)
+	call(t, http.MethodPost, "/api/account/1000000000/va", `{}`, true, http.StatusNotFound)
+
+	body = call(t, http.MethodGet, "/api/account/va/8808512345678901", "", true, http.StatusOK)
+	assert.NotContains(t, string(body), "no_rekening", "payers do not see the account")
+	call(t, http.MethodGet, "/api/account/va/8808000000000000", "", true, http.StatusNotFound)
+	call(t, http.MethodGet, "/api/account/va/8808X", "", false, http.StatusBadRequest)
+
+	authorization = ""
+	call(t, http.MethodPost, "/api/account/va/payment", `{"va_number":"8808512345678901","nominal":150000,"reference":"PAY-001"}`, true, http.StatusUnauthorized)
+	authorization = "Bearer " + adminToken
+	call(t, http.MethodPost, "/api/account/va/payment", `{"va_number":"8808512345678901","nominal":100000,"reference":"PAY-001"}`, true, http.StatusUnprocessableEntity)
+	body = call(t, http.MethodPost, "/api/account/va/payment", `{"va_number":"8808512345678901","nominal":150000,"reference":"PAY-001"}`, true, http.StatusOK)
+	assert.Contains(t, string(body), `"status":"closed"`)
+	call(t, http.MethodPost, "/api/account/va/payment", `{"va_number":"8808512345678901","nominal":150000,"reference":"PAY-001"}`, true, http.StatusConflict)
+	call(t, http.MethodPost, "/api/account/va/payment", `{"va_number":"8808000000000000","nominal":150000,"reference":"PAY-002"}`, true, http.StatusNotFound)
+	call(t, http.MethodPost, "/api/account/va/payment", `{"va_number":"8808512345678901","nominal":150000}`, false, http.StatusBadRequest)
+
+	require.NoError(t, e.accountRepo.CreateAccount(ctx, &models.Account{Name: "Kliring Keluar", NIK: "3201014508950003", NoHP: "+6281234567892", NoRekening: suspenseNoRekening}))
+	body = call(t, http.MethodPost, "/api/account/"+recipient+"/interbank", `{"bank_code":"014","beneficiary_account":"1234567890","beneficiary_name":"budi santoso","nominal":100000,"reference":"INV-2025-0042","description":"SEWA APRIL"}`, true, http.StatusCreated)
+	assert.Contains(t, string(body), `"beneficiary_name":"BUDI SANTOSO"`)
+	transferID := regexp.MustCompile(`"transfer_id":"([^"]+)"`).FindStringSubmatch(string(body))[1]
+	call(t, http.MethodPost, "/api/account/"+recipient+"/interbank", `{"bank_code":"014","beneficiary_account":"1234567890","beneficiary_name":"BUDI","nominal":100000,"reference":"INV-2025-0042"}`, true, http.StatusConflict)
+	call(t, http.MethodPost, "/api/account/"+recipient+"/interbank", `{"bank_code":"014","beneficiary_account":"1234567890","beneficiary_name":"BUDI","nominal":10000000,"reference":"INV-2025-0043"}`, true, http.StatusUnprocessableEntity)
+	call(t, http.MethodPost, "/api/account/"+recipient+"/interbank", `{"bank_code":"484","beneficiary_account":"1234567890","beneficiary_name":"BUDI","nominal":1000,"reference":"INV-2025-0043"}`, true, http.StatusBadRequest)
+	call(t, http.MethodPost, "/api/account/"+recipient+"/interbank", `{"bank_code":"14","beneficiary_account":"1234-567","beneficiary_name":"BUDI","nominal":1000,"reference":"INV-2025-0043"}`, false, http.StatusBadRequest)
+	call(t, http.MethodPost, "/api/account/1000000000/interbank", `{"bank_code":"014","beneficiary_account":"1234567890","beneficiary_name":"BUDI","nominal":1000,"reference":"INV-2025-0043"}`, true, http.StatusNotFound)
+
+	body = call(t, http.MethodGet, "/api/account/interbank/"+transferID, "", true, http.StatusOK)
+	assert.Contains(t, string(body), `"status":"queued"`)
+	call(t, http.MethodGet, "/api/account/interbank/IBT0000000009999", "", true, http.StatusNotFound)
+	call(t, http.MethodGet, "/api/account/interbank/IBT42", "", false, http.StatusBadRequest)
+
+	for path, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			assert.True(t, exercised[method+" "+path], "%s %s is not exercised", method, path)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"accounts-service/models"
+	"accounts-service/usecases"
+	"accounts-service/utils"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type QRISHandler struct {
+	qrisUsecase usecases.QRISUsecase
+	logger      utils.Logger
+}
+
+func NewQRISHandler(qrisUsecase usecases.QRISUsecase, logger utils.Logger) *QRISHandler {
+	return &QRISHandler{
+		qrisUsecase: qrisUsecase,
+		logger:      logger,
+	}
+}
+
+// Generate returns a QRIS code paying the account of the path.
+func (h *QRISHandler) Generate(ctx echo.Context) error {
+	var req models.QRISRequest
+	if err := ctx.Bind(&req); err != nil {
+		return models.QRISInvalidRequestErr.Wrap(err)
+	}
+
+	if err := ctx.Validate(&req); err != nil {
+		return err
+	}
+
+	code, err := h.qrisUsecase.Generate(ctx.Request().Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusCreated, code)
+}
+
+func (h *QRISHandler) Parse(ctx echo.Context) error {
+	var req models.QRISParseRequest
+	if err := ctx.Bind(&req); err != nil {
+		return models.QRISInvalidRequestErr.Wrap(err)
+	}
+
+	if err := ctx.Validate(&req); err != nil {
+		return err
+	}
+
+	code, err := h.qrisUsecase.Parse(ctx.Request().Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, code)
+}
+
+// Pay takes the payment notification of a QRIS code of this bank and
+// credits its merchant.
+func (h *QRISHandler) Pay(ctx echo.Context) error {
+	var req models.QRISPaymentRequest
+	if err := ctx.Bind(&req); err != nil {
+		return models.QRISInvalidRequestErr.Wrap(err)
+	}
+
+	if err := ctx.Validate(&req); err != nil {
+		return err
+	}
+
+	payment, err := h.qrisUsecase.Pay(ctx.Request().Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, payment)
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import "github.com/labstack/echo/v4"
+
+// UntimedAccountRoutes are the /api/account routes exempt from QueryTimeout.
+// They outlast the deadline of a query and set their own.
+var UntimedAccountRoutes = []string{
+	"/api/account/bulk/credit",
+	"/api/account/:no_rekening/statement",
+}
+
+// RegisterAccountRoutes adds the /api/account routes to api. They are
+// documented in openapi/openapi.json, keep both in step. Routes that are not
+// for customers themselves go through adminAuth.
+func RegisterAccountRoutes(api *echo.Group, adminAuth echo.MiddlewareFunc, accountHandler *AccountHandler, notificationHandler *NotificationHandler, statementHandler *StatementHandler, monthlyStatementHandler *MonthlyStatementHandler, bulkCreditHandler *BulkCreditHandler, qrisHandler *QRISHandler, virtualAccountHandler *VirtualAccountHandler) {
+	api.POST("/daftar", accountHandler.CreateAccount)
+	api.POST("/tabung", accountHandler.Credit)
+	api.POST("/tarik", accountHandler.Debit)
+	api.GET("/saldo/:no_rekening", accountHandler.GetSaldo)
+	api.POST("/bulk/credit", bulkCreditHandler.SubmitBatch)
+	api.GET("/bulk/credit/:batch_reference", bulkCreditHandler.GetBatch)
+	api.POST("/qris/parse", qrisHandler.Parse)
+	api.POST("/qris/payment", qrisHandler.Pay, adminAuth)
+	api.POST("/va/payment", virtualAccountHandler.Pay, adminAuth)
+	api.GET("/va/:va_number", virtualAccountHandler.Inquire)
+	api.GET("/notifikasi/:no_rekening", notificationHandler.GetPreference, adminAuth)
+	api.PUT("/notifikasi/:no_rekening", notificationHandler.UpdatePreference, adminAuth)
+	api.GET("/:no_rekening/statement", statementHandler.GetStatement)
+	api.GET("/:no_rekening/statements", monthlyStatementHandler.ListStatements)
+	api.GET("/:no_rekening/statements/:period", monthlyStatementHandler.GetStatement)
+	api.POST("/:no_rekening/qris", qrisHandler.Generate)
+	api.POST("/:no_rekening/va", virtualAccountHandler.Issue)
+}
+
+// RegisterInterbankRoutes adds the /api/account routes of interbank
+// transfers to api, served when they are enabled. They are documented in
+// openapi/openapi.json too.
+func RegisterInterbankRoutes(api *echo.Group, interbankHandler *InterbankHandler) {
+	api.GET("/interbank/:transfer_id", interbankHandler.GetTransfer)
+	api.POST("/:no_rekening/interbank", interbankHandler.Order)
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"accounts-service/models"
+	"accounts-service/statements"
+	"accounts-service/usecases"
+	"accounts-service/utils"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type StatementHandler struct {
+	statementUsecase usecases.StatementUsecase
+	header           statements.Header
+	timeout          time.Duration
+	logger           utils.Logger
+}
+
+// NewStatementHandler returns the handler of statements. A statement is
+// streamed for up to timeout, 0 for no deadline; its route is exempt from
+// QueryTimeout.
+func NewStatementHandler(statementUsecase usecases.StatementUsecase, header statements.Header, timeout time.Duration, logger utils.Logger) *StatementHandler {
+	return &StatementHandler{
+		statementUsecase: statementUsecase,
+		header:           header,
+		timeout:          timeout,
+		logger:           logger,
+	}
+}
+
+// GetStatement streams the rekening koran of a period as CSV or PDF. Errors
+// are answered as usual until the statement starts, a failure while it is
+// written aborts the response so that it is not mistaken for a complete one.
+func (h *StatementHandler) GetStatement(ctx echo.Context) error {
+	var req models.StatementRequest
+	if err := ctx.Bind(&req); err != nil {
+		return models.StatementInvalidRequestErr.Wrap(err)
+	}
+
+	if err := ctx.Validate(&req); err != nil {
+		return err
+	}
+
+	reqCtx := ctx.Request().Context()
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		reqCtx, cancel = context.WithTimeout(reqCtx, h.timeout)
+		defer cancel()
+	}
+
+	statement, err := h.statementUsecase.PrepareStatement(reqCtx, &req)
+	if err != nil {
+		if errors.Is(reqCtx.Err(), context.DeadlineExceeded) {
+			return models.QueryTimeoutErr.Wrap(err)
+		}
+		return err
+	}
+
+	res := ctx.Response()
+	var writer usecases.StatementWriter
+	switch req.Format {
+	case models.StatementFormatPDF:
+		res.Header().Set(echo.HeaderContentType, "application/pdf")
+		writer = statements.NewPDFWriter(res, h.header)
+	default:
+		res.Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+		writer = statements.NewCSVWriter(res)
+	}
+	res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", statements.Filename(statement, req.Format)))
+	res.WriteHeader(http.StatusOK)
+
+	if err := h.statementUsecase.WriteStatement(reqCtx, statement, writer); err != nil {
+		h.logger.Error("Error writing statement of %s: %v", statement.Account.NoRekening, err)
+		panic(http.ErrAbortHandler)
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"accounts-service/models"
+	"accounts-service/usecases"
+	"accounts-service/utils"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type VirtualAccountHandler struct {
+	virtualAccountUsecase usecases.VirtualAccountUsecase
+	logger                utils.Logger
+}
+
+func NewVirtualAccountHandler(virtualAccountUsecase usecases.VirtualAccountUsecase, logger utils.Logger) *VirtualAccountHandler {
+	return &VirtualAccountHandler{
+		virtualAccountUsecase: virtualAccountUsecase,
+		logger:                logger,
+	}
+}
+
+// Issue issues a virtual account mapped to the account of the path.
+func (h *VirtualAccountHandler) Issue(ctx echo.Context) error {
+	var req models.VirtualAccountRequest
+	if err := ctx.Bind(&req); err != nil {
+		return models.VirtualAccountInvalidRequestErr.Wrap(err)
+	}
+
+	if err := ctx.Validate(&req); err != nil {
+		return err
+	}
+
+	virtualAccount, err := h.virtualAccountUsecase.Issue(ctx.Request().Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusCreated, virtualAccount)
+}
+
+// Inquire returns the virtual account of the path, for channels to show
+// before the payment.
+func (h *VirtualAccountHandler) Inquire(ctx echo.Context) error {
+	var req models.VirtualAccountInquiryRequest
+	if err := ctx.Bind(&req); err != nil {
+		return models.VirtualAccountInvalidRequestErr.Wrap(err)
+	}
+
+	if err := ctx.Validate(&req); err != nil {
+		return err
+	}
+
+	virtualAccount, err := h.virtualAccountUsecase.Inquire(ctx.Request().Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, virtualAccount)
+}
+
+// Pay takes an inbound payment to a virtual account and credits the
+// account it is mapped to.
+func (h *VirtualAccountHandler) Pay(ctx echo.Context) error {
+	var req models.VirtualAccountPaymentRequest
+	if err := ctx.Bind(&req); err != nil {
+		return models.VirtualAccountInvalidRequestErr.Wrap(err)
+	}
+
+	if err := ctx.Validate(&req); err != nil {
+		return err
+	}
+
+	payment, err := h.virtualAccountUsecase.Pay(ctx.Request().Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, payment)
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"accounts-service/models"
+	"accounts-service/usecases"
+	"accounts-service/utils"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type WebhookHandler struct {
+	webhookUsecase usecases.WebhookUsecase
+	logger         utils.Logger
+}
+
+func NewWebhookHandler(webhookUsecase usecases.WebhookUsecase, logger utils.Logger) *WebhookHandler {
+	return &WebhookHandler{
+		webhookUsecase: webhookUsecase,
+		logger:         logger,
+	}
+}
+
+func (h *WebhookHandler) CreateWebhook(ctx echo.Context) error {
+	var req models.CreateWebhookRequest
+	if err := ctx.Bind(&req); err != nil {
+		return models.WebhookInvalidRequestErr.Wrap(err)
+	}
+
+	if err := ctx.Validate(&req); err != nil {
+		return err
+	}
+
+	webhook, err := h.webhookUsecase.CreateWebhook(ctx.Request().Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusCreated, webhook)
+}
+
+func (h *WebhookHandler) ListWebhooks(ctx echo.Context) error {
+	webhooks, err := h.webhookUsecase.ListWebhooks(ctx.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, webhooks)
+}
+
+func (h *WebhookHandler) GetWebhook(ctx echo.Context) error {
+	var req models.WebhookRequest
+	if err := ctx.Bind(&req); err != nil {
+		return models.WebhookNotFoundErr.Wrap(err)
+	}
+
+	webhook, err := h.webhookUsecase.GetWebhook(ctx.Request().Context(), req.ID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, webhook)
+}
+
+func (h *WebhookHandler) UpdateWebhook(ctx echo.Context) error {
+	var req models.UpdateWebhookRequest
+	if err := ctx.Bind(&req); err != nil {
+		return models.WebhookInvalidRequestErr.Wrap(err)
+	}
+
+	if err := ctx.Validate(&req); err != nil {
+		return err
+	}
+
+	webhook, err := h.webhookUsecase.UpdateWebhook(ctx.Request().Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, webhook)
+}
+
+func (h *WebhookHandler) DeleteWebhook(ctx echo.Context) error {
+	var req models.WebhookRequest
+	if err := ctx.Bind(&req); err != nil {
+		return models.WebhookNotFoundErr.Wrap(err)
+	}
+
+	if err := h.webhookUsecase.DeleteWebhook(ctx.Request().Context(), req.ID); err != nil {
+		return err
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func (h *WebhookHandler) ListDeliveries(ctx echo.Context) error {
+	var req models.WebhookDeliveriesRequest
+	if err := ctx.Bind(&req); err != nil {
+		return models.WebhookInvalidRequestErr.Wrap(err)
+	}
+
+	if err := ctx.Validate(&req); err != nil {
+		return err
+	}
+
+	deliveries, err := h.webhookUsecase.ListDeliveries(ctx.Request().Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, deliveries)
+}
+
+func (h *WebhookHandler) GetDelivery(ctx echo.Context) error {
+	var req models.WebhookDeliveryRequest
+	if err := ctx.Bind(&req); err != nil {
+		return models.WebhookDeliveryNotFoundErr.Wrap(err)
+	}
+
+	delivery, err := h.webhookUsecase.GetDelivery(ctx.Request().Context(), req.ID, req.DeliveryID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, delivery)
+}
+
+func (h *WebhookHandler) Redeliver(ctx echo.Context) error {
+	var req models.WebhookDeliveryRequest
+	if err := ctx.Bind(&req); err != nil {
+		return models.WebhookDeliveryNotFoundErr.Wrap(err)
+	}
+
+	delivery, err := h.webhookUsecase.Redeliver(ctx.Request().Context(), req.ID, req.DeliveryID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusAccepted, delivery)
+}
@@ -0,0 +1,328 @@
+package imports
+
+import (
+	"accounts-service/models"
+	"accounts-service/usecases"
+	"accounts-service/utils"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Formats of an import file.
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// Columns are the columns of a CSV import file, in any order. A JSON file
+// is an array of objects with the same fields.
+var Columns = []string{"no_rekening", "name", "nik", "no_hp", "opening_balance"}
+
+// rejectColumns are added to the columns in the rejects file. They are
+// ignored when reading, so a fixed rejects file can be imported again.
+var rejectColumns = []string{"row", "error_code", "error"}
+
+// Result counts the accounts of an import. With a dry run Imported counts
+// the accounts that would be imported.
+type Result struct {
+	Imported int
+	Rejected int
+}
+
+// importRow is an account read from the file, with the values as written
+// for the rejects file.
+type importRow struct {
+	number int
+	values map[string]string
+	req    models.ImportAccountRequest
+	// err is set when the row could not be read into req.
+	err *utils.Remark
+}
+
+// Importer imports the accounts of the legacy core from a file. Every row is
+// validated with the rules of the API and imported in its own transaction.
+type Importer struct {
+	importUsecase usecases.ImportUsecase
+	validator     *utils.RequestValidator
+	logger        utils.Logger
+}
+
+func NewImporter(importUsecase usecases.ImportUsecase, logger utils.Logger) *Importer {
+	return &Importer{
+		importUsecase: importUsecase,
+		validator:     utils.NewRequestValidator(models.RequestValidationRemarks),
+		logger:        logger,
+	}
+}
+
+// Import imports the accounts read from r in format, writing the rows that
+// are rejected to rejects as CSV with the error of each. With dryRun
+// nothing is imported. It stops at the first error that is not the fault
+// of a row, like an unreadable file or a storage failure, leaving the rows
+// imported so far.
+func (i *Importer) Import(ctx context.Context, r io.Reader, format string, rejects io.Writer, dryRun bool) (Result, error) {
+	var result Result
+
+	var next func() (*importRow, error)
+	switch format {
+	case FormatCSV:
+		next = readCSV(r)
+	case FormatJSON:
+		next = readJSON(r)
+	default:
+		return result, fmt.Errorf("unknown format %q, expected %s or %s", format, FormatCSV, FormatJSON)
+	}
+
+	out := csv.NewWriter(rejects)
+	if err := out.Write(append(append([]string{}, Columns...), rejectColumns...)); err != nil {
+		return result, err
+	}
+	reject := func(row *importRow, remark *utils.Remark) error {
+		result.Rejected++
+
+		record := make([]string, 0, len(Columns)+len(rejectColumns))
+		for _, column := range Columns {
+			record = append(record, row.values[column])
+		}
+		code, message := describe(remark)
+		record = append(record, strconv.Itoa(row.number), code, message)
+		if err := out.Write(record); err != nil {
+			return err
+		}
+		out.Flush()
+		return out.Error()
+	}
+
+	// Rows are checked against the earlier ones too, which a dry run does
+	// not store
+	seen := map[string]bool{}
+	for {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		row, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return result, err
+		}
+
+		if row.err != nil {
+			if err := reject(row, row.err); err != nil {
+				return result, err
+			}
+			continue
+		}
+
+		if err := i.validator.Validate(&row.req); err != nil {
+			remark, _ := utils.AsRemark(err)
+			if err := reject(row, remark); err != nil {
+				return result, err
+			}
+			continue
+		}
+
+		keys := []struct {
+			key    string
+			remark *utils.Remark
+		}{
+			{"no_rekening:" + row.req.NoRekening, models.AccountWithNoRekeningIsExistErr},
+			{"nik:" + row.req.NIK, models.AccountWithNIKIsExistErr},
+			{"no_hp:" + row.req.NoHP, models.AccountWithNoHpKIsExistErr},
+		}
+		var duplicate *utils.Remark
+		for _, k := range keys {
+			if seen[k.key] {
+				duplicate = k.remark
+				break
+			}
+		}
+		if duplicate != nil {
+			if err := reject(row, duplicate); err != nil {
+				return result, err
+			}
+			continue
+		}
+
+		if _, err := i.importUsecase.ImportAccount(ctx, &row.req, dryRun); err != nil {
+			remark, ok := utils.AsRemark(err)
+			if !ok || remark.HTTPStatus() >= http.StatusInternalServerError {
+				return result, fmt.Errorf("row %d: %w", row.number, err)
+			}
+			if err := reject(row, remark); err != nil {
+				return result, err
+			}
+			continue
+		}
+
+		for _, k := range keys {
+			seen[k.key] = true
+		}
+		result.Imported++
+	}
+
+	i.logger.Info("Imported %d accounts, rejected %d", result.Imported, result.Rejected)
+	return result, nil
+}
+
+// describe returns the code and English message of a rejection, joining
+// the field errors of a failed validation.
+func describe(remark *utils.Remark) (string, string) {
+	localized := models.Messages.Localize(remark, utils.LangEN)
+
+	details, ok := localized.Remark.Object.([]*utils.Remark)
+	if !ok || len(details) == 0 {
+		return localized.Remark.Code, localized.Remark.Message
+	}
+
+	codes := make([]string, len(details))
+	messages := make([]string, len(details))
+	for i, detail := range details {
+		codes[i] = detail.Remark.Code
+		messages[i] = detail.Remark.Message
+	}
+	return strings.Join(codes, ";"), strings.Join(messages, "; ")
+}
+
+// readCSV reads the rows of a CSV file with a header naming Columns.
+func readCSV(r io.Reader) func() (*importRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	var (
+		header []string
+		number int
+	)
+	return func() (*importRow, error) {
+		if header == nil {
+			names, err := reader.Read()
+			if err == io.EOF {
+				return nil, errors.New("file is empty, expected a header")
+			}
+			if err != nil {
+				return nil, err
+			}
+			header, err = readHeader(names)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		record, err := reader.Read()
+		if err != nil && !errors.Is(err, csv.ErrFieldCount) {
+			return nil, err
+		}
+		number++
+
+		row := &importRow{number: number, values: map[string]string{}}
+		for i, value := range record {
+			if i < len(header) && header[i] != "" {
+				row.values[header[i]] = value
+			}
+		}
+		if err != nil {
+			row.err = models.ImportRowInvalidErr.WithParams(map[string]interface{}{"columns": strings.Join(Columns, ",")})
+			return row, nil
+		}
+
+		row.req = models.ImportAccountRequest{
+			NoRekening: row.values["no_rekening"],
+			Name:       row.values["name"],
+			NIK:        row.values["nik"],
+			NoHP:       row.values["no_hp"],
+		}
+		if balance := strings.TrimSpace(row.values["opening_balance"]); balance != "" {
+			amount, err := strconv.ParseFloat(balance, 64)
+			if err != nil || math.IsNaN(amount) || math.IsInf(amount, 0) {
+				row.err = models.ImportOpeningBalanceInvalidErr
+			}
+			row.req.OpeningBalance = amount
+		}
+		return row, nil
+	}
+}
+
+// readHeader returns the column of each field, "" for the columns of the
+// rejects file.
+func readHeader(names []string) ([]string, error) {
+	header := make([]string, len(names))
+	found := map[string]bool{}
+	for i, name := range names {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		switch {
+		case slices.Contains(rejectColumns, name):
+			continue
+		case !slices.Contains(Columns, name):
+			return nil, fmt.Errorf("unknown column %q, expected %s", name, strings.Join(Columns, ","))
+		case found[name]:
+			return nil, fmt.Errorf("column %q appears twice", name)
+		}
+		header[i] = name
+		found[name] = true
+	}
+	for _, column := range Columns {
+		if !found[column] {
+			return nil, fmt.Errorf("column %q is missing, expected %s", column, strings.Join(Columns, ","))
+		}
+	}
+	return header, nil
+}
+
+// readJSON reads the rows of a JSON array of accounts.
+func readJSON(r io.Reader) func() (*importRow, error) {
+	decoder := json.NewDecoder(r)
+
+	var (
+		started bool
+		number  int
+	)
+	return func() (*importRow, error) {
+		if !started {
+			token, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			if token != json.Delim('[') {
+				return nil, errors.New("expected an array of accounts")
+			}
+			started = true
+		}
+
+		if !decoder.More() {
+			if _, err := decoder.Token(); err != nil {
+				return nil, err
+			}
+			return nil, io.EOF
+		}
+
+		number++
+		row := &importRow{number: number}
+		err := decoder.Decode(&row.req)
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			row.err = models.ImportRowInvalidErr.WithParams(map[string]interface{}{"columns": strings.Join(Columns, ",")})
+		} else if err != nil {
+			return nil, fmt.Errorf("row %d: %w", number, err)
+		}
+
+		row.values = map[string]string{
+			"no_rekening":     row.req.NoRekening,
+			"name":            row.req.Name,
+			"nik":             row.req.NIK,
+			"no_hp":           row.req.NoHP,
+			"opening_balance": strconv.FormatFloat(row.req.OpeningBalance, 'f', -1, 64),
+		}
+		return row, nil
+	}
+}
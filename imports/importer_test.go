@@ -0,0 +1,157 @@
+package imports_test
+
+import (
+	"accounts-service/imports"
+	"accounts-service/models"
+	"accounts-service/repositories"
+	"accounts-service/usecases"
+	"accounts-service/utils"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImporter(t *testing.T) {
+	logger := utils.NewLogger("critical")
+	ctx := context.Background()
+
+	type env struct {
+		importer     *imports.Importer
+		accountRepo  repositories.AccountRepository
+		mutationRepo repositories.MutationRepository
+	}
+
+	setup := func(t *testing.T) env {
+		store := repositories.NewMemoryStore()
+		accountRepo := repositories.NewMemoryAccountRepository(store, logger)
+		mutationRepo := repositories.NewMemoryMutationRepository(store, logger)
+
+		// An account opened before the migration
+		require.NoError(t, accountRepo.CreateAccount(ctx, &models.Account{Name: "Siti Aminah", NIK: "3201014508950001", NoHP: "+6281234567890", NoRekening: "1744847261"}))
+
+		importer := imports.NewImporter(usecases.NewImportUsecase(repositories.NewMemoryTxManager(store, logger), accountRepo, mutationRepo, logger), logger)
+		return env{importer: importer, accountRepo: accountRepo, mutationRepo: mutationRepo}
+	}
+
+	// rejected returns the rows of a rejects file by their no_rekening.
+	rejected := func(t *testing.T, rejects *bytes.Buffer) map[string][]string {
+		t.Helper()
+		records, err := csv.NewReader(bytes.NewReader(rejects.Bytes())).ReadAll()
+		require.NoError(t, err)
+		require.NotEmpty(t, records)
+		assert.Equal(t, []string{"no_rekening", "name", "nik", "no_hp", "opening_balance", "row", "error_code", "error"}, records[0])
+
+		rows := map[string][]string{}
+		for _, record := range records[1:] {
+			rows[record[0]] = record
+		}
+		return rows
+	}
+
+	file := strings.Join([]string{
+		"no_rekening,name,nik,no_hp,opening_balance",
+		"0012345678,Budi Santoso,3201014508950002,0812-3456-7891,1500000.50",
+		"0012345679,Dewi Lestari,3201014508950003,081234567892,",
+		"0012345680,Rina,1234,081234567893,100",
+		"0012345681,Agus,3201014508950004,081234567894,seratus",
+		"1744847261,Joko,3201014508950005,081234567895,100",
+		"0012345682,Budi Kembar,3201014508950002,081234567896,100",
+		"0012345683,Kurang Kolom",
+	}, "\n") + "\n"
+
+	t.Run("imports valid rows and rejects the others", func(t *testing.T) {
+		e := setup(t)
+		var rejects bytes.Buffer
+
+		result, err := e.importer.Import(ctx, strings.NewReader(file), imports.FormatCSV, &rejects, false)
+		require.NoError(t, err)
+		assert.Equal(t, imports.Result{Imported: 2, Rejected: 5}, result)
+
+		budi, err := e.accountRepo.GetAccountByNoRekening(ctx, "0012345678")
+		require.NoError(t, err)
+		require.NotNil(t, budi)
+		assert.Equal(t, "+6281234567891", budi.NoHP)
+		assert.Equal(t, 1500000.5, budi.Saldo)
+
+		mutations, err := e.mutationRepo.ListMutations(ctx, budi.ID, 0, 10)
+		require.NoError(t, err)
+		require.Len(t, mutations, 1)
+		assert.Equal(t, models.MutationTypeCredit, mutations[0].Type)
+		assert.Equal(t, models.OpeningBalanceReference, mutations[0].Reference)
+
+		dewi, err := e.accountRepo.GetAccountByNoRekening(ctx, "0012345679")
+		require.NoError(t, err)
+		require.NotNil(t, dewi)
+		mutations, err = e.mutationRepo.ListMutations(ctx, dewi.ID, 0, 10)
+		require.NoError(t, err)
+		assert.Empty(t, mutations, "a zero opening balance posts nothing")
+
+		rows := rejected(t, &rejects)
+		assert.Len(t, rows, 5)
+		assert.Equal(t, []string{"0012345680", "Rina", "1234", "081234567893", "100", "3", models.AccountNikInvalid, "NIK must be 16 digits with a valid province code and birth date"}, rows["0012345680"])
+		assert.Equal(t, models.ImportOpeningBalanceInvalid, rows["0012345681"][6])
+		assert.Equal(t, models.AccountWithNoRekeningIsExist, rows["1744847261"][6])
+		assert.Equal(t, models.AccountWithNIKIsExist, rows["0012345682"][6])
+		assert.Equal(t, models.ImportRowInvalid, rows["0012345683"][6])
+	})
+
+	t.Run("dry run imports nothing and rejects the same rows", func(t *testing.T) {
+		e := setup(t)
+		var rejects bytes.Buffer
+
+		result, err := e.importer.Import(ctx, strings.NewReader(file), imports.FormatCSV, &rejects, true)
+		require.NoError(t, err)
+		assert.Equal(t, imports.Result{Imported: 2, Rejected: 5}, result)
+		assert.Equal(t, models.AccountWithNIKIsExist, rejected(t, &rejects)["0012345682"][6], "rows are checked against the earlier ones")
+
+		account, err := e.accountRepo.GetAccountByNoRekening(ctx, "0012345678")
+		require.NoError(t, err)
+		assert.Nil(t, account)
+	})
+
+	t.Run("a fixed rejects file is imported again", func(t *testing.T) {
+		e := setup(t)
+		var rejects bytes.Buffer
+
+		_, err := e.importer.Import(ctx, strings.NewReader(file), imports.FormatCSV, &rejects, false)
+		require.NoError(t, err)
+
+		fixed := strings.Replace(rejects.String(), "Rina,1234,", "Rina,3201014508950006,", 1)
+		var again bytes.Buffer
+		result, err := e.importer.Import(ctx, strings.NewReader(fixed), imports.FormatCSV, &again, false)
+		require.NoError(t, err)
+		assert.Equal(t, imports.Result{Imported: 1, Rejected: 4}, result)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		e := setup(t)
+		var rejects bytes.Buffer
+
+		result, err := e.importer.Import(ctx, strings.NewReader(`[
+			{"no_rekening":"0012345678","name":"Budi Santoso","nik":"3201014508950002","no_hp":"081234567891","opening_balance":250000},
+			{"no_rekening":"0012345679","name":"Dewi Lestari","nik":"3201014508950003","no_hp":"081234567892","opening_balance":"250000"},
+			{"no_rekening":"0012345680","name":"Rina","nik":"3201014508950004","no_hp":"081234567893","opening_balance":-5}
+		]`), imports.FormatJSON, &rejects, false)
+		require.NoError(t, err)
+		assert.Equal(t, imports.Result{Imported: 1, Rejected: 2}, result)
+
+		rows := rejected(t, &rejects)
+		assert.Equal(t, models.ImportRowInvalid, rows["0012345679"][6])
+		assert.Equal(t, models.ImportOpeningBalanceInvalid, rows["0012345680"][6])
+	})
+
+	t.Run("unreadable files stop the import", func(t *testing.T) {
+		e := setup(t)
+
+		_, err := e.importer.Import(ctx, strings.NewReader("no_rekening,name,nik\n"), imports.FormatCSV, &bytes.Buffer{}, false)
+		assert.ErrorContains(t, err, `column "no_hp" is missing`)
+
+		_, err = e.importer.Import(ctx, strings.NewReader(`{"no_rekening":"0012345678"}`), imports.FormatJSON, &bytes.Buffer{}, false)
+		assert.ErrorContains(t, err, "expected an array of accounts")
+	})
+}
@@ -0,0 +1,268 @@
+package iso8583
+
+import (
+	"accounts-service/models"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Transaction types, the first two digits of the processing code.
+const (
+	ProcessingWithdrawal     = "01"
+	ProcessingDeposit        = "21"
+	ProcessingBalanceInquiry = "31"
+)
+
+// Network management information codes of field 70.
+const (
+	NetworkSignOn   = "001"
+	NetworkSignOff  = "002"
+	NetworkEchoTest = "301"
+)
+
+// CurrencyIDR is the ISO 4217 code of the rupiah, the only currency served.
+// Amounts are in sen.
+const CurrencyIDR = "360"
+
+// References of the postings, followed by the terminal and the retrieval
+// reference number. A reversal finds the posting it undoes by them.
+const (
+	withdrawalReference = "TARIK TUNAI"
+	depositReference    = "SETOR TUNAI"
+)
+
+// errDuplicate is returned when a financial message was already posted,
+// sent again by a switch that did not see the response.
+var errDuplicate = errors.New("the posting was already made")
+
+// errReversed is returned when a financial message arrives after its
+// reversal, which the switch sent when it gave up waiting for it.
+var errReversed = errors.New("the posting was reversed before it was made")
+
+// echoedFields are copied from a request to its response.
+var echoedFields = []int{2, 3, 4, 7, 11, 12, 13, 32, 33, 37, 41, 42, 49, 70, 90, 102, 103}
+
+// handle answers req, returning why it is not approved with the response.
+func (s *Server) handle(ctx context.Context, req *Message) (*Message, error) {
+	switch req.MTI {
+	case "0800":
+		return s.networkManagement(req)
+	case "0200":
+		return s.financial(ctx, req)
+	case "0400", "0401", "0420", "0421":
+		return s.reversal(ctx, req)
+	}
+	return respond(req, ResponseInvalidTransaction), fmt.Errorf("MTI %s is not supported", req.MTI)
+}
+
+// networkManagement answers sign on, sign off and echo tests. There is no
+// session, every connection may send financial messages.
+func (s *Server) networkManagement(req *Message) (*Message, error) {
+	switch code := req.Fields[70]; code {
+	case NetworkSignOn, NetworkSignOff, NetworkEchoTest:
+		return respond(req, ResponseApproved), nil
+	default:
+		return respond(req, ResponseInvalidTransaction), fmt.Errorf("network management code %q is not supported", code)
+	}
+}
+
+// financial answers balance inquiries, cash withdrawals and deposits on the
+// account of field 102. A withdrawal or deposit is posted once, the same
+// terminal and retrieval reference number sent again is answered 94. One
+// reversed before it arrived is answered 05 without posting.
+func (s *Server) financial(ctx context.Context, req *Message) (*Message, error) {
+	processing, err := processingCode(req)
+	if err != nil {
+		return respond(req, ResponseFormatError), err
+	}
+	if currency, ok := req.Fields[49]; ok && currency != CurrencyIDR {
+		return respond(req, ResponseInvalidAmount), fmt.Errorf("currency %s is not supported", currency)
+	}
+
+	switch processing {
+	case ProcessingBalanceInquiry:
+		saldoReq := models.SaldoRequest{NoRekening: strings.TrimSpace(req.Fields[102])}
+		if err := s.validator.Validate(&saldoReq); err != nil {
+			return respond(req, responseCode(err)), err
+		}
+
+		saldo, err := s.accountUsecase.GetSaldo(ctx, saldoReq.NoRekening)
+		if err != nil {
+			return respond(req, responseCode(err)), err
+		}
+
+		resp := respond(req, ResponseApproved)
+		resp.Fields[54] = additionalAmounts(req.Fields[3][2:4], saldo.Saldo)
+		return resp, nil
+
+	case ProcessingWithdrawal, ProcessingDeposit:
+		reference, err := postingReference(req, processing)
+		if err != nil {
+			return respond(req, ResponseFormatError), err
+		}
+		txReq := &models.TransactionRequest{
+			NoRekening: req.Fields[102],
+			Nominal:    amount(req.Fields[4]),
+			Reference:  reference,
+		}
+		if err := s.validator.Validate(txReq); err != nil {
+			return respond(req, responseCode(err)), err
+		}
+
+		err = s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+			// Locking the account keeps the same message sent twice at once
+			// from posting twice
+			account, err := s.accountRepo.GetAccountByNoRekeningForUpdate(ctx, txReq.NoRekening)
+			if err != nil {
+				return err
+			}
+			if account == nil {
+				return models.AccountWithNoRekeningNotFoundErr
+			}
+
+			posted, err := s.mutationRepo.GetMutationByReference(ctx, account.ID, txReq.Reference)
+			if err != nil {
+				return err
+			}
+			if posted != nil {
+				return errDuplicate
+			}
+			reversed, err := s.mutationRepo.IsMarkedReversed(ctx, account.ID, txReq.Reference)
+			if err != nil {
+				return err
+			}
+			if reversed {
+				return errReversed
+			}
+
+			if processing == ProcessingWithdrawal {
+				return s.accountUsecase.Debit(ctx, txReq)
+			}
+			return s.accountUsecase.Credit(ctx, txReq)
+		})
+		if errors.Is(err, errDuplicate) {
+			return respond(req, ResponseDuplicate), err
+		}
+		if errors.Is(err, errReversed) {
+			return respond(req, ResponseDoNotHonor), err
+		}
+		if err != nil {
+			return respond(req, responseCode(err)), err
+		}
+		return respond(req, ResponseApproved), nil
+	}
+
+	return respond(req, ResponseInvalidTransaction), fmt.Errorf("processing code %s is not supported", req.Fields[3])
+}
+
+// reversal undoes the withdrawal or deposit with the terminal and retrieval
+// reference number of req, e.g. when the ATM could not dispense the cash or
+// the response timed out. A posting is reversed once, a reversal repeated
+// is approved again. A posting that was never made is answered 25 and
+// declined if it arrives later.
+func (s *Server) reversal(ctx context.Context, req *Message) (*Message, error) {
+	processing, err := processingCode(req)
+	if err != nil {
+		return respond(req, ResponseFormatError), err
+	}
+
+	switch processing {
+	case ProcessingBalanceInquiry:
+		// Nothing was posted
+		return respond(req, ResponseApproved), nil
+
+	case ProcessingWithdrawal, ProcessingDeposit:
+		reference, err := postingReference(req, processing)
+		if err != nil {
+			return respond(req, ResponseFormatError), err
+		}
+		reversalReq := &models.ReversalRequest{NoRekening: req.Fields[102], Reference: reference}
+		if err := s.validator.Validate(reversalReq); err != nil {
+			return respond(req, responseCode(err)), err
+		}
+
+		if err := s.reversalUsecase.Reverse(ctx, reversalReq); err != nil {
+			return respond(req, responseCode(err)), err
+		}
+		return respond(req, ResponseApproved), nil
+	}
+
+	return respond(req, ResponseInvalidTransaction), fmt.Errorf("processing code %s is not supported", req.Fields[3])
+}
+
+// respond returns the response to req with a response code and the fields
+// the switch matches it by.
+func respond(req *Message, code string) *Message {
+	resp := NewMessage(responseMTI(req.MTI))
+	for _, number := range echoedFields {
+		if value, ok := req.Fields[number]; ok {
+			resp.Fields[number] = value
+		}
+	}
+	resp.Fields[39] = code
+	return resp
+}
+
+// responseMTI returns the MTI of the response to a request, e.g. 0210 for
+// 0200 and 0410 for a repeated 0401.
+func responseMTI(mti string) string {
+	return mti[:2] + string(mti[2]+1) + "0"
+}
+
+// processingCode returns the transaction type of field 3.
+func processingCode(req *Message) (string, error) {
+	code, ok := req.Fields[3]
+	if !ok {
+		return "", errors.New("field 3 is missing")
+	}
+	return code[:2], nil
+}
+
+// postingReference returns the reference of the posting of req, which a
+// reversal of it repeats.
+func postingReference(req *Message, processing string) (string, error) {
+	terminal := strings.TrimSpace(req.Fields[41])
+	if terminal == "" {
+		return "", errors.New("field 41 is missing")
+	}
+	rrn := strings.TrimSpace(req.Fields[37])
+	if rrn == "" {
+		return "", errors.New("field 37 is missing")
+	}
+
+	label := depositReference
+	if processing == ProcessingWithdrawal {
+		label = withdrawalReference
+	}
+	return label + " " + terminal + " " + rrn, nil
+}
+
+// amount converts field 4 from sen, 0 when it is missing.
+func amount(field string) float64 {
+	sen, err := strconv.ParseInt(field, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return float64(sen) / 100
+}
+
+// additionalAmounts formats a balance for field 54, as ledger (01) and
+// available (02) balance: the account type, amount type, currency, C or D
+// and the amount in sen.
+func additionalAmounts(accountType string, saldo float64) string {
+	sign := "C"
+	if saldo < 0 {
+		sign = "D"
+	}
+	sen := int64(math.Round(math.Abs(saldo) * 100))
+
+	var b strings.Builder
+	for _, amountType := range []string{"01", "02"} {
+		fmt.Fprintf(&b, "%s%s%s%s%012d", accountType, amountType, CurrencyIDR, sign, sen)
+	}
+	return b.String()
+}
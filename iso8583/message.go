@@ -0,0 +1,258 @@
+package iso8583
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Field kinds. Numeric fields hold digits only, binary fields raw bytes,
+// the others any characters.
+const (
+	kindNumeric = "n"
+	kindText    = "ans"
+	kindBinary  = "b"
+)
+
+// fieldSpec describes a data element. A fixed field has length characters,
+// a variable one up to length after a decimal length of prefix digits.
+type fieldSpec struct {
+	kind   string
+	length int
+	prefix int
+}
+
+// fields are the ISO 8583:1987 data elements this server reads and writes.
+// A message with any other field is refused.
+var fields = map[int]fieldSpec{
+	2:   {kindNumeric, 19, 2}, // primary account number
+	3:   {kindNumeric, 6, 0},  // processing code
+	4:   {kindNumeric, 12, 0}, // amount, transaction
+	5:   {kindNumeric, 12, 0}, // amount, settlement
+	6:   {kindNumeric, 12, 0}, // amount, cardholder billing
+	7:   {kindNumeric, 10, 0}, // transmission date and time, MMDDhhmmss
+	9:   {kindNumeric, 8, 0},  // conversion rate, settlement
+	10:  {kindNumeric, 8, 0},  // conversion rate, cardholder billing
+	11:  {kindNumeric, 6, 0},  // system trace audit number
+	12:  {kindNumeric, 6, 0},  // time, local transaction
+	13:  {kindNumeric, 4, 0},  // date, local transaction
+	14:  {kindNumeric, 4, 0},  // date, expiration
+	15:  {kindNumeric, 4, 0},  // date, settlement
+	17:  {kindNumeric, 4, 0},  // date, capture
+	18:  {kindNumeric, 4, 0},  // merchant type
+	19:  {kindNumeric, 3, 0},  // acquiring institution country code
+	22:  {kindNumeric, 3, 0},  // point of service entry mode
+	23:  {kindNumeric, 3, 0},  // card sequence number
+	25:  {kindNumeric, 2, 0},  // point of service condition code
+	26:  {kindNumeric, 2, 0},  // point of service capture code
+	28:  {kindText, 9, 0},     // amount, transaction fee
+	32:  {kindNumeric, 11, 2}, // acquiring institution identification code
+	33:  {kindNumeric, 11, 2}, // forwarding institution identification code
+	35:  {kindText, 37, 2},    // track 2 data
+	37:  {kindText, 12, 0},    // retrieval reference number
+	38:  {kindText, 6, 0},     // authorization identification response
+	39:  {kindText, 2, 0},     // response code
+	41:  {kindText, 8, 0},     // card acceptor terminal identification
+	42:  {kindText, 15, 0},    // card acceptor identification code
+	43:  {kindText, 40, 0},    // card acceptor name and location
+	48:  {kindText, 999, 3},   // additional data, private
+	49:  {kindNumeric, 3, 0},  // currency code, transaction
+	52:  {kindBinary, 8, 0},   // PIN data
+	54:  {kindText, 120, 3},   // additional amounts
+	60:  {kindText, 999, 3},   // reserved national
+	61:  {kindText, 999, 3},   // reserved private
+	62:  {kindText, 999, 3},   // reserved private
+	63:  {kindText, 999, 3},   // reserved private
+	64:  {kindBinary, 8, 0},   // message authentication code
+	70:  {kindNumeric, 3, 0},  // network management information code
+	90:  {kindNumeric, 42, 0}, // original data elements
+	95:  {kindText, 42, 0},    // replacement amounts
+	100: {kindNumeric, 11, 2}, // receiving institution identification code
+	102: {kindText, 28, 2},    // account identification 1
+	103: {kindText, 28, 2},    // account identification 2
+	123: {kindText, 999, 3},   // reserved private
+	128: {kindBinary, 8, 0},   // message authentication code
+}
+
+// MaxFrameSize is the largest message a frame carries.
+const MaxFrameSize = 1<<16 - 1
+
+// Message is an ISO 8583 message, its MTI and data elements by number.
+// Values are kept as sent, numeric fields with their leading zeros.
+type Message struct {
+	MTI    string
+	Fields map[int]string
+}
+
+func NewMessage(mti string) *Message {
+	return &Message{MTI: mti, Fields: map[int]string{}}
+}
+
+// Pack encodes m as ASCII: the MTI, the bitmap as hexadecimal, then the
+// fields in order, variable fields after their decimal length.
+func (m *Message) Pack() ([]byte, error) {
+	if !isDigits(m.MTI) || len(m.MTI) != 4 {
+		return nil, fmt.Errorf("MTI must be 4 digits, got %q", m.MTI)
+	}
+
+	numbers := make([]int, 0, len(m.Fields))
+	for number := range m.Fields {
+		numbers = append(numbers, number)
+	}
+	sort.Ints(numbers)
+
+	bitmap := make([]byte, 8)
+	if len(numbers) > 0 && numbers[len(numbers)-1] > 64 {
+		bitmap = make([]byte, 16)
+		bitmap[0] |= 0x80
+	}
+
+	var body strings.Builder
+	for _, number := range numbers {
+		spec, ok := fields[number]
+		if !ok {
+			return nil, fmt.Errorf("field %d is not supported", number)
+		}
+		value := m.Fields[number]
+		if err := spec.check(value); err != nil {
+			return nil, fmt.Errorf("field %d: %w", number, err)
+		}
+
+		bitmap[(number-1)/8] |= 0x80 >> ((number - 1) % 8)
+		if spec.prefix > 0 {
+			fmt.Fprintf(&body, "%0*d", spec.prefix, len(value))
+		}
+		body.WriteString(value)
+	}
+
+	packed := m.MTI + strings.ToUpper(hex.EncodeToString(bitmap)) + body.String()
+	if len(packed) > MaxFrameSize {
+		return nil, fmt.Errorf("message is %d bytes, more than %d", len(packed), MaxFrameSize)
+	}
+	return []byte(packed), nil
+}
+
+// Unpack decodes a message packed like Pack does. When a field cannot be
+// read it returns the message with the fields before it, and the error.
+func Unpack(data []byte) (*Message, error) {
+	if len(data) < 4 || !isDigits(string(data[:4])) {
+		return nil, errors.New("message must start with a 4 digit MTI")
+	}
+	m := NewMessage(string(data[:4]))
+	data = data[4:]
+
+	bitmap, data, err := readBitmap(data)
+	if err != nil {
+		return m, err
+	}
+	if bitmap[0]&0x80 != 0 {
+		var secondary []byte
+		secondary, data, err = readBitmap(data)
+		if err != nil {
+			return m, fmt.Errorf("secondary %w", err)
+		}
+		bitmap = append(bitmap, secondary...)
+	}
+
+	for number := 2; number <= len(bitmap)*8; number++ {
+		if bitmap[(number-1)/8]&(0x80>>((number-1)%8)) == 0 {
+			continue
+		}
+		spec, ok := fields[number]
+		if !ok {
+			return m, fmt.Errorf("field %d is not supported", number)
+		}
+
+		length := spec.length
+		if spec.prefix > 0 {
+			if len(data) < spec.prefix || !isDigits(string(data[:spec.prefix])) {
+				return m, fmt.Errorf("field %d: length must be %d digits", number, spec.prefix)
+			}
+			length, _ = strconv.Atoi(string(data[:spec.prefix]))
+			data = data[spec.prefix:]
+		}
+		if len(data) < length {
+			return m, fmt.Errorf("field %d: message ends after %d of %d characters", number, len(data), length)
+		}
+
+		value := string(data[:length])
+		if err := spec.check(value); err != nil {
+			return m, fmt.Errorf("field %d: %w", number, err)
+		}
+		m.Fields[number] = value
+		data = data[length:]
+	}
+
+	if len(data) > 0 {
+		return m, fmt.Errorf("%d characters after the last field", len(data))
+	}
+	return m, nil
+}
+
+func readBitmap(data []byte) ([]byte, []byte, error) {
+	if len(data) < 16 {
+		return nil, nil, errors.New("bitmap must be 16 hexadecimal characters")
+	}
+	bitmap, err := hex.DecodeString(string(data[:16]))
+	if err != nil {
+		return nil, nil, errors.New("bitmap must be 16 hexadecimal characters")
+	}
+	return bitmap, data[16:], nil
+}
+
+func (s fieldSpec) check(value string) error {
+	if s.prefix == 0 && len(value) != s.length {
+		return fmt.Errorf("must be %d characters, got %d", s.length, len(value))
+	}
+	if s.prefix > 0 && len(value) > s.length {
+		return fmt.Errorf("must be at most %d characters, got %d", s.length, len(value))
+	}
+	if s.kind == kindNumeric && !isDigits(value) {
+		return errors.New("must be digits")
+	}
+	return nil
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// ReadFrame reads a message sent after its length as 2 bytes, big endian.
+func ReadFrame(r io.Reader) ([]byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, binary.BigEndian.Uint16(header[:]))
+	if _, err := io.ReadFull(r, data); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+// WriteFrame writes data after its length as 2 bytes, big endian.
+func WriteFrame(w io.Writer, data []byte) error {
+	if len(data) > MaxFrameSize {
+		return fmt.Errorf("message is %d bytes, more than %d", len(data), MaxFrameSize)
+	}
+
+	frame := make([]byte, 2+len(data))
+	binary.BigEndian.PutUint16(frame, uint16(len(data)))
+	copy(frame[2:], data)
+	_, err := w.Write(frame)
+	return err
+}
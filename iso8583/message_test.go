@@ -0,0 +1,78 @@
+package iso8583_test
+
+import (
+	"accounts-service/iso8583"
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessage_PackUnpack(t *testing.T) {
+	m := iso8583.NewMessage("0200")
+	m.Fields[3] = "011000"
+	m.Fields[4] = "000000150000"
+	m.Fields[11] = "000123"
+	m.Fields[41] = "ATM00001"
+	m.Fields[102] = "1744847261"
+
+	packed, err := m.Pack()
+	require.NoError(t, err)
+	assert.Equal(t, "0200"+"B020000000800000"+"0000000004000000"+"011000"+"000000150000"+"000123"+"ATM00001"+"10"+"1744847261", string(packed))
+
+	unpacked, err := iso8583.Unpack(packed)
+	require.NoError(t, err)
+	assert.Equal(t, m, unpacked)
+
+	t.Run("primary bitmap only", func(t *testing.T) {
+		m := iso8583.NewMessage("0800")
+		m.Fields[7] = "0419103000"
+		m.Fields[11] = "000001"
+
+		packed, err := m.Pack()
+		require.NoError(t, err)
+		assert.Equal(t, "0800"+"0220000000000000"+"0419103000"+"000001", string(packed))
+	})
+
+	t.Run("fields are checked", func(t *testing.T) {
+		for name, fields := range map[string]map[int]string{
+			"fixed length":    {11: "123"},
+			"variable length": {102: "12345678901234567890123456789"},
+			"numeric":         {4: "00000000150A"},
+			"unknown field":   {8: "00000000"},
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := (&iso8583.Message{MTI: "0200", Fields: fields}).Pack()
+				assert.Error(t, err)
+			})
+		}
+	})
+
+	t.Run("unpack keeps the fields read before an error", func(t *testing.T) {
+		m, err := iso8583.Unpack([]byte("0200" + "3020000000000000" + "011000" + "000000150000" + "12"))
+		assert.ErrorContains(t, err, "field 11: message ends after 2 of 6 characters")
+		require.NotNil(t, m)
+		assert.Equal(t, map[int]string{3: "011000", 4: "000000150000"}, m.Fields)
+
+		_, err = iso8583.Unpack([]byte("0200" + "3020000000000000" + "011000" + "000000150000" + "000123" + "XYZ"))
+		assert.ErrorContains(t, err, "3 characters after the last field")
+
+		m, err = iso8583.Unpack([]byte("02x0"))
+		assert.Error(t, err)
+		assert.Nil(t, m)
+	})
+}
+
+func TestFrame(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, iso8583.WriteFrame(&buf, []byte("0800")))
+	assert.Equal(t, []byte{0x00, 0x04, '0', '8', '0', '0'}, buf.Bytes())
+
+	data, err := iso8583.ReadFrame(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, "0800", string(data))
+
+	_, err = iso8583.ReadFrame(bytes.NewReader([]byte{0x00, 0x04, '0'}))
+	assert.Error(t, err)
+}
@@ -0,0 +1,81 @@
+package iso8583
+
+import (
+	"accounts-service/models"
+	"accounts-service/utils"
+	"context"
+	"errors"
+	"net/http"
+)
+
+// Response codes of field 39.
+const (
+	ResponseApproved           = "00"
+	ResponseDoNotHonor         = "05"
+	ResponseError              = "06"
+	ResponseInvalidTransaction = "12"
+	ResponseInvalidAmount      = "13"
+	ResponseInvalidAccount     = "14"
+	ResponseRecordNotFound     = "25"
+	ResponseFormatError        = "30"
+	ResponseInsufficientFunds  = "51"
+	ResponseIssuerUnavailable  = "91"
+	ResponseDuplicate          = "94"
+	ResponseSystemMalfunction  = "96"
+)
+
+// responseCodesByRemark maps the Remark codes a switch acts on to their
+// response code.
+var responseCodesByRemark = map[string]string{
+	models.AccountWithNoRekeningNotFound: ResponseInvalidAccount,
+	models.AccountNoRekeningInvalid:      ResponseInvalidAccount,
+	models.AccountParamNoRekeningEmpty:   ResponseInvalidAccount,
+	models.AccountNominalInvalid:         ResponseInvalidAmount,
+	models.AccountParamNominalLessZero:   ResponseInvalidAmount,
+	models.Accountinsufficient:           ResponseInsufficientFunds,
+	models.ReversalOriginalNotFound:      ResponseRecordNotFound,
+	models.QueryTimeout:                  ResponseIssuerUnavailable,
+}
+
+// responseCodesByHTTPStatus maps the HTTP status of the other Remarks.
+var responseCodesByHTTPStatus = map[int]string{
+	http.StatusBadRequest:          ResponseFormatError,
+	http.StatusNotFound:            ResponseRecordNotFound,
+	http.StatusConflict:            ResponseDuplicate,
+	http.StatusUnprocessableEntity: ResponseDoNotHonor,
+	http.StatusServiceUnavailable:  ResponseIssuerUnavailable,
+	http.StatusGatewayTimeout:      ResponseIssuerUnavailable,
+	http.StatusInternalServerError: ResponseSystemMalfunction,
+}
+
+// responseCode maps err to a response code by its Remark code, or by its
+// HTTP status when the code has no response code of its own. A failed
+// validation answers with the response code of its first mapped field.
+func responseCode(err error) string {
+	if err == nil {
+		return ResponseApproved
+	}
+
+	remark, ok := utils.AsRemark(err)
+	if !ok {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return ResponseIssuerUnavailable
+		}
+		return ResponseSystemMalfunction
+	}
+
+	if code, ok := responseCodesByRemark[remark.Remark.Code]; ok {
+		return code
+	}
+	if details, ok := remark.Remark.Object.([]*utils.Remark); ok {
+		for _, detail := range details {
+			if code, ok := responseCodesByRemark[detail.Remark.Code]; ok {
+				return code
+			}
+		}
+	}
+	if code, ok := responseCodesByHTTPStatus[remark.HTTPStatus()]; ok {
+		return code
+	}
+	return ResponseError
+}
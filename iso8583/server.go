@@ -0,0 +1,282 @@
+package iso8583
+
+import (
+	"accounts-service/models"
+	"accounts-service/repositories"
+	"accounts-service/usecases"
+	"accounts-service/utils"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// ErrServerClosed is returned by Serve after Shutdown.
+var ErrServerClosed = errors.New("iso8583: server closed")
+
+// Options configure the ISO 8583 server.
+type Options struct {
+	// QueryTimeout bounds every message like DB_QUERY_TIMEOUT bounds REST
+	// requests. 0 disables it.
+	QueryTimeout time.Duration
+	// IdleTimeout closes connections that send nothing for that long,
+	// switches keep theirs open with 0800 echo tests. 0 disables it.
+	IdleTimeout time.Duration
+}
+
+// Server answers ISO 8583 messages of an ATM or EDC switch over TCP. Every
+// message is framed by ReadFrame and WriteFrame. Messages of a connection
+// are handled concurrently, so responses may come out of order and are
+// matched by the switch on their STAN.
+type Server struct {
+	txManager       repositories.TxManager
+	accountRepo     repositories.AccountRepository
+	mutationRepo    repositories.MutationRepository
+	accountUsecase  usecases.AccountUsecase
+	reversalUsecase usecases.ReversalUsecase
+	validator       *utils.RequestValidator
+	options         Options
+	logger          utils.Logger
+
+	// ctx is cancelled when Shutdown gives up waiting for the messages in
+	// flight
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu        sync.Mutex
+	listeners map[net.Listener]struct{}
+	conns     map[net.Conn]struct{}
+	closed    bool
+	wg        sync.WaitGroup
+}
+
+func NewServer(txManager repositories.TxManager, accountRepo repositories.AccountRepository, mutationRepo repositories.MutationRepository, accountUsecase usecases.AccountUsecase, reversalUsecase usecases.ReversalUsecase, options Options, logger utils.Logger) *Server {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Server{
+		txManager:       txManager,
+		accountRepo:     accountRepo,
+		mutationRepo:    mutationRepo,
+		accountUsecase:  accountUsecase,
+		reversalUsecase: reversalUsecase,
+		validator:       utils.NewRequestValidator(models.RequestValidationRemarks),
+		options:         options,
+		logger:          logger,
+		ctx:             ctx,
+		cancel:          cancel,
+		listeners:       map[net.Listener]struct{}{},
+		conns:           map[net.Conn]struct{}{},
+	}
+}
+
+// Serve accepts connections on listener until Shutdown, then returns
+// ErrServerClosed.
+func (s *Server) Serve(listener net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		listener.Close()
+		return ErrServerClosed
+	}
+	s.listeners[listener] = struct{}{}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.listeners, listener)
+		s.mu.Unlock()
+		listener.Close()
+	}()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return ErrServerClosed
+			}
+
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				s.logger.Warning("ISO 8583 accept error: %v", err)
+				time.Sleep(100 * time.Millisecond)
+				continue
+			}
+			return err
+		}
+
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			return ErrServerClosed
+		}
+		s.conns[conn] = struct{}{}
+		s.wg.Add(1)
+		s.mu.Unlock()
+
+		go s.serveConn(conn)
+	}
+}
+
+// Shutdown stops accepting connections and reading messages, and waits
+// for the messages in flight to be answered. When ctx is done first the
+// remaining messages are cancelled and their connections closed.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.closed = true
+	for listener := range s.listeners {
+		listener.Close()
+	}
+	// Wake the readers, they stop once their messages are answered
+	for conn := range s.conns {
+		conn.SetReadDeadline(time.Now())
+	}
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.cancel()
+		s.mu.Lock()
+		for conn := range s.conns {
+			conn.Close()
+		}
+		s.mu.Unlock()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// serveConn reads the messages of conn until it is closed, idle or the
+// server shuts down, and answers each in its own goroutine.
+func (s *Server) serveConn(conn net.Conn) {
+	defer s.wg.Done()
+
+	remote := conn.RemoteAddr()
+	s.logger.Info("ISO 8583 connection from %s", remote)
+
+	var (
+		writeMu  sync.Mutex
+		inFlight sync.WaitGroup
+	)
+	defer func() {
+		inFlight.Wait()
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+		s.logger.Info("ISO 8583 connection from %s closed", remote)
+	}()
+
+	for {
+		if s.options.IdleTimeout > 0 {
+			conn.SetReadDeadline(time.Now().Add(s.options.IdleTimeout))
+		}
+
+		s.mu.Lock()
+		closed := s.closed
+		s.mu.Unlock()
+		if closed {
+			return
+		}
+
+		data, err := ReadFrame(conn)
+		if err != nil {
+			var netErr net.Error
+			switch {
+			case errors.Is(err, io.EOF), errors.Is(err, net.ErrClosed):
+			case errors.As(err, &netErr) && netErr.Timeout():
+				s.mu.Lock()
+				closed := s.closed
+				s.mu.Unlock()
+				if !closed {
+					s.logger.Warning("ISO 8583 connection from %s idle, closing", remote)
+				}
+			default:
+				s.logger.Warning("ISO 8583 connection from %s: %v", remote, err)
+			}
+			return
+		}
+
+		inFlight.Add(1)
+		go func() {
+			defer inFlight.Done()
+
+			resp := s.answer(data, remote)
+			if resp == nil {
+				return
+			}
+			packed, err := resp.Pack()
+			if err != nil {
+				s.logger.Error("ISO 8583 %s response: %v", resp.MTI, err)
+				return
+			}
+
+			writeMu.Lock()
+			defer writeMu.Unlock()
+			if err := WriteFrame(conn, packed); err != nil {
+				s.logger.Warning("ISO 8583 connection from %s: %v", remote, err)
+			}
+		}()
+	}
+}
+
+// answer unpacks a message and returns its response, nil for messages
+// that are not answered.
+func (s *Server) answer(data []byte, remote net.Addr) (resp *Message) {
+	req, err := Unpack(data)
+	if req == nil {
+		s.logger.Warning("ISO 8583 message from %s dropped: %v", remote, err)
+		return nil
+	}
+	if !isRequest(req.MTI) {
+		s.logger.Warning("ISO 8583 %s from %s dropped, not a request", req.MTI, remote)
+		return nil
+	}
+	if err != nil {
+		s.logger.Warning("ISO 8583 %s from %s: %v", req.MTI, remote, err)
+		return respond(req, ResponseFormatError)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.Error("ISO 8583 %s panic: %v\n%s", req.MTI, r, debug.Stack())
+			resp = respond(req, ResponseSystemMalfunction)
+		}
+	}()
+
+	ctx := s.ctx
+	if s.options.QueryTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.options.QueryTimeout)
+		defer cancel()
+	}
+
+	resp, cause := s.handle(ctx, req)
+	switch code := resp.Fields[39]; code {
+	case ResponseApproved:
+	case ResponseSystemMalfunction, ResponseIssuerUnavailable:
+		s.logger.Error("ISO 8583 %s STAN %s from %s answered %s: %v", req.MTI, req.Fields[11], remote, code, cause)
+	default:
+		s.logger.Warning("ISO 8583 %s STAN %s from %s answered %s: %v", req.MTI, req.Fields[11], remote, code, cause)
+	}
+	return resp
+}
+
+// isRequest reports whether mti is a request or an advice, which are
+// answered, rather than a response.
+func isRequest(mti string) bool {
+	return (mti[2]-'0')%2 == 0
+}
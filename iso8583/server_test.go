@@ -0,0 +1,245 @@
+package iso8583_test
+
+import (
+	"accounts-service/iso8583"
+	"accounts-service/models"
+	"accounts-service/repositories"
+	"accounts-service/usecases"
+	"accounts-service/utils"
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type env struct {
+	server       *iso8583.Server
+	served       chan error
+	addr         string
+	accountRepo  repositories.AccountRepository
+	mutationRepo repositories.MutationRepository
+}
+
+func newServer(t *testing.T) *env {
+	logger := utils.NewLogger("critical")
+	store := repositories.NewMemoryStore()
+	txManager := repositories.NewMemoryTxManager(store, logger)
+	accountRepo := repositories.NewMemoryAccountRepository(store, logger)
+	mutationRepo := repositories.NewMemoryMutationRepository(store, logger)
+	outboxRepo := repositories.NewMemoryOutboxRepository(store, logger)
+	balanceCache := repositories.NewNoopBalanceCache()
+
+	server := iso8583.NewServer(
+		txManager,
+		accountRepo,
+		mutationRepo,
+		usecases.NewAccountUsecase(txManager, accountRepo, mutationRepo, outboxRepo, balanceCache, logger),
+		usecases.NewReversalUsecase(txManager, accountRepo, mutationRepo, outboxRepo, balanceCache, logger),
+		iso8583.Options{QueryTimeout: time.Second},
+		logger,
+	)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	served := make(chan error, 1)
+	go func() { served <- server.Serve(listener) }()
+	t.Cleanup(func() { server.Shutdown(context.Background()) })
+
+	return &env{server: server, served: served, addr: listener.Addr().String(), accountRepo: accountRepo, mutationRepo: mutationRepo}
+}
+
+// dial connects a switch to the server.
+func (e *env) dial(t *testing.T) net.Conn {
+	conn, err := net.Dial("tcp", e.addr)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// send sends req and returns its response.
+func send(t *testing.T, conn net.Conn, req *iso8583.Message) *iso8583.Message {
+	t.Helper()
+	packed, err := req.Pack()
+	require.NoError(t, err)
+	require.NoError(t, iso8583.WriteFrame(conn, packed))
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	data, err := iso8583.ReadFrame(conn)
+	require.NoError(t, err)
+	resp, err := iso8583.Unpack(data)
+	require.NoError(t, err)
+	return resp
+}
+
+// financial returns a request of the ATM00001 terminal for the account.
+func financial(mti, processing, amount, stan, noRekening string) *iso8583.Message {
+	m := iso8583.NewMessage(mti)
+	m.Fields[3] = processing
+	if amount != "" {
+		m.Fields[4] = amount
+	}
+	m.Fields[7] = "0419103000"
+	m.Fields[11] = stan
+	m.Fields[32] = "008"
+	m.Fields[37] = "000000" + stan
+	m.Fields[41] = "ATM00001"
+	m.Fields[49] = iso8583.CurrencyIDR
+	m.Fields[102] = noRekening
+	return m
+}
+
+func TestServer(t *testing.T) {
+	ctx := context.Background()
+	e := newServer(t)
+	conn := e.dial(t)
+
+	account := &models.Account{Name: "Siti Aminah", NIK: "3201014508950001", NoHP: "+6281234567890", NoRekening: "1744847261"}
+	require.NoError(t, e.accountRepo.CreateAccount(ctx, account))
+	require.NoError(t, e.accountRepo.UpdateSaldo(ctx, account.ID, 1000000))
+
+	saldo := func(t *testing.T) float64 {
+		got, err := e.accountRepo.GetAccountByNoRekening(ctx, account.NoRekening)
+		require.NoError(t, err)
+		return got.Saldo
+	}
+
+	t.Run("echo test", func(t *testing.T) {
+		req := iso8583.NewMessage("0800")
+		req.Fields[7] = "0419103000"
+		req.Fields[11] = "000001"
+		req.Fields[70] = iso8583.NetworkEchoTest
+
+		resp := send(t, conn, req)
+		assert.Equal(t, "0810", resp.MTI)
+		assert.Equal(t, iso8583.ResponseApproved, resp.Fields[39])
+		assert.Equal(t, "000001", resp.Fields[11])
+		assert.Equal(t, iso8583.NetworkEchoTest, resp.Fields[70])
+	})
+
+	t.Run("balance inquiry", func(t *testing.T) {
+		resp := send(t, conn, financial("0200", "311000", "", "000002", account.NoRekening))
+		assert.Equal(t, "0210", resp.MTI)
+		assert.Equal(t, iso8583.ResponseApproved, resp.Fields[39])
+		assert.Equal(t, "1001360C000100000000"+"1002360C000100000000", resp.Fields[54])
+	})
+
+	t.Run("cash withdrawal", func(t *testing.T) {
+		resp := send(t, conn, financial("0200", "011000", "000000150050", "000003", account.NoRekening))
+		assert.Equal(t, "0210", resp.MTI)
+		assert.Equal(t, iso8583.ResponseApproved, resp.Fields[39])
+		assert.Equal(t, "000000150050", resp.Fields[4])
+		assert.Equal(t, "000000000003", resp.Fields[37])
+		assert.Equal(t, 998499.5, saldo(t))
+
+		mutations, err := e.mutationRepo.ListMutations(ctx, account.ID, 0, 1)
+		require.NoError(t, err)
+		require.Len(t, mutations, 1)
+		assert.Equal(t, models.MutationTypeDebit, mutations[0].Type)
+		assert.Equal(t, "TARIK TUNAI ATM00001 000000000003", mutations[0].Reference)
+	})
+
+	t.Run("a withdrawal sent again is a duplicate", func(t *testing.T) {
+		resp := send(t, conn, financial("0200", "011000", "000000150050", "000003", account.NoRekening))
+		assert.Equal(t, "0210", resp.MTI)
+		assert.Equal(t, iso8583.ResponseDuplicate, resp.Fields[39])
+		assert.Equal(t, 998499.5, saldo(t), "posted once")
+	})
+
+	t.Run("reversal is posted once", func(t *testing.T) {
+		resp := send(t, conn, financial("0400", "011000", "000000150050", "000003", account.NoRekening))
+		assert.Equal(t, "0410", resp.MTI)
+		assert.Equal(t, iso8583.ResponseApproved, resp.Fields[39])
+		assert.Equal(t, float64(1000000), saldo(t))
+
+		resp = send(t, conn, financial("0401", "011000", "000000150050", "000003", account.NoRekening))
+		assert.Equal(t, "0410", resp.MTI)
+		assert.Equal(t, iso8583.ResponseApproved, resp.Fields[39])
+		assert.Equal(t, float64(1000000), saldo(t))
+
+		mutations, err := e.mutationRepo.ListMutations(ctx, account.ID, 0, 10)
+		require.NoError(t, err)
+		require.Len(t, mutations, 2)
+		assert.Equal(t, models.MutationTypeCredit, mutations[0].Type)
+		assert.Equal(t, "REVERSAL TARIK TUNAI ATM00001 000000000003", mutations[0].Reference)
+	})
+
+	t.Run("a withdrawal arriving after its reversal is declined", func(t *testing.T) {
+		resp := send(t, conn, financial("0400", "011000", "000000150050", "000005", account.NoRekening))
+		assert.Equal(t, iso8583.ResponseRecordNotFound, resp.Fields[39])
+
+		resp = send(t, conn, financial("0200", "011000", "000000150050", "000005", account.NoRekening))
+		assert.Equal(t, "0210", resp.MTI)
+		assert.Equal(t, iso8583.ResponseDoNotHonor, resp.Fields[39])
+		assert.Equal(t, float64(1000000), saldo(t), "nothing posted")
+
+		resp = send(t, conn, financial("0401", "011000", "000000150050", "000005", account.NoRekening))
+		assert.Equal(t, iso8583.ResponseRecordNotFound, resp.Fields[39], "the reversal repeated finds nothing either")
+	})
+
+	t.Run("cash deposit", func(t *testing.T) {
+		resp := send(t, conn, financial("0200", "210010", "000000500000", "000004", account.NoRekening))
+		assert.Equal(t, iso8583.ResponseApproved, resp.Fields[39])
+		assert.Equal(t, float64(1005000), saldo(t))
+	})
+
+	for name, test := range map[string]struct {
+		req  *iso8583.Message
+		code string
+	}{
+		"insufficient saldo":         {financial("0200", "011000", "000200000000", "000010", account.NoRekening), iso8583.ResponseInsufficientFunds},
+		"unknown account":            {financial("0200", "011000", "000000010000", "000011", "9999999999"), iso8583.ResponseInvalidAccount},
+		"invalid account":            {financial("0200", "311000", "", "000012", "12ab"), iso8583.ResponseInvalidAccount},
+		"zero amount":                {financial("0200", "011000", "000000000000", "000013", account.NoRekening), iso8583.ResponseInvalidAmount},
+		"unknown transaction":        {financial("0200", "401000", "000000010000", "000014", account.NoRekening), iso8583.ResponseInvalidTransaction},
+		"reversal of nothing posted": {financial("0400", "011000", "000000010000", "000015", account.NoRekening), iso8583.ResponseRecordNotFound},
+		"unsupported MTI":            {financial("0100", "011000", "000000010000", "000016", account.NoRekening), iso8583.ResponseInvalidTransaction},
+	} {
+		t.Run(name, func(t *testing.T) {
+			resp := send(t, conn, test.req)
+			assert.Equal(t, test.code, resp.Fields[39])
+			assert.Equal(t, test.req.Fields[11], resp.Fields[11])
+		})
+	}
+	assert.Equal(t, float64(1005000), saldo(t), "declined messages post nothing")
+
+	t.Run("format error", func(t *testing.T) {
+		req := financial("0200", "011000", "000000010000", "000020", account.NoRekening)
+		delete(req.Fields, 41)
+		resp := send(t, conn, req)
+		assert.Equal(t, iso8583.ResponseFormatError, resp.Fields[39])
+
+		// A field cut short is answered with the fields read before it
+		require.NoError(t, iso8583.WriteFrame(conn, []byte("0200"+"3020000000000000"+"011000"+"000000010000"+"0000")))
+		data, err := iso8583.ReadFrame(conn)
+		require.NoError(t, err)
+		resp, err = iso8583.Unpack(data)
+		require.NoError(t, err)
+		assert.Equal(t, "0210", resp.MTI)
+		assert.Equal(t, iso8583.ResponseFormatError, resp.Fields[39])
+		assert.Equal(t, "000000010000", resp.Fields[4])
+	})
+}
+
+func TestServer_Shutdown(t *testing.T) {
+	e := newServer(t)
+	conn := e.dial(t)
+
+	req := iso8583.NewMessage("0800")
+	req.Fields[11] = "000001"
+	req.Fields[70] = iso8583.NetworkSignOn
+	assert.Equal(t, iso8583.ResponseApproved, send(t, conn, req).Fields[39])
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, e.server.Shutdown(ctx))
+	assert.ErrorIs(t, <-e.served, iso8583.ErrServerClosed)
+
+	// The connection is closed
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	_, err := iso8583.ReadFrame(conn)
+	assert.ErrorIs(t, err, io.EOF)
+}
@@ -1,20 +1,33 @@
 package main
 
 import (
+	"accounts-service/clearing"
 	"accounts-service/config"
+	"accounts-service/grpcserver"
 	"accounts-service/handlers"
-	"accounts-service/repositories"
+	"accounts-service/imports"
+	"accounts-service/iso8583"
+	"accounts-service/models"
+	"accounts-service/snap"
+	"accounts-service/statements"
 	"accounts-service/usecases"
 	"accounts-service/utils"
 	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"net"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"google.golang.org/grpc"
 )
 
 func main() {
@@ -27,42 +40,380 @@ func main() {
 		log.Fatalf("Error loading config: %v", err)
 	}
 
+	// Commands that only need the configuration
+	if args.Command == "config" {
+		if err := configCommand(cfg, args.CommandArgs); err != nil {
+			log.Fatalf("config: %v", err)
+		}
+		return
+	}
+
 	// Initialize for logger
 	logger := utils.NewLogger(cfg.LogLevel)
 
-	// Initialize database connection
-	db, err := config.NewDatabaseConnection(cfg)
+	switch args.Command {
+	case "", "serve":
+		err = serve(cfg, logger)
+	case "migrate":
+		err = migrate(cfg, logger, args.CommandArgs)
+	case "statements":
+		err = statementsCommand(cfg, logger, args.CommandArgs)
+	case "import":
+		err = importCommand(cfg, logger, args.CommandArgs)
+	case "clearing":
+		err = clearingCommand(cfg, logger, args.CommandArgs)
+	default:
+		logger.Critical("Unknown command %q, expected serve, migrate, statements, import, clearing or config", args.Command)
+	}
+
+	if err != nil {
+		logger.Critical("%s: %v", args.Command, err)
+	}
+}
+
+// configCommand runs `config print`, showing the effective configuration with
+// secrets masked.
+func configCommand(cfg *config.Config, args []string) error {
+	if len(args) == 0 || args[0] != "print" {
+		return errors.New("expected `config print`")
+	}
+
+	return cfg.Print(os.Stdout)
+}
+
+// migrate runs `migrate up|down|status|redo`.
+func migrate(cfg *config.Config, logger utils.Logger, args []string) error {
+	if cfg.Storage == config.StorageMemory {
+		return fmt.Errorf("migrations do not apply to %s storage", config.StorageMemory)
+	}
+
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	db, migrator, err := openDatabase(cfg, logger)
 	if err != nil {
-		logger.Critical("Failed to connect to database: %v", err)
-		os.Exit(1)
+		return err
 	}
 	defer db.Close()
 
-	// Initialize repositories
-	accountRepo := repositories.NewAccountRepository(db, logger)
-	mutationRepo := repositories.NewMutationRepository(db, logger)
+	return migrator.Run(context.Background(), command)
+}
+
+// statementsCommand runs `statements generate [YYYY-MM]`, archiving the
+// monthly statements of a month, the previous one by default. Statements
+// already generated are kept, so it also resumes an interrupted run.
+func statementsCommand(cfg *config.Config, logger utils.Logger, args []string) error {
+	if len(args) == 0 || args[0] != "generate" {
+		return errors.New("expected `statements generate [YYYY-MM]`")
+	}
+	if cfg.Storage == config.StorageMemory {
+		return fmt.Errorf("statements cannot be archived from %s storage", config.StorageMemory)
+	}
+
+	period := models.PreviousMonthlyStatementPeriod(time.Now())
+	if len(args) > 1 {
+		period = args[1]
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	store, err := openStorage(ctx, cfg, logger)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	archiver, release, err := newArchiver(cfg, store, logger)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	result, err := archiver.Archive(ctx, period)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s: %d generated, %d failed\n", period, result.Generated, result.Failed)
+	if result.Failed > 0 {
+		return fmt.Errorf("%d statements failed, run the command again to retry them", result.Failed)
+	}
+	return nil
+}
+
+// importCommand runs `import [-dry-run] [-rejects FILE] FILE`, importing the
+// accounts of the legacy core with their no rekening and opening balance
+// from a CSV or JSON file. Rejected rows are written to the rejects file,
+// FILE with .rejects.csv by default, with the error of each.
+func importCommand(cfg *config.Config, logger utils.Logger, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "Check the accounts without importing them")
+	rejectsPath := flags.String("rejects", "", "CSV file of the rejected rows, FILE with .rejects.csv by default")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("expected `import [-dry-run] [-rejects FILE] FILE` with a .csv or .json FILE")
+	}
+	if cfg.Storage == config.StorageMemory {
+		return fmt.Errorf("accounts cannot be imported into %s storage", config.StorageMemory)
+	}
+
+	path := flags.Arg(0)
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	if format != imports.FormatCSV && format != imports.FormatJSON {
+		return fmt.Errorf("cannot import %s, expected a .csv or .json file", path)
+	}
+	if *rejectsPath == "" {
+		*rejectsPath = strings.TrimSuffix(path, filepath.Ext(path)) + ".rejects.csv"
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	rejects, err := os.Create(*rejectsPath)
+	if err != nil {
+		return err
+	}
+	defer rejects.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	store, err := openStorage(ctx, cfg, logger)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	importer := imports.NewImporter(usecases.NewImportUsecase(store.txManager, store.accountRepo, store.mutationRepo, logger), logger)
+	result, err := importer.Import(ctx, file, format, rejects, *dryRun)
+
+	// Counted up to an error too, the rows imported before it are kept
+	if *dryRun {
+		fmt.Printf("%s: %d valid, %d rejected, nothing imported\n", path, result.Imported, result.Rejected)
+	} else {
+		fmt.Printf("%s: %d imported, %d rejected\n", path, result.Imported, result.Rejected)
+	}
+	if err != nil {
+		return err
+	}
+	if result.Rejected > 0 {
+		return fmt.Errorf("%d accounts rejected, see %s", result.Rejected, *rejectsPath)
+	}
+	return nil
+}
+
+// clearingCommand runs `clearing cut`, writing the batch file of the last
+// cut-off passed when the clearer has not, or `clearing import FILE`,
+// settling and rejecting the transfers of a settlement file. Transfers
+// already completed are skipped, so a file can be imported again.
+func clearingCommand(cfg *config.Config, logger utils.Logger, args []string) error {
+	if len(args) == 0 || (args[0] != "cut" && args[0] != "import") || (args[0] == "import") != (len(args) == 2) {
+		return errors.New("expected `clearing cut` or `clearing import FILE`")
+	}
+	if !cfg.InterbankEnabled {
+		return errors.New("interbank transfers are disabled, set INTERBANK_ENABLED")
+	}
+	if cfg.Storage == config.StorageMemory {
+		return fmt.Errorf("clearing cannot run from %s storage", config.StorageMemory)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	store, err := openStorage(ctx, cfg, logger)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	clearer, err := newClearer(cfg, store, logger)
+	if err != nil {
+		return err
+	}
+
+	if args[0] == "cut" {
+		cutoffs, err := cfg.InterbankCutoffTimes()
+		if err != nil {
+			return err
+		}
+		batch, err := clearer.Cut(ctx, clearing.LastCutoff(time.Now(), cutoffs))
+		if err != nil {
+			return err
+		}
+		if batch == nil {
+			fmt.Println("nothing to cut")
+			return nil
+		}
+		fmt.Printf("%s: %d transfers, %.2f total, written to %s\n", batch.Reference, batch.TransferCount, batch.TotalNominal, batch.BlobKey)
+		return nil
+	}
+
+	path := args[1]
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	result, err := clearer.Import(ctx, file)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s: %d settled, %d rejected, %d skipped, %d failed\n", path, result.Settled, result.Rejected, result.Skipped, result.Failed)
+	if result.Failed > 0 {
+		return fmt.Errorf("%d transfers failed, run the command again to retry them", result.Failed)
+	}
+	return nil
+}
+
+func serve(cfg *config.Config, logger utils.Logger) error {
+	ctx := context.Background()
+
+	// Initialize repositories for the configured storage
+	store, err := openStorage(ctx, cfg, logger)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
 
 	// Initialize usecase
-	accountUsecase := usecases.NewAccountUsecase(accountRepo, mutationRepo, logger)
+	accountUsecase := usecases.NewAccountUsecase(store.txManager, store.accountRepo, store.mutationRepo, store.outboxRepo, store.balanceCache, logger)
+
+	// Start the outbox relay, webhook dispatcher and notifications
+	workersCtx, stopWorkers := context.WithCancel(ctx)
+	workersDone, err := startWorkers(workersCtx, cfg, store, logger)
+	if err != nil {
+		stopWorkers()
+		return err
+	}
+	defer func() {
+		stopWorkers()
+		<-workersDone
+	}()
 
 	// Initialize handler
 	accountHandler := handlers.NewAccountHandler(accountUsecase, logger)
+	notificationHandler := handlers.NewNotificationHandler(usecases.NewNotificationUsecase(store.accountRepo, store.preferenceRepo, logger), logger)
+	statementHandler := handlers.NewStatementHandler(
+		usecases.NewStatementUsecase(store.accountRepo, store.mutationRepo, logger),
+		statements.Header{BankName: cfg.StatementBankName, Address: cfg.StatementBankAddress},
+		cfg.StatementTimeout,
+		logger,
+	)
+	monthlyStatementHandler := handlers.NewMonthlyStatementHandler(usecases.NewMonthlyStatementUsecase(store.accountRepo, store.statementRepo, store.blobStore, logger), logger)
+	bulkCreditHandler := handlers.NewBulkCreditHandler(newBulkCreditUsecase(cfg, store, logger), logger)
+	qrisHandler := handlers.NewQRISHandler(newQRISUsecase(cfg, store, accountUsecase, logger), logger)
+	virtualAccountHandler := handlers.NewVirtualAccountHandler(
+		usecases.NewVirtualAccountUsecase(store.txManager, store.accountRepo, store.mutationRepo, store.virtualAccountRepo, accountUsecase, cfg.VACompanyPrefix, logger),
+		logger,
+	)
+	metricsHandler := handlers.NewMetricsHandler(store.balanceCache)
+	docsHandler := handlers.NewDocsHandler()
 
 	// Create Echo instance
 	e := echo.New()
+	e.HTTPErrorHandler = handlers.NewHTTPErrorHandler(logger)
+	e.Validator = utils.NewRequestValidator(models.RequestValidationRemarks)
 
 	// Middleware
 	e.Use(middleware.Logger())
 	e.Use(middleware.Recover())
 	e.Use(middleware.RequestID())
+	if cfg.DBQueryTimeout > 0 {
+		e.Use(handlers.QueryTimeout(cfg.DBQueryTimeout, handlers.UntimedAccountRoutes))
+	}
 
 	// Routes
-	api := e.Group("/api/account")
+	handlers.RegisterAccountRoutes(e.Group("/api/account"), handlers.AdminAuth(cfg.AdminToken), accountHandler, notificationHandler, statementHandler, monthlyStatementHandler, bulkCreditHandler, qrisHandler, virtualAccountHandler)
 
-	api.POST("/daftar", accountHandler.CreateAccount)
-	api.POST("/tabung", accountHandler.Credit)
-	api.POST("/tarik", accountHandler.Debit)
-	api.GET("/saldo/:no_rekening", accountHandler.GetSaldo)
+	e.GET("/metrics/balance-cache", metricsHandler.BalanceCache)
+	e.GET("/openapi.json", docsHandler.Spec)
+	e.GET("/docs", docsHandler.UI)
+
+	if cfg.AdminToken != "" {
+		webhookHandler := handlers.NewWebhookHandler(usecases.NewWebhookUsecase(store.webhookRepo, logger), logger)
+
+		admin := e.Group("/api/admin", handlers.AdminAuth(cfg.AdminToken))
+		admin.POST("/webhooks", webhookHandler.CreateWebhook)
+		admin.GET("/webhooks", webhookHandler.ListWebhooks)
+		admin.GET("/webhooks/:id", webhookHandler.GetWebhook)
+		admin.PUT("/webhooks/:id", webhookHandler.UpdateWebhook)
+		admin.DELETE("/webhooks/:id", webhookHandler.DeleteWebhook)
+		admin.GET("/webhooks/:id/deliveries", webhookHandler.ListDeliveries)
+		admin.GET("/webhooks/:id/deliveries/:delivery_id", webhookHandler.GetDelivery)
+		admin.POST("/webhooks/:id/deliveries/:delivery_id/redeliver", webhookHandler.Redeliver)
+	} else {
+		logger.Warning("ADMIN_TOKEN is empty, admin endpoints and the account endpoints behind it are disabled")
+	}
+
+	if cfg.SNAPEnabled {
+		partners, err := snap.LoadPartners(cfg.SNAPPartnersFile)
+		if err != nil {
+			return fmt.Errorf("error loading SNAP partners: %w", err)
+		}
+
+		externalIDUsecase := usecases.NewExternalIDUsecase(store.externalIDRepo, logger)
+		snap.NewHandler(accountUsecase, externalIDUsecase, partners, snap.Options{
+			TokenSecret:   []byte(cfg.SNAPTokenSecret),
+			TokenTTL:      cfg.SNAPTokenTTL,
+			TimestampSkew: cfg.SNAPTimestampSkew,
+		}, logger).Register(e.Group("/snap"))
+		logger.Info("SNAP API serves %d partners", len(partners))
+	}
+
+	if cfg.InterbankEnabled {
+		// Orders would all fail without the account holding them
+		if _, err := accountUsecase.GetAccountByNoRekening(ctx, cfg.InterbankSuspenseNoRekening); err != nil {
+			return fmt.Errorf("error getting interbank suspense account %s: %w", cfg.InterbankSuspenseNoRekening, err)
+		}
+
+		interbankHandler := handlers.NewInterbankHandler(newInterbankUsecase(cfg, store, accountUsecase, logger), logger)
+		handlers.RegisterInterbankRoutes(e.Group("/api/account"), interbankHandler)
+	}
+
+	// Start the gRPC server next to the REST API
+	var grpcServer *grpc.Server
+	if cfg.GRPCEnabled {
+		listener, err := net.Listen("tcp", ":"+cfg.GRPCPort)
+		if err != nil {
+			return fmt.Errorf("error listening for gRPC: %w", err)
+		}
+
+		grpcServer = grpcserver.NewServer(accountUsecase, grpcserver.Options{QueryTimeout: cfg.DBQueryTimeout}, logger)
+		go func() {
+			logger.Info("gRPC server started on %s", listener.Addr())
+			if err := grpcServer.Serve(listener); err != nil {
+				logger.Error("Shutting down the gRPC server: %v", err)
+			}
+		}()
+	}
+
+	// Start the ISO 8583 server for the ATM and EDC switches
+	var iso8583Server *iso8583.Server
+	if cfg.ISO8583Enabled {
+		listener, err := net.Listen("tcp", ":"+cfg.ISO8583Port)
+		if err != nil {
+			return fmt.Errorf("error listening for ISO 8583: %w", err)
+		}
+
+		reversalUsecase := usecases.NewReversalUsecase(store.txManager, store.accountRepo, store.mutationRepo, store.outboxRepo, store.balanceCache, logger)
+		iso8583Server = iso8583.NewServer(store.txManager, store.accountRepo, store.mutationRepo, accountUsecase, reversalUsecase, iso8583.Options{QueryTimeout: cfg.DBQueryTimeout, IdleTimeout: cfg.ISO8583IdleTimeout}, logger)
+		go func() {
+			logger.Info("ISO 8583 server started on %s", listener.Addr())
+			if err := iso8583Server.Serve(listener); err != nil && !errors.Is(err, iso8583.ErrServerClosed) {
+				logger.Error("Shutting down the ISO 8583 server: %v", err)
+			}
+		}()
+	}
 
 	// Start server
 	go func() {
@@ -76,9 +427,48 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
+
+	// Every server drains within the same deadline
+	grpcStopped := make(chan struct{})
+	go func() {
+		if grpcServer != nil {
+			shutdownGRPC(ctx, grpcServer, logger)
+		}
+		close(grpcStopped)
+	}()
+	iso8583Stopped := make(chan struct{})
+	go func() {
+		if iso8583Server != nil {
+			if err := iso8583Server.Shutdown(ctx); err != nil {
+				logger.Error("ISO 8583 server shutdown error: %v", err)
+			}
+		}
+		close(iso8583Stopped)
+	}()
 	if err := e.Shutdown(ctx); err != nil {
 		logger.Error("Server shutdown error: %v", err)
 	}
+	<-grpcStopped
+	<-iso8583Stopped
+
+	return nil
+}
+
+// shutdownGRPC stops the gRPC server gracefully, letting running calls end,
+// and closes the remaining connections when ctx is done first.
+func shutdownGRPC(ctx context.Context, server *grpc.Server, logger utils.Logger) {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		logger.Error("gRPC server shutdown error: %v", ctx.Err())
+		server.Stop()
+	}
 }
@@ -0,0 +1,125 @@
+package migrations
+
+import (
+	"accounts-service/utils"
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/pressly/goose/v3"
+)
+
+//go:embed postgres/*.sql sqlite/*.sql
+var files embed.FS
+
+// Supported goose dialects, each with its own migration directory.
+const (
+	DialectPostgres = "postgres"
+	DialectSQLite   = "sqlite3"
+)
+
+var dialectDirs = map[string]string{
+	DialectPostgres: "postgres",
+	DialectSQLite:   "sqlite",
+}
+
+// Commands lists the supported migrate subcommands.
+var Commands = []string{"up", "down", "status", "redo"}
+
+// Migrator applies the SQL migrations embedded in the binary. It keeps the
+// goose_db_version table, so databases migrated with the goose CLI are
+// picked up as is.
+type Migrator struct {
+	db     *sql.DB
+	logger utils.Logger
+}
+
+func NewMigrator(db *sql.DB, dialect string, logger utils.Logger) (*Migrator, error) {
+	dir, ok := dialectDirs[dialect]
+	if !ok {
+		return nil, fmt.Errorf("no migrations for dialect %q", dialect)
+	}
+
+	dialectFiles, err := fs.Sub(files, dir)
+	if err != nil {
+		return nil, fmt.Errorf("error loading %s migrations: %w", dialect, err)
+	}
+
+	goose.SetBaseFS(dialectFiles)
+	goose.SetLogger(&gooseLogger{logger: logger})
+	if err := goose.SetDialect(dialect); err != nil {
+		return nil, fmt.Errorf("error setting migration dialect: %w", err)
+	}
+
+	return &Migrator{
+		db:     db,
+		logger: logger,
+	}, nil
+}
+
+// Run executes one of Commands.
+func (m *Migrator) Run(ctx context.Context, command string) error {
+	switch command {
+	case "up":
+		return goose.UpContext(ctx, m.db, ".")
+	case "down":
+		return goose.DownContext(ctx, m.db, ".")
+	case "status":
+		return goose.StatusContext(ctx, m.db, ".")
+	case "redo":
+		return goose.RedoContext(ctx, m.db, ".")
+	default:
+		return fmt.Errorf("unknown migrate command %q, expected one of: %s", command, strings.Join(Commands, ", "))
+	}
+}
+
+// Versions returns the schema version of the database and the latest
+// embedded migration version.
+func (m *Migrator) Versions(ctx context.Context) (current, latest int64, err error) {
+	current, err = goose.GetDBVersionContext(ctx, m.db)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error getting schema version: %w", err)
+	}
+
+	all, err := goose.CollectMigrations(".", 0, goose.MaxVersion)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error collecting migrations: %w", err)
+	}
+
+	last, err := all.Last()
+	if err != nil {
+		return 0, 0, fmt.Errorf("error finding latest migration: %w", err)
+	}
+
+	return current, last.Version, nil
+}
+
+// EnsureCurrent returns an error when the database schema is behind the
+// embedded migrations.
+func (m *Migrator) EnsureCurrent(ctx context.Context) error {
+	current, latest, err := m.Versions(ctx)
+	if err != nil {
+		return err
+	}
+
+	if current < latest {
+		return fmt.Errorf("schema version %d is behind latest migration %d, run `migrate up` or set AUTO_MIGRATE=true", current, latest)
+	}
+
+	return nil
+}
+
+type gooseLogger struct {
+	logger utils.Logger
+}
+
+func (l *gooseLogger) Printf(format string, v ...interface{}) {
+	l.logger.Info(strings.TrimSuffix(format, "\n"), v...)
+}
+
+func (l *gooseLogger) Fatalf(format string, v ...interface{}) {
+	l.logger.Critical(strings.TrimSuffix(format, "\n"), v...)
+}
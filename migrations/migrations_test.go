@@ -0,0 +1,88 @@
+package migrations_test
+
+import (
+	"accounts-service/config"
+	"accounts-service/migrations"
+	"accounts-service/utils"
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrator(t *testing.T) {
+	ctx := context.Background()
+	logger := utils.NewLogger("critical")
+
+	db, err := config.NewSQLiteConnection(&config.Config{SQLitePath: filepath.Join(t.TempDir(), "accounts.db")})
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = migrations.NewMigrator(db, "mysql", logger)
+	assert.Error(t, err)
+
+	migrator, err := migrations.NewMigrator(db, migrations.DialectSQLite, logger)
+	require.NoError(t, err)
+
+	hasTable := func(t *testing.T, name string) bool {
+		var found string
+		err := db.QueryRowContext(ctx, `SELECT name FROM sqlite_master WHERE type = 'table' AND name = ?`, name).Scan(&found)
+		if err == sql.ErrNoRows {
+			return false
+		}
+		require.NoError(t, err)
+		return true
+	}
+
+	t.Run("a new database is behind", func(t *testing.T) {
+		current, latest, err := migrator.Versions(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, current)
+		assert.Positive(t, latest)
+
+		err = migrator.EnsureCurrent(ctx)
+		assert.EqualError(t, err, fmt.Sprintf("schema version 0 is behind latest migration %d, run `migrate up` or set AUTO_MIGRATE=true", latest))
+	})
+
+	t.Run("up applies every migration", func(t *testing.T) {
+		require.NoError(t, migrator.Run(ctx, "up"))
+
+		current, latest, err := migrator.Versions(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, latest, current)
+		assert.NoError(t, migrator.EnsureCurrent(ctx))
+		assert.True(t, hasTable(t, "accounts"))
+		assert.True(t, hasTable(t, "interbank_batches"))
+
+		assert.NoError(t, migrator.Run(ctx, "status"))
+		assert.NoError(t, migrator.Run(ctx, "up"), "nothing left to apply")
+	})
+
+	t.Run("down rolls back the latest migration", func(t *testing.T) {
+		_, latest, err := migrator.Versions(ctx)
+		require.NoError(t, err)
+
+		require.NoError(t, migrator.Run(ctx, "down"))
+		current, _, err := migrator.Versions(ctx)
+		require.NoError(t, err)
+		assert.Less(t, current, latest)
+		assert.ErrorContains(t, migrator.EnsureCurrent(ctx), fmt.Sprintf("schema version %d is behind latest migration %d", current, latest))
+		assert.True(t, hasTable(t, "accounts"))
+
+		require.NoError(t, migrator.Run(ctx, "redo"))
+		current, _, err = migrator.Versions(ctx)
+		require.NoError(t, err)
+		assert.Less(t, current, latest, "redo rolls back and applies the same migration")
+
+		require.NoError(t, migrator.Run(ctx, "up"))
+		assert.NoError(t, migrator.EnsureCurrent(ctx))
+	})
+
+	t.Run("unknown command", func(t *testing.T) {
+		assert.EqualError(t, migrator.Run(ctx, "reset"), "unknown migrate command \"reset\", expected one of: up, down, status, redo")
+	})
+}
@@ -0,0 +1,21 @@
+-- +goose Up
+-- Events recorded in the posting transaction and published by the relay
+CREATE TABLE outbox (
+    id BIGSERIAL PRIMARY KEY,
+    aggregate_key VARCHAR(64) NOT NULL,
+    event_type VARCHAR(64) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending', -- 'pending', 'published' or 'dead'
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    published_at TIMESTAMP
+);
+
+-- The relay only looks at pending events, oldest first per aggregate
+CREATE INDEX idx_outbox_pending ON outbox(aggregate_key, id) WHERE status = 'pending';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_outbox_pending;
+DROP TABLE IF EXISTS outbox;
@@ -0,0 +1,24 @@
+-- +goose Up
+-- Archived monthly statements, one row per account and month, kept to resume
+-- the generation job where it stopped
+CREATE TABLE monthly_statements (
+    id SERIAL PRIMARY KEY,
+    account_id INTEGER NOT NULL REFERENCES accounts(id),
+    period CHAR(7) NOT NULL, -- 'YYYY-MM'
+    status VARCHAR(16) NOT NULL, -- 'generated' or 'failed'
+    blob_key VARCHAR(255) NOT NULL,
+    size BIGINT NOT NULL DEFAULT 0,
+    checksum CHAR(64), -- hex SHA-256 of the file
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    generated_at TIMESTAMP,
+    notified_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_monthly_statements_account_period ON monthly_statements(account_id, period);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_monthly_statements_account_period;
+DROP TABLE IF EXISTS monthly_statements;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- The X-EXTERNAL-ID of every SNAP request, unique per partner and day
+CREATE TABLE external_ids (
+    partner_id VARCHAR(64) NOT NULL,
+    day VARCHAR(10) NOT NULL, -- YYYY-MM-DD in Asia/Jakarta
+    external_id VARCHAR(36) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (partner_id, day, external_id)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS external_ids;
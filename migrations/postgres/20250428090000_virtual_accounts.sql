@@ -0,0 +1,23 @@
+-- +goose Up
+-- Virtual account numbers of billers, mapped to the account they credit
+CREATE TABLE virtual_accounts (
+    id SERIAL PRIMARY KEY,
+    va_number VARCHAR(16) NOT NULL, -- company prefix and customer number
+    account_id INTEGER NOT NULL REFERENCES accounts(id),
+    name VARCHAR(64) NOT NULL,
+    nominal DECIMAL(15, 2), -- NULL for any amount
+    single_use BOOLEAN NOT NULL DEFAULT FALSE,
+    status VARCHAR(16) NOT NULL DEFAULT 'open', -- 'open' or 'closed'
+    expires_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    closed_at TIMESTAMP
+);
+
+-- A VA number is issued once, even after it is closed
+CREATE UNIQUE INDEX idx_virtual_accounts_va_number ON virtual_accounts(va_number);
+CREATE INDEX idx_virtual_accounts_account_id ON virtual_accounts(account_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_virtual_accounts_account_id;
+DROP INDEX IF EXISTS idx_virtual_accounts_va_number;
+DROP TABLE IF EXISTS virtual_accounts;
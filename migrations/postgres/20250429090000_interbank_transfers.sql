@@ -0,0 +1,40 @@
+-- +goose Up
+-- Clearing batches, one per cut-off window with queued transfers
+CREATE TABLE interbank_batches (
+    id SERIAL PRIMARY KEY,
+    reference VARCHAR(20) NOT NULL, -- e.g. 'KLR202504280900'
+    cutoff_at TIMESTAMP NOT NULL,
+    blob_key VARCHAR(255) NOT NULL,
+    transfer_count INTEGER NOT NULL,
+    total_nominal DECIMAL(15, 2) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Outgoing transfers to other banks, debited into the clearing suspense account
+CREATE TABLE interbank_transfers (
+    id SERIAL PRIMARY KEY,
+    source_account_id INTEGER NOT NULL REFERENCES accounts(id),
+    bank_code VARCHAR(3) NOT NULL,
+    beneficiary_account VARCHAR(34) NOT NULL,
+    beneficiary_name VARCHAR(35) NOT NULL,
+    nominal DECIMAL(15, 2) NOT NULL,
+    reference VARCHAR(64) NOT NULL,
+    description VARCHAR(35),
+    status VARCHAR(16) NOT NULL DEFAULT 'queued', -- 'queued', 'sent', 'settled' or 'rejected'
+    batch_id INTEGER REFERENCES interbank_batches(id), -- NULL until sent
+    return_reason VARCHAR(4),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP
+);
+
+-- A window is cut once, even by several instances
+CREATE UNIQUE INDEX idx_interbank_batches_reference ON interbank_batches(reference);
+CREATE INDEX idx_interbank_transfers_queued ON interbank_transfers(created_at) WHERE status = 'queued';
+CREATE INDEX idx_interbank_transfers_batch_id ON interbank_transfers(batch_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_interbank_transfers_batch_id;
+DROP INDEX IF EXISTS idx_interbank_transfers_queued;
+DROP INDEX IF EXISTS idx_interbank_batches_reference;
+DROP TABLE IF EXISTS interbank_transfers;
+DROP TABLE IF EXISTS interbank_batches;
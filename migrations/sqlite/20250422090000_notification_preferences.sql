@@ -0,0 +1,12 @@
+-- +goose Up
+-- How each customer wants to be notified, accounts without a row get SMS only
+CREATE TABLE notification_preferences (
+    account_id INTEGER PRIMARY KEY REFERENCES accounts(id),
+    channels VARCHAR(64) NOT NULL, -- comma separated 'sms', 'whatsapp', 'email', empty for none
+    email VARCHAR(255),
+    low_balance_threshold DECIMAL(15, 2) NOT NULL DEFAULT 0, -- 0 disables the alert
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS notification_preferences;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- Statements read the mutations of an account by period
+CREATE INDEX idx_mutations_account_id_created_at ON mutations(account_id, created_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_mutations_account_id_created_at;
@@ -0,0 +1,40 @@
+-- +goose Up
+-- Bulk credit batches paid from one source account, e.g. payrolls
+CREATE TABLE bulk_credit_batches (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    reference VARCHAR(64) NOT NULL,
+    source_account_id INTEGER NOT NULL REFERENCES accounts(id),
+    mode VARCHAR(16) NOT NULL, -- 'all_or_nothing' or 'per_row'
+    status VARCHAR(16) NOT NULL, -- 'processing', 'completed', 'partial' or 'rejected'
+    total_rows INTEGER NOT NULL,
+    credited_rows INTEGER NOT NULL DEFAULT 0,
+    credited_nominal DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP
+);
+
+-- One row per line of the batch with its result
+CREATE TABLE bulk_credit_rows (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    batch_id INTEGER NOT NULL REFERENCES bulk_credit_batches(id) ON DELETE CASCADE,
+    line INTEGER NOT NULL,
+    no_rekening VARCHAR(20) NOT NULL, -- as sent, cut to 20 characters when invalid
+    account_id INTEGER REFERENCES accounts(id), -- NULL for rows rejected before the lookup
+    nominal DECIMAL(15, 2) NOT NULL,
+    reference VARCHAR(255),
+    status VARCHAR(16) NOT NULL, -- 'pending', 'credited', 'rejected', 'cancelled' or 'failed'
+    error_code VARCHAR(64)
+);
+
+-- A batch reference is used once, so a batch sent again is not paid twice
+CREATE UNIQUE INDEX idx_bulk_credit_batches_reference ON bulk_credit_batches(reference);
+CREATE INDEX idx_bulk_credit_batches_processing ON bulk_credit_batches(id) WHERE status = 'processing';
+CREATE INDEX idx_bulk_credit_rows_batch_id ON bulk_credit_rows(batch_id, line);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_bulk_credit_rows_batch_id;
+DROP INDEX IF EXISTS idx_bulk_credit_batches_processing;
+DROP INDEX IF EXISTS idx_bulk_credit_batches_reference;
+DROP TABLE IF EXISTS bulk_credit_rows;
+DROP TABLE IF EXISTS bulk_credit_batches;
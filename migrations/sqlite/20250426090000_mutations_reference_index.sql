@@ -0,0 +1,6 @@
+-- +goose Up
+-- Reversals find the posting they undo by its reference
+CREATE INDEX idx_mutations_account_id_reference ON mutations(account_id, reference);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_mutations_account_id_reference;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- Batch files are written after the batch commits, NULL until written
+ALTER TABLE interbank_batches ADD COLUMN written_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE interbank_batches DROP COLUMN written_at;
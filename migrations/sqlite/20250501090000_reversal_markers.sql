@@ -0,0 +1,12 @@
+-- +goose Up
+-- Postings reversed before they were made, so that they are not made when
+-- they arrive after their reversal
+CREATE TABLE reversal_markers (
+    account_id INTEGER NOT NULL REFERENCES accounts(id),
+    reference VARCHAR(255) NOT NULL, -- reference of the posting
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (account_id, reference)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS reversal_markers;
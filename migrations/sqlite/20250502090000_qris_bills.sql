@@ -0,0 +1,18 @@
+-- +goose Up
+-- Dynamic QRIS codes as generated, a payment must match one and pays it once
+CREATE TABLE qris_bills (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    account_id INTEGER NOT NULL REFERENCES accounts(id),
+    bill_number VARCHAR(25) NOT NULL,
+    nominal DECIMAL(15, 2) NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'open', -- 'open' or 'paid'
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    paid_at TIMESTAMP
+);
+
+-- A bill number is used once per merchant
+CREATE UNIQUE INDEX idx_qris_bills_account_id_bill_number ON qris_bills(account_id, bill_number);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_qris_bills_account_id_bill_number;
+DROP TABLE IF EXISTS qris_bills;
@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"accounts-service/utils"
+	"strings"
+	"time"
+)
 
 type Account struct {
 	ID         uint      `json:"id"`
@@ -14,9 +18,19 @@ type Account struct {
 }
 
 type CreateAccountRequest struct {
-	Name string `json:"name" validate:"required"`
-	NIK  string `json:"nik" validate:"required"`
-	NoHP string `json:"no_hp" validate:"required"`
+	Name string `json:"name" validate:"required,max=255"`
+	NIK  string `json:"nik" validate:"required,nik"`
+	NoHP string `json:"no_hp" validate:"required,phone"`
+}
+
+func (r *CreateAccountRequest) Normalize() {
+	r.Name = strings.TrimSpace(r.Name)
+	r.NIK = strings.TrimSpace(r.NIK)
+	r.NoHP = utils.NormalizePhone(r.NoHP)
+}
+
+type SaldoRequest struct {
+	NoRekening string `param:"no_rekening" validate:"required,norek"`
 }
 
 type SaldoResponse struct {
@@ -25,7 +39,12 @@ type SaldoResponse struct {
 }
 
 type TransactionRequest struct {
-	NoRekening string  `json:"no_rekening" validate:"required"`
-	Nominal    float64 `json:"nominal" validate:"required,gt=0"`
-	Reference  string  `json:"reference"`
+	NoRekening string  `json:"no_rekening" validate:"required,norek"`
+	Nominal    float64 `json:"nominal" validate:"required,gt=0,amount"`
+	Reference  string  `json:"reference" validate:"max=255"`
+}
+
+func (r *TransactionRequest) Normalize() {
+	r.NoRekening = strings.TrimSpace(r.NoRekening)
+	r.Reference = strings.TrimSpace(r.Reference)
 }
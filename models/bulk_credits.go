@@ -0,0 +1,104 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Bulk credit modes. An all_or_nothing batch is credited in one transaction
+// and rejected as a whole when a row is invalid. A per_row batch credits its
+// valid rows one by one and returns what could not be credited to the
+// source account.
+const (
+	BulkCreditModeAllOrNothing = "all_or_nothing"
+	BulkCreditModePerRow       = "per_row"
+)
+
+// BulkCreditModes are the modes a batch may ask for.
+var BulkCreditModes = []string{BulkCreditModeAllOrNothing, BulkCreditModePerRow}
+
+// Bulk credit batch states. A per_row batch is processing until every row is
+// credited or failed, partial when some rows were not credited.
+const (
+	BulkCreditProcessing = "processing"
+	BulkCreditCompleted  = "completed"
+	BulkCreditPartial    = "partial"
+	BulkCreditRejected   = "rejected"
+)
+
+// Bulk credit row states. Rejected rows failed validation, cancelled rows
+// were valid in a rejected batch, failed rows could not be posted.
+const (
+	BulkCreditRowPending   = "pending"
+	BulkCreditRowCredited  = "credited"
+	BulkCreditRowRejected  = "rejected"
+	BulkCreditRowCancelled = "cancelled"
+	BulkCreditRowFailed    = "failed"
+)
+
+// BulkCreditBatch is a batch of credits paid from one source account, e.g. a
+// payroll. The source is debited once with the total of the valid rows.
+type BulkCreditBatch struct {
+	ID               uint            `json:"-"`
+	Reference        string          `json:"batch_reference"`
+	SourceAccountID  uint            `json:"-"`
+	SourceNoRekening string          `json:"source_no_rekening"`
+	Mode             string          `json:"mode"`
+	Status           string          `json:"status"`
+	TotalRows        int             `json:"total_rows"`
+	CreditedRows     int             `json:"credited_rows"`
+	CreditedNominal  float64         `json:"credited_nominal"`
+	Rows             []BulkCreditRow `json:"rows"`
+	CreatedAt        time.Time       `json:"created_at"`
+	CompletedAt      *time.Time      `json:"completed_at"`
+}
+
+// BulkCreditRow is the result of one row of a batch. ErrorCode is the remark
+// code of a rejected or failed row.
+type BulkCreditRow struct {
+	ID         uint    `json:"-"`
+	BatchID    uint    `json:"-"`
+	Line       int     `json:"line"`
+	NoRekening string  `json:"no_rekening"`
+	AccountID  uint    `json:"-"`
+	Nominal    float64 `json:"nominal"`
+	Reference  string  `json:"reference,omitempty"`
+	Status     string  `json:"status"`
+	ErrorCode  string  `json:"error_code,omitempty"`
+}
+
+// BulkCreditRequest is a batch sent as JSON, or as CSV with the batch fields
+// in the query.
+type BulkCreditRequest struct {
+	BatchReference   string `json:"batch_reference" query:"batch_reference" validate:"required,max=64"`
+	SourceNoRekening string `json:"source_no_rekening" query:"source_no_rekening" validate:"required,norek"`
+	// Mode defaults to BULK_CREDIT_MODE.
+	Mode string `json:"mode" query:"mode" validate:"omitempty,oneof=all_or_nothing per_row"`
+	// Rows are validated one by one by the usecase, so every invalid row is
+	// reported.
+	Rows []BulkCreditRowRequest `json:"rows"`
+}
+
+func (r *BulkCreditRequest) Normalize() {
+	r.BatchReference = strings.TrimSpace(r.BatchReference)
+	r.SourceNoRekening = strings.TrimSpace(r.SourceNoRekening)
+	r.Mode = strings.TrimSpace(r.Mode)
+	for i := range r.Rows {
+		r.Rows[i].NoRekening = strings.TrimSpace(r.Rows[i].NoRekening)
+		r.Rows[i].Reference = strings.TrimSpace(r.Rows[i].Reference)
+	}
+}
+
+type BulkCreditRowRequest struct {
+	NoRekening string  `json:"no_rekening"`
+	Nominal    float64 `json:"nominal"`
+	Reference  string  `json:"reference"`
+}
+
+type BulkCreditBatchRequest struct {
+	BatchReference string `param:"batch_reference" validate:"required,max=64"`
+}
+
+func (r *BulkCreditBatchRequest) Normalize() {
+	r.BatchReference = strings.TrimSpace(r.BatchReference)
+}
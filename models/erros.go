@@ -1,37 +1,275 @@
 package models
 
-import "accounts-service/utils"
+import (
+	"accounts-service/utils"
+	"net/http"
+	"strings"
+)
 
 var (
-	AccountWithNIKIsExist         = "ACCOUNT_WITH_NIK_IS_EXIST"
-	AccountWithNoHpKIsExist       = "ACCOUNT_WITH_NO_HP_IS_EXIST"
-	AccountWithNoRekeningNotFound = "ACCOUNT_WITH_NO_REK_NOT_FOUND"
-	AccountNameEmpty              = "ACCOUNT_NAME_EMPTY"
-	AccountNikEmpty               = "ACCOUNT_NIK_EMPTY"
-	AccountNoHpEmpty              = "ACCOUNT_NO_HP_EMPTY"
-	AccountParamNoRekeningEmpty   = "ACCOUNT_PARAM_NO_REKENING_EMPTY"
-	AccountParamNominalLessZero   = "ACCOUNT_PARAM_NOMINAL_LESS_THAN_ZERO"
-	Accountinsufficient           = "ACCOUNT_INSUFFICIENT_SALDO"
-	AccountInvalidRequest         = "ACCOUNT_CREATE_INVALID_REQUEST"
-	CreditInvalidRequest          = "CREDIT_INVALID_REQUEST"
-	DebitInvalidRequest           = "DEBIT_INVALID_REQUEST"
-	GetAccountError               = "GET_ACCOUNT_ERROR"
-	UpdateSaldoError              = "UPDATE_SALDO_ERROR"
-	CreateMutationError           = "CREATE_MUTATION_ERROR"
-	CreateAccountError            = "CREATE_ACCOUNT_ERROR"
-	CreateTransactionDBError      = "CREATE_TRANSACTION_DB_ERROR"
-	CommitTransactionDBError      = "COMMIT_TRANSACTION_DB_ERROR"
+	AccountWithNIKIsExist               = "ACCOUNT_WITH_NIK_IS_EXIST"
+	AccountWithNoHpKIsExist             = "ACCOUNT_WITH_NO_HP_IS_EXIST"
+	AccountWithNoRekeningNotFound       = "ACCOUNT_WITH_NO_REK_NOT_FOUND"
+	AccountNameEmpty                    = "ACCOUNT_NAME_EMPTY"
+	AccountNikEmpty                     = "ACCOUNT_NIK_EMPTY"
+	AccountNoHpEmpty                    = "ACCOUNT_NO_HP_EMPTY"
+	AccountParamNoRekeningEmpty         = "ACCOUNT_PARAM_NO_REKENING_EMPTY"
+	AccountParamNominalLessZero         = "ACCOUNT_PARAM_NOMINAL_LESS_THAN_ZERO"
+	Accountinsufficient                 = "ACCOUNT_INSUFFICIENT_SALDO"
+	AccountInvalidRequest               = "ACCOUNT_CREATE_INVALID_REQUEST"
+	CreditInvalidRequest                = "CREDIT_INVALID_REQUEST"
+	DebitInvalidRequest                 = "DEBIT_INVALID_REQUEST"
+	GetAccountError                     = "GET_ACCOUNT_ERROR"
+	UpdateSaldoError                    = "UPDATE_SALDO_ERROR"
+	CreateMutationError                 = "CREATE_MUTATION_ERROR"
+	CreateAccountError                  = "CREATE_ACCOUNT_ERROR"
+	CreateTransactionDBError            = "CREATE_TRANSACTION_DB_ERROR"
+	CommitTransactionDBError            = "COMMIT_TRANSACTION_DB_ERROR"
+	RouteNotFound                       = "ROUTE_NOT_FOUND"
+	HTTPRequestError                    = "HTTP_REQUEST_ERROR"
+	InternalServerError                 = "INTERNAL_SERVER_ERROR"
+	RequestValidationError              = "REQUEST_VALIDATION_ERROR"
+	RequestFieldInvalid                 = "REQUEST_FIELD_INVALID"
+	AccountNikInvalid                   = "ACCOUNT_NIK_INVALID"
+	AccountNoHpInvalid                  = "ACCOUNT_NO_HP_INVALID"
+	AccountNoRekeningInvalid            = "ACCOUNT_NO_REKENING_INVALID"
+	AccountNominalInvalid               = "ACCOUNT_NOMINAL_INVALID"
+	QueryTimeout                        = "QUERY_TIMEOUT"
+	CreateOutboxEventError              = "CREATE_OUTBOX_EVENT_ERROR"
+	OutboxDBError                       = "OUTBOX_DB_ERROR"
+	AdminUnauthorized                   = "ADMIN_UNAUTHORIZED"
+	WebhookNotFound                     = "WEBHOOK_NOT_FOUND"
+	WebhookDeliveryNotFound             = "WEBHOOK_DELIVERY_NOT_FOUND"
+	WebhookInvalidRequest               = "WEBHOOK_INVALID_REQUEST"
+	WebhookURLInvalid                   = "WEBHOOK_URL_INVALID"
+	WebhookEventTypesInvalid            = "WEBHOOK_EVENT_TYPES_INVALID"
+	WebhookDBError                      = "WEBHOOK_DB_ERROR"
+	NotificationInvalidRequest          = "NOTIFICATION_INVALID_REQUEST"
+	NotificationChannelsInvalid         = "NOTIFICATION_CHANNELS_INVALID"
+	NotificationEmailInvalid            = "NOTIFICATION_EMAIL_INVALID"
+	NotificationThresholdInvalid        = "NOTIFICATION_THRESHOLD_INVALID"
+	NotificationDBError                 = "NOTIFICATION_DB_ERROR"
+	MutationDBError                     = "MUTATION_DB_ERROR"
+	MutationPageTokenInvalid            = "MUTATION_PAGE_TOKEN_INVALID"
+	StatementInvalidRequest             = "STATEMENT_INVALID_REQUEST"
+	StatementPeriodInvalid              = "STATEMENT_PERIOD_INVALID"
+	StatementFormatInvalid              = "STATEMENT_FORMAT_INVALID"
+	MonthlyStatementNotFound            = "MONTHLY_STATEMENT_NOT_FOUND"
+	MonthlyStatementInvalid             = "MONTHLY_STATEMENT_INVALID_REQUEST"
+	MonthlyStatementPeriodInvalid       = "MONTHLY_STATEMENT_PERIOD_INVALID"
+	MonthlyStatementCorrupted           = "MONTHLY_STATEMENT_CORRUPTED"
+	MonthlyStatementDBError             = "MONTHLY_STATEMENT_DB_ERROR"
+	BulkCreditInvalidRequest            = "BULK_CREDIT_INVALID_REQUEST"
+	BulkCreditReferenceInvalid          = "BULK_CREDIT_REFERENCE_INVALID"
+	BulkCreditSourceInvalid             = "BULK_CREDIT_SOURCE_INVALID"
+	BulkCreditModeInvalid               = "BULK_CREDIT_MODE_INVALID"
+	BulkCreditRowsInvalid               = "BULK_CREDIT_ROWS_INVALID"
+	BulkCreditFileInvalid               = "BULK_CREDIT_FILE_INVALID"
+	BulkCreditBatchExists               = "BULK_CREDIT_BATCH_EXISTS"
+	BulkCreditNotFound                  = "BULK_CREDIT_NOT_FOUND"
+	BulkCreditRowIsSource               = "BULK_CREDIT_ROW_IS_SOURCE"
+	BulkCreditRowReferenceInvalid       = "BULK_CREDIT_ROW_REFERENCE_INVALID"
+	BulkCreditDBError                   = "BULK_CREDIT_DB_ERROR"
+	AccountWithNoRekeningIsExist        = "ACCOUNT_WITH_NO_REK_IS_EXIST"
+	ImportOpeningBalanceInvalid         = "IMPORT_OPENING_BALANCE_INVALID"
+	ImportRowInvalid                    = "IMPORT_ROW_INVALID"
+	ReversalOriginalNotFound            = "REVERSAL_ORIGINAL_NOT_FOUND"
+	TransferSameAccount                 = "TRANSFER_SAME_ACCOUNT"
+	TransferReferenceExists             = "TRANSFER_REFERENCE_EXISTS"
+	ExternalIDExists                    = "EXTERNAL_ID_EXISTS"
+	ExternalIDDBError                   = "EXTERNAL_ID_DB_ERROR"
+	QRISInvalidRequest                  = "QRIS_INVALID_REQUEST"
+	QRISBillNumberInvalid               = "QRIS_BILL_NUMBER_INVALID"
+	QRISPayloadInvalid                  = "QRIS_PAYLOAD_INVALID"
+	QRISMerchantUnknown                 = "QRIS_MERCHANT_UNKNOWN"
+	QRISStaticPaymentInvalid            = "QRIS_STATIC_PAYMENT_INVALID"
+	QRISNominalMismatch                 = "QRIS_NOMINAL_MISMATCH"
+	QRISPaymentExists                   = "QRIS_PAYMENT_EXISTS"
+	QRISBillNumberExists                = "QRIS_BILL_NUMBER_EXISTS"
+	QRISCodeNotFound                    = "QRIS_CODE_NOT_FOUND"
+	QRISDBError                         = "QRIS_DB_ERROR"
+	VirtualAccountInvalidRequest        = "VIRTUAL_ACCOUNT_INVALID_REQUEST"
+	VirtualAccountNumberInvalid         = "VIRTUAL_ACCOUNT_NUMBER_INVALID"
+	VirtualAccountCustomerNumberInvalid = "VIRTUAL_ACCOUNT_CUSTOMER_NUMBER_INVALID"
+	VirtualAccountExpiryInvalid         = "VIRTUAL_ACCOUNT_EXPIRY_INVALID"
+	VirtualAccountExists                = "VIRTUAL_ACCOUNT_EXISTS"
+	VirtualAccountNotFound              = "VIRTUAL_ACCOUNT_NOT_FOUND"
+	VirtualAccountClosed                = "VIRTUAL_ACCOUNT_CLOSED"
+	VirtualAccountExpired               = "VIRTUAL_ACCOUNT_EXPIRED"
+	VirtualAccountNominalMismatch       = "VIRTUAL_ACCOUNT_NOMINAL_MISMATCH"
+	VirtualAccountPaymentExists         = "VIRTUAL_ACCOUNT_PAYMENT_EXISTS"
+	VirtualAccountDBError               = "VIRTUAL_ACCOUNT_DB_ERROR"
+	InterbankInvalidRequest             = "INTERBANK_INVALID_REQUEST"
+	InterbankBankCodeInvalid            = "INTERBANK_BANK_CODE_INVALID"
+	InterbankBeneficiaryAccountInvalid  = "INTERBANK_BENEFICIARY_ACCOUNT_INVALID"
+	InterbankBeneficiaryNameInvalid     = "INTERBANK_BENEFICIARY_NAME_INVALID"
+	InterbankDescriptionInvalid         = "INTERBANK_DESCRIPTION_INVALID"
+	InterbankTransferIDInvalid          = "INTERBANK_TRANSFER_ID_INVALID"
+	InterbankTransferNotFound           = "INTERBANK_TRANSFER_NOT_FOUND"
+	InterbankTransferNotSent            = "INTERBANK_TRANSFER_NOT_SENT"
+	InterbankSettlementMismatch         = "INTERBANK_SETTLEMENT_MISMATCH"
+	InterbankSettlementBatchMismatch    = "INTERBANK_SETTLEMENT_BATCH_MISMATCH"
+	InterbankDBError                    = "INTERBANK_DB_ERROR"
 
-	AccountWithNIKIsExistErr         = utils.NewRemark("Account with NIK is already exist", AccountWithNIKIsExist, "nik", nil)
-	AccountWithNoHpKIsExistErr       = utils.NewRemark("Account with No HP is already exist", AccountWithNoHpKIsExist, "name", nil)
-	AccountWithNoRekeningNotFoundErr = utils.NewRemark("Account with No Rekening not found", AccountWithNoRekeningNotFound, "no_rekening", nil)
-	AccountParamNoRekeningEmptyErr   = utils.NewRemark("Param No rekening empty", AccountParamNoRekeningEmpty, "no_rekening", nil)
-	AccountParamNominalErr           = utils.NewRemark("Param nominal less than 0", AccountParamNominalLessZero, "nominal", nil)
-	AccountNameEmptyErr              = utils.NewRemark("Parameter Account name is empty", AccountNameEmpty, "name", nil)
-	AccountNikEmptyErr               = utils.NewRemark("Parameter Account NIK is empty", AccountNikEmpty, "nik", nil)
-	AccountNoHpEmptyErr              = utils.NewRemark("Parameter Account No Hp is empty", AccountNoHpEmpty, "no hp", nil)
-	AccountinsufficientErr           = utils.NewRemark("Saldo not enough / Insufficient balance", AccountNoHpEmpty, "nominal", nil)
-	AccountInvalidRequestErr         = utils.NewRemark("Invalid parameter create account", AccountInvalidRequest, "name, nik, no_hp", nil)
-	CreditInvalidRequestErr          = utils.NewRemark("Invalid parameter credit/tabung", CreditInvalidRequest, "no_rekening, nominal", nil)
-	DebitInvalidRequestErr           = utils.NewRemark("Invalid parameter debit/tarik", DebitInvalidRequest, "no_rekening, nominal", nil)
+	AccountWithNIKIsExistErr               = utils.NewRemark(http.StatusConflict, "Account with NIK is already exist", AccountWithNIKIsExist, "nik", nil)
+	AccountWithNoHpKIsExistErr             = utils.NewRemark(http.StatusConflict, "Account with No HP is already exist", AccountWithNoHpKIsExist, "no_hp", nil)
+	AccountWithNoRekeningNotFoundErr       = utils.NewRemark(http.StatusNotFound, "Account with No Rekening not found", AccountWithNoRekeningNotFound, "no_rekening", nil)
+	AccountParamNoRekeningEmptyErr         = utils.NewRemark(http.StatusBadRequest, "Param No rekening empty", AccountParamNoRekeningEmpty, "no_rekening", nil)
+	AccountParamNominalErr                 = utils.NewRemark(http.StatusBadRequest, "Param nominal less than 0", AccountParamNominalLessZero, "nominal", nil).WithParams(map[string]interface{}{"min": 0.0})
+	AccountNameEmptyErr                    = utils.NewRemark(http.StatusBadRequest, "Parameter Account name is empty", AccountNameEmpty, "name", nil)
+	AccountNikEmptyErr                     = utils.NewRemark(http.StatusBadRequest, "Parameter Account NIK is empty", AccountNikEmpty, "nik", nil)
+	AccountNoHpEmptyErr                    = utils.NewRemark(http.StatusBadRequest, "Parameter Account No Hp is empty", AccountNoHpEmpty, "no_hp", nil)
+	AccountinsufficientErr                 = utils.NewRemark(http.StatusUnprocessableEntity, "Saldo not enough / Insufficient balance", Accountinsufficient, "nominal", nil)
+	AccountInvalidRequestErr               = utils.NewRemark(http.StatusBadRequest, "Invalid parameter create account", AccountInvalidRequest, "name, nik, no_hp", nil)
+	CreditInvalidRequestErr                = utils.NewRemark(http.StatusBadRequest, "Invalid parameter credit/tabung", CreditInvalidRequest, "no_rekening, nominal", nil)
+	DebitInvalidRequestErr                 = utils.NewRemark(http.StatusBadRequest, "Invalid parameter debit/tarik", DebitInvalidRequest, "no_rekening, nominal", nil)
+	GetAccountErr                          = utils.NewRemark(http.StatusInternalServerError, "error getting account", GetAccountError, "", nil)
+	UpdateSaldoErr                         = utils.NewRemark(http.StatusInternalServerError, "error updating account saldo", UpdateSaldoError, "no_rekening", nil)
+	CreateMutationErr                      = utils.NewRemark(http.StatusInternalServerError, "error creating mutation", CreateMutationError, "no_rekening", nil)
+	CreateAccountErr                       = utils.NewRemark(http.StatusInternalServerError, "error creating account", CreateAccountError, "", nil)
+	CreateTransactionDBErr                 = utils.NewRemark(http.StatusInternalServerError, "error beginning transaction", CreateTransactionDBError, "", nil)
+	CommitTransactionDBErr                 = utils.NewRemark(http.StatusInternalServerError, "error committing transaction", CommitTransactionDBError, "", nil)
+	RouteNotFoundErr                       = utils.NewRemark(http.StatusNotFound, "Route not found", RouteNotFound, "", nil)
+	InternalServerErr                      = utils.NewRemark(http.StatusInternalServerError, "Internal server error", InternalServerError, "", nil)
+	RequestValidationErr                   = utils.NewRemark(http.StatusBadRequest, "Request validation failed", RequestValidationError, "", nil)
+	RequestFieldInvalidErr                 = utils.NewRemark(http.StatusBadRequest, "Field {field} fails rule {rule}", RequestFieldInvalid, "", nil)
+	AccountNikInvalidErr                   = utils.NewRemark(http.StatusBadRequest, "NIK must be 16 digits with a valid province code and birth date", AccountNikInvalid, "nik", nil)
+	AccountNoHpInvalidErr                  = utils.NewRemark(http.StatusBadRequest, "No HP must be an Indonesian mobile number", AccountNoHpInvalid, "no_hp", nil)
+	AccountNoRekeningInvalidErr            = utils.NewRemark(http.StatusBadRequest, "No rekening must be 10 to 12 digits", AccountNoRekeningInvalid, "no_rekening", nil)
+	AccountNominalInvalidErr               = utils.NewRemark(http.StatusBadRequest, "Nominal must have at most 2 decimals and be below {max}", AccountNominalInvalid, "nominal", nil).WithParams(map[string]interface{}{"max": utils.MaxAmount})
+	QueryTimeoutErr                        = utils.NewRemark(http.StatusServiceUnavailable, "Request took too long, please retry", QueryTimeout, "", nil)
+	CreateOutboxEventErr                   = utils.NewRemark(http.StatusInternalServerError, "error recording mutation event", CreateOutboxEventError, "", nil)
+	OutboxDBErr                            = utils.NewRemark(http.StatusInternalServerError, "error reading or updating outbox", OutboxDBError, "", nil)
+	AdminUnauthorizedErr                   = utils.NewRemark(http.StatusUnauthorized, "Admin token missing or invalid", AdminUnauthorized, "", nil)
+	WebhookNotFoundErr                     = utils.NewRemark(http.StatusNotFound, "Webhook not found", WebhookNotFound, "id", nil)
+	WebhookDeliveryNotFoundErr             = utils.NewRemark(http.StatusNotFound, "Webhook delivery not found", WebhookDeliveryNotFound, "delivery_id", nil)
+	WebhookInvalidRequestErr               = utils.NewRemark(http.StatusBadRequest, "Invalid parameter webhook", WebhookInvalidRequest, "url, event_types", nil)
+	WebhookURLInvalidErr                   = utils.NewRemark(http.StatusBadRequest, "Webhook URL must be an absolute http or https URL", WebhookURLInvalid, "url", nil)
+	WebhookEventTypesInvalidErr            = utils.NewRemark(http.StatusBadRequest, "Event types must be one or more of {allowed}", WebhookEventTypesInvalid, "event_types", nil).WithParams(map[string]interface{}{"allowed": strings.Join(WebhookEventTypes, ", ")})
+	WebhookDBErr                           = utils.NewRemark(http.StatusInternalServerError, "error reading or updating webhooks", WebhookDBError, "", nil)
+	NotificationInvalidRequestErr          = utils.NewRemark(http.StatusBadRequest, "Invalid parameter notification preference", NotificationInvalidRequest, "channels, email, low_balance_threshold", nil)
+	NotificationChannelsInvalidErr         = utils.NewRemark(http.StatusBadRequest, "Channels must be zero or more of {allowed}", NotificationChannelsInvalid, "channels", nil).WithParams(map[string]interface{}{"allowed": strings.Join(NotificationChannels, ", ")})
+	NotificationEmailInvalidErr            = utils.NewRemark(http.StatusBadRequest, "Email must be a valid address and is required for the email channel", NotificationEmailInvalid, "email", nil)
+	NotificationThresholdInvalidErr        = utils.NewRemark(http.StatusBadRequest, "Low balance threshold must be 0 or a positive amount with at most 2 decimals below {max}", NotificationThresholdInvalid, "low_balance_threshold", nil).WithParams(map[string]interface{}{"max": utils.MaxAmount})
+	NotificationDBErr                      = utils.NewRemark(http.StatusInternalServerError, "error reading or updating notification preferences", NotificationDBError, "", nil)
+	MutationDBErr                          = utils.NewRemark(http.StatusInternalServerError, "error reading mutations", MutationDBError, "", nil)
+	MutationPageTokenInvalidErr            = utils.NewRemark(http.StatusBadRequest, "Page token must be the next_page_token of the previous page", MutationPageTokenInvalid, "page_token", nil)
+	StatementInvalidRequestErr             = utils.NewRemark(http.StatusBadRequest, "Invalid parameter statement", StatementInvalidRequest, "from, to, format", nil)
+	StatementPeriodInvalidErr              = utils.NewRemark(http.StatusBadRequest, "Period must be from and to dates as YYYY-MM-DD with from not after to", StatementPeriodInvalid, "from, to", nil)
+	StatementFormatInvalidErr              = utils.NewRemark(http.StatusBadRequest, "Format must be one of {allowed}", StatementFormatInvalid, "format", nil).WithParams(map[string]interface{}{"allowed": StatementFormatCSV + ", " + StatementFormatPDF})
+	MonthlyStatementNotFoundErr            = utils.NewRemark(http.StatusNotFound, "Monthly statement not found", MonthlyStatementNotFound, "period", nil)
+	MonthlyStatementInvalidErr             = utils.NewRemark(http.StatusBadRequest, "Invalid parameter monthly statement", MonthlyStatementInvalid, "no_rekening, period", nil)
+	MonthlyStatementPeriodInvalidErr       = utils.NewRemark(http.StatusBadRequest, "Period must be a month as YYYY-MM", MonthlyStatementPeriodInvalid, "period", nil)
+	MonthlyStatementCorruptedErr           = utils.NewRemark(http.StatusInternalServerError, "Archived monthly statement does not match its checksum", MonthlyStatementCorrupted, "period", nil)
+	MonthlyStatementDBErr                  = utils.NewRemark(http.StatusInternalServerError, "error reading or updating monthly statements", MonthlyStatementDBError, "", nil)
+	BulkCreditInvalidRequestErr            = utils.NewRemark(http.StatusBadRequest, "Invalid parameter bulk credit", BulkCreditInvalidRequest, "batch_reference, source_no_rekening, mode, rows", nil)
+	BulkCreditReferenceInvalidErr          = utils.NewRemark(http.StatusBadRequest, "Batch reference must be 1 to 64 characters", BulkCreditReferenceInvalid, "batch_reference", nil)
+	BulkCreditSourceInvalidErr             = utils.NewRemark(http.StatusBadRequest, "Source no rekening must be 10 to 12 digits", BulkCreditSourceInvalid, "source_no_rekening", nil)
+	BulkCreditModeInvalidErr               = utils.NewRemark(http.StatusBadRequest, "Mode must be one of {allowed}", BulkCreditModeInvalid, "mode", nil).WithParams(map[string]interface{}{"allowed": strings.Join(BulkCreditModes, ", ")})
+	BulkCreditRowsInvalidErr               = utils.NewRemark(http.StatusBadRequest, "Batch must have 1 to {max} rows", BulkCreditRowsInvalid, "rows", nil)
+	BulkCreditFileInvalidErr               = utils.NewRemark(http.StatusBadRequest, "Line {line} of the CSV file is invalid, expected the columns no_rekening,nominal,reference", BulkCreditFileInvalid, "rows", nil)
+	BulkCreditBatchExistsErr               = utils.NewRemark(http.StatusConflict, "Batch reference is already used", BulkCreditBatchExists, "batch_reference", nil)
+	BulkCreditNotFoundErr                  = utils.NewRemark(http.StatusNotFound, "Bulk credit batch not found", BulkCreditNotFound, "batch_reference", nil)
+	BulkCreditRowIsSourceErr               = utils.NewRemark(http.StatusBadRequest, "Recipient must not be the source account", BulkCreditRowIsSource, "no_rekening", nil)
+	BulkCreditRowReferenceInvalidErr       = utils.NewRemark(http.StatusBadRequest, "Reference must be at most 255 characters", BulkCreditRowReferenceInvalid, "reference", nil)
+	BulkCreditDBErr                        = utils.NewRemark(http.StatusInternalServerError, "error reading or updating bulk credits", BulkCreditDBError, "", nil)
+	AccountWithNoRekeningIsExistErr        = utils.NewRemark(http.StatusConflict, "Account with No Rekening is already exist", AccountWithNoRekeningIsExist, "no_rekening", nil)
+	ImportOpeningBalanceInvalidErr         = utils.NewRemark(http.StatusBadRequest, "Opening balance must be 0 or a positive amount with at most 2 decimals below {max}", ImportOpeningBalanceInvalid, "opening_balance", nil).WithParams(map[string]interface{}{"max": utils.MaxAmount})
+	ImportRowInvalidErr                    = utils.NewRemark(http.StatusBadRequest, "Row must have the columns {columns}", ImportRowInvalid, "", nil)
+	ReversalOriginalNotFoundErr            = utils.NewRemark(http.StatusNotFound, "Transaction to reverse not found", ReversalOriginalNotFound, "reference", nil)
+	TransferSameAccountErr                 = utils.NewRemark(http.StatusBadRequest, "Beneficiary must not be the source account", TransferSameAccount, "beneficiary_no_rekening", nil)
+	TransferReferenceExistsErr             = utils.NewRemark(http.StatusConflict, "Transfer reference is already used", TransferReferenceExists, "reference", nil)
+	ExternalIDExistsErr                    = utils.NewRemark(http.StatusConflict, "X-EXTERNAL-ID is already used today", ExternalIDExists, "X-EXTERNAL-ID", nil)
+	ExternalIDDBErr                        = utils.NewRemark(http.StatusInternalServerError, "error reading or updating external IDs", ExternalIDDBError, "", nil)
+	QRISInvalidRequestErr                  = utils.NewRemark(http.StatusBadRequest, "Invalid parameter QRIS", QRISInvalidRequest, "", nil)
+	QRISBillNumberInvalidErr               = utils.NewRemark(http.StatusBadRequest, "Bill number must be 1 to 25 letters or digits, on dynamic codes only", QRISBillNumberInvalid, "bill_number", nil)
+	QRISPayloadInvalidErr                  = utils.NewRemark(http.StatusBadRequest, "QRIS payload is invalid: {reason}", QRISPayloadInvalid, "payload", nil)
+	QRISMerchantUnknownErr                 = utils.NewRemark(http.StatusUnprocessableEntity, "QRIS code is not of a merchant of this bank", QRISMerchantUnknown, "payload", nil)
+	QRISStaticPaymentInvalidErr            = utils.NewRemark(http.StatusBadRequest, "Nominal and reference are required to pay a static QRIS code", QRISStaticPaymentInvalid, "nominal, reference", nil)
+	QRISNominalMismatchErr                 = utils.NewRemark(http.StatusUnprocessableEntity, "Nominal must be the amount of the dynamic QRIS code", QRISNominalMismatch, "nominal", nil)
+	QRISPaymentExistsErr                   = utils.NewRemark(http.StatusConflict, "QRIS payment is already posted", QRISPaymentExists, "reference", nil)
+	QRISBillNumberExistsErr                = utils.NewRemark(http.StatusConflict, "Bill number is already used by a QRIS code of the account", QRISBillNumberExists, "bill_number", nil)
+	QRISCodeNotFoundErr                    = utils.NewRemark(http.StatusNotFound, "Dynamic QRIS code not found", QRISCodeNotFound, "payload", nil)
+	QRISDBErr                              = utils.NewRemark(http.StatusInternalServerError, "error reading or updating QRIS codes", QRISDBError, "", nil)
+	VirtualAccountInvalidRequestErr        = utils.NewRemark(http.StatusBadRequest, "Invalid parameter virtual account", VirtualAccountInvalidRequest, "", nil)
+	VirtualAccountNumberInvalidErr         = utils.NewRemark(http.StatusBadRequest, "VA number must be 1 to 16 digits", VirtualAccountNumberInvalid, "va_number", nil)
+	VirtualAccountCustomerNumberInvalidErr = utils.NewRemark(http.StatusBadRequest, "Customer number must be digits and fit in 16 digits after the company prefix", VirtualAccountCustomerNumberInvalid, "customer_number", nil)
+	VirtualAccountExpiryInvalidErr         = utils.NewRemark(http.StatusBadRequest, "Expiry must be in the future", VirtualAccountExpiryInvalid, "expires_at", nil)
+	VirtualAccountExistsErr                = utils.NewRemark(http.StatusConflict, "Virtual account number is already issued", VirtualAccountExists, "customer_number", nil)
+	VirtualAccountNotFoundErr              = utils.NewRemark(http.StatusNotFound, "Virtual account not found", VirtualAccountNotFound, "va_number", nil)
+	VirtualAccountClosedErr                = utils.NewRemark(http.StatusUnprocessableEntity, "Virtual account is closed", VirtualAccountClosed, "va_number", nil)
+	VirtualAccountExpiredErr               = utils.NewRemark(http.StatusUnprocessableEntity, "Virtual account is expired", VirtualAccountExpired, "va_number", nil)
+	VirtualAccountNominalMismatchErr       = utils.NewRemark(http.StatusUnprocessableEntity, "Nominal must be the amount of the virtual account", VirtualAccountNominalMismatch, "nominal", nil)
+	VirtualAccountPaymentExistsErr         = utils.NewRemark(http.StatusConflict, "Virtual account payment is already posted", VirtualAccountPaymentExists, "reference", nil)
+	VirtualAccountDBErr                    = utils.NewRemark(http.StatusInternalServerError, "error reading or updating virtual accounts", VirtualAccountDBError, "", nil)
+	InterbankInvalidRequestErr             = utils.NewRemark(http.StatusBadRequest, "Invalid parameter interbank transfer", InterbankInvalidRequest, "", nil)
+	InterbankBankCodeInvalidErr            = utils.NewRemark(http.StatusBadRequest, "Bank code must be the 3 digits of another bank", InterbankBankCodeInvalid, "bank_code", nil)
+	InterbankBeneficiaryAccountInvalidErr  = utils.NewRemark(http.StatusBadRequest, "Beneficiary account must be 1 to 34 letters or digits", InterbankBeneficiaryAccountInvalid, "beneficiary_account", nil)
+	InterbankBeneficiaryNameInvalidErr     = utils.NewRemark(http.StatusBadRequest, "Beneficiary name must be 1 to 35 ASCII characters", InterbankBeneficiaryNameInvalid, "beneficiary_name", nil)
+	InterbankDescriptionInvalidErr         = utils.NewRemark(http.StatusBadRequest, "Description must be at most 35 ASCII characters", InterbankDescriptionInvalid, "description", nil)
+	InterbankTransferIDInvalidErr          = utils.NewRemark(http.StatusBadRequest, "Transfer ID is invalid", InterbankTransferIDInvalid, "transfer_id", nil)
+	InterbankTransferNotFoundErr           = utils.NewRemark(http.StatusNotFound, "Interbank transfer not found", InterbankTransferNotFound, "transfer_id", nil)
+	InterbankTransferNotSentErr            = utils.NewRemark(http.StatusConflict, "Interbank transfer is not awaiting settlement", InterbankTransferNotSent, "transfer_id", nil)
+	InterbankSettlementMismatchErr         = utils.NewRemark(http.StatusUnprocessableEntity, "Settled nominal must be the nominal of the transfer", InterbankSettlementMismatch, "nominal", nil)
+	InterbankSettlementBatchMismatchErr    = utils.NewRemark(http.StatusUnprocessableEntity, "Transfer was not sent in the settled batch", InterbankSettlementBatchMismatch, "batch_reference", nil)
+	InterbankDBErr                         = utils.NewRemark(http.StatusInternalServerError, "error reading or updating interbank transfers", InterbankDBError, "", nil)
 )
+
+// RequestValidationRemarks maps validate tag failures to the remarks
+// returned to clients.
+var RequestValidationRemarks = utils.ValidationRemarks{
+	Aggregate: RequestValidationErr,
+	Fallback:  RequestFieldInvalidErr,
+	Fields: map[string]*utils.Remark{
+		"name.required":                AccountNameEmptyErr,
+		"nik.required":                 AccountNikEmptyErr,
+		"nik.nik":                      AccountNikInvalidErr,
+		"no_hp.required":               AccountNoHpEmptyErr,
+		"no_hp.phone":                  AccountNoHpInvalidErr,
+		"no_rekening.required":         AccountParamNoRekeningEmptyErr,
+		"no_rekening.norek":            AccountNoRekeningInvalidErr,
+		"nominal.required":             AccountParamNominalErr,
+		"nominal.gt":                   AccountParamNominalErr,
+		"nominal.amount":               AccountNominalInvalidErr,
+		"url.required":                 WebhookURLInvalidErr,
+		"url.http_url":                 WebhookURLInvalidErr,
+		"event_types.required":         WebhookEventTypesInvalidErr,
+		"event_types.min":              WebhookEventTypesInvalidErr,
+		"email.email":                  NotificationEmailInvalidErr,
+		"email.max":                    NotificationEmailInvalidErr,
+		"low_balance_threshold.amount": NotificationThresholdInvalidErr,
+		"from.required":                StatementPeriodInvalidErr,
+		"from.datetime":                StatementPeriodInvalidErr,
+		"to.required":                  StatementPeriodInvalidErr,
+		"to.datetime":                  StatementPeriodInvalidErr,
+		"format.oneof":                 StatementFormatInvalidErr,
+		"period.required":              MonthlyStatementPeriodInvalidErr,
+		"period.datetime":              MonthlyStatementPeriodInvalidErr,
+		"batch_reference.required":     BulkCreditReferenceInvalidErr,
+		"batch_reference.max":          BulkCreditReferenceInvalidErr,
+		"source_no_rekening.required":  BulkCreditSourceInvalidErr,
+		"source_no_rekening.norek":     BulkCreditSourceInvalidErr,
+		"mode.oneof":                   BulkCreditModeInvalidErr,
+		"opening_balance.amount":       ImportOpeningBalanceInvalidErr,
+		"bill_number.max":              QRISBillNumberInvalidErr,
+		"bill_number.alphanum":         QRISBillNumberInvalidErr,
+		"bill_number.excluded_without": QRISBillNumberInvalidErr,
+		"va_number.required":           VirtualAccountNumberInvalidErr,
+		"va_number.number":             VirtualAccountNumberInvalidErr,
+		"va_number.max":                VirtualAccountNumberInvalidErr,
+		"customer_number.number":       VirtualAccountCustomerNumberInvalidErr,
+		"customer_number.max":          VirtualAccountCustomerNumberInvalidErr,
+		"bank_code.required":           InterbankBankCodeInvalidErr,
+		"bank_code.len":                InterbankBankCodeInvalidErr,
+		"bank_code.number":             InterbankBankCodeInvalidErr,
+		"beneficiary_account.required": InterbankBeneficiaryAccountInvalidErr,
+		"beneficiary_account.max":      InterbankBeneficiaryAccountInvalidErr,
+		"beneficiary_account.alphanum": InterbankBeneficiaryAccountInvalidErr,
+		"beneficiary_name.required":    InterbankBeneficiaryNameInvalidErr,
+		"beneficiary_name.max":         InterbankBeneficiaryNameInvalidErr,
+		"beneficiary_name.printascii":  InterbankBeneficiaryNameInvalidErr,
+		"description.max":              InterbankDescriptionInvalidErr,
+		"description.printascii":       InterbankDescriptionInvalidErr,
+		"transfer_id.required":         InterbankTransferIDInvalidErr,
+	},
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"accounts-service/utils"
+	"strings"
+)
+
+// OpeningBalanceReference is the reference of the mutation posting the
+// opening balance of an imported account.
+const OpeningBalanceReference = "SALDO AWAL MIGRASI"
+
+// ImportAccountRequest is an account migrated from the legacy core, keeping
+// its no rekening. It is validated with the rules of CreateAccountRequest.
+type ImportAccountRequest struct {
+	NoRekening string `json:"no_rekening" validate:"required,norek"`
+	Name       string `json:"name" validate:"required,max=255"`
+	NIK        string `json:"nik" validate:"required,nik"`
+	NoHP       string `json:"no_hp" validate:"required,phone"`
+	// OpeningBalance is posted as a credit mutation when not 0.
+	OpeningBalance float64 `json:"opening_balance" validate:"omitempty,amount"`
+}
+
+func (r *ImportAccountRequest) Normalize() {
+	r.NoRekening = strings.TrimSpace(r.NoRekening)
+	r.Name = strings.TrimSpace(r.Name)
+	r.NIK = strings.TrimSpace(r.NIK)
+	r.NoHP = utils.NormalizePhone(r.NoHP)
+}
@@ -0,0 +1,119 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Interbank transfer states. A queued transfer is sent in the clearing
+// batch of the next cut-off, then settled or rejected by the settlement
+// file of its batch.
+const (
+	InterbankQueued   = "queued"
+	InterbankSent     = "sent"
+	InterbankSettled  = "settled"
+	InterbankRejected = "rejected"
+)
+
+// References of the postings of an interbank transfer. The order debits the
+// customer into the clearing suspense account referenced with the prefix
+// and the reference of the customer. Settling debits the suspense account,
+// rejecting returns the nominal to the customer, both referenced with the
+// transfer ID.
+const (
+	InterbankReferencePrefix  = "IBT "
+	InterbankSettlementPrefix = "KLIRING "
+	InterbankReturnPrefix     = "RETUR "
+)
+
+// interbankTransferIDPrefix starts the transfer ID, followed by the
+// zero-padded ID of the transfer.
+const interbankTransferIDPrefix = "IBT"
+
+// InterbankTransferID returns the transfer ID of the transfer with id, e.g.
+// "IBT0000000000042", as written in clearing files.
+func InterbankTransferID(id uint) string {
+	return fmt.Sprintf("%s%013d", interbankTransferIDPrefix, id)
+}
+
+// ParseInterbankTransferID returns the ID of a transfer ID, false when it is
+// not one.
+func ParseInterbankTransferID(transferID string) (uint, bool) {
+	digits, ok := strings.CutPrefix(transferID, interbankTransferIDPrefix)
+	if !ok || len(digits) != 13 {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(digits, 10, 64)
+	if err != nil || id == 0 {
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// InterbankTransfer is an order to send money to an account of another
+// bank. CompletedAt is when it was settled or rejected.
+type InterbankTransfer struct {
+	ID                 uint       `json:"-"`
+	TransferID         string     `json:"transfer_id"`
+	SourceAccountID    uint       `json:"-"`
+	SourceNoRekening   string     `json:"source_no_rekening"`
+	BankCode           string     `json:"bank_code"`
+	BeneficiaryAccount string     `json:"beneficiary_account"`
+	BeneficiaryName    string     `json:"beneficiary_name"`
+	Nominal            float64    `json:"nominal"`
+	Reference          string     `json:"reference"`
+	Description        string     `json:"description,omitempty"`
+	Status             string     `json:"status"`
+	BatchID            uint       `json:"-"`
+	BatchReference     string     `json:"batch_reference,omitempty"`
+	ReturnReason       string     `json:"return_reason,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+	CompletedAt        *time.Time `json:"completed_at"`
+}
+
+// InterbankBatch is a clearing batch, the transfers queued before a
+// cut-off written to a file in the blob store. WrittenAt is nil until the
+// file is written, after the batch commits.
+type InterbankBatch struct {
+	ID            uint
+	Reference     string
+	CutoffAt      time.Time
+	BlobKey       string
+	TransferCount int
+	TotalNominal  float64
+	Transfers     []InterbankTransfer
+	CreatedAt     time.Time
+	WrittenAt     *time.Time
+}
+
+// InterbankTransferRequest orders a transfer from the account of the path.
+// Reference is used once per source account. The beneficiary name and
+// description are written to clearing files, so they are ASCII.
+type InterbankTransferRequest struct {
+	NoRekening         string  `param:"no_rekening" validate:"required,norek"`
+	BankCode           string  `json:"bank_code" validate:"required,len=3,number"`
+	BeneficiaryAccount string  `json:"beneficiary_account" validate:"required,max=34,alphanum"`
+	BeneficiaryName    string  `json:"beneficiary_name" validate:"required,max=35,printascii"`
+	Nominal            float64 `json:"nominal" validate:"required,gt=0,amount"`
+	Reference          string  `json:"reference" validate:"required,max=64"`
+	Description        string  `json:"description" validate:"max=35,printascii"`
+}
+
+func (r *InterbankTransferRequest) Normalize() {
+	r.NoRekening = strings.TrimSpace(r.NoRekening)
+	r.BankCode = strings.TrimSpace(r.BankCode)
+	r.BeneficiaryAccount = strings.TrimSpace(r.BeneficiaryAccount)
+	r.BeneficiaryName = strings.ToUpper(strings.TrimSpace(r.BeneficiaryName))
+	r.Reference = strings.TrimSpace(r.Reference)
+	r.Description = strings.TrimSpace(r.Description)
+}
+
+type InterbankTransferLookupRequest struct {
+	TransferID string `param:"transfer_id" validate:"required"`
+}
+
+func (r *InterbankTransferLookupRequest) Normalize() {
+	r.TransferID = strings.ToUpper(strings.TrimSpace(r.TransferID))
+}
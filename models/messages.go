@@ -0,0 +1,419 @@
+package models
+
+import "accounts-service/utils"
+
+// Messages is the translated message catalog for every Remark code.
+var Messages = utils.Catalog{
+	AccountWithNIKIsExist: {
+		utils.LangID: "Rekening dengan NIK tersebut sudah terdaftar",
+		utils.LangEN: "Account with NIK is already exist",
+	},
+	AccountWithNoHpKIsExist: {
+		utils.LangID: "Rekening dengan No HP tersebut sudah terdaftar",
+		utils.LangEN: "Account with No HP is already exist",
+	},
+	AccountWithNoRekeningNotFound: {
+		utils.LangID: "Rekening dengan No Rekening tersebut tidak ditemukan",
+		utils.LangEN: "Account with No Rekening not found",
+	},
+	AccountNameEmpty: {
+		utils.LangID: "Parameter nama rekening kosong",
+		utils.LangEN: "Parameter Account name is empty",
+	},
+	AccountNikEmpty: {
+		utils.LangID: "Parameter NIK rekening kosong",
+		utils.LangEN: "Parameter Account NIK is empty",
+	},
+	AccountNoHpEmpty: {
+		utils.LangID: "Parameter No HP rekening kosong",
+		utils.LangEN: "Parameter Account No Hp is empty",
+	},
+	AccountParamNoRekeningEmpty: {
+		utils.LangID: "Parameter No Rekening kosong",
+		utils.LangEN: "Param No rekening empty",
+	},
+	AccountParamNominalLessZero: {
+		utils.LangID: "Nominal harus lebih besar dari {min}",
+		utils.LangEN: "Nominal must be greater than {min}",
+	},
+	Accountinsufficient: {
+		utils.LangID: "Saldo tidak mencukupi untuk penarikan {nominal}",
+		utils.LangEN: "Insufficient balance for withdrawal of {nominal}",
+	},
+	AccountInvalidRequest: {
+		utils.LangID: "Parameter pembuatan rekening tidak valid",
+		utils.LangEN: "Invalid parameter create account",
+	},
+	CreditInvalidRequest: {
+		utils.LangID: "Parameter setoran/tabung tidak valid",
+		utils.LangEN: "Invalid parameter credit/tabung",
+	},
+	DebitInvalidRequest: {
+		utils.LangID: "Parameter penarikan/tarik tidak valid",
+		utils.LangEN: "Invalid parameter debit/tarik",
+	},
+	GetAccountError: {
+		utils.LangID: "Gagal mengambil data rekening",
+		utils.LangEN: "Error getting account",
+	},
+	UpdateSaldoError: {
+		utils.LangID: "Gagal memperbarui saldo rekening",
+		utils.LangEN: "Error updating account saldo",
+	},
+	CreateMutationError: {
+		utils.LangID: "Gagal mencatat mutasi",
+		utils.LangEN: "Error creating mutation",
+	},
+	CreateAccountError: {
+		utils.LangID: "Gagal membuat rekening",
+		utils.LangEN: "Error creating account",
+	},
+	CreateTransactionDBError: {
+		utils.LangID: "Gagal memulai transaksi",
+		utils.LangEN: "Error beginning transaction",
+	},
+	CommitTransactionDBError: {
+		utils.LangID: "Gagal menyimpan transaksi",
+		utils.LangEN: "Error committing transaction",
+	},
+	RouteNotFound: {
+		utils.LangID: "Alamat tidak ditemukan",
+		utils.LangEN: "Route not found",
+	},
+	HTTPRequestError: {
+		utils.LangID: "Permintaan tidak dapat diproses",
+		utils.LangEN: "Request could not be processed",
+	},
+	InternalServerError: {
+		utils.LangID: "Terjadi kesalahan pada server",
+		utils.LangEN: "Internal server error",
+	},
+	RequestValidationError: {
+		utils.LangID: "Validasi permintaan gagal",
+		utils.LangEN: "Request validation failed",
+	},
+	RequestFieldInvalid: {
+		utils.LangID: "Kolom {field} tidak memenuhi aturan {rule}",
+		utils.LangEN: "Field {field} fails rule {rule}",
+	},
+	AccountNikInvalid: {
+		utils.LangID: "NIK harus 16 digit dengan kode provinsi dan tanggal lahir yang valid",
+		utils.LangEN: "NIK must be 16 digits with a valid province code and birth date",
+	},
+	AccountNoHpInvalid: {
+		utils.LangID: "No HP harus berupa nomor seluler Indonesia",
+		utils.LangEN: "No HP must be an Indonesian mobile number",
+	},
+	AccountNoRekeningInvalid: {
+		utils.LangID: "No rekening harus terdiri dari 10 sampai 12 digit",
+		utils.LangEN: "No rekening must be 10 to 12 digits",
+	},
+	AccountNominalInvalid: {
+		utils.LangID: "Nominal maksimal 2 angka desimal dan kurang dari {max}",
+		utils.LangEN: "Nominal must have at most 2 decimals and be below {max}",
+	},
+	QueryTimeout: {
+		utils.LangID: "Permintaan terlalu lama diproses, silakan coba lagi",
+		utils.LangEN: "Request took too long, please retry",
+	},
+	CreateOutboxEventError: {
+		utils.LangID: "Gagal mencatat event mutasi",
+		utils.LangEN: "error recording mutation event",
+	},
+	OutboxDBError: {
+		utils.LangID: "Gagal membaca atau memperbarui outbox",
+		utils.LangEN: "error reading or updating outbox",
+	},
+	AdminUnauthorized: {
+		utils.LangID: "Token admin tidak ada atau tidak valid",
+		utils.LangEN: "Admin token missing or invalid",
+	},
+	WebhookNotFound: {
+		utils.LangID: "Webhook tidak ditemukan",
+		utils.LangEN: "Webhook not found",
+	},
+	WebhookDeliveryNotFound: {
+		utils.LangID: "Pengiriman webhook tidak ditemukan",
+		utils.LangEN: "Webhook delivery not found",
+	},
+	WebhookInvalidRequest: {
+		utils.LangID: "Parameter webhook tidak valid",
+		utils.LangEN: "Invalid parameter webhook",
+	},
+	WebhookURLInvalid: {
+		utils.LangID: "URL webhook harus berupa URL http atau https lengkap",
+		utils.LangEN: "Webhook URL must be an absolute http or https URL",
+	},
+	WebhookEventTypesInvalid: {
+		utils.LangID: "Jenis event harus satu atau lebih dari {allowed}",
+		utils.LangEN: "Event types must be one or more of {allowed}",
+	},
+	WebhookDBError: {
+		utils.LangID: "Gagal membaca atau memperbarui webhook",
+		utils.LangEN: "error reading or updating webhooks",
+	},
+	NotificationInvalidRequest: {
+		utils.LangID: "Parameter preferensi notifikasi tidak valid",
+		utils.LangEN: "Invalid parameter notification preference",
+	},
+	NotificationChannelsInvalid: {
+		utils.LangID: "Kanal harus kosong atau berisi {allowed}",
+		utils.LangEN: "Channels must be zero or more of {allowed}",
+	},
+	NotificationEmailInvalid: {
+		utils.LangID: "Email harus berupa alamat yang valid dan wajib diisi untuk kanal email",
+		utils.LangEN: "Email must be a valid address and is required for the email channel",
+	},
+	NotificationThresholdInvalid: {
+		utils.LangID: "Batas saldo minimum harus 0 atau nominal positif dengan maksimal 2 desimal di bawah {max}",
+		utils.LangEN: "Low balance threshold must be 0 or a positive amount with at most 2 decimals below {max}",
+	},
+	NotificationDBError: {
+		utils.LangID: "Gagal membaca atau memperbarui preferensi notifikasi",
+		utils.LangEN: "error reading or updating notification preferences",
+	},
+	MutationDBError: {
+		utils.LangID: "Gagal membaca mutasi",
+		utils.LangEN: "error reading mutations",
+	},
+	MutationPageTokenInvalid: {
+		utils.LangID: "Page token harus berupa next_page_token dari halaman sebelumnya",
+		utils.LangEN: "Page token must be the next_page_token of the previous page",
+	},
+	StatementInvalidRequest: {
+		utils.LangID: "Parameter rekening koran tidak valid",
+		utils.LangEN: "Invalid parameter statement",
+	},
+	StatementPeriodInvalid: {
+		utils.LangID: "Periode harus berupa tanggal from dan to dengan format YYYY-MM-DD dan from tidak setelah to",
+		utils.LangEN: "Period must be from and to dates as YYYY-MM-DD with from not after to",
+	},
+	StatementFormatInvalid: {
+		utils.LangID: "Format harus salah satu dari {allowed}",
+		utils.LangEN: "Format must be one of {allowed}",
+	},
+	MonthlyStatementNotFound: {
+		utils.LangID: "Rekening koran bulanan tidak ditemukan",
+		utils.LangEN: "Monthly statement not found",
+	},
+	MonthlyStatementInvalid: {
+		utils.LangID: "Parameter rekening koran bulanan tidak valid",
+		utils.LangEN: "Invalid parameter monthly statement",
+	},
+	MonthlyStatementPeriodInvalid: {
+		utils.LangID: "Periode harus berupa bulan dengan format YYYY-MM",
+		utils.LangEN: "Period must be a month as YYYY-MM",
+	},
+	MonthlyStatementCorrupted: {
+		utils.LangID: "Arsip rekening koran bulanan tidak sesuai dengan checksum",
+		utils.LangEN: "Archived monthly statement does not match its checksum",
+	},
+	MonthlyStatementDBError: {
+		utils.LangID: "Gagal membaca atau memperbarui rekening koran bulanan",
+		utils.LangEN: "error reading or updating monthly statements",
+	},
+	BulkCreditInvalidRequest: {
+		utils.LangID: "Parameter kredit massal tidak valid",
+		utils.LangEN: "Invalid parameter bulk credit",
+	},
+	BulkCreditReferenceInvalid: {
+		utils.LangID: "Referensi batch harus 1 sampai 64 karakter",
+		utils.LangEN: "Batch reference must be 1 to 64 characters",
+	},
+	BulkCreditSourceInvalid: {
+		utils.LangID: "No rekening sumber harus 10 sampai 12 digit",
+		utils.LangEN: "Source no rekening must be 10 to 12 digits",
+	},
+	BulkCreditModeInvalid: {
+		utils.LangID: "Mode harus salah satu dari {allowed}",
+		utils.LangEN: "Mode must be one of {allowed}",
+	},
+	BulkCreditRowsInvalid: {
+		utils.LangID: "Batch harus berisi 1 sampai {max} baris",
+		utils.LangEN: "Batch must have 1 to {max} rows",
+	},
+	BulkCreditFileInvalid: {
+		utils.LangID: "Baris {line} file CSV tidak valid, kolom yang diharapkan no_rekening,nominal,reference",
+		utils.LangEN: "Line {line} of the CSV file is invalid, expected the columns no_rekening,nominal,reference",
+	},
+	BulkCreditBatchExists: {
+		utils.LangID: "Referensi batch sudah digunakan",
+		utils.LangEN: "Batch reference is already used",
+	},
+	BulkCreditNotFound: {
+		utils.LangID: "Batch kredit massal tidak ditemukan",
+		utils.LangEN: "Bulk credit batch not found",
+	},
+	BulkCreditRowIsSource: {
+		utils.LangID: "Rekening penerima tidak boleh sama dengan rekening sumber",
+		utils.LangEN: "Recipient must not be the source account",
+	},
+	BulkCreditRowReferenceInvalid: {
+		utils.LangID: "Referensi maksimal 255 karakter",
+		utils.LangEN: "Reference must be at most 255 characters",
+	},
+	BulkCreditDBError: {
+		utils.LangID: "Gagal membaca atau memperbarui kredit massal",
+		utils.LangEN: "error reading or updating bulk credits",
+	},
+	AccountWithNoRekeningIsExist: {
+		utils.LangID: "Rekening dengan No Rekening tersebut sudah terdaftar",
+		utils.LangEN: "Account with No Rekening is already exist",
+	},
+	ImportOpeningBalanceInvalid: {
+		utils.LangID: "Saldo awal harus 0 atau nominal positif dengan maksimal 2 desimal di bawah {max}",
+		utils.LangEN: "Opening balance must be 0 or a positive amount with at most 2 decimals below {max}",
+	},
+	ImportRowInvalid: {
+		utils.LangID: "Baris harus berisi kolom {columns}",
+		utils.LangEN: "Row must have the columns {columns}",
+	},
+	ReversalOriginalNotFound: {
+		utils.LangID: "Transaksi yang akan dibatalkan tidak ditemukan",
+		utils.LangEN: "Transaction to reverse not found",
+	},
+	TransferSameAccount: {
+		utils.LangID: "Rekening tujuan tidak boleh sama dengan rekening sumber",
+		utils.LangEN: "Beneficiary must not be the source account",
+	},
+	TransferReferenceExists: {
+		utils.LangID: "Referensi transfer sudah digunakan",
+		utils.LangEN: "Transfer reference is already used",
+	},
+	ExternalIDExists: {
+		utils.LangID: "X-EXTERNAL-ID sudah digunakan hari ini",
+		utils.LangEN: "X-EXTERNAL-ID is already used today",
+	},
+	ExternalIDDBError: {
+		utils.LangID: "Gagal membaca atau memperbarui external ID",
+		utils.LangEN: "error reading or updating external IDs",
+	},
+	QRISInvalidRequest: {
+		utils.LangID: "Parameter QRIS tidak valid",
+		utils.LangEN: "Invalid parameter QRIS",
+	},
+	QRISBillNumberInvalid: {
+		utils.LangID: "Nomor tagihan harus 1 sampai 25 huruf atau angka, hanya pada kode dinamis",
+		utils.LangEN: "Bill number must be 1 to 25 letters or digits, on dynamic codes only",
+	},
+	QRISPayloadInvalid: {
+		utils.LangID: "Payload QRIS tidak valid: {reason}",
+		utils.LangEN: "QRIS payload is invalid: {reason}",
+	},
+	QRISMerchantUnknown: {
+		utils.LangID: "Kode QRIS bukan milik merchant bank ini",
+		utils.LangEN: "QRIS code is not of a merchant of this bank",
+	},
+	QRISStaticPaymentInvalid: {
+		utils.LangID: "Nominal dan referensi wajib diisi untuk membayar kode QRIS statis",
+		utils.LangEN: "Nominal and reference are required to pay a static QRIS code",
+	},
+	QRISNominalMismatch: {
+		utils.LangID: "Nominal harus sama dengan jumlah pada kode QRIS dinamis",
+		utils.LangEN: "Nominal must be the amount of the dynamic QRIS code",
+	},
+	QRISPaymentExists: {
+		utils.LangID: "Pembayaran QRIS sudah dibukukan",
+		utils.LangEN: "QRIS payment is already posted",
+	},
+	QRISBillNumberExists: {
+		utils.LangID: "Nomor tagihan sudah dipakai kode QRIS rekening ini",
+		utils.LangEN: "Bill number is already used by a QRIS code of the account",
+	},
+	QRISCodeNotFound: {
+		utils.LangID: "Kode QRIS dinamis tidak ditemukan",
+		utils.LangEN: "Dynamic QRIS code not found",
+	},
+	QRISDBError: {
+		utils.LangID: "Gagal membaca atau memperbarui kode QRIS",
+		utils.LangEN: "error reading or updating QRIS codes",
+	},
+	VirtualAccountInvalidRequest: {
+		utils.LangID: "Parameter virtual account tidak valid",
+		utils.LangEN: "Invalid parameter virtual account",
+	},
+	VirtualAccountNumberInvalid: {
+		utils.LangID: "Nomor VA harus 1 sampai 16 digit",
+		utils.LangEN: "VA number must be 1 to 16 digits",
+	},
+	VirtualAccountCustomerNumberInvalid: {
+		utils.LangID: "Nomor pelanggan harus berupa digit dan muat dalam 16 digit setelah prefix perusahaan",
+		utils.LangEN: "Customer number must be digits and fit in 16 digits after the company prefix",
+	},
+	VirtualAccountExpiryInvalid: {
+		utils.LangID: "Masa berlaku harus di masa depan",
+		utils.LangEN: "Expiry must be in the future",
+	},
+	VirtualAccountExists: {
+		utils.LangID: "Nomor virtual account sudah diterbitkan",
+		utils.LangEN: "Virtual account number is already issued",
+	},
+	VirtualAccountNotFound: {
+		utils.LangID: "Virtual account tidak ditemukan",
+		utils.LangEN: "Virtual account not found",
+	},
+	VirtualAccountClosed: {
+		utils.LangID: "Virtual account sudah ditutup",
+		utils.LangEN: "Virtual account is closed",
+	},
+	VirtualAccountExpired: {
+		utils.LangID: "Virtual account sudah kedaluwarsa",
+		utils.LangEN: "Virtual account is expired",
+	},
+	VirtualAccountNominalMismatch: {
+		utils.LangID: "Nominal harus sama dengan jumlah virtual account",
+		utils.LangEN: "Nominal must be the amount of the virtual account",
+	},
+	VirtualAccountPaymentExists: {
+		utils.LangID: "Pembayaran virtual account sudah dibukukan",
+		utils.LangEN: "Virtual account payment is already posted",
+	},
+	VirtualAccountDBError: {
+		utils.LangID: "Gagal membaca atau memperbarui virtual account",
+		utils.LangEN: "error reading or updating virtual accounts",
+	},
+	InterbankInvalidRequest: {
+		utils.LangID: "Parameter transfer antarbank tidak valid",
+		utils.LangEN: "Invalid parameter interbank transfer",
+	},
+	InterbankBankCodeInvalid: {
+		utils.LangID: "Kode bank harus 3 digit kode bank lain",
+		utils.LangEN: "Bank code must be the 3 digits of another bank",
+	},
+	InterbankBeneficiaryAccountInvalid: {
+		utils.LangID: "Rekening tujuan harus 1 sampai 34 huruf atau angka",
+		utils.LangEN: "Beneficiary account must be 1 to 34 letters or digits",
+	},
+	InterbankBeneficiaryNameInvalid: {
+		utils.LangID: "Nama penerima harus 1 sampai 35 karakter ASCII",
+		utils.LangEN: "Beneficiary name must be 1 to 35 ASCII characters",
+	},
+	InterbankDescriptionInvalid: {
+		utils.LangID: "Keterangan maksimal 35 karakter ASCII",
+		utils.LangEN: "Description must be at most 35 ASCII characters",
+	},
+	InterbankTransferIDInvalid: {
+		utils.LangID: "Transfer ID tidak valid",
+		utils.LangEN: "Transfer ID is invalid",
+	},
+	InterbankTransferNotFound: {
+		utils.LangID: "Transfer antarbank tidak ditemukan",
+		utils.LangEN: "Interbank transfer not found",
+	},
+	InterbankTransferNotSent: {
+		utils.LangID: "Transfer antarbank tidak sedang menunggu settlement",
+		utils.LangEN: "Interbank transfer is not awaiting settlement",
+	},
+	InterbankSettlementMismatch: {
+		utils.LangID: "Nominal settlement harus sama dengan nominal transfer",
+		utils.LangEN: "Settled nominal must be the nominal of the transfer",
+	},
+	InterbankSettlementBatchMismatch: {
+		utils.LangID: "Transfer antarbank tidak dikirim dalam batch settlement",
+		utils.LangEN: "Transfer was not sent in the settled batch",
+	},
+	InterbankDBError: {
+		utils.LangID: "Gagal membaca atau memperbarui transfer antarbank",
+		utils.LangEN: "error reading or updating interbank transfers",
+	},
+}
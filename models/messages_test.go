@@ -0,0 +1,70 @@
+package models_test
+
+import (
+	"accounts-service/models"
+	"accounts-service/utils"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var codePattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]+$`)
+
+// remarkCodes collects every error code string declared in erros.go.
+func remarkCodes(t *testing.T) []string {
+	file, err := parser.ParseFile(token.NewFileSet(), "erros.go", nil, 0)
+	require.NoError(t, err)
+
+	var codes []string
+	ast.Inspect(file, func(n ast.Node) bool {
+		lit, ok := n.(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			return true
+		}
+		value, err := strconv.Unquote(lit.Value)
+		require.NoError(t, err)
+		if codePattern.MatchString(value) {
+			codes = append(codes, value)
+		}
+		return true
+	})
+	require.NotEmpty(t, codes)
+	return codes
+}
+
+func TestMessages_EveryCodeTranslated(t *testing.T) {
+	for _, code := range remarkCodes(t) {
+		translations, ok := models.Messages[code]
+		if !assert.True(t, ok, "code %s has no catalog entry", code) {
+			continue
+		}
+
+		for _, lang := range utils.SupportedLanguages {
+			assert.NotEmpty(t, translations[lang], "code %s has no %q translation", code, lang)
+		}
+
+		assert.Equal(t,
+			utils.Placeholders(translations[utils.LangID]),
+			utils.Placeholders(translations[utils.LangEN]),
+			"code %s uses different placeholders per language", code)
+	}
+}
+
+func TestMessages_Localize(t *testing.T) {
+	remark := models.AccountinsufficientErr.WithParams(map[string]interface{}{"nominal": 1250000.5})
+
+	id := models.Messages.Localize(remark, utils.ParseAcceptLanguage("id-ID,id;q=0.9"))
+	assert.Equal(t, "Saldo tidak mencukupi untuk penarikan Rp1.250.000,50", id.Remark.Message)
+
+	en := models.Messages.Localize(remark, utils.ParseAcceptLanguage("fr;q=1, en-US;q=0.8, id;q=0.5"))
+	assert.Equal(t, "Insufficient balance for withdrawal of IDR 1,250,000.50", en.Remark.Message)
+
+	assert.Equal(t, models.Accountinsufficient, en.Remark.Code)
+	assert.Equal(t, "Saldo not enough / Insufficient balance", models.AccountinsufficientErr.Remark.Message)
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// MonthlyStatementPeriodLayout is the layout of the month of a monthly
+// statement, e.g. "2025-04".
+const MonthlyStatementPeriodLayout = "2006-01"
+
+// Monthly statement states. A failed statement is generated again by the
+// next run of the job.
+const (
+	MonthlyStatementGenerated = "generated"
+	MonthlyStatementFailed    = "failed"
+)
+
+// MonthlyStatement is the archived PDF rekening koran of an account for one
+// month. The file lives in the blob store under BlobKey, Checksum is the hex
+// SHA-256 of its content.
+type MonthlyStatement struct {
+	ID          uint       `json:"-"`
+	AccountID   uint       `json:"-"`
+	NoRekening  string     `json:"no_rekening"`
+	Period      string     `json:"period"`
+	Status      string     `json:"status"`
+	BlobKey     string     `json:"-"`
+	Size        int64      `json:"size"`
+	Checksum    string     `json:"checksum"`
+	Attempts    int        `json:"-"`
+	LastError   string     `json:"-"`
+	GeneratedAt *time.Time `json:"generated_at"`
+	NotifiedAt  *time.Time `json:"notified_at"`
+	CreatedAt   time.Time  `json:"-"`
+	UpdatedAt   time.Time  `json:"-"`
+}
+
+type MonthlyStatementsRequest struct {
+	NoRekening string `param:"no_rekening" validate:"required,norek"`
+}
+
+func (r *MonthlyStatementsRequest) Normalize() {
+	r.NoRekening = strings.TrimSpace(r.NoRekening)
+}
+
+type MonthlyStatementRequest struct {
+	NoRekening string `param:"no_rekening" validate:"required,norek"`
+	Period     string `param:"period" validate:"required,datetime=2006-01"`
+}
+
+func (r *MonthlyStatementRequest) Normalize() {
+	r.NoRekening = strings.TrimSpace(r.NoRekening)
+	r.Period = strings.TrimSpace(r.Period)
+}
+
+// MonthlyStatementBounds returns the first and last day of a period, at
+// midnight in StatementZone.
+func MonthlyStatementBounds(period string) (time.Time, time.Time, error) {
+	from, err := time.ParseInLocation(MonthlyStatementPeriodLayout, period, StatementZone)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return from, from.AddDate(0, 1, -1), nil
+}
+
+// PreviousMonthlyStatementPeriod returns the last month completed at now in
+// StatementZone.
+func PreviousMonthlyStatementPeriod(now time.Time) string {
+	now = now.In(StatementZone)
+	firstOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, StatementZone)
+	return firstOfMonth.AddDate(0, -1, 0).Format(MonthlyStatementPeriodLayout)
+}
@@ -2,6 +2,12 @@ package models
 
 import "time"
 
+// Mutation types.
+const (
+	MutationTypeCredit = "credit/tabung"
+	MutationTypeDebit  = "debit/tarik"
+)
+
 type Mutation struct {
 	ID        uint      `json:"id"`
 	AccountID uint      `json:"account_id"`
@@ -10,3 +16,17 @@ type Mutation struct {
 	Reference string    `json:"reference"`
 	CreatedAt time.Time `json:"created_at"`
 }
+
+type ListMutationsRequest struct {
+	NoRekening string `param:"no_rekening" validate:"required,norek"`
+	// BeforeID continues a listing from the NextBeforeID of the previous page.
+	BeforeID uint `query:"before_id"`
+	Limit    int  `query:"limit" validate:"omitempty,min=1,max=200"`
+}
+
+// MutationPage is one page of mutations, newest first.
+type MutationPage struct {
+	Mutations []Mutation `json:"mutations"`
+	// NextBeforeID requests the next page, it is 0 on the last page.
+	NextBeforeID uint `json:"next_before_id,omitempty"`
+}
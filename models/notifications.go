@@ -0,0 +1,85 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Notification channels a customer can choose. SMS and WhatsApp go to the
+// account's no_hp, email to the address in the preference.
+const (
+	NotificationChannelSMS      = "sms"
+	NotificationChannelWhatsApp = "whatsapp"
+	NotificationChannelEmail    = "email"
+)
+
+// NotificationChannels are the channels a preference can list.
+var NotificationChannels = []string{NotificationChannelSMS, NotificationChannelWhatsApp, NotificationChannelEmail}
+
+// Kinds of customer notification.
+const (
+	NotificationDebit            = "debit"
+	NotificationLargeCredit      = "large_credit"
+	NotificationLowBalance       = "low_balance"
+	NotificationMonthlyStatement = "monthly_statement"
+)
+
+// NotificationPreference is how a customer wants to be notified. Accounts
+// without one get DefaultNotificationPreference.
+type NotificationPreference struct {
+	AccountID  uint     `json:"-"`
+	NoRekening string   `json:"no_rekening"`
+	Channels   []string `json:"channels"`
+	Email      string   `json:"email,omitempty"`
+	// LowBalanceThreshold alerts once the saldo drops below it, 0 disables
+	// the alert.
+	LowBalanceThreshold float64   `json:"low_balance_threshold"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+// DefaultNotificationPreference notifies by SMS without a low balance alert.
+func DefaultNotificationPreference(account *Account) *NotificationPreference {
+	return &NotificationPreference{
+		AccountID:  account.ID,
+		NoRekening: account.NoRekening,
+		Channels:   []string{NotificationChannelSMS},
+	}
+}
+
+type NotificationPreferenceRequest struct {
+	NoRekening string `param:"no_rekening" validate:"required,norek"`
+}
+
+type UpdateNotificationPreferenceRequest struct {
+	NoRekening string `param:"no_rekening" validate:"required,norek"`
+	// Channels may be empty to turn notifications off.
+	Channels            []string `json:"channels"`
+	Email               string   `json:"email" validate:"omitempty,email,max=255"`
+	LowBalanceThreshold float64  `json:"low_balance_threshold" validate:"omitempty,amount"`
+}
+
+func (r *UpdateNotificationPreferenceRequest) Normalize() {
+	r.NoRekening = strings.TrimSpace(r.NoRekening)
+	r.Email = strings.TrimSpace(r.Email)
+}
+
+// Notification is one rendered message for one recipient.
+type Notification struct {
+	Kind       string    `json:"kind"`
+	Channel    string    `json:"channel"`
+	Recipient  string    `json:"recipient"`
+	NoRekening string    `json:"no_rekening"`
+	Subject    string    `json:"subject,omitempty"`
+	Body       string    `json:"body"`
+	CreatedAt  time.Time `json:"created_at"`
+	// Attachments are only sent by email.
+	Attachments []NotificationAttachment `json:"attachments,omitempty"`
+}
+
+// NotificationAttachment is a file sent with a notification. The content is
+// left out of the JSON written by the development sinks.
+type NotificationAttachment struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Content     []byte `json:"-"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Outbox event states. Pending events are retried until they are published
+// or have used up their attempts and are dead-lettered.
+const (
+	OutboxStatusPending   = "pending"
+	OutboxStatusPublished = "published"
+	OutboxStatusDead      = "dead"
+)
+
+// EventMutationCreated is published for every tabung and tarik.
+const EventMutationCreated = "mutation.created"
+
+// OutboxEvent is an event recorded in the same transaction as the change it
+// describes. Events with the same AggregateKey are published in ID order.
+type OutboxEvent struct {
+	ID            uint            `json:"id"`
+	AggregateKey  string          `json:"aggregate_key"`
+	EventType     string          `json:"event_type"`
+	Payload       json.RawMessage `json:"payload"`
+	Status        string          `json:"-"`
+	Attempts      int             `json:"-"`
+	LastError     string          `json:"-"`
+	NextAttemptAt time.Time       `json:"-"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+// MutationEvent is the payload of EventMutationCreated.
+type MutationEvent struct {
+	MutationID uint      `json:"mutation_id"`
+	AccountID  uint      `json:"account_id"`
+	NoRekening string    `json:"no_rekening"`
+	Type       string    `json:"type"`
+	Nominal    float64   `json:"nominal"`
+	Saldo      float64   `json:"saldo"`
+	Reference  string    `json:"reference"`
+	CreatedAt  time.Time `json:"created_at"`
+}
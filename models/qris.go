@@ -0,0 +1,96 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// QRIS code types.
+const (
+	QRISStatic  = "static"
+	QRISDynamic = "dynamic"
+)
+
+// Statuses of a dynamic QRIS code.
+const (
+	QRISBillStatusOpen = "open"
+	QRISBillStatusPaid = "paid"
+)
+
+// QRISReferencePrefix starts the reference of the credit of a QRIS payment,
+// followed by the bill number of a dynamic code or the payment reference of
+// a static one.
+const QRISReferencePrefix = "QRIS "
+
+// QRISRequest generates a QRIS code paying the account. A code with a
+// nominal is dynamic and paid once, one without is static and paid with
+// the nominal the payer enters.
+type QRISRequest struct {
+	NoRekening string  `param:"no_rekening" validate:"required,norek"`
+	Nominal    float64 `json:"nominal" validate:"omitempty,amount"`
+	// BillNumber identifies a dynamic code, one is generated when empty.
+	BillNumber string `json:"bill_number" validate:"omitempty,max=25,alphanum,excluded_without=Nominal"`
+}
+
+func (r *QRISRequest) Normalize() {
+	r.NoRekening = strings.TrimSpace(r.NoRekening)
+	r.BillNumber = strings.TrimSpace(r.BillNumber)
+}
+
+// QRISCode is a QRIS payload and what it holds.
+type QRISCode struct {
+	Payload          string  `json:"payload"`
+	Type             string  `json:"type"`
+	GlobalID         string  `json:"global_id"`
+	MerchantPAN      string  `json:"merchant_pan"`
+	MerchantID       string  `json:"merchant_id"`
+	MerchantName     string  `json:"merchant_name"`
+	MerchantCity     string  `json:"merchant_city"`
+	MerchantCategory string  `json:"merchant_category"`
+	Nominal          float64 `json:"nominal,omitempty"`
+	BillNumber       string  `json:"bill_number,omitempty"`
+	// NoRekening is the account paid, only for codes of this bank.
+	NoRekening string `json:"no_rekening,omitempty"`
+}
+
+type QRISParseRequest struct {
+	Payload string `json:"payload" validate:"required,max=512"`
+}
+
+func (r *QRISParseRequest) Normalize() {
+	r.Payload = strings.TrimSpace(r.Payload)
+}
+
+// QRISPaymentRequest notifies a payment of a QRIS code of this bank. The
+// nominal and reference of the acquirer are required for static codes, a
+// dynamic code is paid its own amount.
+type QRISPaymentRequest struct {
+	Payload   string  `json:"payload" validate:"required,max=512"`
+	Nominal   float64 `json:"nominal" validate:"omitempty,amount"`
+	Reference string  `json:"reference" validate:"max=64"`
+}
+
+func (r *QRISPaymentRequest) Normalize() {
+	r.Payload = strings.TrimSpace(r.Payload)
+	r.Reference = strings.TrimSpace(r.Reference)
+}
+
+// QRISPayment is a QRIS payment as credited.
+type QRISPayment struct {
+	NoRekening string  `json:"no_rekening"`
+	Nominal    float64 `json:"nominal"`
+	Reference  string  `json:"reference"`
+	Saldo      float64 `json:"saldo"`
+}
+
+// QRISBill is a dynamic QRIS code as generated. It is paid once, its own
+// amount.
+type QRISBill struct {
+	ID         uint
+	AccountID  uint
+	BillNumber string
+	Nominal    float64
+	Status     string
+	CreatedAt  time.Time
+	PaidAt     *time.Time
+}
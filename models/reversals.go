@@ -0,0 +1,19 @@
+package models
+
+import "strings"
+
+// ReversalReferencePrefix starts the reference of the mutation undoing a
+// posting, followed by the reference of that posting.
+const ReversalReferencePrefix = "REVERSAL "
+
+// ReversalRequest undoes the newest posting of an account with Reference,
+// e.g. a withdrawal the ATM could not dispense.
+type ReversalRequest struct {
+	NoRekening string `json:"no_rekening" validate:"required,norek"`
+	Reference  string `json:"reference" validate:"required,max=246"`
+}
+
+func (r *ReversalRequest) Normalize() {
+	r.NoRekening = strings.TrimSpace(r.NoRekening)
+	r.Reference = strings.TrimSpace(r.Reference)
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Statement formats.
+const (
+	StatementFormatCSV = "csv"
+	StatementFormatPDF = "pdf"
+)
+
+// StatementDateLayout is the layout of the from and to dates of a statement.
+const StatementDateLayout = "2006-01-02"
+
+// StatementZone is WIB, the zone statement periods and times are read in.
+var StatementZone = time.FixedZone("WIB", 7*60*60)
+
+type StatementRequest struct {
+	NoRekening string `param:"no_rekening" validate:"required,norek"`
+	// From and To are the first and last day of the period, inclusive.
+	From   string `query:"from" validate:"required,datetime=2006-01-02"`
+	To     string `query:"to" validate:"required,datetime=2006-01-02"`
+	Format string `query:"format" validate:"omitempty,oneof=csv pdf"`
+}
+
+func (r *StatementRequest) Normalize() {
+	r.NoRekening = strings.TrimSpace(r.NoRekening)
+	r.Format = strings.ToLower(strings.TrimSpace(r.Format))
+	if r.Format == "" {
+		r.Format = StatementFormatCSV
+	}
+}
+
+// Statement is a rekening koran: the mutations of an account over a period
+// between its opening and closing balance. The totals are complete once every
+// line has been written.
+type Statement struct {
+	Account *Account
+	// From and To are the first and last day of the period, at midnight in
+	// StatementZone.
+	From time.Time
+	To   time.Time
+
+	OpeningBalance float64
+	ClosingBalance float64
+	TotalCredit    float64
+	TotalDebit     float64
+	CreditCount    int
+	DebitCount     int
+
+	GeneratedAt time.Time
+}
+
+// StatementLine is one mutation with the balance after it.
+type StatementLine struct {
+	Mutation Mutation
+	Balance  float64
+}
@@ -0,0 +1,18 @@
+package models
+
+// TransferRequest moves Nominal from one account to another. Reference is
+// recorded on both mutations and is used once per source account.
+type TransferRequest struct {
+	SourceNoRekening      string
+	BeneficiaryNoRekening string
+	Nominal               float64
+	Reference             string
+}
+
+// Transfer is a transfer as posted, with the mutations of both accounts.
+type Transfer struct {
+	Source      *Account
+	Beneficiary *Account
+	Debit       *Mutation
+	Credit      *Mutation
+}
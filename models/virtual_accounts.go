@@ -0,0 +1,112 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Virtual account states. A single use virtual account is closed by its
+// payment. Expired is only reported, an open virtual account past its
+// expiry is stored as open.
+const (
+	VirtualAccountStatusOpen    = "open"
+	VirtualAccountStatusClosed  = "closed"
+	VirtualAccountStatusExpired = "expired"
+)
+
+// VirtualAccountNumberLength is the most digits of a VA number, the company
+// prefix included.
+const VirtualAccountNumberLength = 16
+
+// VirtualAccountReferencePrefix starts the reference of the credit of a
+// virtual account payment, followed by the VA number and the payment
+// reference of the channel.
+const VirtualAccountReferencePrefix = "VA "
+
+// VirtualAccount is a number payers transfer to, credited to the account it
+// is mapped to. A virtual account with a nominal only takes payments of
+// that amount.
+type VirtualAccount struct {
+	ID         uint   `json:"-"`
+	Number     string `json:"va_number"`
+	AccountID  uint   `json:"-"`
+	NoRekening string `json:"no_rekening"`
+	// Name is shown to payers on inquiry.
+	Name      string     `json:"name"`
+	Nominal   float64    `json:"nominal,omitempty"`
+	SingleUse bool       `json:"single_use"`
+	Status    string     `json:"status"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	ClosedAt  *time.Time `json:"closed_at"`
+}
+
+// Expired reports whether an open virtual account is past its expiry at now.
+func (v *VirtualAccount) Expired(now time.Time) bool {
+	return v.Status == VirtualAccountStatusOpen && v.ExpiresAt != nil && !now.Before(*v.ExpiresAt)
+}
+
+// VirtualAccountRequest issues a virtual account mapped to the account of
+// the path.
+type VirtualAccountRequest struct {
+	NoRekening string `param:"no_rekening" validate:"required,norek"`
+	// CustomerNumber follows the company prefix in the VA number, random
+	// digits are used when empty.
+	CustomerNumber string `json:"customer_number" validate:"omitempty,number,max=15"`
+	// Name defaults to the name of the account.
+	Name      string     `json:"name" validate:"max=64"`
+	Nominal   float64    `json:"nominal" validate:"omitempty,amount"`
+	SingleUse bool       `json:"single_use"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+func (r *VirtualAccountRequest) Normalize() {
+	r.NoRekening = strings.TrimSpace(r.NoRekening)
+	r.CustomerNumber = strings.TrimSpace(r.CustomerNumber)
+	r.Name = strings.TrimSpace(r.Name)
+}
+
+// VirtualAccountInquiry is a virtual account as shown to payers, without
+// the account it is mapped to.
+type VirtualAccountInquiry struct {
+	Number    string     `json:"va_number"`
+	Name      string     `json:"name"`
+	Nominal   float64    `json:"nominal,omitempty"`
+	SingleUse bool       `json:"single_use"`
+	Status    string     `json:"status"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	ClosedAt  *time.Time `json:"closed_at"`
+}
+
+type VirtualAccountInquiryRequest struct {
+	Number string `param:"va_number" validate:"required,number,max=16"`
+}
+
+func (r *VirtualAccountInquiryRequest) Normalize() {
+	r.Number = strings.TrimSpace(r.Number)
+}
+
+// VirtualAccountPaymentRequest notifies a payment to a virtual account.
+// Reference is the payment reference of the channel, used once per virtual
+// account.
+type VirtualAccountPaymentRequest struct {
+	Number    string  `json:"va_number" validate:"required,number,max=16"`
+	Nominal   float64 `json:"nominal" validate:"required,gt=0,amount"`
+	Reference string  `json:"reference" validate:"required,max=64"`
+}
+
+func (r *VirtualAccountPaymentRequest) Normalize() {
+	r.Number = strings.TrimSpace(r.Number)
+	r.Reference = strings.TrimSpace(r.Reference)
+}
+
+// VirtualAccountPayment is a virtual account payment as credited, with the
+// status of the virtual account after it.
+type VirtualAccountPayment struct {
+	Number     string  `json:"va_number"`
+	NoRekening string  `json:"no_rekening"`
+	Nominal    float64 `json:"nominal"`
+	Reference  string  `json:"reference"`
+	Saldo      float64 `json:"saldo"`
+	Status     string  `json:"status"`
+}
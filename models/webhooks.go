@@ -0,0 +1,134 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// WebhookAllEvents subscribes a webhook to every event type.
+const WebhookAllEvents = "*"
+
+// WebhookEventTypes are the event types a webhook can subscribe to.
+var WebhookEventTypes = []string{WebhookAllEvents, EventMutationCreated}
+
+// Webhook delivery states. Pending deliveries are retried until they are
+// delivered or have used up their attempts and failed.
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// Webhook is a partner endpoint receiving signed events. An empty NoRekening
+// receives the events of every account.
+type Webhook struct {
+	ID         uint      `json:"id"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	NoRekening string    `json:"no_rekening,omitempty"`
+	Secret     string    `json:"-"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Matches reports whether the webhook subscribes to an event of eventType
+// for the account noRekening.
+func (w *Webhook) Matches(eventType, noRekening string) bool {
+	if !w.Active || (w.NoRekening != "" && w.NoRekening != noRekening) {
+		return false
+	}
+	for _, t := range w.EventTypes {
+		if t == WebhookAllEvents || t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+type CreateWebhookRequest struct {
+	URL        string   `json:"url" validate:"required,http_url,max=2048"`
+	EventTypes []string `json:"event_types" validate:"required,min=1"`
+	NoRekening string   `json:"no_rekening" validate:"omitempty,norek"`
+	// Secret signs the deliveries, one is generated when empty.
+	Secret string `json:"secret" validate:"omitempty,min=16,max=255"`
+}
+
+func (r *CreateWebhookRequest) Normalize() {
+	r.URL = strings.TrimSpace(r.URL)
+	r.NoRekening = strings.TrimSpace(r.NoRekening)
+}
+
+type UpdateWebhookRequest struct {
+	ID         uint     `param:"id" validate:"required"`
+	URL        string   `json:"url" validate:"required,http_url,max=2048"`
+	EventTypes []string `json:"event_types" validate:"required,min=1"`
+	NoRekening string   `json:"no_rekening" validate:"omitempty,norek"`
+	Active     bool     `json:"active"`
+}
+
+func (r *UpdateWebhookRequest) Normalize() {
+	r.URL = strings.TrimSpace(r.URL)
+	r.NoRekening = strings.TrimSpace(r.NoRekening)
+}
+
+// CreateWebhookResponse is the only response showing the webhook secret.
+type CreateWebhookResponse struct {
+	Webhook
+	Secret string `json:"secret"`
+}
+
+// WebhookDelivery is one event sent to one webhook. Body is the exact JSON
+// posted on every attempt.
+type WebhookDelivery struct {
+	ID             uint            `json:"id"`
+	WebhookID      uint            `json:"webhook_id"`
+	EventID        uint            `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Body           json.RawMessage `json:"body"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	LastStatusCode int             `json:"last_status_code,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+
+	// Log lists the attempts, filled in when a single delivery is requested.
+	Log []WebhookAttempt `json:"log,omitempty"`
+}
+
+// WebhookAttempt is the delivery log entry of one POST to a webhook.
+type WebhookAttempt struct {
+	ID         uint      `json:"id"`
+	DeliveryID uint      `json:"delivery_id"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// WebhookEvent is the body posted to webhooks.
+type WebhookEvent struct {
+	EventID    uint            `json:"event_id"`
+	EventType  string          `json:"event_type"`
+	NoRekening string          `json:"no_rekening"`
+	CreatedAt  time.Time       `json:"created_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+type WebhookRequest struct {
+	ID uint `param:"id" validate:"required"`
+}
+
+type WebhookDeliveriesRequest struct {
+	ID     uint   `param:"id" validate:"required"`
+	Status string `query:"status" validate:"omitempty,oneof=pending delivered failed"`
+	Limit  int    `query:"limit" validate:"omitempty,min=1,max=500"`
+}
+
+type WebhookDeliveryRequest struct {
+	ID         uint `param:"id" validate:"required"`
+	DeliveryID uint `param:"delivery_id" validate:"required"`
+}
@@ -0,0 +1,157 @@
+package notifications
+
+import (
+	"accounts-service/models"
+	"accounts-service/repositories"
+	"accounts-service/utils"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// MutationNotifierOptions tune which mutations are notified.
+type MutationNotifierOptions struct {
+	// LargeCredit is the smallest tabung the customer is told about. Every
+	// tarik is notified.
+	LargeCredit float64
+}
+
+// MutationNotifier is the outbox Publisher telling customers about their
+// mutations on the channels they chose. It runs in the relay, so messages
+// are only sent for committed postings and never delay them.
+//
+// Sending is best effort: a failed message is logged and not retried, since
+// failing the event would send the messages of the other channels again.
+type MutationNotifier struct {
+	accountRepo    repositories.AccountRepository
+	preferenceRepo repositories.NotificationPreferenceRepository
+	notifier       Notifier
+	options        MutationNotifierOptions
+	logger         utils.Logger
+}
+
+func NewMutationNotifier(accountRepo repositories.AccountRepository, preferenceRepo repositories.NotificationPreferenceRepository, notifier Notifier, options MutationNotifierOptions, logger utils.Logger) *MutationNotifier {
+	return &MutationNotifier{
+		accountRepo:    accountRepo,
+		preferenceRepo: preferenceRepo,
+		notifier:       notifier,
+		options:        options,
+		logger:         logger,
+	}
+}
+
+func (n *MutationNotifier) Publish(ctx context.Context, event *models.OutboxEvent) error {
+	if event.EventType != models.EventMutationCreated {
+		return nil
+	}
+
+	var mutation models.MutationEvent
+	if err := json.Unmarshal(event.Payload, &mutation); err != nil {
+		return fmt.Errorf("error decoding event %d: %w", event.ID, err)
+	}
+
+	// Small tabung need no message, skip the lookups
+	if mutation.Type == models.MutationTypeCredit && mutation.Nominal < n.options.LargeCredit {
+		return nil
+	}
+
+	account, err := n.accountRepo.GetAccountByNoRekening(ctx, mutation.NoRekening)
+	if err != nil {
+		return err
+	}
+	if account == nil {
+		n.logger.Warning("Not notifying event %d, account %s not found", event.ID, mutation.NoRekening)
+		return nil
+	}
+
+	preference, err := n.preferenceRepo.GetPreference(ctx, account.ID)
+	if err != nil {
+		return err
+	}
+	if preference == nil {
+		preference = models.DefaultNotificationPreference(account)
+	}
+
+	createdAt := mutation.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = event.CreatedAt
+	}
+
+	data := MessageData{
+		Name:       account.Name,
+		NoRekening: maskNoRekening(account.NoRekening),
+		Nominal:    utils.FormatAmount(mutation.Nominal, utils.LangID),
+		Saldo:      utils.FormatAmount(mutation.Saldo, utils.LangID),
+		Threshold:  utils.FormatAmount(preference.LowBalanceThreshold, utils.LangID),
+		Reference:  mutation.Reference,
+		Time:       formatTime(createdAt),
+	}
+
+	for _, kind := range n.kinds(&mutation, preference) {
+		subject, body, err := Render(kind, data)
+		if err != nil {
+			return err
+		}
+
+		for _, channel := range preference.Channels {
+			notification := &models.Notification{
+				Kind:       kind,
+				Channel:    channel,
+				Recipient:  recipient(channel, account, preference),
+				NoRekening: account.NoRekening,
+				Subject:    subject,
+				Body:       body,
+				CreatedAt:  time.Now(),
+			}
+			if notification.Recipient == "" {
+				n.logger.Warning("Not sending %s %s for event %d, account %s has no recipient", kind, channel, event.ID, account.NoRekening)
+				continue
+			}
+
+			if err := n.notifier.Notify(ctx, notification); err != nil {
+				n.logger.Error("Error sending %s %s for event %d to account %s: %v", kind, channel, event.ID, account.NoRekening, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// kinds returns the notifications a mutation calls for. The low balance
+// alert is only sent by the tarik crossing the threshold, not by every tarik
+// below it.
+func (n *MutationNotifier) kinds(mutation *models.MutationEvent, preference *models.NotificationPreference) []string {
+	var kinds []string
+	switch mutation.Type {
+	case models.MutationTypeDebit:
+		kinds = append(kinds, models.NotificationDebit)
+
+		threshold := preference.LowBalanceThreshold
+		if threshold > 0 && mutation.Saldo < threshold && mutation.Saldo+mutation.Nominal >= threshold {
+			kinds = append(kinds, models.NotificationLowBalance)
+		}
+	case models.MutationTypeCredit:
+		if mutation.Nominal >= n.options.LargeCredit {
+			kinds = append(kinds, models.NotificationLargeCredit)
+		}
+	}
+	return kinds
+}
+
+// recipient returns the address of the account on channel.
+func recipient(channel string, account *models.Account, preference *models.NotificationPreference) string {
+	if channel == models.NotificationChannelEmail {
+		return preference.Email
+	}
+	return account.NoHP
+}
+
+// Close closes the notifier when it needs closing.
+func (n *MutationNotifier) Close() error {
+	if closer, ok := n.notifier.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
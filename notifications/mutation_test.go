@@ -0,0 +1,224 @@
+package notifications_test
+
+import (
+	"accounts-service/events"
+	"accounts-service/models"
+	"accounts-service/notifications"
+	"accounts-service/repositories"
+	"accounts-service/usecases"
+	"accounts-service/utils"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder keeps the notifications it is asked to send, failing the
+// channels in fail.
+type recorder struct {
+	mu   sync.Mutex
+	sent []models.Notification
+	fail map[string]bool
+}
+
+func (r *recorder) Notify(ctx context.Context, notification *models.Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.fail[notification.Channel] {
+		return errors.New("provider unavailable")
+	}
+	r.sent = append(r.sent, *notification)
+	return nil
+}
+
+func (r *recorder) take() []models.Notification {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sent := r.sent
+	r.sent = nil
+	return sent
+}
+
+func TestMutationNotifier(t *testing.T) {
+	logger := utils.NewLogger("critical")
+	ctx := context.Background()
+
+	type env struct {
+		accounts      usecases.AccountUsecase
+		notifications usecases.NotificationUsecase
+		relay         *events.Relay
+		recorder      *recorder
+		account       *models.Account
+	}
+
+	setup := func(t *testing.T) env {
+		store := repositories.NewMemoryStore()
+		accountRepo := repositories.NewMemoryAccountRepository(store, logger)
+		preferenceRepo := repositories.NewMemoryNotificationPreferenceRepository(store, logger)
+		outboxRepo := repositories.NewMemoryOutboxRepository(store, logger)
+
+		accounts := usecases.NewAccountUsecase(
+			repositories.NewMemoryTxManager(store, logger),
+			accountRepo,
+			repositories.NewMemoryMutationRepository(store, logger),
+			outboxRepo,
+			repositories.NewNoopBalanceCache(),
+			logger,
+		)
+
+		rec := &recorder{fail: map[string]bool{}}
+		notifier := notifications.NewMutationNotifier(accountRepo, preferenceRepo, rec, notifications.MutationNotifierOptions{LargeCredit: 1000000}, logger)
+		relay := events.NewRelay(outboxRepo, notifier, events.RelayOptions{
+			PollInterval: time.Millisecond,
+			BatchSize:    10,
+			MaxAttempts:  3,
+			Backoff:      time.Millisecond,
+			MaxBackoff:   time.Millisecond,
+		}, logger)
+
+		account, err := accounts.CreateAccount(ctx, &models.CreateAccountRequest{Name: "Siti Aminah", NIK: "3201014508950001", NoHP: "+6281234567890"})
+		require.NoError(t, err)
+
+		return env{
+			accounts:      accounts,
+			notifications: usecases.NewNotificationUsecase(accountRepo, preferenceRepo, logger),
+			relay:         relay,
+			recorder:      rec,
+			account:       account,
+		}
+	}
+
+	drain := func(t *testing.T, e env) []models.Notification {
+		_, err := e.relay.Drain(ctx)
+		require.NoError(t, err)
+		return e.recorder.take()
+	}
+
+	masked := func(noRekening string) string {
+		return "******" + noRekening[len(noRekening)-4:]
+	}
+
+	t.Run("tarik is sent by sms by default after commit", func(t *testing.T) {
+		e := setup(t)
+
+		require.NoError(t, e.accounts.Credit(ctx, &models.TransactionRequest{NoRekening: e.account.NoRekening, Nominal: 500000}))
+		require.NoError(t, e.accounts.Debit(ctx, &models.TransactionRequest{NoRekening: e.account.NoRekening, Nominal: 150000, Reference: "ATM-001"}))
+		assert.Empty(t, e.recorder.take(), "nothing is sent before the relay runs")
+
+		sent := drain(t, e)
+		require.Len(t, sent, 1, "small tabung is not notified")
+		assert.Equal(t, models.NotificationDebit, sent[0].Kind)
+		assert.Equal(t, models.NotificationChannelSMS, sent[0].Channel)
+		assert.Equal(t, "+6281234567890", sent[0].Recipient)
+		assert.Contains(t, sent[0].Body, "Yth. Siti Aminah, penarikan Rp150.000,00 dari rekening "+masked(e.account.NoRekening))
+		assert.Contains(t, sent[0].Body, "Ref: ATM-001.")
+		assert.Contains(t, sent[0].Body, "Saldo Anda Rp350.000,00.")
+		assert.Contains(t, sent[0].Body, "WIB")
+		assert.NotContains(t, sent[0].Body, e.account.NoRekening)
+	})
+
+	t.Run("failed debit sends nothing", func(t *testing.T) {
+		e := setup(t)
+
+		err := e.accounts.Debit(ctx, &models.TransactionRequest{NoRekening: e.account.NoRekening, Nominal: 150000})
+		require.Error(t, err)
+		assert.Empty(t, drain(t, e))
+	})
+
+	t.Run("large tabung is sent on the chosen channels", func(t *testing.T) {
+		e := setup(t)
+		_, err := e.notifications.UpdatePreference(ctx, &models.UpdateNotificationPreferenceRequest{
+			NoRekening: e.account.NoRekening,
+			Channels:   []string{models.NotificationChannelWhatsApp, models.NotificationChannelEmail},
+			Email:      "siti@example.com",
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, e.accounts.Credit(ctx, &models.TransactionRequest{NoRekening: e.account.NoRekening, Nominal: 2500000}))
+
+		sent := drain(t, e)
+		require.Len(t, sent, 2)
+		assert.Equal(t, models.NotificationChannelWhatsApp, sent[0].Channel)
+		assert.Equal(t, "+6281234567890", sent[0].Recipient)
+		assert.Equal(t, models.NotificationChannelEmail, sent[1].Channel)
+		assert.Equal(t, "siti@example.com", sent[1].Recipient)
+		assert.Equal(t, "Dana masuk Rp2.500.000,00 ke rekening "+masked(e.account.NoRekening), sent[1].Subject)
+		assert.Contains(t, sent[1].Body, "menerima dana Rp2.500.000,00")
+	})
+
+	t.Run("low balance alert once when crossing the threshold", func(t *testing.T) {
+		e := setup(t)
+		_, err := e.notifications.UpdatePreference(ctx, &models.UpdateNotificationPreferenceRequest{
+			NoRekening:          e.account.NoRekening,
+			Channels:            []string{models.NotificationChannelSMS},
+			LowBalanceThreshold: 100000,
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, e.accounts.Credit(ctx, &models.TransactionRequest{NoRekening: e.account.NoRekening, Nominal: 300000}))
+		require.NoError(t, e.accounts.Debit(ctx, &models.TransactionRequest{NoRekening: e.account.NoRekening, Nominal: 150000}))
+		sent := drain(t, e)
+		require.Len(t, sent, 1, "saldo 150.000 is still above the threshold")
+
+		require.NoError(t, e.accounts.Debit(ctx, &models.TransactionRequest{NoRekening: e.account.NoRekening, Nominal: 60000}))
+		require.NoError(t, e.accounts.Debit(ctx, &models.TransactionRequest{NoRekening: e.account.NoRekening, Nominal: 10000}))
+		sent = drain(t, e)
+		require.Len(t, sent, 3)
+		assert.Equal(t, models.NotificationDebit, sent[0].Kind)
+		assert.Equal(t, models.NotificationLowBalance, sent[1].Kind)
+		assert.Contains(t, sent[1].Body, "kini Rp90.000,00, di bawah batas Rp100.000,00")
+		assert.Equal(t, models.NotificationDebit, sent[2].Kind)
+	})
+
+	t.Run("failing channel does not hold back the others", func(t *testing.T) {
+		e := setup(t)
+		_, err := e.notifications.UpdatePreference(ctx, &models.UpdateNotificationPreferenceRequest{
+			NoRekening: e.account.NoRekening,
+			Channels:   []string{models.NotificationChannelEmail, models.NotificationChannelSMS},
+			Email:      "siti@example.com",
+		})
+		require.NoError(t, err)
+		e.recorder.fail[models.NotificationChannelEmail] = true
+
+		require.NoError(t, e.accounts.Credit(ctx, &models.TransactionRequest{NoRekening: e.account.NoRekening, Nominal: 100000}))
+		require.NoError(t, e.accounts.Debit(ctx, &models.TransactionRequest{NoRekening: e.account.NoRekening, Nominal: 10000}))
+
+		published, err := e.relay.Drain(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, published, "events are published despite the failed email")
+
+		sent := e.recorder.take()
+		require.Len(t, sent, 1)
+		assert.Equal(t, models.NotificationChannelSMS, sent[0].Channel)
+	})
+
+	t.Run("preferences", func(t *testing.T) {
+		e := setup(t)
+
+		preference, err := e.notifications.GetPreference(ctx, e.account.NoRekening)
+		require.NoError(t, err)
+		assert.Equal(t, []string{models.NotificationChannelSMS}, preference.Channels)
+		assert.Zero(t, preference.LowBalanceThreshold)
+
+		_, err = e.notifications.UpdatePreference(ctx, &models.UpdateNotificationPreferenceRequest{NoRekening: e.account.NoRekening, Channels: []string{"pager"}})
+		assert.ErrorIs(t, err, models.NotificationChannelsInvalidErr)
+
+		_, err = e.notifications.UpdatePreference(ctx, &models.UpdateNotificationPreferenceRequest{NoRekening: e.account.NoRekening, Channels: []string{models.NotificationChannelEmail}})
+		assert.ErrorIs(t, err, models.NotificationEmailInvalidErr)
+
+		_, err = e.notifications.GetPreference(ctx, "1000000001")
+		assert.ErrorIs(t, err, models.AccountWithNoRekeningNotFoundErr)
+
+		_, err = e.notifications.UpdatePreference(ctx, &models.UpdateNotificationPreferenceRequest{NoRekening: e.account.NoRekening, Channels: []string{}})
+		require.NoError(t, err)
+		require.NoError(t, e.accounts.Credit(ctx, &models.TransactionRequest{NoRekening: e.account.NoRekening, Nominal: 100000}))
+		require.NoError(t, e.accounts.Debit(ctx, &models.TransactionRequest{NoRekening: e.account.NoRekening, Nominal: 10000}))
+		assert.Empty(t, drain(t, e), "no channel turns notifications off")
+	})
+}
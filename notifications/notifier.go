@@ -0,0 +1,87 @@
+package notifications
+
+import (
+	"accounts-service/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Notifier sends a rendered notification to its recipient.
+type Notifier interface {
+	Notify(ctx context.Context, notification *models.Notification) error
+}
+
+// Router sends every notification through the notifier of its channel.
+type Router map[string]Notifier
+
+func (r Router) Notify(ctx context.Context, notification *models.Notification) error {
+	notifier, ok := r[notification.Channel]
+	if !ok {
+		return fmt.Errorf("no notifier for channel %q", notification.Channel)
+	}
+	return notifier.Notify(ctx, notification)
+}
+
+// Close closes the notifiers that need closing, once each even when they
+// serve several channels.
+func (r Router) Close() error {
+	var errs []error
+	closed := make(map[io.Closer]bool)
+	for _, notifier := range r {
+		if closer, ok := notifier.(io.Closer); ok && !closed[closer] {
+			closed[closer] = true
+			errs = append(errs, closer.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WriterNotifier writes every notification as one JSON line instead of
+// sending it, for development.
+type WriterNotifier struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewWriterNotifier(w io.Writer) *WriterNotifier {
+	return &WriterNotifier{w: w}
+}
+
+// NewFileNotifier appends notifications to the file at path, creating it
+// when needed. The file is closed with Close.
+func NewFileNotifier(path string) (*WriterNotifier, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("error opening notification file: %w", err)
+	}
+
+	return NewWriterNotifier(file), nil
+}
+
+func (n *WriterNotifier) Notify(ctx context.Context, notification *models.Notification) error {
+	line, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("error encoding notification: %w", err)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if _, err := n.w.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("error writing notification: %w", err)
+	}
+	return nil
+}
+
+// Close closes the underlying writer when it is a file.
+func (n *WriterNotifier) Close() error {
+	if file, ok := n.w.(*os.File); ok && file != os.Stdout && file != os.Stderr {
+		return file.Close()
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package notifications
+
+import (
+	"accounts-service/models"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"time"
+)
+
+// SMTPOptions configure the SMTP notifier. Username and Password are only
+// used when set, and STARTTLS is used whenever the server offers it.
+type SMTPOptions struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	Timeout  time.Duration
+}
+
+// SMTPNotifier sends email notifications through an SMTP server, one
+// connection per message.
+type SMTPNotifier struct {
+	options SMTPOptions
+}
+
+func NewSMTPNotifier(options SMTPOptions) *SMTPNotifier {
+	return &SMTPNotifier{options: options}
+}
+
+func (n *SMTPNotifier) Notify(ctx context.Context, notification *models.Notification) error {
+	if n.options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, n.options.Timeout)
+		defer cancel()
+	}
+
+	addr := net.JoinHostPort(n.options.Host, strconv.Itoa(n.options.Port))
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("error connecting to smtp server %s: %w", addr, err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, n.options.Host)
+	if err != nil {
+		return fmt.Errorf("error greeting smtp server %s: %w", addr, err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: n.options.Host}); err != nil {
+			return fmt.Errorf("error starting tls with %s: %w", addr, err)
+		}
+	}
+
+	if n.options.Username != "" {
+		auth := smtp.PlainAuth("", n.options.Username, n.options.Password, n.options.Host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("error authenticating with %s: %w", addr, err)
+		}
+	}
+
+	if err := client.Mail(n.options.From); err != nil {
+		return fmt.Errorf("error sending MAIL FROM: %w", err)
+	}
+	if err := client.Rcpt(notification.Recipient); err != nil {
+		return fmt.Errorf("error sending RCPT TO %s: %w", notification.Recipient, err)
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("error sending DATA: %w", err)
+	}
+	if _, err := w.Write(n.message(notification)); err != nil {
+		return fmt.Errorf("error writing message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("error sending message: %w", err)
+	}
+
+	return client.Quit()
+}
+
+// message formats the notification as a plain text UTF-8 email, with its
+// attachments as a multipart/mixed email.
+func (n *SMTPNotifier) message(notification *models.Notification) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", n.options.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", notification.Recipient)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", notification.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+
+	if len(notification.Attachments) == 0 {
+		buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+		buf.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+		buf.WriteString("\r\n")
+		buf.WriteString(notification.Body)
+		buf.WriteString("\r\n")
+		return buf.Bytes()
+	}
+
+	parts := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", parts.Boundary())
+
+	body, _ := parts.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"8bit"},
+	})
+	io.WriteString(body, notification.Body+"\r\n")
+
+	for _, attachment := range notification.Attachments {
+		part, _ := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {attachment.ContentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename})},
+		})
+		writeBase64Lines(part, attachment.Content)
+	}
+	parts.Close()
+
+	return buf.Bytes()
+}
+
+// writeBase64Lines writes content in base64 with lines of 76 characters, the
+// most email allows.
+func writeBase64Lines(w io.Writer, content []byte) {
+	encoded := base64.StdEncoding.EncodeToString(content)
+	for len(encoded) > 76 {
+		io.WriteString(w, encoded[:76]+"\r\n")
+		encoded = encoded[76:]
+	}
+	io.WriteString(w, encoded+"\r\n")
+}
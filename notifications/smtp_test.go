@@ -0,0 +1,209 @@
+package notifications_test
+
+import (
+	"accounts-service/models"
+	"accounts-service/notifications"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// smtpServer is a local SMTP stand-in accepting every message.
+type smtpServer struct {
+	listener net.Listener
+
+	mu       sync.Mutex
+	auth     string
+	from     string
+	rcpt     []string
+	messages []string
+}
+
+func newSMTPServer(t *testing.T) *smtpServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	server := &smtpServer{listener: listener}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	return server
+}
+
+func (s *smtpServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *smtpServer) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 localhost ESMTP stand-in")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		s.mu.Lock()
+		switch command {
+		case "EHLO", "HELO":
+			reply("250-localhost")
+			reply("250-8BITMIME")
+			reply("250 AUTH PLAIN")
+		case "AUTH":
+			credentials, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(line, "AUTH PLAIN "))
+			s.auth = string(credentials)
+			reply("235 2.7.0 Authentication successful")
+		case "MAIL":
+			s.from = line
+			reply("250 OK")
+		case "RCPT":
+			s.rcpt = append(s.rcpt, line)
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				dataLine, err := r.ReadString('\n')
+				if err != nil {
+					s.mu.Unlock()
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(dataLine)
+			}
+			s.messages = append(s.messages, data.String())
+			reply("250 OK queued")
+		case "QUIT":
+			reply("221 Bye")
+			s.mu.Unlock()
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+		s.mu.Unlock()
+	}
+}
+
+func TestSMTPNotifier(t *testing.T) {
+	ctx := context.Background()
+
+	notification := &models.Notification{
+		Kind:       models.NotificationLargeCredit,
+		Channel:    models.NotificationChannelEmail,
+		Recipient:  "siti@example.com",
+		NoRekening: "1744847261",
+		Subject:    "Dana masuk Rp2.500.000,00 ke rekening ******7261",
+		Body:       "Yth. Siti Aminah, rekening ******7261 menerima dana Rp2.500.000,00.",
+	}
+
+	t.Run("sends through the local server", func(t *testing.T) {
+		server := newSMTPServer(t)
+		notifier := notifications.NewSMTPNotifier(notifications.SMTPOptions{
+			Host:     "127.0.0.1",
+			Port:     server.port(),
+			Username: "accounts",
+			Password: "rahasia",
+			From:     "notifikasi@bank.example",
+			Timeout:  time.Second,
+		})
+
+		require.NoError(t, notifier.Notify(ctx, notification))
+
+		server.mu.Lock()
+		defer server.mu.Unlock()
+		assert.Equal(t, "\x00accounts\x00rahasia", server.auth)
+		assert.Equal(t, "MAIL FROM:<notifikasi@bank.example> BODY=8BITMIME", server.from)
+		assert.Equal(t, []string{"RCPT TO:<siti@example.com>"}, server.rcpt)
+		require.Len(t, server.messages, 1)
+
+		message := server.messages[0]
+		assert.Contains(t, message, "From: notifikasi@bank.example\r\n")
+		assert.Contains(t, message, "To: siti@example.com\r\n")
+		assert.Contains(t, message, "Subject: Dana masuk Rp2.500.000,00 ke rekening ******7261\r\n")
+		assert.Contains(t, message, "Content-Type: text/plain; charset=utf-8\r\n")
+		assert.Contains(t, message, "\r\n\r\nYth. Siti Aminah, rekening ******7261 menerima dana Rp2.500.000,00.\r\n")
+	})
+
+	t.Run("attachments are sent as multipart parts", func(t *testing.T) {
+		server := newSMTPServer(t)
+		notifier := notifications.NewSMTPNotifier(notifications.SMTPOptions{Host: "127.0.0.1", Port: server.port(), From: "notifikasi@bank.example", Timeout: time.Second})
+
+		content := bytes.Repeat([]byte("%PDF-1.4 rekening koran\n"), 20)
+		withFile := *notification
+		withFile.Attachments = []models.NotificationAttachment{{Filename: "rekening-koran-1744847261-2025-04-01-2025-04-30.pdf", ContentType: "application/pdf", Content: content}}
+		require.NoError(t, notifier.Notify(ctx, &withFile))
+
+		server.mu.Lock()
+		defer server.mu.Unlock()
+		require.Len(t, server.messages, 1)
+
+		message, err := mail.ReadMessage(strings.NewReader(server.messages[0]))
+		require.NoError(t, err)
+		mediaType, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+		require.NoError(t, err)
+		assert.Equal(t, "multipart/mixed", mediaType)
+
+		parts := multipart.NewReader(message.Body, params["boundary"])
+		text, err := parts.NextPart()
+		require.NoError(t, err)
+		body, err := io.ReadAll(text)
+		require.NoError(t, err)
+		assert.Equal(t, notification.Body+"\r\n", string(body))
+
+		file, err := parts.NextPart()
+		require.NoError(t, err)
+		assert.Equal(t, "application/pdf", file.Header.Get("Content-Type"))
+		assert.Equal(t, "rekening-koran-1744847261-2025-04-01-2025-04-30.pdf", file.FileName())
+		encoded, err := io.ReadAll(file)
+		require.NoError(t, err)
+		for _, line := range strings.Split(strings.TrimSpace(string(encoded)), "\r\n") {
+			assert.LessOrEqual(t, len(line), 76)
+		}
+		decoded, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, bytes.NewReader(encoded)))
+		require.NoError(t, err)
+		assert.Equal(t, content, decoded)
+
+		_, err = parts.NextPart()
+		assert.Equal(t, io.EOF, err)
+	})
+
+	t.Run("unreachable server", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		port := listener.Addr().(*net.TCPAddr).Port
+		listener.Close()
+
+		notifier := notifications.NewSMTPNotifier(notifications.SMTPOptions{Host: "127.0.0.1", Port: port, From: "notifikasi@bank.example", Timeout: time.Second})
+		err = notifier.Notify(ctx, notification)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "127.0.0.1:"+strconv.Itoa(port))
+	})
+}
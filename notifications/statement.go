@@ -0,0 +1,80 @@
+package notifications
+
+import (
+	"accounts-service/models"
+	"accounts-service/repositories"
+	"accounts-service/utils"
+	"context"
+	"time"
+)
+
+// StatementNotifier tells customers their monthly statement is archived, on
+// the channels they chose. The email carries the statement file.
+//
+// Sending is best effort like for mutations: a failed message is logged and
+// not retried.
+type StatementNotifier struct {
+	preferenceRepo repositories.NotificationPreferenceRepository
+	notifier       Notifier
+	logger         utils.Logger
+}
+
+func NewStatementNotifier(preferenceRepo repositories.NotificationPreferenceRepository, notifier Notifier, logger utils.Logger) *StatementNotifier {
+	return &StatementNotifier{
+		preferenceRepo: preferenceRepo,
+		notifier:       notifier,
+		logger:         logger,
+	}
+}
+
+// NotifyStatement sends the notifications of the statement of a month. It
+// only fails when the preference of the account cannot be read.
+func (n *StatementNotifier) NotifyStatement(ctx context.Context, statement *models.Statement, file models.NotificationAttachment) error {
+	account := statement.Account
+
+	preference, err := n.preferenceRepo.GetPreference(ctx, account.ID)
+	if err != nil {
+		return err
+	}
+	if preference == nil {
+		preference = models.DefaultNotificationPreference(account)
+	}
+
+	data := MessageData{
+		Name:       account.Name,
+		NoRekening: maskNoRekening(account.NoRekening),
+		Saldo:      utils.FormatAmount(statement.ClosingBalance, utils.LangID),
+		Period:     formatPeriod(statement.From),
+	}
+
+	for _, channel := range preference.Channels {
+		data.Attached = channel == models.NotificationChannelEmail
+		subject, body, err := Render(models.NotificationMonthlyStatement, data)
+		if err != nil {
+			return err
+		}
+
+		notification := &models.Notification{
+			Kind:       models.NotificationMonthlyStatement,
+			Channel:    channel,
+			Recipient:  recipient(channel, account, preference),
+			NoRekening: account.NoRekening,
+			Subject:    subject,
+			Body:       body,
+			CreatedAt:  time.Now(),
+		}
+		if data.Attached {
+			notification.Attachments = []models.NotificationAttachment{file}
+		}
+		if notification.Recipient == "" {
+			n.logger.Warning("Not sending monthly statement %s, account %s has no recipient", channel, account.NoRekening)
+			continue
+		}
+
+		if err := n.notifier.Notify(ctx, notification); err != nil {
+			n.logger.Error("Error sending monthly statement %s to account %s: %v", channel, account.NoRekening, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package notifications_test
+
+import (
+	"accounts-service/models"
+	"accounts-service/notifications"
+	"accounts-service/repositories"
+	"accounts-service/usecases"
+	"accounts-service/utils"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatementNotifier(t *testing.T) {
+	logger := utils.NewLogger("critical")
+	ctx := context.Background()
+
+	store := repositories.NewMemoryStore()
+	accountRepo := repositories.NewMemoryAccountRepository(store, logger)
+	preferenceRepo := repositories.NewMemoryNotificationPreferenceRepository(store, logger)
+
+	account := &models.Account{Name: "Siti Aminah", NIK: "3201014508950001", NoHP: "+6281234567890", NoRekening: "1744847261"}
+	require.NoError(t, accountRepo.CreateAccount(ctx, account))
+
+	from := time.Date(2025, time.April, 1, 0, 0, 0, 0, models.StatementZone)
+	statement := &models.Statement{Account: account, From: from, To: from.AddDate(0, 1, -1), ClosingBalance: 350000}
+	file := models.NotificationAttachment{Filename: "rekening-koran-1744847261-2025-04-01-2025-04-30.pdf", ContentType: "application/pdf", Content: []byte("%PDF-1.4")}
+
+	rec := &recorder{fail: map[string]bool{}}
+	notifier := notifications.NewStatementNotifier(preferenceRepo, rec, logger)
+
+	t.Run("sms by default without the file", func(t *testing.T) {
+		require.NoError(t, notifier.NotifyStatement(ctx, statement, file))
+
+		sent := rec.take()
+		require.Len(t, sent, 1)
+		assert.Equal(t, models.NotificationMonthlyStatement, sent[0].Kind)
+		assert.Equal(t, models.NotificationChannelSMS, sent[0].Channel)
+		assert.Equal(t, "+6281234567890", sent[0].Recipient)
+		assert.Equal(t, "Yth. Siti Aminah, rekening koran April 2025 untuk rekening ******7261 telah terbit dengan saldo akhir Rp350.000,00.", sent[0].Body)
+		assert.Empty(t, sent[0].Attachments)
+	})
+
+	t.Run("email carries the file", func(t *testing.T) {
+		_, err := usecases.NewNotificationUsecase(accountRepo, preferenceRepo, logger).UpdatePreference(ctx, &models.UpdateNotificationPreferenceRequest{
+			NoRekening: account.NoRekening,
+			Channels:   []string{models.NotificationChannelSMS, models.NotificationChannelEmail},
+			Email:      "siti@example.com",
+		})
+		require.NoError(t, err)
+		rec.fail[models.NotificationChannelSMS] = true
+
+		require.NoError(t, notifier.NotifyStatement(ctx, statement, file), "a failed channel is only logged")
+
+		sent := rec.take()
+		require.Len(t, sent, 1)
+		assert.Equal(t, models.NotificationChannelEmail, sent[0].Channel)
+		assert.Equal(t, "siti@example.com", sent[0].Recipient)
+		assert.Equal(t, "Rekening koran April 2025 rekening ******7261", sent[0].Subject)
+		assert.Contains(t, sent[0].Body, "Rekening koran terlampir dalam format PDF.")
+		assert.Equal(t, []models.NotificationAttachment{file}, sent[0].Attachments)
+	})
+}
@@ -0,0 +1,99 @@
+package notifications
+
+import (
+	"accounts-service/models"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// wib is Western Indonesia Time, in which customers read their messages.
+var wib = time.FixedZone("WIB", 7*60*60)
+
+// MessageData fills the message templates. Amounts are already formatted
+// and the account number is masked.
+type MessageData struct {
+	Name       string
+	NoRekening string
+	Nominal    string
+	Saldo      string
+	Threshold  string
+	Reference  string
+	Time       string
+	Period     string
+	// Attached is set for the channels carrying the attachments.
+	Attached bool
+}
+
+type messageTemplate struct {
+	subject *template.Template
+	body    *template.Template
+}
+
+func newMessageTemplate(kind, subject, body string) *messageTemplate {
+	return &messageTemplate{
+		subject: template.Must(template.New(kind + ".subject").Parse(subject)),
+		body:    template.Must(template.New(kind + ".body").Parse(body)),
+	}
+}
+
+// templates are the Bahasa Indonesia messages of every notification kind.
+// The subject is only used by email.
+var templates = map[string]*messageTemplate{
+	models.NotificationDebit: newMessageTemplate(models.NotificationDebit,
+		`Penarikan {{.Nominal}} dari rekening {{.NoRekening}}`,
+		`Yth. {{.Name}}, penarikan {{.Nominal}} dari rekening {{.NoRekening}} pada {{.Time}} berhasil.{{if .Reference}} Ref: {{.Reference}}.{{end}} Saldo Anda {{.Saldo}}. Jika Anda tidak melakukan transaksi ini, segera hubungi kami.`,
+	),
+	models.NotificationLargeCredit: newMessageTemplate(models.NotificationLargeCredit,
+		`Dana masuk {{.Nominal}} ke rekening {{.NoRekening}}`,
+		`Yth. {{.Name}}, rekening {{.NoRekening}} menerima dana {{.Nominal}} pada {{.Time}}.{{if .Reference}} Ref: {{.Reference}}.{{end}} Saldo Anda {{.Saldo}}.`,
+	),
+	models.NotificationLowBalance: newMessageTemplate(models.NotificationLowBalance,
+		`Saldo rekening {{.NoRekening}} di bawah batas minimum`,
+		`Yth. {{.Name}}, saldo rekening {{.NoRekening}} kini {{.Saldo}}, di bawah batas {{.Threshold}} yang Anda tetapkan.`,
+	),
+	models.NotificationMonthlyStatement: newMessageTemplate(models.NotificationMonthlyStatement,
+		`Rekening koran {{.Period}} rekening {{.NoRekening}}`,
+		`Yth. {{.Name}}, rekening koran {{.Period}} untuk rekening {{.NoRekening}} telah terbit dengan saldo akhir {{.Saldo}}.{{if .Attached}} Rekening koran terlampir dalam format PDF.{{end}}`,
+	),
+}
+
+// Render returns the subject and body of a notification kind.
+func Render(kind string, data MessageData) (string, string, error) {
+	tmpl, ok := templates[kind]
+	if !ok {
+		return "", "", fmt.Errorf("no template for notification %q", kind)
+	}
+
+	var subject, body strings.Builder
+	if err := tmpl.subject.Execute(&subject, data); err != nil {
+		return "", "", fmt.Errorf("error rendering %s subject: %w", kind, err)
+	}
+	if err := tmpl.body.Execute(&body, data); err != nil {
+		return "", "", fmt.Errorf("error rendering %s body: %w", kind, err)
+	}
+
+	return subject.String(), body.String(), nil
+}
+
+// maskNoRekening hides all but the last 4 digits, e.g. "******7261".
+func maskNoRekening(noRekening string) string {
+	if len(noRekening) <= 4 {
+		return noRekening
+	}
+	return strings.Repeat("*", len(noRekening)-4) + noRekening[len(noRekening)-4:]
+}
+
+// months are the month names in Bahasa Indonesia.
+var months = [...]string{"Januari", "Februari", "Maret", "April", "Mei", "Juni", "Juli", "Agustus", "September", "Oktober", "November", "Desember"}
+
+// formatPeriod formats the month of t, e.g. "April 2025".
+func formatPeriod(t time.Time) string {
+	return fmt.Sprintf("%s %d", months[t.Month()-1], t.Year())
+}
+
+// formatTime formats t in WIB, e.g. "17/04/2025 06:47 WIB".
+func formatTime(t time.Time) string {
+	return t.In(wib).Format("02/01/2006 15:04") + " WIB"
+}
@@ -0,0 +1,4 @@
+// Package accountsv1 holds the generated gRPC API of the accounts service.
+package accountsv1
+
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=paths=source_relative --go-grpc_out=../.. --go-grpc_opt=paths=source_relative accounts/v1/accounts.proto
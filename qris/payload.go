@@ -0,0 +1,318 @@
+package qris
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Points of initiation, the value of ID 01. A static code is paid many
+// times with the amount the payer enters, a dynamic one once with its
+// amount.
+const (
+	InitiationStatic  = "11"
+	InitiationDynamic = "12"
+)
+
+// CurrencyIDR and CountryID are the only currency and country of QRIS.
+const (
+	CurrencyIDR = "360"
+	CountryID   = "ID"
+)
+
+// IDs of the root data objects.
+const (
+	idPayloadFormat    = "00"
+	idInitiation       = "01"
+	idMerchantCategory = "52"
+	idCurrency         = "53"
+	idAmount           = "54"
+	idCountry          = "58"
+	idMerchantName     = "59"
+	idMerchantCity     = "60"
+	idPostalCode       = "61"
+	idAdditionalData   = "62"
+	idCRC              = "63"
+)
+
+// Merchant account information templates take IDs 26 to 51, with these
+// sub IDs.
+const (
+	firstMerchantAccountID = 26
+	lastMerchantAccountID  = 51
+
+	idGlobalID   = "00"
+	idPAN        = "01"
+	idMerchantID = "02"
+	idCriteria   = "03"
+)
+
+// Sub IDs of the additional data template.
+const (
+	idBillNumber    = "01"
+	idTerminalLabel = "07"
+)
+
+// payloadFormat is the only payload format indicator of EMVCo MPM.
+const payloadFormat = "01"
+
+// MerchantAccount is a merchant account information template: the acquirer
+// by its reverse domain, the merchant PAN, the merchant ID at the acquirer
+// and the merchant criteria, e.g. UMI for micro businesses.
+type MerchantAccount struct {
+	GlobalID   string
+	PAN        string
+	MerchantID string
+	Criteria   string
+}
+
+// Payload is an EMVCo merchant presented QR code as QRIS uses it.
+type Payload struct {
+	Dynamic          bool
+	MerchantAccounts []MerchantAccount
+	MerchantCategory string
+	Currency         string
+	// Amount is empty on static codes, the payer enters it.
+	Amount        string
+	CountryCode   string
+	MerchantName  string
+	MerchantCity  string
+	PostalCode    string
+	BillNumber    string
+	TerminalLabel string
+}
+
+// Encode returns the payload string of p, ending with its CRC.
+func (p *Payload) Encode() (string, error) {
+	if len(p.MerchantAccounts) == 0 {
+		return "", errors.New("a merchant account is required")
+	}
+	if len(p.MerchantAccounts) > lastMerchantAccountID-firstMerchantAccountID+1 {
+		return "", fmt.Errorf("at most %d merchant accounts fit", lastMerchantAccountID-firstMerchantAccountID+1)
+	}
+
+	if p.Amount != "" {
+		if err := checkAmount(p.Amount); err != nil {
+			return "", fmt.Errorf("ID %s: %w", idAmount, err)
+		}
+	}
+
+	initiation := InitiationStatic
+	if p.Dynamic {
+		initiation = InitiationDynamic
+	}
+
+	var b strings.Builder
+	w := &writer{b: &b}
+	w.write(idPayloadFormat, payloadFormat)
+	w.write(idInitiation, initiation)
+	for i, account := range p.MerchantAccounts {
+		var template strings.Builder
+		tw := &writer{b: &template}
+		tw.write(idGlobalID, account.GlobalID)
+		tw.write(idPAN, account.PAN)
+		tw.write(idMerchantID, account.MerchantID)
+		tw.write(idCriteria, account.Criteria)
+		if tw.err != nil {
+			return "", tw.err
+		}
+		w.write(strconv.Itoa(firstMerchantAccountID+i), template.String())
+	}
+	w.write(idMerchantCategory, p.MerchantCategory)
+	w.write(idCurrency, p.Currency)
+	w.write(idAmount, p.Amount)
+	w.write(idCountry, p.CountryCode)
+	w.write(idMerchantName, p.MerchantName)
+	w.write(idMerchantCity, p.MerchantCity)
+	w.write(idPostalCode, p.PostalCode)
+
+	var additional strings.Builder
+	aw := &writer{b: &additional}
+	aw.write(idBillNumber, p.BillNumber)
+	aw.write(idTerminalLabel, p.TerminalLabel)
+	if aw.err != nil {
+		return "", aw.err
+	}
+	w.write(idAdditionalData, additional.String())
+	if w.err != nil {
+		return "", w.err
+	}
+
+	b.WriteString(idCRC + "04")
+	return b.String() + CRC16(b.String()), nil
+}
+
+// writer appends data objects, skipping empty values, until the first
+// value too long for its 2 digit length.
+type writer struct {
+	b   *strings.Builder
+	err error
+}
+
+func (w *writer) write(id, value string) {
+	if value == "" || w.err != nil {
+		return
+	}
+	if len(value) > 99 {
+		w.err = fmt.Errorf("ID %s: value is %d characters, more than 99", id, len(value))
+		return
+	}
+	fmt.Fprintf(w.b, "%s%02d%s", id, len(value), value)
+}
+
+// Parse reads a payload string, checking its CRC and the data objects
+// every QRIS code has.
+func Parse(payload string) (*Payload, error) {
+	crcAt := len(payload) - 4
+	if crcAt < 4 || payload[crcAt-4:crcAt] != idCRC+"04" {
+		return nil, errors.New("payload must end with its CRC, ID 63")
+	}
+	if want := CRC16(payload[:crcAt]); !strings.EqualFold(payload[crcAt:], want) {
+		return nil, fmt.Errorf("CRC is %s, expected %s", payload[crcAt:], want)
+	}
+
+	objects, err := decode(payload[:crcAt-4])
+	if err != nil {
+		return nil, err
+	}
+	if objects[idPayloadFormat] != payloadFormat {
+		return nil, fmt.Errorf("payload format indicator must be %s", payloadFormat)
+	}
+
+	p := &Payload{
+		MerchantCategory: objects[idMerchantCategory],
+		Currency:         objects[idCurrency],
+		Amount:           objects[idAmount],
+		CountryCode:      objects[idCountry],
+		MerchantName:     objects[idMerchantName],
+		MerchantCity:     objects[idMerchantCity],
+		PostalCode:       objects[idPostalCode],
+	}
+	switch objects[idInitiation] {
+	case "", InitiationStatic:
+	case InitiationDynamic:
+		p.Dynamic = true
+	default:
+		return nil, fmt.Errorf("point of initiation must be %s or %s", InitiationStatic, InitiationDynamic)
+	}
+
+	for id := firstMerchantAccountID; id <= lastMerchantAccountID; id++ {
+		value, ok := objects[strconv.Itoa(id)]
+		if !ok {
+			continue
+		}
+		template, err := decode(value)
+		if err != nil {
+			return nil, fmt.Errorf("ID %d: %w", id, err)
+		}
+		p.MerchantAccounts = append(p.MerchantAccounts, MerchantAccount{
+			GlobalID:   template[idGlobalID],
+			PAN:        template[idPAN],
+			MerchantID: template[idMerchantID],
+			Criteria:   template[idCriteria],
+		})
+	}
+	if value, ok := objects[idAdditionalData]; ok {
+		additional, err := decode(value)
+		if err != nil {
+			return nil, fmt.Errorf("ID %s: %w", idAdditionalData, err)
+		}
+		p.BillNumber = additional[idBillNumber]
+		p.TerminalLabel = additional[idTerminalLabel]
+	}
+
+	var missing []string
+	for id, value := range map[string]string{
+		idMerchantCategory: p.MerchantCategory,
+		idCurrency:         p.Currency,
+		idCountry:          p.CountryCode,
+		idMerchantName:     p.MerchantName,
+		idMerchantCity:     p.MerchantCity,
+	} {
+		if value == "" {
+			missing = append(missing, id)
+		}
+	}
+	if len(p.MerchantAccounts) == 0 {
+		missing = append(missing, "26-51")
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("ID %s must be set", strings.Join(missing, ", "))
+	}
+	if p.Dynamic && p.Amount == "" {
+		return nil, fmt.Errorf("dynamic codes must have an amount, ID %s", idAmount)
+	}
+	if p.Amount != "" {
+		if err := checkAmount(p.Amount); err != nil {
+			return nil, fmt.Errorf("ID %s: %w", idAmount, err)
+		}
+	}
+	return p, nil
+}
+
+// checkAmount checks that an amount is a positive number of rupiah with at
+// most two decimals, e.g. "15000" or "15000.5".
+func checkAmount(amount string) error {
+	whole, decimals, _ := strings.Cut(amount, ".")
+	if whole == "" || !isDigits(whole) || !isDigits(decimals) || len(decimals) > 2 || strings.HasSuffix(amount, ".") {
+		return fmt.Errorf("amount %q must be digits with at most 2 decimals", amount)
+	}
+	if value, _ := strconv.ParseFloat(amount, 64); value <= 0 {
+		return fmt.Errorf("amount %q must be positive", amount)
+	}
+	return nil
+}
+
+// decode splits data objects by ID. An ID may appear once.
+func decode(data string) (map[string]string, error) {
+	objects := map[string]string{}
+	for len(data) > 0 {
+		if len(data) < 4 {
+			return nil, fmt.Errorf("data object %q is cut short", data)
+		}
+		id := data[:2]
+		length, err := strconv.Atoi(data[2:4])
+		if err != nil || !isDigits(id) || !isDigits(data[2:4]) {
+			return nil, fmt.Errorf("data object %q must start with a 2 digit ID and length", data[:4])
+		}
+		if len(data) < 4+length {
+			return nil, fmt.Errorf("ID %s: value ends after %d of %d characters", id, len(data)-4, length)
+		}
+		if _, ok := objects[id]; ok {
+			return nil, fmt.Errorf("ID %s appears twice", id)
+		}
+		objects[id] = data[4 : 4+length]
+		data = data[4+length:]
+	}
+	return objects, nil
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// CRC16 returns the CRC-16/CCITT-FALSE of data as 4 uppercase hexadecimal
+// digits, the value of ID 63 computed over the payload up to and including
+// "6304".
+func CRC16(data string) string {
+	crc := uint16(0xFFFF)
+	for i := 0; i < len(data); i++ {
+		crc ^= uint16(data[i]) << 8
+		for bit := 0; bit < 8; bit++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return fmt.Sprintf("%04X", crc)
+}
@@ -0,0 +1,102 @@
+package qris_test
+
+import (
+	"accounts-service/qris"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCRC16(t *testing.T) {
+	assert.Equal(t, "29B1", qris.CRC16("123456789"))
+}
+
+func TestPayload_EncodeParse(t *testing.T) {
+	p := &qris.Payload{
+		Dynamic: true,
+		MerchantAccounts: []qris.MerchantAccount{{
+			GlobalID:   "ID.CO.ACCOUNTS.WWW",
+			PAN:        "936009991744847261",
+			MerchantID: "1744847261",
+			Criteria:   "UMI",
+		}},
+		MerchantCategory: "5499",
+		Currency:         qris.CurrencyIDR,
+		Amount:           "15000.5",
+		CountryCode:      qris.CountryID,
+		MerchantName:     "WARUNG SITI",
+		MerchantCity:     "JAKARTA",
+		PostalCode:       "10110",
+		BillNumber:       "INV001",
+	}
+
+	payload, err := p.Encode()
+	require.NoError(t, err)
+	assert.Equal(t, "000201"+"010212"+
+		"2665"+"0018ID.CO.ACCOUNTS.WWW"+"0118936009991744847261"+"02101744847261"+"0303UMI"+
+		"52045499"+"5303360"+"540715000.5"+"5802ID"+"5911WARUNG SITI"+"6007JAKARTA"+"610510110"+
+		"62100106INV001"+"6304", payload[:len(payload)-4])
+	assert.Equal(t, qris.CRC16(payload[:len(payload)-4]), payload[len(payload)-4:])
+
+	parsed, err := qris.Parse(payload)
+	require.NoError(t, err)
+	assert.Equal(t, p, parsed)
+
+	t.Run("static codes have no amount", func(t *testing.T) {
+		static := *p
+		static.Dynamic, static.Amount, static.BillNumber = false, "", ""
+		payload, err := static.Encode()
+		require.NoError(t, err)
+		assert.Contains(t, payload, "010211")
+		assert.NotContains(t, payload, "5407")
+
+		parsed, err := qris.Parse(payload)
+		require.NoError(t, err)
+		assert.Equal(t, &static, parsed)
+	})
+
+	t.Run("amounts are positive with at most 2 decimals", func(t *testing.T) {
+		for _, amount := range []string{"-500000", "0", "abc", "1.005", "1e5", "."} {
+			invalid := *p
+			invalid.Amount = amount
+			_, err := invalid.Encode()
+			assert.Error(t, err, amount)
+		}
+	})
+
+	t.Run("values are at most 99 characters", func(t *testing.T) {
+		long := *p
+		long.MerchantName = strings.Repeat("A", 100)
+		_, err := long.Encode()
+		assert.Error(t, err)
+	})
+}
+
+func TestParse_Invalid(t *testing.T) {
+	withCRC := func(s string) string { return s + qris.CRC16(s) }
+	valid := "000201010211" + "26300018ID.CO.ACCOUNTS.WWW0204X123" + "52045499530336058" + "02ID5904SITI6007JAKARTA6304"
+
+	_, err := qris.Parse(withCRC(valid))
+	require.NoError(t, err)
+
+	for name, payload := range map[string]string{
+		"no CRC":            valid[:len(valid)-4],
+		"wrong CRC":         valid + "0000",
+		"tampered":          strings.Replace(withCRC(valid), "SITI", "BUDI", 1),
+		"length too long":   withCRC("000201" + "5999SITI" + "6304"),
+		"no merchant":       withCRC("000201010211" + "52045499530336058" + "02ID5904SITI6007JAKARTA6304"),
+		"dynamic no amount": withCRC(strings.Replace(valid, "010211", "010212", 1)),
+		"format indicator":  withCRC(strings.Replace(valid, "000201", "000202", 1)),
+		"negative amount":   withCRC(strings.Replace(valid, "5303360", "5303360"+"5407-500000", 1)),
+		"zero amount":       withCRC(strings.Replace(valid, "5303360", "5303360"+"54040.00", 1)),
+		"garbage amount":    withCRC(strings.Replace(valid, "5303360", "5303360"+"5403abc", 1)),
+		"three decimals":    withCRC(strings.Replace(valid, "5303360", "5303360"+"54051.005", 1)),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := qris.Parse(payload)
+			assert.Error(t, err)
+		})
+	}
+}
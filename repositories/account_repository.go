@@ -14,36 +14,46 @@ const (
 type AccountRepository interface {
 	CreateAccount(ctx context.Context, account *models.Account) error
 	GetAccountByNoRekening(ctx context.Context, noRekening string) (*models.Account, error)
+	// GetAccountByNoRekeningForUpdate locks the account row until the
+	// ambient transaction ends.
+	GetAccountByNoRekeningForUpdate(ctx context.Context, noRekening string) (*models.Account, error)
 	GetAccountByNoHp(ctx context.Context, noHp string) (*models.Account, error)
 	GetAccountByNik(ctx context.Context, nik string) (*models.Account, error)
-	UpdateSaldo(ctx context.Context, tx *sql.Tx, accountID uint, nominal float64) error
-	BeginTx(ctx context.Context) (*sql.Tx, error)
+	UpdateSaldo(ctx context.Context, accountID uint, nominal float64) error
 }
 
 type accountRepository struct {
-	db     *sql.DB
-	logger utils.Logger
+	db      *sql.DB
+	replica *ReplicaRouter
+	dialect Dialect
+	logger  utils.Logger
 }
 
 func NewAccountRepository(db *sql.DB, logger utils.Logger) AccountRepository {
 	return &accountRepository{
-		db:     db,
-		logger: logger,
+		db:      db,
+		dialect: DialectPostgres,
+		logger:  logger,
 	}
 }
 
-func (r *accountRepository) BeginTx(ctx context.Context) (*sql.Tx, error) {
-	tx, err := r.db.BeginTx(ctx, nil)
-	if err != nil {
-		r.logger.Error("Error beginning transaction: %v", err)
-		return nil, utils.NewRemark(
-			"Error beginning transaction",
-			models.CreateTransactionDBError,
-			"",
-			err,
-		)
+// NewAccountRepositoryWithReplica returns a Postgres repository that reads
+// through replica when it is fresh enough.
+func NewAccountRepositoryWithReplica(db *sql.DB, replica *ReplicaRouter, logger utils.Logger) AccountRepository {
+	return &accountRepository{
+		db:      db,
+		replica: replica,
+		dialect: DialectPostgres,
+		logger:  logger,
+	}
+}
+
+func NewSQLiteAccountRepository(db *sql.DB, logger utils.Logger) AccountRepository {
+	return &accountRepository{
+		db:      db,
+		dialect: DialectSQLite,
+		logger:  logger,
 	}
-	return tx, nil
 }
 
 func (r *accountRepository) CreateAccount(ctx context.Context, account *models.Account) error {
@@ -52,22 +62,17 @@ func (r *accountRepository) CreateAccount(ctx context.Context, account *models.A
 		VALUES ($1, $2, $3, $4, $5)
 		RETURNING id, created_at, updated_at
 	`
-	err := r.db.QueryRowContext(ctx, queryInsert,
+	err := conn(ctx, r.db).QueryRowContext(ctx, r.dialect.rebind(queryInsert),
 		account.Name,
 		account.NIK,
 		account.NoHP,
 		account.Saldo,
 		account.NoRekening,
-	).Scan(&account.ID, &account.CreatedAt, &account.UpdatedAt)
+	).Scan(&account.ID, scanTime(&account.CreatedAt), scanTime(&account.UpdatedAt))
 
 	if err != nil {
 		r.logger.Error("Error creating account: %v", err)
-		return utils.NewRemark(
-			"Error creating account",
-			models.CreateAccountError,
-			"",
-			err,
-		)
+		return models.CreateAccountErr.Wrap(err)
 	}
 
 	return nil
@@ -81,15 +86,15 @@ func (r *accountRepository) GetAccountByNoRekening(ctx context.Context, no_reken
 	`
 
 	var account models.Account
-	err := r.db.QueryRowContext(ctx, query, no_rekening).Scan(
+	err := r.reader(ctx).QueryRowContext(ctx, r.dialect.rebind(query), no_rekening).Scan(
 		&account.ID,
 		&account.Name,
 		&account.NIK,
 		&account.NoHP,
 		&account.NoRekening,
 		&account.Saldo,
-		&account.CreatedAt,
-		&account.UpdatedAt,
+		scanTime(&account.CreatedAt),
+		scanTime(&account.UpdatedAt),
 	)
 
 	if err != nil {
@@ -97,12 +102,37 @@ func (r *accountRepository) GetAccountByNoRekening(ctx context.Context, no_reken
 			return nil, nil
 		}
 		r.logger.Error("Error getting account by no rekening: %v", err)
-		return nil, utils.NewRemark(
-			"Error getting account by no rekening",
-			models.GetAccountError,
-			"no_rekening",
-			err,
-		)
+		return nil, models.GetAccountErr.Wrap(err)
+	}
+
+	return &account, nil
+}
+
+func (r *accountRepository) GetAccountByNoRekeningForUpdate(ctx context.Context, no_rekening string) (*models.Account, error) {
+	query := `
+		SELECT id, name, nik, no_hp, no_rekening, saldo, created_at, updated_at
+		FROM accounts
+		WHERE no_rekening = $1
+		` + r.dialect.forUpdate()
+
+	var account models.Account
+	err := conn(ctx, r.db).QueryRowContext(ctx, r.dialect.rebind(query), no_rekening).Scan(
+		&account.ID,
+		&account.Name,
+		&account.NIK,
+		&account.NoHP,
+		&account.NoRekening,
+		&account.Saldo,
+		scanTime(&account.CreatedAt),
+		scanTime(&account.UpdatedAt),
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("Error locking account by no rekening: %v", err)
+		return nil, models.GetAccountErr.Wrap(err)
 	}
 
 	return &account, nil
@@ -116,15 +146,15 @@ func (r *accountRepository) GetAccountByNik(ctx context.Context, nik string) (*m
 	`
 
 	var account models.Account
-	err := r.db.QueryRowContext(ctx, query, nik).Scan(
+	err := r.reader(ctx).QueryRowContext(ctx, r.dialect.rebind(query), nik).Scan(
 		&account.ID,
 		&account.Name,
 		&account.NIK,
 		&account.NoHP,
 		&account.NoRekening,
 		&account.Saldo,
-		&account.CreatedAt,
-		&account.UpdatedAt,
+		scanTime(&account.CreatedAt),
+		scanTime(&account.UpdatedAt),
 	)
 
 	if err != nil {
@@ -132,12 +162,7 @@ func (r *accountRepository) GetAccountByNik(ctx context.Context, nik string) (*m
 			return nil, nil
 		}
 		r.logger.Error("Error getting account by NIK: %v", err)
-		return nil, utils.NewRemark(
-			"Error getting account by no nik",
-			models.GetAccountError,
-			"no_rekening",
-			err,
-		)
+		return nil, models.GetAccountErr.Wrap(err)
 	}
 
 	return &account, nil
@@ -151,15 +176,15 @@ func (r *accountRepository) GetAccountByNoHp(ctx context.Context, no_hp string)
 	`
 
 	var account models.Account
-	err := r.db.QueryRowContext(ctx, query, no_hp).Scan(
+	err := r.reader(ctx).QueryRowContext(ctx, r.dialect.rebind(query), no_hp).Scan(
 		&account.ID,
 		&account.Name,
 		&account.NIK,
 		&account.NoHP,
 		&account.NoRekening,
 		&account.Saldo,
-		&account.CreatedAt,
-		&account.UpdatedAt,
+		scanTime(&account.CreatedAt),
+		scanTime(&account.UpdatedAt),
 	)
 
 	if err != nil {
@@ -167,47 +192,39 @@ func (r *accountRepository) GetAccountByNoHp(ctx context.Context, no_hp string)
 			return nil, nil
 		}
 		r.logger.Error("Error getting account by no hp: %v", err)
-		return nil, utils.NewRemark(
-			"Error getting account by no no_hp",
-			models.GetAccountError,
-			"no_rekening",
-			err,
-		)
+		return nil, models.GetAccountErr.Wrap(err)
 	}
 
 	return &account, nil
 }
 
-func (r *accountRepository) UpdateSaldo(ctx context.Context, tx *sql.Tx, accountID uint, nominal float64) error {
+func (r *accountRepository) UpdateSaldo(ctx context.Context, accountID uint, nominal float64) error {
 	query := `
 		UPDATE accounts
-		SET saldo = saldo + $1, updated_at = NOW()
+		SET saldo = saldo + $1, updated_at = ` + r.dialect.now() + `
 		WHERE id = $2
 	`
 
-	var err error
-	if tx != nil {
-		_, err = tx.ExecContext(ctx, query, nominal, accountID)
-	} else {
-		_, err = r.db.ExecContext(ctx, query, nominal, accountID)
-	}
-
+	_, err := conn(ctx, r.db).ExecContext(ctx, r.dialect.rebind(query), nominal, accountID)
 	if err != nil {
 		typeTransaction := "credit/tabung"
 		if nominal < 0 {
 			typeTransaction = "debit/tarik"
 		}
 		r.logger.Error("Error updating account saldo: %v", err)
-		return utils.NewRemark(
-			"error updating account saldo",
-			models.UpdateSaldoError,
-			"no_rekening",
-			map[string]interface{}{
-				"error": err,
-				"type":  typeTransaction,
-			},
-		)
+		return models.UpdateSaldoErr.
+			WithObject(map[string]interface{}{"type": typeTransaction}).
+			Wrap(err)
 	}
 
 	return nil
 }
+
+// reader returns the connection for read-only queries, the replica when one
+// is configured and fresh.
+func (r *accountRepository) reader(ctx context.Context) DBTX {
+	if r.replica != nil {
+		return r.replica.reader(ctx)
+	}
+	return conn(ctx, r.db)
+}
@@ -0,0 +1,88 @@
+package repositories
+
+import (
+	"accounts-service/models"
+	"accounts-service/utils"
+	"context"
+	"errors"
+	"time"
+)
+
+type memoryAccountRepository struct {
+	store  *MemoryStore
+	logger utils.Logger
+}
+
+func NewMemoryAccountRepository(store *MemoryStore, logger utils.Logger) AccountRepository {
+	return &memoryAccountRepository{
+		store:  store,
+		logger: logger,
+	}
+}
+
+func (r *memoryAccountRepository) CreateAccount(ctx context.Context, account *models.Account) error {
+	return r.store.write(ctx, func(state *memoryState) error {
+		for _, existing := range state.accounts {
+			if existing.NIK == account.NIK {
+				r.logger.Error("Error creating account: duplicate nik")
+				return models.CreateAccountErr.Wrap(errors.New("duplicate key value violates unique constraint accounts_nik_key"))
+			}
+		}
+
+		now := time.Now()
+		account.ID = state.nextAccountID
+		account.CreatedAt = now
+		account.UpdatedAt = now
+
+		state.accounts[account.ID] = *account
+		state.nextAccountID++
+		return nil
+	})
+}
+
+func (r *memoryAccountRepository) find(ctx context.Context, match func(account models.Account) bool) (*models.Account, error) {
+	var found *models.Account
+	err := r.store.read(ctx, func(state *memoryState) error {
+		for _, account := range state.accounts {
+			if match(account) {
+				found = &account
+				return nil
+			}
+		}
+		return nil
+	})
+	return found, err
+}
+
+func (r *memoryAccountRepository) GetAccountByNoRekening(ctx context.Context, noRekening string) (*models.Account, error) {
+	return r.find(ctx, func(account models.Account) bool { return account.NoRekening == noRekening })
+}
+
+func (r *memoryAccountRepository) GetAccountByNoRekeningForUpdate(ctx context.Context, noRekening string) (*models.Account, error) {
+	// Transactions on the memory store are serialized, so the plain read
+	// already holds the row for the rest of the transaction.
+	return r.GetAccountByNoRekening(ctx, noRekening)
+}
+
+func (r *memoryAccountRepository) GetAccountByNoHp(ctx context.Context, noHp string) (*models.Account, error) {
+	return r.find(ctx, func(account models.Account) bool { return account.NoHP == noHp })
+}
+
+func (r *memoryAccountRepository) GetAccountByNik(ctx context.Context, nik string) (*models.Account, error) {
+	return r.find(ctx, func(account models.Account) bool { return account.NIK == nik })
+}
+
+func (r *memoryAccountRepository) UpdateSaldo(ctx context.Context, accountID uint, nominal float64) error {
+	return r.store.write(ctx, func(state *memoryState) error {
+		account, ok := state.accounts[accountID]
+		if !ok {
+			// UPDATE on a missing row affects nothing in Postgres either.
+			return nil
+		}
+
+		account.Saldo += nominal
+		account.UpdatedAt = time.Now()
+		state.accounts[accountID] = account
+		return nil
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"accounts-service/repositories"
 	"accounts-service/utils"
 	"context"
+	"database/sql"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
@@ -22,21 +23,27 @@ func TestAccountnRepository_Account(t *testing.T) {
 		assert.NotNil(t, repo)
 	})
 
-	t.Run("test begin transaction", func(t *testing.T) {
+	t.Run("update saldo joins ambient transaction", func(t *testing.T) {
 		db, mock, err := sqlmock.New()
 		assert.NoError(t, err)
 		defer db.Close()
 
 		logger := utils.NewLogger("info")
 		repo := repositories.NewAccountRepository(db, logger)
+		txManager := repositories.NewTxManager(db, sql.LevelDefault, 0, logger)
 
-		// Create a transaction
+		// Mock expectation
 		mock.ExpectBegin()
-		assert.NoError(t, err)
+		mock.ExpectExec(`UPDATE accounts`).
+			WithArgs(float64(-5000), uint(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
 
 		// Execute test
-		tx, err := repo.BeginTx(context.Background())
+		err = txManager.WithinTx(context.Background(), func(ctx context.Context) error {
+			return repo.UpdateSaldo(ctx, 1, -5000)
+		})
 		assert.NoError(t, err)
-		assert.NotNil(t, tx)
+		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 }
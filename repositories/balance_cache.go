@@ -0,0 +1,210 @@
+package repositories
+
+import (
+	"accounts-service/models"
+	"container/list"
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// BalanceCache keeps recent account balances in front of GetSaldo. Every
+// entry carries the version of the account row it was read from, the
+// updated_at timestamp in microseconds, and an entry is only ever replaced
+// by one of the same or a newer version. A slow reader that loaded the row
+// before a posting committed therefore cannot overwrite the balance written
+// after the commit.
+type BalanceCache interface {
+	// Get returns the cached balance of noRekening.
+	Get(ctx context.Context, noRekening string) (*models.SaldoResponse, bool)
+	// Fill caches a balance read from the database on a cache miss.
+	Fill(ctx context.Context, saldo *models.SaldoResponse, version int64)
+	// Store caches the balance committed by a posting. The entry is kept for
+	// at least the pin duration of the cache even when it is evicted by size,
+	// so that a lagging replica cannot serve the caller an older balance.
+	Store(ctx context.Context, saldo *models.SaldoResponse, version int64)
+	Stats() CacheStats
+}
+
+// CacheStats are the counters of a BalanceCache since it was created.
+type CacheStats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Stores    uint64 `json:"stores"`
+	Stale     uint64 `json:"stale"`
+	Evictions uint64 `json:"evictions"`
+	Errors    uint64 `json:"errors"`
+	Entries   int    `json:"entries"`
+}
+
+// cacheCounters are the atomic counters shared by the cache implementations.
+type cacheCounters struct {
+	hits, misses, stores, stale, evictions, errors atomic.Uint64
+}
+
+func (c *cacheCounters) stats() CacheStats {
+	return CacheStats{
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Stores:    c.stores.Load(),
+		Stale:     c.stale.Load(),
+		Evictions: c.evictions.Load(),
+		Errors:    c.errors.Load(),
+	}
+}
+
+// BalanceVersion returns the cache version of an account row.
+func BalanceVersion(account *models.Account) int64 {
+	return account.UpdatedAt.UnixMicro()
+}
+
+type noopBalanceCache struct{}
+
+// NewNoopBalanceCache returns a cache that never holds anything, used when
+// the balance cache is disabled.
+func NewNoopBalanceCache() BalanceCache {
+	return noopBalanceCache{}
+}
+
+func (noopBalanceCache) Get(ctx context.Context, noRekening string) (*models.SaldoResponse, bool) {
+	return nil, false
+}
+
+func (noopBalanceCache) Fill(ctx context.Context, saldo *models.SaldoResponse, version int64) {}
+
+func (noopBalanceCache) Store(ctx context.Context, saldo *models.SaldoResponse, version int64) {}
+
+func (noopBalanceCache) Stats() CacheStats {
+	return CacheStats{}
+}
+
+type lruEntry struct {
+	noRekening  string
+	saldo       float64
+	version     int64
+	expiresAt   time.Time
+	pinnedUntil time.Time
+}
+
+// lruBalanceCache is an in-process cache holding at most size balances for
+// ttl each. It only sees the postings of this process, so it must not be
+// used when several instances serve the same accounts.
+type lruBalanceCache struct {
+	size int
+	ttl  time.Duration
+	pin  time.Duration
+	now  func() time.Time
+
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+
+	cacheCounters
+}
+
+// NewLRUBalanceCache returns an in-process cache of up to size balances kept
+// for ttl. Balances stored after a posting are not evicted by size for pin.
+func NewLRUBalanceCache(size int, ttl, pin time.Duration) BalanceCache {
+	return &lruBalanceCache{
+		size:    size,
+		ttl:     ttl,
+		pin:     pin,
+		now:     time.Now,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (c *lruBalanceCache) Get(ctx context.Context, noRekening string) (*models.SaldoResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[noRekening]
+	if !ok {
+		c.misses.Add(1)
+		return nil, false
+	}
+
+	entry := elem.Value.(*lruEntry)
+	if !c.now().Before(entry.expiresAt) {
+		c.remove(elem)
+		c.misses.Add(1)
+		return nil, false
+	}
+
+	c.order.MoveToFront(elem)
+	c.hits.Add(1)
+	return &models.SaldoResponse{NoRekening: entry.noRekening, Saldo: entry.saldo}, true
+}
+
+func (c *lruBalanceCache) Fill(ctx context.Context, saldo *models.SaldoResponse, version int64) {
+	c.set(saldo, version, time.Time{})
+}
+
+func (c *lruBalanceCache) Store(ctx context.Context, saldo *models.SaldoResponse, version int64) {
+	c.set(saldo, version, c.now().Add(c.pin))
+}
+
+func (c *lruBalanceCache) set(saldo *models.SaldoResponse, version int64, pinnedUntil time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if elem, ok := c.entries[saldo.NoRekening]; ok {
+		entry := elem.Value.(*lruEntry)
+		if version < entry.version {
+			c.stale.Add(1)
+			return
+		}
+
+		entry.saldo = saldo.Saldo
+		entry.version = version
+		entry.expiresAt = now.Add(c.ttl)
+		if pinnedUntil.After(entry.pinnedUntil) {
+			entry.pinnedUntil = pinnedUntil
+		}
+		c.order.MoveToFront(elem)
+		c.stores.Add(1)
+		return
+	}
+
+	c.entries[saldo.NoRekening] = c.order.PushFront(&lruEntry{
+		noRekening:  saldo.NoRekening,
+		saldo:       saldo.Saldo,
+		version:     version,
+		expiresAt:   now.Add(c.ttl),
+		pinnedUntil: pinnedUntil,
+	})
+	c.stores.Add(1)
+	c.evict(now)
+}
+
+// evict removes the least recently used entries over size, skipping pinned
+// ones. The cache grows past size while every entry is pinned.
+func (c *lruBalanceCache) evict(now time.Time) {
+	for elem := c.order.Back(); elem != nil && c.order.Len() > c.size; {
+		prev := elem.Prev()
+		entry := elem.Value.(*lruEntry)
+		if !now.Before(entry.expiresAt) || !now.Before(entry.pinnedUntil) {
+			c.remove(elem)
+			c.evictions.Add(1)
+		}
+		elem = prev
+	}
+}
+
+func (c *lruBalanceCache) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.entries, elem.Value.(*lruEntry).noRekening)
+}
+
+func (c *lruBalanceCache) Stats() CacheStats {
+	c.mu.Lock()
+	entries := c.order.Len()
+	c.mu.Unlock()
+
+	stats := c.stats()
+	stats.Entries = entries
+	return stats
+}
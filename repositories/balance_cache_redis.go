@@ -0,0 +1,131 @@
+package repositories
+
+import (
+	"accounts-service/models"
+	"accounts-service/utils"
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisSetIfNewer stores a balance unless the key holds a newer version.
+// KEYS[1] is the balance key, ARGV the saldo, version and ttl in ms.
+var redisSetIfNewer = redis.NewScript(`
+local current = redis.call('HGET', KEYS[1], 'version')
+if current and tonumber(current) > tonumber(ARGV[2]) then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'saldo', ARGV[1], 'version', ARGV[2])
+redis.call('PEXPIRE', KEYS[1], ARGV[3])
+return 1
+`)
+
+// redisCacheTimeout bounds every cache call so a slow Redis degrades to
+// cache misses instead of slowing down requests.
+const redisCacheTimeout = 200 * time.Millisecond
+
+// redisBalanceCache shares balances between instances through Redis. Cache
+// errors are logged and counted, and treated as misses.
+type redisBalanceCache struct {
+	client *redis.Client
+	prefix string
+	ttl    time.Duration
+	pin    time.Duration
+	logger utils.Logger
+
+	cacheCounters
+}
+
+// NewRedisBalanceCache returns a cache keeping balances in Redis under
+// prefix for ttl. Balances stored after a posting are kept for at least pin.
+func NewRedisBalanceCache(client *redis.Client, prefix string, ttl, pin time.Duration, logger utils.Logger) BalanceCache {
+	return &redisBalanceCache{
+		client: client,
+		prefix: prefix,
+		ttl:    ttl,
+		pin:    pin,
+		logger: logger,
+	}
+}
+
+func (c *redisBalanceCache) key(noRekening string) string {
+	return c.prefix + noRekening
+}
+
+func (c *redisBalanceCache) Get(ctx context.Context, noRekening string) (*models.SaldoResponse, bool) {
+	ctx, cancel := context.WithTimeout(ctx, redisCacheTimeout)
+	defer cancel()
+
+	value, err := c.client.HGet(ctx, c.key(noRekening), "saldo").Result()
+	if errors.Is(err, redis.Nil) {
+		c.misses.Add(1)
+		return nil, false
+	}
+	if err != nil {
+		c.logger.Warning("Error reading balance cache: %v", err)
+		c.errors.Add(1)
+		c.misses.Add(1)
+		return nil, false
+	}
+
+	saldo, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		c.logger.Warning("Invalid cached balance %q for %s: %v", value, noRekening, err)
+		c.errors.Add(1)
+		c.misses.Add(1)
+		return nil, false
+	}
+
+	c.hits.Add(1)
+	return &models.SaldoResponse{NoRekening: noRekening, Saldo: saldo}, true
+}
+
+func (c *redisBalanceCache) Fill(ctx context.Context, saldo *models.SaldoResponse, version int64) {
+	ctx, cancel := context.WithTimeout(ctx, redisCacheTimeout)
+	defer cancel()
+
+	if err := c.set(ctx, saldo, version, c.ttl); err != nil {
+		c.logger.Warning("Error writing balance cache: %v", err)
+		c.errors.Add(1)
+	}
+}
+
+func (c *redisBalanceCache) Store(ctx context.Context, saldo *models.SaldoResponse, version int64) {
+	// The balance is committed, a cancelled request must not skip caching it.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), redisCacheTimeout)
+	defer cancel()
+
+	err := c.set(ctx, saldo, version, max(c.ttl, c.pin))
+	if err == nil {
+		return
+	}
+
+	// Drop the entry so the balance from before the posting is not served.
+	c.logger.Warning("Error writing balance cache, invalidating %s: %v", saldo.NoRekening, err)
+	c.errors.Add(1)
+	if err := c.client.Del(ctx, c.key(saldo.NoRekening)).Err(); err != nil {
+		c.logger.Error("Error invalidating balance cache for %s: %v", saldo.NoRekening, err)
+	}
+}
+
+func (c *redisBalanceCache) set(ctx context.Context, saldo *models.SaldoResponse, version int64, ttl time.Duration) error {
+	stored, err := redisSetIfNewer.Run(ctx, c.client, []string{c.key(saldo.NoRekening)},
+		strconv.FormatFloat(saldo.Saldo, 'f', -1, 64), version, ttl.Milliseconds()).Int()
+	if err != nil {
+		return err
+	}
+
+	if stored == 0 {
+		c.stale.Add(1)
+		return nil
+	}
+	c.stores.Add(1)
+	return nil
+}
+
+func (c *redisBalanceCache) Stats() CacheStats {
+	return c.stats()
+}
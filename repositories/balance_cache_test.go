@@ -0,0 +1,121 @@
+package repositories_test
+
+import (
+	"accounts-service/models"
+	"accounts-service/repositories"
+	"accounts-service/utils"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func saldo(noRekening string, amount float64) *models.SaldoResponse {
+	return &models.SaldoResponse{NoRekening: noRekening, Saldo: amount}
+}
+
+func TestBalanceCache(t *testing.T) {
+	logger := utils.NewLogger("critical")
+	ctx := context.Background()
+
+	caches := map[string]func(t *testing.T) repositories.BalanceCache{
+		"lru": func(t *testing.T) repositories.BalanceCache {
+			return repositories.NewLRUBalanceCache(100, time.Minute, 0)
+		},
+		"redis": func(t *testing.T) repositories.BalanceCache {
+			server := miniredis.RunT(t)
+			client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+			t.Cleanup(func() { client.Close() })
+			return repositories.NewRedisBalanceCache(client, "saldo:", time.Minute, 0, logger)
+		},
+	}
+
+	for name, newCache := range caches {
+		t.Run(name, func(t *testing.T) {
+			t.Run("get after fill and store", func(t *testing.T) {
+				cache := newCache(t)
+
+				_, ok := cache.Get(ctx, "1744847261")
+				assert.False(t, ok)
+
+				cache.Fill(ctx, saldo("1744847261", 1000), 1)
+				cached, ok := cache.Get(ctx, "1744847261")
+				require.True(t, ok)
+				assert.Equal(t, float64(1000), cached.Saldo)
+
+				cache.Store(ctx, saldo("1744847261", 1250.5), 2)
+				cached, ok = cache.Get(ctx, "1744847261")
+				require.True(t, ok)
+				assert.Equal(t, 1250.5, cached.Saldo)
+
+				stats := cache.Stats()
+				assert.Equal(t, uint64(2), stats.Hits)
+				assert.Equal(t, uint64(1), stats.Misses)
+				assert.Equal(t, uint64(2), stats.Stores)
+			})
+
+			t.Run("older version never replaces newer", func(t *testing.T) {
+				cache := newCache(t)
+
+				cache.Store(ctx, saldo("1744847261", 500), 20)
+				cache.Fill(ctx, saldo("1744847261", 1000), 10)
+
+				cached, ok := cache.Get(ctx, "1744847261")
+				require.True(t, ok)
+				assert.Equal(t, float64(500), cached.Saldo)
+				assert.Equal(t, uint64(1), cache.Stats().Stale)
+			})
+		})
+	}
+
+	t.Run("lru expires entries after ttl", func(t *testing.T) {
+		cache := repositories.NewLRUBalanceCache(100, 20*time.Millisecond, 0)
+
+		cache.Fill(ctx, saldo("1744847261", 1000), 1)
+		time.Sleep(30 * time.Millisecond)
+
+		_, ok := cache.Get(ctx, "1744847261")
+		assert.False(t, ok)
+	})
+
+	t.Run("lru evicts least recently used but keeps pinned entries", func(t *testing.T) {
+		cache := repositories.NewLRUBalanceCache(2, time.Minute, time.Minute)
+
+		cache.Store(ctx, saldo("1000000001", 1), 1)
+		cache.Fill(ctx, saldo("1000000002", 2), 1)
+		cache.Fill(ctx, saldo("1000000003", 3), 1)
+
+		_, ok := cache.Get(ctx, "1000000001")
+		assert.True(t, ok, "pinned entry was evicted")
+		_, ok = cache.Get(ctx, "1000000002")
+		assert.False(t, ok)
+		_, ok = cache.Get(ctx, "1000000003")
+		assert.True(t, ok)
+		assert.Equal(t, uint64(1), cache.Stats().Evictions)
+	})
+
+	t.Run("redis errors are misses and failed stores invalidate", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+		t.Cleanup(func() { client.Close() })
+		cache := repositories.NewRedisBalanceCache(client, "saldo:", time.Minute, 0, logger)
+
+		cache.Fill(ctx, saldo("1744847261", 1000), 1)
+		server.SetError("LOADING")
+		_, ok := cache.Get(ctx, "1744847261")
+		assert.False(t, ok)
+		server.SetError("")
+
+		// A key of the wrong type makes the store script fail
+		server.Del("saldo:1744847261")
+		require.NoError(t, server.Set("saldo:1744847261", "1000"))
+		cache.Store(ctx, saldo("1744847261", 500), 2)
+		assert.False(t, server.Exists("saldo:1744847261"))
+
+		assert.Equal(t, uint64(2), cache.Stats().Errors)
+	})
+}
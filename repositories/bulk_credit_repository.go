@@ -0,0 +1,252 @@
+package repositories
+
+import (
+	"accounts-service/models"
+	"accounts-service/utils"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type BulkCreditRepository interface {
+	// CreateBatch records a batch with its rows. Call it in a transaction, so
+	// the batch is not left without rows. It does nothing and leaves
+	// batch.ID zero when the reference is already used.
+	CreateBatch(ctx context.Context, batch *models.BulkCreditBatch) error
+	// GetBatch returns a batch with its rows by line, or nil when the
+	// reference is unknown.
+	GetBatch(ctx context.Context, reference string) (*models.BulkCreditBatch, error)
+	// ListProcessingBatches returns the references of the batches still
+	// processing, oldest first.
+	ListProcessingBatches(ctx context.Context) ([]string, error)
+	// UpdateRowStatus moves a row from status from to status to. It reports
+	// false and changes nothing when the row is no longer in from, so a row
+	// is only posted once.
+	UpdateRowStatus(ctx context.Context, id uint, from, to, errorCode string) (bool, error)
+	// FinishBatch stores the status, totals and CompletedAt of a processing
+	// batch. It reports false and changes nothing when the batch is no
+	// longer processing.
+	FinishBatch(ctx context.Context, batch *models.BulkCreditBatch) (bool, error)
+}
+
+type bulkCreditRepository struct {
+	db      *sql.DB
+	dialect Dialect
+	logger  utils.Logger
+}
+
+func NewBulkCreditRepository(db *sql.DB, logger utils.Logger) BulkCreditRepository {
+	return &bulkCreditRepository{
+		db:      db,
+		dialect: DialectPostgres,
+		logger:  logger,
+	}
+}
+
+func NewSQLiteBulkCreditRepository(db *sql.DB, logger utils.Logger) BulkCreditRepository {
+	return &bulkCreditRepository{
+		db:      db,
+		dialect: DialectSQLite,
+		logger:  logger,
+	}
+}
+
+func (r *bulkCreditRepository) fail(action string, err error) error {
+	r.logger.Error("Error %s: %v", action, err)
+	return models.BulkCreditDBErr.Wrap(err)
+}
+
+func (r *bulkCreditRepository) CreateBatch(ctx context.Context, batch *models.BulkCreditBatch) error {
+	query := `
+		INSERT INTO bulk_credit_batches (reference, source_account_id, mode, status, total_rows, credited_rows, credited_nominal, completed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (reference) DO NOTHING
+		RETURNING id, created_at
+	`
+
+	batch.TotalRows = len(batch.Rows)
+	err := conn(ctx, r.db).QueryRowContext(ctx, r.dialect.rebind(query),
+		batch.Reference,
+		batch.SourceAccountID,
+		batch.Mode,
+		batch.Status,
+		batch.TotalRows,
+		batch.CreditedRows,
+		batch.CreditedNominal,
+		nullTime(batch.CompletedAt),
+	).Scan(&batch.ID, scanTime(&batch.CreatedAt))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return r.fail("creating bulk credit batch", err)
+	}
+
+	insert := `
+		INSERT INTO bulk_credit_rows (batch_id, line, no_rekening, account_id, nominal, reference, status, error_code)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`
+	for i := range batch.Rows {
+		row := &batch.Rows[i]
+		row.BatchID = batch.ID
+
+		err := conn(ctx, r.db).QueryRowContext(ctx, r.dialect.rebind(insert),
+			row.BatchID,
+			row.Line,
+			row.NoRekening,
+			sql.NullInt64{Int64: int64(row.AccountID), Valid: row.AccountID != 0},
+			row.Nominal,
+			nullString(row.Reference),
+			row.Status,
+			nullString(row.ErrorCode),
+		).Scan(&row.ID)
+		if err != nil {
+			return r.fail("creating bulk credit row", err)
+		}
+	}
+
+	return nil
+}
+
+func (r *bulkCreditRepository) GetBatch(ctx context.Context, reference string) (*models.BulkCreditBatch, error) {
+	query := `
+		SELECT b.id, b.reference, b.source_account_id, a.no_rekening, b.mode, b.status,
+			b.total_rows, b.credited_rows, b.credited_nominal, b.created_at, b.completed_at
+		FROM bulk_credit_batches b
+		JOIN accounts a ON a.id = b.source_account_id
+		WHERE b.reference = $1
+	`
+
+	var (
+		batch       models.BulkCreditBatch
+		completedAt time.Time
+	)
+	err := conn(ctx, r.db).QueryRowContext(ctx, r.dialect.rebind(query), reference).Scan(
+		&batch.ID,
+		&batch.Reference,
+		&batch.SourceAccountID,
+		&batch.SourceNoRekening,
+		&batch.Mode,
+		&batch.Status,
+		&batch.TotalRows,
+		&batch.CreditedRows,
+		&batch.CreditedNominal,
+		scanTime(&batch.CreatedAt),
+		scanTime(&completedAt),
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, r.fail("getting bulk credit batch", err)
+	}
+	if !completedAt.IsZero() {
+		batch.CompletedAt = &completedAt
+	}
+
+	rowsQuery := `
+		SELECT id, batch_id, line, no_rekening, COALESCE(account_id, 0), nominal,
+			COALESCE(reference, ''), status, COALESCE(error_code, '')
+		FROM bulk_credit_rows
+		WHERE batch_id = $1
+		ORDER BY line, id
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, r.dialect.rebind(rowsQuery), batch.ID)
+	if err != nil {
+		return nil, r.fail("listing bulk credit rows", err)
+	}
+	defer rows.Close()
+
+	batch.Rows = []models.BulkCreditRow{}
+	for rows.Next() {
+		var row models.BulkCreditRow
+		err := rows.Scan(
+			&row.ID,
+			&row.BatchID,
+			&row.Line,
+			&row.NoRekening,
+			&row.AccountID,
+			&row.Nominal,
+			&row.Reference,
+			&row.Status,
+			&row.ErrorCode,
+		)
+		if err != nil {
+			return nil, r.fail("scanning bulk credit row", err)
+		}
+		batch.Rows = append(batch.Rows, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, r.fail("listing bulk credit rows", err)
+	}
+
+	return &batch, nil
+}
+
+func (r *bulkCreditRepository) ListProcessingBatches(ctx context.Context) ([]string, error) {
+	query := `SELECT reference FROM bulk_credit_batches WHERE status = $1 ORDER BY id`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, r.dialect.rebind(query), models.BulkCreditProcessing)
+	if err != nil {
+		return nil, r.fail("listing processing bulk credit batches", err)
+	}
+	defer rows.Close()
+
+	references := []string{}
+	for rows.Next() {
+		var reference string
+		if err := rows.Scan(&reference); err != nil {
+			return nil, r.fail("scanning bulk credit batch", err)
+		}
+		references = append(references, reference)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, r.fail("listing processing bulk credit batches", err)
+	}
+
+	return references, nil
+}
+
+func (r *bulkCreditRepository) UpdateRowStatus(ctx context.Context, id uint, from, to, errorCode string) (bool, error) {
+	query := `UPDATE bulk_credit_rows SET status = $1, error_code = $2 WHERE id = $3 AND status = $4`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, r.dialect.rebind(query), to, nullString(errorCode), id, from)
+	if err != nil {
+		return false, r.fail("updating bulk credit row", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, r.fail("updating bulk credit row", err)
+	}
+
+	return affected == 1, nil
+}
+
+func (r *bulkCreditRepository) FinishBatch(ctx context.Context, batch *models.BulkCreditBatch) (bool, error) {
+	query := `
+		UPDATE bulk_credit_batches
+		SET status = $1, credited_rows = $2, credited_nominal = $3, completed_at = $4, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $5 AND status = $6
+	`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, r.dialect.rebind(query),
+		batch.Status,
+		batch.CreditedRows,
+		batch.CreditedNominal,
+		nullTime(batch.CompletedAt),
+		batch.ID,
+		models.BulkCreditProcessing,
+	)
+	if err != nil {
+		return false, r.fail("finishing bulk credit batch", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, r.fail("finishing bulk credit batch", err)
+	}
+
+	return affected == 1, nil
+}
@@ -0,0 +1,129 @@
+package repositories
+
+import (
+	"accounts-service/models"
+	"accounts-service/utils"
+	"context"
+	"errors"
+	"sort"
+	"time"
+)
+
+type memoryBulkCreditRepository struct {
+	store  *MemoryStore
+	logger utils.Logger
+}
+
+func NewMemoryBulkCreditRepository(store *MemoryStore, logger utils.Logger) BulkCreditRepository {
+	return &memoryBulkCreditRepository{
+		store:  store,
+		logger: logger,
+	}
+}
+
+func (r *memoryBulkCreditRepository) CreateBatch(ctx context.Context, batch *models.BulkCreditBatch) error {
+	return r.store.write(ctx, func(state *memoryState) error {
+		for _, existing := range state.bulkBatches {
+			if existing.Reference == batch.Reference {
+				return nil
+			}
+		}
+
+		source, ok := state.accounts[batch.SourceAccountID]
+		if !ok {
+			r.logger.Error("Error creating bulk credit batch: unknown account %d", batch.SourceAccountID)
+			return models.BulkCreditDBErr.Wrap(errors.New("violates foreign key constraint bulk_credit_batches_source_account_id_fkey"))
+		}
+
+		batch.ID = state.nextBulkBatchID
+		batch.SourceNoRekening = source.NoRekening
+		batch.TotalRows = len(batch.Rows)
+		batch.CreatedAt = time.Now()
+		state.nextBulkBatchID++
+
+		for i := range batch.Rows {
+			row := &batch.Rows[i]
+			row.ID = state.nextBulkRowID
+			row.BatchID = batch.ID
+			state.bulkRows = append(state.bulkRows, *row)
+			state.nextBulkRowID++
+		}
+
+		stored := *batch
+		stored.Rows = nil
+		state.bulkBatches = append(state.bulkBatches, stored)
+		return nil
+	})
+}
+
+func (r *memoryBulkCreditRepository) GetBatch(ctx context.Context, reference string) (*models.BulkCreditBatch, error) {
+	var found *models.BulkCreditBatch
+	err := r.store.read(ctx, func(state *memoryState) error {
+		for _, batch := range state.bulkBatches {
+			if batch.Reference != reference {
+				continue
+			}
+
+			batch.Rows = []models.BulkCreditRow{}
+			for _, row := range state.bulkRows {
+				if row.BatchID == batch.ID {
+					batch.Rows = append(batch.Rows, row)
+				}
+			}
+			found = &batch
+			return nil
+		}
+		return nil
+	})
+	if found != nil {
+		sort.SliceStable(found.Rows, func(i, j int) bool { return found.Rows[i].Line < found.Rows[j].Line })
+	}
+	return found, err
+}
+
+func (r *memoryBulkCreditRepository) ListProcessingBatches(ctx context.Context) ([]string, error) {
+	references := []string{}
+	err := r.store.read(ctx, func(state *memoryState) error {
+		for _, batch := range state.bulkBatches {
+			if batch.Status == models.BulkCreditProcessing {
+				references = append(references, batch.Reference)
+			}
+		}
+		return nil
+	})
+	return references, err
+}
+
+func (r *memoryBulkCreditRepository) UpdateRowStatus(ctx context.Context, id uint, from, to, errorCode string) (bool, error) {
+	var updated bool
+	err := r.store.write(ctx, func(state *memoryState) error {
+		for i := range state.bulkRows {
+			row := &state.bulkRows[i]
+			if row.ID == id && row.Status == from {
+				row.Status = to
+				row.ErrorCode = errorCode
+				updated = true
+			}
+		}
+		return nil
+	})
+	return updated, err
+}
+
+func (r *memoryBulkCreditRepository) FinishBatch(ctx context.Context, batch *models.BulkCreditBatch) (bool, error) {
+	var updated bool
+	err := r.store.write(ctx, func(state *memoryState) error {
+		for i := range state.bulkBatches {
+			existing := &state.bulkBatches[i]
+			if existing.ID == batch.ID && existing.Status == models.BulkCreditProcessing {
+				existing.Status = batch.Status
+				existing.CreditedRows = batch.CreditedRows
+				existing.CreditedNominal = batch.CreditedNominal
+				existing.CompletedAt = batch.CompletedAt
+				updated = true
+			}
+		}
+		return nil
+	})
+	return updated, err
+}
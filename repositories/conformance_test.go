@@ -0,0 +1,973 @@
+package repositories_test
+
+import (
+	"accounts-service/config"
+	"accounts-service/migrations"
+	"accounts-service/models"
+	"accounts-service/repositories"
+	"accounts-service/utils"
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// backend is one storage implementation under the conformance suite.
+type backend struct {
+	txManager          repositories.TxManager
+	accountRepo        repositories.AccountRepository
+	mutationRepo       repositories.MutationRepository
+	outboxRepo         repositories.OutboxRepository
+	webhookRepo        repositories.WebhookRepository
+	preferenceRepo     repositories.NotificationPreferenceRepository
+	statementRepo      repositories.MonthlyStatementRepository
+	bulkCreditRepo     repositories.BulkCreditRepository
+	externalIDRepo     repositories.ExternalIDRepository
+	virtualAccountRepo repositories.VirtualAccountRepository
+	interbankRepo      repositories.InterbankRepository
+	qrisRepo           repositories.QRISRepository
+}
+
+var errRollback = errors.New("rollback")
+
+func TestRepositoryConformance(t *testing.T) {
+	logger := utils.NewLogger("critical")
+
+	backends := map[string]func(t *testing.T) backend{
+		"memory": func(t *testing.T) backend {
+			store := repositories.NewMemoryStore()
+			return backend{
+				txManager:          repositories.NewMemoryTxManager(store, logger),
+				accountRepo:        repositories.NewMemoryAccountRepository(store, logger),
+				mutationRepo:       repositories.NewMemoryMutationRepository(store, logger),
+				outboxRepo:         repositories.NewMemoryOutboxRepository(store, logger),
+				webhookRepo:        repositories.NewMemoryWebhookRepository(store, logger),
+				preferenceRepo:     repositories.NewMemoryNotificationPreferenceRepository(store, logger),
+				statementRepo:      repositories.NewMemoryMonthlyStatementRepository(store, logger),
+				bulkCreditRepo:     repositories.NewMemoryBulkCreditRepository(store, logger),
+				externalIDRepo:     repositories.NewMemoryExternalIDRepository(store, logger),
+				virtualAccountRepo: repositories.NewMemoryVirtualAccountRepository(store, logger),
+				interbankRepo:      repositories.NewMemoryInterbankRepository(store, logger),
+				qrisRepo:           repositories.NewMemoryQRISRepository(store, logger),
+			}
+		},
+		"sqlite": func(t *testing.T) backend {
+			db, err := config.NewSQLiteConnection(&config.Config{SQLitePath: filepath.Join(t.TempDir(), "accounts.db")})
+			require.NoError(t, err)
+			t.Cleanup(func() { db.Close() })
+			migrate(t, db, migrations.DialectSQLite, logger)
+
+			return backend{
+				txManager:          repositories.NewTxManager(db, sql.LevelDefault, 0, logger),
+				accountRepo:        repositories.NewSQLiteAccountRepository(db, logger),
+				mutationRepo:       repositories.NewSQLiteMutationRepository(db, logger),
+				outboxRepo:         repositories.NewSQLiteOutboxRepository(db, logger),
+				webhookRepo:        repositories.NewSQLiteWebhookRepository(db, logger),
+				preferenceRepo:     repositories.NewSQLiteNotificationPreferenceRepository(db, logger),
+				statementRepo:      repositories.NewSQLiteMonthlyStatementRepository(db, logger),
+				bulkCreditRepo:     repositories.NewSQLiteBulkCreditRepository(db, logger),
+				externalIDRepo:     repositories.NewSQLiteExternalIDRepository(db, logger),
+				virtualAccountRepo: repositories.NewSQLiteVirtualAccountRepository(db, logger),
+				interbankRepo:      repositories.NewSQLiteInterbankRepository(db, logger),
+				qrisRepo:           repositories.NewSQLiteQRISRepository(db, logger),
+			}
+		},
+		"postgres": func(t *testing.T) backend {
+			dsn := os.Getenv("TEST_POSTGRES_DSN")
+			if dsn == "" {
+				t.Skip("TEST_POSTGRES_DSN not set")
+			}
+			db, err := sql.Open("postgres", dsn)
+			require.NoError(t, err)
+			t.Cleanup(func() { db.Close() })
+			migrate(t, db, migrations.DialectPostgres, logger)
+
+			_, err = db.Exec(`TRUNCATE accounts, mutations, outbox, webhooks, webhook_deliveries, webhook_delivery_attempts, notification_preferences, monthly_statements, bulk_credit_batches, bulk_credit_rows, external_ids, virtual_accounts, interbank_batches, interbank_transfers RESTART IDENTITY CASCADE`)
+			require.NoError(t, err)
+
+			return backend{
+				txManager:          repositories.NewTxManager(db, sql.LevelReadCommitted, 0, logger),
+				accountRepo:        repositories.NewAccountRepository(db, logger),
+				mutationRepo:       repositories.NewMutationRepository(db, logger),
+				outboxRepo:         repositories.NewOutboxRepository(db, logger),
+				webhookRepo:        repositories.NewWebhookRepository(db, logger),
+				preferenceRepo:     repositories.NewNotificationPreferenceRepository(db, logger),
+				statementRepo:      repositories.NewMonthlyStatementRepository(db, logger),
+				bulkCreditRepo:     repositories.NewBulkCreditRepository(db, logger),
+				externalIDRepo:     repositories.NewExternalIDRepository(db, logger),
+				virtualAccountRepo: repositories.NewVirtualAccountRepository(db, logger),
+				interbankRepo:      repositories.NewInterbankRepository(db, logger),
+				qrisRepo:           repositories.NewQRISRepository(db, logger),
+			}
+		},
+	}
+
+	for name, newBackend := range backends {
+		t.Run(name, func(t *testing.T) {
+			runConformance(t, newBackend)
+		})
+	}
+}
+
+func migrate(t *testing.T, db *sql.DB, dialect string, logger utils.Logger) {
+	migrator, err := migrations.NewMigrator(db, dialect, logger)
+	require.NoError(t, err)
+	require.NoError(t, migrator.Run(context.Background(), "up"))
+}
+
+func newAccount(nik, noHp, noRekening string) *models.Account {
+	return &models.Account{Name: "Siti", NIK: nik, NoHP: noHp, NoRekening: noRekening}
+}
+
+func runConformance(t *testing.T, newBackend func(t *testing.T) backend) {
+	ctx := context.Background()
+
+	t.Run("create and get account", func(t *testing.T) {
+		b := newBackend(t)
+
+		account := newAccount("3201014508950001", "+6281234567890", "1744847261")
+		require.NoError(t, b.accountRepo.CreateAccount(ctx, account))
+		assert.NotZero(t, account.ID)
+		assert.False(t, account.CreatedAt.IsZero())
+
+		for _, get := range []func() (*models.Account, error){
+			func() (*models.Account, error) { return b.accountRepo.GetAccountByNoRekening(ctx, "1744847261") },
+			func() (*models.Account, error) { return b.accountRepo.GetAccountByNik(ctx, "3201014508950001") },
+			func() (*models.Account, error) { return b.accountRepo.GetAccountByNoHp(ctx, "+6281234567890") },
+		} {
+			found, err := get()
+			require.NoError(t, err)
+			require.NotNil(t, found)
+			assert.Equal(t, account.ID, found.ID)
+			assert.Equal(t, "Siti", found.Name)
+			assert.Equal(t, float64(0), found.Saldo)
+		}
+
+		missing, err := b.accountRepo.GetAccountByNoRekening(ctx, "1000000000")
+		assert.NoError(t, err)
+		assert.Nil(t, missing)
+	})
+
+	t.Run("duplicate nik", func(t *testing.T) {
+		b := newBackend(t)
+
+		require.NoError(t, b.accountRepo.CreateAccount(ctx, newAccount("3201014508950001", "+6281234567890", "1744847261")))
+		err := b.accountRepo.CreateAccount(ctx, newAccount("3201014508950001", "+6281200000000", "1744847262"))
+		assert.ErrorIs(t, err, models.CreateAccountErr)
+	})
+
+	t.Run("posting commits saldo and mutation together", func(t *testing.T) {
+		b := newBackend(t)
+
+		account := newAccount("3201014508950001", "+6281234567890", "1744847261")
+		require.NoError(t, b.accountRepo.CreateAccount(ctx, account))
+
+		err := b.txManager.WithinTx(ctx, func(ctx context.Context) error {
+			locked, err := b.accountRepo.GetAccountByNoRekeningForUpdate(ctx, account.NoRekening)
+			if err != nil {
+				return err
+			}
+			if err := b.accountRepo.UpdateSaldo(ctx, locked.ID, 150000.5); err != nil {
+				return err
+			}
+			mutation := &models.Mutation{AccountID: locked.ID, Nominal: 150000.5, Type: "credit/tabung", Reference: "ref-1"}
+			if err := b.mutationRepo.CreateMutation(ctx, mutation); err != nil {
+				return err
+			}
+			assert.NotZero(t, mutation.ID)
+			assert.False(t, mutation.CreatedAt.IsZero())
+			return nil
+		})
+		require.NoError(t, err)
+
+		found, err := b.accountRepo.GetAccountByNoRekening(ctx, account.NoRekening)
+		require.NoError(t, err)
+		assert.Equal(t, 150000.5, found.Saldo)
+	})
+
+	t.Run("rollback discards posting", func(t *testing.T) {
+		b := newBackend(t)
+
+		account := newAccount("3201014508950001", "+6281234567890", "1744847261")
+		require.NoError(t, b.accountRepo.CreateAccount(ctx, account))
+
+		err := b.txManager.WithinTx(ctx, func(ctx context.Context) error {
+			if err := b.accountRepo.UpdateSaldo(ctx, account.ID, 5000); err != nil {
+				return err
+			}
+
+			inTx, err := b.accountRepo.GetAccountByNoRekening(ctx, account.NoRekening)
+			require.NoError(t, err)
+			assert.Equal(t, float64(5000), inTx.Saldo)
+
+			return errRollback
+		})
+		assert.ErrorIs(t, err, errRollback)
+
+		found, err := b.accountRepo.GetAccountByNoRekening(ctx, account.NoRekening)
+		require.NoError(t, err)
+		assert.Equal(t, float64(0), found.Saldo)
+	})
+
+	t.Run("mutation requires existing account", func(t *testing.T) {
+		b := newBackend(t)
+
+		err := b.mutationRepo.CreateMutation(ctx, &models.Mutation{AccountID: 999, Nominal: 1000, Type: "credit/tabung"})
+		assert.ErrorIs(t, err, models.CreateMutationErr)
+	})
+
+	t.Run("mutations are listed newest first per account", func(t *testing.T) {
+		b := newBackend(t)
+
+		account := newAccount("3201014508950001", "+6281234567890", "1744847261")
+		require.NoError(t, b.accountRepo.CreateAccount(ctx, account))
+		other := newAccount("3201014508950002", "+6281234567891", "1744847262")
+		require.NoError(t, b.accountRepo.CreateAccount(ctx, other))
+
+		for i := 1; i <= 5; i++ {
+			require.NoError(t, b.mutationRepo.CreateMutation(ctx, &models.Mutation{AccountID: account.ID, Nominal: float64(i * 1000), Type: models.MutationTypeCredit}))
+			require.NoError(t, b.mutationRepo.CreateMutation(ctx, &models.Mutation{AccountID: other.ID, Nominal: 1, Type: models.MutationTypeCredit}))
+		}
+
+		first, err := b.mutationRepo.ListMutations(ctx, account.ID, 0, 2)
+		require.NoError(t, err)
+		require.Len(t, first, 2)
+		assert.Equal(t, float64(5000), first[0].Nominal)
+		assert.Equal(t, float64(4000), first[1].Nominal)
+		assert.Equal(t, account.ID, first[0].AccountID)
+		assert.False(t, first[0].CreatedAt.IsZero())
+
+		rest, err := b.mutationRepo.ListMutations(ctx, account.ID, first[1].ID, 10)
+		require.NoError(t, err)
+		require.Len(t, rest, 3)
+		assert.Equal(t, float64(3000), rest[0].Nominal)
+		assert.Equal(t, float64(1000), rest[2].Nominal)
+
+		none, err := b.mutationRepo.ListMutations(ctx, account.ID+2, 0, 10)
+		require.NoError(t, err)
+		assert.Empty(t, none)
+	})
+
+	t.Run("mutations are summed and streamed by period", func(t *testing.T) {
+		b := newBackend(t)
+
+		account := newAccount("3201014508950001", "+6281234567890", "1744847261")
+		require.NoError(t, b.accountRepo.CreateAccount(ctx, account))
+		other := newAccount("3201014508950002", "+6281234567891", "1744847262")
+		require.NoError(t, b.accountRepo.CreateAccount(ctx, other))
+
+		require.NoError(t, b.mutationRepo.CreateMutation(ctx, &models.Mutation{AccountID: account.ID, Nominal: 5000, Type: models.MutationTypeCredit}))
+		require.NoError(t, b.mutationRepo.CreateMutation(ctx, &models.Mutation{AccountID: account.ID, Nominal: 1500.5, Type: models.MutationTypeDebit, Reference: "INV-1"}))
+		require.NoError(t, b.mutationRepo.CreateMutation(ctx, &models.Mutation{AccountID: account.ID, Nominal: 2000, Type: models.MutationTypeCredit}))
+		require.NoError(t, b.mutationRepo.CreateMutation(ctx, &models.Mutation{AccountID: other.ID, Nominal: 9999, Type: models.MutationTypeCredit}))
+
+		hourAgo, inAnHour := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+
+		credit, debit, err := b.mutationRepo.SumMutations(ctx, account.ID, inAnHour)
+		require.NoError(t, err)
+		assert.Equal(t, float64(7000), credit)
+		assert.Equal(t, 1500.5, debit)
+
+		credit, debit, err = b.mutationRepo.SumMutations(ctx, account.ID, hourAgo)
+		require.NoError(t, err)
+		assert.Zero(t, credit)
+		assert.Zero(t, debit)
+
+		var streamed []models.Mutation
+		require.NoError(t, b.mutationRepo.EachMutation(ctx, account.ID, hourAgo, inAnHour, func(mutation *models.Mutation) error {
+			streamed = append(streamed, *mutation)
+			return nil
+		}))
+		require.Len(t, streamed, 3)
+		assert.Equal(t, float64(5000), streamed[0].Nominal)
+		assert.Equal(t, "INV-1", streamed[1].Reference)
+		assert.Equal(t, models.MutationTypeDebit, streamed[1].Type)
+		assert.Equal(t, float64(2000), streamed[2].Nominal)
+		assert.False(t, streamed[0].CreatedAt.IsZero())
+
+		require.NoError(t, b.mutationRepo.EachMutation(ctx, account.ID, inAnHour, inAnHour.Add(time.Hour), func(mutation *models.Mutation) error {
+			t.Errorf("unexpected mutation %d outside the period", mutation.ID)
+			return nil
+		}))
+
+		stop := errors.New("stop")
+		calls := 0
+		err = b.mutationRepo.EachMutation(ctx, account.ID, hourAgo, inAnHour, func(mutation *models.Mutation) error {
+			calls++
+			return stop
+		})
+		assert.ErrorIs(t, err, stop)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("mutations are found by reference per account", func(t *testing.T) {
+		b := newBackend(t)
+
+		account := newAccount("3201014508950001", "+6281234567890", "1744847261")
+		require.NoError(t, b.accountRepo.CreateAccount(ctx, account))
+		other := newAccount("3201014508950002", "+6281234567891", "1744847262")
+		require.NoError(t, b.accountRepo.CreateAccount(ctx, other))
+
+		require.NoError(t, b.mutationRepo.CreateMutation(ctx, &models.Mutation{AccountID: account.ID, Nominal: 1000, Type: models.MutationTypeDebit, Reference: "INV-1"}))
+		require.NoError(t, b.mutationRepo.CreateMutation(ctx, &models.Mutation{AccountID: account.ID, Nominal: 2000, Type: models.MutationTypeDebit, Reference: "INV-1"}))
+		require.NoError(t, b.mutationRepo.CreateMutation(ctx, &models.Mutation{AccountID: other.ID, Nominal: 3000, Type: models.MutationTypeCredit, Reference: "INV-2"}))
+
+		mutation, err := b.mutationRepo.GetMutationByReference(ctx, account.ID, "INV-1")
+		require.NoError(t, err)
+		require.NotNil(t, mutation)
+		assert.Equal(t, float64(2000), mutation.Nominal, "the newest is returned")
+		assert.Equal(t, models.MutationTypeDebit, mutation.Type)
+		assert.Equal(t, "INV-1", mutation.Reference)
+		assert.False(t, mutation.CreatedAt.IsZero())
+
+		mutation, err = b.mutationRepo.GetMutationByReference(ctx, account.ID, "INV-2")
+		require.NoError(t, err)
+		assert.Nil(t, mutation)
+	})
+
+	t.Run("postings are marked reversed per account", func(t *testing.T) {
+		b := newBackend(t)
+
+		account := newAccount("3201014508950001", "+6281234567890", "1744847261")
+		require.NoError(t, b.accountRepo.CreateAccount(ctx, account))
+		other := newAccount("3201014508950002", "+6281234567891", "1744847262")
+		require.NoError(t, b.accountRepo.CreateAccount(ctx, other))
+
+		require.NoError(t, b.mutationRepo.MarkReversed(ctx, account.ID, "TARIK TUNAI ATM00001 000000000005"))
+		require.NoError(t, b.mutationRepo.MarkReversed(ctx, account.ID, "TARIK TUNAI ATM00001 000000000005"), "marking again does nothing")
+
+		marked, err := b.mutationRepo.IsMarkedReversed(ctx, account.ID, "TARIK TUNAI ATM00001 000000000005")
+		require.NoError(t, err)
+		assert.True(t, marked)
+
+		marked, err = b.mutationRepo.IsMarkedReversed(ctx, other.ID, "TARIK TUNAI ATM00001 000000000005")
+		require.NoError(t, err)
+		assert.False(t, marked)
+
+		err = b.txManager.WithinTx(ctx, func(ctx context.Context) error {
+			require.NoError(t, b.mutationRepo.MarkReversed(ctx, other.ID, "INV-1"))
+			return errors.New("rollback")
+		})
+		require.Error(t, err)
+		marked, err = b.mutationRepo.IsMarkedReversed(ctx, other.ID, "INV-1")
+		require.NoError(t, err)
+		assert.False(t, marked, "a rolled back marker is discarded")
+
+		assert.Error(t, b.mutationRepo.MarkReversed(ctx, 999, "INV-1"), "the account must exist")
+	})
+
+	t.Run("dynamic QRIS codes are recorded once and paid once", func(t *testing.T) {
+		b := newBackend(t)
+
+		account := newAccount("3201014508950001", "+6281234567890", "1744847261")
+		require.NoError(t, b.accountRepo.CreateAccount(ctx, account))
+		other := newAccount("3201014508950002", "+6281234567891", "1744847262")
+		require.NoError(t, b.accountRepo.CreateAccount(ctx, other))
+
+		bill := &models.QRISBill{AccountID: account.ID, BillNumber: "INV001", Nominal: 15000.5, Status: models.QRISBillStatusOpen}
+		require.NoError(t, b.qrisRepo.CreateBill(ctx, bill))
+		assert.NotZero(t, bill.ID)
+
+		duplicate := &models.QRISBill{AccountID: account.ID, BillNumber: "INV001", Nominal: 100, Status: models.QRISBillStatusOpen}
+		require.NoError(t, b.qrisRepo.CreateBill(ctx, duplicate))
+		assert.Zero(t, duplicate.ID, "the bill number is used by the account")
+
+		otherBill := &models.QRISBill{AccountID: other.ID, BillNumber: "INV001", Nominal: 100, Status: models.QRISBillStatusOpen}
+		require.NoError(t, b.qrisRepo.CreateBill(ctx, otherBill))
+		assert.NotZero(t, otherBill.ID, "bill numbers are unique per account")
+
+		found, err := b.qrisRepo.GetBill(ctx, account.ID, "INV001")
+		require.NoError(t, err)
+		require.NotNil(t, found)
+		assert.Equal(t, bill.ID, found.ID)
+		assert.Equal(t, 15000.5, found.Nominal)
+		assert.Equal(t, models.QRISBillStatusOpen, found.Status)
+		assert.Nil(t, found.PaidAt)
+
+		missing, err := b.qrisRepo.GetBill(ctx, account.ID, "INV999")
+		require.NoError(t, err)
+		assert.Nil(t, missing)
+
+		err = b.txManager.WithinTx(ctx, func(ctx context.Context) error {
+			paid, err := b.qrisRepo.MarkBillPaid(ctx, bill.ID, time.Now())
+			require.NoError(t, err)
+			assert.True(t, paid)
+			return errors.New("rollback")
+		})
+		require.Error(t, err)
+
+		paid, err := b.qrisRepo.MarkBillPaid(ctx, bill.ID, time.Now())
+		require.NoError(t, err)
+		assert.True(t, paid, "a rolled back payment leaves the code open")
+		paid, err = b.qrisRepo.MarkBillPaid(ctx, bill.ID, time.Now())
+		require.NoError(t, err)
+		assert.False(t, paid, "a code is paid once")
+
+		found, err = b.qrisRepo.GetBill(ctx, account.ID, "INV001")
+		require.NoError(t, err)
+		assert.Equal(t, models.QRISBillStatusPaid, found.Status)
+		assert.NotNil(t, found.PaidAt)
+	})
+
+	t.Run("monthly statements resume from the accounts not generated", func(t *testing.T) {
+		b := newBackend(t)
+
+		accounts := []*models.Account{
+			newAccount("3201014508950001", "+6281234567890", "1744847261"),
+			newAccount("3201014508950002", "+6281234567891", "1744847262"),
+			newAccount("3201014508950003", "+6281234567892", "1744847263"),
+		}
+		for _, account := range accounts {
+			require.NoError(t, b.accountRepo.CreateAccount(ctx, account))
+		}
+		until := time.Now().Add(time.Hour)
+
+		pending, err := b.statementRepo.ListPendingAccounts(ctx, "2025-04", until, 0, 2)
+		require.NoError(t, err)
+		require.Len(t, pending, 2)
+		assert.Equal(t, accounts[0].ID, pending[0].ID)
+		assert.Equal(t, "1744847262", pending[1].NoRekening)
+
+		none, err := b.statementRepo.ListPendingAccounts(ctx, "2025-04", time.Now().Add(-time.Hour), 0, 10)
+		require.NoError(t, err)
+		assert.Empty(t, none, "accounts opened after the period are skipped")
+
+		generatedAt := time.Now()
+		generated := &models.MonthlyStatement{AccountID: accounts[0].ID, Period: "2025-04", Status: models.MonthlyStatementGenerated, BlobKey: "statements/2025-04/1744847261.pdf", Size: 1024, Checksum: "ab12", GeneratedAt: &generatedAt}
+		require.NoError(t, b.statementRepo.SaveStatement(ctx, generated))
+		assert.NotZero(t, generated.ID)
+		assert.Equal(t, 1, generated.Attempts)
+
+		failed := &models.MonthlyStatement{AccountID: accounts[1].ID, Period: "2025-04", Status: models.MonthlyStatementFailed, BlobKey: "statements/2025-04/1744847262.pdf", LastError: "disk full"}
+		require.NoError(t, b.statementRepo.SaveStatement(ctx, failed))
+
+		pending, err = b.statementRepo.ListPendingAccounts(ctx, "2025-04", until, 0, 10)
+		require.NoError(t, err)
+		require.Len(t, pending, 2, "failed statements are generated again")
+		assert.Equal(t, accounts[1].ID, pending[0].ID)
+		assert.Equal(t, accounts[2].ID, pending[1].ID)
+
+		pending, err = b.statementRepo.ListPendingAccounts(ctx, "2025-04", until, accounts[1].ID, 10)
+		require.NoError(t, err)
+		require.Len(t, pending, 1)
+
+		pending, err = b.statementRepo.ListPendingAccounts(ctx, "2025-05", until, 0, 10)
+		require.NoError(t, err)
+		assert.Len(t, pending, 3)
+
+		retriedAt := time.Now()
+		retried := &models.MonthlyStatement{AccountID: accounts[1].ID, Period: "2025-04", Status: models.MonthlyStatementGenerated, BlobKey: "statements/2025-04/1744847262.pdf", Size: 2048, Checksum: "cd34", GeneratedAt: &retriedAt}
+		require.NoError(t, b.statementRepo.SaveStatement(ctx, retried))
+		assert.Equal(t, failed.ID, retried.ID)
+		assert.Equal(t, 2, retried.Attempts)
+
+		require.NoError(t, b.statementRepo.MarkNotified(ctx, retried.ID, time.Now()))
+
+		found, err := b.statementRepo.GetStatement(ctx, accounts[1].ID, "2025-04")
+		require.NoError(t, err)
+		require.NotNil(t, found)
+		assert.Equal(t, "1744847262", found.NoRekening)
+		assert.Equal(t, models.MonthlyStatementGenerated, found.Status)
+		assert.Equal(t, int64(2048), found.Size)
+		assert.Equal(t, "cd34", found.Checksum)
+		assert.Empty(t, found.LastError)
+		assert.Equal(t, 2, found.Attempts)
+		require.NotNil(t, found.GeneratedAt)
+		assert.WithinDuration(t, retriedAt, *found.GeneratedAt, time.Second)
+		assert.NotNil(t, found.NotifiedAt)
+
+		missing, err := b.statementRepo.GetStatement(ctx, accounts[2].ID, "2025-04")
+		require.NoError(t, err)
+		assert.Nil(t, missing)
+
+		may := &models.MonthlyStatement{AccountID: accounts[0].ID, Period: "2025-05", Status: models.MonthlyStatementGenerated, BlobKey: "statements/2025-05/1744847261.pdf", GeneratedAt: &generatedAt}
+		require.NoError(t, b.statementRepo.SaveStatement(ctx, may))
+		listed, err := b.statementRepo.ListStatements(ctx, accounts[0].ID)
+		require.NoError(t, err)
+		require.Len(t, listed, 2)
+		assert.Equal(t, "2025-05", listed[0].Period)
+		assert.Equal(t, "2025-04", listed[1].Period)
+		assert.Nil(t, listed[1].NotifiedAt)
+	})
+
+	t.Run("bulk credit batches are recorded once and rows move one way", func(t *testing.T) {
+		b := newBackend(t)
+
+		source := newAccount("3201014508950001", "+6281234567890", "1744847261")
+		recipient := newAccount("3201014508950002", "+6281234567891", "1744847262")
+		require.NoError(t, b.accountRepo.CreateAccount(ctx, source))
+		require.NoError(t, b.accountRepo.CreateAccount(ctx, recipient))
+
+		newBatch := func() *models.BulkCreditBatch {
+			return &models.BulkCreditBatch{
+				Reference:       "PAYROLL-2025-04",
+				SourceAccountID: source.ID,
+				Mode:            models.BulkCreditModePerRow,
+				Status:          models.BulkCreditProcessing,
+				Rows: []models.BulkCreditRow{
+					{Line: 1, NoRekening: "1744847262", AccountID: recipient.ID, Nominal: 2500000.5, Reference: "GAJI-001", Status: models.BulkCreditRowPending},
+					{Line: 2, NoRekening: "abc", Nominal: 100, Status: models.BulkCreditRowRejected, ErrorCode: models.AccountNoRekeningInvalid},
+				},
+			}
+		}
+
+		batch := newBatch()
+		require.NoError(t, b.txManager.WithinTx(ctx, func(ctx context.Context) error {
+			return b.bulkCreditRepo.CreateBatch(ctx, batch)
+		}))
+		assert.NotZero(t, batch.ID)
+		assert.NotZero(t, batch.Rows[1].ID)
+
+		again := newBatch()
+		require.NoError(t, b.bulkCreditRepo.CreateBatch(ctx, again))
+		assert.Zero(t, again.ID, "a reference is used once")
+
+		processing, err := b.bulkCreditRepo.ListProcessingBatches(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"PAYROLL-2025-04"}, processing)
+
+		updated, err := b.bulkCreditRepo.UpdateRowStatus(ctx, batch.Rows[0].ID, models.BulkCreditRowPending, models.BulkCreditRowCredited, "")
+		require.NoError(t, err)
+		assert.True(t, updated)
+		updated, err = b.bulkCreditRepo.UpdateRowStatus(ctx, batch.Rows[0].ID, models.BulkCreditRowPending, models.BulkCreditRowFailed, models.InternalServerError)
+		require.NoError(t, err)
+		assert.False(t, updated, "a credited row stays credited")
+
+		completedAt := time.Now()
+		batch.Status = models.BulkCreditPartial
+		batch.CreditedRows = 1
+		batch.CreditedNominal = 2500000.5
+		batch.CompletedAt = &completedAt
+		finished, err := b.bulkCreditRepo.FinishBatch(ctx, batch)
+		require.NoError(t, err)
+		assert.True(t, finished)
+		finished, err = b.bulkCreditRepo.FinishBatch(ctx, batch)
+		require.NoError(t, err)
+		assert.False(t, finished, "a batch is finished once")
+
+		found, err := b.bulkCreditRepo.GetBatch(ctx, "PAYROLL-2025-04")
+		require.NoError(t, err)
+		require.NotNil(t, found)
+		assert.Equal(t, "1744847261", found.SourceNoRekening)
+		assert.Equal(t, models.BulkCreditPartial, found.Status)
+		assert.Equal(t, 2, found.TotalRows)
+		assert.Equal(t, 1, found.CreditedRows)
+		assert.Equal(t, 2500000.5, found.CreditedNominal)
+		require.NotNil(t, found.CompletedAt)
+		assert.WithinDuration(t, completedAt, *found.CompletedAt, time.Second)
+		require.Len(t, found.Rows, 2)
+		assert.Equal(t, models.BulkCreditRow{ID: batch.Rows[0].ID, BatchID: batch.ID, Line: 1, NoRekening: "1744847262", AccountID: recipient.ID, Nominal: 2500000.5, Reference: "GAJI-001", Status: models.BulkCreditRowCredited}, found.Rows[0])
+		assert.Equal(t, models.AccountNoRekeningInvalid, found.Rows[1].ErrorCode)
+		assert.Zero(t, found.Rows[1].AccountID)
+
+		processing, err = b.bulkCreditRepo.ListProcessingBatches(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, processing)
+
+		missing, err := b.bulkCreditRepo.GetBatch(ctx, "PAYROLL-2025-05")
+		require.NoError(t, err)
+		assert.Nil(t, missing)
+	})
+
+	t.Run("external IDs are claimed once per partner and day", func(t *testing.T) {
+		b := newBackend(t)
+
+		claimed, err := b.externalIDRepo.ClaimExternalID(ctx, "FINTECH01", "2025-04-27", "41807553358950093184162180797837")
+		require.NoError(t, err)
+		assert.True(t, claimed)
+
+		claimed, err = b.externalIDRepo.ClaimExternalID(ctx, "FINTECH01", "2025-04-27", "41807553358950093184162180797837")
+		require.NoError(t, err)
+		assert.False(t, claimed, "used again the same day")
+
+		claimed, err = b.externalIDRepo.ClaimExternalID(ctx, "FINTECH01", "2025-04-28", "41807553358950093184162180797837")
+		require.NoError(t, err)
+		assert.True(t, claimed, "used again the next day")
+
+		claimed, err = b.externalIDRepo.ClaimExternalID(ctx, "FINTECH02", "2025-04-27", "41807553358950093184162180797837")
+		require.NoError(t, err)
+		assert.True(t, claimed, "used by another partner")
+
+		err = b.txManager.WithinTx(ctx, func(ctx context.Context) error {
+			claimed, err := b.externalIDRepo.ClaimExternalID(ctx, "FINTECH01", "2025-04-27", "1")
+			require.NoError(t, err)
+			require.True(t, claimed)
+			return errRollback
+		})
+		require.ErrorIs(t, err, errRollback)
+		claimed, err = b.externalIDRepo.ClaimExternalID(ctx, "FINTECH01", "2025-04-27", "1")
+		require.NoError(t, err)
+		assert.True(t, claimed, "a rolled back claim is discarded")
+	})
+
+	t.Run("virtual accounts are issued once and closed once", func(t *testing.T) {
+		b := newBackend(t)
+
+		account := newAccount("3201014508950001", "+6281234567890", "1744847261")
+		require.NoError(t, b.accountRepo.CreateAccount(ctx, account))
+
+		expiresAt := time.Now().Add(24 * time.Hour)
+		newVirtualAccount := func() *models.VirtualAccount {
+			return &models.VirtualAccount{
+				Number:    "8808000000000001",
+				AccountID: account.ID,
+				Name:      "PLN 512345678901",
+				Nominal:   250000.5,
+				SingleUse: true,
+				ExpiresAt: &expiresAt,
+			}
+		}
+
+		virtualAccount := newVirtualAccount()
+		require.NoError(t, b.virtualAccountRepo.CreateVirtualAccount(ctx, virtualAccount))
+		assert.NotZero(t, virtualAccount.ID)
+		assert.Equal(t, models.VirtualAccountStatusOpen, virtualAccount.Status)
+
+		again := newVirtualAccount()
+		require.NoError(t, b.virtualAccountRepo.CreateVirtualAccount(ctx, again))
+		assert.Zero(t, again.ID, "a VA number is issued once")
+
+		open := &models.VirtualAccount{Number: "8808000000000002", AccountID: account.ID, Name: "Siti"}
+		require.NoError(t, b.virtualAccountRepo.CreateVirtualAccount(ctx, open))
+
+		found, err := b.virtualAccountRepo.GetVirtualAccount(ctx, "8808000000000001")
+		require.NoError(t, err)
+		require.NotNil(t, found)
+		assert.Equal(t, virtualAccount.ID, found.ID)
+		assert.Equal(t, "1744847261", found.NoRekening)
+		assert.Equal(t, "PLN 512345678901", found.Name)
+		assert.Equal(t, 250000.5, found.Nominal)
+		assert.True(t, found.SingleUse)
+		require.NotNil(t, found.ExpiresAt)
+		assert.WithinDuration(t, expiresAt, *found.ExpiresAt, time.Second)
+		assert.Nil(t, found.ClosedAt)
+
+		closedAt := time.Now()
+		closed, err := b.virtualAccountRepo.CloseVirtualAccount(ctx, virtualAccount.ID, closedAt)
+		require.NoError(t, err)
+		assert.True(t, closed)
+		closed, err = b.virtualAccountRepo.CloseVirtualAccount(ctx, virtualAccount.ID, closedAt)
+		require.NoError(t, err)
+		assert.False(t, closed, "a virtual account is closed once")
+
+		found, err = b.virtualAccountRepo.GetVirtualAccount(ctx, "8808000000000001")
+		require.NoError(t, err)
+		assert.Equal(t, models.VirtualAccountStatusClosed, found.Status)
+		require.NotNil(t, found.ClosedAt)
+		assert.WithinDuration(t, closedAt, *found.ClosedAt, time.Second)
+
+		found, err = b.virtualAccountRepo.GetVirtualAccount(ctx, "8808000000000002")
+		require.NoError(t, err)
+		assert.Equal(t, models.VirtualAccountStatusOpen, found.Status)
+		assert.Zero(t, found.Nominal)
+		assert.Nil(t, found.ExpiresAt)
+
+		missing, err := b.virtualAccountRepo.GetVirtualAccount(ctx, "8808000000000003")
+		require.NoError(t, err)
+		assert.Nil(t, missing)
+	})
+
+	t.Run("interbank transfers are sent in one batch and completed once", func(t *testing.T) {
+		b := newBackend(t)
+
+		account := newAccount("3201014508950001", "+6281234567890", "1744847261")
+		require.NoError(t, b.accountRepo.CreateAccount(ctx, account))
+
+		newTransfer := func(reference string) *models.InterbankTransfer {
+			return &models.InterbankTransfer{
+				SourceAccountID:    account.ID,
+				BankCode:           "014",
+				BeneficiaryAccount: "1234567890",
+				BeneficiaryName:    "BUDI SANTOSO",
+				Nominal:            1500000.5,
+				Reference:          reference,
+			}
+		}
+
+		first := newTransfer("INV-1")
+		first.Description = "SEWA APRIL"
+		require.NoError(t, b.interbankRepo.CreateTransfer(ctx, first))
+		assert.NotZero(t, first.ID)
+		assert.Equal(t, models.InterbankTransferID(first.ID), first.TransferID)
+		assert.Equal(t, models.InterbankQueued, first.Status)
+		second := newTransfer("INV-2")
+		require.NoError(t, b.interbankRepo.CreateTransfer(ctx, second))
+
+		queued, err := b.interbankRepo.ListQueuedTransfers(ctx, time.Now().Add(time.Second))
+		require.NoError(t, err)
+		require.Len(t, queued, 2)
+		assert.Equal(t, first.ID, queued[0].ID)
+		assert.Equal(t, "1744847261", queued[0].SourceNoRekening)
+		assert.Equal(t, "SEWA APRIL", queued[0].Description)
+		assert.Equal(t, 1500000.5, queued[0].Nominal)
+		assert.Equal(t, second.ID, queued[1].ID)
+
+		queued, err = b.interbankRepo.ListQueuedTransfers(ctx, time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		assert.Empty(t, queued, "queued after the cut-off")
+
+		cutoffAt := time.Date(2025, 4, 28, 2, 0, 0, 0, time.UTC)
+		newBatch := func() *models.InterbankBatch {
+			return &models.InterbankBatch{
+				Reference:     "KLR202504280900",
+				CutoffAt:      cutoffAt,
+				BlobKey:       "clearing/2025-04-28/KLR202504280900.txt",
+				TransferCount: 1,
+				TotalNominal:  1500000.5,
+			}
+		}
+		batch := newBatch()
+		require.NoError(t, b.interbankRepo.CreateBatch(ctx, batch))
+		assert.NotZero(t, batch.ID)
+		again := newBatch()
+		require.NoError(t, b.interbankRepo.CreateBatch(ctx, again))
+		assert.Zero(t, again.ID, "a window is cut once")
+
+		sent, err := b.interbankRepo.SendTransfer(ctx, first.ID, batch.ID)
+		require.NoError(t, err)
+		assert.True(t, sent)
+		sent, err = b.interbankRepo.SendTransfer(ctx, first.ID, batch.ID)
+		require.NoError(t, err)
+		assert.False(t, sent, "a transfer is sent once")
+
+		unwritten, err := b.interbankRepo.ListUnwrittenBatches(ctx)
+		require.NoError(t, err)
+		require.Len(t, unwritten, 1)
+		assert.Equal(t, batch.ID, unwritten[0].ID)
+		assert.Equal(t, "KLR202504280900", unwritten[0].Reference)
+		assert.True(t, cutoffAt.Equal(unwritten[0].CutoffAt))
+		assert.Equal(t, "clearing/2025-04-28/KLR202504280900.txt", unwritten[0].BlobKey)
+		assert.Equal(t, 1, unwritten[0].TransferCount)
+		assert.Equal(t, 1500000.5, unwritten[0].TotalNominal)
+
+		batchTransfers, err := b.interbankRepo.ListBatchTransfers(ctx, batch.ID)
+		require.NoError(t, err)
+		require.Len(t, batchTransfers, 1)
+		assert.Equal(t, first.ID, batchTransfers[0].ID)
+		assert.Equal(t, "KLR202504280900", batchTransfers[0].BatchReference)
+
+		require.NoError(t, b.interbankRepo.MarkBatchWritten(ctx, batch.ID, time.Now()))
+		unwritten, err = b.interbankRepo.ListUnwrittenBatches(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, unwritten)
+
+		queued, err = b.interbankRepo.ListQueuedTransfers(ctx, time.Now().Add(time.Second))
+		require.NoError(t, err)
+		require.Len(t, queued, 1)
+		assert.Equal(t, second.ID, queued[0].ID)
+
+		completed, err := b.interbankRepo.CompleteTransfer(ctx, second.ID, models.InterbankSettled, "", time.Now())
+		require.NoError(t, err)
+		assert.False(t, completed, "a queued transfer is not completed")
+
+		completedAt := time.Now()
+		completed, err = b.interbankRepo.CompleteTransfer(ctx, first.ID, models.InterbankRejected, "R03", completedAt)
+		require.NoError(t, err)
+		assert.True(t, completed)
+		completed, err = b.interbankRepo.CompleteTransfer(ctx, first.ID, models.InterbankSettled, "", completedAt)
+		require.NoError(t, err)
+		assert.False(t, completed, "a transfer is completed once")
+
+		found, err := b.interbankRepo.GetTransfer(ctx, first.ID)
+		require.NoError(t, err)
+		require.NotNil(t, found)
+		assert.Equal(t, first.TransferID, found.TransferID)
+		assert.Equal(t, models.InterbankRejected, found.Status)
+		assert.Equal(t, batch.ID, found.BatchID)
+		assert.Equal(t, "KLR202504280900", found.BatchReference)
+		assert.Equal(t, "R03", found.ReturnReason)
+		require.NotNil(t, found.CompletedAt)
+		assert.WithinDuration(t, completedAt, *found.CompletedAt, time.Second)
+
+		found, err = b.interbankRepo.GetTransfer(ctx, second.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.InterbankQueued, found.Status)
+		assert.Empty(t, found.BatchReference)
+		assert.Empty(t, found.Description)
+		assert.Nil(t, found.CompletedAt)
+
+		missing, err := b.interbankRepo.GetTransfer(ctx, second.ID+1)
+		require.NoError(t, err)
+		assert.Nil(t, missing)
+	})
+
+	t.Run("outbox delivers the oldest pending event per aggregate", func(t *testing.T) {
+		b := newBackend(t)
+		now := time.Now()
+
+		var ids []uint
+		for _, key := range []string{"1000000001", "1000000001", "1000000002"} {
+			event := &models.OutboxEvent{AggregateKey: key, EventType: models.EventMutationCreated, Payload: []byte(`{"nominal":1000}`)}
+			require.NoError(t, b.outboxRepo.CreateEvent(ctx, event))
+			assert.NotZero(t, event.ID)
+			ids = append(ids, event.ID)
+		}
+
+		events, err := b.outboxRepo.ListDeliverable(ctx, now.Add(time.Second), 10)
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		assert.Equal(t, ids[0], events[0].ID)
+		assert.Equal(t, ids[2], events[1].ID)
+		assert.JSONEq(t, `{"nominal":1000}`, string(events[0].Payload))
+
+		// A failed event holds back its aggregate until it is due again
+		require.NoError(t, b.outboxRepo.MarkFailed(ctx, ids[0], "timeout", now.Add(time.Minute), false))
+		require.NoError(t, b.outboxRepo.MarkPublished(ctx, ids[2], now))
+		events, err = b.outboxRepo.ListDeliverable(ctx, now.Add(time.Second), 10)
+		require.NoError(t, err)
+		assert.Empty(t, events)
+
+		events, err = b.outboxRepo.ListDeliverable(ctx, now.Add(2*time.Minute), 10)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, ids[0], events[0].ID)
+		assert.Equal(t, 1, events[0].Attempts)
+		assert.Equal(t, "timeout", events[0].LastError)
+
+		// A dead-lettered event releases the next one
+		require.NoError(t, b.outboxRepo.MarkFailed(ctx, ids[0], "timeout", now, true))
+		events, err = b.outboxRepo.ListDeliverable(ctx, now.Add(time.Second), 10)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, ids[1], events[0].ID)
+	})
+
+	t.Run("rollback discards outbox events", func(t *testing.T) {
+		b := newBackend(t)
+
+		err := b.txManager.WithinTx(ctx, func(ctx context.Context) error {
+			require.NoError(t, b.outboxRepo.CreateEvent(ctx, &models.OutboxEvent{AggregateKey: "1000000001", EventType: models.EventMutationCreated, Payload: []byte(`{}`)}))
+			return errRollback
+		})
+		assert.ErrorIs(t, err, errRollback)
+
+		events, err := b.outboxRepo.ListDeliverable(ctx, time.Now().Add(time.Second), 10)
+		require.NoError(t, err)
+		assert.Empty(t, events)
+	})
+
+	t.Run("webhook registry", func(t *testing.T) {
+		b := newBackend(t)
+
+		webhook := &models.Webhook{URL: "https://partner.example/hooks", EventTypes: []string{models.EventMutationCreated}, NoRekening: "1744847261", Secret: "0123456789abcdef", Active: true}
+		require.NoError(t, b.webhookRepo.CreateWebhook(ctx, webhook))
+		require.NotZero(t, webhook.ID)
+
+		found, err := b.webhookRepo.GetWebhook(ctx, webhook.ID)
+		require.NoError(t, err)
+		require.NotNil(t, found)
+		assert.Equal(t, webhook.URL, found.URL)
+		assert.Equal(t, webhook.EventTypes, found.EventTypes)
+		assert.Equal(t, webhook.Secret, found.Secret)
+		assert.True(t, found.Active)
+
+		found.URL = "https://partner.example/v2/hooks"
+		found.NoRekening = ""
+		found.Active = false
+		require.NoError(t, b.webhookRepo.UpdateWebhook(ctx, found))
+
+		webhooks, err := b.webhookRepo.ListWebhooks(ctx)
+		require.NoError(t, err)
+		require.Len(t, webhooks, 1)
+		assert.Equal(t, "https://partner.example/v2/hooks", webhooks[0].URL)
+		assert.Empty(t, webhooks[0].NoRekening)
+		assert.False(t, webhooks[0].Active)
+
+		missing, err := b.webhookRepo.GetWebhook(ctx, webhook.ID+1)
+		require.NoError(t, err)
+		assert.Nil(t, missing)
+	})
+
+	t.Run("webhook deliveries record attempts and redeliver", func(t *testing.T) {
+		b := newBackend(t)
+		now := time.Now()
+
+		webhook := &models.Webhook{URL: "https://partner.example/hooks", EventTypes: []string{models.WebhookAllEvents}, Secret: "0123456789abcdef", Active: true}
+		require.NoError(t, b.webhookRepo.CreateWebhook(ctx, webhook))
+
+		delivery := &models.WebhookDelivery{WebhookID: webhook.ID, EventID: 1, EventType: models.EventMutationCreated, Body: []byte(`{"event_id":1}`), NextAttemptAt: now}
+		require.NoError(t, b.webhookRepo.CreateDelivery(ctx, delivery))
+		require.NotZero(t, delivery.ID)
+
+		duplicate := &models.WebhookDelivery{WebhookID: webhook.ID, EventID: 1, EventType: models.EventMutationCreated, Body: []byte(`{"event_id":1}`), NextAttemptAt: now}
+		require.NoError(t, b.webhookRepo.CreateDelivery(ctx, duplicate))
+		assert.Zero(t, duplicate.ID)
+
+		due, err := b.webhookRepo.ListDueDeliveries(ctx, now.Add(time.Second), 10)
+		require.NoError(t, err)
+		require.Len(t, due, 1)
+		assert.JSONEq(t, `{"event_id":1}`, string(due[0].Body))
+
+		require.NoError(t, b.webhookRepo.RecordAttempt(ctx, &models.WebhookAttempt{DeliveryID: delivery.ID, StatusCode: 500, Error: "status 500"}, models.WebhookDeliveryPending, now.Add(time.Minute)))
+		due, err = b.webhookRepo.ListDueDeliveries(ctx, now.Add(time.Second), 10)
+		require.NoError(t, err)
+		assert.Empty(t, due, "delivery backs off until its next attempt")
+
+		require.NoError(t, b.webhookRepo.RecordAttempt(ctx, &models.WebhookAttempt{DeliveryID: delivery.ID, StatusCode: 200}, models.WebhookDeliveryDelivered, now))
+		found, err := b.webhookRepo.GetDelivery(ctx, webhook.ID, delivery.ID)
+		require.NoError(t, err)
+		require.NotNil(t, found)
+		assert.Equal(t, models.WebhookDeliveryDelivered, found.Status)
+		assert.Equal(t, 2, found.Attempts)
+		assert.Equal(t, 200, found.LastStatusCode)
+		assert.NotNil(t, found.DeliveredAt)
+		require.Len(t, found.Log, 2)
+		assert.Equal(t, 500, found.Log[0].StatusCode)
+		assert.Equal(t, "status 500", found.Log[0].Error)
+
+		delivered, err := b.webhookRepo.ListDeliveries(ctx, webhook.ID, models.WebhookDeliveryDelivered, 10)
+		require.NoError(t, err)
+		assert.Len(t, delivered, 1)
+
+		require.NoError(t, b.webhookRepo.Redeliver(ctx, delivery.ID, now))
+		due, err = b.webhookRepo.ListDueDeliveries(ctx, now.Add(time.Second), 10)
+		require.NoError(t, err)
+		require.Len(t, due, 1)
+		assert.Zero(t, due[0].Attempts)
+		assert.Nil(t, due[0].DeliveredAt)
+
+		require.NoError(t, b.webhookRepo.DeleteWebhook(ctx, webhook.ID))
+		found, err = b.webhookRepo.GetDelivery(ctx, webhook.ID, delivery.ID)
+		require.NoError(t, err)
+		assert.Nil(t, found)
+	})
+
+	t.Run("notification preferences are replaced per account", func(t *testing.T) {
+		b := newBackend(t)
+
+		account := newAccount("3201014508950001", "+6281234567890", "1744847261")
+		require.NoError(t, b.accountRepo.CreateAccount(ctx, account))
+
+		missing, err := b.preferenceRepo.GetPreference(ctx, account.ID)
+		require.NoError(t, err)
+		assert.Nil(t, missing)
+
+		preference := &models.NotificationPreference{AccountID: account.ID, Channels: []string{models.NotificationChannelSMS, models.NotificationChannelEmail}, Email: "siti@example.com", LowBalanceThreshold: 100000.5}
+		require.NoError(t, b.preferenceRepo.SavePreference(ctx, preference))
+		assert.False(t, preference.UpdatedAt.IsZero())
+
+		found, err := b.preferenceRepo.GetPreference(ctx, account.ID)
+		require.NoError(t, err)
+		require.NotNil(t, found)
+		assert.Equal(t, "1744847261", found.NoRekening)
+		assert.Equal(t, []string{models.NotificationChannelSMS, models.NotificationChannelEmail}, found.Channels)
+		assert.Equal(t, "siti@example.com", found.Email)
+		assert.Equal(t, 100000.5, found.LowBalanceThreshold)
+
+		require.NoError(t, b.preferenceRepo.SavePreference(ctx, &models.NotificationPreference{AccountID: account.ID, Channels: []string{}}))
+		found, err = b.preferenceRepo.GetPreference(ctx, account.ID)
+		require.NoError(t, err)
+		assert.Empty(t, found.Channels)
+		assert.Empty(t, found.Email)
+		assert.Zero(t, found.LowBalanceThreshold)
+
+		err = b.preferenceRepo.SavePreference(ctx, &models.NotificationPreference{AccountID: account.ID + 1, Channels: []string{}})
+		assert.ErrorIs(t, err, models.NotificationDBErr, "preference requires an existing account")
+	})
+}
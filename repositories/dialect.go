@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// Dialect is the SQL flavour spoken by a repository. Queries are written with
+// Postgres $N placeholders and rewritten for the other dialects.
+type Dialect int
+
+const (
+	DialectPostgres Dialect = iota
+	DialectSQLite
+)
+
+var placeholder = regexp.MustCompile(`\$\d+`)
+
+// rebind converts $N placeholders to the dialect. The arguments of every
+// query are numbered in order, so positional ? placeholders are equivalent.
+func (d Dialect) rebind(query string) string {
+	if d == DialectSQLite {
+		return placeholder.ReplaceAllString(query, "?")
+	}
+	return query
+}
+
+// forUpdate returns the row locking clause. SQLite has none, its
+// transactions take the database write lock when they begin instead.
+func (d Dialect) forUpdate() string {
+	if d == DialectSQLite {
+		return ""
+	}
+	return "FOR UPDATE"
+}
+
+// now returns the current time as of the statement rather than the start
+// of the transaction, so that updated_at follows the order in which postings
+// acquire the row lock and can serve as the version of the row.
+func (d Dialect) now() string {
+	if d == DialectSQLite {
+		return "strftime('%Y-%m-%d %H:%M:%f', 'now')"
+	}
+	return "clock_timestamp()"
+}
+
+// timestamp returns t as a query argument comparable with the timestamp
+// columns. SQLite compares the text it stores them as, so t is formatted
+// like CURRENT_TIMESTAMP, in UTC and to the second.
+func (d Dialect) timestamp(t time.Time) interface{} {
+	if d == DialectSQLite {
+		return t.UTC().Format("2006-01-02 15:04:05")
+	}
+	return t.UTC()
+}
+
+// sqliteTimeLayouts are the formats SQLite stores timestamps in.
+var sqliteTimeLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02T15:04:05Z07:00",
+	time.RFC3339Nano,
+}
+
+// timeScanner scans timestamps returned as time.Time (Postgres, declared
+// SQLite columns) or as text (SQLite RETURNING and expressions).
+type timeScanner struct {
+	t *time.Time
+}
+
+func scanTime(t *time.Time) *timeScanner {
+	return &timeScanner{t: t}
+}
+
+func (s *timeScanner) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*s.t = time.Time{}
+		return nil
+	case time.Time:
+		*s.t = v
+		return nil
+	case []byte:
+		return s.parse(string(v))
+	case string:
+		return s.parse(v)
+	default:
+		return fmt.Errorf("cannot scan %T into time.Time", src)
+	}
+}
+
+func (s *timeScanner) parse(value string) error {
+	for _, layout := range sqliteTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			*s.t = t
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot parse %q as time", value)
+}
@@ -0,0 +1,58 @@
+package repositories
+
+import (
+	"accounts-service/models"
+	"accounts-service/utils"
+	"context"
+	"database/sql"
+)
+
+type ExternalIDRepository interface {
+	// ClaimExternalID records the external ID a partner sent on a day, as
+	// YYYY-MM-DD. It reports false and records nothing when the partner
+	// already sent it that day.
+	ClaimExternalID(ctx context.Context, partnerID, day, externalID string) (bool, error)
+}
+
+type externalIDRepository struct {
+	db      *sql.DB
+	dialect Dialect
+	logger  utils.Logger
+}
+
+func NewExternalIDRepository(db *sql.DB, logger utils.Logger) ExternalIDRepository {
+	return &externalIDRepository{
+		db:      db,
+		dialect: DialectPostgres,
+		logger:  logger,
+	}
+}
+
+func NewSQLiteExternalIDRepository(db *sql.DB, logger utils.Logger) ExternalIDRepository {
+	return &externalIDRepository{
+		db:      db,
+		dialect: DialectSQLite,
+		logger:  logger,
+	}
+}
+
+func (r *externalIDRepository) ClaimExternalID(ctx context.Context, partnerID, day, externalID string) (bool, error) {
+	query := `
+		INSERT INTO external_ids (partner_id, day, external_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (partner_id, day, external_id) DO NOTHING
+	`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, r.dialect.rebind(query), partnerID, day, externalID)
+	if err != nil {
+		r.logger.Error("Error claiming external ID: %v", err)
+		return false, models.ExternalIDDBErr.Wrap(err)
+	}
+	claimed, err := result.RowsAffected()
+	if err != nil {
+		r.logger.Error("Error claiming external ID: %v", err)
+		return false, models.ExternalIDDBErr.Wrap(err)
+	}
+
+	return claimed == 1, nil
+}
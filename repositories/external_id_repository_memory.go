@@ -0,0 +1,33 @@
+package repositories
+
+import (
+	"accounts-service/utils"
+	"context"
+)
+
+type memoryExternalIDRepository struct {
+	store  *MemoryStore
+	logger utils.Logger
+}
+
+func NewMemoryExternalIDRepository(store *MemoryStore, logger utils.Logger) ExternalIDRepository {
+	return &memoryExternalIDRepository{
+		store:  store,
+		logger: logger,
+	}
+}
+
+func (r *memoryExternalIDRepository) ClaimExternalID(ctx context.Context, partnerID, day, externalID string) (bool, error) {
+	key := externalIDKey{partnerID: partnerID, day: day, externalID: externalID}
+
+	var claimed bool
+	err := r.store.write(ctx, func(state *memoryState) error {
+		if state.externalIDs[key] {
+			return nil
+		}
+		state.externalIDs[key] = true
+		claimed = true
+		return nil
+	})
+	return claimed, err
+}
@@ -0,0 +1,292 @@
+package repositories
+
+import (
+	"accounts-service/models"
+	"accounts-service/utils"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type InterbankRepository interface {
+	// CreateTransfer records a queued transfer.
+	CreateTransfer(ctx context.Context, transfer *models.InterbankTransfer) error
+	// GetTransfer returns a transfer with the no_rekening of its source and
+	// the reference of its batch, or nil when it does not exist.
+	GetTransfer(ctx context.Context, id uint) (*models.InterbankTransfer, error)
+	// ListQueuedTransfers returns the transfers queued before until, oldest
+	// first.
+	ListQueuedTransfers(ctx context.Context, until time.Time) ([]models.InterbankTransfer, error)
+	// CreateBatch records a clearing batch without its transfers. It does
+	// nothing and leaves batch.ID zero when the reference is already used.
+	CreateBatch(ctx context.Context, batch *models.InterbankBatch) error
+	// SendTransfer moves a queued transfer to sent in a batch. It reports
+	// false and changes nothing when the transfer is no longer queued.
+	SendTransfer(ctx context.Context, id, batchID uint) (bool, error)
+	// ListUnwrittenBatches returns the batches whose file is not written,
+	// oldest first, without their transfers.
+	ListUnwrittenBatches(ctx context.Context) ([]models.InterbankBatch, error)
+	// ListBatchTransfers returns the transfers sent in a batch, in the
+	// order of its file.
+	ListBatchTransfers(ctx context.Context, batchID uint) ([]models.InterbankTransfer, error)
+	// MarkBatchWritten records that the file of a batch is written.
+	MarkBatchWritten(ctx context.Context, id uint, writtenAt time.Time) error
+	// CompleteTransfer moves a sent transfer to settled or rejected at
+	// completedAt. It reports false and changes nothing when the transfer
+	// is no longer sent, so a settlement is only posted once.
+	CompleteTransfer(ctx context.Context, id uint, status, returnReason string, completedAt time.Time) (bool, error)
+}
+
+type interbankRepository struct {
+	db      *sql.DB
+	dialect Dialect
+	logger  utils.Logger
+}
+
+func NewInterbankRepository(db *sql.DB, logger utils.Logger) InterbankRepository {
+	return &interbankRepository{
+		db:      db,
+		dialect: DialectPostgres,
+		logger:  logger,
+	}
+}
+
+func NewSQLiteInterbankRepository(db *sql.DB, logger utils.Logger) InterbankRepository {
+	return &interbankRepository{
+		db:      db,
+		dialect: DialectSQLite,
+		logger:  logger,
+	}
+}
+
+const interbankTransferColumns = `t.id, t.source_account_id, a.no_rekening, t.bank_code, t.beneficiary_account,
+	t.beneficiary_name, t.nominal, t.reference, COALESCE(t.description, ''), t.status, COALESCE(t.batch_id, 0),
+	COALESCE(b.reference, ''), COALESCE(t.return_reason, ''), t.created_at, t.completed_at`
+
+const interbankTransferTables = `interbank_transfers t
+	JOIN accounts a ON a.id = t.source_account_id
+	LEFT JOIN interbank_batches b ON b.id = t.batch_id`
+
+func scanInterbankTransfer(row rowScanner) (*models.InterbankTransfer, error) {
+	var (
+		transfer    models.InterbankTransfer
+		completedAt time.Time
+	)
+	err := row.Scan(
+		&transfer.ID,
+		&transfer.SourceAccountID,
+		&transfer.SourceNoRekening,
+		&transfer.BankCode,
+		&transfer.BeneficiaryAccount,
+		&transfer.BeneficiaryName,
+		&transfer.Nominal,
+		&transfer.Reference,
+		&transfer.Description,
+		&transfer.Status,
+		&transfer.BatchID,
+		&transfer.BatchReference,
+		&transfer.ReturnReason,
+		scanTime(&transfer.CreatedAt),
+		scanTime(&completedAt),
+	)
+	if err != nil {
+		return nil, err
+	}
+	transfer.TransferID = models.InterbankTransferID(transfer.ID)
+	if !completedAt.IsZero() {
+		transfer.CompletedAt = &completedAt
+	}
+	return &transfer, nil
+}
+
+func (r *interbankRepository) fail(action string, err error) error {
+	r.logger.Error("Error %s: %v", action, err)
+	return models.InterbankDBErr.Wrap(err)
+}
+
+func (r *interbankRepository) CreateTransfer(ctx context.Context, transfer *models.InterbankTransfer) error {
+	query := `
+		INSERT INTO interbank_transfers (source_account_id, bank_code, beneficiary_account, beneficiary_name, nominal, reference, description, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`
+
+	transfer.Status = models.InterbankQueued
+	err := conn(ctx, r.db).QueryRowContext(ctx, r.dialect.rebind(query),
+		transfer.SourceAccountID,
+		transfer.BankCode,
+		transfer.BeneficiaryAccount,
+		transfer.BeneficiaryName,
+		transfer.Nominal,
+		transfer.Reference,
+		nullString(transfer.Description),
+		transfer.Status,
+	).Scan(&transfer.ID, scanTime(&transfer.CreatedAt))
+	if err != nil {
+		return r.fail("creating interbank transfer", err)
+	}
+	transfer.TransferID = models.InterbankTransferID(transfer.ID)
+
+	return nil
+}
+
+func (r *interbankRepository) GetTransfer(ctx context.Context, id uint) (*models.InterbankTransfer, error) {
+	query := `SELECT ` + interbankTransferColumns + ` FROM ` + interbankTransferTables + ` WHERE t.id = $1`
+
+	transfer, err := scanInterbankTransfer(conn(ctx, r.db).QueryRowContext(ctx, r.dialect.rebind(query), id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, r.fail("getting interbank transfer", err)
+	}
+
+	return transfer, nil
+}
+
+func (r *interbankRepository) ListQueuedTransfers(ctx context.Context, until time.Time) ([]models.InterbankTransfer, error) {
+	transfers, err := r.listTransfers(ctx, `t.status = $1 AND t.created_at < $2`, models.InterbankQueued, r.dialect.timestamp(until))
+	if err != nil {
+		return nil, r.fail("listing queued interbank transfers", err)
+	}
+	return transfers, nil
+}
+
+func (r *interbankRepository) ListBatchTransfers(ctx context.Context, batchID uint) ([]models.InterbankTransfer, error) {
+	transfers, err := r.listTransfers(ctx, `t.batch_id = $1`, batchID)
+	if err != nil {
+		return nil, r.fail("listing interbank batch transfers", err)
+	}
+	return transfers, nil
+}
+
+// listTransfers returns the transfers matching where, oldest first.
+func (r *interbankRepository) listTransfers(ctx context.Context, where string, args ...interface{}) ([]models.InterbankTransfer, error) {
+	query := `
+		SELECT ` + interbankTransferColumns + `
+		FROM ` + interbankTransferTables + `
+		WHERE ` + where + `
+		ORDER BY t.id
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, r.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transfers := []models.InterbankTransfer{}
+	for rows.Next() {
+		transfer, err := scanInterbankTransfer(rows)
+		if err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, *transfer)
+	}
+	return transfers, rows.Err()
+}
+
+func (r *interbankRepository) CreateBatch(ctx context.Context, batch *models.InterbankBatch) error {
+	query := `
+		INSERT INTO interbank_batches (reference, cutoff_at, blob_key, transfer_count, total_nominal)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (reference) DO NOTHING
+		RETURNING id, created_at
+	`
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, r.dialect.rebind(query),
+		batch.Reference,
+		batch.CutoffAt.UTC(),
+		batch.BlobKey,
+		batch.TransferCount,
+		batch.TotalNominal,
+	).Scan(&batch.ID, scanTime(&batch.CreatedAt))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return r.fail("creating interbank batch", err)
+	}
+
+	return nil
+}
+
+func (r *interbankRepository) SendTransfer(ctx context.Context, id, batchID uint) (bool, error) {
+	query := `UPDATE interbank_transfers SET status = $1, batch_id = $2 WHERE id = $3 AND status = $4`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, r.dialect.rebind(query), models.InterbankSent, batchID, id, models.InterbankQueued)
+	if err != nil {
+		return false, r.fail("sending interbank transfer", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, r.fail("sending interbank transfer", err)
+	}
+
+	return affected == 1, nil
+}
+
+func (r *interbankRepository) ListUnwrittenBatches(ctx context.Context) ([]models.InterbankBatch, error) {
+	query := `
+		SELECT id, reference, cutoff_at, blob_key, transfer_count, total_nominal, created_at
+		FROM interbank_batches
+		WHERE written_at IS NULL
+		ORDER BY id
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, r.dialect.rebind(query))
+	if err != nil {
+		return nil, r.fail("listing unwritten interbank batches", err)
+	}
+	defer rows.Close()
+
+	batches := []models.InterbankBatch{}
+	for rows.Next() {
+		var batch models.InterbankBatch
+		err := rows.Scan(&batch.ID, &batch.Reference, scanTime(&batch.CutoffAt), &batch.BlobKey, &batch.TransferCount, &batch.TotalNominal, scanTime(&batch.CreatedAt))
+		if err != nil {
+			return nil, r.fail("scanning interbank batch", err)
+		}
+		batches = append(batches, batch)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, r.fail("listing unwritten interbank batches", err)
+	}
+
+	return batches, nil
+}
+
+func (r *interbankRepository) MarkBatchWritten(ctx context.Context, id uint, writtenAt time.Time) error {
+	query := `UPDATE interbank_batches SET written_at = $1 WHERE id = $2`
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, r.dialect.rebind(query), writtenAt.UTC(), id); err != nil {
+		return r.fail("marking interbank batch written", err)
+	}
+	return nil
+}
+
+func (r *interbankRepository) CompleteTransfer(ctx context.Context, id uint, status, returnReason string, completedAt time.Time) (bool, error) {
+	query := `
+		UPDATE interbank_transfers
+		SET status = $1, return_reason = $2, completed_at = $3
+		WHERE id = $4 AND status = $5
+	`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, r.dialect.rebind(query),
+		status,
+		nullString(returnReason),
+		completedAt.UTC(),
+		id,
+		models.InterbankSent,
+	)
+	if err != nil {
+		return false, r.fail("completing interbank transfer", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, r.fail("completing interbank transfer", err)
+	}
+
+	return affected == 1, nil
+}
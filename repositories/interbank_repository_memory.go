@@ -0,0 +1,167 @@
+package repositories
+
+import (
+	"accounts-service/models"
+	"accounts-service/utils"
+	"context"
+	"errors"
+	"time"
+)
+
+type memoryInterbankRepository struct {
+	store  *MemoryStore
+	logger utils.Logger
+}
+
+func NewMemoryInterbankRepository(store *MemoryStore, logger utils.Logger) InterbankRepository {
+	return &memoryInterbankRepository{
+		store:  store,
+		logger: logger,
+	}
+}
+
+func (r *memoryInterbankRepository) CreateTransfer(ctx context.Context, transfer *models.InterbankTransfer) error {
+	return r.store.write(ctx, func(state *memoryState) error {
+		if _, ok := state.accounts[transfer.SourceAccountID]; !ok {
+			r.logger.Error("Error creating interbank transfer: unknown account %d", transfer.SourceAccountID)
+			return models.InterbankDBErr.Wrap(errors.New("violates foreign key constraint interbank_transfers_source_account_id_fkey"))
+		}
+
+		transfer.ID = state.nextInterbankTransferID
+		transfer.TransferID = models.InterbankTransferID(transfer.ID)
+		transfer.Status = models.InterbankQueued
+		transfer.CreatedAt = time.Now()
+		state.interbankTransfers = append(state.interbankTransfers, *transfer)
+		state.nextInterbankTransferID++
+		return nil
+	})
+}
+
+func (r *memoryInterbankRepository) GetTransfer(ctx context.Context, id uint) (*models.InterbankTransfer, error) {
+	var found *models.InterbankTransfer
+	err := r.store.read(ctx, func(state *memoryState) error {
+		for _, transfer := range state.interbankTransfers {
+			if transfer.ID == id {
+				transfer = r.join(state, transfer)
+				found = &transfer
+				break
+			}
+		}
+		return nil
+	})
+	return found, err
+}
+
+func (r *memoryInterbankRepository) ListQueuedTransfers(ctx context.Context, until time.Time) ([]models.InterbankTransfer, error) {
+	transfers := []models.InterbankTransfer{}
+	err := r.store.read(ctx, func(state *memoryState) error {
+		for _, transfer := range state.interbankTransfers {
+			if transfer.Status == models.InterbankQueued && transfer.CreatedAt.Before(until) {
+				transfers = append(transfers, r.join(state, transfer))
+			}
+		}
+		return nil
+	})
+	return transfers, err
+}
+
+func (r *memoryInterbankRepository) CreateBatch(ctx context.Context, batch *models.InterbankBatch) error {
+	return r.store.write(ctx, func(state *memoryState) error {
+		for _, existing := range state.interbankBatches {
+			if existing.Reference == batch.Reference {
+				return nil
+			}
+		}
+
+		batch.ID = state.nextInterbankBatchID
+		batch.CreatedAt = time.Now()
+		stored := *batch
+		stored.Transfers = nil
+		state.interbankBatches = append(state.interbankBatches, stored)
+		state.nextInterbankBatchID++
+		return nil
+	})
+}
+
+func (r *memoryInterbankRepository) SendTransfer(ctx context.Context, id, batchID uint) (bool, error) {
+	var sent bool
+	err := r.store.write(ctx, func(state *memoryState) error {
+		for i := range state.interbankTransfers {
+			transfer := &state.interbankTransfers[i]
+			if transfer.ID == id && transfer.Status == models.InterbankQueued {
+				transfer.Status = models.InterbankSent
+				transfer.BatchID = batchID
+				sent = true
+			}
+		}
+		return nil
+	})
+	return sent, err
+}
+
+func (r *memoryInterbankRepository) ListUnwrittenBatches(ctx context.Context) ([]models.InterbankBatch, error) {
+	batches := []models.InterbankBatch{}
+	err := r.store.read(ctx, func(state *memoryState) error {
+		for _, batch := range state.interbankBatches {
+			if batch.WrittenAt == nil {
+				batches = append(batches, batch)
+			}
+		}
+		return nil
+	})
+	return batches, err
+}
+
+func (r *memoryInterbankRepository) ListBatchTransfers(ctx context.Context, batchID uint) ([]models.InterbankTransfer, error) {
+	transfers := []models.InterbankTransfer{}
+	err := r.store.read(ctx, func(state *memoryState) error {
+		for _, transfer := range state.interbankTransfers {
+			if transfer.BatchID == batchID {
+				transfers = append(transfers, r.join(state, transfer))
+			}
+		}
+		return nil
+	})
+	return transfers, err
+}
+
+func (r *memoryInterbankRepository) MarkBatchWritten(ctx context.Context, id uint, writtenAt time.Time) error {
+	return r.store.write(ctx, func(state *memoryState) error {
+		for i := range state.interbankBatches {
+			if state.interbankBatches[i].ID == id {
+				state.interbankBatches[i].WrittenAt = &writtenAt
+			}
+		}
+		return nil
+	})
+}
+
+func (r *memoryInterbankRepository) CompleteTransfer(ctx context.Context, id uint, status, returnReason string, completedAt time.Time) (bool, error) {
+	var completed bool
+	err := r.store.write(ctx, func(state *memoryState) error {
+		for i := range state.interbankTransfers {
+			transfer := &state.interbankTransfers[i]
+			if transfer.ID == id && transfer.Status == models.InterbankSent {
+				transfer.Status = status
+				transfer.ReturnReason = returnReason
+				transfer.CompletedAt = &completedAt
+				completed = true
+			}
+		}
+		return nil
+	})
+	return completed, err
+}
+
+// join fills the no_rekening of the source and the reference of the batch
+// of a stored transfer.
+func (r *memoryInterbankRepository) join(state *memoryState, transfer models.InterbankTransfer) models.InterbankTransfer {
+	transfer.SourceNoRekening = state.accounts[transfer.SourceAccountID].NoRekening
+	for _, batch := range state.interbankBatches {
+		if batch.ID == transfer.BatchID {
+			transfer.BatchReference = batch.Reference
+			break
+		}
+	}
+	return transfer
+}
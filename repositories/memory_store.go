@@ -0,0 +1,220 @@
+package repositories
+
+import (
+	"accounts-service/models"
+	"accounts-service/utils"
+	"context"
+	"sync"
+)
+
+// MemoryStore keeps accounts, mutations, reversal markers, outbox events,
+// webhooks, notification preferences, monthly statements, bulk credits, the
+// external IDs of partners, dynamic QRIS codes, virtual accounts and
+// interbank transfers in process memory, for local development and tests
+// without Postgres.
+// Transactions run one at a time on a private copy of the data that replaces
+// the committed data on success, so they are serializable and readers never
+// see uncommitted changes.
+type MemoryStore struct {
+	// txMu serializes transactions.
+	txMu sync.Mutex
+
+	// mu guards state.
+	mu    sync.RWMutex
+	state *memoryState
+}
+
+type memoryState struct {
+	accounts                map[uint]models.Account
+	mutations               []models.Mutation
+	outbox                  []models.OutboxEvent
+	webhooks                map[uint]models.Webhook
+	deliveries              []models.WebhookDelivery
+	attempts                []models.WebhookAttempt
+	preferences             map[uint]models.NotificationPreference
+	statements              []models.MonthlyStatement
+	bulkBatches             []models.BulkCreditBatch
+	bulkRows                []models.BulkCreditRow
+	externalIDs             map[externalIDKey]bool
+	reversalMarkers         map[reversalMarkerKey]bool
+	qrisBills               []models.QRISBill
+	virtualAccounts         []models.VirtualAccount
+	interbankTransfers      []models.InterbankTransfer
+	interbankBatches        []models.InterbankBatch
+	nextAccountID           uint
+	nextMutationID          uint
+	nextOutboxID            uint
+	nextWebhookID           uint
+	nextDeliveryID          uint
+	nextAttemptID           uint
+	nextStatementID         uint
+	nextBulkBatchID         uint
+	nextBulkRowID           uint
+	nextQRISBillID          uint
+	nextVirtualAccountID    uint
+	nextInterbankTransferID uint
+	nextInterbankBatchID    uint
+}
+
+type externalIDKey struct {
+	partnerID, day, externalID string
+}
+
+type reversalMarkerKey struct {
+	accountID uint
+	reference string
+}
+
+type memoryTxKey struct{}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		state: &memoryState{
+			accounts:                make(map[uint]models.Account),
+			webhooks:                make(map[uint]models.Webhook),
+			preferences:             make(map[uint]models.NotificationPreference),
+			externalIDs:             make(map[externalIDKey]bool),
+			reversalMarkers:         make(map[reversalMarkerKey]bool),
+			nextAccountID:           1,
+			nextMutationID:          1,
+			nextOutboxID:            1,
+			nextWebhookID:           1,
+			nextDeliveryID:          1,
+			nextAttemptID:           1,
+			nextStatementID:         1,
+			nextBulkBatchID:         1,
+			nextBulkRowID:           1,
+			nextQRISBillID:          1,
+			nextVirtualAccountID:    1,
+			nextInterbankTransferID: 1,
+			nextInterbankBatchID:    1,
+		},
+	}
+}
+
+func (s *memoryState) clone() *memoryState {
+	c := &memoryState{
+		accounts:                make(map[uint]models.Account, len(s.accounts)),
+		mutations:               make([]models.Mutation, len(s.mutations)),
+		outbox:                  make([]models.OutboxEvent, len(s.outbox)),
+		webhooks:                make(map[uint]models.Webhook, len(s.webhooks)),
+		deliveries:              make([]models.WebhookDelivery, len(s.deliveries)),
+		attempts:                make([]models.WebhookAttempt, len(s.attempts)),
+		preferences:             make(map[uint]models.NotificationPreference, len(s.preferences)),
+		statements:              make([]models.MonthlyStatement, len(s.statements)),
+		bulkBatches:             make([]models.BulkCreditBatch, len(s.bulkBatches)),
+		bulkRows:                make([]models.BulkCreditRow, len(s.bulkRows)),
+		externalIDs:             make(map[externalIDKey]bool, len(s.externalIDs)),
+		reversalMarkers:         make(map[reversalMarkerKey]bool, len(s.reversalMarkers)),
+		qrisBills:               make([]models.QRISBill, len(s.qrisBills)),
+		virtualAccounts:         make([]models.VirtualAccount, len(s.virtualAccounts)),
+		interbankTransfers:      make([]models.InterbankTransfer, len(s.interbankTransfers)),
+		interbankBatches:        make([]models.InterbankBatch, len(s.interbankBatches)),
+		nextAccountID:           s.nextAccountID,
+		nextMutationID:          s.nextMutationID,
+		nextOutboxID:            s.nextOutboxID,
+		nextWebhookID:           s.nextWebhookID,
+		nextDeliveryID:          s.nextDeliveryID,
+		nextAttemptID:           s.nextAttemptID,
+		nextStatementID:         s.nextStatementID,
+		nextBulkBatchID:         s.nextBulkBatchID,
+		nextBulkRowID:           s.nextBulkRowID,
+		nextQRISBillID:          s.nextQRISBillID,
+		nextVirtualAccountID:    s.nextVirtualAccountID,
+		nextInterbankTransferID: s.nextInterbankTransferID,
+		nextInterbankBatchID:    s.nextInterbankBatchID,
+	}
+	for id, account := range s.accounts {
+		c.accounts[id] = account
+	}
+	copy(c.mutations, s.mutations)
+	copy(c.outbox, s.outbox)
+	for id, webhook := range s.webhooks {
+		c.webhooks[id] = webhook
+	}
+	copy(c.deliveries, s.deliveries)
+	copy(c.attempts, s.attempts)
+	for id, preference := range s.preferences {
+		c.preferences[id] = preference
+	}
+	copy(c.statements, s.statements)
+	copy(c.bulkBatches, s.bulkBatches)
+	copy(c.bulkRows, s.bulkRows)
+	for key := range s.externalIDs {
+		c.externalIDs[key] = true
+	}
+	for key := range s.reversalMarkers {
+		c.reversalMarkers[key] = true
+	}
+	copy(c.qrisBills, s.qrisBills)
+	copy(c.virtualAccounts, s.virtualAccounts)
+	copy(c.interbankTransfers, s.interbankTransfers)
+	copy(c.interbankBatches, s.interbankBatches)
+	return c
+}
+
+// read runs fn on the state of the ambient transaction, or on the committed
+// state outside a transaction.
+func (s *MemoryStore) read(ctx context.Context, fn func(state *memoryState) error) error {
+	if state, ok := ctx.Value(memoryTxKey{}).(*memoryState); ok {
+		return fn(state)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return fn(s.state)
+}
+
+// write runs fn on the state of the ambient transaction, or in its own
+// transaction outside one.
+func (s *MemoryStore) write(ctx context.Context, fn func(state *memoryState) error) error {
+	if state, ok := ctx.Value(memoryTxKey{}).(*memoryState); ok {
+		return fn(state)
+	}
+
+	return s.withinTx(ctx, func(ctx context.Context) error {
+		return fn(ctx.Value(memoryTxKey{}).(*memoryState))
+	})
+}
+
+func (s *MemoryStore) withinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(memoryTxKey{}).(*memoryState); ok {
+		return fn(ctx)
+	}
+
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	s.mu.RLock()
+	working := s.state.clone()
+	s.mu.RUnlock()
+
+	ctx, hooks := withAfterCommit(context.WithValue(ctx, memoryTxKey{}, working))
+	if err := fn(ctx); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.state = working
+	s.mu.Unlock()
+
+	hooks.run()
+	return nil
+}
+
+type memoryTxManager struct {
+	store  *MemoryStore
+	logger utils.Logger
+}
+
+// NewMemoryTxManager returns a TxManager for the repositories backed by store.
+func NewMemoryTxManager(store *MemoryStore, logger utils.Logger) TxManager {
+	return &memoryTxManager{
+		store:  store,
+		logger: logger,
+	}
+}
+
+func (m *memoryTxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return m.store.withinTx(ctx, fn)
+}
@@ -0,0 +1,224 @@
+package repositories
+
+import (
+	"accounts-service/models"
+	"accounts-service/utils"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type MonthlyStatementRepository interface {
+	// ListPendingAccounts returns up to limit accounts created before until,
+	// with an ID above afterID and no generated statement for period, by ID.
+	ListPendingAccounts(ctx context.Context, period string, until time.Time, afterID uint, limit int) ([]models.Account, error)
+	// SaveStatement records an attempt at generating the statement of an
+	// account and period, creating its row on the first attempt.
+	SaveStatement(ctx context.Context, statement *models.MonthlyStatement) error
+	// GetStatement returns nil when the account has no statement for period.
+	GetStatement(ctx context.Context, accountID uint, period string) (*models.MonthlyStatement, error)
+	// ListStatements returns the generated statements of an account, latest
+	// period first.
+	ListStatements(ctx context.Context, accountID uint) ([]models.MonthlyStatement, error)
+	MarkNotified(ctx context.Context, id uint, at time.Time) error
+}
+
+type monthlyStatementRepository struct {
+	db      *sql.DB
+	dialect Dialect
+	logger  utils.Logger
+}
+
+func NewMonthlyStatementRepository(db *sql.DB, logger utils.Logger) MonthlyStatementRepository {
+	return &monthlyStatementRepository{
+		db:      db,
+		dialect: DialectPostgres,
+		logger:  logger,
+	}
+}
+
+func NewSQLiteMonthlyStatementRepository(db *sql.DB, logger utils.Logger) MonthlyStatementRepository {
+	return &monthlyStatementRepository{
+		db:      db,
+		dialect: DialectSQLite,
+		logger:  logger,
+	}
+}
+
+const monthlyStatementColumns = `s.id, s.account_id, a.no_rekening, s.period, s.status, s.blob_key, s.size,
+	COALESCE(s.checksum, ''), s.attempts, COALESCE(s.last_error, ''), s.generated_at, s.notified_at, s.created_at, s.updated_at`
+
+func scanMonthlyStatement(row rowScanner) (*models.MonthlyStatement, error) {
+	var (
+		statement               models.MonthlyStatement
+		generatedAt, notifiedAt time.Time
+	)
+	err := row.Scan(
+		&statement.ID,
+		&statement.AccountID,
+		&statement.NoRekening,
+		&statement.Period,
+		&statement.Status,
+		&statement.BlobKey,
+		&statement.Size,
+		&statement.Checksum,
+		&statement.Attempts,
+		&statement.LastError,
+		scanTime(&generatedAt),
+		scanTime(&notifiedAt),
+		scanTime(&statement.CreatedAt),
+		scanTime(&statement.UpdatedAt),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if !generatedAt.IsZero() {
+		statement.GeneratedAt = &generatedAt
+	}
+	if !notifiedAt.IsZero() {
+		statement.NotifiedAt = &notifiedAt
+	}
+	return &statement, nil
+}
+
+// nullTime stores a nil time as NULL.
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
+
+func (r *monthlyStatementRepository) fail(action string, err error) error {
+	r.logger.Error("Error %s: %v", action, err)
+	return models.MonthlyStatementDBErr.Wrap(err)
+}
+
+func (r *monthlyStatementRepository) ListPendingAccounts(ctx context.Context, period string, until time.Time, afterID uint, limit int) ([]models.Account, error) {
+	query := `
+		SELECT a.id, a.name, a.nik, a.no_hp, a.no_rekening, a.saldo, a.created_at, a.updated_at
+		FROM accounts a
+		LEFT JOIN monthly_statements s ON s.account_id = a.id AND s.period = $1 AND s.status = $2
+		WHERE s.id IS NULL AND a.created_at < $3 AND a.id > $4
+		ORDER BY a.id
+		LIMIT $5
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, r.dialect.rebind(query),
+		period, models.MonthlyStatementGenerated, r.dialect.timestamp(until), afterID, limit)
+	if err != nil {
+		return nil, r.fail("listing accounts pending a monthly statement", err)
+	}
+	defer rows.Close()
+
+	accounts := []models.Account{}
+	for rows.Next() {
+		var account models.Account
+		err := rows.Scan(
+			&account.ID,
+			&account.Name,
+			&account.NIK,
+			&account.NoHP,
+			&account.NoRekening,
+			&account.Saldo,
+			scanTime(&account.CreatedAt),
+			scanTime(&account.UpdatedAt),
+		)
+		if err != nil {
+			return nil, r.fail("scanning account pending a monthly statement", err)
+		}
+		accounts = append(accounts, account)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, r.fail("listing accounts pending a monthly statement", err)
+	}
+
+	return accounts, nil
+}
+
+func (r *monthlyStatementRepository) SaveStatement(ctx context.Context, statement *models.MonthlyStatement) error {
+	query := `
+		INSERT INTO monthly_statements (account_id, period, status, blob_key, size, checksum, attempts, last_error, generated_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, 1, $7, $8, CURRENT_TIMESTAMP)
+		ON CONFLICT (account_id, period) DO UPDATE
+		SET status = excluded.status, blob_key = excluded.blob_key, size = excluded.size, checksum = excluded.checksum,
+			attempts = monthly_statements.attempts + 1, last_error = excluded.last_error,
+			generated_at = excluded.generated_at, updated_at = excluded.updated_at
+		RETURNING id, attempts, created_at, updated_at
+	`
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, r.dialect.rebind(query),
+		statement.AccountID,
+		statement.Period,
+		statement.Status,
+		statement.BlobKey,
+		statement.Size,
+		nullString(statement.Checksum),
+		nullString(statement.LastError),
+		nullTime(statement.GeneratedAt),
+	).Scan(&statement.ID, &statement.Attempts, scanTime(&statement.CreatedAt), scanTime(&statement.UpdatedAt))
+	if err != nil {
+		return r.fail("saving monthly statement", err)
+	}
+
+	return nil
+}
+
+func (r *monthlyStatementRepository) GetStatement(ctx context.Context, accountID uint, period string) (*models.MonthlyStatement, error) {
+	query := `
+		SELECT ` + monthlyStatementColumns + `
+		FROM monthly_statements s
+		JOIN accounts a ON a.id = s.account_id
+		WHERE s.account_id = $1 AND s.period = $2
+	`
+
+	statement, err := scanMonthlyStatement(conn(ctx, r.db).QueryRowContext(ctx, r.dialect.rebind(query), accountID, period))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, r.fail("getting monthly statement", err)
+	}
+
+	return statement, nil
+}
+
+func (r *monthlyStatementRepository) ListStatements(ctx context.Context, accountID uint) ([]models.MonthlyStatement, error) {
+	query := `
+		SELECT ` + monthlyStatementColumns + `
+		FROM monthly_statements s
+		JOIN accounts a ON a.id = s.account_id
+		WHERE s.account_id = $1 AND s.status = $2
+		ORDER BY s.period DESC
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, r.dialect.rebind(query), accountID, models.MonthlyStatementGenerated)
+	if err != nil {
+		return nil, r.fail("listing monthly statements", err)
+	}
+	defer rows.Close()
+
+	statements := []models.MonthlyStatement{}
+	for rows.Next() {
+		statement, err := scanMonthlyStatement(rows)
+		if err != nil {
+			return nil, r.fail("scanning monthly statement", err)
+		}
+		statements = append(statements, *statement)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, r.fail("listing monthly statements", err)
+	}
+
+	return statements, nil
+}
+
+func (r *monthlyStatementRepository) MarkNotified(ctx context.Context, id uint, at time.Time) error {
+	query := `UPDATE monthly_statements SET notified_at = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, r.dialect.rebind(query), at.UTC(), id); err != nil {
+		return r.fail("marking monthly statement notified", err)
+	}
+	return nil
+}
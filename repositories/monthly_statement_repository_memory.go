@@ -0,0 +1,121 @@
+package repositories
+
+import (
+	"accounts-service/models"
+	"accounts-service/utils"
+	"context"
+	"errors"
+	"sort"
+	"time"
+)
+
+type memoryMonthlyStatementRepository struct {
+	store  *MemoryStore
+	logger utils.Logger
+}
+
+func NewMemoryMonthlyStatementRepository(store *MemoryStore, logger utils.Logger) MonthlyStatementRepository {
+	return &memoryMonthlyStatementRepository{
+		store:  store,
+		logger: logger,
+	}
+}
+
+func (r *memoryMonthlyStatementRepository) ListPendingAccounts(ctx context.Context, period string, until time.Time, afterID uint, limit int) ([]models.Account, error) {
+	accounts := []models.Account{}
+	err := r.store.read(ctx, func(state *memoryState) error {
+		generated := make(map[uint]bool)
+		for _, statement := range state.statements {
+			if statement.Period == period && statement.Status == models.MonthlyStatementGenerated {
+				generated[statement.AccountID] = true
+			}
+		}
+
+		for _, account := range state.accounts {
+			if account.ID > afterID && account.CreatedAt.Before(until) && !generated[account.ID] {
+				accounts = append(accounts, account)
+			}
+		}
+		return nil
+	})
+
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].ID < accounts[j].ID })
+	if len(accounts) > limit {
+		accounts = accounts[:limit]
+	}
+	return accounts, err
+}
+
+func (r *memoryMonthlyStatementRepository) SaveStatement(ctx context.Context, statement *models.MonthlyStatement) error {
+	return r.store.write(ctx, func(state *memoryState) error {
+		account, ok := state.accounts[statement.AccountID]
+		if !ok {
+			r.logger.Error("Error saving monthly statement: unknown account %d", statement.AccountID)
+			return models.MonthlyStatementDBErr.Wrap(errors.New("violates foreign key constraint monthly_statements_account_id_fkey"))
+		}
+
+		now := time.Now()
+		statement.NoRekening = account.NoRekening
+		statement.UpdatedAt = now
+
+		for i := range state.statements {
+			existing := &state.statements[i]
+			if existing.AccountID == statement.AccountID && existing.Period == statement.Period {
+				statement.ID = existing.ID
+				statement.Attempts = existing.Attempts + 1
+				statement.CreatedAt = existing.CreatedAt
+				statement.NotifiedAt = existing.NotifiedAt
+				*existing = *statement
+				return nil
+			}
+		}
+
+		statement.ID = state.nextStatementID
+		statement.Attempts = 1
+		statement.CreatedAt = now
+		state.statements = append(state.statements, *statement)
+		state.nextStatementID++
+		return nil
+	})
+}
+
+func (r *memoryMonthlyStatementRepository) GetStatement(ctx context.Context, accountID uint, period string) (*models.MonthlyStatement, error) {
+	var found *models.MonthlyStatement
+	err := r.store.read(ctx, func(state *memoryState) error {
+		for _, statement := range state.statements {
+			if statement.AccountID == accountID && statement.Period == period {
+				found = &statement
+				return nil
+			}
+		}
+		return nil
+	})
+	return found, err
+}
+
+func (r *memoryMonthlyStatementRepository) ListStatements(ctx context.Context, accountID uint) ([]models.MonthlyStatement, error) {
+	statements := []models.MonthlyStatement{}
+	err := r.store.read(ctx, func(state *memoryState) error {
+		for _, statement := range state.statements {
+			if statement.AccountID == accountID && statement.Status == models.MonthlyStatementGenerated {
+				statements = append(statements, statement)
+			}
+		}
+		return nil
+	})
+
+	sort.Slice(statements, func(i, j int) bool { return statements[i].Period > statements[j].Period })
+	return statements, err
+}
+
+func (r *memoryMonthlyStatementRepository) MarkNotified(ctx context.Context, id uint, at time.Time) error {
+	return r.store.write(ctx, func(state *memoryState) error {
+		for i := range state.statements {
+			if state.statements[i].ID == id {
+				state.statements[i].NotifiedAt = &at
+				state.statements[i].UpdatedAt = time.Now()
+			}
+		}
+		return nil
+	})
+}
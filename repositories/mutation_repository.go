@@ -5,60 +5,241 @@ import (
 	"accounts-service/utils"
 	"context"
 	"database/sql"
+	"strconv"
+	"time"
 )
 
 type MutationRepository interface {
-	CreateMutation(ctx context.Context, tx *sql.Tx, mutation *models.Mutation) error
+	CreateMutation(ctx context.Context, mutation *models.Mutation) error
+	// ListMutations returns up to limit mutations of an account, newest
+	// first, with an ID below beforeID unless it is 0.
+	ListMutations(ctx context.Context, accountID, beforeID uint, limit int) ([]models.Mutation, error)
+	// SumMutations returns the credit and debit totals of an account over
+	// the mutations created before a time.
+	SumMutations(ctx context.Context, accountID uint, before time.Time) (credit, debit float64, err error)
+	// EachMutation calls fn with the mutations of an account created in
+	// [from, to), oldest first. Rows are streamed rather than loaded at once.
+	EachMutation(ctx context.Context, accountID uint, from, to time.Time, fn func(mutation *models.Mutation) error) error
+	// GetMutationByReference returns the newest mutation of an account with
+	// a reference, nil when there is none.
+	GetMutationByReference(ctx context.Context, accountID uint, reference string) (*models.Mutation, error)
+	// MarkReversed records that the posting of an account with a reference
+	// was reversed before it was made. Marking it again does nothing.
+	MarkReversed(ctx context.Context, accountID uint, reference string) error
+	// IsMarkedReversed reports whether MarkReversed recorded the posting.
+	IsMarkedReversed(ctx context.Context, accountID uint, reference string) (bool, error)
 }
 
 type mutationRepository struct {
-	db     *sql.DB
-	logger utils.Logger
+	db      *sql.DB
+	dialect Dialect
+	logger  utils.Logger
 }
 
 func NewMutationRepository(db *sql.DB, logger utils.Logger) MutationRepository {
 	return &mutationRepository{
-		db:     db,
-		logger: logger,
+		db:      db,
+		dialect: DialectPostgres,
+		logger:  logger,
 	}
 }
 
-func (r *mutationRepository) CreateMutation(ctx context.Context, tx *sql.Tx, mutation *models.Mutation) error {
+func NewSQLiteMutationRepository(db *sql.DB, logger utils.Logger) MutationRepository {
+	return &mutationRepository{
+		db:      db,
+		dialect: DialectSQLite,
+		logger:  logger,
+	}
+}
+
+func (r *mutationRepository) CreateMutation(ctx context.Context, mutation *models.Mutation) error {
 	query := `
 		INSERT INTO mutations (account_id, nominal, type, reference)
 		VALUES ($1, $2, $3, $4)
 		RETURNING id, created_at
 	`
 
-	var err error
-	if tx != nil {
-		err = tx.QueryRowContext(ctx, query,
-			mutation.AccountID,
-			mutation.Nominal,
-			mutation.Type,
-			mutation.Reference,
-		).Scan(&mutation.ID, &mutation.CreatedAt)
-	} else {
-		err = r.db.QueryRowContext(ctx, query,
-			mutation.AccountID,
-			mutation.Nominal,
-			mutation.Type,
-			mutation.Reference,
-		).Scan(&mutation.ID, &mutation.CreatedAt)
-	}
+	err := conn(ctx, r.db).QueryRowContext(ctx, r.dialect.rebind(query),
+		mutation.AccountID,
+		mutation.Nominal,
+		mutation.Type,
+		mutation.Reference,
+	).Scan(&mutation.ID, scanTime(&mutation.CreatedAt))
 
 	if err != nil {
 		r.logger.Error("Error creating mutation: %v", err)
-		return utils.NewRemark(
-			"Error creating mutation",
-			models.CreateMutationError,
-			"no_rekening",
-			map[string]interface{}{
-				"error": err,
-				"type":  mutation.Type,
-			},
+		return models.CreateMutationErr.
+			WithObject(map[string]interface{}{"type": mutation.Type}).
+			Wrap(err)
+	}
+
+	return nil
+}
+
+func (r *mutationRepository) ListMutations(ctx context.Context, accountID, beforeID uint, limit int) ([]models.Mutation, error) {
+	query := `
+		SELECT id, account_id, nominal, type, COALESCE(reference, ''), created_at
+		FROM mutations
+		WHERE account_id = $1
+	`
+	args := []interface{}{accountID}
+	if beforeID > 0 {
+		query += ` AND id < $2`
+		args = append(args, beforeID)
+	}
+	query += ` ORDER BY id DESC LIMIT ` + strconv.Itoa(limit)
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, r.dialect.rebind(query), args...)
+	if err != nil {
+		r.logger.Error("Error listing mutations: %v", err)
+		return nil, models.MutationDBErr.Wrap(err)
+	}
+	defer rows.Close()
+
+	mutations := []models.Mutation{}
+	for rows.Next() {
+		var mutation models.Mutation
+		err := rows.Scan(
+			&mutation.ID,
+			&mutation.AccountID,
+			&mutation.Nominal,
+			&mutation.Type,
+			&mutation.Reference,
+			scanTime(&mutation.CreatedAt),
+		)
+		if err != nil {
+			r.logger.Error("Error scanning mutation: %v", err)
+			return nil, models.MutationDBErr.Wrap(err)
+		}
+		mutations = append(mutations, mutation)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Error listing mutations: %v", err)
+		return nil, models.MutationDBErr.Wrap(err)
+	}
+
+	return mutations, nil
+}
+
+func (r *mutationRepository) SumMutations(ctx context.Context, accountID uint, before time.Time) (credit, debit float64, err error) {
+	query := `
+		SELECT
+			COALESCE(SUM(CASE WHEN type = $1 THEN nominal ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN type = $2 THEN nominal ELSE 0 END), 0)
+		FROM mutations
+		WHERE account_id = $3 AND created_at < $4
+	`
+
+	err = conn(ctx, r.db).QueryRowContext(ctx, r.dialect.rebind(query),
+		models.MutationTypeCredit,
+		models.MutationTypeDebit,
+		accountID,
+		r.dialect.timestamp(before),
+	).Scan(&credit, &debit)
+	if err != nil {
+		r.logger.Error("Error summing mutations: %v", err)
+		return 0, 0, models.MutationDBErr.Wrap(err)
+	}
+
+	return credit, debit, nil
+}
+
+func (r *mutationRepository) EachMutation(ctx context.Context, accountID uint, from, to time.Time, fn func(mutation *models.Mutation) error) error {
+	query := `
+		SELECT id, account_id, nominal, type, COALESCE(reference, ''), created_at
+		FROM mutations
+		WHERE account_id = $1 AND created_at >= $2 AND created_at < $3
+		ORDER BY created_at, id
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, r.dialect.rebind(query), accountID, r.dialect.timestamp(from), r.dialect.timestamp(to))
+	if err != nil {
+		r.logger.Error("Error reading mutations: %v", err)
+		return models.MutationDBErr.Wrap(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var mutation models.Mutation
+		err := rows.Scan(
+			&mutation.ID,
+			&mutation.AccountID,
+			&mutation.Nominal,
+			&mutation.Type,
+			&mutation.Reference,
+			scanTime(&mutation.CreatedAt),
 		)
+		if err != nil {
+			r.logger.Error("Error scanning mutation: %v", err)
+			return models.MutationDBErr.Wrap(err)
+		}
+		if err := fn(&mutation); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Error reading mutations: %v", err)
+		return models.MutationDBErr.Wrap(err)
 	}
 
 	return nil
 }
+
+func (r *mutationRepository) GetMutationByReference(ctx context.Context, accountID uint, reference string) (*models.Mutation, error) {
+	query := `
+		SELECT id, account_id, nominal, type, COALESCE(reference, ''), created_at
+		FROM mutations
+		WHERE account_id = $1 AND reference = $2
+		ORDER BY id DESC LIMIT 1
+	`
+
+	var mutation models.Mutation
+	err := conn(ctx, r.db).QueryRowContext(ctx, r.dialect.rebind(query), accountID, reference).Scan(
+		&mutation.ID,
+		&mutation.AccountID,
+		&mutation.Nominal,
+		&mutation.Type,
+		&mutation.Reference,
+		scanTime(&mutation.CreatedAt),
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		r.logger.Error("Error getting mutation by reference: %v", err)
+		return nil, models.MutationDBErr.Wrap(err)
+	}
+
+	return &mutation, nil
+}
+
+func (r *mutationRepository) MarkReversed(ctx context.Context, accountID uint, reference string) error {
+	query := `
+		INSERT INTO reversal_markers (account_id, reference)
+		VALUES ($1, $2)
+		ON CONFLICT (account_id, reference) DO NOTHING
+	`
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, r.dialect.rebind(query), accountID, reference); err != nil {
+		r.logger.Error("Error marking posting reversed: %v", err)
+		return models.MutationDBErr.Wrap(err)
+	}
+
+	return nil
+}
+
+func (r *mutationRepository) IsMarkedReversed(ctx context.Context, accountID uint, reference string) (bool, error) {
+	query := `SELECT 1 FROM reversal_markers WHERE account_id = $1 AND reference = $2`
+
+	var found int
+	err := conn(ctx, r.db).QueryRowContext(ctx, r.dialect.rebind(query), accountID, reference).Scan(&found)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		r.logger.Error("Error getting reversal marker: %v", err)
+		return false, models.MutationDBErr.Wrap(err)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,128 @@
+package repositories
+
+import (
+	"accounts-service/models"
+	"accounts-service/utils"
+	"context"
+	"errors"
+	"time"
+)
+
+type memoryMutationRepository struct {
+	store  *MemoryStore
+	logger utils.Logger
+}
+
+func NewMemoryMutationRepository(store *MemoryStore, logger utils.Logger) MutationRepository {
+	return &memoryMutationRepository{
+		store:  store,
+		logger: logger,
+	}
+}
+
+func (r *memoryMutationRepository) CreateMutation(ctx context.Context, mutation *models.Mutation) error {
+	return r.store.write(ctx, func(state *memoryState) error {
+		if _, ok := state.accounts[mutation.AccountID]; !ok {
+			r.logger.Error("Error creating mutation: unknown account %d", mutation.AccountID)
+			return models.CreateMutationErr.
+				WithObject(map[string]interface{}{"type": mutation.Type}).
+				Wrap(errors.New("violates foreign key constraint mutations_account_id_fkey"))
+		}
+
+		mutation.ID = state.nextMutationID
+		mutation.CreatedAt = time.Now()
+
+		state.mutations = append(state.mutations, *mutation)
+		state.nextMutationID++
+		return nil
+	})
+}
+
+func (r *memoryMutationRepository) ListMutations(ctx context.Context, accountID, beforeID uint, limit int) ([]models.Mutation, error) {
+	mutations := []models.Mutation{}
+	err := r.store.read(ctx, func(state *memoryState) error {
+		for i := len(state.mutations) - 1; i >= 0 && len(mutations) < limit; i-- {
+			mutation := state.mutations[i]
+			if mutation.AccountID == accountID && (beforeID == 0 || mutation.ID < beforeID) {
+				mutations = append(mutations, mutation)
+			}
+		}
+		return nil
+	})
+	return mutations, err
+}
+
+func (r *memoryMutationRepository) SumMutations(ctx context.Context, accountID uint, before time.Time) (credit, debit float64, err error) {
+	err = r.store.read(ctx, func(state *memoryState) error {
+		for _, mutation := range state.mutations {
+			if mutation.AccountID != accountID || !mutation.CreatedAt.Before(before) {
+				continue
+			}
+			switch mutation.Type {
+			case models.MutationTypeCredit:
+				credit += mutation.Nominal
+			case models.MutationTypeDebit:
+				debit += mutation.Nominal
+			}
+		}
+		return nil
+	})
+	return credit, debit, err
+}
+
+func (r *memoryMutationRepository) EachMutation(ctx context.Context, accountID uint, from, to time.Time, fn func(mutation *models.Mutation) error) error {
+	var mutations []models.Mutation
+	err := r.store.read(ctx, func(state *memoryState) error {
+		for _, mutation := range state.mutations {
+			if mutation.AccountID == accountID && !mutation.CreatedAt.Before(from) && mutation.CreatedAt.Before(to) {
+				mutations = append(mutations, mutation)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Call fn outside the store lock, it may be slow to write
+	for i := range mutations {
+		if err := fn(&mutations[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *memoryMutationRepository) GetMutationByReference(ctx context.Context, accountID uint, reference string) (*models.Mutation, error) {
+	var found *models.Mutation
+	err := r.store.read(ctx, func(state *memoryState) error {
+		for i := len(state.mutations) - 1; i >= 0; i-- {
+			if mutation := state.mutations[i]; mutation.AccountID == accountID && mutation.Reference == reference {
+				found = &mutation
+				return nil
+			}
+		}
+		return nil
+	})
+	return found, err
+}
+
+func (r *memoryMutationRepository) MarkReversed(ctx context.Context, accountID uint, reference string) error {
+	return r.store.write(ctx, func(state *memoryState) error {
+		if _, ok := state.accounts[accountID]; !ok {
+			r.logger.Error("Error marking posting reversed: unknown account %d", accountID)
+			return models.MutationDBErr.Wrap(errors.New("violates foreign key constraint reversal_markers_account_id_fkey"))
+		}
+		state.reversalMarkers[reversalMarkerKey{accountID: accountID, reference: reference}] = true
+		return nil
+	})
+}
+
+func (r *memoryMutationRepository) IsMarkedReversed(ctx context.Context, accountID uint, reference string) (bool, error) {
+	var marked bool
+	err := r.store.read(ctx, func(state *memoryState) error {
+		marked = state.reversalMarkers[reversalMarkerKey{accountID: accountID, reference: reference}]
+		return nil
+	})
+	return marked, err
+}
@@ -5,6 +5,7 @@ import (
 	"accounts-service/repositories"
 	"accounts-service/utils"
 	"context"
+	"database/sql"
 	"errors"
 	"testing"
 	"time"
@@ -32,11 +33,10 @@ func TestMutationRepository_CreateMutation(t *testing.T) {
 
 		logger := utils.NewLogger("info")
 		repo := repositories.NewMutationRepository(db, logger)
+		txManager := repositories.NewTxManager(db, sql.LevelDefault, 0, logger)
 
 		// Create a transaction
 		mock.ExpectBegin()
-		tx, err := db.Begin()
-		assert.NoError(t, err)
 
 		// Test data
 		mutation := &models.Mutation{
@@ -54,9 +54,12 @@ func TestMutationRepository_CreateMutation(t *testing.T) {
 		`).
 			WithArgs(mutation.AccountID, mutation.Nominal, mutation.Type, mutation.Reference).
 			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, time.Now()))
+		mock.ExpectCommit()
 
 		// Execute test
-		err = repo.CreateMutation(context.Background(), tx, mutation)
+		err = txManager.WithinTx(context.Background(), func(ctx context.Context) error {
+			return repo.CreateMutation(ctx, mutation)
+		})
 
 		// Assertions result
 		assert.NoError(t, err)
@@ -91,7 +94,7 @@ func TestMutationRepository_CreateMutation(t *testing.T) {
 			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(2, time.Now()))
 
 		// Execute
-		err = repo.CreateMutation(context.Background(), nil, mutation)
+		err = repo.CreateMutation(context.Background(), mutation)
 
 		// Assertions
 		assert.NoError(t, err)
@@ -126,7 +129,7 @@ func TestMutationRepository_CreateMutation(t *testing.T) {
 			WillReturnError(errors.New("database error"))
 
 		// Execute
-		err = repo.CreateMutation(context.Background(), nil, mutation)
+		err = repo.CreateMutation(context.Background(), mutation)
 
 		// Assertions
 		assert.Error(t, err)
@@ -0,0 +1,104 @@
+package repositories
+
+import (
+	"accounts-service/models"
+	"accounts-service/utils"
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+)
+
+type NotificationPreferenceRepository interface {
+	// GetPreference returns nil when the account has no preference yet.
+	GetPreference(ctx context.Context, accountID uint) (*models.NotificationPreference, error)
+	// SavePreference creates or replaces the preference of its account.
+	SavePreference(ctx context.Context, preference *models.NotificationPreference) error
+}
+
+type notificationPreferenceRepository struct {
+	db      *sql.DB
+	dialect Dialect
+	logger  utils.Logger
+}
+
+func NewNotificationPreferenceRepository(db *sql.DB, logger utils.Logger) NotificationPreferenceRepository {
+	return &notificationPreferenceRepository{
+		db:      db,
+		dialect: DialectPostgres,
+		logger:  logger,
+	}
+}
+
+func NewSQLiteNotificationPreferenceRepository(db *sql.DB, logger utils.Logger) NotificationPreferenceRepository {
+	return &notificationPreferenceRepository{
+		db:      db,
+		dialect: DialectSQLite,
+		logger:  logger,
+	}
+}
+
+// splitChannels reads the comma separated channels column, where an empty
+// string means no channel.
+func splitChannels(channels string) []string {
+	if channels == "" {
+		return []string{}
+	}
+	return strings.Split(channels, ",")
+}
+
+func (r *notificationPreferenceRepository) GetPreference(ctx context.Context, accountID uint) (*models.NotificationPreference, error) {
+	query := `
+		SELECT p.account_id, a.no_rekening, p.channels, COALESCE(p.email, ''), p.low_balance_threshold, p.updated_at
+		FROM notification_preferences p
+		JOIN accounts a ON a.id = p.account_id
+		WHERE p.account_id = $1
+	`
+
+	var (
+		preference models.NotificationPreference
+		channels   string
+	)
+	err := conn(ctx, r.db).QueryRowContext(ctx, r.dialect.rebind(query), accountID).Scan(
+		&preference.AccountID,
+		&preference.NoRekening,
+		&channels,
+		&preference.Email,
+		&preference.LowBalanceThreshold,
+		scanTime(&preference.UpdatedAt),
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		r.logger.Error("Error getting notification preference: %v", err)
+		return nil, models.NotificationDBErr.Wrap(err)
+	}
+
+	preference.Channels = splitChannels(channels)
+	return &preference, nil
+}
+
+func (r *notificationPreferenceRepository) SavePreference(ctx context.Context, preference *models.NotificationPreference) error {
+	query := `
+		INSERT INTO notification_preferences (account_id, channels, email, low_balance_threshold, updated_at)
+		VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP)
+		ON CONFLICT (account_id) DO UPDATE
+		SET channels = excluded.channels, email = excluded.email,
+			low_balance_threshold = excluded.low_balance_threshold, updated_at = excluded.updated_at
+		RETURNING updated_at
+	`
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, r.dialect.rebind(query),
+		preference.AccountID,
+		strings.Join(preference.Channels, ","),
+		nullString(preference.Email),
+		preference.LowBalanceThreshold,
+	).Scan(scanTime(&preference.UpdatedAt))
+	if err != nil {
+		r.logger.Error("Error saving notification preference: %v", err)
+		return models.NotificationDBErr.Wrap(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package repositories
+
+import (
+	"accounts-service/models"
+	"accounts-service/utils"
+	"context"
+	"errors"
+	"time"
+)
+
+type memoryNotificationPreferenceRepository struct {
+	store  *MemoryStore
+	logger utils.Logger
+}
+
+func NewMemoryNotificationPreferenceRepository(store *MemoryStore, logger utils.Logger) NotificationPreferenceRepository {
+	return &memoryNotificationPreferenceRepository{
+		store:  store,
+		logger: logger,
+	}
+}
+
+func (r *memoryNotificationPreferenceRepository) GetPreference(ctx context.Context, accountID uint) (*models.NotificationPreference, error) {
+	var found *models.NotificationPreference
+	err := r.store.read(ctx, func(state *memoryState) error {
+		if preference, ok := state.preferences[accountID]; ok {
+			preference.NoRekening = state.accounts[accountID].NoRekening
+			preference.Channels = append([]string{}, preference.Channels...)
+			found = &preference
+		}
+		return nil
+	})
+	return found, err
+}
+
+func (r *memoryNotificationPreferenceRepository) SavePreference(ctx context.Context, preference *models.NotificationPreference) error {
+	return r.store.write(ctx, func(state *memoryState) error {
+		if _, ok := state.accounts[preference.AccountID]; !ok {
+			r.logger.Error("Error saving notification preference: unknown account %d", preference.AccountID)
+			return models.NotificationDBErr.Wrap(errors.New("violates foreign key constraint notification_preferences_account_id_fkey"))
+		}
+
+		preference.UpdatedAt = time.Now()
+
+		stored := *preference
+		stored.Channels = append([]string{}, preference.Channels...)
+		state.preferences[preference.AccountID] = stored
+		return nil
+	})
+}
@@ -0,0 +1,161 @@
+package repositories
+
+import (
+	"accounts-service/models"
+	"accounts-service/utils"
+	"context"
+	"database/sql"
+	"time"
+)
+
+type OutboxRepository interface {
+	// CreateEvent records a pending event in the ambient transaction.
+	CreateEvent(ctx context.Context, event *models.OutboxEvent) error
+	// ListDeliverable returns up to limit pending events that are due at now
+	// and have no older pending event with the same aggregate key, oldest
+	// first.
+	ListDeliverable(ctx context.Context, now time.Time, limit int) ([]models.OutboxEvent, error)
+	MarkPublished(ctx context.Context, id uint, publishedAt time.Time) error
+	// MarkFailed records a failed attempt. The event is retried at
+	// nextAttemptAt, or dead-lettered when dead is set.
+	MarkFailed(ctx context.Context, id uint, lastError string, nextAttemptAt time.Time, dead bool) error
+}
+
+type outboxRepository struct {
+	db      *sql.DB
+	dialect Dialect
+	logger  utils.Logger
+}
+
+func NewOutboxRepository(db *sql.DB, logger utils.Logger) OutboxRepository {
+	return &outboxRepository{
+		db:      db,
+		dialect: DialectPostgres,
+		logger:  logger,
+	}
+}
+
+func NewSQLiteOutboxRepository(db *sql.DB, logger utils.Logger) OutboxRepository {
+	return &outboxRepository{
+		db:      db,
+		dialect: DialectSQLite,
+		logger:  logger,
+	}
+}
+
+func (r *outboxRepository) CreateEvent(ctx context.Context, event *models.OutboxEvent) error {
+	query := `
+		INSERT INTO outbox (aggregate_key, event_type, payload, status, next_attempt_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+
+	event.Status = models.OutboxStatusPending
+	if event.NextAttemptAt.IsZero() {
+		event.NextAttemptAt = time.Now().UTC()
+	}
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, r.dialect.rebind(query),
+		event.AggregateKey,
+		event.EventType,
+		string(event.Payload),
+		event.Status,
+		event.NextAttemptAt.UTC(),
+	).Scan(&event.ID, scanTime(&event.CreatedAt))
+	if err != nil {
+		r.logger.Error("Error creating outbox event: %v", err)
+		return models.CreateOutboxEventErr.Wrap(err)
+	}
+
+	return nil
+}
+
+func (r *outboxRepository) ListDeliverable(ctx context.Context, now time.Time, limit int) ([]models.OutboxEvent, error) {
+	query := `
+		SELECT o.id, o.aggregate_key, o.event_type, o.payload, o.status, o.attempts,
+			COALESCE(o.last_error, ''), o.next_attempt_at, o.created_at
+		FROM outbox o
+		WHERE o.status = 'pending' AND o.next_attempt_at <= $1
+			AND NOT EXISTS (
+				SELECT 1 FROM outbox p
+				WHERE p.aggregate_key = o.aggregate_key AND p.status = 'pending' AND p.id < o.id
+			)
+		ORDER BY o.id
+		LIMIT $2
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, r.dialect.rebind(query), now.UTC(), limit)
+	if err != nil {
+		r.logger.Error("Error listing outbox events: %v", err)
+		return nil, models.OutboxDBErr.Wrap(err)
+	}
+	defer rows.Close()
+
+	var events []models.OutboxEvent
+	for rows.Next() {
+		var (
+			event   models.OutboxEvent
+			payload []byte
+		)
+		err := rows.Scan(
+			&event.ID,
+			&event.AggregateKey,
+			&event.EventType,
+			&payload,
+			&event.Status,
+			&event.Attempts,
+			&event.LastError,
+			scanTime(&event.NextAttemptAt),
+			scanTime(&event.CreatedAt),
+		)
+		if err != nil {
+			r.logger.Error("Error scanning outbox event: %v", err)
+			return nil, models.OutboxDBErr.Wrap(err)
+		}
+		event.Payload = payload
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Error listing outbox events: %v", err)
+		return nil, models.OutboxDBErr.Wrap(err)
+	}
+
+	return events, nil
+}
+
+func (r *outboxRepository) MarkPublished(ctx context.Context, id uint, publishedAt time.Time) error {
+	query := `
+		UPDATE outbox
+		SET status = 'published', attempts = attempts + 1, last_error = NULL, published_at = $1
+		WHERE id = $2
+	`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, r.dialect.rebind(query), publishedAt.UTC(), id)
+	if err != nil {
+		r.logger.Error("Error marking outbox event %d published: %v", id, err)
+		return models.OutboxDBErr.Wrap(err)
+	}
+
+	return nil
+}
+
+func (r *outboxRepository) MarkFailed(ctx context.Context, id uint, lastError string, nextAttemptAt time.Time, dead bool) error {
+	query := `
+		UPDATE outbox
+		SET status = $1, attempts = attempts + 1, last_error = $2, next_attempt_at = $3
+		WHERE id = $4
+	`
+
+	status := models.OutboxStatusPending
+	if dead {
+		status = models.OutboxStatusDead
+	}
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, r.dialect.rebind(query), status, lastError, nextAttemptAt.UTC(), id)
+	if err != nil {
+		r.logger.Error("Error marking outbox event %d failed: %v", id, err)
+		return models.OutboxDBErr.Wrap(err)
+	}
+
+	return nil
+}
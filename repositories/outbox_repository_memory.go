@@ -0,0 +1,91 @@
+package repositories
+
+import (
+	"accounts-service/models"
+	"accounts-service/utils"
+	"context"
+	"time"
+)
+
+type memoryOutboxRepository struct {
+	store  *MemoryStore
+	logger utils.Logger
+}
+
+func NewMemoryOutboxRepository(store *MemoryStore, logger utils.Logger) OutboxRepository {
+	return &memoryOutboxRepository{
+		store:  store,
+		logger: logger,
+	}
+}
+
+func (r *memoryOutboxRepository) CreateEvent(ctx context.Context, event *models.OutboxEvent) error {
+	return r.store.write(ctx, func(state *memoryState) error {
+		event.ID = state.nextOutboxID
+		event.Status = models.OutboxStatusPending
+		event.CreatedAt = time.Now()
+		if event.NextAttemptAt.IsZero() {
+			event.NextAttemptAt = event.CreatedAt
+		}
+
+		state.outbox = append(state.outbox, *event)
+		state.nextOutboxID++
+		return nil
+	})
+}
+
+func (r *memoryOutboxRepository) ListDeliverable(ctx context.Context, now time.Time, limit int) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	err := r.store.read(ctx, func(state *memoryState) error {
+		blocked := make(map[string]bool)
+		for _, event := range state.outbox {
+			if len(events) == limit {
+				break
+			}
+			if event.Status != models.OutboxStatusPending || blocked[event.AggregateKey] {
+				continue
+			}
+
+			// Only the oldest pending event of an aggregate is deliverable
+			blocked[event.AggregateKey] = true
+			if !event.NextAttemptAt.After(now) {
+				events = append(events, event)
+			}
+		}
+		return nil
+	})
+
+	return events, err
+}
+
+func (r *memoryOutboxRepository) MarkPublished(ctx context.Context, id uint, publishedAt time.Time) error {
+	return r.update(ctx, id, func(event *models.OutboxEvent) {
+		event.Status = models.OutboxStatusPublished
+		event.Attempts++
+		event.LastError = ""
+	})
+}
+
+func (r *memoryOutboxRepository) MarkFailed(ctx context.Context, id uint, lastError string, nextAttemptAt time.Time, dead bool) error {
+	return r.update(ctx, id, func(event *models.OutboxEvent) {
+		event.Status = models.OutboxStatusPending
+		if dead {
+			event.Status = models.OutboxStatusDead
+		}
+		event.Attempts++
+		event.LastError = lastError
+		event.NextAttemptAt = nextAttemptAt
+	})
+}
+
+func (r *memoryOutboxRepository) update(ctx context.Context, id uint, fn func(event *models.OutboxEvent)) error {
+	return r.store.write(ctx, func(state *memoryState) error {
+		for i := range state.outbox {
+			if state.outbox[i].ID == id {
+				fn(&state.outbox[i])
+				return nil
+			}
+		}
+		return nil
+	})
+}
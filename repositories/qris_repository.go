@@ -0,0 +1,128 @@
+package repositories
+
+import (
+	"accounts-service/models"
+	"accounts-service/utils"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type QRISRepository interface {
+	// CreateBill records an open dynamic QRIS code. It does nothing and
+	// leaves bill.ID zero when the account already used the bill number.
+	CreateBill(ctx context.Context, bill *models.QRISBill) error
+	// GetBill returns the dynamic QRIS code of an account with a bill
+	// number, or nil when there is none.
+	GetBill(ctx context.Context, accountID uint, billNumber string) (*models.QRISBill, error)
+	// MarkBillPaid marks an open dynamic QRIS code paid at paidAt. It
+	// reports false and changes nothing when it is no longer open, so a code
+	// is only paid once.
+	MarkBillPaid(ctx context.Context, id uint, paidAt time.Time) (bool, error)
+}
+
+type qrisRepository struct {
+	db      *sql.DB
+	dialect Dialect
+	logger  utils.Logger
+}
+
+func NewQRISRepository(db *sql.DB, logger utils.Logger) QRISRepository {
+	return &qrisRepository{
+		db:      db,
+		dialect: DialectPostgres,
+		logger:  logger,
+	}
+}
+
+func NewSQLiteQRISRepository(db *sql.DB, logger utils.Logger) QRISRepository {
+	return &qrisRepository{
+		db:      db,
+		dialect: DialectSQLite,
+		logger:  logger,
+	}
+}
+
+func (r *qrisRepository) fail(action string, err error) error {
+	r.logger.Error("Error %s: %v", action, err)
+	return models.QRISDBErr.Wrap(err)
+}
+
+func (r *qrisRepository) CreateBill(ctx context.Context, bill *models.QRISBill) error {
+	query := `
+		INSERT INTO qris_bills (account_id, bill_number, nominal, status)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (account_id, bill_number) DO NOTHING
+		RETURNING id, created_at
+	`
+
+	bill.Status = models.QRISBillStatusOpen
+	err := conn(ctx, r.db).QueryRowContext(ctx, r.dialect.rebind(query),
+		bill.AccountID,
+		bill.BillNumber,
+		bill.Nominal,
+		bill.Status,
+	).Scan(&bill.ID, scanTime(&bill.CreatedAt))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return r.fail("creating QRIS bill", err)
+	}
+
+	return nil
+}
+
+func (r *qrisRepository) GetBill(ctx context.Context, accountID uint, billNumber string) (*models.QRISBill, error) {
+	query := `
+		SELECT id, account_id, bill_number, nominal, status, created_at, paid_at
+		FROM qris_bills
+		WHERE account_id = $1 AND bill_number = $2
+	`
+
+	var (
+		bill   models.QRISBill
+		paidAt time.Time
+	)
+	err := conn(ctx, r.db).QueryRowContext(ctx, r.dialect.rebind(query), accountID, billNumber).Scan(
+		&bill.ID,
+		&bill.AccountID,
+		&bill.BillNumber,
+		&bill.Nominal,
+		&bill.Status,
+		scanTime(&bill.CreatedAt),
+		scanTime(&paidAt),
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, r.fail("getting QRIS bill", err)
+	}
+	if !paidAt.IsZero() {
+		bill.PaidAt = &paidAt
+	}
+
+	return &bill, nil
+}
+
+func (r *qrisRepository) MarkBillPaid(ctx context.Context, id uint, paidAt time.Time) (bool, error) {
+	query := `UPDATE qris_bills SET status = $1, paid_at = $2 WHERE id = $3 AND status = $4`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, r.dialect.rebind(query),
+		models.QRISBillStatusPaid,
+		paidAt.UTC(),
+		id,
+		models.QRISBillStatusOpen,
+	)
+	if err != nil {
+		return false, r.fail("marking QRIS bill paid", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, r.fail("marking QRIS bill paid", err)
+	}
+
+	return affected == 1, nil
+}
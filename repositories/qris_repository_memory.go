@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"accounts-service/models"
+	"accounts-service/utils"
+	"context"
+	"errors"
+	"time"
+)
+
+type memoryQRISRepository struct {
+	store  *MemoryStore
+	logger utils.Logger
+}
+
+func NewMemoryQRISRepository(store *MemoryStore, logger utils.Logger) QRISRepository {
+	return &memoryQRISRepository{
+		store:  store,
+		logger: logger,
+	}
+}
+
+func (r *memoryQRISRepository) CreateBill(ctx context.Context, bill *models.QRISBill) error {
+	return r.store.write(ctx, func(state *memoryState) error {
+		for _, existing := range state.qrisBills {
+			if existing.AccountID == bill.AccountID && existing.BillNumber == bill.BillNumber {
+				return nil
+			}
+		}
+
+		if _, ok := state.accounts[bill.AccountID]; !ok {
+			r.logger.Error("Error creating QRIS bill: unknown account %d", bill.AccountID)
+			return models.QRISDBErr.Wrap(errors.New("violates foreign key constraint qris_bills_account_id_fkey"))
+		}
+
+		bill.ID = state.nextQRISBillID
+		bill.Status = models.QRISBillStatusOpen
+		bill.CreatedAt = time.Now()
+		state.qrisBills = append(state.qrisBills, *bill)
+		state.nextQRISBillID++
+		return nil
+	})
+}
+
+func (r *memoryQRISRepository) GetBill(ctx context.Context, accountID uint, billNumber string) (*models.QRISBill, error) {
+	var found *models.QRISBill
+	err := r.store.read(ctx, func(state *memoryState) error {
+		for _, bill := range state.qrisBills {
+			if bill.AccountID == accountID && bill.BillNumber == billNumber {
+				found = &bill
+				break
+			}
+		}
+		return nil
+	})
+	return found, err
+}
+
+func (r *memoryQRISRepository) MarkBillPaid(ctx context.Context, id uint, paidAt time.Time) (bool, error) {
+	var paid bool
+	err := r.store.write(ctx, func(state *memoryState) error {
+		for i := range state.qrisBills {
+			existing := &state.qrisBills[i]
+			if existing.ID == id && existing.Status == models.QRISBillStatusOpen {
+				existing.Status = models.QRISBillStatusPaid
+				existing.PaidAt = &paidAt
+				paid = true
+			}
+		}
+		return nil
+	})
+	return paid, err
+}
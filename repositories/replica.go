@@ -0,0 +1,111 @@
+package repositories
+
+import (
+	"accounts-service/utils"
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+)
+
+// replicaLagQuery returns how far the replica is behind in seconds, whether
+// its WAL receiver is streaming and how many seconds ago it last heard from
+// the primary. A replica that has replayed everything it received is not
+// lagging, even when the primary has been idle since the last replayed
+// transaction, but that only holds while it still receives from the primary:
+// a stalled receiver has replayed everything too.
+const replicaLagQuery = `
+	SELECT
+		CASE
+			WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+			ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+		END,
+		COALESCE((SELECT status = 'streaming' FROM pg_stat_wal_receiver), false),
+		COALESCE((SELECT EXTRACT(EPOCH FROM now() - last_msg_receipt_time) FROM pg_stat_wal_receiver), 0)
+`
+
+// replicaLagTimeout bounds the lag check so a hanging replica does not hold
+// up reads that can be served by the primary.
+const replicaLagTimeout = time.Second
+
+// ReplicaRouter sends read-only queries to a read replica while it is within
+// maxLag of the primary and has heard from it within maxReceiptAge, and to the
+// primary otherwise. Reads inside a
+// transaction always use the transaction on the primary.
+type ReplicaRouter struct {
+	primary       *sql.DB
+	replica       *sql.DB
+	maxLag        time.Duration
+	maxReceipt    time.Duration
+	checkInterval time.Duration
+	logger        utils.Logger
+
+	mu        sync.Mutex
+	checkedAt time.Time
+	fresh     bool
+}
+
+func NewReplicaRouter(primary, replica *sql.DB, maxLag, maxReceiptAge, checkInterval time.Duration, logger utils.Logger) *ReplicaRouter {
+	return &ReplicaRouter{
+		primary:       primary,
+		replica:       replica,
+		maxLag:        maxLag,
+		maxReceipt:    maxReceiptAge,
+		checkInterval: checkInterval,
+		logger:        logger,
+	}
+}
+
+// reader returns the connection for a read-only query.
+func (r *ReplicaRouter) reader(ctx context.Context) DBTX {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+
+	if r.replica == nil || !r.isFresh(ctx) {
+		return r.primary
+	}
+	return r.replica
+}
+
+// isFresh reports whether the replica is streaming from the primary, has
+// heard from it within maxReceipt and lags within maxLag. The replica is
+// checked at most once per checkInterval.
+func (r *ReplicaRouter) isFresh(ctx context.Context) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Since(r.checkedAt) < r.checkInterval {
+		return r.fresh
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, replicaLagTimeout)
+	defer cancel()
+
+	var (
+		lagSeconds, receiptSeconds float64
+		streaming                  bool
+	)
+	err := r.replica.QueryRowContext(ctx, replicaLagQuery).Scan(&lagSeconds, &streaming, &receiptSeconds)
+	lag := time.Duration(lagSeconds * float64(time.Second))
+	receipt := time.Duration(receiptSeconds * float64(time.Second))
+	switch {
+	case err != nil:
+		r.logger.Warning("Error checking replica lag, reading from primary: %v", err)
+		r.fresh = false
+	case !streaming:
+		r.logger.Warning("Replica is not streaming from the primary, reading from primary")
+		r.fresh = false
+	case receipt > r.maxReceipt:
+		r.logger.Warning("Replica has not heard from the primary for %s, reading from primary", receipt)
+		r.fresh = false
+	case lag > r.maxLag:
+		r.logger.Warning("Replica lag %s exceeds %s, reading from primary", lag, r.maxLag)
+		r.fresh = false
+	default:
+		r.fresh = true
+	}
+
+	r.checkedAt = time.Now()
+	return r.fresh
+}
@@ -0,0 +1,104 @@
+package repositories_test
+
+import (
+	"accounts-service/repositories"
+	"accounts-service/utils"
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var accountColumns = []string{"id", "name", "nik", "no_hp", "no_rekening", "saldo", "created_at", "updated_at"}
+
+func accountRow(saldo float64) *sqlmock.Rows {
+	return sqlmock.NewRows(accountColumns).
+		AddRow(1, "Siti", "3201014508950001", "+6281234567890", "1744847261", saldo, time.Now(), time.Now())
+}
+
+// lagRow is the replica lag, whether its WAL receiver is streaming and the
+// seconds since it last heard from the primary.
+func lagRow(lag float64, streaming bool, receipt float64) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"lag", "streaming", "receipt"}).AddRow(lag, streaming, receipt)
+}
+
+func TestReplicaRouter(t *testing.T) {
+	logger := utils.NewLogger("critical")
+	ctx := context.Background()
+
+	setup := func(t *testing.T) (primary, replica sqlmock.Sqlmock, repo repositories.AccountRepository, txManager repositories.TxManager) {
+		primaryDB, primary, err := sqlmock.New()
+		require.NoError(t, err)
+		t.Cleanup(func() { primaryDB.Close() })
+
+		replicaDB, replica, err := sqlmock.New()
+		require.NoError(t, err)
+		t.Cleanup(func() { replicaDB.Close() })
+
+		router := repositories.NewReplicaRouter(primaryDB, replicaDB, 5*time.Second, time.Minute, time.Minute, logger)
+		repo = repositories.NewAccountRepositoryWithReplica(primaryDB, router, logger)
+		txManager = repositories.NewTxManager(primaryDB, sql.LevelDefault, 0, logger)
+		return primary, replica, repo, txManager
+	}
+
+	t.Run("fresh replica serves reads", func(t *testing.T) {
+		primary, replica, repo, _ := setup(t)
+
+		replica.ExpectQuery(`pg_last_wal_replay_lsn`).WillReturnRows(lagRow(0.2, true, 10))
+		replica.ExpectQuery(`FROM accounts`).WithArgs("1744847261").WillReturnRows(accountRow(1000))
+		replica.ExpectQuery(`FROM accounts`).WithArgs("1744847261").WillReturnRows(accountRow(1000))
+
+		for i := 0; i < 2; i++ {
+			account, err := repo.GetAccountByNoRekening(ctx, "1744847261")
+			require.NoError(t, err)
+			assert.Equal(t, float64(1000), account.Saldo)
+		}
+
+		assert.NoError(t, replica.ExpectationsWereMet())
+		assert.NoError(t, primary.ExpectationsWereMet())
+	})
+
+	for name, lag := range map[string]*sqlmock.Rows{
+		"lagging replica falls back to primary":             lagRow(30, true, 1),
+		"stopped or missing receiver falls back to primary": lagRow(0, false, 0),
+		"stalled receiver falls back to primary":            lagRow(0, true, 120),
+	} {
+		t.Run(name, func(t *testing.T) {
+			primary, replica, repo, _ := setup(t)
+
+			replica.ExpectQuery(`pg_stat_wal_receiver`).WillReturnRows(lag)
+			primary.ExpectQuery(`FROM accounts`).WithArgs("1744847261").WillReturnRows(accountRow(2000))
+
+			account, err := repo.GetAccountByNoRekening(ctx, "1744847261")
+			require.NoError(t, err)
+			assert.Equal(t, float64(2000), account.Saldo)
+
+			assert.NoError(t, replica.ExpectationsWereMet())
+			assert.NoError(t, primary.ExpectationsWereMet())
+		})
+	}
+
+	t.Run("reads inside a transaction stay on primary", func(t *testing.T) {
+		primary, replica, repo, txManager := setup(t)
+
+		primary.ExpectBegin()
+		primary.ExpectQuery(`FROM accounts`).WithArgs("1744847261").WillReturnRows(accountRow(3000))
+		primary.ExpectCommit()
+
+		err := txManager.WithinTx(ctx, func(ctx context.Context) error {
+			account, err := repo.GetAccountByNoRekening(ctx, "1744847261")
+			if err == nil {
+				assert.Equal(t, float64(3000), account.Saldo)
+			}
+			return err
+		})
+		require.NoError(t, err)
+
+		assert.NoError(t, replica.ExpectationsWereMet())
+		assert.NoError(t, primary.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,165 @@
+package repositories
+
+import (
+	"accounts-service/models"
+	"accounts-service/utils"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// serializationFailureCodes are the Postgres error codes after which a
+// transaction can be safely retried.
+var serializationFailureCodes = map[pq.ErrorCode]bool{
+	"40001": true, // serialization_failure
+	"40P01": true, // deadlock_detected
+}
+
+// sqliteBusy is the SQLite result code for a database locked by another
+// connection.
+const sqliteBusy = 5
+
+// TxManager runs units of work in a database transaction. The transaction is
+// carried in the context, and every repository method called with that
+// context joins it.
+type TxManager interface {
+	// WithinTx runs fn in a transaction, committing when fn returns nil and
+	// rolling back otherwise. Calls nested inside fn join the outer
+	// transaction. Serialization failures are retried, so fn must be safe to
+	// run more than once.
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type afterCommitKey struct{}
+
+// afterCommitHooks are the funcs registered with AfterCommit during one
+// attempt of a transaction.
+type afterCommitHooks struct {
+	fns []func()
+}
+
+// withAfterCommit returns ctx carrying a new list of hooks for the
+// transaction it starts.
+func withAfterCommit(ctx context.Context) (context.Context, *afterCommitHooks) {
+	hooks := &afterCommitHooks{}
+	return context.WithValue(ctx, afterCommitKey{}, hooks), hooks
+}
+
+// run calls the hooks in the order they were registered.
+func (h *afterCommitHooks) run() {
+	for _, fn := range h.fns {
+		fn()
+	}
+}
+
+// AfterCommit runs fn once the outermost transaction carried by ctx commits,
+// or right away outside a transaction. fn is dropped when the transaction
+// rolls back, so state kept outside the database, like the balance cache,
+// never sees what was not committed.
+func AfterCommit(ctx context.Context, fn func()) {
+	if hooks, ok := ctx.Value(afterCommitKey{}).(*afterCommitHooks); ok {
+		hooks.fns = append(hooks.fns, fn)
+		return
+	}
+	fn()
+}
+
+// DBTX is the subset of *sql.DB and *sql.Tx used by the repositories.
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+type txKey struct{}
+
+type txManager struct {
+	db         *sql.DB
+	opts       *sql.TxOptions
+	maxRetries int
+	logger     utils.Logger
+}
+
+func NewTxManager(db *sql.DB, isolation sql.IsolationLevel, maxRetries int, logger utils.Logger) TxManager {
+	return &txManager{
+		db:         db,
+		opts:       &sql.TxOptions{Isolation: isolation},
+		maxRetries: maxRetries,
+		logger:     logger,
+	}
+}
+
+func (m *txManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := m.run(ctx, fn)
+		if err == nil || !isSerializationFailure(err) || attempt >= m.maxRetries {
+			return err
+		}
+
+		m.logger.Warning("Retrying transaction after serialization failure (attempt %d/%d): %v", attempt+1, m.maxRetries, err)
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(time.Duration(attempt+1) * 10 * time.Millisecond):
+		}
+	}
+}
+
+func (m *txManager) run(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	tx, err := m.db.BeginTx(ctx, m.opts)
+	if err != nil {
+		m.logger.Error("Error beginning transaction: %v", err)
+		return models.CreateTransactionDBErr.Wrap(err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+				m.logger.Error("Error rolling back transaction: %v", rbErr)
+			}
+		}
+	}()
+
+	ctx, hooks := withAfterCommit(context.WithValue(ctx, txKey{}, tx))
+	if err = fn(ctx); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		m.logger.Error("Error committing transaction: %v", err)
+		return models.CommitTransactionDBErr.Wrap(err)
+	}
+
+	hooks.run()
+	return nil
+}
+
+// conn returns the transaction carried by ctx, or db outside a transaction.
+func conn(ctx context.Context, db *sql.DB) DBTX {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return db
+}
+
+func isSerializationFailure(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return serializationFailureCodes[pqErr.Code]
+	}
+
+	var sqliteErr interface{ Code() int }
+	return errors.As(err, &sqliteErr) && sqliteErr.Code()&0xff == sqliteBusy
+}
@@ -0,0 +1,152 @@
+package repositories_test
+
+import (
+	"accounts-service/models"
+	"accounts-service/repositories"
+	"accounts-service/utils"
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTxManager_WithinTx(t *testing.T) {
+	logger := utils.NewLogger("critical")
+
+	t.Run("rollback when fn fails", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		txManager := repositories.NewTxManager(db, sql.LevelDefault, 0, logger)
+
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		err = txManager.WithinTx(context.Background(), func(ctx context.Context) error {
+			return models.AccountinsufficientErr
+		})
+		assert.ErrorIs(t, err, models.AccountinsufficientErr)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("nested call joins outer transaction", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		txManager := repositories.NewTxManager(db, sql.LevelDefault, 0, logger)
+
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+
+		err = txManager.WithinTx(context.Background(), func(ctx context.Context) error {
+			return txManager.WithinTx(ctx, func(ctx context.Context) error { return nil })
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("retry serialization failure", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		repo := repositories.NewAccountRepository(db, logger)
+		txManager := repositories.NewTxManager(db, sql.LevelSerializable, 1, logger)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE accounts`).WillReturnError(&pq.Error{Code: "40001"})
+		mock.ExpectRollback()
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE accounts`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		attempts := 0
+		err = txManager.WithinTx(context.Background(), func(ctx context.Context) error {
+			attempts++
+			return repo.UpdateSaldo(ctx, 1, 1000)
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, attempts)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("commit failure", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		txManager := repositories.NewTxManager(db, sql.LevelDefault, 0, logger)
+
+		mock.ExpectBegin()
+		mock.ExpectCommit().WillReturnError(errors.New("connection reset"))
+
+		err = txManager.WithinTx(context.Background(), func(ctx context.Context) error { return nil })
+		assert.ErrorIs(t, err, models.CommitTransactionDBErr)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+	t.Run("after commit hooks wait for the outermost commit", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		txManager := repositories.NewTxManager(db, sql.LevelDefault, 0, logger)
+
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+		mock.ExpectBegin()
+		mock.ExpectCommit().WillReturnError(errors.New("connection reset"))
+
+		var ran []string
+		err = txManager.WithinTx(context.Background(), func(ctx context.Context) error {
+			err := txManager.WithinTx(ctx, func(ctx context.Context) error {
+				repositories.AfterCommit(ctx, func() { ran = append(ran, "nested") })
+				return nil
+			})
+			assert.Empty(t, ran, "the outer transaction has not committed")
+			return err
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"nested"}, ran)
+
+		err = txManager.WithinTx(context.Background(), func(ctx context.Context) error {
+			repositories.AfterCommit(ctx, func() { ran = append(ran, "rolled back") })
+			return models.AccountinsufficientErr
+		})
+		assert.ErrorIs(t, err, models.AccountinsufficientErr)
+		err = txManager.WithinTx(context.Background(), func(ctx context.Context) error {
+			repositories.AfterCommit(ctx, func() { ran = append(ran, "commit failed") })
+			return nil
+		})
+		assert.ErrorIs(t, err, models.CommitTransactionDBErr)
+		assert.Equal(t, []string{"nested"}, ran)
+
+		repositories.AfterCommit(context.Background(), func() { ran = append(ran, "outside") })
+		assert.Equal(t, []string{"nested", "outside"}, ran, "run right away outside a transaction")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("memory after commit hooks are dropped on rollback", func(t *testing.T) {
+		txManager := repositories.NewMemoryTxManager(repositories.NewMemoryStore(), logger)
+
+		var ran []string
+		err := txManager.WithinTx(context.Background(), func(ctx context.Context) error {
+			repositories.AfterCommit(ctx, func() { ran = append(ran, "rolled back") })
+			return models.AccountinsufficientErr
+		})
+		assert.ErrorIs(t, err, models.AccountinsufficientErr)
+		err = txManager.WithinTx(context.Background(), func(ctx context.Context) error {
+			repositories.AfterCommit(ctx, func() { ran = append(ran, "committed") })
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"committed"}, ran)
+	})
+}
@@ -0,0 +1,142 @@
+package repositories
+
+import (
+	"accounts-service/models"
+	"accounts-service/utils"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type VirtualAccountRepository interface {
+	// CreateVirtualAccount records an open virtual account. It does nothing
+	// and leaves virtualAccount.ID zero when the VA number is already
+	// issued.
+	CreateVirtualAccount(ctx context.Context, virtualAccount *models.VirtualAccount) error
+	// GetVirtualAccount returns a virtual account with the no_rekening it is
+	// mapped to, or nil when the VA number is unknown.
+	GetVirtualAccount(ctx context.Context, number string) (*models.VirtualAccount, error)
+	// CloseVirtualAccount closes an open virtual account at closedAt. It
+	// reports false and changes nothing when it is no longer open, so a
+	// single use virtual account is only paid once.
+	CloseVirtualAccount(ctx context.Context, id uint, closedAt time.Time) (bool, error)
+}
+
+type virtualAccountRepository struct {
+	db      *sql.DB
+	dialect Dialect
+	logger  utils.Logger
+}
+
+func NewVirtualAccountRepository(db *sql.DB, logger utils.Logger) VirtualAccountRepository {
+	return &virtualAccountRepository{
+		db:      db,
+		dialect: DialectPostgres,
+		logger:  logger,
+	}
+}
+
+func NewSQLiteVirtualAccountRepository(db *sql.DB, logger utils.Logger) VirtualAccountRepository {
+	return &virtualAccountRepository{
+		db:      db,
+		dialect: DialectSQLite,
+		logger:  logger,
+	}
+}
+
+func (r *virtualAccountRepository) fail(action string, err error) error {
+	r.logger.Error("Error %s: %v", action, err)
+	return models.VirtualAccountDBErr.Wrap(err)
+}
+
+func (r *virtualAccountRepository) CreateVirtualAccount(ctx context.Context, virtualAccount *models.VirtualAccount) error {
+	query := `
+		INSERT INTO virtual_accounts (va_number, account_id, name, nominal, single_use, status, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (va_number) DO NOTHING
+		RETURNING id, created_at
+	`
+
+	virtualAccount.Status = models.VirtualAccountStatusOpen
+	err := conn(ctx, r.db).QueryRowContext(ctx, r.dialect.rebind(query),
+		virtualAccount.Number,
+		virtualAccount.AccountID,
+		virtualAccount.Name,
+		sql.NullFloat64{Float64: virtualAccount.Nominal, Valid: virtualAccount.Nominal != 0},
+		virtualAccount.SingleUse,
+		virtualAccount.Status,
+		nullTime(virtualAccount.ExpiresAt),
+	).Scan(&virtualAccount.ID, scanTime(&virtualAccount.CreatedAt))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return r.fail("creating virtual account", err)
+	}
+
+	return nil
+}
+
+func (r *virtualAccountRepository) GetVirtualAccount(ctx context.Context, number string) (*models.VirtualAccount, error) {
+	query := `
+		SELECT v.id, v.va_number, v.account_id, a.no_rekening, v.name, COALESCE(v.nominal, 0),
+			v.single_use, v.status, v.expires_at, v.created_at, v.closed_at
+		FROM virtual_accounts v
+		JOIN accounts a ON a.id = v.account_id
+		WHERE v.va_number = $1
+	`
+
+	var (
+		virtualAccount models.VirtualAccount
+		expiresAt      time.Time
+		closedAt       time.Time
+	)
+	err := conn(ctx, r.db).QueryRowContext(ctx, r.dialect.rebind(query), number).Scan(
+		&virtualAccount.ID,
+		&virtualAccount.Number,
+		&virtualAccount.AccountID,
+		&virtualAccount.NoRekening,
+		&virtualAccount.Name,
+		&virtualAccount.Nominal,
+		&virtualAccount.SingleUse,
+		&virtualAccount.Status,
+		scanTime(&expiresAt),
+		scanTime(&virtualAccount.CreatedAt),
+		scanTime(&closedAt),
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, r.fail("getting virtual account", err)
+	}
+	if !expiresAt.IsZero() {
+		virtualAccount.ExpiresAt = &expiresAt
+	}
+	if !closedAt.IsZero() {
+		virtualAccount.ClosedAt = &closedAt
+	}
+
+	return &virtualAccount, nil
+}
+
+func (r *virtualAccountRepository) CloseVirtualAccount(ctx context.Context, id uint, closedAt time.Time) (bool, error) {
+	query := `UPDATE virtual_accounts SET status = $1, closed_at = $2 WHERE id = $3 AND status = $4`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, r.dialect.rebind(query),
+		models.VirtualAccountStatusClosed,
+		closedAt.UTC(),
+		id,
+		models.VirtualAccountStatusOpen,
+	)
+	if err != nil {
+		return false, r.fail("closing virtual account", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, r.fail("closing virtual account", err)
+	}
+
+	return affected == 1, nil
+}
@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"accounts-service/models"
+	"accounts-service/utils"
+	"context"
+	"errors"
+	"time"
+)
+
+type memoryVirtualAccountRepository struct {
+	store  *MemoryStore
+	logger utils.Logger
+}
+
+func NewMemoryVirtualAccountRepository(store *MemoryStore, logger utils.Logger) VirtualAccountRepository {
+	return &memoryVirtualAccountRepository{
+		store:  store,
+		logger: logger,
+	}
+}
+
+func (r *memoryVirtualAccountRepository) CreateVirtualAccount(ctx context.Context, virtualAccount *models.VirtualAccount) error {
+	return r.store.write(ctx, func(state *memoryState) error {
+		for _, existing := range state.virtualAccounts {
+			if existing.Number == virtualAccount.Number {
+				return nil
+			}
+		}
+
+		account, ok := state.accounts[virtualAccount.AccountID]
+		if !ok {
+			r.logger.Error("Error creating virtual account: unknown account %d", virtualAccount.AccountID)
+			return models.VirtualAccountDBErr.Wrap(errors.New("violates foreign key constraint virtual_accounts_account_id_fkey"))
+		}
+
+		virtualAccount.ID = state.nextVirtualAccountID
+		virtualAccount.NoRekening = account.NoRekening
+		virtualAccount.Status = models.VirtualAccountStatusOpen
+		virtualAccount.CreatedAt = time.Now()
+		state.virtualAccounts = append(state.virtualAccounts, *virtualAccount)
+		state.nextVirtualAccountID++
+		return nil
+	})
+}
+
+func (r *memoryVirtualAccountRepository) GetVirtualAccount(ctx context.Context, number string) (*models.VirtualAccount, error) {
+	var found *models.VirtualAccount
+	err := r.store.read(ctx, func(state *memoryState) error {
+		for _, virtualAccount := range state.virtualAccounts {
+			if virtualAccount.Number == number {
+				found = &virtualAccount
+				break
+			}
+		}
+		return nil
+	})
+	return found, err
+}
+
+func (r *memoryVirtualAccountRepository) CloseVirtualAccount(ctx context.Context, id uint, closedAt time.Time) (bool, error) {
+	var closed bool
+	err := r.store.write(ctx, func(state *memoryState) error {
+		for i := range state.virtualAccounts {
+			existing := &state.virtualAccounts[i]
+			if existing.ID == id && existing.Status == models.VirtualAccountStatusOpen {
+				existing.Status = models.VirtualAccountStatusClosed
+				existing.ClosedAt = &closedAt
+				closed = true
+			}
+		}
+		return nil
+	})
+	return closed, err
+}
@@ -0,0 +1,404 @@
+package repositories
+
+import (
+	"accounts-service/models"
+	"accounts-service/utils"
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+type WebhookRepository interface {
+	CreateWebhook(ctx context.Context, webhook *models.Webhook) error
+	// GetWebhook returns nil when the webhook does not exist.
+	GetWebhook(ctx context.Context, id uint) (*models.Webhook, error)
+	ListWebhooks(ctx context.Context) ([]models.Webhook, error)
+	UpdateWebhook(ctx context.Context, webhook *models.Webhook) error
+	DeleteWebhook(ctx context.Context, id uint) error
+
+	// CreateDelivery records a pending delivery. It does nothing and leaves
+	// delivery.ID zero when the webhook already has a delivery of the event.
+	CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	// ListDueDeliveries returns up to limit pending deliveries due at now,
+	// oldest first.
+	ListDueDeliveries(ctx context.Context, now time.Time, limit int) ([]models.WebhookDelivery, error)
+	// ListDeliveries returns the latest deliveries of a webhook, optionally
+	// only those with status.
+	ListDeliveries(ctx context.Context, webhookID uint, status string, limit int) ([]models.WebhookDelivery, error)
+	// GetDelivery returns a delivery of the webhook with its log, or nil
+	// when it does not exist.
+	GetDelivery(ctx context.Context, webhookID, id uint) (*models.WebhookDelivery, error)
+	// RecordAttempt logs an attempt and moves its delivery to status, to be
+	// retried at nextAttemptAt when it is still pending.
+	RecordAttempt(ctx context.Context, attempt *models.WebhookAttempt, status string, nextAttemptAt time.Time) error
+	// Redeliver makes a delivery pending again with fresh attempts.
+	Redeliver(ctx context.Context, id uint, at time.Time) error
+}
+
+type webhookRepository struct {
+	db      *sql.DB
+	dialect Dialect
+	logger  utils.Logger
+}
+
+func NewWebhookRepository(db *sql.DB, logger utils.Logger) WebhookRepository {
+	return &webhookRepository{
+		db:      db,
+		dialect: DialectPostgres,
+		logger:  logger,
+	}
+}
+
+func NewSQLiteWebhookRepository(db *sql.DB, logger utils.Logger) WebhookRepository {
+	return &webhookRepository{
+		db:      db,
+		dialect: DialectSQLite,
+		logger:  logger,
+	}
+}
+
+const webhookColumns = `id, url, event_types, COALESCE(no_rekening, ''), secret, active, created_at, updated_at`
+
+const deliveryColumns = `id, webhook_id, event_id, event_type, body, status, attempts, next_attempt_at,
+	COALESCE(last_status_code, 0), COALESCE(last_error, ''), created_at, delivered_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanWebhook(row rowScanner) (*models.Webhook, error) {
+	var (
+		webhook    models.Webhook
+		eventTypes string
+	)
+	err := row.Scan(
+		&webhook.ID,
+		&webhook.URL,
+		&eventTypes,
+		&webhook.NoRekening,
+		&webhook.Secret,
+		&webhook.Active,
+		scanTime(&webhook.CreatedAt),
+		scanTime(&webhook.UpdatedAt),
+	)
+	if err != nil {
+		return nil, err
+	}
+	webhook.EventTypes = strings.Split(eventTypes, ",")
+	return &webhook, nil
+}
+
+func scanDelivery(row rowScanner) (*models.WebhookDelivery, error) {
+	var (
+		delivery    models.WebhookDelivery
+		body        []byte
+		deliveredAt time.Time
+	)
+	err := row.Scan(
+		&delivery.ID,
+		&delivery.WebhookID,
+		&delivery.EventID,
+		&delivery.EventType,
+		&body,
+		&delivery.Status,
+		&delivery.Attempts,
+		scanTime(&delivery.NextAttemptAt),
+		&delivery.LastStatusCode,
+		&delivery.LastError,
+		scanTime(&delivery.CreatedAt),
+		scanTime(&deliveredAt),
+	)
+	if err != nil {
+		return nil, err
+	}
+	delivery.Body = body
+	if !deliveredAt.IsZero() {
+		delivery.DeliveredAt = &deliveredAt
+	}
+	return &delivery, nil
+}
+
+// nullString stores an empty string as NULL.
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func (r *webhookRepository) fail(action string, err error) error {
+	r.logger.Error("Error %s: %v", action, err)
+	return models.WebhookDBErr.Wrap(err)
+}
+
+func (r *webhookRepository) CreateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	query := `
+		INSERT INTO webhooks (url, event_types, no_rekening, secret, active)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, updated_at
+	`
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, r.dialect.rebind(query),
+		webhook.URL,
+		strings.Join(webhook.EventTypes, ","),
+		nullString(webhook.NoRekening),
+		webhook.Secret,
+		webhook.Active,
+	).Scan(&webhook.ID, scanTime(&webhook.CreatedAt), scanTime(&webhook.UpdatedAt))
+	if err != nil {
+		return r.fail("creating webhook", err)
+	}
+
+	return nil
+}
+
+func (r *webhookRepository) GetWebhook(ctx context.Context, id uint) (*models.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE id = $1`
+
+	webhook, err := scanWebhook(conn(ctx, r.db).QueryRowContext(ctx, r.dialect.rebind(query), id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, r.fail("getting webhook", err)
+	}
+
+	return webhook, nil
+}
+
+func (r *webhookRepository) ListWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks ORDER BY id`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query)
+	if err != nil {
+		return nil, r.fail("listing webhooks", err)
+	}
+	defer rows.Close()
+
+	webhooks := []models.Webhook{}
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, r.fail("scanning webhook", err)
+		}
+		webhooks = append(webhooks, *webhook)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, r.fail("listing webhooks", err)
+	}
+
+	return webhooks, nil
+}
+
+func (r *webhookRepository) UpdateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	query := `
+		UPDATE webhooks
+		SET url = $1, event_types = $2, no_rekening = $3, active = $4, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $5
+		RETURNING updated_at
+	`
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, r.dialect.rebind(query),
+		webhook.URL,
+		strings.Join(webhook.EventTypes, ","),
+		nullString(webhook.NoRekening),
+		webhook.Active,
+		webhook.ID,
+	).Scan(scanTime(&webhook.UpdatedAt))
+	if err != nil {
+		return r.fail("updating webhook", err)
+	}
+
+	return nil
+}
+
+func (r *webhookRepository) DeleteWebhook(ctx context.Context, id uint) error {
+	// Deliveries are removed explicitly, SQLite only cascades with foreign keys on
+	for _, query := range []string{
+		`DELETE FROM webhook_delivery_attempts WHERE delivery_id IN (SELECT id FROM webhook_deliveries WHERE webhook_id = $1)`,
+		`DELETE FROM webhook_deliveries WHERE webhook_id = $1`,
+		`DELETE FROM webhooks WHERE id = $1`,
+	} {
+		if _, err := conn(ctx, r.db).ExecContext(ctx, r.dialect.rebind(query), id); err != nil {
+			return r.fail("deleting webhook", err)
+		}
+	}
+
+	return nil
+}
+
+func (r *webhookRepository) CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	query := `
+		INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, body, status, next_attempt_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (webhook_id, event_id) DO NOTHING
+		RETURNING id, created_at
+	`
+
+	delivery.Status = models.WebhookDeliveryPending
+	err := conn(ctx, r.db).QueryRowContext(ctx, r.dialect.rebind(query),
+		delivery.WebhookID,
+		delivery.EventID,
+		delivery.EventType,
+		string(delivery.Body),
+		delivery.Status,
+		delivery.NextAttemptAt.UTC(),
+	).Scan(&delivery.ID, scanTime(&delivery.CreatedAt))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return r.fail("creating webhook delivery", err)
+	}
+
+	return nil
+}
+
+func (r *webhookRepository) ListDueDeliveries(ctx context.Context, now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	query := `
+		SELECT ` + deliveryColumns + `
+		FROM webhook_deliveries
+		WHERE status = 'pending' AND next_attempt_at <= $1
+		ORDER BY id
+		LIMIT $2
+	`
+
+	return r.listDeliveries(ctx, query, now.UTC(), limit)
+}
+
+func (r *webhookRepository) ListDeliveries(ctx context.Context, webhookID uint, status string, limit int) ([]models.WebhookDelivery, error) {
+	query := `
+		SELECT ` + deliveryColumns + `
+		FROM webhook_deliveries
+		WHERE webhook_id = $1 AND ($2 = '' OR status = $3)
+		ORDER BY id DESC
+		LIMIT $4
+	`
+
+	return r.listDeliveries(ctx, query, webhookID, status, status, limit)
+}
+
+func (r *webhookRepository) listDeliveries(ctx context.Context, query string, args ...interface{}) ([]models.WebhookDelivery, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, r.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, r.fail("listing webhook deliveries", err)
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, r.fail("scanning webhook delivery", err)
+		}
+		deliveries = append(deliveries, *delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, r.fail("listing webhook deliveries", err)
+	}
+
+	return deliveries, nil
+}
+
+func (r *webhookRepository) GetDelivery(ctx context.Context, webhookID, id uint) (*models.WebhookDelivery, error) {
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries WHERE id = $1 AND webhook_id = $2`
+
+	delivery, err := scanDelivery(conn(ctx, r.db).QueryRowContext(ctx, r.dialect.rebind(query), id, webhookID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, r.fail("getting webhook delivery", err)
+	}
+
+	logQuery := `
+		SELECT id, delivery_id, COALESCE(status_code, 0), COALESCE(error, ''), duration_ms, created_at
+		FROM webhook_delivery_attempts
+		WHERE delivery_id = $1
+		ORDER BY id
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, r.dialect.rebind(logQuery), id)
+	if err != nil {
+		return nil, r.fail("listing webhook delivery log", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var attempt models.WebhookAttempt
+		err := rows.Scan(
+			&attempt.ID,
+			&attempt.DeliveryID,
+			&attempt.StatusCode,
+			&attempt.Error,
+			&attempt.DurationMs,
+			scanTime(&attempt.CreatedAt),
+		)
+		if err != nil {
+			return nil, r.fail("scanning webhook delivery log", err)
+		}
+		delivery.Log = append(delivery.Log, attempt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, r.fail("listing webhook delivery log", err)
+	}
+
+	return delivery, nil
+}
+
+func (r *webhookRepository) RecordAttempt(ctx context.Context, attempt *models.WebhookAttempt, status string, nextAttemptAt time.Time) error {
+	insert := `
+		INSERT INTO webhook_delivery_attempts (delivery_id, status_code, error, duration_ms)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, r.dialect.rebind(insert),
+		attempt.DeliveryID,
+		sql.NullInt64{Int64: int64(attempt.StatusCode), Valid: attempt.StatusCode != 0},
+		nullString(attempt.Error),
+		attempt.DurationMs,
+	).Scan(&attempt.ID, scanTime(&attempt.CreatedAt))
+	if err != nil {
+		return r.fail("logging webhook attempt", err)
+	}
+
+	var deliveredAt sql.NullTime
+	if status == models.WebhookDeliveryDelivered {
+		deliveredAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+	}
+
+	update := `
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = attempts + 1, next_attempt_at = $2,
+			last_status_code = $3, last_error = $4, delivered_at = $5
+		WHERE id = $6
+	`
+
+	_, err = conn(ctx, r.db).ExecContext(ctx, r.dialect.rebind(update),
+		status,
+		nextAttemptAt.UTC(),
+		sql.NullInt64{Int64: int64(attempt.StatusCode), Valid: attempt.StatusCode != 0},
+		nullString(attempt.Error),
+		deliveredAt,
+		attempt.DeliveryID,
+	)
+	if err != nil {
+		return r.fail("updating webhook delivery", err)
+	}
+
+	return nil
+}
+
+func (r *webhookRepository) Redeliver(ctx context.Context, id uint, at time.Time) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = 'pending', attempts = 0, next_attempt_at = $1, delivered_at = NULL
+		WHERE id = $2
+	`
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, r.dialect.rebind(query), at.UTC(), id); err != nil {
+		return r.fail("redelivering webhook delivery", err)
+	}
+
+	return nil
+}
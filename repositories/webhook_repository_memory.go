@@ -0,0 +1,224 @@
+package repositories
+
+import (
+	"accounts-service/models"
+	"accounts-service/utils"
+	"context"
+	"sort"
+	"time"
+)
+
+type memoryWebhookRepository struct {
+	store  *MemoryStore
+	logger utils.Logger
+}
+
+func NewMemoryWebhookRepository(store *MemoryStore, logger utils.Logger) WebhookRepository {
+	return &memoryWebhookRepository{
+		store:  store,
+		logger: logger,
+	}
+}
+
+func (r *memoryWebhookRepository) CreateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	return r.store.write(ctx, func(state *memoryState) error {
+		webhook.ID = state.nextWebhookID
+		webhook.CreatedAt = time.Now()
+		webhook.UpdatedAt = webhook.CreatedAt
+
+		state.webhooks[webhook.ID] = cloneWebhook(*webhook)
+		state.nextWebhookID++
+		return nil
+	})
+}
+
+func (r *memoryWebhookRepository) GetWebhook(ctx context.Context, id uint) (*models.Webhook, error) {
+	var found *models.Webhook
+	err := r.store.read(ctx, func(state *memoryState) error {
+		if webhook, ok := state.webhooks[id]; ok {
+			webhook = cloneWebhook(webhook)
+			found = &webhook
+		}
+		return nil
+	})
+	return found, err
+}
+
+func (r *memoryWebhookRepository) ListWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	webhooks := []models.Webhook{}
+	err := r.store.read(ctx, func(state *memoryState) error {
+		for _, webhook := range state.webhooks {
+			webhooks = append(webhooks, cloneWebhook(webhook))
+		}
+		return nil
+	})
+	sort.Slice(webhooks, func(i, j int) bool { return webhooks[i].ID < webhooks[j].ID })
+	return webhooks, err
+}
+
+func (r *memoryWebhookRepository) UpdateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	return r.store.write(ctx, func(state *memoryState) error {
+		current, ok := state.webhooks[webhook.ID]
+		if !ok {
+			return nil
+		}
+
+		current.URL = webhook.URL
+		current.EventTypes = webhook.EventTypes
+		current.NoRekening = webhook.NoRekening
+		current.Active = webhook.Active
+		current.UpdatedAt = time.Now()
+		webhook.UpdatedAt = current.UpdatedAt
+
+		state.webhooks[webhook.ID] = cloneWebhook(current)
+		return nil
+	})
+}
+
+func (r *memoryWebhookRepository) DeleteWebhook(ctx context.Context, id uint) error {
+	return r.store.write(ctx, func(state *memoryState) error {
+		delete(state.webhooks, id)
+
+		deliveries := state.deliveries[:0]
+		removed := make(map[uint]bool)
+		for _, delivery := range state.deliveries {
+			if delivery.WebhookID == id {
+				removed[delivery.ID] = true
+				continue
+			}
+			deliveries = append(deliveries, delivery)
+		}
+		state.deliveries = deliveries
+
+		attempts := state.attempts[:0]
+		for _, attempt := range state.attempts {
+			if !removed[attempt.DeliveryID] {
+				attempts = append(attempts, attempt)
+			}
+		}
+		state.attempts = attempts
+		return nil
+	})
+}
+
+func (r *memoryWebhookRepository) CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return r.store.write(ctx, func(state *memoryState) error {
+		for _, existing := range state.deliveries {
+			if existing.WebhookID == delivery.WebhookID && existing.EventID == delivery.EventID {
+				return nil
+			}
+		}
+
+		delivery.ID = state.nextDeliveryID
+		delivery.Status = models.WebhookDeliveryPending
+		delivery.CreatedAt = time.Now()
+
+		state.deliveries = append(state.deliveries, *delivery)
+		state.nextDeliveryID++
+		return nil
+	})
+}
+
+func (r *memoryWebhookRepository) ListDueDeliveries(ctx context.Context, now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	return r.listDeliveries(ctx, limit, false, func(delivery *models.WebhookDelivery) bool {
+		return delivery.Status == models.WebhookDeliveryPending && !delivery.NextAttemptAt.After(now)
+	})
+}
+
+func (r *memoryWebhookRepository) ListDeliveries(ctx context.Context, webhookID uint, status string, limit int) ([]models.WebhookDelivery, error) {
+	return r.listDeliveries(ctx, limit, true, func(delivery *models.WebhookDelivery) bool {
+		return delivery.WebhookID == webhookID && (status == "" || delivery.Status == status)
+	})
+}
+
+func (r *memoryWebhookRepository) listDeliveries(ctx context.Context, limit int, newestFirst bool, match func(delivery *models.WebhookDelivery) bool) ([]models.WebhookDelivery, error) {
+	deliveries := []models.WebhookDelivery{}
+	err := r.store.read(ctx, func(state *memoryState) error {
+		for i := range state.deliveries {
+			delivery := state.deliveries[i]
+			if newestFirst {
+				delivery = state.deliveries[len(state.deliveries)-1-i]
+			}
+			if len(deliveries) == limit {
+				break
+			}
+			if match(&delivery) {
+				deliveries = append(deliveries, delivery)
+			}
+		}
+		return nil
+	})
+	return deliveries, err
+}
+
+func (r *memoryWebhookRepository) GetDelivery(ctx context.Context, webhookID, id uint) (*models.WebhookDelivery, error) {
+	var found *models.WebhookDelivery
+	err := r.store.read(ctx, func(state *memoryState) error {
+		for _, delivery := range state.deliveries {
+			if delivery.ID == id && delivery.WebhookID == webhookID {
+				found = &delivery
+				break
+			}
+		}
+		if found == nil {
+			return nil
+		}
+
+		for _, attempt := range state.attempts {
+			if attempt.DeliveryID == id {
+				found.Log = append(found.Log, attempt)
+			}
+		}
+		return nil
+	})
+	return found, err
+}
+
+func (r *memoryWebhookRepository) RecordAttempt(ctx context.Context, attempt *models.WebhookAttempt, status string, nextAttemptAt time.Time) error {
+	return r.store.write(ctx, func(state *memoryState) error {
+		attempt.ID = state.nextAttemptID
+		attempt.CreatedAt = time.Now()
+		state.attempts = append(state.attempts, *attempt)
+		state.nextAttemptID++
+
+		return r.updateDelivery(state, attempt.DeliveryID, func(delivery *models.WebhookDelivery) {
+			delivery.Status = status
+			delivery.Attempts++
+			delivery.NextAttemptAt = nextAttemptAt
+			delivery.LastStatusCode = attempt.StatusCode
+			delivery.LastError = attempt.Error
+			if status == models.WebhookDeliveryDelivered {
+				deliveredAt := attempt.CreatedAt
+				delivery.DeliveredAt = &deliveredAt
+			}
+		})
+	})
+}
+
+func (r *memoryWebhookRepository) Redeliver(ctx context.Context, id uint, at time.Time) error {
+	return r.store.write(ctx, func(state *memoryState) error {
+		return r.updateDelivery(state, id, func(delivery *models.WebhookDelivery) {
+			delivery.Status = models.WebhookDeliveryPending
+			delivery.Attempts = 0
+			delivery.NextAttemptAt = at
+			delivery.DeliveredAt = nil
+		})
+	})
+}
+
+func (r *memoryWebhookRepository) updateDelivery(state *memoryState, id uint, fn func(delivery *models.WebhookDelivery)) error {
+	for i := range state.deliveries {
+		if state.deliveries[i].ID == id {
+			fn(&state.deliveries[i])
+			return nil
+		}
+	}
+	return nil
+}
+
+// cloneWebhook copies the event types so callers cannot change the stored
+// webhook.
+func cloneWebhook(webhook models.Webhook) models.Webhook {
+	webhook.EventTypes = append([]string(nil), webhook.EventTypes...)
+	return webhook
+}
@@ -0,0 +1,102 @@
+package snap
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimestampLayout is the layout of X-TIMESTAMP, e.g.
+// 2025-04-27T10:30:00+07:00.
+const TimestampLayout = time.RFC3339
+
+// parseTimestamp reads X-TIMESTAMP and checks it is within skew of now, so
+// a signed request cannot be replayed later.
+func parseTimestamp(value string, now time.Time, skew time.Duration) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, failure(caseInvalidMandatoryField, HeaderTimestamp)
+	}
+	at, err := time.Parse(TimestampLayout, value)
+	if err != nil {
+		return time.Time{}, failure(caseInvalidFieldFormat, HeaderTimestamp)
+	}
+	if at.Before(now.Add(-skew)) || at.After(now.Add(skew)) {
+		return time.Time{}, failure(caseUnauthorized, HeaderTimestamp+" is out of range")
+	}
+	return at, nil
+}
+
+// verifyAccessTokenSignature checks signature is the base64 SHA256withRSA
+// signature of clientKey|timestamp by the partner's private key.
+func verifyAccessTokenSignature(publicKey *rsa.PublicKey, clientKey, timestamp, signature string) bool {
+	decoded, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	digest := sha256.Sum256([]byte(clientKey + "|" + timestamp))
+	return rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, digest[:], decoded) == nil
+}
+
+// transactionSignature returns the base64 HMAC-SHA512 signature of a
+// transaction request, keyed by the client secret over
+// METHOD:path:token:lowercase hex SHA-256 of the minified body:timestamp.
+func transactionSignature(clientSecret, method, path, token string, body []byte, timestamp string) (string, error) {
+	var minified bytes.Buffer
+	if len(bytes.TrimSpace(body)) > 0 {
+		if err := json.Compact(&minified, body); err != nil {
+			return "", err
+		}
+	}
+	bodyDigest := sha256.Sum256(minified.Bytes())
+
+	mac := hmac.New(sha512.New, []byte(clientSecret))
+	mac.Write([]byte(strings.Join([]string{method, path, token, hex.EncodeToString(bodyDigest[:]), timestamp}, ":")))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// issueToken returns an access token of the partner until expiry. The token
+// is its own record: the partner and expiry, then their HMAC-SHA256 under
+// secret, so tokens survive restarts and need no storage.
+func issueToken(secret []byte, partnerID string, expiry time.Time) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(partnerID + "|" + strconv.FormatInt(expiry.Unix(), 10)))
+	return payload + "." + tokenMAC(secret, payload)
+}
+
+// parseToken returns the partner of a token issued by issueToken that has
+// not expired at now.
+func parseToken(secret []byte, token string, now time.Time) (string, error) {
+	payload, mac, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(mac), []byte(tokenMAC(secret, payload))) {
+		return "", errors.New("token is not valid")
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return "", errors.New("token is not valid")
+	}
+	partnerID, expiry, ok := strings.Cut(string(decoded), "|")
+	unix, err := strconv.ParseInt(expiry, 10, 64)
+	if !ok || err != nil {
+		return "", errors.New("token is not valid")
+	}
+	if !now.Before(time.Unix(unix, 0)) {
+		return "", errors.New("token has expired")
+	}
+	return partnerID, nil
+}
+
+func tokenMAC(secret []byte, payload string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,326 @@
+package snap
+
+import (
+	"accounts-service/models"
+	"accounts-service/usecases"
+	"accounts-service/utils"
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// SNAP request headers.
+const (
+	HeaderTimestamp  = "X-TIMESTAMP"
+	HeaderSignature  = "X-SIGNATURE"
+	HeaderClientKey  = "X-CLIENT-KEY"
+	HeaderPartnerID  = "X-PARTNER-ID"
+	HeaderExternalID = "X-EXTERNAL-ID"
+)
+
+// maxBodySize bounds the request bodies read to check their signature.
+const maxBodySize = 1 << 20
+
+// ReferencePrefix starts the reference of the mutations of a transfer,
+// followed by the partner and its partnerReferenceNo.
+const ReferencePrefix = "SNAP"
+
+var (
+	externalIDPattern = regexp.MustCompile(`^[0-9]{1,36}$`)
+	amountPattern     = regexp.MustCompile(`^[0-9]{1,16}\.[0-9]{2}$`)
+)
+
+// Options configure the SNAP API.
+type Options struct {
+	// TokenSecret keys the HMAC of access tokens.
+	TokenSecret []byte
+	// TokenTTL is how long an access token is valid.
+	TokenTTL time.Duration
+	// TimestampSkew is how far X-TIMESTAMP may be from the server time.
+	TimestampSkew time.Duration
+}
+
+// Handler serves the SNAP BI API of partner fintechs: a B2B access token,
+// then balance inquiry, account inquiry and intrabank transfer. Every
+// response carries a SNAP response code instead of a Remark.
+type Handler struct {
+	accountUsecase    usecases.AccountUsecase
+	externalIDUsecase usecases.ExternalIDUsecase
+	partners          Partners
+	validator         *utils.RequestValidator
+	options           Options
+	logger            utils.Logger
+}
+
+func NewHandler(accountUsecase usecases.AccountUsecase, externalIDUsecase usecases.ExternalIDUsecase, partners Partners, options Options, logger utils.Logger) *Handler {
+	return &Handler{
+		accountUsecase:    accountUsecase,
+		externalIDUsecase: externalIDUsecase,
+		partners:          partners,
+		validator:         utils.NewRequestValidator(models.RequestValidationRemarks),
+		options:           options,
+		logger:            logger,
+	}
+}
+
+// transactionFunc serves a transaction of a partner with its JSON body.
+type transactionFunc func(ctx context.Context, partner *Partner, body []byte) (interface{}, error)
+
+// Register adds the SNAP routes to api, e.g. the /snap group.
+func (h *Handler) Register(api *echo.Group) {
+	api.POST("/v1.0/access-token/b2b", h.AccessToken)
+	api.POST("/v1.0/balance-inquiry", h.transaction(ServiceBalanceInquiry, h.balanceInquiry))
+	api.POST("/v1.0/account-inquiry-internal", h.transaction(ServiceAccountInquiry, h.accountInquiry))
+	api.POST("/v1.0/transfer-intrabank", h.transaction(ServiceTransferIntrabank, h.transferIntrabank))
+}
+
+// AccessToken issues a B2B access token to a partner signing
+// X-CLIENT-KEY|X-TIMESTAMP with its RSA private key.
+func (h *Handler) AccessToken(ctx echo.Context) error {
+	header := ctx.Request().Header
+	now := time.Now()
+
+	partner, ok := h.partners[header.Get(HeaderClientKey)]
+	if !ok {
+		return h.fail(ctx, ServiceAccessToken, failure(caseUnauthorized, "Unknown client"))
+	}
+	if _, err := parseTimestamp(header.Get(HeaderTimestamp), now, h.options.TimestampSkew); err != nil {
+		return h.fail(ctx, ServiceAccessToken, err)
+	}
+	if !verifyAccessTokenSignature(partner.PublicKey, partner.ID, header.Get(HeaderTimestamp), header.Get(HeaderSignature)) {
+		return h.fail(ctx, ServiceAccessToken, failure(caseUnauthorized, "Signature"))
+	}
+
+	body, err := readBody(ctx)
+	if err != nil {
+		return h.fail(ctx, ServiceAccessToken, err)
+	}
+	var req AccessTokenRequest
+	if err := decode(body, &req); err != nil {
+		return h.fail(ctx, ServiceAccessToken, err)
+	}
+	if err := h.validator.Validate(&req); err != nil {
+		return h.fail(ctx, ServiceAccessToken, err)
+	}
+
+	return ctx.JSON(http.StatusOK, &AccessTokenResponse{
+		Response:    response(ServiceAccessToken, caseSuccessful, ""),
+		AccessToken: issueToken(h.options.TokenSecret, partner.ID, now.Add(h.options.TokenTTL)),
+		TokenType:   "Bearer",
+		ExpiresIn:   strconv.Itoa(int(h.options.TokenTTL.Seconds())),
+	})
+}
+
+// transaction authenticates a transaction before fn serves it: the access
+// token, X-PARTNER-ID of its partner, X-TIMESTAMP, the HMAC-SHA512
+// X-SIGNATURE of the request, and X-EXTERNAL-ID, used once per partner and
+// day.
+func (h *Handler) transaction(service string, fn transactionFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		req := ctx.Request()
+		now := time.Now()
+
+		token, ok := strings.CutPrefix(req.Header.Get(echo.HeaderAuthorization), "Bearer ")
+		if !ok {
+			return h.fail(ctx, service, failure(caseInvalidToken, ""))
+		}
+		partnerID, err := parseToken(h.options.TokenSecret, token, now)
+		if err != nil {
+			return h.fail(ctx, service, failure(caseInvalidToken, ""))
+		}
+		partner, ok := h.partners[partnerID]
+		if !ok || req.Header.Get(HeaderPartnerID) != partnerID {
+			return h.fail(ctx, service, failure(caseUnauthorized, "Unknown "+HeaderPartnerID))
+		}
+
+		timestamp := req.Header.Get(HeaderTimestamp)
+		if _, err := parseTimestamp(timestamp, now, h.options.TimestampSkew); err != nil {
+			return h.fail(ctx, service, err)
+		}
+
+		body, err := readBody(ctx)
+		if err != nil {
+			return h.fail(ctx, service, err)
+		}
+		signature, err := transactionSignature(partner.ClientSecret, req.Method, req.URL.RequestURI(), token, body, timestamp)
+		if err != nil {
+			return h.fail(ctx, service, failure(caseBadRequest, ""))
+		}
+		if !hmac.Equal([]byte(req.Header.Get(HeaderSignature)), []byte(signature)) {
+			return h.fail(ctx, service, failure(caseUnauthorized, "Signature"))
+		}
+
+		// The deadline of DB_QUERY_TIMEOUT is answered as a SNAP timeout
+		c := req.Context()
+		externalID := req.Header.Get(HeaderExternalID)
+		if externalID == "" {
+			return h.fail(ctx, service, failure(caseInvalidMandatoryField, HeaderExternalID))
+		}
+		if !externalIDPattern.MatchString(externalID) {
+			return h.fail(ctx, service, failure(caseInvalidFieldFormat, HeaderExternalID))
+		}
+		if err := h.externalIDUsecase.Claim(c, partner.ID, externalID, now); err != nil {
+			return h.fail(ctx, service, err)
+		}
+
+		resp, err := fn(c, partner, body)
+		if err != nil {
+			return h.fail(ctx, service, err)
+		}
+		return ctx.JSON(http.StatusOK, resp)
+	}
+}
+
+func (h *Handler) balanceInquiry(ctx context.Context, partner *Partner, body []byte) (interface{}, error) {
+	var req BalanceInquiryRequest
+	if err := decode(body, &req); err != nil {
+		return nil, err
+	}
+	if err := h.validator.Validate(&req); err != nil {
+		return nil, err
+	}
+	if !partner.Accounts[req.AccountNo] {
+		return nil, failure(caseTransactionNotPermitted, "accountNo")
+	}
+
+	account, err := h.accountUsecase.GetAccountByNoRekening(ctx, req.AccountNo)
+	if err != nil {
+		return nil, err
+	}
+
+	saldo := amount(account.Saldo)
+	return &BalanceInquiryResponse{
+		Response:           response(ServiceBalanceInquiry, caseSuccessful, ""),
+		PartnerReferenceNo: req.PartnerReferenceNo,
+		AccountNo:          account.NoRekening,
+		Name:               account.Name,
+		AccountInfos:       []AccountInfo{{BalanceType: "Cash", Amount: saldo, AvailableBalance: saldo}},
+	}, nil
+}
+
+func (h *Handler) accountInquiry(ctx context.Context, partner *Partner, body []byte) (interface{}, error) {
+	var req AccountInquiryRequest
+	if err := decode(body, &req); err != nil {
+		return nil, err
+	}
+	if err := h.validator.Validate(&req); err != nil {
+		return nil, err
+	}
+
+	account, err := h.accountUsecase.GetAccountByNoRekening(ctx, req.BeneficiaryAccountNo)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AccountInquiryResponse{
+		Response:               response(ServiceAccountInquiry, caseSuccessful, ""),
+		PartnerReferenceNo:     req.PartnerReferenceNo,
+		BeneficiaryAccountNo:   account.NoRekening,
+		BeneficiaryAccountName: account.Name,
+	}, nil
+}
+
+// transferIntrabank moves funds from an account of the partner to any
+// account. A partnerReferenceNo is used once per source account.
+func (h *Handler) transferIntrabank(ctx context.Context, partner *Partner, body []byte) (interface{}, error) {
+	var req TransferIntrabankRequest
+	if err := decode(body, &req); err != nil {
+		return nil, err
+	}
+	if err := h.validator.Validate(&req); err != nil {
+		return nil, err
+	}
+	nominal, err := parseAmount(req.Amount)
+	if err != nil {
+		return nil, err
+	}
+	if !partner.Accounts[req.SourceAccountNo] {
+		return nil, failure(caseTransactionNotPermitted, "sourceAccountNo")
+	}
+
+	transfer, err := h.accountUsecase.Transfer(ctx, &models.TransferRequest{
+		SourceNoRekening:      req.SourceAccountNo,
+		BeneficiaryNoRekening: req.BeneficiaryAccountNo,
+		Nominal:               nominal,
+		Reference:             strings.Join([]string{ReferencePrefix, partner.ID, req.PartnerReferenceNo}, " "),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &TransferIntrabankResponse{
+		Response:             response(ServiceTransferIntrabank, caseSuccessful, ""),
+		ReferenceNo:          strconv.FormatUint(uint64(transfer.Debit.ID), 10),
+		PartnerReferenceNo:   req.PartnerReferenceNo,
+		Amount:               req.Amount,
+		BeneficiaryAccountNo: req.BeneficiaryAccountNo,
+		SourceAccountNo:      req.SourceAccountNo,
+		TransactionDate:      req.TransactionDate,
+	}, nil
+}
+
+// fail answers err with its SNAP response code, logging the errors that
+// are not the partner's.
+func (h *Handler) fail(ctx echo.Context, service string, err error) error {
+	c, detail := caseOf(err)
+	if c.status >= http.StatusInternalServerError {
+		h.logger.Error("SNAP %s %s: %v", ctx.Request().Method, ctx.Request().URL.Path, err)
+	}
+	return ctx.JSON(c.status, response(service, c, detail))
+}
+
+// readBody reads the request body, which is signed as sent.
+func readBody(ctx echo.Context) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(ctx.Request().Body, maxBodySize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxBodySize {
+		return nil, failure(caseBadRequest, "")
+	}
+	return body, nil
+}
+
+func decode(body []byte, v interface{}) error {
+	if err := json.Unmarshal(body, v); err != nil {
+		return failure(caseBadRequest, "")
+	}
+	return nil
+}
+
+// parseAmount returns the rupiah of an amount, a positive value with 2
+// decimals in IDR.
+func parseAmount(a Amount) (float64, error) {
+	if a.Value == "" {
+		return 0, failure(caseInvalidMandatoryField, "amount.value")
+	}
+	if a.Currency == "" {
+		return 0, failure(caseInvalidMandatoryField, "amount.currency")
+	}
+	if !amountPattern.MatchString(a.Value) {
+		return 0, failure(caseInvalidFieldFormat, "amount.value")
+	}
+	if a.Currency != CurrencyIDR {
+		return 0, failure(caseInvalidFieldFormat, "amount.currency")
+	}
+	value, _ := strconv.ParseFloat(a.Value, 64)
+	if !utils.IsValidAmount(value) {
+		return 0, failure(caseInvalidFieldFormat, "amount.value")
+	}
+	return value, nil
+}
+
+// amount formats rupiah as a SNAP amount.
+func amount(rupiah float64) Amount {
+	return Amount{Value: fmt.Sprintf("%.2f", math.Round(rupiah*100)/100), Currency: CurrencyIDR}
+}
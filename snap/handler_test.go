@@ -0,0 +1,256 @@
+package snap_test
+
+import (
+	"accounts-service/models"
+	"accounts-service/repositories"
+	"accounts-service/snap"
+	"accounts-service/usecases"
+	"accounts-service/utils"
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	partnerID    = "FINTECH01"
+	clientSecret = "0123456789abcdef0123456789abcdef"
+)
+
+type env struct {
+	echo        *echo.Echo
+	privateKey  *rsa.PrivateKey
+	accountRepo repositories.AccountRepository
+	externalID  atomic.Int64
+}
+
+func newAPI(t *testing.T) *env {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	require.NoError(t, err)
+	file, err := json.Marshal([]map[string]interface{}{{
+		"partner_id":    partnerID,
+		"client_secret": clientSecret,
+		"public_key":    string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+		"accounts":      []string{"1744847261"},
+	}})
+	require.NoError(t, err)
+	partners, err := snap.ParsePartners(file)
+	require.NoError(t, err)
+
+	logger := utils.NewLogger("critical")
+	store := repositories.NewMemoryStore()
+	accountRepo := repositories.NewMemoryAccountRepository(store, logger)
+	accountUsecase := usecases.NewAccountUsecase(
+		repositories.NewMemoryTxManager(store, logger),
+		accountRepo,
+		repositories.NewMemoryMutationRepository(store, logger),
+		repositories.NewMemoryOutboxRepository(store, logger),
+		repositories.NewNoopBalanceCache(),
+		logger,
+	)
+	externalIDUsecase := usecases.NewExternalIDUsecase(repositories.NewMemoryExternalIDRepository(store, logger), logger)
+
+	e := echo.New()
+	snap.NewHandler(accountUsecase, externalIDUsecase, partners, snap.Options{
+		TokenSecret:   []byte("token-secret-token-secret-token-secret"),
+		TokenTTL:      15 * time.Minute,
+		TimestampSkew: 5 * time.Minute,
+	}, logger).Register(e.Group("/snap"))
+
+	return &env{echo: e, privateKey: privateKey, accountRepo: accountRepo}
+}
+
+func timestamp() string {
+	return time.Now().Format(snap.TimestampLayout)
+}
+
+// accessToken requests a token with a timestamp signed by the partner key.
+func (e *env) accessToken(t *testing.T, ts string, key *rsa.PrivateKey) *httptest.ResponseRecorder {
+	digest := sha256.Sum256([]byte(partnerID + "|" + ts))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/snap/v1.0/access-token/b2b", strings.NewReader(`{"grantType":"client_credentials"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(snap.HeaderTimestamp, ts)
+	req.Header.Set(snap.HeaderClientKey, partnerID)
+	req.Header.Set(snap.HeaderSignature, base64.StdEncoding.EncodeToString(signature))
+	rec := httptest.NewRecorder()
+	e.echo.ServeHTTP(rec, req)
+	return rec
+}
+
+func (e *env) token(t *testing.T) string {
+	rec := e.accessToken(t, timestamp(), e.privateKey)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var resp snap.AccessTokenResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	return resp.AccessToken
+}
+
+// call sends a transaction signed with secret, or unsigned when secret is
+// empty, under the external ID, or a new one when it is empty.
+func (e *env) call(t *testing.T, token, path, body, secret, externalID string) (int, map[string]interface{}) {
+	ts := timestamp()
+	if externalID == "" {
+		externalID = strconv.FormatInt(e.externalID.Add(1), 10)
+	}
+
+	var minified bytes.Buffer
+	require.NoError(t, json.Compact(&minified, []byte(body)))
+	bodyDigest := sha256.Sum256(minified.Bytes())
+	mac := hmac.New(sha512.New, []byte(secret))
+	mac.Write([]byte(http.MethodPost + ":" + path + ":" + token + ":" + hex.EncodeToString(bodyDigest[:]) + ":" + ts))
+
+	// The body is signed minified and may be sent formatted
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	req.Header.Set(snap.HeaderTimestamp, ts)
+	req.Header.Set(snap.HeaderPartnerID, partnerID)
+	req.Header.Set(snap.HeaderExternalID, externalID)
+	req.Header.Set(snap.HeaderSignature, base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	rec := httptest.NewRecorder()
+	e.echo.ServeHTTP(rec, req)
+
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp), rec.Body.String())
+	return rec.Code, resp
+}
+
+func TestHandler(t *testing.T) {
+	ctx := context.Background()
+	e := newAPI(t)
+
+	source := &models.Account{Name: "PT Dompet Digital", NIK: "3201014508950001", NoHP: "+6281234567890", NoRekening: "1744847261"}
+	beneficiary := &models.Account{Name: "Budi Santoso", NIK: "3201010101900001", NoHP: "+6281200000000", NoRekening: "1744847262"}
+	require.NoError(t, e.accountRepo.CreateAccount(ctx, source))
+	require.NoError(t, e.accountRepo.CreateAccount(ctx, beneficiary))
+	require.NoError(t, e.accountRepo.UpdateSaldo(ctx, source.ID, 1000000))
+
+	token := e.token(t)
+
+	t.Run("access token", func(t *testing.T) {
+		otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		rec := e.accessToken(t, timestamp(), otherKey)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.JSONEq(t, `{"responseCode":"4017300","responseMessage":"Unauthorized. Signature"}`, rec.Body.String())
+
+		rec = e.accessToken(t, time.Now().Add(-time.Hour).Format(snap.TimestampLayout), e.privateKey)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("balance inquiry", func(t *testing.T) {
+		status, resp := e.call(t, token, "/snap/v1.0/balance-inquiry", `{"partnerReferenceNo": "INQ-1", "accountNo": "1744847261"}`, clientSecret, "")
+		require.Equal(t, http.StatusOK, status, resp)
+		assert.Equal(t, "2001100", resp["responseCode"])
+		assert.Equal(t, "PT Dompet Digital", resp["name"])
+		assert.Equal(t, map[string]interface{}{"value": "1000000.00", "currency": "IDR"}, resp["accountInfos"].([]interface{})[0].(map[string]interface{})["availableBalance"])
+
+		// Only the partner's accounts are served
+		status, resp = e.call(t, token, "/snap/v1.0/balance-inquiry", `{"accountNo": "1744847262"}`, clientSecret, "")
+		assert.Equal(t, http.StatusForbidden, status)
+		assert.Equal(t, "4031115", resp["responseCode"])
+	})
+
+	t.Run("account inquiry", func(t *testing.T) {
+		status, resp := e.call(t, token, "/snap/v1.0/account-inquiry-internal", `{"beneficiaryAccountNo": "1744847262"}`, clientSecret, "")
+		require.Equal(t, http.StatusOK, status, resp)
+		assert.Equal(t, "2001500", resp["responseCode"])
+		assert.Equal(t, "Budi Santoso", resp["beneficiaryAccountName"])
+
+		status, resp = e.call(t, token, "/snap/v1.0/account-inquiry-internal", `{"beneficiaryAccountNo": "9999999999"}`, clientSecret, "")
+		assert.Equal(t, http.StatusNotFound, status)
+		assert.Equal(t, "4041511", resp["responseCode"])
+	})
+
+	transfer := func(partnerReferenceNo, value string) string {
+		return `{"partnerReferenceNo": "` + partnerReferenceNo + `", "amount": {"value": "` + value + `", "currency": "IDR"}, "beneficiaryAccountNo": "1744847262", "sourceAccountNo": "1744847261", "transactionDate": "2025-04-27T10:30:00+07:00"}`
+	}
+
+	t.Run("transfer intrabank", func(t *testing.T) {
+		status, resp := e.call(t, token, "/snap/v1.0/transfer-intrabank", transfer("TRF-1", "250000.50"), clientSecret, "")
+		require.Equal(t, http.StatusOK, status, resp)
+		assert.Equal(t, "2001700", resp["responseCode"])
+		assert.Equal(t, "TRF-1", resp["partnerReferenceNo"])
+		assert.NotEmpty(t, resp["referenceNo"])
+
+		got, err := e.accountRepo.GetAccountByNoRekening(ctx, beneficiary.NoRekening)
+		require.NoError(t, err)
+		assert.Equal(t, 250000.5, got.Saldo)
+
+		status, resp = e.call(t, token, "/snap/v1.0/transfer-intrabank", transfer("TRF-1", "250000.50"), clientSecret, "")
+		assert.Equal(t, http.StatusConflict, status)
+		assert.Equal(t, "4091701", resp["responseCode"])
+	})
+
+	for name, test := range map[string]struct {
+		token, body, secret, externalID string
+		status                          int
+		code                            string
+	}{
+		"insufficient funds":    {token: token, body: transfer("TRF-2", "999999999.00"), secret: clientSecret, status: http.StatusForbidden, code: "4031714"},
+		"invalid signature":     {token: token, body: transfer("TRF-3", "1000.00"), secret: "wrong", status: http.StatusUnauthorized, code: "4011700"},
+		"invalid token":         {token: "x.y", body: transfer("TRF-4", "1000.00"), secret: clientSecret, status: http.StatusUnauthorized, code: "4011701"},
+		"invalid amount":        {token: token, body: transfer("TRF-5", "1000"), secret: clientSecret, status: http.StatusBadRequest, code: "4001701"},
+		"missing reference":     {token: token, body: transfer("", "1000.00"), secret: clientSecret, status: http.StatusBadRequest, code: "4001702"},
+		"invalid external ID":   {token: token, body: transfer("TRF-6", "1000.00"), secret: clientSecret, externalID: "ABC", status: http.StatusBadRequest, code: "4001701"},
+		"body that is not JSON": {token: token, body: `{"partnerReferenceNo": 1}`, secret: clientSecret, status: http.StatusBadRequest, code: "4001700"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			status, resp := e.call(t, test.token, "/snap/v1.0/transfer-intrabank", test.body, test.secret, test.externalID)
+			assert.Equal(t, test.status, status)
+			assert.Equal(t, test.code, resp["responseCode"], resp["responseMessage"])
+		})
+	}
+
+	t.Run("external ID is used once a day", func(t *testing.T) {
+		status, _ := e.call(t, token, "/snap/v1.0/balance-inquiry", `{"accountNo": "1744847261"}`, clientSecret, "20250427000001")
+		require.Equal(t, http.StatusOK, status)
+
+		status, resp := e.call(t, token, "/snap/v1.0/balance-inquiry", `{"accountNo": "1744847261"}`, clientSecret, "20250427000001")
+		assert.Equal(t, http.StatusConflict, status)
+		assert.Equal(t, "4091100", resp["responseCode"])
+	})
+
+	got, err := e.accountRepo.GetAccountByNoRekening(ctx, source.NoRekening)
+	require.NoError(t, err)
+	assert.Equal(t, 749999.5, got.Saldo, "declined transfers post nothing")
+}
+
+func TestParsePartners(t *testing.T) {
+	for name, file := range map[string]string{
+		"not JSON":      `{`,
+		"no partner ID": `[{"client_secret": "0123456789abcdef0123456789abcdef"}]`,
+		"short secret":  `[{"partner_id": "A", "client_secret": "short"}]`,
+		"no public key": `[{"partner_id": "A", "client_secret": "0123456789abcdef0123456789abcdef"}]`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := snap.ParsePartners([]byte(file))
+			assert.Error(t, err)
+		})
+	}
+}
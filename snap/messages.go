@@ -0,0 +1,74 @@
+package snap
+
+// Request and response bodies of the SNAP services. Fields are named and
+// formatted as the SNAP standard does, amounts as {"value": "10000.00",
+// "currency": "IDR"}.
+
+// CurrencyIDR is the only currency served.
+const CurrencyIDR = "IDR"
+
+type Amount struct {
+	Value    string `json:"value"`
+	Currency string `json:"currency"`
+}
+
+type AccessTokenRequest struct {
+	GrantType string `json:"grantType" validate:"required,eq=client_credentials"`
+}
+
+type AccessTokenResponse struct {
+	Response
+	AccessToken string `json:"accessToken"`
+	TokenType   string `json:"tokenType"`
+	ExpiresIn   string `json:"expiresIn"`
+}
+
+type BalanceInquiryRequest struct {
+	PartnerReferenceNo string `json:"partnerReferenceNo" validate:"max=64"`
+	AccountNo          string `json:"accountNo" validate:"required,norek"`
+}
+
+type BalanceInquiryResponse struct {
+	Response
+	PartnerReferenceNo string        `json:"partnerReferenceNo,omitempty"`
+	AccountNo          string        `json:"accountNo"`
+	Name               string        `json:"name"`
+	AccountInfos       []AccountInfo `json:"accountInfos"`
+}
+
+type AccountInfo struct {
+	BalanceType      string `json:"balanceType"`
+	Amount           Amount `json:"amount"`
+	AvailableBalance Amount `json:"availableBalance"`
+}
+
+type AccountInquiryRequest struct {
+	PartnerReferenceNo   string `json:"partnerReferenceNo" validate:"max=64"`
+	BeneficiaryAccountNo string `json:"beneficiaryAccountNo" validate:"required,norek"`
+}
+
+type AccountInquiryResponse struct {
+	Response
+	PartnerReferenceNo     string `json:"partnerReferenceNo,omitempty"`
+	BeneficiaryAccountNo   string `json:"beneficiaryAccountNo"`
+	BeneficiaryAccountName string `json:"beneficiaryAccountName"`
+}
+
+type TransferIntrabankRequest struct {
+	PartnerReferenceNo   string `json:"partnerReferenceNo" validate:"required,max=64"`
+	Amount               Amount `json:"amount"`
+	BeneficiaryAccountNo string `json:"beneficiaryAccountNo" validate:"required,norek"`
+	SourceAccountNo      string `json:"sourceAccountNo" validate:"required,norek"`
+	Remark               string `json:"remark" validate:"max=50"`
+	TransactionDate      string `json:"transactionDate" validate:"required,datetime=2006-01-02T15:04:05Z07:00"`
+}
+
+type TransferIntrabankResponse struct {
+	Response
+	ReferenceNo          string `json:"referenceNo"`
+	PartnerReferenceNo   string `json:"partnerReferenceNo"`
+	Amount               Amount `json:"amount"`
+	BeneficiaryAccountNo string `json:"beneficiaryAccountNo"`
+	SourceAccountNo      string `json:"sourceAccountNo"`
+	TransactionDate      string `json:"transactionDate"`
+}
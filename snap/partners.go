@@ -0,0 +1,107 @@
+package snap
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Partner is a fintech allowed to call the SNAP API.
+type Partner struct {
+	// ID is sent as X-CLIENT-KEY for an access token and as X-PARTNER-ID
+	// with every transaction.
+	ID string
+	// ClientSecret keys the HMAC-SHA512 signatures of transactions.
+	ClientSecret string
+	// PublicKey verifies the SHA256withRSA signatures of access token
+	// requests.
+	PublicKey *rsa.PublicKey
+	// Accounts are the no_rekening the partner may inquire and transfer
+	// from. Any account may receive its transfers.
+	Accounts map[string]bool
+}
+
+// Partners are the partners by ID.
+type Partners map[string]*Partner
+
+// partnerFile is a partner as written in the partners file.
+type partnerFile struct {
+	PartnerID    string   `json:"partner_id"`
+	ClientSecret string   `json:"client_secret"`
+	PublicKey    string   `json:"public_key"`
+	Accounts     []string `json:"accounts"`
+}
+
+// LoadPartners reads the JSON array of partners at path, e.g.
+//
+//	[{"partner_id": "FINTECH01", "client_secret": "...",
+//	  "public_key": "-----BEGIN PUBLIC KEY-----\n...", "accounts": ["1744847261"]}]
+func LoadPartners(path string) (Partners, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePartners(data)
+}
+
+// ParsePartners parses partners written like the partners file.
+func ParsePartners(data []byte) (Partners, error) {
+	var files []partnerFile
+	if err := json.Unmarshal(data, &files); err != nil {
+		return nil, fmt.Errorf("partners: %w", err)
+	}
+
+	partners := Partners{}
+	for i, file := range files {
+		if file.PartnerID == "" {
+			return nil, fmt.Errorf("partner %d: partner_id is empty", i+1)
+		}
+		if _, ok := partners[file.PartnerID]; ok {
+			return nil, fmt.Errorf("partner %s is listed twice", file.PartnerID)
+		}
+		if len(file.ClientSecret) < 32 {
+			return nil, fmt.Errorf("partner %s: client_secret must be at least 32 characters", file.PartnerID)
+		}
+		publicKey, err := parsePublicKey(file.PublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("partner %s: public_key: %w", file.PartnerID, err)
+		}
+
+		partner := &Partner{
+			ID:           file.PartnerID,
+			ClientSecret: file.ClientSecret,
+			PublicKey:    publicKey,
+			Accounts:     map[string]bool{},
+		}
+		for _, account := range file.Accounts {
+			partner.Accounts[account] = true
+		}
+		partners[partner.ID] = partner
+	}
+	return partners, nil
+}
+
+// parsePublicKey reads a PEM RSA public key, PKIX or PKCS #1.
+func parsePublicKey(data string) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	if block.Type == "RSA PUBLIC KEY" {
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("key is not an RSA key")
+	}
+	return publicKey, nil
+}
@@ -0,0 +1,118 @@
+package snap
+
+import (
+	"accounts-service/models"
+	"accounts-service/utils"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Service codes, the middle two digits of a response code.
+const (
+	ServiceAccessToken       = "73"
+	ServiceBalanceInquiry    = "11"
+	ServiceAccountInquiry    = "15"
+	ServiceTransferIntrabank = "17"
+)
+
+// responseCase is a SNAP response, the HTTP status and case code that make
+// a response code with the service code, e.g. 4011700, and its message.
+type responseCase struct {
+	status  int
+	code    string
+	message string
+}
+
+var (
+	caseSuccessful                  = responseCase{http.StatusOK, "00", "Successful"}
+	caseBadRequest                  = responseCase{http.StatusBadRequest, "00", "Bad Request"}
+	caseInvalidFieldFormat          = responseCase{http.StatusBadRequest, "01", "Invalid Field Format"}
+	caseInvalidMandatoryField       = responseCase{http.StatusBadRequest, "02", "Invalid Mandatory Field"}
+	caseUnauthorized                = responseCase{http.StatusUnauthorized, "00", "Unauthorized."}
+	caseInvalidToken                = responseCase{http.StatusUnauthorized, "01", "Invalid Token (B2B)"}
+	caseInsufficientFunds           = responseCase{http.StatusForbidden, "14", "Insufficient Funds"}
+	caseTransactionNotPermitted     = responseCase{http.StatusForbidden, "15", "Transaction Not Permitted"}
+	caseInvalidAccount              = responseCase{http.StatusNotFound, "11", "Invalid Account"}
+	caseConflict                    = responseCase{http.StatusConflict, "00", "Conflict"}
+	caseDuplicatePartnerReferenceNo = responseCase{http.StatusConflict, "01", "Duplicate partnerReferenceNo"}
+	caseGeneralError                = responseCase{http.StatusInternalServerError, "00", "General Error"}
+	caseTimeout                     = responseCase{http.StatusGatewayTimeout, "00", "Timeout"}
+)
+
+// casesByRemark maps the Remark codes partners act on to their case.
+var casesByRemark = map[string]responseCase{
+	models.AccountWithNoRekeningNotFound: caseInvalidAccount,
+	models.Accountinsufficient:           caseInsufficientFunds,
+	models.TransferSameAccount:           caseTransactionNotPermitted,
+	models.TransferReferenceExists:       caseDuplicatePartnerReferenceNo,
+	models.ExternalIDExists:              caseConflict,
+	models.QueryTimeout:                  caseTimeout,
+}
+
+// Response starts every SNAP response body.
+type Response struct {
+	ResponseCode    string `json:"responseCode"`
+	ResponseMessage string `json:"responseMessage"`
+}
+
+// response returns the response of a case for a service. detail, when
+// set, follows the message, e.g. the field of an invalid field format.
+func response(service string, c responseCase, detail string) Response {
+	message := c.message
+	if detail != "" {
+		message += " " + detail
+	}
+	return Response{
+		ResponseCode:    fmt.Sprintf("%d%s%s", c.status, service, c.code),
+		ResponseMessage: message,
+	}
+}
+
+// snapError is a failure with its case, answered as is.
+type snapError struct {
+	responseCase
+	detail string
+}
+
+func (e *snapError) Error() string {
+	return e.message + " " + e.detail
+}
+
+func failure(c responseCase, detail string) error {
+	return &snapError{responseCase: c, detail: detail}
+}
+
+// caseOf maps err to its case and detail: a snapError as is, a Remark by
+// its code, a failed validation by its first field, required fields as
+// mandatory and the others as invalid format.
+func caseOf(err error) (responseCase, string) {
+	var snapErr *snapError
+	if errors.As(err, &snapErr) {
+		return snapErr.responseCase, snapErr.detail
+	}
+
+	remark, ok := utils.AsRemark(err)
+	if !ok {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return caseTimeout, ""
+		}
+		return caseGeneralError, ""
+	}
+
+	if c, ok := casesByRemark[remark.Remark.Code]; ok {
+		return c, ""
+	}
+	if details, ok := remark.Remark.Object.([]*utils.Remark); ok && len(details) > 0 {
+		detail := details[0]
+		if detail.Params()["rule"] == "required" {
+			return caseInvalidMandatoryField, detail.Remark.Field
+		}
+		return caseInvalidFieldFormat, detail.Remark.Field
+	}
+	if remark.HTTPStatus() == http.StatusBadRequest {
+		return caseBadRequest, ""
+	}
+	return caseGeneralError, ""
+}
@@ -0,0 +1,241 @@
+package statements
+
+import (
+	"accounts-service/blobs"
+	"accounts-service/models"
+	"accounts-service/repositories"
+	"accounts-service/usecases"
+	"accounts-service/utils"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Notifier sends customers their archived monthly statement.
+type Notifier interface {
+	NotifyStatement(ctx context.Context, statement *models.Statement, file models.NotificationAttachment) error
+}
+
+// ArchiverOptions tune the monthly statement job.
+type ArchiverOptions struct {
+	// Interval is the wait between checks for a month to archive.
+	Interval time.Duration
+	// BatchSize is how many accounts are read per query.
+	BatchSize int
+}
+
+// ArchiveResult counts the statements generated by a run.
+type ArchiveResult struct {
+	Generated int
+	Failed    int
+}
+
+// Archiver generates the PDF statement of every account for a month into
+// the blob store. The outcome of each account is recorded, so a run that
+// stopped resumes with the accounts whose statement is missing or failed,
+// and generating a month again leaves the generated statements alone.
+//
+// Two archivers working on the same month would generate statements twice,
+// run it on a single instance.
+type Archiver struct {
+	statementUsecase usecases.StatementUsecase
+	statementRepo    repositories.MonthlyStatementRepository
+	store            blobs.Store
+	header           Header
+	notifier         Notifier
+	options          ArchiverOptions
+	logger           utils.Logger
+}
+
+// NewArchiver returns an archiver. A nil notifier sends no notification.
+func NewArchiver(statementUsecase usecases.StatementUsecase, statementRepo repositories.MonthlyStatementRepository, store blobs.Store, header Header, notifier Notifier, options ArchiverOptions, logger utils.Logger) *Archiver {
+	return &Archiver{
+		statementUsecase: statementUsecase,
+		statementRepo:    statementRepo,
+		store:            store,
+		header:           header,
+		notifier:         notifier,
+		options:          options,
+		logger:           logger,
+	}
+}
+
+// BlobKey returns the key of the statement of an account for period, e.g.
+// "statements/2025-04/1744847261.pdf".
+func BlobKey(period, noRekening string) string {
+	return "statements/" + period + "/" + noRekening + "." + models.StatementFormatPDF
+}
+
+// Run archives the last completed month until ctx is done. A month is
+// archived again on the next check until none of its statements failed.
+func (a *Archiver) Run(ctx context.Context) {
+	a.logger.Info("Monthly statement archiver started")
+
+	ticker := time.NewTicker(a.options.Interval)
+	defer ticker.Stop()
+
+	archived := ""
+	for {
+		period := models.PreviousMonthlyStatementPeriod(time.Now())
+		if period != archived {
+			result, err := a.Archive(ctx, period)
+			switch {
+			case err != nil && ctx.Err() == nil:
+				a.logger.Error("Error archiving monthly statements of %s: %v", period, err)
+			case err == nil && result.Failed == 0:
+				archived = period
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			a.logger.Info("Monthly statement archiver stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Archive generates the missing statements of period, a month that is
+// over, for the accounts opened before its end.
+func (a *Archiver) Archive(ctx context.Context, period string) (ArchiveResult, error) {
+	var result ArchiveResult
+
+	from, to, err := models.MonthlyStatementBounds(period)
+	if err != nil {
+		return result, models.MonthlyStatementPeriodInvalidErr.Wrap(err)
+	}
+	end := to.AddDate(0, 0, 1)
+	if end.After(time.Now()) {
+		return result, fmt.Errorf("period %s is not over", period)
+	}
+
+	var afterID uint
+	for {
+		accounts, err := a.statementRepo.ListPendingAccounts(ctx, period, end, afterID, a.options.BatchSize)
+		if err != nil || len(accounts) == 0 {
+			if result.Generated+result.Failed > 0 {
+				a.logger.Info("Archived monthly statements of %s: %d generated, %d failed", period, result.Generated, result.Failed)
+			}
+			return result, err
+		}
+
+		for i := range accounts {
+			if ctx.Err() != nil {
+				return result, ctx.Err()
+			}
+
+			account := &accounts[i]
+			afterID = account.ID
+			if err := a.archive(ctx, account, period, from, to); err != nil {
+				a.logger.Error("Error archiving monthly statement %s of account %s: %v", period, account.NoRekening, err)
+				result.Failed++
+				continue
+			}
+			result.Generated++
+		}
+	}
+}
+
+// archive generates, stores and records the statement of one account. A
+// failure is recorded on the statement too, unless recording fails.
+func (a *Archiver) archive(ctx context.Context, account *models.Account, period string, from, to time.Time) error {
+	record := &models.MonthlyStatement{
+		AccountID: account.ID,
+		Period:    period,
+		BlobKey:   BlobKey(period, account.NoRekening),
+	}
+
+	statement, size, checksum, err := a.generate(ctx, account, record.BlobKey, from, to)
+	if err != nil {
+		record.Status = models.MonthlyStatementFailed
+		record.LastError = err.Error()
+		if saveErr := a.statementRepo.SaveStatement(ctx, record); saveErr != nil {
+			return saveErr
+		}
+		return err
+	}
+
+	record.Status = models.MonthlyStatementGenerated
+	record.Size = size
+	record.Checksum = checksum
+	record.GeneratedAt = &statement.GeneratedAt
+	if err := a.statementRepo.SaveStatement(ctx, record); err != nil {
+		return err
+	}
+
+	if a.notifier != nil {
+		a.notify(ctx, statement, record)
+	}
+	return nil
+}
+
+// generate streams the PDF statement into the blob store, hashing it on
+// the way, and returns its size and hex SHA-256.
+func (a *Archiver) generate(ctx context.Context, account *models.Account, key string, from, to time.Time) (*models.Statement, int64, string, error) {
+	statement, err := a.statementUsecase.PrepareStatement(ctx, &models.StatementRequest{
+		NoRekening: account.NoRekening,
+		From:       from.Format(models.StatementDateLayout),
+		To:         to.Format(models.StatementDateLayout),
+		Format:     models.StatementFormatPDF,
+	})
+	if err != nil {
+		return nil, 0, "", err
+	}
+
+	hash := sha256.New()
+	counter := &countingWriter{w: hash}
+	r, w := io.Pipe()
+
+	written := make(chan error, 1)
+	go func() {
+		err := a.statementUsecase.WriteStatement(ctx, statement, NewPDFWriter(io.MultiWriter(w, counter), a.header))
+		w.CloseWithError(err)
+		written <- err
+	}()
+
+	err = a.store.Put(ctx, key, r)
+	// Unblocks the writer when the store stopped reading early
+	r.CloseWithError(err)
+	if writeErr := <-written; writeErr != nil {
+		return nil, 0, "", writeErr
+	}
+	if err != nil {
+		return nil, 0, "", err
+	}
+
+	return statement, counter.n, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// notify sends the statement read back from the store. Notifications are
+// best effort, a failure is logged and the statement stays generated.
+func (a *Archiver) notify(ctx context.Context, statement *models.Statement, record *models.MonthlyStatement) {
+	blob, err := a.store.Open(ctx, record.BlobKey)
+	if err != nil {
+		a.logger.Error("Error reading monthly statement %s of account %s to notify: %v", record.Period, statement.Account.NoRekening, err)
+		return
+	}
+	content, err := io.ReadAll(blob)
+	blob.Close()
+	if err != nil {
+		a.logger.Error("Error reading monthly statement %s of account %s to notify: %v", record.Period, statement.Account.NoRekening, err)
+		return
+	}
+
+	file := models.NotificationAttachment{
+		Filename:    Filename(statement, models.StatementFormatPDF),
+		ContentType: "application/pdf",
+		Content:     content,
+	}
+	if err := a.notifier.NotifyStatement(ctx, statement, file); err != nil {
+		a.logger.Error("Error notifying monthly statement %s of account %s: %v", record.Period, statement.Account.NoRekening, err)
+		return
+	}
+
+	if err := a.statementRepo.MarkNotified(ctx, record.ID, time.Now()); err != nil {
+		a.logger.Error("Error marking monthly statement %s of account %s notified: %v", record.Period, statement.Account.NoRekening, err)
+	}
+}
@@ -0,0 +1,185 @@
+package statements_test
+
+import (
+	"accounts-service/blobs"
+	"accounts-service/models"
+	"accounts-service/repositories"
+	"accounts-service/statements"
+	"accounts-service/usecases"
+	"accounts-service/utils"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// backdated lists the accounts as opened before the end of any month, the
+// memory store dating accounts when they are created.
+type backdated struct {
+	repositories.MonthlyStatementRepository
+}
+
+func (r backdated) ListPendingAccounts(ctx context.Context, period string, until time.Time, afterID uint, limit int) ([]models.Account, error) {
+	return r.MonthlyStatementRepository.ListPendingAccounts(ctx, period, time.Now().Add(time.Hour), afterID, limit)
+}
+
+// failingStore fails the puts of the keys in fail after reading part of the
+// blob.
+type failingStore struct {
+	blobs.Store
+
+	mu   sync.Mutex
+	fail map[string]bool
+}
+
+func (s *failingStore) Put(ctx context.Context, key string, r io.Reader) error {
+	s.mu.Lock()
+	fail := s.fail[key]
+	s.mu.Unlock()
+
+	if fail {
+		io.CopyN(io.Discard, r, 16)
+		return errors.New("disk full")
+	}
+	return s.Store.Put(ctx, key, r)
+}
+
+// statementRecorder keeps the statements it is asked to notify.
+type statementRecorder struct {
+	files map[string]models.NotificationAttachment
+}
+
+func (r *statementRecorder) NotifyStatement(ctx context.Context, statement *models.Statement, file models.NotificationAttachment) error {
+	r.files[statement.Account.NoRekening] = file
+	return nil
+}
+
+func TestArchiver(t *testing.T) {
+	logger := utils.NewLogger("critical")
+	ctx := context.Background()
+	period := models.PreviousMonthlyStatementPeriod(time.Now())
+
+	type env struct {
+		archiver      *statements.Archiver
+		statementRepo repositories.MonthlyStatementRepository
+		store         *failingStore
+		notified      *statementRecorder
+		accounts      []*models.Account
+	}
+
+	setup := func(t *testing.T) env {
+		memory := repositories.NewMemoryStore()
+		accountRepo := repositories.NewMemoryAccountRepository(memory, logger)
+		statementRepo := backdated{repositories.NewMemoryMonthlyStatementRepository(memory, logger)}
+
+		files, err := blobs.NewFileStore(t.TempDir())
+		require.NoError(t, err)
+		store := &failingStore{Store: files, fail: map[string]bool{}}
+
+		accounts := []*models.Account{
+			{Name: "Siti Aminah", NIK: "3201014508950001", NoHP: "+6281234567890", NoRekening: "1744847261"},
+			{Name: "Budi Santoso", NIK: "3201014508950002", NoHP: "+6281234567891", NoRekening: "1744847262"},
+			{Name: "Dewi Lestari", NIK: "3201014508950003", NoHP: "+6281234567892", NoRekening: "1744847263"},
+		}
+		for _, account := range accounts {
+			require.NoError(t, accountRepo.CreateAccount(ctx, account))
+		}
+
+		notified := &statementRecorder{files: map[string]models.NotificationAttachment{}}
+		archiver := statements.NewArchiver(
+			usecases.NewStatementUsecase(accountRepo, repositories.NewMemoryMutationRepository(memory, logger), logger),
+			statementRepo,
+			store,
+			statements.Header{BankName: "Bank Contoh"},
+			notified,
+			statements.ArchiverOptions{Interval: time.Hour, BatchSize: 2},
+			logger,
+		)
+
+		return env{archiver: archiver, statementRepo: statementRepo, store: store, notified: notified, accounts: accounts}
+	}
+
+	t.Run("generates every account once with its checksum", func(t *testing.T) {
+		e := setup(t)
+
+		result, err := e.archiver.Archive(ctx, period)
+		require.NoError(t, err)
+		assert.Equal(t, statements.ArchiveResult{Generated: 3}, result)
+
+		for _, account := range e.accounts {
+			statement, err := e.statementRepo.GetStatement(ctx, account.ID, period)
+			require.NoError(t, err)
+			require.NotNil(t, statement)
+			assert.Equal(t, models.MonthlyStatementGenerated, statement.Status)
+			assert.Equal(t, statements.BlobKey(period, account.NoRekening), statement.BlobKey)
+			assert.NotNil(t, statement.GeneratedAt)
+			assert.NotNil(t, statement.NotifiedAt)
+
+			blob, err := e.store.Open(ctx, statement.BlobKey)
+			require.NoError(t, err)
+			content, err := io.ReadAll(blob)
+			blob.Close()
+			require.NoError(t, err)
+			sum := sha256.Sum256(content)
+			assert.True(t, bytes.HasPrefix(content, []byte("%PDF-")))
+			assert.Equal(t, int64(len(content)), statement.Size)
+			assert.Equal(t, hex.EncodeToString(sum[:]), statement.Checksum)
+
+			file := e.notified.files[account.NoRekening]
+			assert.Equal(t, "application/pdf", file.ContentType)
+			assert.Equal(t, content, file.Content)
+		}
+
+		result, err = e.archiver.Archive(ctx, period)
+		require.NoError(t, err)
+		assert.Equal(t, statements.ArchiveResult{}, result, "generated statements are kept")
+	})
+
+	t.Run("failed statements are recorded and retried", func(t *testing.T) {
+		e := setup(t)
+		failing := statements.BlobKey(period, e.accounts[1].NoRekening)
+		e.store.fail[failing] = true
+
+		result, err := e.archiver.Archive(ctx, period)
+		require.NoError(t, err)
+		assert.Equal(t, statements.ArchiveResult{Generated: 2, Failed: 1}, result)
+
+		statement, err := e.statementRepo.GetStatement(ctx, e.accounts[1].ID, period)
+		require.NoError(t, err)
+		require.NotNil(t, statement)
+		assert.Equal(t, models.MonthlyStatementFailed, statement.Status)
+		assert.Equal(t, "disk full", statement.LastError)
+		assert.NotContains(t, e.notified.files, e.accounts[1].NoRekening)
+
+		_, err = e.store.Open(ctx, failing)
+		assert.ErrorIs(t, err, blobs.ErrNotFound)
+
+		delete(e.store.fail, failing)
+		result, err = e.archiver.Archive(ctx, period)
+		require.NoError(t, err)
+		assert.Equal(t, statements.ArchiveResult{Generated: 1}, result)
+
+		statement, err = e.statementRepo.GetStatement(ctx, e.accounts[1].ID, period)
+		require.NoError(t, err)
+		assert.Equal(t, models.MonthlyStatementGenerated, statement.Status)
+		assert.Equal(t, 2, statement.Attempts)
+	})
+
+	t.Run("months not over are refused", func(t *testing.T) {
+		e := setup(t)
+
+		_, err := e.archiver.Archive(ctx, time.Now().In(models.StatementZone).Format(models.MonthlyStatementPeriodLayout))
+		require.Error(t, err)
+
+		_, err = e.archiver.Archive(ctx, "2025-13")
+		assert.ErrorIs(t, err, models.MonthlyStatementPeriodInvalidErr)
+	})
+}
@@ -0,0 +1,66 @@
+package statements
+
+import (
+	"accounts-service/models"
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// CSVHeader are the columns of a CSV statement.
+var CSVHeader = []string{"tanggal", "keterangan", "referensi", "debit", "kredit", "saldo"}
+
+// CSVWriter writes a statement as CSV: the opening balance, one row per
+// mutation with the balance after it, then the totals and the closing
+// balance. Amounts are plain decimals with 2 digits, times are in WIB.
+type CSVWriter struct {
+	w *csv.Writer
+}
+
+func NewCSVWriter(w io.Writer) *CSVWriter {
+	return &CSVWriter{w: csv.NewWriter(w)}
+}
+
+func (c *CSVWriter) WriteHeader(statement *models.Statement) error {
+	c.w.Write(CSVHeader)
+	return c.w.Write([]string{statement.From.Format(models.StatementDateLayout), DescriptionOpening, "", "", "", csvAmount(statement.OpeningBalance)})
+}
+
+func (c *CSVWriter) WriteLine(statement *models.Statement, line *models.StatementLine) error {
+	debit, credit := "", ""
+	if line.Mutation.Type == models.MutationTypeDebit {
+		debit = csvAmount(line.Mutation.Nominal)
+	} else {
+		credit = csvAmount(line.Mutation.Nominal)
+	}
+
+	return c.w.Write([]string{
+		line.Mutation.CreatedAt.In(models.StatementZone).Format("2006-01-02 15:04:05"),
+		Description(line.Mutation.Type),
+		csvText(line.Mutation.Reference),
+		debit,
+		credit,
+		csvAmount(line.Balance),
+	})
+}
+
+func (c *CSVWriter) WriteFooter(statement *models.Statement) error {
+	to := statement.To.Format(models.StatementDateLayout)
+	c.w.Write([]string{to, DescriptionTotal, "", csvAmount(statement.TotalDebit), csvAmount(statement.TotalCredit), ""})
+	c.w.Write([]string{to, DescriptionClosing, "", "", "", csvAmount(statement.ClosingBalance)})
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func csvAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
+
+// csvText keeps spreadsheets from reading free text as a formula.
+func csvText(text string) string {
+	if text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
@@ -0,0 +1,196 @@
+package statements
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// A4 page size and the fonts of the standard 14 used by statements, which
+// every PDF reader has so nothing is embedded.
+const (
+	pageWidth  = 595.28
+	pageHeight = 841.89
+
+	fontRegular   = "F1"
+	fontBold      = "F2"
+	fontMono      = "F3"
+	fontMonoBold  = "F4"
+	monoCharWidth = 0.6 // Courier advance, in font size units
+)
+
+var baseFonts = []string{"Helvetica", "Helvetica-Bold", "Courier", "Courier-Bold"}
+
+// pdfDocument writes a PDF 1.4 file page by page, so memory stays bounded by
+// one page however long the document. The page tree is written last, once
+// the pages are known.
+type pdfDocument struct {
+	w       *countingWriter
+	offsets map[int]int64
+	next    int
+	pages   []int
+}
+
+// Object numbers reserved at the start, the fonts follow the page tree.
+const (
+	catalogObject   = 1
+	pagesObject     = 2
+	firstFontObject = 3
+)
+
+func newPDFDocument(w io.Writer) (*pdfDocument, error) {
+	d := &pdfDocument{
+		w:       &countingWriter{w: w},
+		offsets: map[int]int64{},
+		next:    firstFontObject + len(baseFonts),
+	}
+
+	// The binary comment marks the file as binary for transfer tools
+	if _, err := io.WriteString(d.w, "%PDF-1.4\n%\xe2\xe3\xcf\xd3\n"); err != nil {
+		return nil, err
+	}
+	if err := d.writeObject(catalogObject, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesObject)); err != nil {
+		return nil, err
+	}
+	for i, font := range baseFonts {
+		if err := d.writeObject(firstFontObject+i, fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", font)); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+func (d *pdfDocument) reserve() int {
+	d.next++
+	return d.next - 1
+}
+
+func (d *pdfDocument) writeObject(num int, body string) error {
+	d.offsets[num] = d.w.n
+	_, err := fmt.Fprintf(d.w, "%d 0 obj\n%s\nendobj\n", num, body)
+	return err
+}
+
+// addPage compresses the content stream of a page and writes the page.
+func (d *pdfDocument) addPage(content []byte) error {
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	zw.Write(content)
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	contentObject := d.reserve()
+	d.offsets[contentObject] = d.w.n
+	if _, err := fmt.Fprintf(d.w, "%d 0 obj\n<< /Length %d /Filter /FlateDecode >>\nstream\n", contentObject, compressed.Len()); err != nil {
+		return err
+	}
+	if _, err := d.w.Write(compressed.Bytes()); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(d.w, "\nendstream\nendobj\n"); err != nil {
+		return err
+	}
+
+	var fonts strings.Builder
+	for i := range baseFonts {
+		fmt.Fprintf(&fonts, " /F%d %d 0 R", i+1, firstFontObject+i)
+	}
+
+	pageObject := d.reserve()
+	d.pages = append(d.pages, pageObject)
+	return d.writeObject(pageObject, fmt.Sprintf(
+		"<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font <<%s >> >> /Contents %d 0 R >>",
+		pagesObject, pageWidth, pageHeight, fonts.String(), contentObject,
+	))
+}
+
+// close writes the page tree, the document information, the cross
+// reference table and the trailer.
+func (d *pdfDocument) close(title string, created time.Time) error {
+	kids := make([]string, len(d.pages))
+	for i, page := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", page)
+	}
+	if err := d.writeObject(pagesObject, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages))); err != nil {
+		return err
+	}
+
+	_, offset := created.Zone()
+	infoObject := d.reserve()
+	err := d.writeObject(infoObject, fmt.Sprintf("<< /Title %s /Producer (accounts-service) /CreationDate (D:%s%+03d'%02d') >>",
+		pdfString(title), created.Format("20060102150405"), offset/3600, offset%3600/60))
+	if err != nil {
+		return err
+	}
+
+	xref := d.w.n
+	fmt.Fprintf(d.w, "xref\n0 %d\n0000000000 65535 f \n", d.next)
+	for num := 1; num < d.next; num++ {
+		fmt.Fprintf(d.w, "%010d 00000 n \n", d.offsets[num])
+	}
+	_, err = fmt.Fprintf(d.w, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", d.next, catalogObject, infoObject, xref)
+	return err
+}
+
+// pdfPage builds the content stream of one page. Coordinates are points from
+// the bottom left corner.
+type pdfPage struct {
+	content bytes.Buffer
+}
+
+func (p *pdfPage) text(font string, size, x, y float64, text string) {
+	fmt.Fprintf(&p.content, "BT /%s %.1f Tf %.2f %.2f Td %s Tj ET\n", font, size, x, y, pdfString(text))
+}
+
+// textRight right aligns text at x. Only the monospaced fonts have a width
+// known without font metrics.
+func (p *pdfPage) textRight(font string, size, x, y float64, text string) {
+	p.text(font, size, x-monoWidth(size, text), y, text)
+}
+
+func (p *pdfPage) line(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(&p.content, "0.5 w %.2f %.2f m %.2f %.2f l S\n", x1, y1, x2, y2)
+}
+
+func monoWidth(size float64, text string) float64 {
+	return float64(len([]rune(text))) * size * monoCharWidth
+}
+
+// pdfString encodes text as a literal string in WinAnsiEncoding. Characters
+// outside Latin-1 are replaced by "?".
+func pdfString(text string) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// countingWriter counts the bytes written, the offsets of the cross
+// reference table.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
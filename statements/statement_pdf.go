@@ -0,0 +1,182 @@
+package statements
+
+import (
+	"accounts-service/models"
+	"fmt"
+	"io"
+)
+
+// Layout of a statement page, in points.
+const (
+	margin     = 40.0
+	tableSize  = 7.5
+	rowHeight  = 12.0
+	tableTop   = 672.0
+	tableEnd   = 60.0
+	footerSize = 7.0
+
+	// Columns, the amounts are right aligned at their x
+	columnDate        = margin
+	columnDescription = columnDate + 18*tableSize*monoCharWidth
+	columnReference   = columnDescription + 12*tableSize*monoCharWidth
+	columnDebit       = columnReference + 40*tableSize*monoCharWidth
+	columnCredit      = columnDebit + 22*tableSize*monoCharWidth
+	columnBalance     = columnCredit + 22*tableSize*monoCharWidth
+
+	referenceWidth = 18
+	summaryRows    = 6
+)
+
+// PDFWriter writes a statement as an A4 PDF with the bank header, the
+// account and the period on every page, and the totals after the last line.
+// Each page is written as soon as it is full.
+type PDFWriter struct {
+	header Header
+	w      io.Writer
+
+	doc  *pdfDocument
+	page *pdfPage
+	y    float64
+}
+
+func NewPDFWriter(w io.Writer, header Header) *PDFWriter {
+	return &PDFWriter{header: header, w: w}
+}
+
+func (p *PDFWriter) WriteHeader(statement *models.Statement) error {
+	doc, err := newPDFDocument(p.w)
+	if err != nil {
+		return err
+	}
+	p.doc = doc
+	p.newPage(statement)
+
+	p.row(statement.From.Format("02/01/2006"), DescriptionOpening, "", "", "", formatAmount(statement.OpeningBalance))
+	return nil
+}
+
+func (p *PDFWriter) WriteLine(statement *models.Statement, line *models.StatementLine) error {
+	if p.y < tableEnd {
+		if err := p.nextPage(statement); err != nil {
+			return err
+		}
+	}
+
+	debit, credit := "", ""
+	if line.Mutation.Type == models.MutationTypeDebit {
+		debit = formatAmount(line.Mutation.Nominal)
+	} else {
+		credit = formatAmount(line.Mutation.Nominal)
+	}
+
+	reference := []rune(line.Mutation.Reference)
+	if len(reference) > referenceWidth {
+		reference = append(reference[:referenceWidth-1], '~')
+	}
+
+	p.row(
+		line.Mutation.CreatedAt.In(models.StatementZone).Format("02/01/2006 15:04"),
+		Description(line.Mutation.Type),
+		string(reference),
+		debit,
+		credit,
+		formatAmount(line.Balance),
+	)
+	return nil
+}
+
+func (p *PDFWriter) WriteFooter(statement *models.Statement) error {
+	if p.y-summaryRows*rowHeight < tableEnd {
+		if err := p.nextPage(statement); err != nil {
+			return err
+		}
+	}
+
+	p.page.line(margin, p.y+rowHeight-4, pageWidth-margin, p.y+rowHeight-4)
+	p.y -= 4
+
+	summary := []struct {
+		label  string
+		amount float64
+	}{
+		{"Saldo Awal", statement.OpeningBalance},
+		{fmt.Sprintf("Total Kredit (%d transaksi)", statement.CreditCount), statement.TotalCredit},
+		{fmt.Sprintf("Total Debit (%d transaksi)", statement.DebitCount), statement.TotalDebit},
+		{"Saldo Akhir", statement.ClosingBalance},
+	}
+	for _, row := range summary {
+		p.page.text(fontMonoBold, tableSize, columnReference, p.y, row.label)
+		p.page.textRight(fontMonoBold, tableSize, columnBalance, p.y, formatAmount(row.amount))
+		p.y -= rowHeight
+	}
+
+	if err := p.doc.addPage(p.page.content.Bytes()); err != nil {
+		return err
+	}
+	return p.doc.close("Rekening Koran "+statement.Account.NoRekening, statement.GeneratedAt.In(models.StatementZone))
+}
+
+func (p *PDFWriter) nextPage(statement *models.Statement) error {
+	if err := p.doc.addPage(p.page.content.Bytes()); err != nil {
+		return err
+	}
+	p.newPage(statement)
+	return nil
+}
+
+// newPage starts a page with the bank header, the statement details and the
+// table header.
+func (p *PDFWriter) newPage(statement *models.Statement) {
+	p.page = &pdfPage{}
+	number := len(p.doc.pages) + 1
+	page := p.page
+
+	page.text(fontBold, 14, margin, 800, p.header.BankName)
+	if p.header.Address != "" {
+		page.text(fontRegular, 8, margin, 787, p.header.Address)
+	}
+	page.text(fontBold, 11, margin, 762, "REKENING KORAN")
+
+	details := [][2]string{
+		{"Nama", statement.Account.Name},
+		{"No. Rekening", statement.Account.NoRekening},
+		{"Periode", statement.From.Format("02/01/2006") + " - " + statement.To.Format("02/01/2006")},
+		{"Mata Uang", "IDR"},
+	}
+	for i, detail := range details {
+		y := 745 - float64(i)*12
+		page.text(fontRegular, 9, margin, y, detail[0])
+		page.text(fontRegular, 9, margin+70, y, ": "+detail[1])
+	}
+	page.textRight(fontMono, 8, pageWidth-margin, 745, "Dicetak "+statement.GeneratedAt.In(models.StatementZone).Format("02/01/2006 15:04")+" WIB")
+	page.textRight(fontMono, 8, pageWidth-margin, 733, fmt.Sprintf("Halaman %d", number))
+
+	page.line(margin, tableTop+14, pageWidth-margin, tableTop+14)
+	page.text(fontMonoBold, tableSize, columnDate, tableTop, "Tanggal")
+	page.text(fontMonoBold, tableSize, columnDescription, tableTop, "Keterangan")
+	page.text(fontMonoBold, tableSize, columnReference, tableTop, "Referensi")
+	page.textRight(fontMonoBold, tableSize, columnDebit, tableTop, "Debit")
+	page.textRight(fontMonoBold, tableSize, columnCredit, tableTop, "Kredit")
+	page.textRight(fontMonoBold, tableSize, columnBalance, tableTop, "Saldo")
+	page.line(margin, tableTop-5, pageWidth-margin, tableTop-5)
+
+	page.text(fontRegular, footerSize, margin, 30, "Dokumen ini dicetak oleh sistem dan tidak memerlukan tanda tangan.")
+
+	p.y = tableTop - rowHeight - 4
+}
+
+func (p *PDFWriter) row(date, description, reference, debit, credit, balance string) {
+	p.page.text(fontMono, tableSize, columnDate, p.y, date)
+	p.page.text(fontMono, tableSize, columnDescription, p.y, description)
+	if reference != "" {
+		p.page.text(fontMono, tableSize, columnReference, p.y, reference)
+	}
+	if debit != "" {
+		p.page.textRight(fontMono, tableSize, columnDebit, p.y, debit)
+	}
+	if credit != "" {
+		p.page.textRight(fontMono, tableSize, columnCredit, p.y, credit)
+	}
+	p.page.textRight(fontMono, tableSize, columnBalance, p.y, balance)
+	p.y -= rowHeight
+}
@@ -0,0 +1,48 @@
+package statements
+
+import (
+	"accounts-service/models"
+	"accounts-service/utils"
+	"strings"
+)
+
+// Descriptions of the rows of a statement, in Bahasa Indonesia like the
+// rekening koran customers know.
+const (
+	DescriptionOpening = "SALDO AWAL"
+	DescriptionCredit  = "TABUNG"
+	DescriptionDebit   = "TARIK"
+	DescriptionTotal   = "TOTAL"
+	DescriptionClosing = "SALDO AKHIR"
+)
+
+// Header identifies the bank on printed statements.
+type Header struct {
+	BankName string
+	Address  string
+}
+
+// Description returns the statement description of a mutation type.
+func Description(mutationType string) string {
+	if mutationType == models.MutationTypeDebit {
+		return DescriptionDebit
+	}
+	return DescriptionCredit
+}
+
+// Filename returns the download name of a statement, e.g.
+// "rekening-koran-1744847261-2025-04-01-2025-04-30.pdf".
+func Filename(statement *models.Statement, format string) string {
+	return strings.Join([]string{
+		"rekening-koran",
+		statement.Account.NoRekening,
+		statement.From.Format(models.StatementDateLayout),
+		statement.To.Format(models.StatementDateLayout),
+	}, "-") + "." + format
+}
+
+// formatAmount formats an amount the Indonesian way without the currency,
+// e.g. "1.250.000,50".
+func formatAmount(amount float64) string {
+	return strings.Replace(utils.FormatAmount(amount, utils.LangID), "Rp", "", 1)
+}
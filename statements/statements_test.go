@@ -0,0 +1,221 @@
+package statements_test
+
+import (
+	"accounts-service/models"
+	"accounts-service/repositories"
+	"accounts-service/statements"
+	"accounts-service/usecases"
+	"accounts-service/utils"
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/csv"
+	"io"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type env struct {
+	accounts   usecases.AccountUsecase
+	statements usecases.StatementUsecase
+	account    *models.Account
+}
+
+func setup(t *testing.T) env {
+	logger := utils.NewLogger("critical")
+	store := repositories.NewMemoryStore()
+	accountRepo := repositories.NewMemoryAccountRepository(store, logger)
+	mutationRepo := repositories.NewMemoryMutationRepository(store, logger)
+
+	accounts := usecases.NewAccountUsecase(
+		repositories.NewMemoryTxManager(store, logger),
+		accountRepo,
+		mutationRepo,
+		repositories.NewMemoryOutboxRepository(store, logger),
+		repositories.NewNoopBalanceCache(),
+		logger,
+	)
+
+	account, err := accounts.CreateAccount(context.Background(), &models.CreateAccountRequest{Name: "Siti Aminah", NIK: "3201014508950001", NoHP: "+6281234567890"})
+	require.NoError(t, err)
+
+	return env{
+		accounts:   accounts,
+		statements: usecases.NewStatementUsecase(accountRepo, mutationRepo, logger),
+		account:    account,
+	}
+}
+
+func day(offset int) string {
+	return time.Now().In(models.StatementZone).AddDate(0, 0, offset).Format(models.StatementDateLayout)
+}
+
+func TestStatement(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("csv lists running balances and totals", func(t *testing.T) {
+		e := setup(t)
+		require.NoError(t, e.accounts.Credit(ctx, &models.TransactionRequest{NoRekening: e.account.NoRekening, Nominal: 500000}))
+		require.NoError(t, e.accounts.Debit(ctx, &models.TransactionRequest{NoRekening: e.account.NoRekening, Nominal: 150000.5, Reference: "=HYPERLINK(1)"}))
+		require.NoError(t, e.accounts.Credit(ctx, &models.TransactionRequest{NoRekening: e.account.NoRekening, Nominal: 25000, Reference: "TRF-001"}))
+
+		statement, err := e.statements.PrepareStatement(ctx, &models.StatementRequest{NoRekening: e.account.NoRekening, From: day(-1), To: day(0)})
+		require.NoError(t, err)
+
+		var out bytes.Buffer
+		require.NoError(t, e.statements.WriteStatement(ctx, statement, statements.NewCSVWriter(&out)))
+
+		records, err := csv.NewReader(&out).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, 7)
+		assert.Equal(t, statements.CSVHeader, records[0])
+		assert.Equal(t, []string{day(-1), "SALDO AWAL", "", "", "", "0.00"}, records[1])
+
+		assert.Equal(t, []string{"TABUNG", "", "", "500000.00", "500000.00"}, records[2][1:])
+		assert.Equal(t, []string{"TARIK", "'=HYPERLINK(1)", "150000.50", "", "349999.50"}, records[3][1:])
+		assert.Equal(t, []string{"TABUNG", "TRF-001", "", "25000.00", "374999.50"}, records[4][1:])
+		_, err = time.ParseInLocation("2006-01-02 15:04:05", records[2][0], models.StatementZone)
+		assert.NoError(t, err)
+
+		assert.Equal(t, []string{day(0), "TOTAL", "", "150000.50", "525000.00", ""}, records[5])
+		assert.Equal(t, []string{day(0), "SALDO AKHIR", "", "", "", "374999.50"}, records[6])
+
+		assert.Equal(t, 374999.5, statement.ClosingBalance)
+		assert.Equal(t, 2, statement.CreditCount)
+		assert.Equal(t, 1, statement.DebitCount)
+		assert.Equal(t, "rekening-koran-"+e.account.NoRekening+"-"+day(-1)+"-"+day(0)+".csv", statements.Filename(statement, models.StatementFormatCSV))
+	})
+
+	t.Run("period after the mutations opens and closes with the saldo", func(t *testing.T) {
+		e := setup(t)
+		require.NoError(t, e.accounts.Credit(ctx, &models.TransactionRequest{NoRekening: e.account.NoRekening, Nominal: 500000}))
+		require.NoError(t, e.accounts.Debit(ctx, &models.TransactionRequest{NoRekening: e.account.NoRekening, Nominal: 100000}))
+
+		statement, err := e.statements.PrepareStatement(ctx, &models.StatementRequest{NoRekening: e.account.NoRekening, From: day(1), To: day(1)})
+		require.NoError(t, err)
+		assert.Equal(t, float64(400000), statement.OpeningBalance)
+
+		var out bytes.Buffer
+		require.NoError(t, e.statements.WriteStatement(ctx, statement, statements.NewCSVWriter(&out)))
+		records, err := csv.NewReader(&out).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, 4)
+		assert.Equal(t, "400000.00", records[3][5])
+		assert.Zero(t, statement.TotalCredit+statement.TotalDebit)
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		e := setup(t)
+
+		_, err := e.statements.PrepareStatement(ctx, &models.StatementRequest{NoRekening: e.account.NoRekening, From: day(0), To: day(-1)})
+		assert.ErrorIs(t, err, models.StatementPeriodInvalidErr)
+
+		_, err = e.statements.PrepareStatement(ctx, &models.StatementRequest{NoRekening: "1000000000", From: day(-1), To: day(0)})
+		assert.ErrorIs(t, err, models.AccountWithNoRekeningNotFoundErr)
+
+		validator := utils.NewRequestValidator(models.RequestValidationRemarks)
+		err = validator.Validate(&models.StatementRequest{NoRekening: e.account.NoRekening, From: "01-04-2025", To: day(0), Format: "xlsx"})
+		remark, ok := utils.AsRemark(err)
+		require.True(t, ok)
+		var codes []string
+		for _, field := range remark.Remark.Object.([]*utils.Remark) {
+			codes = append(codes, field.Remark.Code)
+		}
+		assert.ElementsMatch(t, []string{models.StatementPeriodInvalid, models.StatementFormatInvalid}, codes)
+	})
+}
+
+var (
+	objectPattern  = regexp.MustCompile(`(?m)^(\d+) 0 obj$`)
+	xrefPattern    = regexp.MustCompile(`(?m)^(\d{10}) 00000 n $`)
+	streamPattern  = regexp.MustCompile(`(?s)stream\n(.*?)\nendstream`)
+	startxrefMatch = regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`)
+)
+
+// pageTexts checks the cross reference table of a PDF and returns the
+// decompressed content of its pages.
+func pageTexts(t *testing.T, pdf []byte) []string {
+	require.True(t, bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")))
+
+	startxref := startxrefMatch.FindSubmatch(pdf)
+	require.NotNil(t, startxref, "missing trailer")
+	offset, err := strconv.Atoi(string(startxref[1]))
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(pdf[offset:], []byte("xref\n")))
+
+	for i, entry := range xrefPattern.FindAllSubmatch(pdf, -1) {
+		at, err := strconv.Atoi(string(entry[1]))
+		require.NoError(t, err)
+		object := objectPattern.FindSubmatch(pdf[at:])
+		require.NotNil(t, object)
+		assert.Equal(t, strconv.Itoa(i+1), string(object[1]), "xref entry %d points to another object", i+1)
+	}
+
+	var pages []string
+	for _, stream := range streamPattern.FindAllSubmatch(pdf, -1) {
+		r, err := zlib.NewReader(bytes.NewReader(stream[1]))
+		require.NoError(t, err)
+		content, err := io.ReadAll(r)
+		require.NoError(t, err)
+		pages = append(pages, string(content))
+	}
+	return pages
+}
+
+func TestPDFWriter(t *testing.T) {
+	ctx := context.Background()
+	e := setup(t)
+
+	for i := 0; i < 120; i++ {
+		require.NoError(t, e.accounts.Credit(ctx, &models.TransactionRequest{NoRekening: e.account.NoRekening, Nominal: 10000, Reference: "SETOR-" + strconv.Itoa(i) + " (tunai)"}))
+	}
+	require.NoError(t, e.accounts.Debit(ctx, &models.TransactionRequest{NoRekening: e.account.NoRekening, Nominal: 200000.25, Reference: "Pembayaran listrik PLN bulan April"}))
+
+	statement, err := e.statements.PrepareStatement(ctx, &models.StatementRequest{NoRekening: e.account.NoRekening, From: day(0), To: day(0)})
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	var sizes []int
+	writer := &lineCounter{
+		StatementWriter: statements.NewPDFWriter(&out, statements.Header{BankName: "Bank Contoh", Address: "Jl. Sudirman No. 1, Jakarta"}),
+		onLine:          func() { sizes = append(sizes, out.Len()) },
+	}
+	require.NoError(t, e.statements.WriteStatement(ctx, statement, writer))
+	assert.Greater(t, sizes[99], sizes[0], "full pages are written before the statement ends")
+
+	pages := pageTexts(t, out.Bytes())
+	require.Len(t, pages, 3)
+	assert.Contains(t, string(out.Bytes()), "/Count 3")
+
+	for i, page := range pages {
+		assert.Contains(t, page, "(Bank Contoh)")
+		assert.Contains(t, page, "(REKENING KORAN)")
+		assert.Contains(t, page, "(: "+e.account.NoRekening+")")
+		assert.Contains(t, page, "(Halaman "+strconv.Itoa(i+1)+")")
+	}
+	assert.Contains(t, pages[0], "(SALDO AWAL)")
+	assert.Contains(t, pages[0], `(SETOR-0 \(tunai\))`)
+	assert.Contains(t, pages[2], "(Pembayaran listri~)")
+	assert.Contains(t, pages[2], "(Total Kredit \\(120 transaksi\\))")
+	assert.Contains(t, pages[2], "(999.999,75)")
+	assert.NotContains(t, pages[1], "(Saldo Akhir)")
+}
+
+// lineCounter calls onLine after every line it writes.
+type lineCounter struct {
+	usecases.StatementWriter
+	onLine func()
+}
+
+func (w *lineCounter) WriteLine(statement *models.Statement, line *models.StatementLine) error {
+	if err := w.StatementWriter.WriteLine(statement, line); err != nil {
+		return err
+	}
+	w.onLine()
+	return nil
+}
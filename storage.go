@@ -0,0 +1,230 @@
+package main
+
+import (
+	"accounts-service/blobs"
+	"accounts-service/config"
+	"accounts-service/migrations"
+	"accounts-service/repositories"
+	"accounts-service/utils"
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// storage bundles the repositories of the configured backend.
+type storage struct {
+	txManager          repositories.TxManager
+	accountRepo        repositories.AccountRepository
+	mutationRepo       repositories.MutationRepository
+	outboxRepo         repositories.OutboxRepository
+	externalIDRepo     repositories.ExternalIDRepository
+	webhookRepo        repositories.WebhookRepository
+	preferenceRepo     repositories.NotificationPreferenceRepository
+	statementRepo      repositories.MonthlyStatementRepository
+	bulkCreditRepo     repositories.BulkCreditRepository
+	qrisRepo           repositories.QRISRepository
+	virtualAccountRepo repositories.VirtualAccountRepository
+	interbankRepo      repositories.InterbankRepository
+	balanceCache       repositories.BalanceCache
+	blobStore          blobs.Store
+
+	db      *sql.DB
+	replica *sql.DB
+	redis   *redis.Client
+}
+
+// openStorage builds the repositories selected by cfg.Storage, the balance
+// cache selected by cfg.BalanceCache and the blob store selected by
+// cfg.BlobStore.
+func openStorage(ctx context.Context, cfg *config.Config, logger utils.Logger) (*storage, error) {
+	blobStore, err := openBlobStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	balanceCache, redisClient, err := openBalanceCache(cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := openRepositories(ctx, cfg, logger)
+	if err != nil {
+		if redisClient != nil {
+			redisClient.Close()
+		}
+		return nil, err
+	}
+
+	store.balanceCache = balanceCache
+	store.blobStore = blobStore
+	store.redis = redisClient
+	return store, nil
+}
+
+// openBlobStore builds the store of generated files, like the archived
+// monthly statements.
+func openBlobStore(cfg *config.Config) (blobs.Store, error) {
+	// file is the only store for now, BLOB_STORE is validated with the config
+	store, err := blobs.NewFileStore(cfg.BlobStorePath)
+	if err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// openBalanceCache builds the balance cache. Balances stored after a posting
+// outlive the replica lag, so a read that misses the cache and goes to the
+// replica never returns a balance older than the posting.
+func openBalanceCache(cfg *config.Config, logger utils.Logger) (repositories.BalanceCache, *redis.Client, error) {
+	var pin time.Duration
+	if cfg.DBReplicaDSN != "" {
+		pin = cfg.DBReplicaMaxLag
+	}
+
+	switch cfg.BalanceCache {
+	case config.BalanceCacheMemory:
+		logger.Warning("Using in-process balance cache, do not run more than one instance")
+		return repositories.NewLRUBalanceCache(cfg.BalanceCacheSize, cfg.BalanceCacheTTL, pin), nil, nil
+	case config.BalanceCacheRedis:
+		client, err := config.NewRedisClient(cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		return repositories.NewRedisBalanceCache(client, cfg.RedisKeyPrefix, cfg.BalanceCacheTTL, pin, logger), client, nil
+	default:
+		return repositories.NewNoopBalanceCache(), nil, nil
+	}
+}
+
+// openRepositories builds the repositories selected by cfg.Storage. For
+// postgres and sqlite it applies pending migrations when AUTO_MIGRATE is set
+// and refuses to start while the schema is behind.
+func openRepositories(ctx context.Context, cfg *config.Config, logger utils.Logger) (*storage, error) {
+	if cfg.Storage == config.StorageMemory {
+		logger.Warning("Using in-memory storage, data is lost on restart")
+
+		store := repositories.NewMemoryStore()
+		return &storage{
+			txManager:          repositories.NewMemoryTxManager(store, logger),
+			accountRepo:        repositories.NewMemoryAccountRepository(store, logger),
+			mutationRepo:       repositories.NewMemoryMutationRepository(store, logger),
+			outboxRepo:         repositories.NewMemoryOutboxRepository(store, logger),
+			externalIDRepo:     repositories.NewMemoryExternalIDRepository(store, logger),
+			webhookRepo:        repositories.NewMemoryWebhookRepository(store, logger),
+			preferenceRepo:     repositories.NewMemoryNotificationPreferenceRepository(store, logger),
+			statementRepo:      repositories.NewMemoryMonthlyStatementRepository(store, logger),
+			bulkCreditRepo:     repositories.NewMemoryBulkCreditRepository(store, logger),
+			qrisRepo:           repositories.NewMemoryQRISRepository(store, logger),
+			virtualAccountRepo: repositories.NewMemoryVirtualAccountRepository(store, logger),
+			interbankRepo:      repositories.NewMemoryInterbankRepository(store, logger),
+		}, nil
+	}
+
+	db, migrator, err := openDatabase(cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	// Apply or verify the schema before accepting traffic
+	if cfg.AutoMigrate {
+		if err := migrator.Run(ctx, "up"); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	if err := migrator.EnsureCurrent(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if cfg.Storage == config.StorageSQLite {
+		return &storage{
+			txManager:          repositories.NewTxManager(db, sql.LevelDefault, cfg.DBTxMaxRetries, logger),
+			accountRepo:        repositories.NewSQLiteAccountRepository(db, logger),
+			mutationRepo:       repositories.NewSQLiteMutationRepository(db, logger),
+			outboxRepo:         repositories.NewSQLiteOutboxRepository(db, logger),
+			externalIDRepo:     repositories.NewSQLiteExternalIDRepository(db, logger),
+			webhookRepo:        repositories.NewSQLiteWebhookRepository(db, logger),
+			preferenceRepo:     repositories.NewSQLiteNotificationPreferenceRepository(db, logger),
+			statementRepo:      repositories.NewSQLiteMonthlyStatementRepository(db, logger),
+			bulkCreditRepo:     repositories.NewSQLiteBulkCreditRepository(db, logger),
+			qrisRepo:           repositories.NewSQLiteQRISRepository(db, logger),
+			virtualAccountRepo: repositories.NewSQLiteVirtualAccountRepository(db, logger),
+			interbankRepo:      repositories.NewSQLiteInterbankRepository(db, logger),
+			db:                 db,
+		}, nil
+	}
+
+	store := &storage{
+		txManager:          repositories.NewTxManager(db, cfg.TxIsolationLevel(), cfg.DBTxMaxRetries, logger),
+		accountRepo:        repositories.NewAccountRepository(db, logger),
+		mutationRepo:       repositories.NewMutationRepository(db, logger),
+		outboxRepo:         repositories.NewOutboxRepository(db, logger),
+		externalIDRepo:     repositories.NewExternalIDRepository(db, logger),
+		webhookRepo:        repositories.NewWebhookRepository(db, logger),
+		preferenceRepo:     repositories.NewNotificationPreferenceRepository(db, logger),
+		statementRepo:      repositories.NewMonthlyStatementRepository(db, logger),
+		bulkCreditRepo:     repositories.NewBulkCreditRepository(db, logger),
+		qrisRepo:           repositories.NewQRISRepository(db, logger),
+		virtualAccountRepo: repositories.NewVirtualAccountRepository(db, logger),
+		interbankRepo:      repositories.NewInterbankRepository(db, logger),
+		db:                 db,
+	}
+
+	if cfg.DBReplicaDSN != "" {
+		replica, err := config.NewReplicaConnection(cfg)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+
+		router := repositories.NewReplicaRouter(db, replica, cfg.DBReplicaMaxLag, cfg.DBReplicaMaxReceiptAge, cfg.DBReplicaLagCheckInterval, logger)
+		store.accountRepo = repositories.NewAccountRepositoryWithReplica(db, router, logger)
+		store.replica = replica
+	}
+
+	return store, nil
+}
+
+// openDatabase connects to the Postgres or SQLite database and prepares the
+// embedded migrations of its dialect.
+func openDatabase(cfg *config.Config, logger utils.Logger) (*sql.DB, *migrations.Migrator, error) {
+	var (
+		db      *sql.DB
+		dialect string
+		err     error
+	)
+	if cfg.Storage == config.StorageSQLite {
+		db, err = config.NewSQLiteConnection(cfg)
+		dialect = migrations.DialectSQLite
+	} else {
+		db, err = config.NewDatabaseConnection(cfg, logger)
+		dialect = migrations.DialectPostgres
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	migrator, err := migrations.NewMigrator(db, dialect, logger)
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+
+	return db, migrator, nil
+}
+
+func (s *storage) Close() error {
+	if s.redis != nil {
+		s.redis.Close()
+	}
+	if s.replica != nil {
+		s.replica.Close()
+	}
+	if s.db != nil {
+		return s.db.Close()
+	}
+	return nil
+}
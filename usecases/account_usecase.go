@@ -5,7 +5,7 @@ import (
 	"accounts-service/repositories"
 	"accounts-service/utils"
 	"context"
-	"errors"
+	"encoding/json"
 	"strconv"
 	"time"
 )
@@ -16,45 +16,37 @@ type AccountUsecase interface {
 	GetSaldo(ctx context.Context, noRekening string) (*models.SaldoResponse, error)
 	Debit(ctx context.Context, req *models.TransactionRequest) error
 	Credit(ctx context.Context, req *models.TransactionRequest) error
+	// Transfer debits the source and credits the beneficiary in one
+	// transaction. A reference is used once per source account.
+	Transfer(ctx context.Context, req *models.TransferRequest) (*models.Transfer, error)
+	ListMutations(ctx context.Context, req *models.ListMutationsRequest) (*models.MutationPage, error)
 }
 
+// defaultMutationsLimit is how many mutations are listed when the request
+// sets no limit.
+const defaultMutationsLimit = 50
+
 type accountUsecase struct {
+	txManager    repositories.TxManager
 	accountRepo  repositories.AccountRepository
 	mutationRepo repositories.MutationRepository
+	outboxRepo   repositories.OutboxRepository
+	balanceCache repositories.BalanceCache
 	logger       utils.Logger
 }
 
-func NewAccountUsecase(accountRepo repositories.AccountRepository, mutationRepo repositories.MutationRepository, logger utils.Logger) AccountUsecase {
+func NewAccountUsecase(txManager repositories.TxManager, accountRepo repositories.AccountRepository, mutationRepo repositories.MutationRepository, outboxRepo repositories.OutboxRepository, balanceCache repositories.BalanceCache, logger utils.Logger) AccountUsecase {
 	return &accountUsecase{
+		txManager:    txManager,
 		accountRepo:  accountRepo,
 		mutationRepo: mutationRepo,
+		outboxRepo:   outboxRepo,
+		balanceCache: balanceCache,
 		logger:       logger,
 	}
 }
 
 func (u *accountUsecase) CreateAccount(ctx context.Context, req *models.CreateAccountRequest) (*models.Account, error) {
-	// Check if account with same nik already exists
-	existingAccount, err := u.accountRepo.GetAccountByNik(ctx, req.NIK)
-	if err != nil {
-		u.logger.Error("Error checking existing account: %v", err)
-		return nil, err
-	}
-
-	if existingAccount != nil {
-		return nil, models.AccountWithNIKIsExistErr
-	}
-
-	// Check if account with same no_hp already exists
-	existingAccount, err = u.accountRepo.GetAccountByNoHp(ctx, req.NoHP)
-	if err != nil {
-		u.logger.Error("Error checking existing account: %v", err)
-		return nil, err
-	}
-
-	if existingAccount != nil {
-		return nil, models.AccountWithNoHpKIsExistErr
-	}
-
 	// generate no rekening from timestamp
 	currentTime := time.Now()
 	unixTime := currentTime.Unix()
@@ -67,9 +59,39 @@ func (u *accountUsecase) CreateAccount(ctx context.Context, req *models.CreateAc
 		NoRekening: noRekening,
 	}
 
-	err = u.accountRepo.CreateAccount(ctx, account)
+	// The checks run in the transaction of the insert, so they read the
+	// primary and not a replica that may not have a new account yet
+	err := u.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		// Check if account with same nik already exists
+		existingAccount, err := u.accountRepo.GetAccountByNik(ctx, req.NIK)
+		if err != nil {
+			u.logger.Error("Error checking existing account: %v", err)
+			return err
+		}
+
+		if existingAccount != nil {
+			return models.AccountWithNIKIsExistErr
+		}
+
+		// Check if account with same no_hp already exists
+		existingAccount, err = u.accountRepo.GetAccountByNoHp(ctx, req.NoHP)
+		if err != nil {
+			u.logger.Error("Error checking existing account: %v", err)
+			return err
+		}
+
+		if existingAccount != nil {
+			return models.AccountWithNoHpKIsExistErr
+		}
+
+		err = u.accountRepo.CreateAccount(ctx, account)
+		if err != nil {
+			u.logger.Error("Error creating account: %v", err)
+			return err
+		}
+		return nil
+	})
 	if err != nil {
-		u.logger.Error("Error creating account: %v", err)
 		return nil, err
 	}
 
@@ -84,13 +106,17 @@ func (u *accountUsecase) GetAccountByNoRekening(ctx context.Context, noRekening
 	}
 
 	if account == nil {
-		return nil, errors.New("account not found")
+		return nil, models.AccountWithNoRekeningNotFoundErr
 	}
 
 	return account, nil
 }
 
 func (u *accountUsecase) GetSaldo(ctx context.Context, noRekening string) (*models.SaldoResponse, error) {
+	if saldo, ok := u.balanceCache.Get(ctx, noRekening); ok {
+		return saldo, nil
+	}
+
 	account, err := u.accountRepo.GetAccountByNoRekening(ctx, noRekening)
 	if err != nil {
 		u.logger.Error("Error getting account saldo: %v", err)
@@ -101,131 +127,226 @@ func (u *accountUsecase) GetSaldo(ctx context.Context, noRekening string) (*mode
 		return nil, models.AccountWithNoRekeningNotFoundErr
 	}
 
-	return &models.SaldoResponse{
+	saldo := &models.SaldoResponse{
 		NoRekening: account.NoRekening,
 		Saldo:      account.Saldo,
-	}, nil
+	}
+	u.balanceCache.Fill(ctx, saldo, repositories.BalanceVersion(account))
+
+	return saldo, nil
 }
 
 func (u *accountUsecase) Debit(ctx context.Context, req *models.TransactionRequest) error {
-	// Start transaction1
-	tx, err := u.accountRepo.BeginTx(ctx)
+	var posted *models.Account
+	err := u.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		// Get and lock account
+		account, err := u.accountRepo.GetAccountByNoRekeningForUpdate(ctx, req.NoRekening)
+		if err != nil {
+			u.logger.Error("Error getting account for debit/tarik: %v", err)
+			return err
+		}
+
+		if account == nil {
+			return models.AccountWithNoRekeningNotFoundErr
+		}
+
+		// Check if saldo is enough
+		if account.Saldo < req.Nominal {
+			return models.AccountinsufficientErr.WithParams(map[string]interface{}{"nominal": req.Nominal})
+		}
+
+		// Update saldo (debit/tarik)
+		_, posted, err = postMutation(ctx, u.accountRepo, u.mutationRepo, u.outboxRepo, u.logger, account, models.MutationTypeDebit, req.Nominal, req.Reference)
+		return err
+	})
 	if err != nil {
-		u.logger.Error("Error starting transaction: %v", err)
 		return err
 	}
 
-	defer func() {
+	storeBalance(ctx, u.balanceCache, posted)
+	return nil
+}
+
+func (u *accountUsecase) Credit(ctx context.Context, req *models.TransactionRequest) error {
+	var posted *models.Account
+	err := u.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		// Get account
+		account, err := u.accountRepo.GetAccountByNoRekening(ctx, req.NoRekening)
 		if err != nil {
-			if rbErr := tx.Rollback(); rbErr != nil {
-				u.logger.Error("Error rolling back transaction: %v", rbErr)
-			}
+			u.logger.Error("Error getting account for credit/tabung: %v", err)
+			return err
+		}
+		if account == nil {
+			return models.AccountWithNoRekeningNotFoundErr
 		}
-	}()
 
-	// Get account
-	account, err := u.accountRepo.GetAccountByNoRekening(ctx, req.NoRekening)
+		// Update saldo (credit/tabung)
+		_, posted, err = postMutation(ctx, u.accountRepo, u.mutationRepo, u.outboxRepo, u.logger, account, models.MutationTypeCredit, req.Nominal, req.Reference)
+		return err
+	})
 	if err != nil {
-		u.logger.Error("Error getting account for debit/tarik: %v", err)
 		return err
 	}
 
-	if account == nil {
-		return models.AccountWithNoRekeningNotFoundErr
+	storeBalance(ctx, u.balanceCache, posted)
+	return nil
+}
+
+func (u *accountUsecase) Transfer(ctx context.Context, req *models.TransferRequest) (*models.Transfer, error) {
+	if req.SourceNoRekening == req.BeneficiaryNoRekening {
+		return nil, models.TransferSameAccountErr
 	}
 
-	// Check if saldo is enough
-	if account.Saldo < req.Nominal {
-		return models.AccountinsufficientErr
+	transfer := &models.Transfer{}
+	err := u.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		// Lock both accounts in the same order whatever the direction, so
+		// transfers between them both ways do not deadlock
+		locked := map[string]*models.Account{}
+		for _, noRekening := range sortedPair(req.SourceNoRekening, req.BeneficiaryNoRekening) {
+			account, err := u.accountRepo.GetAccountByNoRekeningForUpdate(ctx, noRekening)
+			if err != nil {
+				u.logger.Error("Error getting account for transfer: %v", err)
+				return err
+			}
+			if account == nil {
+				return models.AccountWithNoRekeningNotFoundErr
+			}
+			locked[noRekening] = account
+		}
+		source, beneficiary := locked[req.SourceNoRekening], locked[req.BeneficiaryNoRekening]
+
+		existing, err := u.mutationRepo.GetMutationByReference(ctx, source.ID, req.Reference)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			return models.TransferReferenceExistsErr
+		}
+
+		if source.Saldo < req.Nominal {
+			return models.AccountinsufficientErr.WithParams(map[string]interface{}{"nominal": req.Nominal})
+		}
+
+		transfer.Debit, transfer.Source, err = postMutation(ctx, u.accountRepo, u.mutationRepo, u.outboxRepo, u.logger, source, models.MutationTypeDebit, req.Nominal, req.Reference)
+		if err != nil {
+			return err
+		}
+		transfer.Credit, transfer.Beneficiary, err = postMutation(ctx, u.accountRepo, u.mutationRepo, u.outboxRepo, u.logger, beneficiary, models.MutationTypeCredit, req.Nominal, req.Reference)
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Update saldo (debit/tarik)
-	err = u.accountRepo.UpdateSaldo(ctx, tx, account.ID, -req.Nominal)
+	storeBalance(ctx, u.balanceCache, transfer.Source)
+	storeBalance(ctx, u.balanceCache, transfer.Beneficiary)
+	return transfer, nil
+}
+
+// sortedPair returns a and b in ascending order.
+func sortedPair(a, b string) []string {
+	if b < a {
+		return []string{b, a}
+	}
+	return []string{a, b}
+}
+
+func (u *accountUsecase) ListMutations(ctx context.Context, req *models.ListMutationsRequest) (*models.MutationPage, error) {
+	account, err := u.GetAccountByNoRekening(ctx, req.NoRekening)
 	if err != nil {
-		u.logger.Error("Error updating saldo for debit/tarik: %v", err)
-		return err
+		return nil, err
 	}
 
-	// Create mutation record
-	mutation := &models.Mutation{
-		AccountID: account.ID,
-		Nominal:   req.Nominal,
-		Type:      "debit/tarik",
-		Reference: req.Reference,
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultMutationsLimit
 	}
 
-	err = u.mutationRepo.CreateMutation(ctx, tx, mutation)
+	mutations, err := u.mutationRepo.ListMutations(ctx, account.ID, req.BeforeID, limit)
 	if err != nil {
-		u.logger.Error("Error creating mutation for debit/tarik: %v", err)
-		return err
+		return nil, err
 	}
 
-	// Commit transaction
-	if err = tx.Commit(); err != nil {
-		u.logger.Error("Error committing transaction: %v", err)
-		return err
+	page := &models.MutationPage{Mutations: mutations}
+	if len(mutations) == limit {
+		page.NextBeforeID = mutations[len(mutations)-1].ID
 	}
 
-	return nil
+	return page, nil
 }
 
-func (u *accountUsecase) Credit(ctx context.Context, req *models.TransactionRequest) error {
-	// Start transaction
-	tx, err := u.accountRepo.BeginTx(ctx)
-	if err != nil {
-		u.logger.Error("Error starting transaction: %v", err)
-		return err
+// postMutation changes the saldo of an account by nominal, records the
+// mutation and its event, and returns them with the account as posted. It
+// runs in the caller's transaction.
+func postMutation(ctx context.Context, accountRepo repositories.AccountRepository, mutationRepo repositories.MutationRepository, outboxRepo repositories.OutboxRepository, logger utils.Logger, account *models.Account, mutationType string, nominal float64, reference string) (*models.Mutation, *models.Account, error) {
+	delta := nominal
+	if mutationType == models.MutationTypeDebit {
+		delta = -nominal
 	}
 
-	defer func() {
-		if err != nil {
-			if rbErr := tx.Rollback(); rbErr != nil {
-				u.logger.Error("Error rolling back transaction: %v", rbErr)
-			}
-		}
-	}()
+	if err := accountRepo.UpdateSaldo(ctx, account.ID, delta); err != nil {
+		logger.Error("Error updating saldo for %s: %v", mutationType, err)
+		return nil, nil, err
+	}
 
-	// Get account
-	account, err := u.accountRepo.GetAccountByNoRekening(ctx, req.NoRekening)
-	if err != nil {
-		u.logger.Error("Error getting account for credit/tabung: %v", err)
-		return err
+	mutation := &models.Mutation{
+		AccountID: account.ID,
+		Nominal:   nominal,
+		Type:      mutationType,
+		Reference: reference,
 	}
-	if account == nil {
-		return models.AccountWithNoRekeningNotFoundErr
+	if err := mutationRepo.CreateMutation(ctx, mutation); err != nil {
+		logger.Error("Error creating %s mutation: %v", mutationType, err)
+		return nil, nil, err
 	}
 
-	// Update saldo (credit/tabung)
-	err = u.accountRepo.UpdateSaldo(ctx, tx, account.ID, req.Nominal)
+	// Read back the balance and version this posting commits
+	posted, err := accountRepo.GetAccountByNoRekening(ctx, account.NoRekening)
 	if err != nil {
-		u.logger.Error("Error updating saldo for credit/tabung: %v", err)
-		return err
+		return nil, nil, err
 	}
 
-	// Create mutation record
-	mutation := &models.Mutation{
-		AccountID: account.ID,
-		Nominal:   req.Nominal,
-		Type:      "credit/tabung",
-		Reference: req.Reference,
-	}
+	return mutation, posted, recordMutationEvent(ctx, outboxRepo, logger, posted, mutation)
+}
 
-	err = u.mutationRepo.CreateMutation(ctx, tx, mutation)
+// recordMutationEvent adds the mutation to the outbox in the posting
+// transaction, so the event is published if and only if the posting commits.
+func recordMutationEvent(ctx context.Context, outboxRepo repositories.OutboxRepository, logger utils.Logger, account *models.Account, mutation *models.Mutation) error {
+	payload, err := json.Marshal(models.MutationEvent{
+		MutationID: mutation.ID,
+		AccountID:  account.ID,
+		NoRekening: account.NoRekening,
+		Type:       mutation.Type,
+		Nominal:    mutation.Nominal,
+		Saldo:      account.Saldo,
+		Reference:  mutation.Reference,
+		CreatedAt:  mutation.CreatedAt,
+	})
 	if err != nil {
-		u.logger.Error("Error creating mutation for credit/tabung: %v", err)
-		return err
+		return models.CreateOutboxEventErr.Wrap(err)
 	}
 
-	// Commit transaction
-	if err = tx.Commit(); err != nil {
-		u.logger.Error("Error committing transaction: %v", err)
-		return utils.NewRemark(
-			"Error commit transaction",
-			models.CreateMutationError,
-			"",
-			err,
-		)
+	err = outboxRepo.CreateEvent(ctx, &models.OutboxEvent{
+		AggregateKey: account.NoRekening,
+		EventType:    models.EventMutationCreated,
+		Payload:      payload,
+	})
+	if err != nil {
+		logger.Error("Error recording %s event: %v", mutation.Type, err)
+		return err
 	}
 
 	return nil
 }
+
+// storeBalance caches the balance committed by a posting, so the caller's
+// next GetSaldo sees it even while the replica is catching up. Called inside
+// a caller's transaction, it waits for that transaction to commit.
+func storeBalance(ctx context.Context, balanceCache repositories.BalanceCache, account *models.Account) {
+	repositories.AfterCommit(ctx, func() {
+		balanceCache.Store(ctx, &models.SaldoResponse{
+			NoRekening: account.NoRekening,
+			Saldo:      account.Saldo,
+		}, repositories.BalanceVersion(account))
+	})
+}
@@ -0,0 +1,280 @@
+package usecases_test
+
+import (
+	"accounts-service/models"
+	"accounts-service/repositories"
+	"accounts-service/usecases"
+	"accounts-service/utils"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMemoryUsecase() usecases.AccountUsecase {
+	return newCachedMemoryUsecase(repositories.NewNoopBalanceCache())
+}
+
+func newCachedMemoryUsecase(balanceCache repositories.BalanceCache) usecases.AccountUsecase {
+	logger := utils.NewLogger("critical")
+	store := repositories.NewMemoryStore()
+
+	return usecases.NewAccountUsecase(
+		repositories.NewMemoryTxManager(store, logger),
+		repositories.NewMemoryAccountRepository(store, logger),
+		repositories.NewMemoryMutationRepository(store, logger),
+		repositories.NewMemoryOutboxRepository(store, logger),
+		balanceCache,
+		logger,
+	)
+}
+
+func TestAccountUsecase(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("create account rejects duplicate nik and no hp", func(t *testing.T) {
+		uc := newMemoryUsecase()
+
+		_, err := uc.CreateAccount(ctx, &models.CreateAccountRequest{Name: "Siti", NIK: "3201014508950001", NoHP: "+6281234567890"})
+		require.NoError(t, err)
+
+		_, err = uc.CreateAccount(ctx, &models.CreateAccountRequest{Name: "Budi", NIK: "3201014508950001", NoHP: "+6281200000000"})
+		assert.ErrorIs(t, err, models.AccountWithNIKIsExistErr)
+
+		_, err = uc.CreateAccount(ctx, &models.CreateAccountRequest{Name: "Budi", NIK: "3201010101900001", NoHP: "+6281234567890"})
+		assert.ErrorIs(t, err, models.AccountWithNoHpKIsExistErr)
+	})
+
+	t.Run("create account checks duplicates on the primary", func(t *testing.T) {
+		logger := utils.NewLogger("critical")
+		primaryDB, primary, err := sqlmock.New()
+		require.NoError(t, err)
+		defer primaryDB.Close()
+		replicaDB, replica, err := sqlmock.New()
+		require.NoError(t, err)
+		defer replicaDB.Close()
+
+		router := repositories.NewReplicaRouter(primaryDB, replicaDB, 5*time.Second, time.Minute, time.Minute, logger)
+		uc := usecases.NewAccountUsecase(
+			repositories.NewTxManager(primaryDB, sql.LevelDefault, 0, logger),
+			repositories.NewAccountRepositoryWithReplica(primaryDB, router, logger),
+			repositories.NewMutationRepository(primaryDB, logger),
+			repositories.NewOutboxRepository(primaryDB, logger),
+			repositories.NewNoopBalanceCache(),
+			logger,
+		)
+
+		primary.ExpectBegin()
+		primary.ExpectQuery(`FROM accounts`).WithArgs("3201014508950001").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "nik", "no_hp", "no_rekening", "saldo", "created_at", "updated_at"}).
+				AddRow(1, "Siti", "3201014508950001", "+6281234567890", "1744847261", 0, time.Now(), time.Now()))
+		primary.ExpectRollback()
+
+		_, err = uc.CreateAccount(ctx, &models.CreateAccountRequest{Name: "Budi", NIK: "3201014508950001", NoHP: "+6281200000000"})
+		assert.ErrorIs(t, err, models.AccountWithNIKIsExistErr)
+		assert.NoError(t, primary.ExpectationsWereMet())
+		assert.NoError(t, replica.ExpectationsWereMet(), "the replica is not asked")
+	})
+
+	t.Run("credit and debit", func(t *testing.T) {
+		uc := newMemoryUsecase()
+
+		account, err := uc.CreateAccount(ctx, &models.CreateAccountRequest{Name: "Siti", NIK: "3201014508950001", NoHP: "+6281234567890"})
+		require.NoError(t, err)
+
+		require.NoError(t, uc.Credit(ctx, &models.TransactionRequest{NoRekening: account.NoRekening, Nominal: 100000}))
+		require.NoError(t, uc.Debit(ctx, &models.TransactionRequest{NoRekening: account.NoRekening, Nominal: 40000}))
+
+		err = uc.Debit(ctx, &models.TransactionRequest{NoRekening: account.NoRekening, Nominal: 60001})
+		assert.ErrorIs(t, err, models.AccountinsufficientErr)
+
+		saldo, err := uc.GetSaldo(ctx, account.NoRekening)
+		require.NoError(t, err)
+		assert.Equal(t, float64(60000), saldo.Saldo)
+	})
+
+	t.Run("unknown account", func(t *testing.T) {
+		uc := newMemoryUsecase()
+
+		err := uc.Credit(ctx, &models.TransactionRequest{NoRekening: "1000000000", Nominal: 1000})
+		assert.ErrorIs(t, err, models.AccountWithNoRekeningNotFoundErr)
+
+		_, err = uc.GetSaldo(ctx, "1000000000")
+		assert.ErrorIs(t, err, models.AccountWithNoRekeningNotFoundErr)
+	})
+
+	t.Run("list mutations pages newest first", func(t *testing.T) {
+		uc := newMemoryUsecase()
+
+		account, err := uc.CreateAccount(ctx, &models.CreateAccountRequest{Name: "Siti", NIK: "3201014508950001", NoHP: "+6281234567890"})
+		require.NoError(t, err)
+
+		require.NoError(t, uc.Credit(ctx, &models.TransactionRequest{NoRekening: account.NoRekening, Nominal: 100000}))
+		require.NoError(t, uc.Debit(ctx, &models.TransactionRequest{NoRekening: account.NoRekening, Nominal: 40000, Reference: "ATM-001"}))
+		require.NoError(t, uc.Credit(ctx, &models.TransactionRequest{NoRekening: account.NoRekening, Nominal: 5000}))
+
+		page, err := uc.ListMutations(ctx, &models.ListMutationsRequest{NoRekening: account.NoRekening, Limit: 2})
+		require.NoError(t, err)
+		require.Len(t, page.Mutations, 2)
+		assert.Equal(t, float64(5000), page.Mutations[0].Nominal)
+		assert.Equal(t, models.MutationTypeDebit, page.Mutations[1].Type)
+		assert.Equal(t, "ATM-001", page.Mutations[1].Reference)
+		require.NotZero(t, page.NextBeforeID)
+
+		page, err = uc.ListMutations(ctx, &models.ListMutationsRequest{NoRekening: account.NoRekening, Limit: 2, BeforeID: page.NextBeforeID})
+		require.NoError(t, err)
+		require.Len(t, page.Mutations, 1)
+		assert.Equal(t, float64(100000), page.Mutations[0].Nominal)
+		assert.Zero(t, page.NextBeforeID, "a short page is the last one")
+
+		_, err = uc.ListMutations(ctx, &models.ListMutationsRequest{NoRekening: "1000000000"})
+		assert.ErrorIs(t, err, models.AccountWithNoRekeningNotFoundErr)
+	})
+
+	t.Run("concurrent debits never overdraw", func(t *testing.T) {
+		uc := newMemoryUsecase()
+
+		account, err := uc.CreateAccount(ctx, &models.CreateAccountRequest{Name: "Siti", NIK: "3201014508950001", NoHP: "+6281234567890"})
+		require.NoError(t, err)
+		require.NoError(t, uc.Credit(ctx, &models.TransactionRequest{NoRekening: account.NoRekening, Nominal: 10000}))
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		succeeded := 0
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if uc.Debit(ctx, &models.TransactionRequest{NoRekening: account.NoRekening, Nominal: 1000}) == nil {
+					mu.Lock()
+					succeeded++
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		saldo, err := uc.GetSaldo(ctx, account.NoRekening)
+		require.NoError(t, err)
+		assert.Equal(t, 10, succeeded)
+		assert.Equal(t, float64(0), saldo.Saldo)
+	})
+
+	t.Run("balance cache follows postings", func(t *testing.T) {
+		balanceCache := repositories.NewLRUBalanceCache(100, time.Minute, 0)
+		uc := newCachedMemoryUsecase(balanceCache)
+
+		account, err := uc.CreateAccount(ctx, &models.CreateAccountRequest{Name: "Siti", NIK: "3201014508950001", NoHP: "+6281234567890"})
+		require.NoError(t, err)
+
+		saldo, err := uc.GetSaldo(ctx, account.NoRekening)
+		require.NoError(t, err)
+		assert.Equal(t, float64(0), saldo.Saldo)
+
+		require.NoError(t, uc.Credit(ctx, &models.TransactionRequest{NoRekening: account.NoRekening, Nominal: 100000}))
+		saldo, err = uc.GetSaldo(ctx, account.NoRekening)
+		require.NoError(t, err)
+		assert.Equal(t, float64(100000), saldo.Saldo)
+
+		require.NoError(t, uc.Debit(ctx, &models.TransactionRequest{NoRekening: account.NoRekening, Nominal: 40000}))
+		saldo, err = uc.GetSaldo(ctx, account.NoRekening)
+		require.NoError(t, err)
+		assert.Equal(t, float64(60000), saldo.Saldo)
+
+		stats := balanceCache.Stats()
+		assert.Equal(t, uint64(1), stats.Misses)
+		assert.Equal(t, uint64(2), stats.Hits)
+		assert.Equal(t, uint64(3), stats.Stores)
+	})
+
+	t.Run("postings record mutation events", func(t *testing.T) {
+		logger := utils.NewLogger("critical")
+		store := repositories.NewMemoryStore()
+		outboxRepo := repositories.NewMemoryOutboxRepository(store, logger)
+		uc := usecases.NewAccountUsecase(
+			repositories.NewMemoryTxManager(store, logger),
+			repositories.NewMemoryAccountRepository(store, logger),
+			repositories.NewMemoryMutationRepository(store, logger),
+			outboxRepo,
+			repositories.NewNoopBalanceCache(),
+			logger,
+		)
+
+		account, err := uc.CreateAccount(ctx, &models.CreateAccountRequest{Name: "Siti", NIK: "3201014508950001", NoHP: "+6281234567890"})
+		require.NoError(t, err)
+		require.NoError(t, uc.Credit(ctx, &models.TransactionRequest{NoRekening: account.NoRekening, Nominal: 100000, Reference: "setoran"}))
+		assert.Error(t, uc.Debit(ctx, &models.TransactionRequest{NoRekening: account.NoRekening, Nominal: 200000}))
+
+		events, err := outboxRepo.ListDeliverable(ctx, time.Now().Add(time.Second), 10)
+		require.NoError(t, err)
+		require.Len(t, events, 1, "failed debit must not record an event")
+		assert.Equal(t, account.NoRekening, events[0].AggregateKey)
+		assert.Equal(t, models.EventMutationCreated, events[0].EventType)
+
+		var payload models.MutationEvent
+		require.NoError(t, json.Unmarshal(events[0].Payload, &payload))
+		assert.Equal(t, "credit/tabung", payload.Type)
+		assert.Equal(t, float64(100000), payload.Nominal)
+		assert.Equal(t, float64(100000), payload.Saldo)
+		assert.Equal(t, "setoran", payload.Reference)
+		assert.NotZero(t, payload.MutationID)
+	})
+
+	t.Run("transfer posts both sides once per reference", func(t *testing.T) {
+		logger := utils.NewLogger("critical")
+		store := repositories.NewMemoryStore()
+		accountRepo := repositories.NewMemoryAccountRepository(store, logger)
+		outboxRepo := repositories.NewMemoryOutboxRepository(store, logger)
+		uc := usecases.NewAccountUsecase(
+			repositories.NewMemoryTxManager(store, logger),
+			accountRepo,
+			repositories.NewMemoryMutationRepository(store, logger),
+			outboxRepo,
+			repositories.NewNoopBalanceCache(),
+			logger,
+		)
+
+		source := &models.Account{Name: "Siti", NIK: "3201014508950001", NoHP: "+6281234567890", NoRekening: "1744847261"}
+		beneficiary := &models.Account{Name: "Budi", NIK: "3201010101900001", NoHP: "+6281200000000", NoRekening: "1744847262"}
+		require.NoError(t, accountRepo.CreateAccount(ctx, source))
+		require.NoError(t, accountRepo.CreateAccount(ctx, beneficiary))
+		require.NoError(t, uc.Credit(ctx, &models.TransactionRequest{NoRekening: source.NoRekening, Nominal: 100000}))
+
+		req := &models.TransferRequest{SourceNoRekening: source.NoRekening, BeneficiaryNoRekening: beneficiary.NoRekening, Nominal: 40000, Reference: "SNAP 1 0001"}
+		transfer, err := uc.Transfer(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, float64(60000), transfer.Source.Saldo)
+		assert.Equal(t, float64(40000), transfer.Beneficiary.Saldo)
+		assert.Equal(t, models.MutationTypeDebit, transfer.Debit.Type)
+		assert.Equal(t, models.MutationTypeCredit, transfer.Credit.Type)
+		assert.Equal(t, "SNAP 1 0001", transfer.Credit.Reference)
+
+		_, err = uc.Transfer(ctx, req)
+		assert.ErrorIs(t, err, models.TransferReferenceExistsErr)
+
+		_, err = uc.Transfer(ctx, &models.TransferRequest{SourceNoRekening: source.NoRekening, BeneficiaryNoRekening: beneficiary.NoRekening, Nominal: 60001, Reference: "SNAP 1 0002"})
+		assert.ErrorIs(t, err, models.AccountinsufficientErr)
+
+		_, err = uc.Transfer(ctx, &models.TransferRequest{SourceNoRekening: source.NoRekening, BeneficiaryNoRekening: "1000000000", Nominal: 1000, Reference: "SNAP 1 0003"})
+		assert.ErrorIs(t, err, models.AccountWithNoRekeningNotFoundErr)
+
+		_, err = uc.Transfer(ctx, &models.TransferRequest{SourceNoRekening: source.NoRekening, BeneficiaryNoRekening: source.NoRekening, Nominal: 1000, Reference: "SNAP 1 0004"})
+		assert.ErrorIs(t, err, models.TransferSameAccountErr)
+
+		saldo, err := uc.GetSaldo(ctx, source.NoRekening)
+		require.NoError(t, err)
+		assert.Equal(t, float64(60000), saldo.Saldo, "declined transfers post nothing")
+
+		// Events are delivered in order per account, the oldest first
+		events, err := outboxRepo.ListDeliverable(ctx, time.Now().Add(time.Second), 10)
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		assert.Equal(t, beneficiary.NoRekening, events[1].AggregateKey)
+	})
+}
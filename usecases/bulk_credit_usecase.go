@@ -0,0 +1,437 @@
+package usecases
+
+import (
+	"accounts-service/models"
+	"accounts-service/repositories"
+	"accounts-service/utils"
+	"context"
+	"math"
+	"time"
+	"unicode/utf8"
+)
+
+type BulkCreditUsecase interface {
+	// SubmitBatch validates every row of a batch, debits the source account
+	// once and credits the rows according to the mode of the batch. A
+	// per_row batch is returned processing and its rows are credited in the
+	// background, beyond the end of ctx.
+	SubmitBatch(ctx context.Context, req *models.BulkCreditRequest) (*models.BulkCreditBatch, error)
+	// GetBatch returns a batch with the result of each row.
+	GetBatch(ctx context.Context, req *models.BulkCreditBatchRequest) (*models.BulkCreditBatch, error)
+	// ResumeBatches finishes the per_row batches left processing, e.g. by a
+	// restart.
+	ResumeBatches(ctx context.Context) error
+}
+
+// BulkCreditOptions tune bulk credits.
+type BulkCreditOptions struct {
+	// DefaultMode applies to batches sent without a mode.
+	DefaultMode string
+	// MaxRows is the most rows a batch may have.
+	MaxRows int
+}
+
+type bulkCreditUsecase struct {
+	txManager      repositories.TxManager
+	accountRepo    repositories.AccountRepository
+	mutationRepo   repositories.MutationRepository
+	outboxRepo     repositories.OutboxRepository
+	bulkCreditRepo repositories.BulkCreditRepository
+	balanceCache   repositories.BalanceCache
+	options        BulkCreditOptions
+	logger         utils.Logger
+}
+
+func NewBulkCreditUsecase(txManager repositories.TxManager, accountRepo repositories.AccountRepository, mutationRepo repositories.MutationRepository, outboxRepo repositories.OutboxRepository, bulkCreditRepo repositories.BulkCreditRepository, balanceCache repositories.BalanceCache, options BulkCreditOptions, logger utils.Logger) BulkCreditUsecase {
+	return &bulkCreditUsecase{
+		txManager:      txManager,
+		accountRepo:    accountRepo,
+		mutationRepo:   mutationRepo,
+		outboxRepo:     outboxRepo,
+		bulkCreditRepo: bulkCreditRepo,
+		balanceCache:   balanceCache,
+		options:        options,
+		logger:         logger,
+	}
+}
+
+func (u *bulkCreditUsecase) SubmitBatch(ctx context.Context, req *models.BulkCreditRequest) (*models.BulkCreditBatch, error) {
+	mode := req.Mode
+	if mode == "" {
+		mode = u.options.DefaultMode
+	}
+
+	if len(req.Rows) == 0 || len(req.Rows) > u.options.MaxRows {
+		return nil, models.BulkCreditRowsInvalidErr.WithParams(map[string]interface{}{"max": u.options.MaxRows})
+	}
+
+	existing, err := u.bulkCreditRepo.GetBatch(ctx, req.BatchReference)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, models.BulkCreditBatchExistsErr
+	}
+
+	source, err := u.accountRepo.GetAccountByNoRekening(ctx, req.SourceNoRekening)
+	if err != nil {
+		u.logger.Error("Error getting source account for bulk credit: %v", err)
+		return nil, err
+	}
+	if source == nil {
+		return nil, models.AccountWithNoRekeningNotFoundErr
+	}
+
+	batch := &models.BulkCreditBatch{
+		Reference:        req.BatchReference,
+		SourceAccountID:  source.ID,
+		SourceNoRekening: source.NoRekening,
+		Mode:             mode,
+	}
+	valid, err := u.validateRows(ctx, source, req.Rows, batch)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case valid == 0 || (mode == models.BulkCreditModeAllOrNothing && valid < len(batch.Rows)):
+		err = u.reject(ctx, batch)
+	case mode == models.BulkCreditModeAllOrNothing:
+		err = u.creditAll(ctx, source, batch)
+	default:
+		err = u.debitSource(ctx, source, batch)
+		if err == nil {
+			// The source is debited, the rows must be credited or refunded
+			// whatever happens to the request
+			processCtx := context.WithoutCancel(ctx)
+			go func() {
+				if _, err := u.process(processCtx, batch); err != nil {
+					u.logger.Error("Error processing bulk credit batch %s, it is resumed later: %v", batch.Reference, err)
+				}
+			}()
+			return batch, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return batch, nil
+}
+
+func (u *bulkCreditUsecase) GetBatch(ctx context.Context, req *models.BulkCreditBatchRequest) (*models.BulkCreditBatch, error) {
+	batch, err := u.bulkCreditRepo.GetBatch(ctx, req.BatchReference)
+	if err != nil {
+		return nil, err
+	}
+	if batch == nil {
+		return nil, models.BulkCreditNotFoundErr
+	}
+
+	return batch, nil
+}
+
+func (u *bulkCreditUsecase) ResumeBatches(ctx context.Context) error {
+	references, err := u.bulkCreditRepo.ListProcessingBatches(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, reference := range references {
+		batch, err := u.bulkCreditRepo.GetBatch(ctx, reference)
+		if err != nil {
+			return err
+		}
+		if batch == nil {
+			continue
+		}
+
+		u.logger.Info("Resuming bulk credit batch %s", reference)
+		if _, err := u.process(ctx, batch); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateRows checks every row, adding them to the batch as pending or
+// rejected with the code of the first problem found. It returns how many
+// rows are valid.
+func (u *bulkCreditUsecase) validateRows(ctx context.Context, source *models.Account, rows []models.BulkCreditRowRequest, batch *models.BulkCreditBatch) (int, error) {
+	valid := 0
+	batch.Rows = make([]models.BulkCreditRow, 0, len(rows))
+	for i, req := range rows {
+		row := models.BulkCreditRow{
+			Line:       i + 1,
+			NoRekening: truncate(req.NoRekening, 20),
+			Nominal:    req.Nominal,
+			Reference:  truncate(req.Reference, 255),
+			Status:     models.BulkCreditRowPending,
+		}
+
+		switch {
+		case !utils.IsValidNoRekening(req.NoRekening):
+			row.ErrorCode = models.AccountNoRekeningInvalid
+		case !utils.IsValidAmount(req.Nominal):
+			row.ErrorCode = models.AccountNominalInvalid
+			// Kept for the report unless it does not fit the column
+			if math.IsNaN(req.Nominal) || math.Abs(req.Nominal) >= utils.MaxAmount {
+				row.Nominal = 0
+			}
+		case utf8.RuneCountInString(req.Reference) > 255:
+			row.ErrorCode = models.BulkCreditRowReferenceInvalid
+		case req.NoRekening == source.NoRekening:
+			row.ErrorCode = models.BulkCreditRowIsSource
+		default:
+			account, err := u.accountRepo.GetAccountByNoRekening(ctx, req.NoRekening)
+			if err != nil {
+				u.logger.Error("Error getting account for bulk credit: %v", err)
+				return 0, err
+			}
+			if account == nil {
+				row.ErrorCode = models.AccountWithNoRekeningNotFound
+			} else {
+				row.AccountID = account.ID
+			}
+		}
+
+		if row.ErrorCode != "" {
+			row.Status = models.BulkCreditRowRejected
+		} else {
+			valid++
+		}
+		batch.Rows = append(batch.Rows, row)
+	}
+
+	return valid, nil
+}
+
+// reject records a batch that credits nothing, for its report.
+func (u *bulkCreditUsecase) reject(ctx context.Context, batch *models.BulkCreditBatch) error {
+	completedAt := time.Now()
+	batch.Status = models.BulkCreditRejected
+	batch.CompletedAt = &completedAt
+	for i := range batch.Rows {
+		if batch.Rows[i].Status == models.BulkCreditRowPending {
+			batch.Rows[i].Status = models.BulkCreditRowCancelled
+		}
+	}
+
+	return u.createBatch(ctx, batch)
+}
+
+// creditAll debits the source and credits every row of an all_or_nothing
+// batch in one transaction.
+func (u *bulkCreditUsecase) creditAll(ctx context.Context, source *models.Account, batch *models.BulkCreditBatch) error {
+	posted := make(map[string]*models.Account)
+	err := u.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		total, err := u.debit(ctx, source, batch, posted)
+		if err != nil {
+			return err
+		}
+
+		for i := range batch.Rows {
+			row := &batch.Rows[i]
+			account := &models.Account{ID: row.AccountID, NoRekening: row.NoRekening}
+			if _, posted[row.NoRekening], err = postMutation(ctx, u.accountRepo, u.mutationRepo, u.outboxRepo, u.logger, account, models.MutationTypeCredit, row.Nominal, rowReference(batch, row)); err != nil {
+				return err
+			}
+			row.Status = models.BulkCreditRowCredited
+		}
+
+		completedAt := time.Now()
+		batch.Status = models.BulkCreditCompleted
+		batch.CreditedRows = len(batch.Rows)
+		batch.CreditedNominal = total
+		batch.CompletedAt = &completedAt
+		return u.createBatch(ctx, batch)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, account := range posted {
+		storeBalance(ctx, u.balanceCache, account)
+	}
+	return nil
+}
+
+// debitSource debits the source with the total of the valid rows of a
+// per_row batch and records the batch as processing, in one transaction.
+func (u *bulkCreditUsecase) debitSource(ctx context.Context, source *models.Account, batch *models.BulkCreditBatch) error {
+	posted := make(map[string]*models.Account)
+	err := u.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := u.debit(ctx, source, batch, posted); err != nil {
+			return err
+		}
+
+		batch.Status = models.BulkCreditProcessing
+		return u.createBatch(ctx, batch)
+	})
+	if err != nil {
+		return err
+	}
+
+	storeBalance(ctx, u.balanceCache, posted[source.NoRekening])
+	return nil
+}
+
+// debit takes the total of the pending rows from the locked source account.
+func (u *bulkCreditUsecase) debit(ctx context.Context, source *models.Account, batch *models.BulkCreditBatch, posted map[string]*models.Account) (float64, error) {
+	var total float64
+	for _, row := range batch.Rows {
+		if row.Status == models.BulkCreditRowPending {
+			total += row.Nominal
+		}
+	}
+	total = math.Round(total*100) / 100
+
+	locked, err := u.accountRepo.GetAccountByNoRekeningForUpdate(ctx, source.NoRekening)
+	if err != nil {
+		u.logger.Error("Error getting source account for bulk credit: %v", err)
+		return 0, err
+	}
+	if locked == nil {
+		return 0, models.AccountWithNoRekeningNotFoundErr
+	}
+	if locked.Saldo < total {
+		return 0, models.AccountinsufficientErr.WithParams(map[string]interface{}{"nominal": total})
+	}
+
+	_, posted[locked.NoRekening], err = postMutation(ctx, u.accountRepo, u.mutationRepo, u.outboxRepo, u.logger, locked, models.MutationTypeDebit, total, batch.Reference)
+	return total, err
+}
+
+// createBatch records the batch, failing when its reference was used in the
+// meantime.
+func (u *bulkCreditUsecase) createBatch(ctx context.Context, batch *models.BulkCreditBatch) error {
+	if err := u.bulkCreditRepo.CreateBatch(ctx, batch); err != nil {
+		return err
+	}
+	if batch.ID == 0 {
+		return models.BulkCreditBatchExistsErr
+	}
+	return nil
+}
+
+// process credits the pending rows of a per_row batch, each in its own
+// transaction, and finishes the batch. A row is only credited once, even
+// when two instances process the same batch. Once ctx is done the rows left
+// pending wait for the batch to be resumed.
+func (u *bulkCreditUsecase) process(ctx context.Context, batch *models.BulkCreditBatch) (*models.BulkCreditBatch, error) {
+	for _, row := range batch.Rows {
+		if row.Status != models.BulkCreditRowPending {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		var posted *models.Account
+		err := u.txManager.WithinTx(ctx, func(ctx context.Context) error {
+			credited, err := u.bulkCreditRepo.UpdateRowStatus(ctx, row.ID, models.BulkCreditRowPending, models.BulkCreditRowCredited, "")
+			if err != nil || !credited {
+				return err
+			}
+
+			account := &models.Account{ID: row.AccountID, NoRekening: row.NoRekening}
+			_, posted, err = postMutation(ctx, u.accountRepo, u.mutationRepo, u.outboxRepo, u.logger, account, models.MutationTypeCredit, row.Nominal, rowReference(batch, &row))
+			return err
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				// Not a failure of the row, it is credited when resumed
+				return nil, ctx.Err()
+			}
+			u.logger.Error("Error crediting line %d of bulk credit batch %s: %v", row.Line, batch.Reference, err)
+
+			code := models.InternalServerError
+			if remark, ok := utils.AsRemark(err); ok {
+				code = remark.Remark.Code
+			}
+			// A row left pending is credited when the batch is resumed
+			if _, err := u.bulkCreditRepo.UpdateRowStatus(context.WithoutCancel(ctx), row.ID, models.BulkCreditRowPending, models.BulkCreditRowFailed, code); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if posted != nil {
+			storeBalance(ctx, u.balanceCache, posted)
+		}
+	}
+
+	return u.finish(ctx, batch.Reference)
+}
+
+// finish completes a per_row batch once none of its rows is pending,
+// returning the total of the failed rows to the source account.
+func (u *bulkCreditUsecase) finish(ctx context.Context, reference string) (*models.BulkCreditBatch, error) {
+	batch, err := u.bulkCreditRepo.GetBatch(ctx, reference)
+	if err != nil {
+		return nil, err
+	}
+	if batch == nil || batch.Status != models.BulkCreditProcessing {
+		return batch, nil
+	}
+
+	var failed float64
+	batch.CreditedRows, batch.CreditedNominal = 0, 0
+	for _, row := range batch.Rows {
+		switch row.Status {
+		case models.BulkCreditRowPending:
+			return batch, nil
+		case models.BulkCreditRowCredited:
+			batch.CreditedRows++
+			batch.CreditedNominal += row.Nominal
+		case models.BulkCreditRowFailed:
+			failed += row.Nominal
+		}
+	}
+	batch.CreditedNominal = math.Round(batch.CreditedNominal*100) / 100
+	failed = math.Round(failed*100) / 100
+
+	completedAt := time.Now()
+	batch.Status = models.BulkCreditCompleted
+	if batch.CreditedRows < batch.TotalRows {
+		batch.Status = models.BulkCreditPartial
+	}
+	batch.CompletedAt = &completedAt
+
+	var refunded *models.Account
+	err = u.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		finished, err := u.bulkCreditRepo.FinishBatch(ctx, batch)
+		if err != nil || !finished || failed == 0 {
+			return err
+		}
+
+		source := &models.Account{ID: batch.SourceAccountID, NoRekening: batch.SourceNoRekening}
+		_, refunded, err = postMutation(ctx, u.accountRepo, u.mutationRepo, u.outboxRepo, u.logger, source, models.MutationTypeCredit, failed, "REFUND "+batch.Reference)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if refunded != nil {
+		storeBalance(ctx, u.balanceCache, refunded)
+	}
+
+	return batch, nil
+}
+
+// rowReference is the mutation reference of a row, the batch reference when
+// the row has none.
+func rowReference(batch *models.BulkCreditBatch, row *models.BulkCreditRow) string {
+	if row.Reference != "" {
+		return row.Reference
+	}
+	return batch.Reference
+}
+
+// truncate cuts s to at most n runes.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
@@ -0,0 +1,284 @@
+package usecases_test
+
+import (
+	"accounts-service/models"
+	"accounts-service/repositories"
+	"accounts-service/usecases"
+	"accounts-service/utils"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingSaldo fails the saldo updates of the accounts in fail.
+type failingSaldo struct {
+	repositories.AccountRepository
+	fail map[uint]bool
+}
+
+func (r *failingSaldo) UpdateSaldo(ctx context.Context, id uint, amount float64) error {
+	if r.fail[id] {
+		return errors.New("disk full")
+	}
+	return r.AccountRepository.UpdateSaldo(ctx, id, amount)
+}
+
+func TestBulkCreditUsecase(t *testing.T) {
+	logger := utils.NewLogger("critical")
+	ctx := context.Background()
+
+	type env struct {
+		uc             usecases.BulkCreditUsecase
+		accountRepo    *failingSaldo
+		mutationRepo   repositories.MutationRepository
+		bulkCreditRepo repositories.BulkCreditRepository
+		source         *models.Account
+		recipients     []*models.Account
+	}
+
+	setup := func(t *testing.T, saldo float64) env {
+		store := repositories.NewMemoryStore()
+		accountRepo := &failingSaldo{AccountRepository: repositories.NewMemoryAccountRepository(store, logger), fail: map[uint]bool{}}
+		mutationRepo := repositories.NewMemoryMutationRepository(store, logger)
+		bulkCreditRepo := repositories.NewMemoryBulkCreditRepository(store, logger)
+
+		source := &models.Account{Name: "PT Maju Jaya", NIK: "3201014508950001", NoHP: "+6281234567890", NoRekening: "1744847261"}
+		recipients := []*models.Account{
+			{Name: "Budi Santoso", NIK: "3201014508950002", NoHP: "+6281234567891", NoRekening: "1744847262"},
+			{Name: "Dewi Lestari", NIK: "3201014508950003", NoHP: "+6281234567892", NoRekening: "1744847263"},
+		}
+		for _, account := range append([]*models.Account{source}, recipients...) {
+			require.NoError(t, accountRepo.CreateAccount(ctx, account))
+		}
+		require.NoError(t, accountRepo.UpdateSaldo(ctx, source.ID, saldo))
+
+		uc := usecases.NewBulkCreditUsecase(
+			repositories.NewMemoryTxManager(store, logger),
+			accountRepo,
+			mutationRepo,
+			repositories.NewMemoryOutboxRepository(store, logger),
+			bulkCreditRepo,
+			repositories.NewNoopBalanceCache(),
+			usecases.BulkCreditOptions{DefaultMode: models.BulkCreditModeAllOrNothing, MaxRows: 3},
+			logger,
+		)
+		return env{uc: uc, accountRepo: accountRepo, mutationRepo: mutationRepo, bulkCreditRepo: bulkCreditRepo, source: source, recipients: recipients}
+	}
+
+	// processed waits for a per_row batch to finish in the background.
+	processed := func(t *testing.T, e env, reference string) *models.BulkCreditBatch {
+		t.Helper()
+		var batch *models.BulkCreditBatch
+		require.Eventually(t, func() bool {
+			found, err := e.uc.GetBatch(ctx, &models.BulkCreditBatchRequest{BatchReference: reference})
+			require.NoError(t, err)
+			batch = found
+			return found.Status != models.BulkCreditProcessing
+		}, 5*time.Second, 10*time.Millisecond)
+		return batch
+	}
+
+	saldo := func(t *testing.T, e env, account *models.Account) float64 {
+		t.Helper()
+		found, err := e.accountRepo.GetAccountByNoRekening(ctx, account.NoRekening)
+		require.NoError(t, err)
+		return found.Saldo
+	}
+
+	t.Run("all_or_nothing debits the source once and credits every row", func(t *testing.T) {
+		e := setup(t, 1000000)
+
+		batch, err := e.uc.SubmitBatch(ctx, &models.BulkCreditRequest{
+			BatchReference:   "PAYROLL-2025-04",
+			SourceNoRekening: e.source.NoRekening,
+			Rows: []models.BulkCreditRowRequest{
+				{NoRekening: e.recipients[0].NoRekening, Nominal: 250000, Reference: "GAJI-BUDI"},
+				{NoRekening: e.recipients[1].NoRekening, Nominal: 300000.5},
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, models.BulkCreditModeAllOrNothing, batch.Mode)
+		assert.Equal(t, models.BulkCreditCompleted, batch.Status)
+		assert.Equal(t, 2, batch.CreditedRows)
+		assert.Equal(t, 550000.5, batch.CreditedNominal)
+		assert.NotNil(t, batch.CompletedAt)
+
+		assert.Equal(t, 449999.5, saldo(t, e, e.source))
+		assert.Equal(t, float64(250000), saldo(t, e, e.recipients[0]))
+		assert.Equal(t, 300000.5, saldo(t, e, e.recipients[1]))
+
+		mutations, err := e.mutationRepo.ListMutations(ctx, e.source.ID, 0, 10)
+		require.NoError(t, err)
+		require.Len(t, mutations, 1)
+		assert.Equal(t, models.MutationTypeDebit, mutations[0].Type)
+		assert.Equal(t, "PAYROLL-2025-04", mutations[0].Reference)
+
+		mutations, err = e.mutationRepo.ListMutations(ctx, e.recipients[1].ID, 0, 10)
+		require.NoError(t, err)
+		require.Len(t, mutations, 1)
+		assert.Equal(t, "PAYROLL-2025-04", mutations[0].Reference, "rows without a reference use the batch reference")
+
+		found, err := e.uc.GetBatch(ctx, &models.BulkCreditBatchRequest{BatchReference: "PAYROLL-2025-04"})
+		require.NoError(t, err)
+		assert.Equal(t, batch.Status, found.Status)
+		require.Len(t, found.Rows, 2)
+		assert.Equal(t, models.BulkCreditRowCredited, found.Rows[0].Status)
+		assert.Equal(t, "GAJI-BUDI", found.Rows[0].Reference)
+
+		_, err = e.uc.SubmitBatch(ctx, &models.BulkCreditRequest{
+			BatchReference:   "PAYROLL-2025-04",
+			SourceNoRekening: e.source.NoRekening,
+			Rows:             []models.BulkCreditRowRequest{{NoRekening: e.recipients[0].NoRekening, Nominal: 1000}},
+		})
+		assert.ErrorIs(t, err, models.BulkCreditBatchExistsErr)
+	})
+
+	t.Run("all_or_nothing rejects the batch when a row is invalid", func(t *testing.T) {
+		e := setup(t, 1000000)
+
+		batch, err := e.uc.SubmitBatch(ctx, &models.BulkCreditRequest{
+			BatchReference:   "PAYROLL-2025-04",
+			SourceNoRekening: e.source.NoRekening,
+			Rows: []models.BulkCreditRowRequest{
+				{NoRekening: e.recipients[0].NoRekening, Nominal: 250000},
+				{NoRekening: "1000000000", Nominal: 1000},
+				{NoRekening: e.source.NoRekening, Nominal: 0.001},
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, models.BulkCreditRejected, batch.Status)
+		assert.Zero(t, batch.CreditedRows)
+
+		found, err := e.uc.GetBatch(ctx, &models.BulkCreditBatchRequest{BatchReference: "PAYROLL-2025-04"})
+		require.NoError(t, err)
+		require.Len(t, found.Rows, 3)
+		assert.Equal(t, models.BulkCreditRowCancelled, found.Rows[0].Status)
+		assert.Equal(t, models.BulkCreditRowRejected, found.Rows[1].Status)
+		assert.Equal(t, models.AccountWithNoRekeningNotFound, found.Rows[1].ErrorCode)
+		assert.Equal(t, models.AccountNominalInvalid, found.Rows[2].ErrorCode, "the first problem of a row is reported")
+
+		assert.Equal(t, float64(1000000), saldo(t, e, e.source))
+		assert.Zero(t, saldo(t, e, e.recipients[0]))
+	})
+
+	t.Run("per_row credits the valid rows and refunds the failed ones", func(t *testing.T) {
+		e := setup(t, 1000000)
+		e.accountRepo.fail[e.recipients[1].ID] = true
+
+		batch, err := e.uc.SubmitBatch(ctx, &models.BulkCreditRequest{
+			BatchReference:   "PAYROLL-2025-04",
+			SourceNoRekening: e.source.NoRekening,
+			Mode:             models.BulkCreditModePerRow,
+			Rows: []models.BulkCreditRowRequest{
+				{NoRekening: e.recipients[0].NoRekening, Nominal: 250000},
+				{NoRekening: e.recipients[1].NoRekening, Nominal: 300000},
+				{NoRekening: "123", Nominal: 1000},
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, models.BulkCreditProcessing, batch.Status)
+
+		batch = processed(t, e, "PAYROLL-2025-04")
+		assert.Equal(t, models.BulkCreditPartial, batch.Status)
+		assert.Equal(t, 1, batch.CreditedRows)
+		assert.Equal(t, float64(250000), batch.CreditedNominal)
+		require.Len(t, batch.Rows, 3)
+		assert.Equal(t, models.BulkCreditRowCredited, batch.Rows[0].Status)
+		assert.Equal(t, models.BulkCreditRowFailed, batch.Rows[1].Status)
+		assert.Equal(t, models.InternalServerError, batch.Rows[1].ErrorCode)
+		assert.Equal(t, models.AccountNoRekeningInvalid, batch.Rows[2].ErrorCode)
+
+		assert.Equal(t, float64(750000), saldo(t, e, e.source))
+		assert.Equal(t, float64(250000), saldo(t, e, e.recipients[0]))
+		assert.Zero(t, saldo(t, e, e.recipients[1]))
+
+		mutations, err := e.mutationRepo.ListMutations(ctx, e.source.ID, 0, 10)
+		require.NoError(t, err)
+		require.Len(t, mutations, 2)
+		assert.Equal(t, models.MutationTypeCredit, mutations[0].Type)
+		assert.Equal(t, "REFUND PAYROLL-2025-04", mutations[0].Reference)
+		assert.Equal(t, float64(300000), mutations[0].Nominal)
+	})
+
+	t.Run("per_row is processed beyond the request", func(t *testing.T) {
+		e := setup(t, 1000000)
+
+		reqCtx, cancel := context.WithCancel(ctx)
+		_, err := e.uc.SubmitBatch(reqCtx, &models.BulkCreditRequest{
+			BatchReference:   "PAYROLL-2025-04",
+			SourceNoRekening: e.source.NoRekening,
+			Mode:             models.BulkCreditModePerRow,
+			Rows:             []models.BulkCreditRowRequest{{NoRekening: e.recipients[0].NoRekening, Nominal: 250000}},
+		})
+		cancel()
+		require.NoError(t, err)
+
+		batch := processed(t, e, "PAYROLL-2025-04")
+		assert.Equal(t, models.BulkCreditCompleted, batch.Status)
+		assert.Equal(t, float64(250000), saldo(t, e, e.recipients[0]))
+	})
+
+	t.Run("batches left processing are resumed", func(t *testing.T) {
+		e := setup(t, 1000000)
+
+		// A batch recorded before a restart, its rows not yet credited
+		batch := &models.BulkCreditBatch{
+			Reference:       "PAYROLL-2025-04",
+			SourceAccountID: e.source.ID,
+			Mode:            models.BulkCreditModePerRow,
+			Status:          models.BulkCreditProcessing,
+			Rows: []models.BulkCreditRow{
+				{Line: 1, NoRekening: e.recipients[0].NoRekening, AccountID: e.recipients[0].ID, Nominal: 250000, Status: models.BulkCreditRowCredited},
+				{Line: 2, NoRekening: e.recipients[1].NoRekening, AccountID: e.recipients[1].ID, Nominal: 300000, Status: models.BulkCreditRowPending},
+			},
+		}
+		require.NoError(t, e.bulkCreditRepo.CreateBatch(ctx, batch))
+
+		require.NoError(t, e.uc.ResumeBatches(ctx))
+
+		found, err := e.uc.GetBatch(ctx, &models.BulkCreditBatchRequest{BatchReference: "PAYROLL-2025-04"})
+		require.NoError(t, err)
+		assert.Equal(t, models.BulkCreditCompleted, found.Status)
+		assert.Equal(t, 2, found.CreditedRows)
+		assert.Equal(t, float64(550000), found.CreditedNominal)
+		assert.Equal(t, float64(300000), saldo(t, e, e.recipients[1]))
+		assert.Zero(t, saldo(t, e, e.recipients[0]), "credited rows are not posted again")
+
+		references, err := e.bulkCreditRepo.ListProcessingBatches(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, references)
+	})
+
+	t.Run("batch errors record nothing", func(t *testing.T) {
+		e := setup(t, 1000)
+
+		_, err := e.uc.SubmitBatch(ctx, &models.BulkCreditRequest{
+			BatchReference:   "PAYROLL-2025-04",
+			SourceNoRekening: e.source.NoRekening,
+			Rows:             []models.BulkCreditRowRequest{{NoRekening: e.recipients[0].NoRekening, Nominal: 1000.01}},
+		})
+		assert.ErrorIs(t, err, models.AccountinsufficientErr)
+
+		_, err = e.uc.SubmitBatch(ctx, &models.BulkCreditRequest{
+			BatchReference:   "PAYROLL-2025-04",
+			SourceNoRekening: "1000000000",
+			Rows:             []models.BulkCreditRowRequest{{NoRekening: e.recipients[0].NoRekening, Nominal: 1}},
+		})
+		assert.ErrorIs(t, err, models.AccountWithNoRekeningNotFoundErr)
+
+		_, err = e.uc.SubmitBatch(ctx, &models.BulkCreditRequest{
+			BatchReference:   "PAYROLL-2025-04",
+			SourceNoRekening: e.source.NoRekening,
+			Rows:             make([]models.BulkCreditRowRequest, 4),
+		})
+		assert.ErrorIs(t, err, models.BulkCreditRowsInvalidErr)
+
+		_, err = e.uc.GetBatch(ctx, &models.BulkCreditBatchRequest{BatchReference: "PAYROLL-2025-04"})
+		assert.ErrorIs(t, err, models.BulkCreditNotFoundErr)
+		assert.Equal(t, float64(1000), saldo(t, e, e.source))
+	})
+}
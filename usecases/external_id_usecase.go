@@ -0,0 +1,40 @@
+package usecases
+
+import (
+	"accounts-service/models"
+	"accounts-service/repositories"
+	"accounts-service/utils"
+	"context"
+	"time"
+)
+
+type ExternalIDUsecase interface {
+	// Claim records the external ID a partner sent at a time. An external ID
+	// is used once per partner and day in WIB, sending it again that day
+	// returns ExternalIDExistsErr.
+	Claim(ctx context.Context, partnerID, externalID string, at time.Time) error
+}
+
+type externalIDUsecase struct {
+	externalIDRepo repositories.ExternalIDRepository
+	logger         utils.Logger
+}
+
+func NewExternalIDUsecase(externalIDRepo repositories.ExternalIDRepository, logger utils.Logger) ExternalIDUsecase {
+	return &externalIDUsecase{
+		externalIDRepo: externalIDRepo,
+		logger:         logger,
+	}
+}
+
+func (u *externalIDUsecase) Claim(ctx context.Context, partnerID, externalID string, at time.Time) error {
+	day := at.In(models.StatementZone).Format(models.StatementDateLayout)
+	claimed, err := u.externalIDRepo.ClaimExternalID(ctx, partnerID, day, externalID)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return models.ExternalIDExistsErr
+	}
+	return nil
+}
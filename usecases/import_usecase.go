@@ -0,0 +1,104 @@
+package usecases
+
+import (
+	"accounts-service/models"
+	"accounts-service/repositories"
+	"accounts-service/utils"
+	"context"
+)
+
+type ImportUsecase interface {
+	// ImportAccount creates an account migrated from the legacy core, keeping
+	// its no rekening, and posts its opening balance in the same transaction.
+	// With dryRun it only checks that the account can be imported.
+	ImportAccount(ctx context.Context, req *models.ImportAccountRequest, dryRun bool) (*models.Account, error)
+}
+
+type importUsecase struct {
+	txManager    repositories.TxManager
+	accountRepo  repositories.AccountRepository
+	mutationRepo repositories.MutationRepository
+	logger       utils.Logger
+}
+
+func NewImportUsecase(txManager repositories.TxManager, accountRepo repositories.AccountRepository, mutationRepo repositories.MutationRepository, logger utils.Logger) ImportUsecase {
+	return &importUsecase{
+		txManager:    txManager,
+		accountRepo:  accountRepo,
+		mutationRepo: mutationRepo,
+		logger:       logger,
+	}
+}
+
+// ImportAccount does not record mutation events for opening balances, so
+// migrated customers are not notified of the migration.
+func (u *importUsecase) ImportAccount(ctx context.Context, req *models.ImportAccountRequest, dryRun bool) (*models.Account, error) {
+	account := &models.Account{
+		Name:       req.Name,
+		NIK:        req.NIK,
+		NoHP:       req.NoHP,
+		NoRekening: req.NoRekening,
+	}
+
+	err := u.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		// The checks of CreateAccount, and the no rekening kept from the
+		// legacy core
+		existing, err := u.accountRepo.GetAccountByNoRekening(ctx, req.NoRekening)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			return models.AccountWithNoRekeningIsExistErr
+		}
+
+		existing, err = u.accountRepo.GetAccountByNik(ctx, req.NIK)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			return models.AccountWithNIKIsExistErr
+		}
+
+		existing, err = u.accountRepo.GetAccountByNoHp(ctx, req.NoHP)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			return models.AccountWithNoHpKIsExistErr
+		}
+
+		if dryRun {
+			return nil
+		}
+
+		if err := u.accountRepo.CreateAccount(ctx, account); err != nil {
+			u.logger.Error("Error importing account: %v", err)
+			return err
+		}
+		if req.OpeningBalance == 0 {
+			return nil
+		}
+
+		if err := u.accountRepo.UpdateSaldo(ctx, account.ID, req.OpeningBalance); err != nil {
+			u.logger.Error("Error posting opening balance: %v", err)
+			return err
+		}
+		mutation := &models.Mutation{
+			AccountID: account.ID,
+			Nominal:   req.OpeningBalance,
+			Type:      models.MutationTypeCredit,
+			Reference: models.OpeningBalanceReference,
+		}
+		if err := u.mutationRepo.CreateMutation(ctx, mutation); err != nil {
+			u.logger.Error("Error creating opening balance mutation: %v", err)
+			return err
+		}
+		account.Saldo = req.OpeningBalance
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return account, nil
+}
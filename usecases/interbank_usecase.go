@@ -0,0 +1,286 @@
+package usecases
+
+import (
+	"accounts-service/models"
+	"accounts-service/repositories"
+	"accounts-service/utils"
+	"context"
+	"math"
+	"time"
+)
+
+type InterbankUsecase interface {
+	// Order debits the source account into the clearing suspense account
+	// and queues a transfer to another bank. A reference is used once per
+	// source account.
+	Order(ctx context.Context, req *models.InterbankTransferRequest) (*models.InterbankTransfer, error)
+	GetTransfer(ctx context.Context, req *models.InterbankTransferLookupRequest) (*models.InterbankTransfer, error)
+	// CutBatch sends the transfers queued before batch.CutoffAt in batch
+	// and commits, then calls write with the batch and its transfers and
+	// marks it written. The file is only written once the transfers are
+	// committed as sent, so a transfer is never in two batches. A batch
+	// whose file failed stays unwritten with its transfers sent, and
+	// WritePendingBatches writes it again under the same key. It reports
+	// false when the window of the batch was already cut or nothing is
+	// queued.
+	CutBatch(ctx context.Context, batch *models.InterbankBatch, write func(ctx context.Context, batch *models.InterbankBatch) error) (bool, error)
+	// WritePendingBatches calls write with every batch cut whose file is
+	// not written yet, oldest first, and marks them written.
+	WritePendingBatches(ctx context.Context, write func(ctx context.Context, batch *models.InterbankBatch) error) (int, error)
+	// Settle completes a transfer sent in the batch of batchReference that
+	// the other bank credited, debiting its nominal from the suspense
+	// account. It reports false when the transfer was already settled.
+	Settle(ctx context.Context, batchReference, transferID string, nominal float64) (bool, error)
+	// Reject completes a transfer sent in the batch of batchReference that
+	// the other bank returned, crediting its nominal back to the source
+	// account from the suspense account. It reports false when the transfer
+	// was already rejected.
+	Reject(ctx context.Context, batchReference, transferID string, nominal float64, returnReason string) (bool, error)
+}
+
+// InterbankOptions describe this bank in clearing.
+type InterbankOptions struct {
+	// BankCode is the clearing code of this bank, not taken as the bank of
+	// a beneficiary.
+	BankCode string
+	// SuspenseNoRekening is the account holding ordered transfers until
+	// they are settled or rejected.
+	SuspenseNoRekening string
+}
+
+type interbankUsecase struct {
+	txManager      repositories.TxManager
+	accountRepo    repositories.AccountRepository
+	interbankRepo  repositories.InterbankRepository
+	accountUsecase AccountUsecase
+	options        InterbankOptions
+	logger         utils.Logger
+}
+
+func NewInterbankUsecase(txManager repositories.TxManager, accountRepo repositories.AccountRepository, interbankRepo repositories.InterbankRepository, accountUsecase AccountUsecase, options InterbankOptions, logger utils.Logger) InterbankUsecase {
+	return &interbankUsecase{
+		txManager:      txManager,
+		accountRepo:    accountRepo,
+		interbankRepo:  interbankRepo,
+		accountUsecase: accountUsecase,
+		options:        options,
+		logger:         logger,
+	}
+}
+
+func (u *interbankUsecase) Order(ctx context.Context, req *models.InterbankTransferRequest) (*models.InterbankTransfer, error) {
+	if req.BankCode == u.options.BankCode {
+		return nil, models.InterbankBankCodeInvalidErr
+	}
+
+	transfer := &models.InterbankTransfer{
+		BankCode:           req.BankCode,
+		BeneficiaryAccount: req.BeneficiaryAccount,
+		BeneficiaryName:    req.BeneficiaryName,
+		Nominal:            req.Nominal,
+		Reference:          req.Reference,
+		Description:        req.Description,
+	}
+
+	err := u.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		posted, err := u.accountUsecase.Transfer(ctx, &models.TransferRequest{
+			SourceNoRekening:      req.NoRekening,
+			BeneficiaryNoRekening: u.options.SuspenseNoRekening,
+			Nominal:               req.Nominal,
+			Reference:             models.InterbankReferencePrefix + req.Reference,
+		})
+		if err != nil {
+			return err
+		}
+
+		transfer.SourceAccountID = posted.Source.ID
+		transfer.SourceNoRekening = posted.Source.NoRekening
+		return u.interbankRepo.CreateTransfer(ctx, transfer)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+func (u *interbankUsecase) GetTransfer(ctx context.Context, req *models.InterbankTransferLookupRequest) (*models.InterbankTransfer, error) {
+	id, ok := models.ParseInterbankTransferID(req.TransferID)
+	if !ok {
+		return nil, models.InterbankTransferIDInvalidErr
+	}
+
+	transfer, err := u.interbankRepo.GetTransfer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if transfer == nil {
+		return nil, models.InterbankTransferNotFoundErr
+	}
+	return transfer, nil
+}
+
+func (u *interbankUsecase) CutBatch(ctx context.Context, batch *models.InterbankBatch, write func(ctx context.Context, batch *models.InterbankBatch) error) (bool, error) {
+	var cut bool
+	err := u.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		cut = false
+		queued, err := u.interbankRepo.ListQueuedTransfers(ctx, batch.CutoffAt)
+		if err != nil || len(queued) == 0 {
+			return err
+		}
+
+		batch.TransferCount = len(queued)
+		batch.TotalNominal = 0
+		for _, transfer := range queued {
+			batch.TotalNominal += transfer.Nominal
+		}
+		if err := u.interbankRepo.CreateBatch(ctx, batch); err != nil {
+			return err
+		}
+		if batch.ID == 0 {
+			return nil
+		}
+
+		batch.Transfers = queued
+		for i := range batch.Transfers {
+			transfer := &batch.Transfers[i]
+			sent, err := u.interbankRepo.SendTransfer(ctx, transfer.ID, batch.ID)
+			if err != nil {
+				return err
+			}
+			if !sent {
+				// Another instance cut the window at the same time, its
+				// batch has the transfers
+				return models.InterbankTransferNotSentErr
+			}
+			transfer.Status = models.InterbankSent
+			transfer.BatchID = batch.ID
+			transfer.BatchReference = batch.Reference
+		}
+
+		cut = true
+		return nil
+	})
+	if err != nil || !cut {
+		return false, err
+	}
+
+	return true, u.writeBatch(ctx, batch, write)
+}
+
+func (u *interbankUsecase) WritePendingBatches(ctx context.Context, write func(ctx context.Context, batch *models.InterbankBatch) error) (int, error) {
+	batches, err := u.interbankRepo.ListUnwrittenBatches(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range batches {
+		batch := &batches[i]
+		batch.Transfers, err = u.interbankRepo.ListBatchTransfers(ctx, batch.ID)
+		if err != nil {
+			return i, err
+		}
+		if err := u.writeBatch(ctx, batch, write); err != nil {
+			return i, err
+		}
+	}
+	return len(batches), nil
+}
+
+// writeBatch writes the file of a committed batch and marks it written.
+func (u *interbankUsecase) writeBatch(ctx context.Context, batch *models.InterbankBatch, write func(ctx context.Context, batch *models.InterbankBatch) error) error {
+	if err := write(ctx, batch); err != nil {
+		u.logger.Error("Error writing interbank batch %s, it is written again on the next check: %v", batch.Reference, err)
+		return err
+	}
+
+	writtenAt := time.Now()
+	if err := u.interbankRepo.MarkBatchWritten(ctx, batch.ID, writtenAt); err != nil {
+		return err
+	}
+	batch.WrittenAt = &writtenAt
+	return nil
+}
+
+func (u *interbankUsecase) Settle(ctx context.Context, batchReference, transferID string, nominal float64) (bool, error) {
+	var settled bool
+	err := u.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		transfer, err := u.complete(ctx, batchReference, transferID, nominal, models.InterbankSettled, "")
+		if err != nil || transfer == nil {
+			return err
+		}
+
+		err = u.accountUsecase.Debit(ctx, &models.TransactionRequest{
+			NoRekening: u.options.SuspenseNoRekening,
+			Nominal:    transfer.Nominal,
+			Reference:  models.InterbankSettlementPrefix + transfer.TransferID,
+		})
+		if err != nil {
+			return err
+		}
+		settled = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return settled, nil
+}
+
+func (u *interbankUsecase) Reject(ctx context.Context, batchReference, transferID string, nominal float64, returnReason string) (bool, error) {
+	var rejected bool
+	err := u.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		transfer, err := u.complete(ctx, batchReference, transferID, nominal, models.InterbankRejected, returnReason)
+		if err != nil || transfer == nil {
+			return err
+		}
+
+		_, err = u.accountUsecase.Transfer(ctx, &models.TransferRequest{
+			SourceNoRekening:      u.options.SuspenseNoRekening,
+			BeneficiaryNoRekening: transfer.SourceNoRekening,
+			Nominal:               transfer.Nominal,
+			Reference:             models.InterbankReturnPrefix + transfer.TransferID,
+		})
+		if err != nil {
+			return err
+		}
+		rejected = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return rejected, nil
+}
+
+// complete moves a transfer sent in the batch of batchReference to status
+// in the caller's transaction and returns it. It returns nil when the
+// transfer already has status, so a settlement file imported twice posts
+// once.
+func (u *interbankUsecase) complete(ctx context.Context, batchReference, transferID string, nominal float64, status, returnReason string) (*models.InterbankTransfer, error) {
+	transfer, err := u.GetTransfer(ctx, &models.InterbankTransferLookupRequest{TransferID: transferID})
+	if err != nil {
+		return nil, err
+	}
+	if transfer.Status == status {
+		return nil, nil
+	}
+	if transfer.Status != models.InterbankSent {
+		return nil, models.InterbankTransferNotSentErr
+	}
+	if transfer.BatchReference != batchReference {
+		return nil, models.InterbankSettlementBatchMismatchErr
+	}
+	if math.Abs(nominal-transfer.Nominal) >= 0.005 {
+		return nil, models.InterbankSettlementMismatchErr
+	}
+
+	completed, err := u.interbankRepo.CompleteTransfer(ctx, transfer.ID, status, returnReason, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !completed {
+		// Completed by a concurrent import since it was read
+		return nil, models.InterbankTransferNotSentErr
+	}
+	return transfer, nil
+}
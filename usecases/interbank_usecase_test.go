@@ -0,0 +1,246 @@
+package usecases_test
+
+import (
+	"accounts-service/models"
+	"accounts-service/repositories"
+	"accounts-service/usecases"
+	"accounts-service/utils"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingInterbankRepository fails to record transfers, after the order
+// posted them.
+type failingInterbankRepository struct {
+	repositories.InterbankRepository
+}
+
+func (failingInterbankRepository) CreateTransfer(ctx context.Context, transfer *models.InterbankTransfer) error {
+	return models.InterbankDBErr.Wrap(errors.New("connection reset"))
+}
+
+func TestInterbankUsecase(t *testing.T) {
+	ctx := context.Background()
+
+	newUsecases := func(t *testing.T) (usecases.AccountUsecase, usecases.InterbankUsecase, *models.Account, *models.Account) {
+		logger := utils.NewLogger("critical")
+		store := repositories.NewMemoryStore()
+		txManager := repositories.NewMemoryTxManager(store, logger)
+		accountRepo := repositories.NewMemoryAccountRepository(store, logger)
+
+		accountUsecase := usecases.NewAccountUsecase(txManager, accountRepo, repositories.NewMemoryMutationRepository(store, logger), repositories.NewMemoryOutboxRepository(store, logger), repositories.NewNoopBalanceCache(), logger)
+
+		suspense := &models.Account{Name: "Kliring Keluar", NIK: "3201010101700001", NoHP: "+6281200000001", NoRekening: "9000000001"}
+		account := &models.Account{Name: "Siti Aminah", NIK: "3201014508950001", NoHP: "+6281234567890", NoRekening: "1744847261"}
+		require.NoError(t, accountRepo.CreateAccount(ctx, suspense))
+		require.NoError(t, accountRepo.CreateAccount(ctx, account))
+		require.NoError(t, accountUsecase.Credit(ctx, &models.TransactionRequest{NoRekening: account.NoRekening, Nominal: 1000000}))
+
+		interbankUsecase := usecases.NewInterbankUsecase(txManager, accountRepo, repositories.NewMemoryInterbankRepository(store, logger), accountUsecase, usecases.InterbankOptions{
+			BankCode:           "484",
+			SuspenseNoRekening: suspense.NoRekening,
+		}, logger)
+		return accountUsecase, interbankUsecase, account, suspense
+	}
+
+	order := func(noRekening, reference string, nominal float64) *models.InterbankTransferRequest {
+		return &models.InterbankTransferRequest{
+			NoRekening:         noRekening,
+			BankCode:           "014",
+			BeneficiaryAccount: "1234567890",
+			BeneficiaryName:    "BUDI SANTOSO",
+			Nominal:            nominal,
+			Reference:          reference,
+		}
+	}
+
+	saldo := func(t *testing.T, accountUsecase usecases.AccountUsecase, noRekening string) float64 {
+		response, err := accountUsecase.GetSaldo(ctx, noRekening)
+		require.NoError(t, err)
+		return response.Saldo
+	}
+
+	cut := func(t *testing.T, uc usecases.InterbankUsecase) *models.InterbankBatch {
+		batch := &models.InterbankBatch{Reference: "KLR202504280900", CutoffAt: time.Now().Add(time.Second), BlobKey: "clearing/2025-04-28/KLR202504280900.txt"}
+		done, err := uc.CutBatch(ctx, batch, func(ctx context.Context, batch *models.InterbankBatch) error { return nil })
+		require.NoError(t, err)
+		require.True(t, done)
+		return batch
+	}
+
+	t.Run("order debits the customer into the suspense account", func(t *testing.T) {
+		accountUsecase, uc, account, suspense := newUsecases(t)
+
+		transfer, err := uc.Order(ctx, order(account.NoRekening, "INV-1", 250000))
+		require.NoError(t, err)
+		assert.Equal(t, models.InterbankQueued, transfer.Status)
+		assert.Equal(t, account.NoRekening, transfer.SourceNoRekening)
+		assert.Equal(t, float64(750000), saldo(t, accountUsecase, account.NoRekening))
+		assert.Equal(t, float64(250000), saldo(t, accountUsecase, suspense.NoRekening))
+
+		found, err := uc.GetTransfer(ctx, &models.InterbankTransferLookupRequest{TransferID: transfer.TransferID})
+		require.NoError(t, err)
+		assert.Equal(t, transfer.ID, found.ID)
+
+		_, err = uc.Order(ctx, order(account.NoRekening, "INV-1", 1000))
+		assert.ErrorIs(t, err, models.TransferReferenceExistsErr)
+		_, err = uc.Order(ctx, order(account.NoRekening, "INV-2", 800000))
+		assert.ErrorIs(t, err, models.AccountinsufficientErr)
+		_, err = uc.Order(ctx, order("1000000000", "INV-2", 1000))
+		assert.ErrorIs(t, err, models.AccountWithNoRekeningNotFoundErr)
+
+		own := order(account.NoRekening, "INV-2", 1000)
+		own.BankCode = "484"
+		_, err = uc.Order(ctx, own)
+		assert.ErrorIs(t, err, models.InterbankBankCodeInvalidErr)
+
+		_, err = uc.GetTransfer(ctx, &models.InterbankTransferLookupRequest{TransferID: "IBT1"})
+		assert.ErrorIs(t, err, models.InterbankTransferIDInvalidErr)
+		_, err = uc.GetTransfer(ctx, &models.InterbankTransferLookupRequest{TransferID: models.InterbankTransferID(99)})
+		assert.ErrorIs(t, err, models.InterbankTransferNotFoundErr)
+	})
+
+	t.Run("a window is cut once and its file written again when it failed", func(t *testing.T) {
+		_, uc, account, _ := newUsecases(t)
+
+		first, err := uc.Order(ctx, order(account.NoRekening, "INV-1", 100000))
+		require.NoError(t, err)
+		second, err := uc.Order(ctx, order(account.NoRekening, "INV-2", 50000.5))
+		require.NoError(t, err)
+
+		failing := &models.InterbankBatch{Reference: "KLR202504280900", CutoffAt: time.Now().Add(time.Second), BlobKey: "clearing/2025-04-28/KLR202504280900.txt"}
+		done, err := uc.CutBatch(ctx, failing, func(ctx context.Context, batch *models.InterbankBatch) error { return errors.New("disk full") })
+		require.Error(t, err)
+		assert.True(t, done, "the batch is committed before its file is written")
+		assert.Nil(t, failing.WrittenAt)
+		found, err := uc.GetTransfer(ctx, &models.InterbankTransferLookupRequest{TransferID: first.TransferID})
+		require.NoError(t, err)
+		assert.Equal(t, models.InterbankSent, found.Status)
+
+		var written []*models.InterbankBatch
+		write := func(ctx context.Context, batch *models.InterbankBatch) error {
+			written = append(written, batch)
+			return nil
+		}
+		count, err := uc.WritePendingBatches(ctx, write)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+		require.Len(t, written, 1)
+		batch := written[0]
+		assert.Equal(t, "KLR202504280900", batch.Reference)
+		assert.Equal(t, "clearing/2025-04-28/KLR202504280900.txt", batch.BlobKey)
+		assert.NotNil(t, batch.WrittenAt)
+		assert.Equal(t, 2, batch.TransferCount)
+		assert.Equal(t, 150000.5, batch.TotalNominal)
+		require.Len(t, batch.Transfers, 2)
+		assert.Equal(t, first.ID, batch.Transfers[0].ID)
+		assert.Equal(t, second.ID, batch.Transfers[1].ID)
+		assert.Equal(t, models.InterbankSent, batch.Transfers[0].Status)
+
+		count, err = uc.WritePendingBatches(ctx, write)
+		require.NoError(t, err)
+		assert.Equal(t, 0, count, "the file is written once")
+
+		found, err = uc.GetTransfer(ctx, &models.InterbankTransferLookupRequest{TransferID: second.TransferID})
+		require.NoError(t, err)
+		assert.Equal(t, models.InterbankSent, found.Status)
+		assert.Equal(t, "KLR202504280900", found.BatchReference)
+
+		_, err = uc.Order(ctx, order(account.NoRekening, "INV-3", 1000))
+		require.NoError(t, err)
+		again := &models.InterbankBatch{Reference: "KLR202504280900", CutoffAt: time.Now().Add(time.Second)}
+		done, err = uc.CutBatch(ctx, again, write)
+		require.NoError(t, err)
+		assert.False(t, done, "the window is already cut")
+
+		empty := &models.InterbankBatch{Reference: "KLR202504281300", CutoffAt: time.Now().Add(-time.Hour)}
+		done, err = uc.CutBatch(ctx, empty, write)
+		require.NoError(t, err)
+		assert.False(t, done, "nothing queued before the cut-off")
+		assert.Len(t, written, 1)
+	})
+
+	t.Run("settlement clears the suspense account once", func(t *testing.T) {
+		accountUsecase, uc, account, suspense := newUsecases(t)
+
+		transfer, err := uc.Order(ctx, order(account.NoRekening, "INV-1", 250000))
+		require.NoError(t, err)
+
+		_, err = uc.Settle(ctx, "KLR202504280900", transfer.TransferID, 250000)
+		assert.ErrorIs(t, err, models.InterbankTransferNotSentErr, "still queued")
+
+		cut(t, uc)
+
+		_, err = uc.Settle(ctx, "KLR202504281500", transfer.TransferID, 250000)
+		assert.ErrorIs(t, err, models.InterbankSettlementBatchMismatchErr, "sent in another batch")
+		_, err = uc.Settle(ctx, "KLR202504280900", transfer.TransferID, 200000)
+		assert.ErrorIs(t, err, models.InterbankSettlementMismatchErr)
+
+		settled, err := uc.Settle(ctx, "KLR202504280900", transfer.TransferID, 250000)
+		require.NoError(t, err)
+		assert.True(t, settled)
+		settled, err = uc.Settle(ctx, "KLR202504280900", transfer.TransferID, 250000)
+		require.NoError(t, err)
+		assert.False(t, settled, "a settlement imported again")
+
+		_, err = uc.Reject(ctx, "KLR202504280900", transfer.TransferID, 250000, "R01")
+		assert.ErrorIs(t, err, models.InterbankTransferNotSentErr)
+
+		found, err := uc.GetTransfer(ctx, &models.InterbankTransferLookupRequest{TransferID: transfer.TransferID})
+		require.NoError(t, err)
+		assert.Equal(t, models.InterbankSettled, found.Status)
+		assert.NotNil(t, found.CompletedAt)
+		assert.Equal(t, float64(0), saldo(t, accountUsecase, suspense.NoRekening))
+		assert.Equal(t, float64(750000), saldo(t, accountUsecase, account.NoRekening))
+	})
+
+	t.Run("rejection returns the nominal to the customer once", func(t *testing.T) {
+		accountUsecase, uc, account, suspense := newUsecases(t)
+
+		transfer, err := uc.Order(ctx, order(account.NoRekening, "INV-1", 250000))
+		require.NoError(t, err)
+		cut(t, uc)
+
+		rejected, err := uc.Reject(ctx, "KLR202504280900", transfer.TransferID, 250000, "R03")
+		require.NoError(t, err)
+		assert.True(t, rejected)
+		rejected, err = uc.Reject(ctx, "KLR202504280900", transfer.TransferID, 250000, "R03")
+		require.NoError(t, err)
+		assert.False(t, rejected)
+
+		found, err := uc.GetTransfer(ctx, &models.InterbankTransferLookupRequest{TransferID: transfer.TransferID})
+		require.NoError(t, err)
+		assert.Equal(t, models.InterbankRejected, found.Status)
+		assert.Equal(t, "R03", found.ReturnReason)
+		assert.Equal(t, float64(0), saldo(t, accountUsecase, suspense.NoRekening))
+		assert.Equal(t, float64(1000000), saldo(t, accountUsecase, account.NoRekening))
+	})
+	t.Run("a rolled back order leaves the cached balance alone", func(t *testing.T) {
+		logger := utils.NewLogger("critical")
+		store := repositories.NewMemoryStore()
+		txManager := repositories.NewMemoryTxManager(store, logger)
+		accountRepo := repositories.NewMemoryAccountRepository(store, logger)
+		balanceCache := repositories.NewLRUBalanceCache(10, time.Minute, time.Minute)
+		accountUsecase := usecases.NewAccountUsecase(txManager, accountRepo, repositories.NewMemoryMutationRepository(store, logger), repositories.NewMemoryOutboxRepository(store, logger), balanceCache, logger)
+
+		require.NoError(t, accountRepo.CreateAccount(ctx, &models.Account{Name: "Kliring Keluar", NIK: "3201010101700001", NoHP: "+6281200000001", NoRekening: "9000000001"}))
+		require.NoError(t, accountRepo.CreateAccount(ctx, &models.Account{Name: "Siti Aminah", NIK: "3201014508950001", NoHP: "+6281234567890", NoRekening: "1744847261"}))
+		require.NoError(t, accountUsecase.Credit(ctx, &models.TransactionRequest{NoRekening: "1744847261", Nominal: 100000}))
+
+		uc := usecases.NewInterbankUsecase(txManager, accountRepo, failingInterbankRepository{repositories.NewMemoryInterbankRepository(store, logger)}, accountUsecase, usecases.InterbankOptions{
+			BankCode:           "484",
+			SuspenseNoRekening: "9000000001",
+		}, logger)
+
+		_, err := uc.Order(ctx, order("1744847261", "INV-1", 40000))
+		require.ErrorIs(t, err, models.InterbankDBErr)
+
+		assert.Equal(t, float64(100000), saldo(t, accountUsecase, "1744847261"))
+		assert.Equal(t, float64(0), saldo(t, accountUsecase, "9000000001"))
+	})
+}
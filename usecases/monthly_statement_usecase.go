@@ -0,0 +1,117 @@
+package usecases
+
+import (
+	"accounts-service/blobs"
+	"accounts-service/models"
+	"accounts-service/repositories"
+	"accounts-service/utils"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+)
+
+type MonthlyStatementUsecase interface {
+	// ListStatements returns the archived statements of an account, latest
+	// month first.
+	ListStatements(ctx context.Context, req *models.MonthlyStatementsRequest) ([]models.MonthlyStatement, error)
+	// OpenStatement returns an archived statement with its file, once the
+	// file is checked against the recorded size and checksum.
+	OpenStatement(ctx context.Context, req *models.MonthlyStatementRequest) (*models.MonthlyStatement, io.ReadCloser, error)
+}
+
+type monthlyStatementUsecase struct {
+	accountRepo   repositories.AccountRepository
+	statementRepo repositories.MonthlyStatementRepository
+	store         blobs.Store
+	logger        utils.Logger
+}
+
+func NewMonthlyStatementUsecase(accountRepo repositories.AccountRepository, statementRepo repositories.MonthlyStatementRepository, store blobs.Store, logger utils.Logger) MonthlyStatementUsecase {
+	return &monthlyStatementUsecase{
+		accountRepo:   accountRepo,
+		statementRepo: statementRepo,
+		store:         store,
+		logger:        logger,
+	}
+}
+
+func (u *monthlyStatementUsecase) account(ctx context.Context, noRekening string) (*models.Account, error) {
+	account, err := u.accountRepo.GetAccountByNoRekening(ctx, noRekening)
+	if err != nil {
+		u.logger.Error("Error getting account for monthly statements: %v", err)
+		return nil, err
+	}
+	if account == nil {
+		return nil, models.AccountWithNoRekeningNotFoundErr
+	}
+	return account, nil
+}
+
+func (u *monthlyStatementUsecase) ListStatements(ctx context.Context, req *models.MonthlyStatementsRequest) ([]models.MonthlyStatement, error) {
+	account, err := u.account(ctx, req.NoRekening)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.statementRepo.ListStatements(ctx, account.ID)
+}
+
+func (u *monthlyStatementUsecase) OpenStatement(ctx context.Context, req *models.MonthlyStatementRequest) (*models.MonthlyStatement, io.ReadCloser, error) {
+	account, err := u.account(ctx, req.NoRekening)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	statement, err := u.statementRepo.GetStatement(ctx, account.ID, req.Period)
+	if err != nil {
+		return nil, nil, err
+	}
+	if statement == nil || statement.Status != models.MonthlyStatementGenerated {
+		return nil, nil, models.MonthlyStatementNotFoundErr
+	}
+
+	// The file is read twice, to check it before any of it is sent
+	if err := u.verify(ctx, statement); err != nil {
+		return nil, nil, err
+	}
+
+	blob, err := u.store.Open(ctx, statement.BlobKey)
+	if err != nil {
+		u.logger.Error("Error opening monthly statement %s: %v", statement.BlobKey, err)
+		return nil, nil, models.InternalServerErr.Wrap(err)
+	}
+	return statement, blob, nil
+}
+
+// verify compares the stored file with the size and checksum recorded when
+// it was generated.
+func (u *monthlyStatementUsecase) verify(ctx context.Context, statement *models.MonthlyStatement) error {
+	blob, err := u.store.Open(ctx, statement.BlobKey)
+	if errors.Is(err, blobs.ErrNotFound) {
+		u.logger.Error("Monthly statement %s is missing from the blob store", statement.BlobKey)
+		return models.MonthlyStatementCorruptedErr.Wrap(err)
+	}
+	if err != nil {
+		u.logger.Error("Error opening monthly statement %s: %v", statement.BlobKey, err)
+		return models.InternalServerErr.Wrap(err)
+	}
+	defer blob.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, blob)
+	if err != nil {
+		u.logger.Error("Error reading monthly statement %s: %v", statement.BlobKey, err)
+		return models.InternalServerErr.Wrap(err)
+	}
+
+	checksum := hex.EncodeToString(hash.Sum(nil))
+	if size != statement.Size || checksum != statement.Checksum {
+		u.logger.Error("Monthly statement %s is corrupted: %d bytes with checksum %s, expected %d bytes with checksum %s",
+			statement.BlobKey, size, checksum, statement.Size, statement.Checksum)
+		return models.MonthlyStatementCorruptedErr.Wrap(fmt.Errorf("checksum %s, expected %s", checksum, statement.Checksum))
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package usecases
+
+import (
+	"accounts-service/models"
+	"accounts-service/repositories"
+	"accounts-service/utils"
+	"context"
+	"slices"
+)
+
+type NotificationUsecase interface {
+	// GetPreference returns the account's preference, or the default one
+	// when the customer has not chosen yet.
+	GetPreference(ctx context.Context, noRekening string) (*models.NotificationPreference, error)
+	UpdatePreference(ctx context.Context, req *models.UpdateNotificationPreferenceRequest) (*models.NotificationPreference, error)
+}
+
+type notificationUsecase struct {
+	accountRepo    repositories.AccountRepository
+	preferenceRepo repositories.NotificationPreferenceRepository
+	logger         utils.Logger
+}
+
+func NewNotificationUsecase(accountRepo repositories.AccountRepository, preferenceRepo repositories.NotificationPreferenceRepository, logger utils.Logger) NotificationUsecase {
+	return &notificationUsecase{
+		accountRepo:    accountRepo,
+		preferenceRepo: preferenceRepo,
+		logger:         logger,
+	}
+}
+
+func (u *notificationUsecase) GetPreference(ctx context.Context, noRekening string) (*models.NotificationPreference, error) {
+	account, err := u.getAccount(ctx, noRekening)
+	if err != nil {
+		return nil, err
+	}
+
+	preference, err := u.preferenceRepo.GetPreference(ctx, account.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if preference == nil {
+		return models.DefaultNotificationPreference(account), nil
+	}
+
+	return preference, nil
+}
+
+func (u *notificationUsecase) UpdatePreference(ctx context.Context, req *models.UpdateNotificationPreferenceRequest) (*models.NotificationPreference, error) {
+	channels, err := validChannels(req.Channels)
+	if err != nil {
+		return nil, err
+	}
+
+	if slices.Contains(channels, models.NotificationChannelEmail) && req.Email == "" {
+		return nil, models.NotificationEmailInvalidErr
+	}
+
+	account, err := u.getAccount(ctx, req.NoRekening)
+	if err != nil {
+		return nil, err
+	}
+
+	preference := &models.NotificationPreference{
+		AccountID:           account.ID,
+		NoRekening:          account.NoRekening,
+		Channels:            channels,
+		Email:               req.Email,
+		LowBalanceThreshold: req.LowBalanceThreshold,
+	}
+
+	if err := u.preferenceRepo.SavePreference(ctx, preference); err != nil {
+		return nil, err
+	}
+
+	return preference, nil
+}
+
+func (u *notificationUsecase) getAccount(ctx context.Context, noRekening string) (*models.Account, error) {
+	account, err := u.accountRepo.GetAccountByNoRekening(ctx, noRekening)
+	if err != nil {
+		u.logger.Error("Error getting account for notification preference: %v", err)
+		return nil, err
+	}
+
+	if account == nil {
+		return nil, models.AccountWithNoRekeningNotFoundErr
+	}
+
+	return account, nil
+}
+
+// validChannels checks the requested channels and removes duplicates.
+func validChannels(channels []string) ([]string, error) {
+	valid := []string{}
+	for _, channel := range channels {
+		if !slices.Contains(models.NotificationChannels, channel) {
+			return nil, models.NotificationChannelsInvalidErr
+		}
+		if !slices.Contains(valid, channel) {
+			valid = append(valid, channel)
+		}
+	}
+
+	return valid, nil
+}
@@ -0,0 +1,263 @@
+package usecases
+
+import (
+	"accounts-service/models"
+	"accounts-service/qris"
+	"accounts-service/repositories"
+	"accounts-service/utils"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Most characters of the merchant name and city of a QRIS code.
+const (
+	qrisMerchantNameLength = 25
+	qrisMerchantCityLength = 15
+)
+
+type QRISUsecase interface {
+	// Generate returns the QRIS code paying an account, dynamic when the
+	// request has a nominal and static otherwise. Dynamic codes are recorded
+	// to be paid.
+	Generate(ctx context.Context, req *models.QRISRequest) (*models.QRISCode, error)
+	// Parse reads a QRIS payload of any acquirer.
+	Parse(ctx context.Context, req *models.QRISParseRequest) (*models.QRISCode, error)
+	// Pay credits the merchant of a QRIS code of this bank. A dynamic code
+	// must be one generated, with its amount, and is paid once. A static
+	// one is paid once per reference.
+	Pay(ctx context.Context, req *models.QRISPaymentRequest) (*models.QRISPayment, error)
+}
+
+// QRISOptions describe the merchants of the QRIS codes generated.
+type QRISOptions struct {
+	// GlobalID is the reverse domain of this bank as acquirer. Payments are
+	// only taken for codes with it.
+	GlobalID string
+	// PANPrefix is followed by the no_rekening in the merchant PAN.
+	PANPrefix string
+	// MerchantCategory is the ISO 18245 merchant category code.
+	MerchantCategory string
+	// MerchantCriteria is the QRIS merchant criteria, e.g. UMI.
+	MerchantCriteria string
+	MerchantCity     string
+	PostalCode       string
+}
+
+type qrisUsecase struct {
+	txManager      repositories.TxManager
+	accountRepo    repositories.AccountRepository
+	mutationRepo   repositories.MutationRepository
+	qrisRepo       repositories.QRISRepository
+	accountUsecase AccountUsecase
+	options        QRISOptions
+	logger         utils.Logger
+}
+
+func NewQRISUsecase(txManager repositories.TxManager, accountRepo repositories.AccountRepository, mutationRepo repositories.MutationRepository, qrisRepo repositories.QRISRepository, accountUsecase AccountUsecase, options QRISOptions, logger utils.Logger) QRISUsecase {
+	return &qrisUsecase{
+		txManager:      txManager,
+		accountRepo:    accountRepo,
+		mutationRepo:   mutationRepo,
+		qrisRepo:       qrisRepo,
+		accountUsecase: accountUsecase,
+		options:        options,
+		logger:         logger,
+	}
+}
+
+func (u *qrisUsecase) Generate(ctx context.Context, req *models.QRISRequest) (*models.QRISCode, error) {
+	account, err := u.accountRepo.GetAccountByNoRekening(ctx, req.NoRekening)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return nil, models.AccountWithNoRekeningNotFoundErr
+	}
+
+	payload := &qris.Payload{
+		MerchantAccounts: []qris.MerchantAccount{{
+			GlobalID:   u.options.GlobalID,
+			PAN:        u.options.PANPrefix + account.NoRekening,
+			MerchantID: account.NoRekening,
+			Criteria:   u.options.MerchantCriteria,
+		}},
+		MerchantCategory: u.options.MerchantCategory,
+		Currency:         qris.CurrencyIDR,
+		CountryCode:      qris.CountryID,
+		MerchantName:     truncate(strings.ToUpper(account.Name), qrisMerchantNameLength),
+		MerchantCity:     truncate(strings.ToUpper(u.options.MerchantCity), qrisMerchantCityLength),
+		PostalCode:       u.options.PostalCode,
+	}
+	if req.Nominal > 0 {
+		payload.Dynamic = true
+		payload.Amount = strconv.FormatFloat(req.Nominal, 'f', -1, 64)
+		payload.BillNumber = req.BillNumber
+		if payload.BillNumber == "" {
+			payload.BillNumber = newBillNumber()
+		}
+	}
+
+	encoded, err := payload.Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	if payload.Dynamic {
+		bill := &models.QRISBill{AccountID: account.ID, BillNumber: payload.BillNumber, Nominal: req.Nominal}
+		if err := u.qrisRepo.CreateBill(ctx, bill); err != nil {
+			return nil, err
+		}
+		if bill.ID == 0 {
+			return nil, models.QRISBillNumberExistsErr
+		}
+	}
+	return u.code(encoded, payload), nil
+}
+
+func (u *qrisUsecase) Parse(ctx context.Context, req *models.QRISParseRequest) (*models.QRISCode, error) {
+	payload, err := qris.Parse(req.Payload)
+	if err != nil {
+		return nil, models.QRISPayloadInvalidErr.WithParams(map[string]interface{}{"reason": err.Error()})
+	}
+	return u.code(req.Payload, payload), nil
+}
+
+func (u *qrisUsecase) Pay(ctx context.Context, req *models.QRISPaymentRequest) (*models.QRISPayment, error) {
+	code, err := u.Parse(ctx, &models.QRISParseRequest{Payload: req.Payload})
+	if err != nil {
+		return nil, err
+	}
+	if code.NoRekening == "" {
+		return nil, models.QRISMerchantUnknownErr
+	}
+
+	payment := &models.QRISPayment{NoRekening: code.NoRekening, Nominal: req.Nominal}
+	if code.Type == models.QRISDynamic {
+		// Parse only checks the amount is a positive number, it must also
+		// fit the balance columns
+		if !utils.IsValidAmount(code.Nominal) {
+			return nil, models.QRISPayloadInvalidErr.WithParams(map[string]interface{}{"reason": "amount " + strconv.FormatFloat(code.Nominal, 'f', -1, 64) + " is out of range"})
+		}
+		if req.Nominal != 0 && math.Abs(req.Nominal-code.Nominal) >= 0.005 {
+			return nil, models.QRISNominalMismatchErr
+		}
+		payment.Nominal = code.Nominal
+		payment.Reference = models.QRISReferencePrefix + code.BillNumber
+	} else {
+		if req.Nominal == 0 || req.Reference == "" {
+			return nil, models.QRISStaticPaymentInvalidErr
+		}
+		payment.Reference = models.QRISReferencePrefix + req.Reference
+	}
+
+	err = u.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		// Locking the merchant keeps a notification sent twice at once from
+		// crediting twice
+		account, err := u.accountRepo.GetAccountByNoRekeningForUpdate(ctx, payment.NoRekening)
+		if err != nil {
+			u.logger.Error("Error getting account for QRIS payment: %v", err)
+			return err
+		}
+		if account == nil {
+			return models.AccountWithNoRekeningNotFoundErr
+		}
+
+		paid, err := u.mutationRepo.GetMutationByReference(ctx, account.ID, payment.Reference)
+		if err != nil {
+			return err
+		}
+		if paid != nil {
+			return models.QRISPaymentExistsErr
+		}
+
+		if code.Type == models.QRISDynamic {
+			// The payload is only trusted as far as it matches a code
+			// generated here
+			bill, err := u.qrisRepo.GetBill(ctx, account.ID, code.BillNumber)
+			if err != nil {
+				return err
+			}
+			if bill == nil {
+				return models.QRISCodeNotFoundErr
+			}
+			if math.Abs(bill.Nominal-payment.Nominal) >= 0.005 {
+				return models.QRISNominalMismatchErr
+			}
+			marked, err := u.qrisRepo.MarkBillPaid(ctx, bill.ID, time.Now())
+			if err != nil {
+				return err
+			}
+			if !marked {
+				return models.QRISPaymentExistsErr
+			}
+		}
+
+		// Credit joins this transaction, the balance it caches is only
+		// stored once the payment commits
+		err = u.accountUsecase.Credit(ctx, &models.TransactionRequest{
+			NoRekening: payment.NoRekening,
+			Nominal:    payment.Nominal,
+			Reference:  payment.Reference,
+		})
+		if err != nil {
+			return err
+		}
+
+		posted, err := u.accountRepo.GetAccountByNoRekening(ctx, payment.NoRekening)
+		if err != nil {
+			return err
+		}
+		payment.Saldo = posted.Saldo
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+// code describes payload. The account paid is only set for codes with the
+// global ID of this bank.
+func (u *qrisUsecase) code(encoded string, payload *qris.Payload) *models.QRISCode {
+	code := &models.QRISCode{
+		Payload:          encoded,
+		Type:             models.QRISStatic,
+		MerchantName:     payload.MerchantName,
+		MerchantCity:     payload.MerchantCity,
+		MerchantCategory: payload.MerchantCategory,
+		BillNumber:       payload.BillNumber,
+	}
+	if payload.Dynamic {
+		code.Type = models.QRISDynamic
+	}
+	if payload.Amount != "" {
+		code.Nominal, _ = strconv.ParseFloat(payload.Amount, 64)
+	}
+
+	merchant := payload.MerchantAccounts[0]
+	for _, account := range payload.MerchantAccounts {
+		if account.GlobalID == u.options.GlobalID {
+			merchant = account
+			if utils.IsValidNoRekening(account.MerchantID) {
+				code.NoRekening = account.MerchantID
+			}
+			break
+		}
+	}
+	code.GlobalID = merchant.GlobalID
+	code.MerchantPAN = merchant.PAN
+	code.MerchantID = merchant.MerchantID
+	return code
+}
+
+// newBillNumber returns a random bill number for a dynamic code.
+func newBillNumber() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return strings.ToUpper(hex.EncodeToString(b))
+}
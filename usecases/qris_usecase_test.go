@@ -0,0 +1,170 @@
+package usecases_test
+
+import (
+	"accounts-service/models"
+	"accounts-service/qris"
+	"accounts-service/repositories"
+	"accounts-service/usecases"
+	"accounts-service/utils"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQRISUsecase(t *testing.T) {
+	ctx := context.Background()
+
+	newUsecases := func(t *testing.T) (usecases.AccountUsecase, usecases.QRISUsecase, *models.Account) {
+		logger := utils.NewLogger("critical")
+		store := repositories.NewMemoryStore()
+		txManager := repositories.NewMemoryTxManager(store, logger)
+		accountRepo := repositories.NewMemoryAccountRepository(store, logger)
+		mutationRepo := repositories.NewMemoryMutationRepository(store, logger)
+
+		accountUsecase := usecases.NewAccountUsecase(txManager, accountRepo, mutationRepo, repositories.NewMemoryOutboxRepository(store, logger), repositories.NewLRUBalanceCache(10, time.Minute, time.Minute), logger)
+		qrisUsecase := usecases.NewQRISUsecase(txManager, accountRepo, mutationRepo, repositories.NewMemoryQRISRepository(store, logger), accountUsecase, usecases.QRISOptions{
+			GlobalID:         "ID.CO.ACCOUNTS.WWW",
+			PANPrefix:        "9360099",
+			MerchantCategory: "5499",
+			MerchantCriteria: "UMI",
+			MerchantCity:     "Jakarta",
+		}, logger)
+
+		account, err := accountUsecase.CreateAccount(ctx, &models.CreateAccountRequest{Name: "Siti Aminah", NIK: "3201014508950001", NoHP: "+6281234567890"})
+		require.NoError(t, err)
+		return accountUsecase, qrisUsecase, account
+	}
+
+	saldo := func(t *testing.T, accountUsecase usecases.AccountUsecase, noRekening string) float64 {
+		response, err := accountUsecase.GetSaldo(ctx, noRekening)
+		require.NoError(t, err)
+		return response.Saldo
+	}
+
+	// withAmount replaces the amount of a dynamic payload, as a tampered or
+	// broken code would have it, with a valid CRC.
+	withAmount := func(payload, from, to string) string {
+		payload = strings.Replace(payload[:len(payload)-4], fmt.Sprintf("54%02d%s", len(from), from), fmt.Sprintf("54%02d%s", len(to), to), 1)
+		return payload + qris.CRC16(payload)
+	}
+
+	t.Run("dynamic code is paid its amount once", func(t *testing.T) {
+		accountUsecase, uc, account := newUsecases(t)
+
+		code, err := uc.Generate(ctx, &models.QRISRequest{NoRekening: account.NoRekening, Nominal: 15000.5, BillNumber: "INV001"})
+		require.NoError(t, err)
+		assert.Equal(t, models.QRISDynamic, code.Type)
+
+		_, err = uc.Pay(ctx, &models.QRISPaymentRequest{Payload: code.Payload, Nominal: 10000})
+		assert.ErrorIs(t, err, models.QRISNominalMismatchErr)
+
+		payment, err := uc.Pay(ctx, &models.QRISPaymentRequest{Payload: code.Payload})
+		require.NoError(t, err)
+		assert.Equal(t, &models.QRISPayment{
+			NoRekening: account.NoRekening,
+			Nominal:    15000.5,
+			Reference:  "QRIS INV001",
+			Saldo:      15000.5,
+		}, payment)
+
+		_, err = uc.Pay(ctx, &models.QRISPaymentRequest{Payload: code.Payload, Nominal: 15000.5})
+		assert.ErrorIs(t, err, models.QRISPaymentExistsErr)
+		assert.Equal(t, 15000.5, saldo(t, accountUsecase, account.NoRekening))
+	})
+
+	t.Run("dynamic codes not generated as sent are not paid", func(t *testing.T) {
+		accountUsecase, uc, account := newUsecases(t)
+
+		code, err := uc.Generate(ctx, &models.QRISRequest{NoRekening: account.NoRekening, Nominal: 500000, BillNumber: "INV001"})
+		require.NoError(t, err)
+
+		_, err = uc.Generate(ctx, &models.QRISRequest{NoRekening: account.NoRekening, Nominal: 100000, BillNumber: "INV001"})
+		assert.ErrorIs(t, err, models.QRISBillNumberExistsErr)
+
+		_, err = uc.Pay(ctx, &models.QRISPaymentRequest{Payload: withAmount(code.Payload, "500000", "5000000")})
+		assert.ErrorIs(t, err, models.QRISNominalMismatchErr, "the amount is not the one generated")
+
+		forged, err := (&qris.Payload{
+			MerchantAccounts: []qris.MerchantAccount{{GlobalID: "ID.CO.ACCOUNTS.WWW", PAN: "9360099" + account.NoRekening, MerchantID: account.NoRekening}},
+			MerchantCategory: "5499",
+			Currency:         qris.CurrencyIDR,
+			CountryCode:      qris.CountryID,
+			MerchantName:     "SITI AMINAH",
+			MerchantCity:     "JAKARTA",
+			Dynamic:          true,
+			Amount:           "750000",
+			BillNumber:       "INV999",
+		}).Encode()
+		require.NoError(t, err)
+		_, err = uc.Pay(ctx, &models.QRISPaymentRequest{Payload: forged})
+		assert.ErrorIs(t, err, models.QRISCodeNotFoundErr)
+
+		assert.Equal(t, float64(0), saldo(t, accountUsecase, account.NoRekening))
+
+		_, err = uc.Pay(ctx, &models.QRISPaymentRequest{Payload: code.Payload})
+		require.NoError(t, err, "the code generated is still paid")
+		assert.Equal(t, float64(500000), saldo(t, accountUsecase, account.NoRekening))
+	})
+
+	t.Run("static code is paid once per reference", func(t *testing.T) {
+		accountUsecase, uc, account := newUsecases(t)
+
+		code, err := uc.Generate(ctx, &models.QRISRequest{NoRekening: account.NoRekening})
+		require.NoError(t, err)
+		assert.Equal(t, models.QRISStatic, code.Type)
+
+		_, err = uc.Pay(ctx, &models.QRISPaymentRequest{Payload: code.Payload, Reference: "TRX1"})
+		assert.ErrorIs(t, err, models.QRISStaticPaymentInvalidErr, "the payer enters the amount")
+		_, err = uc.Pay(ctx, &models.QRISPaymentRequest{Payload: code.Payload, Nominal: 10000})
+		assert.ErrorIs(t, err, models.QRISStaticPaymentInvalidErr, "the reference of the acquirer is required")
+
+		for _, reference := range []string{"TRX1", "TRX2"} {
+			payment, err := uc.Pay(ctx, &models.QRISPaymentRequest{Payload: code.Payload, Nominal: 10000, Reference: reference})
+			require.NoError(t, err)
+			assert.Equal(t, "QRIS "+reference, payment.Reference)
+		}
+		_, err = uc.Pay(ctx, &models.QRISPaymentRequest{Payload: code.Payload, Nominal: 10000, Reference: "TRX1"})
+		assert.ErrorIs(t, err, models.QRISPaymentExistsErr)
+		assert.Equal(t, float64(20000), saldo(t, accountUsecase, account.NoRekening))
+	})
+
+	t.Run("codes of other acquirers are not paid", func(t *testing.T) {
+		_, uc, account := newUsecases(t)
+
+		payload, err := (&qris.Payload{
+			MerchantAccounts: []qris.MerchantAccount{{GlobalID: "ID.CO.OTHERBANK.WWW", PAN: "9360001" + account.NoRekening, MerchantID: account.NoRekening}},
+			MerchantCategory: "5499",
+			Currency:         qris.CurrencyIDR,
+			CountryCode:      qris.CountryID,
+			MerchantName:     "SITI AMINAH",
+			MerchantCity:     "JAKARTA",
+		}).Encode()
+		require.NoError(t, err)
+
+		code, err := uc.Parse(ctx, &models.QRISParseRequest{Payload: payload})
+		require.NoError(t, err)
+		assert.Equal(t, "ID.CO.OTHERBANK.WWW", code.GlobalID)
+		assert.Empty(t, code.NoRekening)
+
+		_, err = uc.Pay(ctx, &models.QRISPaymentRequest{Payload: payload, Nominal: 10000, Reference: "TRX1"})
+		assert.ErrorIs(t, err, models.QRISMerchantUnknownErr)
+	})
+
+	t.Run("amounts that are not valid nominals are not credited", func(t *testing.T) {
+		accountUsecase, uc, account := newUsecases(t)
+
+		code, err := uc.Generate(ctx, &models.QRISRequest{NoRekening: account.NoRekening, Nominal: 500000, BillNumber: "INV001"})
+		require.NoError(t, err)
+
+		for _, amount := range []string{"-500000", "abcdef", "0", "10000000000000"} {
+			_, err := uc.Pay(ctx, &models.QRISPaymentRequest{Payload: withAmount(code.Payload, "500000", amount)})
+			assert.ErrorIs(t, err, models.QRISPayloadInvalidErr, amount)
+		}
+		assert.Equal(t, float64(0), saldo(t, accountUsecase, account.NoRekening))
+	})
+}
@@ -0,0 +1,98 @@
+package usecases
+
+import (
+	"accounts-service/models"
+	"accounts-service/repositories"
+	"accounts-service/utils"
+	"context"
+)
+
+type ReversalUsecase interface {
+	// Reverse posts the opposite of the newest posting of an account with
+	// req.Reference. A posting is reversed once, reversing it again does
+	// nothing. A posting not made yet is marked reversed, so that it is
+	// declined when it arrives late, and ReversalOriginalNotFoundErr is
+	// returned.
+	Reverse(ctx context.Context, req *models.ReversalRequest) error
+}
+
+type reversalUsecase struct {
+	txManager    repositories.TxManager
+	accountRepo  repositories.AccountRepository
+	mutationRepo repositories.MutationRepository
+	outboxRepo   repositories.OutboxRepository
+	balanceCache repositories.BalanceCache
+	logger       utils.Logger
+}
+
+func NewReversalUsecase(txManager repositories.TxManager, accountRepo repositories.AccountRepository, mutationRepo repositories.MutationRepository, outboxRepo repositories.OutboxRepository, balanceCache repositories.BalanceCache, logger utils.Logger) ReversalUsecase {
+	return &reversalUsecase{
+		txManager:    txManager,
+		accountRepo:  accountRepo,
+		mutationRepo: mutationRepo,
+		outboxRepo:   outboxRepo,
+		balanceCache: balanceCache,
+		logger:       logger,
+	}
+}
+
+func (u *reversalUsecase) Reverse(ctx context.Context, req *models.ReversalRequest) error {
+	var (
+		posted  *models.Account
+		missing bool
+	)
+	err := u.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		// Locking the account keeps a reversal sent twice at once from
+		// posting twice
+		account, err := u.accountRepo.GetAccountByNoRekeningForUpdate(ctx, req.NoRekening)
+		if err != nil {
+			u.logger.Error("Error getting account for reversal: %v", err)
+			return err
+		}
+		if account == nil {
+			return models.AccountWithNoRekeningNotFoundErr
+		}
+
+		original, err := u.mutationRepo.GetMutationByReference(ctx, account.ID, req.Reference)
+		if err != nil {
+			return err
+		}
+		if original == nil {
+			// Committed with the lock held, the original checks the marker
+			// under the same lock
+			missing = true
+			return u.mutationRepo.MarkReversed(ctx, account.ID, req.Reference)
+		}
+
+		reference := models.ReversalReferencePrefix + req.Reference
+		reversed, err := u.mutationRepo.GetMutationByReference(ctx, account.ID, reference)
+		if err != nil {
+			return err
+		}
+		if reversed != nil && reversed.ID > original.ID {
+			return nil
+		}
+
+		mutationType := models.MutationTypeCredit
+		if original.Type == models.MutationTypeCredit {
+			if account.Saldo < original.Nominal {
+				return models.AccountinsufficientErr.WithParams(map[string]interface{}{"nominal": original.Nominal})
+			}
+			mutationType = models.MutationTypeDebit
+		}
+
+		_, posted, err = postMutation(ctx, u.accountRepo, u.mutationRepo, u.outboxRepo, u.logger, account, mutationType, original.Nominal, reference)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if missing {
+		return models.ReversalOriginalNotFoundErr
+	}
+
+	if posted != nil {
+		storeBalance(ctx, u.balanceCache, posted)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package usecases
+
+import (
+	"accounts-service/models"
+	"accounts-service/repositories"
+	"accounts-service/utils"
+	"context"
+	"math"
+	"time"
+)
+
+// StatementWriter renders a statement as it is read: the header with the
+// opening balance, every line, then the footer with the closing balance and
+// totals.
+type StatementWriter interface {
+	WriteHeader(statement *models.Statement) error
+	WriteLine(statement *models.Statement, line *models.StatementLine) error
+	WriteFooter(statement *models.Statement) error
+}
+
+type StatementUsecase interface {
+	// PrepareStatement checks the request and computes the opening balance,
+	// so that errors are known before anything is written.
+	PrepareStatement(ctx context.Context, req *models.StatementRequest) (*models.Statement, error)
+	// WriteStatement streams the mutations of the period to w, keeping the
+	// running balance and the totals.
+	WriteStatement(ctx context.Context, statement *models.Statement, w StatementWriter) error
+}
+
+type statementUsecase struct {
+	accountRepo  repositories.AccountRepository
+	mutationRepo repositories.MutationRepository
+	logger       utils.Logger
+}
+
+func NewStatementUsecase(accountRepo repositories.AccountRepository, mutationRepo repositories.MutationRepository, logger utils.Logger) StatementUsecase {
+	return &statementUsecase{
+		accountRepo:  accountRepo,
+		mutationRepo: mutationRepo,
+		logger:       logger,
+	}
+}
+
+func (u *statementUsecase) PrepareStatement(ctx context.Context, req *models.StatementRequest) (*models.Statement, error) {
+	from, err := time.ParseInLocation(models.StatementDateLayout, req.From, models.StatementZone)
+	if err != nil {
+		return nil, models.StatementPeriodInvalidErr.Wrap(err)
+	}
+	to, err := time.ParseInLocation(models.StatementDateLayout, req.To, models.StatementZone)
+	if err != nil {
+		return nil, models.StatementPeriodInvalidErr.Wrap(err)
+	}
+	if to.Before(from) {
+		return nil, models.StatementPeriodInvalidErr
+	}
+
+	account, err := u.accountRepo.GetAccountByNoRekening(ctx, req.NoRekening)
+	if err != nil {
+		u.logger.Error("Error getting account for statement: %v", err)
+		return nil, err
+	}
+	if account == nil {
+		return nil, models.AccountWithNoRekeningNotFoundErr
+	}
+
+	credit, debit, err := u.mutationRepo.SumMutations(ctx, account.ID, from)
+	if err != nil {
+		return nil, err
+	}
+
+	opening := roundCents(credit - debit)
+	return &models.Statement{
+		Account:        account,
+		From:           from,
+		To:             to,
+		OpeningBalance: opening,
+		ClosingBalance: opening,
+		GeneratedAt:    time.Now(),
+	}, nil
+}
+
+func (u *statementUsecase) WriteStatement(ctx context.Context, statement *models.Statement, w StatementWriter) error {
+	if err := w.WriteHeader(statement); err != nil {
+		return err
+	}
+
+	end := statement.To.AddDate(0, 0, 1)
+	err := u.mutationRepo.EachMutation(ctx, statement.Account.ID, statement.From, end, func(mutation *models.Mutation) error {
+		switch mutation.Type {
+		case models.MutationTypeCredit:
+			statement.ClosingBalance = roundCents(statement.ClosingBalance + mutation.Nominal)
+			statement.TotalCredit = roundCents(statement.TotalCredit + mutation.Nominal)
+			statement.CreditCount++
+		case models.MutationTypeDebit:
+			statement.ClosingBalance = roundCents(statement.ClosingBalance - mutation.Nominal)
+			statement.TotalDebit = roundCents(statement.TotalDebit + mutation.Nominal)
+			statement.DebitCount++
+		}
+
+		return w.WriteLine(statement, &models.StatementLine{Mutation: *mutation, Balance: statement.ClosingBalance})
+	})
+	if err != nil {
+		return err
+	}
+
+	return w.WriteFooter(statement)
+}
+
+// roundCents keeps sums of amounts at 2 decimals, like the DECIMAL columns.
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,221 @@
+package usecases
+
+import (
+	"accounts-service/models"
+	"accounts-service/repositories"
+	"accounts-service/utils"
+	"context"
+	"crypto/rand"
+	"math"
+	"time"
+)
+
+// Most characters of the name of a virtual account, and the most random VA
+// numbers tried before giving up.
+const (
+	virtualAccountNameLength     = 64
+	virtualAccountNumberAttempts = 5
+)
+
+type VirtualAccountUsecase interface {
+	// Issue issues a virtual account mapped to an account. The VA number is
+	// the company prefix followed by the customer number of the request, or
+	// by random digits.
+	Issue(ctx context.Context, req *models.VirtualAccountRequest) (*models.VirtualAccount, error)
+	// Inquire returns a virtual account, with the expired status once past
+	// its expiry.
+	Inquire(ctx context.Context, req *models.VirtualAccountInquiryRequest) (*models.VirtualAccountInquiry, error)
+	// Pay credits the account a virtual account is mapped to, once per
+	// payment reference. A single use virtual account is closed by it.
+	Pay(ctx context.Context, req *models.VirtualAccountPaymentRequest) (*models.VirtualAccountPayment, error)
+}
+
+type virtualAccountUsecase struct {
+	txManager          repositories.TxManager
+	accountRepo        repositories.AccountRepository
+	mutationRepo       repositories.MutationRepository
+	virtualAccountRepo repositories.VirtualAccountRepository
+	accountUsecase     AccountUsecase
+	companyPrefix      string
+	logger             utils.Logger
+}
+
+func NewVirtualAccountUsecase(txManager repositories.TxManager, accountRepo repositories.AccountRepository, mutationRepo repositories.MutationRepository, virtualAccountRepo repositories.VirtualAccountRepository, accountUsecase AccountUsecase, companyPrefix string, logger utils.Logger) VirtualAccountUsecase {
+	return &virtualAccountUsecase{
+		txManager:          txManager,
+		accountRepo:        accountRepo,
+		mutationRepo:       mutationRepo,
+		virtualAccountRepo: virtualAccountRepo,
+		accountUsecase:     accountUsecase,
+		companyPrefix:      companyPrefix,
+		logger:             logger,
+	}
+}
+
+func (u *virtualAccountUsecase) Issue(ctx context.Context, req *models.VirtualAccountRequest) (*models.VirtualAccount, error) {
+	length := models.VirtualAccountNumberLength - len(u.companyPrefix)
+	if len(req.CustomerNumber) > length {
+		return nil, models.VirtualAccountCustomerNumberInvalidErr
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, models.VirtualAccountExpiryInvalidErr
+	}
+
+	account, err := u.accountRepo.GetAccountByNoRekening(ctx, req.NoRekening)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return nil, models.AccountWithNoRekeningNotFoundErr
+	}
+
+	virtualAccount := &models.VirtualAccount{
+		AccountID:  account.ID,
+		NoRekening: account.NoRekening,
+		Name:       req.Name,
+		Nominal:    req.Nominal,
+		SingleUse:  req.SingleUse,
+		ExpiresAt:  req.ExpiresAt,
+	}
+	if virtualAccount.Name == "" {
+		virtualAccount.Name = truncate(account.Name, virtualAccountNameLength)
+	}
+
+	if req.CustomerNumber != "" {
+		virtualAccount.Number = u.companyPrefix + req.CustomerNumber
+		if err := u.virtualAccountRepo.CreateVirtualAccount(ctx, virtualAccount); err != nil {
+			return nil, err
+		}
+		if virtualAccount.ID == 0 {
+			return nil, models.VirtualAccountExistsErr
+		}
+		return virtualAccount, nil
+	}
+
+	for attempt := 0; attempt < virtualAccountNumberAttempts; attempt++ {
+		virtualAccount.Number = u.companyPrefix + randomDigits(length)
+		if err := u.virtualAccountRepo.CreateVirtualAccount(ctx, virtualAccount); err != nil {
+			return nil, err
+		}
+		if virtualAccount.ID != 0 {
+			return virtualAccount, nil
+		}
+	}
+
+	u.logger.Error("Error issuing virtual account: no free VA number after %d attempts", virtualAccountNumberAttempts)
+	return nil, models.VirtualAccountExistsErr
+}
+
+func (u *virtualAccountUsecase) Inquire(ctx context.Context, req *models.VirtualAccountInquiryRequest) (*models.VirtualAccountInquiry, error) {
+	virtualAccount, err := u.virtualAccountRepo.GetVirtualAccount(ctx, req.Number)
+	if err != nil {
+		return nil, err
+	}
+	if virtualAccount == nil {
+		return nil, models.VirtualAccountNotFoundErr
+	}
+	if virtualAccount.Expired(time.Now()) {
+		virtualAccount.Status = models.VirtualAccountStatusExpired
+	}
+	return &models.VirtualAccountInquiry{
+		Number:    virtualAccount.Number,
+		Name:      virtualAccount.Name,
+		Nominal:   virtualAccount.Nominal,
+		SingleUse: virtualAccount.SingleUse,
+		Status:    virtualAccount.Status,
+		ExpiresAt: virtualAccount.ExpiresAt,
+		ClosedAt:  virtualAccount.ClosedAt,
+	}, nil
+}
+
+func (u *virtualAccountUsecase) Pay(ctx context.Context, req *models.VirtualAccountPaymentRequest) (*models.VirtualAccountPayment, error) {
+	payment := &models.VirtualAccountPayment{
+		Number:    req.Number,
+		Nominal:   req.Nominal,
+		Reference: models.VirtualAccountReferencePrefix + req.Number + " " + req.Reference,
+	}
+
+	err := u.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		virtualAccount, err := u.virtualAccountRepo.GetVirtualAccount(ctx, req.Number)
+		if err != nil {
+			return err
+		}
+		if virtualAccount == nil {
+			return models.VirtualAccountNotFoundErr
+		}
+
+		// Locking the account keeps a payment notified twice at once from
+		// crediting twice, or a single use virtual account from being paid
+		// twice
+		account, err := u.accountRepo.GetAccountByNoRekeningForUpdate(ctx, virtualAccount.NoRekening)
+		if err != nil {
+			u.logger.Error("Error getting account for virtual account payment: %v", err)
+			return err
+		}
+		if account == nil {
+			return models.AccountWithNoRekeningNotFoundErr
+		}
+
+		paid, err := u.mutationRepo.GetMutationByReference(ctx, account.ID, payment.Reference)
+		if err != nil {
+			return err
+		}
+		if paid != nil {
+			return models.VirtualAccountPaymentExistsErr
+		}
+
+		now := time.Now()
+		if virtualAccount.Status == models.VirtualAccountStatusClosed {
+			return models.VirtualAccountClosedErr
+		}
+		if virtualAccount.Expired(now) {
+			return models.VirtualAccountExpiredErr
+		}
+		if virtualAccount.Nominal != 0 && math.Abs(req.Nominal-virtualAccount.Nominal) >= 0.005 {
+			return models.VirtualAccountNominalMismatchErr
+		}
+
+		err = u.accountUsecase.Credit(ctx, &models.TransactionRequest{
+			NoRekening: account.NoRekening,
+			Nominal:    payment.Nominal,
+			Reference:  payment.Reference,
+		})
+		if err != nil {
+			return err
+		}
+
+		payment.Status = virtualAccount.Status
+		if virtualAccount.SingleUse {
+			closed, err := u.virtualAccountRepo.CloseVirtualAccount(ctx, virtualAccount.ID, now)
+			if err != nil {
+				return err
+			}
+			if !closed {
+				return models.VirtualAccountClosedErr
+			}
+			payment.Status = models.VirtualAccountStatusClosed
+		}
+
+		posted, err := u.accountRepo.GetAccountByNoRekening(ctx, account.NoRekening)
+		if err != nil {
+			return err
+		}
+		payment.NoRekening = posted.NoRekening
+		payment.Saldo = posted.Saldo
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+// randomDigits returns n random decimal digits.
+func randomDigits(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	for i := range b {
+		b[i] = '0' + b[i]%10
+	}
+	return string(b)
+}
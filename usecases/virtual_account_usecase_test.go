@@ -0,0 +1,165 @@
+package usecases_test
+
+import (
+	"accounts-service/models"
+	"accounts-service/repositories"
+	"accounts-service/usecases"
+	"accounts-service/utils"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// racingVirtualAccountRepository loses every close, as when a concurrent
+// payment closed the single use virtual account first.
+type racingVirtualAccountRepository struct {
+	repositories.VirtualAccountRepository
+}
+
+func (racingVirtualAccountRepository) CloseVirtualAccount(ctx context.Context, id uint, closedAt time.Time) (bool, error) {
+	return false, nil
+}
+
+func TestVirtualAccountUsecase(t *testing.T) {
+	ctx := context.Background()
+
+	newUsecases := func(t *testing.T) (usecases.AccountUsecase, usecases.VirtualAccountUsecase, repositories.VirtualAccountRepository, *models.Account) {
+		logger := utils.NewLogger("critical")
+		store := repositories.NewMemoryStore()
+		txManager := repositories.NewMemoryTxManager(store, logger)
+		accountRepo := repositories.NewMemoryAccountRepository(store, logger)
+		mutationRepo := repositories.NewMemoryMutationRepository(store, logger)
+		virtualAccountRepo := repositories.NewMemoryVirtualAccountRepository(store, logger)
+
+		accountUsecase := usecases.NewAccountUsecase(txManager, accountRepo, mutationRepo, repositories.NewMemoryOutboxRepository(store, logger), repositories.NewNoopBalanceCache(), logger)
+		virtualAccountUsecase := usecases.NewVirtualAccountUsecase(txManager, accountRepo, mutationRepo, virtualAccountRepo, accountUsecase, "8808", logger)
+
+		account, err := accountUsecase.CreateAccount(ctx, &models.CreateAccountRequest{Name: "Siti Aminah", NIK: "3201014508950001", NoHP: "+6281234567890"})
+		require.NoError(t, err)
+		return accountUsecase, virtualAccountUsecase, virtualAccountRepo, account
+	}
+
+	t.Run("issue uses the customer number or random digits after the prefix", func(t *testing.T) {
+		_, uc, _, account := newUsecases(t)
+
+		virtualAccount, err := uc.Issue(ctx, &models.VirtualAccountRequest{NoRekening: account.NoRekening, CustomerNumber: "512345678901"})
+		require.NoError(t, err)
+		assert.Equal(t, "8808512345678901", virtualAccount.Number)
+		assert.Equal(t, account.NoRekening, virtualAccount.NoRekening)
+		assert.Equal(t, "Siti Aminah", virtualAccount.Name)
+		assert.Equal(t, models.VirtualAccountStatusOpen, virtualAccount.Status)
+
+		_, err = uc.Issue(ctx, &models.VirtualAccountRequest{NoRekening: account.NoRekening, CustomerNumber: "512345678901"})
+		assert.ErrorIs(t, err, models.VirtualAccountExistsErr)
+
+		_, err = uc.Issue(ctx, &models.VirtualAccountRequest{NoRekening: account.NoRekening, CustomerNumber: "5123456789012"})
+		assert.ErrorIs(t, err, models.VirtualAccountCustomerNumberInvalidErr, "17 digits with the prefix")
+
+		random, err := uc.Issue(ctx, &models.VirtualAccountRequest{NoRekening: account.NoRekening, Name: "Tagihan April"})
+		require.NoError(t, err)
+		assert.Len(t, random.Number, models.VirtualAccountNumberLength)
+		assert.True(t, strings.HasPrefix(random.Number, "8808"))
+		assert.Equal(t, "Tagihan April", random.Name)
+
+		past := time.Now().Add(-time.Minute)
+		_, err = uc.Issue(ctx, &models.VirtualAccountRequest{NoRekening: account.NoRekening, ExpiresAt: &past})
+		assert.ErrorIs(t, err, models.VirtualAccountExpiryInvalidErr)
+
+		_, err = uc.Issue(ctx, &models.VirtualAccountRequest{NoRekening: "1000000000"})
+		assert.ErrorIs(t, err, models.AccountWithNoRekeningNotFoundErr)
+	})
+
+	t.Run("single use virtual account is closed by its payment", func(t *testing.T) {
+		accountUsecase, uc, _, account := newUsecases(t)
+
+		_, err := uc.Issue(ctx, &models.VirtualAccountRequest{NoRekening: account.NoRekening, CustomerNumber: "1", Nominal: 150000, SingleUse: true})
+		require.NoError(t, err)
+
+		_, err = uc.Pay(ctx, &models.VirtualAccountPaymentRequest{Number: "88081", Nominal: 100000, Reference: "BILL-1"})
+		assert.ErrorIs(t, err, models.VirtualAccountNominalMismatchErr)
+
+		payment, err := uc.Pay(ctx, &models.VirtualAccountPaymentRequest{Number: "88081", Nominal: 150000, Reference: "BILL-1"})
+		require.NoError(t, err)
+		assert.Equal(t, &models.VirtualAccountPayment{
+			Number:     "88081",
+			NoRekening: account.NoRekening,
+			Nominal:    150000,
+			Reference:  "VA 88081 BILL-1",
+			Saldo:      150000,
+			Status:     models.VirtualAccountStatusClosed,
+		}, payment)
+
+		_, err = uc.Pay(ctx, &models.VirtualAccountPaymentRequest{Number: "88081", Nominal: 150000, Reference: "BILL-1"})
+		assert.ErrorIs(t, err, models.VirtualAccountPaymentExistsErr, "the same payment notified again")
+		_, err = uc.Pay(ctx, &models.VirtualAccountPaymentRequest{Number: "88081", Nominal: 150000, Reference: "BILL-2"})
+		assert.ErrorIs(t, err, models.VirtualAccountClosedErr)
+
+		inquired, err := uc.Inquire(ctx, &models.VirtualAccountInquiryRequest{Number: "88081"})
+		require.NoError(t, err)
+		assert.Equal(t, models.VirtualAccountStatusClosed, inquired.Status)
+		assert.NotNil(t, inquired.ClosedAt)
+
+		saldo, err := accountUsecase.GetSaldo(ctx, account.NoRekening)
+		require.NoError(t, err)
+		assert.Equal(t, float64(150000), saldo.Saldo)
+	})
+
+	t.Run("open virtual account takes any amount once per reference", func(t *testing.T) {
+		_, uc, _, account := newUsecases(t)
+
+		_, err := uc.Issue(ctx, &models.VirtualAccountRequest{NoRekening: account.NoRekening, CustomerNumber: "2"})
+		require.NoError(t, err)
+
+		for i, nominal := range []float64{10000, 25000.5} {
+			payment, err := uc.Pay(ctx, &models.VirtualAccountPaymentRequest{Number: "88082", Nominal: nominal, Reference: fmt.Sprintf("TRX%d", i)})
+			require.NoError(t, err)
+			assert.Equal(t, models.VirtualAccountStatusOpen, payment.Status)
+		}
+
+		_, err = uc.Pay(ctx, &models.VirtualAccountPaymentRequest{Number: "88083", Nominal: 10000, Reference: "TRX1"})
+		assert.ErrorIs(t, err, models.VirtualAccountNotFoundErr)
+	})
+
+	t.Run("expired virtual account is not paid", func(t *testing.T) {
+		_, uc, virtualAccountRepo, account := newUsecases(t)
+
+		expiresAt := time.Now().Add(-time.Second)
+		require.NoError(t, virtualAccountRepo.CreateVirtualAccount(ctx, &models.VirtualAccount{Number: "88083", AccountID: account.ID, Name: "Siti", ExpiresAt: &expiresAt}))
+
+		inquired, err := uc.Inquire(ctx, &models.VirtualAccountInquiryRequest{Number: "88083"})
+		require.NoError(t, err)
+		assert.Equal(t, models.VirtualAccountStatusExpired, inquired.Status)
+
+		_, err = uc.Pay(ctx, &models.VirtualAccountPaymentRequest{Number: "88083", Nominal: 10000, Reference: "TRX1"})
+		assert.ErrorIs(t, err, models.VirtualAccountExpiredErr)
+	})
+
+	t.Run("a payment losing the close leaves the cached balance alone", func(t *testing.T) {
+		logger := utils.NewLogger("critical")
+		store := repositories.NewMemoryStore()
+		txManager := repositories.NewMemoryTxManager(store, logger)
+		accountRepo := repositories.NewMemoryAccountRepository(store, logger)
+		mutationRepo := repositories.NewMemoryMutationRepository(store, logger)
+		virtualAccountRepo := racingVirtualAccountRepository{repositories.NewMemoryVirtualAccountRepository(store, logger)}
+
+		accountUsecase := usecases.NewAccountUsecase(txManager, accountRepo, mutationRepo, repositories.NewMemoryOutboxRepository(store, logger), repositories.NewLRUBalanceCache(10, time.Minute, time.Minute), logger)
+		uc := usecases.NewVirtualAccountUsecase(txManager, accountRepo, mutationRepo, virtualAccountRepo, accountUsecase, "8808", logger)
+
+		account, err := accountUsecase.CreateAccount(ctx, &models.CreateAccountRequest{Name: "Siti Aminah", NIK: "3201014508950001", NoHP: "+6281234567890"})
+		require.NoError(t, err)
+		_, err = uc.Issue(ctx, &models.VirtualAccountRequest{NoRekening: account.NoRekening, CustomerNumber: "1", Nominal: 150000, SingleUse: true})
+		require.NoError(t, err)
+
+		_, err = uc.Pay(ctx, &models.VirtualAccountPaymentRequest{Number: "88081", Nominal: 150000, Reference: "BILL-2"})
+		assert.ErrorIs(t, err, models.VirtualAccountClosedErr)
+
+		saldo, err := accountUsecase.GetSaldo(ctx, account.NoRekening)
+		require.NoError(t, err)
+		assert.Equal(t, float64(0), saldo.Saldo, "the credit rolled back with the close")
+	})
+}
@@ -0,0 +1,183 @@
+package usecases
+
+import (
+	"accounts-service/models"
+	"accounts-service/repositories"
+	"accounts-service/utils"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"slices"
+	"time"
+)
+
+// defaultDeliveriesLimit is how many deliveries are listed when the request
+// sets no limit.
+const defaultDeliveriesLimit = 50
+
+type WebhookUsecase interface {
+	CreateWebhook(ctx context.Context, req *models.CreateWebhookRequest) (*models.CreateWebhookResponse, error)
+	ListWebhooks(ctx context.Context) ([]models.Webhook, error)
+	GetWebhook(ctx context.Context, id uint) (*models.Webhook, error)
+	UpdateWebhook(ctx context.Context, req *models.UpdateWebhookRequest) (*models.Webhook, error)
+	DeleteWebhook(ctx context.Context, id uint) error
+	ListDeliveries(ctx context.Context, req *models.WebhookDeliveriesRequest) ([]models.WebhookDelivery, error)
+	GetDelivery(ctx context.Context, webhookID, deliveryID uint) (*models.WebhookDelivery, error)
+	// Redeliver queues a delivery again, whatever its status.
+	Redeliver(ctx context.Context, webhookID, deliveryID uint) (*models.WebhookDelivery, error)
+}
+
+type webhookUsecase struct {
+	webhookRepo repositories.WebhookRepository
+	logger      utils.Logger
+}
+
+func NewWebhookUsecase(webhookRepo repositories.WebhookRepository, logger utils.Logger) WebhookUsecase {
+	return &webhookUsecase{
+		webhookRepo: webhookRepo,
+		logger:      logger,
+	}
+}
+
+func (u *webhookUsecase) CreateWebhook(ctx context.Context, req *models.CreateWebhookRequest) (*models.CreateWebhookResponse, error) {
+	eventTypes, err := validEventTypes(req.EventTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		secret, err = generateWebhookSecret()
+		if err != nil {
+			u.logger.Error("Error generating webhook secret: %v", err)
+			return nil, models.InternalServerErr.Wrap(err)
+		}
+	}
+
+	webhook := &models.Webhook{
+		URL:        req.URL,
+		EventTypes: eventTypes,
+		NoRekening: req.NoRekening,
+		Secret:     secret,
+		Active:     true,
+	}
+
+	if err := u.webhookRepo.CreateWebhook(ctx, webhook); err != nil {
+		return nil, err
+	}
+
+	return &models.CreateWebhookResponse{Webhook: *webhook, Secret: secret}, nil
+}
+
+func (u *webhookUsecase) ListWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	return u.webhookRepo.ListWebhooks(ctx)
+}
+
+func (u *webhookUsecase) GetWebhook(ctx context.Context, id uint) (*models.Webhook, error) {
+	webhook, err := u.webhookRepo.GetWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if webhook == nil {
+		return nil, models.WebhookNotFoundErr
+	}
+
+	return webhook, nil
+}
+
+func (u *webhookUsecase) UpdateWebhook(ctx context.Context, req *models.UpdateWebhookRequest) (*models.Webhook, error) {
+	eventTypes, err := validEventTypes(req.EventTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook, err := u.GetWebhook(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook.URL = req.URL
+	webhook.EventTypes = eventTypes
+	webhook.NoRekening = req.NoRekening
+	webhook.Active = req.Active
+
+	if err := u.webhookRepo.UpdateWebhook(ctx, webhook); err != nil {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+func (u *webhookUsecase) DeleteWebhook(ctx context.Context, id uint) error {
+	if _, err := u.GetWebhook(ctx, id); err != nil {
+		return err
+	}
+
+	return u.webhookRepo.DeleteWebhook(ctx, id)
+}
+
+func (u *webhookUsecase) ListDeliveries(ctx context.Context, req *models.WebhookDeliveriesRequest) ([]models.WebhookDelivery, error) {
+	if _, err := u.GetWebhook(ctx, req.ID); err != nil {
+		return nil, err
+	}
+
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultDeliveriesLimit
+	}
+
+	return u.webhookRepo.ListDeliveries(ctx, req.ID, req.Status, limit)
+}
+
+func (u *webhookUsecase) GetDelivery(ctx context.Context, webhookID, deliveryID uint) (*models.WebhookDelivery, error) {
+	delivery, err := u.webhookRepo.GetDelivery(ctx, webhookID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	if delivery == nil {
+		return nil, models.WebhookDeliveryNotFoundErr
+	}
+
+	return delivery, nil
+}
+
+func (u *webhookUsecase) Redeliver(ctx context.Context, webhookID, deliveryID uint) (*models.WebhookDelivery, error) {
+	if _, err := u.GetDelivery(ctx, webhookID, deliveryID); err != nil {
+		return nil, err
+	}
+
+	if err := u.webhookRepo.Redeliver(ctx, deliveryID, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return u.GetDelivery(ctx, webhookID, deliveryID)
+}
+
+// validEventTypes checks the requested event types and removes duplicates.
+func validEventTypes(eventTypes []string) ([]string, error) {
+	var valid []string
+	for _, eventType := range eventTypes {
+		if !slices.Contains(models.WebhookEventTypes, eventType) {
+			return nil, models.WebhookEventTypesInvalidErr
+		}
+		if !slices.Contains(valid, eventType) {
+			valid = append(valid, eventType)
+		}
+	}
+
+	if len(valid) == 0 {
+		return nil, models.WebhookEventTypesInvalidErr
+	}
+
+	return valid, nil
+}
+
+func generateWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
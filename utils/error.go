@@ -1,7 +1,23 @@
 package utils
 
+import (
+	"errors"
+	"net/http"
+)
+
 type Remark struct {
 	Remark ErrorDetails
+
+	// Status is the HTTP status code the error is answered with. It is not
+	// part of the response body.
+	Status int `json:"-"`
+
+	// cause is the internal error behind the remark. It is only written to
+	// logs and never serialized to clients.
+	cause error
+
+	// params holds the values substituted into localized message placeholders.
+	params map[string]interface{}
 }
 
 type ErrorDetails struct {
@@ -21,9 +37,10 @@ type ErrorDetails struct {
 	Object interface{}
 }
 
-func NewRemark(message, code, field string, object interface{}) *Remark {
+func NewRemark(status int, message, code, field string, object interface{}) *Remark {
 	return &Remark{
 		Remark: *NewErrorDetailsWithObject(message, code, field, object),
+		Status: status,
 	}
 }
 
@@ -37,6 +54,64 @@ func NewErrorDetailsWithObject(message, code, field string, object interface{})
 	}
 }
 
+// Wrap returns a copy of the remark carrying err as its internal cause.
+func (e *Remark) Wrap(err error) *Remark {
+	r := *e
+	r.cause = err
+	return &r
+}
+
+// WithObject returns a copy of the remark with the given client-safe object.
+func (e *Remark) WithObject(object interface{}) *Remark {
+	r := *e
+	r.Remark.Object = object
+	return &r
+}
+
+// WithParams returns a copy of the remark with the given message placeholder
+// values, e.g. {"nominal": 10000.0} for "{nominal}".
+func (e *Remark) WithParams(params map[string]interface{}) *Remark {
+	r := *e
+	r.params = params
+	return &r
+}
+
+// Params returns the message placeholder values of the remark.
+func (e *Remark) Params() map[string]interface{} {
+	return e.params
+}
+
 func (e *Remark) Error() string {
+	if e.cause != nil {
+		return e.Remark.Message + ": " + e.cause.Error()
+	}
 	return e.Remark.Message
 }
+
+func (e *Remark) Unwrap() error {
+	return e.cause
+}
+
+// Is reports whether target is a remark with the same code, so that wrapped
+// copies still match the sentinel remarks declared in models.
+func (e *Remark) Is(target error) bool {
+	t, ok := target.(*Remark)
+	return ok && t.Remark.Code == e.Remark.Code
+}
+
+// HTTPStatus returns the HTTP status of the remark, defaulting to 500.
+func (e *Remark) HTTPStatus() int {
+	if e.Status == 0 {
+		return http.StatusInternalServerError
+	}
+	return e.Status
+}
+
+// AsRemark extracts the first remark in the error chain, if any.
+func AsRemark(err error) (*Remark, bool) {
+	var remark *Remark
+	if errors.As(err, &remark) {
+		return remark, true
+	}
+	return nil, false
+}
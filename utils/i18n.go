@@ -0,0 +1,149 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	LangID = "id"
+	LangEN = "en"
+
+	DefaultLanguage = LangID
+)
+
+// SupportedLanguages lists the languages every catalog entry must provide.
+var SupportedLanguages = []string{LangID, LangEN}
+
+var placeholderPattern = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+// Catalog maps a Remark code to its message template per language.
+// Templates may contain placeholders such as "{nominal}" which are filled
+// from the remark params.
+type Catalog map[string]map[string]string
+
+// Localize returns a copy of the remark with its message translated to lang.
+// Field remarks nested in the Object are translated as well. The message is
+// kept unchanged when the catalog has no entry for the code.
+func (c Catalog) Localize(remark *Remark, lang string) *Remark {
+	if details, ok := remark.Remark.Object.([]*Remark); ok {
+		localized := make([]*Remark, len(details))
+		for i, detail := range details {
+			localized[i] = c.Localize(detail, lang)
+		}
+		remark = remark.WithObject(localized)
+	}
+
+	translations, ok := c[remark.Remark.Code]
+	if !ok {
+		return remark
+	}
+
+	template, ok := translations[lang]
+	if !ok {
+		template, ok = translations[DefaultLanguage]
+		if !ok {
+			return remark
+		}
+		lang = DefaultLanguage
+	}
+
+	r := *remark
+	r.Remark.Message = FormatMessage(template, lang, remark.Params())
+	return &r
+}
+
+// FormatMessage fills the placeholders of template with params. Float values
+// are written as currency amounts in the conventions of lang.
+func FormatMessage(template, lang string, params map[string]interface{}) string {
+	return placeholderPattern.ReplaceAllStringFunc(template, func(match string) string {
+		value, ok := params[match[1:len(match)-1]]
+		if !ok {
+			return match
+		}
+
+		switch v := value.(type) {
+		case float64:
+			return FormatAmount(v, lang)
+		case float32:
+			return FormatAmount(float64(v), lang)
+		default:
+			return fmt.Sprint(v)
+		}
+	})
+}
+
+// Placeholders returns the sorted placeholder names used in template.
+func Placeholders(template string) []string {
+	var names []string
+	for _, m := range placeholderPattern.FindAllStringSubmatch(template, -1) {
+		names = append(names, m[1])
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FormatAmount formats a rupiah amount, e.g. "Rp10.000,50" for id and
+// "IDR 10,000.50" for en.
+func FormatAmount(amount float64, lang string) string {
+	thousands, decimal, prefix := ".", ",", "Rp"
+	if lang == LangEN {
+		thousands, decimal, prefix = ",", ".", "IDR "
+	}
+
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	cents := int64(math.Round(amount * 100))
+	whole := strconv.FormatInt(cents/100, 10)
+
+	var grouped strings.Builder
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			grouped.WriteString(thousands)
+		}
+		grouped.WriteRune(digit)
+	}
+
+	return fmt.Sprintf("%s%s%s%s%02d", sign, prefix, grouped.String(), decimal, cents%100)
+}
+
+// ParseAcceptLanguage picks the supported language with the highest quality
+// from an Accept-Language header, falling back to DefaultLanguage.
+func ParseAcceptLanguage(header string) string {
+	best, bestQ := DefaultLanguage, 0.0
+
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if tag == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if parsed, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+
+		base, _, _ := strings.Cut(tag, "-")
+		for _, lang := range SupportedLanguages {
+			if base == lang && q > bestQ {
+				best, bestQ = lang, q
+			}
+		}
+	}
+
+	return best
+}
@@ -4,13 +4,24 @@ import "flag"
 
 type Arguments struct {
 	ConfigPath string
+
+	// Command is the subcommand to run, e.g. "migrate". Empty means serve.
+	Command string
+
+	// CommandArgs are the arguments following the subcommand.
+	CommandArgs []string
 }
 
 func ParseArguments() *Arguments {
 	args := &Arguments{}
 
-	flag.StringVar(&args.ConfigPath, "config", ".env", "Path to config file")
+	flag.StringVar(&args.ConfigPath, "config", "", "Path to config file (.yaml, .yml, .toml or .env), defaults to .env when present")
 	flag.Parse()
 
+	if flag.NArg() > 0 {
+		args.Command = flag.Arg(0)
+		args.CommandArgs = flag.Args()[1:]
+	}
+
 	return args
 }
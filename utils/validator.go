@@ -0,0 +1,209 @@
+package utils
+
+import (
+	"math"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// MaxAmount is the largest nominal that fits the DECIMAL(15, 2) columns.
+const MaxAmount = 1e13
+
+var (
+	noRekeningPattern = regexp.MustCompile(`^[0-9]{10,12}$`)
+	phonePattern      = regexp.MustCompile(`^\+628[0-9]{7,11}$`)
+	phoneSeparators   = strings.NewReplacer(" ", "", "-", "", ".", "", "(", "", ")", "")
+
+	// nikProvinceCodes are the Dukcapil province codes a NIK may start with.
+	nikProvinceCodes = map[string]bool{
+		"11": true, "12": true, "13": true, "14": true, "15": true, "16": true, "17": true, "18": true, "19": true,
+		"21": true,
+		"31": true, "32": true, "33": true, "34": true, "35": true, "36": true,
+		"51": true, "52": true, "53": true,
+		"61": true, "62": true, "63": true, "64": true, "65": true,
+		"71": true, "72": true, "73": true, "74": true, "75": true, "76": true,
+		"81": true, "82": true,
+		"91": true, "92": true, "93": true, "94": true, "95": true, "96": true,
+	}
+)
+
+// Normalizer is implemented by requests that clean up their fields (trim
+// spaces, canonicalize phone numbers) before they are validated.
+type Normalizer interface {
+	Normalize()
+}
+
+// ValidationRemarks describes how validation failures are reported.
+type ValidationRemarks struct {
+	// Aggregate is the remark returned to the client, holding every field
+	// remark in its Object.
+	Aggregate *Remark
+
+	// Fields maps "<json field>.<rule>" to the remark for that failure,
+	// e.g. "nik.required".
+	Fields map[string]*Remark
+
+	// Fallback is used for failures without an entry in Fields. It receives
+	// the "field" and "rule" params.
+	Fallback *Remark
+}
+
+// RequestValidator implements echo.Validator using the validate struct tags.
+type RequestValidator struct {
+	validate *validator.Validate
+	remarks  ValidationRemarks
+}
+
+func NewRequestValidator(remarks ValidationRemarks) *RequestValidator {
+	v := validator.New(validator.WithRequiredStructEnabled())
+
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"json", "param", "query", "form"} {
+			name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+			if name != "" && name != "-" {
+				return name
+			}
+		}
+		return field.Name
+	})
+
+	v.RegisterValidation("nik", func(fl validator.FieldLevel) bool {
+		return IsValidNIK(fl.Field().String())
+	})
+	v.RegisterValidation("phone", func(fl validator.FieldLevel) bool {
+		return phonePattern.MatchString(fl.Field().String())
+	})
+	v.RegisterValidation("norek", func(fl validator.FieldLevel) bool {
+		return IsValidNoRekening(fl.Field().String())
+	})
+	v.RegisterValidation("amount", func(fl validator.FieldLevel) bool {
+		return IsValidAmount(fl.Field().Float())
+	})
+
+	return &RequestValidator{
+		validate: v,
+		remarks:  remarks,
+	}
+}
+
+// Validate normalizes i when it implements Normalizer and validates it. All
+// field failures are returned together in a single aggregate remark.
+func (v *RequestValidator) Validate(i interface{}) error {
+	if n, ok := i.(Normalizer); ok {
+		n.Normalize()
+	}
+
+	err := v.validate.Struct(i)
+	if err == nil {
+		return nil
+	}
+
+	fieldErrors, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return v.remarks.Aggregate.Wrap(err)
+	}
+
+	details := make([]*Remark, 0, len(fieldErrors))
+	fields := make([]string, 0, len(fieldErrors))
+	for _, fe := range fieldErrors {
+		details = append(details, v.fieldRemark(fe))
+		fields = append(fields, fe.Field())
+	}
+
+	aggregate := *v.remarks.Aggregate
+	aggregate.Remark.Field = strings.Join(fields, ", ")
+	aggregate.Remark.Object = details
+	if aggregate.Status == 0 {
+		aggregate.Status = http.StatusBadRequest
+	}
+	return &aggregate
+}
+
+func (v *RequestValidator) fieldRemark(fe validator.FieldError) *Remark {
+	if remark, ok := v.remarks.Fields[fe.Field()+"."+fe.Tag()]; ok {
+		return remark
+	}
+
+	remark := *v.remarks.Fallback
+	remark.Remark.Field = fe.Field()
+	return remark.WithParams(map[string]interface{}{
+		"field": fe.Field(),
+		"rule":  fe.Tag(),
+	})
+}
+
+// NormalizePhone converts an Indonesian mobile number to the +62 format, e.g.
+// "0812-3456-7890" to "+6281234567890". Unrecognized input is returned with
+// only its separators removed.
+func NormalizePhone(phone string) string {
+	phone = phoneSeparators.Replace(strings.TrimSpace(phone))
+
+	switch {
+	case strings.HasPrefix(phone, "+62"):
+		return phone
+	case strings.HasPrefix(phone, "62"):
+		return "+" + phone
+	case strings.HasPrefix(phone, "0"):
+		return "+62" + phone[1:]
+	default:
+		return phone
+	}
+}
+
+// IsValidNIK checks the structure of a 16 digit NIK: a known province code,
+// non-zero regency and district codes, a real birth date (day + 40 for
+// women) and a non-zero serial number.
+func IsValidNIK(nik string) bool {
+	if len(nik) != 16 {
+		return false
+	}
+	for _, c := range nik {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	if !nikProvinceCodes[nik[0:2]] || nik[2:4] == "00" || nik[4:6] == "00" || nik[12:16] == "0000" {
+		return false
+	}
+
+	day, _ := strconv.Atoi(nik[6:8])
+	month, _ := strconv.Atoi(nik[8:10])
+	yy, _ := strconv.Atoi(nik[10:12])
+
+	if day > 40 {
+		day -= 40
+	}
+	if day < 1 || day > 31 || month < 1 || month > 12 {
+		return false
+	}
+
+	year := 2000 + yy
+	if year > time.Now().Year() {
+		year -= 100
+	}
+
+	birthDate := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	return birthDate.Day() == day && int(birthDate.Month()) == month
+}
+
+// IsValidNoRekening checks that an account number is 10 to 12 digits.
+func IsValidNoRekening(noRekening string) bool {
+	return noRekeningPattern.MatchString(noRekening)
+}
+
+// IsValidAmount checks that a nominal is positive, has at most two decimals
+// and fits the DECIMAL(15, 2) columns.
+func IsValidAmount(amount float64) bool {
+	if amount <= 0 || amount >= MaxAmount || math.IsNaN(amount) {
+		return false
+	}
+	cents := amount * 100
+	return math.Abs(cents-math.Round(cents)) < 1e-6
+}
@@ -0,0 +1,84 @@
+package utils_test
+
+import (
+	"accounts-service/models"
+	"accounts-service/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsValidNIK(t *testing.T) {
+	cases := map[string]bool{
+		"3201014508950001": true,  // female, born 5 Aug 1995
+		"3201010508950001": true,  // male, born 5 Aug 1995
+		"3201013102000001": false, // 31 February
+		"9901010508950001": false, // unknown province
+		"3200010508950001": false, // zero regency
+		"3201010508950000": false, // zero serial
+		"320101050895000":  false, // 15 digits
+		"32010105089500A1": false,
+	}
+
+	for nik, want := range cases {
+		assert.Equal(t, want, utils.IsValidNIK(nik), nik)
+	}
+}
+
+func TestNormalizePhone(t *testing.T) {
+	assert.Equal(t, "+6281234567890", utils.NormalizePhone("0812-3456-7890"))
+	assert.Equal(t, "+6281234567890", utils.NormalizePhone("6281234567890"))
+	assert.Equal(t, "+6281234567890", utils.NormalizePhone(" +62 812 3456 7890 "))
+}
+
+func TestIsValidAmount(t *testing.T) {
+	assert.True(t, utils.IsValidAmount(10000))
+	assert.True(t, utils.IsValidAmount(0.1+0.2))
+	assert.True(t, utils.IsValidAmount(1250.75))
+	assert.False(t, utils.IsValidAmount(1250.755))
+	assert.False(t, utils.IsValidAmount(0))
+	assert.False(t, utils.IsValidAmount(utils.MaxAmount))
+}
+
+func TestRequestValidator(t *testing.T) {
+	v := utils.NewRequestValidator(models.RequestValidationRemarks)
+
+	t.Run("valid request is normalized", func(t *testing.T) {
+		req := &models.CreateAccountRequest{Name: " Siti ", NIK: "3201014508950001", NoHP: "0812 3456 7890"}
+		assert.NoError(t, v.Validate(req))
+		assert.Equal(t, "Siti", req.Name)
+		assert.Equal(t, "+6281234567890", req.NoHP)
+	})
+
+	t.Run("all field errors are returned together", func(t *testing.T) {
+		err := v.Validate(&models.CreateAccountRequest{NIK: "1234", NoHP: "12345"})
+
+		remark, ok := utils.AsRemark(err)
+		require.True(t, ok)
+		assert.Equal(t, models.RequestValidationError, remark.Remark.Code)
+
+		details, ok := remark.Remark.Object.([]*utils.Remark)
+		require.True(t, ok)
+
+		var codes []string
+		for _, detail := range details {
+			codes = append(codes, detail.Remark.Code)
+		}
+		assert.ElementsMatch(t, []string{
+			models.AccountNameEmpty,
+			models.AccountNikInvalid,
+			models.AccountNoHpInvalid,
+		}, codes)
+	})
+
+	t.Run("amount precision", func(t *testing.T) {
+		err := v.Validate(&models.TransactionRequest{NoRekening: "1744847261", Nominal: 10.001})
+
+		remark, ok := utils.AsRemark(err)
+		require.True(t, ok)
+		details := remark.Remark.Object.([]*utils.Remark)
+		require.Len(t, details, 1)
+		assert.Equal(t, models.AccountNominalInvalid, details[0].Remark.Code)
+	})
+}
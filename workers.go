@@ -0,0 +1,303 @@
+package main
+
+import (
+	"accounts-service/clearing"
+	"accounts-service/config"
+	"accounts-service/events"
+	"accounts-service/models"
+	"accounts-service/notifications"
+	"accounts-service/statements"
+	"accounts-service/usecases"
+	"accounts-service/utils"
+	"context"
+	"os"
+	"sync"
+	"time"
+)
+
+// startWorkers runs the outbox relay and, when enabled, the webhook
+// dispatcher, the monthly statement archiver and the interbank clearer until
+// ctx is done. It also resumes the bulk credit batches left processing, at
+// start and every BULK_CREDIT_RESUME_INTERVAL. All
+// of them are built before any is started, so an error leaves nothing
+// running. The returned channel is closed once all of them have stopped.
+func startWorkers(ctx context.Context, cfg *config.Config, store *storage, logger utils.Logger) (<-chan struct{}, error) {
+	var publishers events.MultiPublisher
+	switch cfg.OutboxPublisher {
+	case config.OutboxPublisherStdout:
+		publishers = append(publishers, events.NewWriterPublisher(os.Stdout))
+	case config.OutboxPublisherFile:
+		filePublisher, err := events.NewFilePublisher(cfg.OutboxFilePath)
+		if err != nil {
+			return nil, err
+		}
+		publishers = append(publishers, filePublisher)
+	case config.OutboxPublisherHTTP:
+		publishers = append(publishers, events.NewHTTPPublisher(cfg.OutboxHTTPURL, cfg.OutboxHTTPTimeout))
+	}
+	if cfg.WebhooksEnabled {
+		publishers = append(publishers, events.NewWebhookFanout(store.webhookRepo, logger))
+	}
+	if cfg.NotificationsEnabled {
+		// Last, so an event failing on another publisher is retried before
+		// any customer is notified
+		notifier, err := newNotifier(cfg)
+		if err != nil {
+			publishers.Close()
+			return nil, err
+		}
+		publishers = append(publishers, notifications.NewMutationNotifier(store.accountRepo, store.preferenceRepo, notifier, notifications.MutationNotifierOptions{
+			LargeCredit: cfg.NotificationLargeCredit,
+		}, logger))
+	}
+
+	var (
+		archiver      *statements.Archiver
+		closeArchiver = func() {}
+		clearer       *clearing.Clearer
+	)
+	if cfg.MonthlyStatementsEnabled {
+		var err error
+		archiver, closeArchiver, err = newArchiver(cfg, store, logger)
+		if err != nil {
+			publishers.Close()
+			return nil, err
+		}
+	}
+	if cfg.InterbankEnabled {
+		var err error
+		clearer, err = newClearer(cfg, store, logger)
+		if err != nil {
+			closeArchiver()
+			publishers.Close()
+			return nil, err
+		}
+	}
+
+	var wg sync.WaitGroup
+	if len(publishers) == 0 {
+		logger.Warning("OUTBOX_PUBLISHER is none and webhooks and notifications are disabled, mutation events stay pending in the outbox")
+	} else {
+		relay := events.NewRelay(store.outboxRepo, publishers, events.RelayOptions{
+			PollInterval: cfg.OutboxPollInterval,
+			BatchSize:    cfg.OutboxBatchSize,
+			MaxAttempts:  cfg.OutboxMaxAttempts,
+			Backoff:      cfg.OutboxRetryBackoff,
+			MaxBackoff:   cfg.OutboxRetryMaxBackoff,
+		}, logger)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			relay.Run(ctx)
+			publishers.Close()
+		}()
+	}
+
+	if cfg.WebhooksEnabled {
+		dispatcher := events.NewWebhookDispatcher(store.txManager, store.webhookRepo, events.DispatcherOptions{
+			PollInterval: cfg.WebhookPollInterval,
+			BatchSize:    cfg.WebhookBatchSize,
+			Timeout:      cfg.WebhookTimeout,
+			MaxAttempts:  cfg.WebhookMaxAttempts,
+			Backoff:      cfg.WebhookRetryBackoff,
+			MaxBackoff:   cfg.WebhookRetryMaxBackoff,
+		}, logger)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			dispatcher.Run(ctx)
+		}()
+	}
+
+	if archiver != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			archiver.Run(ctx)
+			closeArchiver()
+		}()
+	}
+
+	if clearer != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			clearer.Run(ctx)
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		resumeBulkCredits(ctx, newBulkCreditUsecase(cfg, store, logger), cfg.BulkCreditResumeInterval, logger)
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	return done, nil
+}
+
+// resumeBulkCredits resumes the bulk credit batches left processing now and
+// every interval until ctx is done. Batches whose processing stopped, e.g.
+// with an instance, are finished by the others.
+func resumeBulkCredits(ctx context.Context, bulkCreditUsecase usecases.BulkCreditUsecase, interval time.Duration, logger utils.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := bulkCreditUsecase.ResumeBatches(ctx); err != nil && ctx.Err() == nil {
+			logger.Error("Error resuming bulk credit batches: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// newNotifier routes SMS and WhatsApp to the development sink, and email to
+// the SMTP server when one is configured.
+func newNotifier(cfg *config.Config) (notifications.Router, error) {
+	var sink *notifications.WriterNotifier
+	if cfg.NotificationSink == config.NotificationSinkFile {
+		fileNotifier, err := notifications.NewFileNotifier(cfg.NotificationFilePath)
+		if err != nil {
+			return nil, err
+		}
+		sink = fileNotifier
+	} else {
+		sink = notifications.NewWriterNotifier(os.Stdout)
+	}
+
+	router := notifications.Router{
+		models.NotificationChannelSMS:      sink,
+		models.NotificationChannelWhatsApp: sink,
+		models.NotificationChannelEmail:    sink,
+	}
+	if cfg.SMTPHost != "" {
+		router[models.NotificationChannelEmail] = notifications.NewSMTPNotifier(notifications.SMTPOptions{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.SMTPFrom,
+			Timeout:  cfg.SMTPTimeout,
+		})
+	}
+
+	return router, nil
+}
+
+// newArchiver builds the monthly statement archiver, notifying customers when
+// MONTHLY_STATEMENTS_NOTIFY is set. The returned func releases the notifier.
+func newArchiver(cfg *config.Config, store *storage, logger utils.Logger) (*statements.Archiver, func(), error) {
+	var (
+		notifier statements.Notifier
+		release  = func() {}
+	)
+	if cfg.MonthlyStatementsNotify {
+		router, err := newNotifier(cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		notifier = notifications.NewStatementNotifier(store.preferenceRepo, router, logger)
+		release = func() { router.Close() }
+	}
+
+	archiver := statements.NewArchiver(
+		usecases.NewStatementUsecase(store.accountRepo, store.mutationRepo, logger),
+		store.statementRepo,
+		store.blobStore,
+		statements.Header{BankName: cfg.StatementBankName, Address: cfg.StatementBankAddress},
+		notifier,
+		statements.ArchiverOptions{
+			Interval:  cfg.MonthlyStatementsInterval,
+			BatchSize: cfg.MonthlyStatementsBatchSize,
+		},
+		logger,
+	)
+	return archiver, release, nil
+}
+
+// newBulkCreditUsecase builds the bulk credit usecase with the BULK_CREDIT_*
+// settings.
+func newBulkCreditUsecase(cfg *config.Config, store *storage, logger utils.Logger) usecases.BulkCreditUsecase {
+	return usecases.NewBulkCreditUsecase(
+		store.txManager,
+		store.accountRepo,
+		store.mutationRepo,
+		store.outboxRepo,
+		store.bulkCreditRepo,
+		store.balanceCache,
+		usecases.BulkCreditOptions{
+			DefaultMode: cfg.BulkCreditMode,
+			MaxRows:     cfg.BulkCreditMaxRows,
+		},
+		logger,
+	)
+}
+
+// newQRISUsecase builds the QRIS usecase with the QRIS_* settings.
+func newQRISUsecase(cfg *config.Config, store *storage, accountUsecase usecases.AccountUsecase, logger utils.Logger) usecases.QRISUsecase {
+	return usecases.NewQRISUsecase(
+		store.txManager,
+		store.accountRepo,
+		store.mutationRepo,
+		store.qrisRepo,
+		accountUsecase,
+		usecases.QRISOptions{
+			GlobalID:         cfg.QRISGlobalID,
+			PANPrefix:        cfg.QRISPANPrefix,
+			MerchantCategory: cfg.QRISMerchantCategory,
+			MerchantCriteria: cfg.QRISMerchantCriteria,
+			MerchantCity:     cfg.QRISMerchantCity,
+			PostalCode:       cfg.QRISPostalCode,
+		},
+		logger,
+	)
+}
+
+// newInterbankUsecase builds the interbank usecase with the INTERBANK_*
+// settings.
+func newInterbankUsecase(cfg *config.Config, store *storage, accountUsecase usecases.AccountUsecase, logger utils.Logger) usecases.InterbankUsecase {
+	return usecases.NewInterbankUsecase(
+		store.txManager,
+		store.accountRepo,
+		store.interbankRepo,
+		accountUsecase,
+		usecases.InterbankOptions{
+			BankCode:           cfg.InterbankBankCode,
+			SuspenseNoRekening: cfg.InterbankSuspenseNoRekening,
+		},
+		logger,
+	)
+}
+
+// newClearer builds the interbank clearer writing batch files to the blob
+// store at the INTERBANK_CUTOFFS.
+func newClearer(cfg *config.Config, store *storage, logger utils.Logger) (*clearing.Clearer, error) {
+	cutoffs, err := cfg.InterbankCutoffTimes()
+	if err != nil {
+		return nil, err
+	}
+
+	accountUsecase := usecases.NewAccountUsecase(store.txManager, store.accountRepo, store.mutationRepo, store.outboxRepo, store.balanceCache, logger)
+	return clearing.NewClearer(
+		newInterbankUsecase(cfg, store, accountUsecase, logger),
+		store.blobStore,
+		clearing.ClearerOptions{
+			BankCode: cfg.InterbankBankCode,
+			Cutoffs:  cutoffs,
+			Interval: cfg.InterbankClearingInterval,
+		},
+		logger,
+	), nil
+}